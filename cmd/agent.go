@@ -2,18 +2,29 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
-	"os/exec"
+	"os/signal"
+	"slices"
+	"sort"
 	"strings"
+	"syscall"
 
-	"github.com/sandwichlabs/mcp-task-bridge/internal/inspector"
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
 	"github.com/spf13/cobra"
 	"github.com/tmc/langchaingo/agents"
+	"github.com/tmc/langchaingo/callbacks"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/anthropic"
 	"github.com/tmc/langchaingo/llms/openai"
+	"github.com/tmc/langchaingo/schema"
 	"github.com/tmc/langchaingo/tools"
 	// react "github.com/tmc/langchaingo/agents/react" // Example if react agent was to be used
 )
@@ -24,96 +35,134 @@ var (
 	newAnthropicFn = anthropic.New
 )
 
-// taskExecutorTool implements the tools.Tool interface
-type taskExecutorTool struct {
-	taskName        string
-	taskDescription string
-	taskUsage       string
-	taskfilePath    string
+// mcpTool is a tool of the bridge, called through an MCP client so the
+// agent goes through the same validation, limits and result handling as any
+// other client.
+type mcpTool struct {
+	client *mcpclient.Client
+	tool   mcp.Tool
 }
 
-func (t *taskExecutorTool) Name() string {
-	return t.taskName
+func (t *mcpTool) Name() string {
+	return t.tool.Name
 }
 
-func (t *taskExecutorTool) Description() string {
-	// It's often helpful for the LLM to know how to use the tool, including parameters.
-	// Combining description and usage.
-	return fmt.Sprintf("%s Usage: %s", t.taskDescription, t.taskUsage)
+func (t *mcpTool) Description() string {
+	params := make([]string, 0, len(t.tool.InputSchema.Properties))
+	for name := range t.tool.InputSchema.Properties {
+		if slices.Contains(t.tool.InputSchema.Required, name) {
+			name += " (required)"
+		}
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	if len(params) == 0 {
+		return t.tool.Description + " Input: none."
+	}
+	return fmt.Sprintf("%s Input: KEY=value pairs or a JSON object with %s.", t.tool.Description, strings.Join(params, ", "))
 }
 
-func (t *taskExecutorTool) Call(ctx context.Context, input string) (string, error) {
-	slog.Info("Executing tool (task)", "name", t.taskName, "input", input)
-	taskCmdArgs := []string{"-t", t.taskfilePath, t.taskName}
-	if input != "" {
-		taskCmdArgs = append(taskCmdArgs, strings.Fields(input)...) // strings.Fields splits by whitespace
+func (t *mcpTool) Call(ctx context.Context, input string) (string, error) {
+	slog.Info("Calling tool", "name", t.tool.Name, "input", input)
+	request := mcp.CallToolRequest{}
+	request.Params.Name = t.tool.Name
+	request.Params.Arguments = toolArguments(input)
+	result, err := t.client.CallTool(ctx, request)
+	if err != nil {
+		return "", fmt.Errorf("calling %s: %w", t.tool.Name, err)
 	}
-	slog.Debug("Preparing to run command", "command", "task", "args", taskCmdArgs)
-
-	// #nosec G204
-	execCmd := exec.CommandContext(ctx, "task", taskCmdArgs...)
-	var outbuf, errbuf strings.Builder
-	execCmd.Stdout = &outbuf
-	execCmd.Stderr = &errbuf
-
-	err := execCmd.Run()
-	stdout := strings.TrimSpace(outbuf.String())
-	stderr := strings.TrimSpace(errbuf.String())
+	output := resultText(result)
+	// Failures are observations the agent can react to, not errors.
+	if result.IsError {
+		return "Error: " + output, nil
+	}
+	return output, nil
+}
 
-	if err != nil {
-		slog.Error("Error executing task", "task", t.taskName, "error", err, "stdout", stdout, "stderr", stderr)
-		return fmt.Sprintf("Error executing task %s: %v. Stderr: %s. Stdout: %s", t.taskName, err, stderr, stdout), nil
+// resultText returns the text content of a tool result.
+func resultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			parts = append(parts, text.Text)
+		}
 	}
+	return strings.TrimSpace(strings.Join(parts, "\n"))
+}
 
-	slog.Info("Task executed successfully", "task", t.taskName, "stdout", stdout, "stderr", stderr)
-	if stderr != "" {
-		return fmt.Sprintf("Stdout:\n%s\nStderr:\n%s", stdout, stderr), nil
+// toolArguments parses the agent's tool input, either a JSON object or
+// whitespace-separated KEY=value pairs.
+func toolArguments(input string) map[string]any {
+	input = strings.TrimSpace(input)
+	args := make(map[string]any)
+	if strings.HasPrefix(input, "{") && json.Unmarshal([]byte(input), &args) == nil {
+		return args
+	}
+	for _, field := range strings.Fields(input) {
+		if key, value, ok := strings.Cut(field, "="); ok && key != "" {
+			args[key] = value
+		}
 	}
-	return stdout, nil
+	return args
 }
 
 var (
-	provider    string
-	modelName   string
-	temperature float64
-	maxTokens   int
-	agentCmd    = &cobra.Command{
+	provider      string
+	modelName     string
+	temperature   float64
+	maxTokens     int
+	prompt        string
+	maxIterations int
+	agentCmd      = &cobra.Command{
 		Use:   "agent [Taskfile]",
 		Short: "Run a Langchain agent with tools from a Taskfile.",
-		Long:  `The agent command configures and runs a Langchain Go REACT agent. Tools are derived from the provided Taskfile.`,
-		Args:  cobra.ExactArgs(1),
-		Run:   runAgent,
+		Long: `The agent command configures and runs a Langchain Go REACT agent. Tools are derived from the provided Taskfile.
+
+With --prompt the agent answers the prompt without interaction, for use in
+scripts and CI: the final answer is printed to stdout and the tool calls to
+stderr. The exit code is 0 when the agent answered, 5 when it failed and 6
+when it gave no answer within --max-iterations.
+
+Without a Taskfile argument it uses the Taskfile task would use, found in the
+current directory or the closest parent directory that has one.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runAgent,
 	}
 )
 
+// defaultModelName is the model used when --model-name isn't set.
+const defaultModelName = "claude-3-5-sonnet-latest"
+
 func init() {
 	agentCmd.Flags().StringVar(&provider, "provider", "anthropic", "LLM provider (e.g., anthropic, openai)")
-	agentCmd.Flags().StringVar(&modelName, "model-name", "claude-3-5-sonnet-latest ", "Name of the model to use")
+	agentCmd.Flags().StringVar(&modelName, "model-name", defaultModelName, "Name of the model to use")
 	agentCmd.Flags().Float64Var(&temperature, "temperature", 0.7, "Sampling temperature for the LLM (0.0-1.0)")
 	agentCmd.Flags().IntVar(&maxTokens, "max-tokens", 2000, "Maximum number of tokens to generate")
+	agentCmd.Flags().String("task-bin", "task", "Path to the task binary (default: 'task')")
+	agentCmd.Flags().Bool("no-cache", false, "Inspect the Taskfile afresh instead of reusing a cached inspection")
+	agentCmd.Flags().String("config", "", "Path to the tmcp config file (default: .tmcp.yml next to the Taskfile)")
+	agentCmd.Flags().StringVar(&prompt, "prompt", "", "Answer this prompt and exit; '-' reads it from stdin")
+	agentCmd.Flags().IntVar(&maxIterations, "max-iterations", 10, "Maximum number of reasoning steps with --prompt")
+	agentCmd.ValidArgsFunction = completeTaskfile
+	mustRegisterFlagCompletion(agentCmd, "config", completeYAMLFile)
+	mustRegisterFlagCompletion(agentCmd, "provider", completeProvider)
 	rootCmd.AddCommand(agentCmd)
 }
 
-func runAgent(cmd *cobra.Command, args []string) {
-	taskfilePath := args[0]
-	slog.Info("Starting agent command", "taskfile", taskfilePath)
-	taskBinPath, _ := cmd.Flags().GetString("task-bin")
-	inspector, err := inspector.New(
-		inspector.WithTaskfile(taskfilePath),
-		inspector.WithTaskBin(taskBinPath),
-	)
+func runAgent(cmd *cobra.Command, args []string) error {
+	bridge, client, err := openBridge(cmd, args)
 	if err != nil {
-		slog.Error("Failed to create inspector", "error", err)
-		return
+		return err
 	}
-	mcpConfig, err := inspector.Inspect()
+	defer bridge.Close()
+	defer client.Close()
+	listed, err := client.ListTools(cmd.Context(), mcp.ListToolsRequest{})
 	if err != nil {
-		slog.Error("Failed to inspect Taskfile", "error", err)
-		return
+		return withExitCode(exitExecutionFailed, fmt.Errorf("listing tools: %w", err))
 	}
-	slog.Info("Successfully inspected Taskfile", "task_count", len(mcpConfig.Tasks))
+	slog.Info("Connected to the bridge", "tool_count", len(listed.Tools))
 
-	var llm llms.Model // Use llms.Model interface
+	var llm llms.Model
 	var llmCallOpts []llms.CallOption
 
 	if temperature > 0.0 { // Only add if set, 0.0 might be default or invalid for some models
@@ -123,46 +172,22 @@ func runAgent(cmd *cobra.Command, args []string) {
 		llmCallOpts = append(llmCallOpts, llms.WithMaxTokens(maxTokens))
 	}
 
-	switch provider {
-	case "openai":
-		// Assumes openai.New and its options like WithToken, WithModel exist in v0.1.13.
-		// This might need adjustment if the API is different (e.g., direct params token, model to New).
-		opts := []openai.Option{
-			openai.WithToken(getOpenAIToken()),
-			openai.WithModel(modelName), // Model name for the client
-		}
-		llm, err = newOpenAIFn(opts...) // Use the function variable
-		if err != nil {
-			slog.Error("Failed to initialize OpenAI LLM", "error", err)
-			return
-		}
-		slog.Info("OpenAI LLM client initialized", "configured_model_for_client", modelName)
-	case "anthropic":
-		opts := []anthropic.Option{
-			anthropic.WithToken(getAnthropicToken()),
-			anthropic.WithModel(modelName), // Model name for the client
-		}
-		llm, err = newAnthropicFn(opts...) // Use the function variable
-		if err != nil {
-			slog.Error("Failed to initialize Anthropic LLM", "error", err)
-			return
-		}
-		slog.Info("Anthropic LLM client initialized", "configured_model_for_client", modelName)
-	default:
-		slog.Error("Unsupported LLM provider", "provider", provider)
-		return
+	llm, err = newLLM(provider, modelName)
+	if err != nil {
+		return withExitCode(exitUsage, fmt.Errorf("initializing the %s LLM: %w", provider, err))
 	}
 
 	var langchainTools []tools.Tool
-	for _, taskDef := range mcpConfig.Tasks {
-		tool := &taskExecutorTool{
-			taskName:        taskDef.Name,
-			taskDescription: taskDef.Description,
-			taskUsage:       taskDef.Usage,
-			taskfilePath:    taskfilePath,
-		}
-		langchainTools = append(langchainTools, tool)
-		slog.Debug("Created tool", "name", tool.Name(), "description", tool.Description())
+	for _, tool := range listed.Tools {
+		t := &mcpTool{client: client, tool: tool}
+		langchainTools = append(langchainTools, t)
+		slog.Debug("Created tool", "name", t.Name(), "description", t.Description())
+	}
+
+	if prompt != "" {
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return runPrompt(ctx, withCallOptions{Model: llm, options: llmCallOpts}, langchainTools)
 	}
 
 	slog.Info("LLM client and tools prepared.", "llm_type", fmt.Sprintf("%T", llm), "num_tools", len(langchainTools))
@@ -212,6 +237,187 @@ func runAgent(cmd *cobra.Command, args []string) {
 	var agentExecutor *agents.Executor
 	_ = agentExecutor // Prevent unused variable error.
 	slog.Info("Agent components (LLM, Tools, Call Options) are configured. Full agent execution would require specific agent type construction (e.g., ReAct) and use of agents.NewExecutor for v0.1.13.")
+	return nil
+}
+
+// runPrompt lets a one-shot agent answer the --prompt. The answer goes to
+// stdout and the tool calls to stderr.
+func runPrompt(ctx context.Context, llm llms.Model, langchainTools []tools.Tool) error {
+	input := prompt
+	if input == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("reading the prompt from stdin: %w", err)
+		}
+		input = strings.TrimSpace(string(data))
+	}
+	if maxIterations < 1 {
+		return usageErrorf("--max-iterations must be at least 1")
+	}
+
+	answer, err := askAgent(ctx, llm, langchainTools, input, os.Stderr)
+	if errors.Is(err, agents.ErrNotFinished) {
+		return withExitCode(exitNotFinished, fmt.Errorf("agent gave no answer within %d iterations", maxIterations))
+	}
+	if err != nil {
+		return withExitCode(exitExecutionFailed, fmt.Errorf("agent failed: %w", err))
+	}
+	fmt.Println(answer)
+	return nil
+}
+
+// askAgent lets a one-shot agent answer input within --max-iterations,
+// tracing its tool calls to w.
+func askAgent(ctx context.Context, llm llms.Model, langchainTools []tools.Tool, input string, w io.Writer) (string, error) {
+	trace := &agentTrace{w: w}
+	traced := make([]tools.Tool, len(langchainTools))
+	for i, tool := range langchainTools {
+		traced[i] = tracedTool{Tool: tool, trace: trace}
+	}
+	executor := agents.NewExecutor(
+		agents.NewOneShotAgent(llm, traced),
+		agents.WithMaxIterations(maxIterations),
+		agents.WithCallbacksHandler(trace),
+		// Let the model correct malformed replies instead of failing.
+		agents.WithParserErrorHandler(agents.NewParserErrorHandler(nil)),
+	)
+	outputs, err := executor.Call(ctx, map[string]any{"input": input})
+	if err != nil {
+		return "", err
+	}
+	answer, _ := outputs["output"].(string)
+	return strings.TrimSpace(answer), nil
+}
+
+// maxTraceOutput caps the tool output shown per call in the trace.
+const maxTraceOutput = 500
+
+// agentTrace prints the agent's tool calls.
+type agentTrace struct {
+	callbacks.SimpleHandler
+	w io.Writer
+}
+
+func (t *agentTrace) HandleAgentAction(ctx context.Context, action schema.AgentAction) {
+	fmt.Fprintf(t.w, "> %s %s\n", action.Tool, strings.TrimSpace(action.ToolInput))
+}
+
+// observation prints the result of a tool call.
+func (t *agentTrace) observation(output string) {
+	output = strings.TrimSpace(output)
+	if len(output) > maxTraceOutput {
+		output = output[:maxTraceOutput] + "..."
+	}
+	for _, line := range strings.Split(output, "\n") {
+		fmt.Fprintf(t.w, "  %s\n", line)
+	}
+}
+
+// tracedTool reports the results of a tool to the trace.
+type tracedTool struct {
+	tools.Tool
+	trace *agentTrace
+}
+
+func (t tracedTool) Call(ctx context.Context, input string) (string, error) {
+	output, err := t.Tool.Call(ctx, input)
+	if err != nil {
+		t.trace.observation("error: " + err.Error())
+	} else {
+		t.trace.observation(output)
+	}
+	return output, err
+}
+
+// withCallOptions applies the sampling flags to every model call.
+type withCallOptions struct {
+	llms.Model
+	options []llms.CallOption
+}
+
+func (m withCallOptions) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	return m.Model.GenerateContent(ctx, messages, append(append([]llms.CallOption{}, m.options...), options...)...)
+}
+
+func (m withCallOptions) Call(ctx context.Context, text string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, text, options...)
+}
+
+// openBridge serves the Taskfile of args in process and connects an MCP
+// client to it, as the agent command and other clients of the tools do.
+func openBridge(cmd *cobra.Command, args []string) (*server.Bridge, *mcpclient.Client, error) {
+	taskfilePath, err := taskfileArg(cmd, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	slog.Info("Starting "+cmd.Name()+" command", "taskfile", taskfilePath)
+	if err := checkTaskfile(taskfilePath); err != nil {
+		return nil, nil, err
+	}
+	taskBinPath, err := taskBinFor(cmd, taskfilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	configPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.Load(configPath, configAnchor(args, taskfilePath))
+	if err != nil {
+		return nil, nil, withExitCode(exitUsage, err)
+	}
+	// The request log would mix with the agent's output.
+	opts := append(serverOptions(cmd, cfg), server.WithLogOutput(io.Discard))
+	if isRemoteArg(args) {
+		opts = append(opts, server.WithDir("."))
+	}
+	bridge, err := newBridge(cmd.Context(), taskfilePath, taskBinPath, "tasks", opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := connectBridge(cmd.Context(), bridge)
+	if err != nil {
+		bridge.Close()
+		return nil, nil, withExitCode(exitExecutionFailed, fmt.Errorf("connecting to the bridge: %w", err))
+	}
+	return bridge, client, nil
+}
+
+// connectBridge connects an in-process MCP client to the bridge.
+func connectBridge(ctx context.Context, bridge *server.Bridge) (*mcpclient.Client, error) {
+	c, err := mcpclient.NewInProcessClient(bridge.MCPServer())
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Start(ctx); err != nil {
+		return nil, err
+	}
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "tmcp-agent", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, initRequest); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("initializing MCP session: %w", err)
+	}
+	return c, nil
+}
+
+// newLLM creates a client of the provider's model.
+func newLLM(provider string, model string) (llms.Model, error) {
+	switch provider {
+	case "openai":
+		llm, err := newOpenAIFn(openai.WithToken(getOpenAIToken()), openai.WithModel(model))
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("OpenAI LLM client initialized", "configured_model_for_client", model)
+		return llm, nil
+	case "anthropic":
+		llm, err := newAnthropicFn(anthropic.WithToken(getAnthropicToken()), anthropic.WithModel(model))
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Anthropic LLM client initialized", "configured_model_for_client", model)
+		return llm, nil
+	}
+	return nil, fmt.Errorf("unsupported LLM provider %q (want anthropic or openai)", provider)
 }
 
 func getOpenAIToken() string {
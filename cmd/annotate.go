@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/annotate"
+	"github.com/spf13/cobra"
+)
+
+var annotateCmd = &cobra.Command{
+	Use:   "annotate [Taskfile]",
+	Short: "Draft missing task descriptions and summaries with an LLM.",
+	Long: `The annotate command finds the tasks of a Taskfile without a desc or summary,
+asks an LLM to draft them from the tasks' commands, and writes them back in the
+form tmcp reads: a summary with Usage and Required lines. The rest of the file
+is left exactly as it is.
+
+The changes are printed as a diff. With --dry-run the Taskfile isn't changed.
+Tasks in included Taskfiles and tasks written in the short string or list
+forms are not annotated.
+
+Without a Taskfile argument it annotates the Taskfile task would use, found in
+the current directory or the closest parent directory that has one.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		taskfilePath, err := localTaskfileArg(cmd, args)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+		defer cancel()
+		return runAnnotate(ctx, cmd, taskfilePath)
+	},
+}
+
+func init() {
+	annotateCmd.Flags().String("provider", "anthropic", "LLM provider (e.g., anthropic, openai)")
+	annotateCmd.Flags().String("model-name", defaultModelName, "Name of the model to use")
+	annotateCmd.Flags().StringSlice("task", nil, "Only annotate these tasks (repeatable)")
+	annotateCmd.Flags().Bool("dry-run", false, "Print the changes without writing them")
+	annotateCmd.ValidArgsFunction = completeTaskfile
+	mustRegisterFlagCompletion(annotateCmd, "task", completeUndocumentedTask)
+	mustRegisterFlagCompletion(annotateCmd, "provider", completeProvider)
+	rootCmd.AddCommand(annotateCmd)
+}
+
+func runAnnotate(ctx context.Context, cmd *cobra.Command, taskfilePath string) error {
+	data, err := os.ReadFile(taskfilePath)
+	if err != nil {
+		return err
+	}
+	tasks, err := annotate.Find(data)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", taskfilePath, err)
+	}
+	if only, _ := cmd.Flags().GetStringSlice("task"); len(only) > 0 {
+		tasks = slices.DeleteFunc(tasks, func(t annotate.Task) bool { return !slices.Contains(only, t.Name) })
+	}
+	if len(tasks) == 0 {
+		fmt.Fprintln(os.Stderr, "Every task already has a desc and a summary.")
+		return nil
+	}
+
+	provider, _ := cmd.Flags().GetString("provider")
+	model, _ := cmd.Flags().GetString("model-name")
+	llm, err := newLLM(provider, model)
+	if err != nil {
+		return err
+	}
+	annotations := make(map[string]annotate.Annotation, len(tasks))
+	for _, t := range tasks {
+		fmt.Fprintf(os.Stderr, "Drafting %s...\n", t.Name)
+		a, err := annotate.Draft(ctx, llm, t)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(os.Stderr, "  Skipped: %v\n", err)
+			continue
+		}
+		annotations[t.Name] = a
+	}
+	edits, err := annotate.Plan(tasks, annotations)
+	if err != nil {
+		return err
+	}
+	if len(edits) == 0 {
+		return errors.New("no task could be annotated")
+	}
+
+	fmt.Print(annotate.Diff(taskfilePath, data, edits))
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		return nil
+	}
+	info, err := os.Stat(taskfilePath)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(taskfilePath, annotate.Apply(data, edits), info.Mode().Perm()); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Annotated %d tasks in %s. Check the drafts before committing them.\n", len(edits), taskfilePath)
+	return nil
+}
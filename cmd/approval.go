@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
+)
+
+// approvalNotifier returns the notifier for the configured notify methods,
+// or nil when there are none.
+func approvalNotifier(cfg config.ApprovalConfig) server.ApprovalNotifier {
+	var notifiers []server.ApprovalNotifier
+	for _, notify := range cfg.Notify {
+		switch notify {
+		case "terminal":
+			notifiers = append(notifiers, promptTerminal)
+		case "desktop":
+			notifiers = append(notifiers, notifyDesktop)
+		}
+	}
+	if len(notifiers) == 0 {
+		return nil
+	}
+	return func(a server.Approval, decide func(bool) error) {
+		for _, notify := range notifiers {
+			go notify(a, decide)
+		}
+	}
+}
+
+// describeApproval summarizes a waiting call on one line.
+func describeApproval(a server.Approval) string {
+	var b strings.Builder
+	b.WriteString(a.Task)
+	if len(a.Arguments) > 0 {
+		args := make([]string, 0, len(a.Arguments))
+		for name, value := range a.Arguments {
+			args = append(args, fmt.Sprintf("%s=%v", name, value))
+		}
+		sort.Strings(args)
+		fmt.Fprintf(&b, " (%s)", strings.Join(args, " "))
+	}
+	if a.Client != "" {
+		fmt.Fprintf(&b, " requested by %s", strings.TrimSpace(a.Client+" "+a.ClientVersion))
+	}
+	return b.String()
+}
+
+// terminalMu keeps prompts for concurrent calls from interleaving.
+var terminalMu sync.Mutex
+
+// promptTerminal asks on the controlling terminal, which stays usable when
+// stdin and stdout carry MCP.
+func promptTerminal(a server.Approval, decide func(bool) error) {
+	terminalMu.Lock()
+	defer terminalMu.Unlock()
+	if time.Now().After(a.Expires) {
+		return
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		slog.Warn("Cannot prompt for approval without a terminal", "approval", a.ID, "error", err)
+		return
+	}
+	defer tty.Close()
+
+	fmt.Fprintf(tty, "\ntmcp: run %s? Expires at %s. [y/N] ", describeApproval(a), a.Expires.Format("15:04:05"))
+	answer, _ := bufio.NewReader(tty).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if err := decide(answer == "y" || answer == "yes"); err != nil {
+		fmt.Fprintf(tty, "tmcp: too late, %s is no longer waiting.\n", a.ID)
+	}
+}
+
+// notifyDesktop shows a desktop notification. It can't take a decision; the
+// human answers on the terminal or via the admin API.
+func notifyDesktop(a server.Approval, _ func(bool) error) {
+	if err := showDesktopNotification(fmt.Sprintf("Approval needed (%s): %s", a.ID, describeApproval(a))); err != nil {
+		slog.Warn("Could not show desktop notification", "approval", a.ID, "error", err)
+	}
+}
+
+// showDesktopNotification shows message with notify-send, or osascript on
+// macOS. It does nothing on other platforms.
+func showDesktopNotification(message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// #nosec G204
+		cmd = exec.Command("osascript", "-e", fmt.Sprintf("display notification %q with title %q", message, "tmcp"))
+	case "linux", "freebsd", "openbsd":
+		// #nosec G204
+		cmd = exec.Command("notify-send", "tmcp", message)
+	default:
+		slog.Debug("Desktop notifications are not supported on this platform", "os", runtime.GOOS)
+		return nil
+	}
+	return cmd.Run()
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of Taskfile inspections.",
+	Long: `serve, view, inspect and agent cache the inspection of each Taskfile in the
+user's cache directory, so they start instantly until the Taskfile, a Taskfile
+it includes, or the task binary changes. Pass --no-cache to inspect afresh.
+
+serve --sample-descriptions caches the descriptions clients write for tasks
+there too.`,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every cached inspection and description.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := inspector.DefaultCacheDir()
+		if err != nil {
+			return fmt.Errorf("locating the cache: %w", err)
+		}
+		removed, err := (&inspector.Cache{Dir: dir}).Clear()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d cached inspections from %s\n", removed, dir)
+		// Descriptions are JSON files too.
+		if dir = descriptionCacheDir(cmd); dir != "" {
+			removed, err := (&inspector.Cache{Dir: dir}).Clear()
+			if err != nil {
+				return err
+			}
+			if removed > 0 {
+				fmt.Printf("Removed %d cached descriptions from %s\n", removed, dir)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// inspectCache returns the inspection cache cmd uses, or nil with
+// --no-cache or when there is no cache directory.
+func inspectCache(cmd *cobra.Command) *inspector.Cache {
+	if noCache, _ := cmd.Flags().GetBool("no-cache"); noCache {
+		return nil
+	}
+	dir, err := inspector.DefaultCacheDir()
+	if err != nil {
+		slog.Debug("Not caching inspections", "error", err)
+		return nil
+	}
+	return &inspector.Cache{Dir: dir}
+}
+
+// descriptionCacheDir returns the directory of the task descriptions written
+// by clients, see server.WithSampledDescriptions, or "" with --no-cache or
+// when there is no cache directory.
+func descriptionCacheDir(cmd *cobra.Command) string {
+	if noCache, _ := cmd.Flags().GetBool("no-cache"); noCache {
+		return ""
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "tmcp", "descriptions")
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// ciSystems are the values of --ci.
+var ciSystems = []string{"github"}
+
+// addCIFlag registers --ci on a command that reports failures.
+func addCIFlag(cmd *cobra.Command) {
+	cmd.Flags().String("ci", "", "Also report failures as annotations of this CI system: github")
+	mustRegisterFlagCompletion(cmd, "ci", cobra.FixedCompletions(ciSystems, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// annotator writes failures as annotations of the CI system chosen with
+// --ci, so they show up next to the file in pull requests.
+type annotator struct {
+	system string
+}
+
+// newAnnotator returns the annotator of the --ci system; one writing
+// nothing without the flag.
+func newAnnotator(cmd *cobra.Command) (annotator, error) {
+	system, _ := cmd.Flags().GetString("ci")
+	if system != "" && system != "github" {
+		return annotator{}, usageErrorf("unknown --ci %q, want one of %s", system, strings.Join(ciSystems, ", "))
+	}
+	return annotator{system: system}, nil
+}
+
+// annotate writes a failure at line of file; a line of 0 annotates the
+// whole file. level is error or warning.
+func (a annotator) annotate(level, file string, line int, title, message string) {
+	if a.system == "" {
+		return
+	}
+	properties := "file=" + escapeProperty(annotationPath(file))
+	if line > 0 {
+		properties += fmt.Sprintf(",line=%d", line)
+	}
+	if title != "" {
+		properties += ",title=" + escapeProperty(title)
+	}
+	// GitHub Actions workflow commands, see "Workflow commands for GitHub
+	// Actions" in its documentation.
+	fmt.Printf("::%s %s::%s\n", level, properties, escapeData(message))
+}
+
+// annotationPath makes path relative to the repository, which GitHub
+// Actions checks out to $GITHUB_WORKSPACE, or else to the current
+// directory.
+func annotationPath(path string) string {
+	base := os.Getenv("GITHUB_WORKSPACE")
+	if base == "" {
+		base, _ = os.Getwd()
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(base, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(strings.TrimRight(s, "\n"))
+}
+
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
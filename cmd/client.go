@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+)
+
+var clientCmd = &cobra.Command{
+	Use:   "client <server-command> [args...] | <url>",
+	Short: "Connect to an MCP server and call its tools interactively.",
+	Long: `The client command connects to any MCP server, lists its tools and lets you
+call them from a prompt. Give either the command that starts a stdio server,
+e.g. 'tmcp client tmcp serve Taskfile.yml', or the URL of a streamable HTTP
+server, e.g. 'tmcp client http://127.0.0.1:8080/mcp'.
+
+Commands are read from stdin, so they can also be piped in:
+
+  tools                      list the tools
+  describe <tool>            show a tool's description and parameters
+  call <tool> [arguments]    call a tool; arguments are KEY=value pairs or a
+                             JSON object ('call' may be left out)
+  quit                       disconnect`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+		defer cancel()
+		return runClient(ctx, cmd, args)
+	},
+}
+
+func init() {
+	clientCmd.Flags().Bool("server-stderr", false, "Show the stderr output of a stdio server")
+	clientCmd.Flags().Bool("sse", false, "Connect to the URL with the legacy SSE transport")
+	clientCmd.Flags().StringArray("header", nil, "HTTP header to send, as 'Name: value' (repeatable)")
+	// Everything after the server command belongs to it.
+	clientCmd.Flags().SetInterspersed(false)
+	rootCmd.AddCommand(clientCmd)
+}
+
+func runClient(ctx context.Context, cmd *cobra.Command, args []string) error {
+	c, err := connectClient(ctx, cmd, args)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "tmcp-client", Version: "1.0.0"}
+	initResult, err := c.Initialize(ctx, initRequest)
+	if err != nil {
+		return fmt.Errorf("initializing MCP session: %w", err)
+	}
+	listed, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		return fmt.Errorf("listing tools: %w", err)
+	}
+
+	r := &clientREPL{client: c, tools: listed.Tools, out: os.Stdout}
+	fmt.Fprintf(r.out, "Connected to %s %s with %d tools. Type 'help' for commands.\n", initResult.ServerInfo.Name, initResult.ServerInfo.Version, len(r.tools))
+	prompt := ""
+	if isTerminal(os.Stdin) {
+		prompt = "> "
+	}
+	return r.run(ctx, os.Stdin, prompt)
+}
+
+// connectClient starts a client for the server command or URL in args.
+func connectClient(ctx context.Context, cmd *cobra.Command, args []string) (*mcpclient.Client, error) {
+	target := args[0]
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		c, err := mcpclient.NewStdioMCPClient(target, os.Environ(), args[1:]...)
+		if err != nil {
+			return nil, fmt.Errorf("starting %s: %w", target, err)
+		}
+		// The server blocks once its stderr pipe is full, so always drain it.
+		if stderr, ok := mcpclient.GetStderr(c); ok {
+			logs := io.Discard
+			if show, _ := cmd.Flags().GetBool("server-stderr"); show {
+				logs = os.Stderr
+			}
+			go io.Copy(logs, stderr)
+		}
+		return c, nil
+	}
+	if len(args) > 1 {
+		return nil, fmt.Errorf("unexpected arguments after the URL: %s", strings.Join(args[1:], " "))
+	}
+
+	headerFlags, _ := cmd.Flags().GetStringArray("header")
+	headers := make(map[string]string, len(headerFlags))
+	for _, h := range headerFlags {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return nil, fmt.Errorf("bad header %q, want 'Name: value'", h)
+		}
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	var c *mcpclient.Client
+	var err error
+	if sse, _ := cmd.Flags().GetBool("sse"); sse {
+		c, err = mcpclient.NewSSEMCPClient(target, transport.WithHeaders(headers))
+	} else {
+		c, err = mcpclient.NewStreamableHttpClient(target, transport.WithHTTPHeaders(headers))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", target, err)
+	}
+	if err := c.Start(ctx); err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", target, err)
+	}
+	return c, nil
+}
+
+// clientREPL reads and runs client commands.
+type clientREPL struct {
+	client *mcpclient.Client
+	tools  []mcp.Tool
+	out    io.Writer
+}
+
+func (r *clientREPL) run(ctx context.Context, in io.Reader, prompt string) error {
+	scanner := bufio.NewScanner(in)
+	// Allow long JSON arguments.
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for {
+		fmt.Fprint(r.out, prompt)
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, rest, _ := strings.Cut(line, " ")
+		rest = strings.TrimSpace(rest)
+		switch name {
+		case "quit", "exit":
+			return nil
+		case "help":
+			fmt.Fprintln(r.out, "Commands: tools, describe <tool>, call <tool> [KEY=value ... | JSON], quit")
+		case "tools", "list":
+			r.listTools()
+		case "describe":
+			r.describe(rest)
+		case "call":
+			name, rest, _ = strings.Cut(rest, " ")
+			r.call(ctx, name, rest)
+		default:
+			if _, ok := r.tool(name); !ok {
+				fmt.Fprintf(r.out, "Unknown command or tool %q. Type 'help' for commands.\n", name)
+				continue
+			}
+			r.call(ctx, name, rest)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+func (r *clientREPL) tool(name string) (mcp.Tool, bool) {
+	for _, t := range r.tools {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return mcp.Tool{}, false
+}
+
+func (r *clientREPL) listTools() {
+	for _, t := range r.tools {
+		description, _, _ := strings.Cut(t.Description, "\n")
+		fmt.Fprintf(r.out, "%-30s %s\n", t.Name, description)
+	}
+}
+
+func (r *clientREPL) describe(name string) {
+	t, ok := r.tool(name)
+	if !ok {
+		fmt.Fprintf(r.out, "Unknown tool %q\n", name)
+		return
+	}
+	fmt.Fprintf(r.out, "%s\n\n%s\n", t.Name, t.Description)
+	params := make([]string, 0, len(t.InputSchema.Properties))
+	for param := range t.InputSchema.Properties {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+	if len(params) > 0 {
+		fmt.Fprintln(r.out, "\nParameters:")
+	}
+	for _, param := range params {
+		prop, _ := t.InputSchema.Properties[param].(map[string]any)
+		line := fmt.Sprintf("  %s (%v", param, prop["type"])
+		for _, required := range t.InputSchema.Required {
+			if required == param {
+				line += ", required"
+			}
+		}
+		line += ")"
+		if description, ok := prop["description"].(string); ok && description != "" {
+			line += ": " + description
+		}
+		fmt.Fprintln(r.out, line)
+	}
+}
+
+func (r *clientREPL) call(ctx context.Context, name string, input string) {
+	t, ok := r.tool(name)
+	if !ok {
+		fmt.Fprintf(r.out, "Unknown tool %q\n", name)
+		return
+	}
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = coerceArguments(t, toolArguments(input))
+	result, err := r.client.CallTool(ctx, request)
+	if err != nil {
+		fmt.Fprintf(r.out, "Error: %v\n", err)
+		return
+	}
+	if result.IsError {
+		fmt.Fprintln(r.out, "Tool returned an error:")
+	}
+	for _, content := range result.Content {
+		switch c := content.(type) {
+		case mcp.TextContent:
+			fmt.Fprintln(r.out, strings.TrimRight(c.Text, "\n"))
+		case mcp.ImageContent:
+			fmt.Fprintf(r.out, "[image, %s]\n", c.MIMEType)
+		case mcp.AudioContent:
+			fmt.Fprintf(r.out, "[audio, %s]\n", c.MIMEType)
+		default:
+			fmt.Fprintf(r.out, "[%T]\n", c)
+		}
+	}
+}
+
+// coerceArguments converts KEY=value strings to the numbers and booleans
+// the tool's schema asks for. Values that don't parse are left as strings
+// for the server to reject.
+func coerceArguments(t mcp.Tool, args map[string]any) map[string]any {
+	for name, value := range args {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		prop, _ := t.InputSchema.Properties[name].(map[string]any)
+		switch prop["type"] {
+		case "number", "integer":
+			if n, err := strconv.ParseFloat(s, 64); err == nil {
+				args[name] = n
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(s); err == nil {
+				args[name] = b
+			}
+		}
+	}
+	return args
+}
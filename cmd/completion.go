@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/annotate"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/taskbin"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+	"github.com/spf13/cobra"
+)
+
+// Shell completion is provided by cobra's completion command, e.g.
+// `source <(tmcp completion bash)`. The functions below complete the
+// arguments that depend on the Taskfile.
+
+// yamlExtensions are the file extensions of Taskfiles and config files.
+var yamlExtensions = []string{"yml", "yaml"}
+
+// completeTaskfile completes the Taskfile argument of commands that take
+// one.
+func completeTaskfile(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return yamlExtensions, cobra.ShellCompDirectiveFilterFileExt
+}
+
+// completeYAMLFile completes flags naming a YAML file.
+func completeYAMLFile(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return yamlExtensions, cobra.ShellCompDirectiveFilterFileExt
+}
+
+// completeProvider completes the LLM providers newLLM supports.
+var completeProvider = cobra.FixedCompletions([]string{"anthropic", "openai"}, cobra.ShellCompDirectiveNoFileComp)
+
+// completionTaskfile returns the Taskfile the command line names, or the
+// one task would use from the current directory.
+func completionTaskfile(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return defaultTaskfile()
+}
+
+// completeTaskName completes task names by listing the tasks of the
+// Taskfile with the task binary.
+func completeTaskName(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	name, _ := cmd.Flags().GetString("task-bin")
+	// Skip the version check: completion must stay quick and quiet.
+	bin, err := taskbin.Resolve(name)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	path := completionTaskfile(args)
+	opts := []inspector.Option{inspector.WithTaskfile(path)}
+	// Other sources run with their own program, the inspector's default.
+	if inspector.SourceOf(path) == inspector.Taskfile {
+		opts = append(opts, inspector.WithTaskBin(bin))
+	}
+	i, err := inspector.New(opts...)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	tasks, err := i.DiscoverTasks(cmd.Context())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return matchingPrefix(tasks, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeUndocumentedTask completes the tasks annotate would document.
+func completeUndocumentedTask(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	data, err := os.ReadFile(completionTaskfile(args))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	tasks, err := annotate.Find(data)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, t := range tasks {
+		names = append(names, t.Name)
+	}
+	// --task is repeatable, so don't offer tasks already given.
+	given, _ := cmd.Flags().GetStringSlice("task")
+	names = slices.DeleteFunc(names, func(name string) bool { return slices.Contains(given, name) })
+	return matchingPrefix(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func matchingPrefix(names []string, prefix string) []string {
+	var matches []string
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+	return matches
+}
+
+// mustRegisterFlagCompletion registers fn for the flag, which must exist.
+func mustRegisterFlagCompletion(cmd *cobra.Command, flag string, fn func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective)) {
+	if err := cmd.RegisterFlagCompletionFunc(flag, fn); err != nil {
+		panic(err)
+	}
+}
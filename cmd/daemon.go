@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
+	"github.com/spf13/cobra"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Serve many Taskfiles from one long-running process.",
+	Long: `The daemon command serves the Taskfiles registered with it over one HTTP
+listener, each at http://<listen>/<name>/mcp, so working on many projects
+doesn't take one tmcp process per project.
+
+Taskfiles are registered and unregistered while the daemon runs:
+
+  tmcp daemon register path/to/Taskfile.yml [--name api]
+  tmcp daemon unregister api
+  tmcp daemon list
+
+These talk to the daemon over a Unix socket (--socket). Registrations are saved
+to a state file (--state) and served again when the daemon restarts. Each
+Taskfile uses the .tmcp.yml next to it, as with serve.`,
+	Args: cobra.NoArgs,
+	RunE: runDaemon,
+}
+
+var daemonRegisterCmd = &cobra.Command{
+	Use:   "register [Taskfile]",
+	Short: "Serve a Taskfile from the running daemon.",
+	Long: `The register command asks the running daemon to serve a Taskfile. It is served
+at /<name>/mcp, where the name defaults to the Taskfile's directory name.
+Without a Taskfile argument it registers the Taskfile task would use, found in
+the current directory or the closest parent directory that has one.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		taskfile, err := localTaskfileArg(cmd, args)
+		if err != nil {
+			return err
+		}
+		if taskfile, err = filepath.Abs(taskfile); err != nil {
+			return err
+		}
+		if err := checkTaskfile(taskfile); err != nil {
+			return err
+		}
+		name, _ := cmd.Flags().GetString("name")
+		if name == "" {
+			name = projectName(taskfile)
+		}
+		if err := config.ValidateProjectName(name); err != nil {
+			return withExitCode(exitUsage, err)
+		}
+		body, _ := json.Marshal(map[string]string{"name": name, "taskfile": taskfile})
+		var p server.Project
+		if err := daemonRequest(cmd, http.MethodPost, "/projects", body, &p); err != nil {
+			return err
+		}
+		fmt.Printf("Serving %s at %s (%d tools)\n", p.Name, p.URL, p.Tools)
+		return nil
+	},
+}
+
+var daemonUnregisterCmd = &cobra.Command{
+	Use:   "unregister <name>",
+	Short: "Stop serving a project from the running daemon.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := daemonRequest(cmd, http.MethodDelete, "/projects/"+args[0], nil, nil); err != nil {
+			return err
+		}
+		fmt.Printf("Unregistered %s\n", args[0])
+		return nil
+	},
+	ValidArgsFunction: completeProjectName,
+}
+
+var daemonListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the projects the running daemon serves.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return withExitCode(exitUsage, err)
+		}
+		var projects []server.Project
+		if err := daemonRequest(cmd, http.MethodGet, "/projects", nil, &projects); err != nil {
+			return err
+		}
+		switch format {
+		case "table":
+			printProjectTable(os.Stdout, projects)
+			return nil
+		case "yaml":
+			return printYAML(os.Stdout, projects)
+		default:
+			return printJSON(os.Stdout, projects)
+		}
+	},
+}
+
+func init() {
+	daemonCmd.PersistentFlags().String("socket", defaultDaemonSocket(), "Unix socket the daemon is controlled over")
+	daemonCmd.Flags().String("listen", "127.0.0.1:8080", "Address to serve MCP on, or unix:///path/to.sock for a Unix socket")
+	daemonCmd.Flags().String("listen-mode", "0600", "Permissions of the Unix socket --listen creates, which decide who may connect")
+	daemonCmd.Flags().String("state", "", "File the registered projects are saved to (default: tmcp/"+config.DaemonStateFileName+" in the user config directory)")
+	daemonCmd.Flags().String("task-bin", "task", "Path to the task binary (default: 'task')")
+	daemonCmd.Flags().Duration("handshake-timeout", 30*time.Second, "Drop clients that do not complete the initialize handshake in time (0 disables)")
+	daemonCmd.Flags().Duration("read-timeout", 0, "Drop clients that send no message for this long after initializing (0 disables)")
+	daemonCmd.Flags().String("hook-token", "", "Enable webhooks at /<name>/hooks/<tool>, authenticated with this token (default: $TMCP_HOOK_TOKEN)")
+	daemonCmd.Flags().Bool("auto-json", false, "Return the output of tasks that print a JSON object or array as structured content too")
+	daemonCmd.Flags().Bool("no-cache", false, "Inspect the Taskfile afresh instead of reusing a cached inspection")
+	daemonCmd.Flags().Bool("lazy", false, "Start from a single task listing and inspect each task's parameters when it is first listed or called")
+	daemonCmd.Flags().Bool("safe", false, "Strict arguments, clean env, redacted output, and dry runs for tasks not read-only or in safe.allow")
+	daemonRegisterCmd.Flags().String("name", "", "Name the project is served under (default: the Taskfile's directory name)")
+	daemonRegisterCmd.ValidArgsFunction = completeTaskfile
+	daemonListCmd.Flags().StringP("output", "o", "", "Output format: table, json or yaml (default: table on a terminal, json otherwise)")
+	mustRegisterFlagCompletion(daemonCmd, "state", completeYAMLFile)
+	mustRegisterFlagCompletion(daemonListCmd, "output", cobra.FixedCompletions([]string{"table", "json", "yaml"}, cobra.ShellCompDirectiveNoFileComp))
+	daemonCmd.AddCommand(daemonRegisterCmd, daemonUnregisterCmd, daemonListCmd)
+	rootCmd.AddCommand(daemonCmd)
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	taskBinPath, err := resolveTaskBin(cmd)
+	if err != nil {
+		return err
+	}
+	statePath, _ := cmd.Flags().GetString("state")
+	if statePath == "" {
+		if statePath, err = config.DefaultDaemonStatePath(); err != nil {
+			return err
+		}
+	}
+	state, err := config.LoadDaemonState(statePath)
+	if err != nil {
+		return withExitCode(exitUsage, err)
+	}
+	httpOpts, err := httpOptions(cmd)
+	if err != nil {
+		return err
+	}
+	socket, _ := cmd.Flags().GetString("socket")
+
+	registry := server.NewRegistry(httpOpts.HookToken)
+	defer registry.Close()
+	control := server.NewControl(registry, server.ControlOptions{
+		Load: func(ctx context.Context, name string, taskfile string) (*server.Bridge, error) {
+			cfg, err := config.Load("", taskfile)
+			if err != nil {
+				return nil, err
+			}
+			// Each project's MCP server is named after it.
+			return newBridge(ctx, taskfile, taskBinPath, name, serverOptions(cmd, cfg)...)
+		},
+		Persist: func(projects []server.Project) error {
+			state := &config.DaemonState{}
+			for _, p := range projects {
+				state.Projects = append(state.Projects, config.DaemonProject{Name: p.Name, Taskfile: p.Taskfile})
+			}
+			return config.SaveDaemonState(statePath, state)
+		},
+		BaseURL: server.ListenURL(httpOpts.Addr),
+	})
+	// Fail before loading any project if another daemon is running.
+	listener, err := server.ListenControl(socket)
+	if err != nil {
+		return withExitCode(exitExecutionFailed, err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+	for _, p := range state.Projects {
+		control.Restore(ctx, p.Name, p.Taskfile)
+	}
+	controlErr := make(chan error, 1)
+	go func() {
+		controlErr <- server.ServeControl(ctx, listener, control)
+		// Without its control API the daemon can't be managed.
+		cancel()
+	}()
+
+	err = server.ServeRegistry(ctx, httpOpts, registry)
+	cancel()
+	if cerr := <-controlErr; err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return withExitCode(exitExecutionFailed, fmt.Errorf("serving MCP: %w", err))
+	}
+	return nil
+}
+
+// defaultDaemonSocket returns the control socket in the user's runtime
+// directory, or in the temp directory when there is none.
+func defaultDaemonSocket() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "tmcp.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("tmcp-%d.sock", os.Getuid()))
+}
+
+// unsafeNameChars matches what project names can't contain.
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// projectName derives a project name from the directory of a Taskfile.
+func projectName(taskfile string) string {
+	name := strings.Trim(unsafeNameChars.ReplaceAllString(filepath.Base(filepath.Dir(taskfile)), "-"), "-")
+	if name == "" {
+		return "tasks"
+	}
+	return name
+}
+
+// daemonRequest calls the control API of the running daemon and decodes its
+// JSON reply into out, unless out is nil.
+func daemonRequest(cmd *cobra.Command, method string, path string, body []byte, out any) error {
+	socket, _ := cmd.Flags().GetString("socket")
+	client := &http.Client{
+		Timeout: 5 * time.Minute,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	req, err := http.NewRequestWithContext(cmd.Context(), method, "http://tmcp"+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("no daemon listening on %s, start one with 'tmcp daemon': %w", socket, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		err := fmt.Errorf("daemon: %s", strings.TrimSpace(string(msg)))
+		switch resp.StatusCode {
+		case http.StatusUnprocessableEntity:
+			return withExitCode(exitInspectionFailed, err)
+		case http.StatusBadRequest, http.StatusNotFound, http.StatusConflict:
+			return withExitCode(exitUsage, err)
+		}
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// printProjectTable lists the daemon's projects one per row.
+func printProjectTable(w io.Writer, projects []server.Project) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tTOOLS\tURL\tTASKFILE")
+	for _, p := range projects {
+		tools, url := fmt.Sprint(p.Tools), p.URL
+		if p.Error != "" {
+			tools, url = "-", "error: "+p.Error
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", p.Name, tools, url, p.Taskfile)
+	}
+	tw.Flush()
+}
+
+// completeProjectName completes the names of the daemon's projects.
+func completeProjectName(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var projects []server.Project
+	if err := daemonRequest(cmd, http.MethodGet, "/projects", nil, &projects); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, p := range projects {
+		names = append(names, p.Name)
+	}
+	return matchingPrefix(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
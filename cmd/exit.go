@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Exit codes of tmcp, so wrappers and CI can tell failures apart.
+const (
+	exitOK = 0
+	// exitFailure is any failure without a more specific code.
+	exitFailure = 1
+	// exitUsage is an invalid command line or config file.
+	exitUsage = 2
+	// exitTaskfileNotFound means the Taskfile or workspace manifest doesn't
+	// exist.
+	exitTaskfileNotFound = 3
+	// exitInspectionFailed means the task binary is missing or couldn't
+	// list or describe the tasks.
+	exitInspectionFailed = 4
+	// exitExecutionFailed means serving MCP or running the agent failed.
+	exitExecutionFailed = 5
+	// exitNotFinished means `agent --prompt` gave no answer within
+	// --max-iterations.
+	exitNotFinished = 6
+)
+
+// exitError is an error with the code tmcp exits with.
+type exitError struct {
+	code int
+	err  error
+	// quiet errors were already reported and aren't printed again.
+	quiet bool
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode makes tmcp exit with code when err ends the command. Errors
+// that already carry a code keep it.
+func withExitCode(code int, err error) error {
+	var e *exitError
+	if err == nil || errors.As(err, &e) {
+		return err
+	}
+	return &exitError{code: code, err: err}
+}
+
+// usageErrorf returns an error for an invalid command line.
+func usageErrorf(format string, args ...any) error {
+	return &exitError{code: exitUsage, err: fmt.Errorf(format, args...)}
+}
+
+// exitCode returns the code tmcp exits with when err ends the command.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var e *exitError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return exitFailure
+}
+
+// exit reports err, unless it is nil or quiet, and exits with its code.
+func exit(err error) {
+	var e *exitError
+	if err != nil && !(errors.As(err, &e) && e.quiet) {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+	os.Exit(exitCode(err))
+}
+
+// checkTaskfile reports a missing Taskfile or workspace manifest with
+// exitTaskfileNotFound.
+func checkTaskfile(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &exitError{code: exitTaskfileNotFound, err: fmt.Errorf("taskfile not found: %s", path)}
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// envPrefix starts the environment variables read with --from-env.
+	envPrefix = "TMCP_"
+	// envTaskfile is the variable holding the Taskfile with --from-env.
+	envTaskfile = envPrefix + "TASKFILE"
+	// envVarPrefix starts the variables setting one task variable each,
+	// e.g. TMCP_VAR_STAGE=dev for --var STAGE=dev.
+	envVarPrefix = envPrefix + "VAR_"
+)
+
+// envName returns the environment variable of a flag, e.g. TMCP_TOOL_PREFIX
+// for --tool-prefix.
+func envName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// applyEnv sets the flags of cmd that weren't given on the command line
+// from environment, as KEY=VALUE pairs, and returns the arguments to use:
+// args, or the Taskfile in TMCP_TASKFILE when there are none. List flags
+// take comma-separated values. Variables with the prefix that match no
+// flag are reported on stderr, as they are most likely typos.
+func applyEnv(cmd *cobra.Command, args []string, environ []string) ([]string, error) {
+	flags := make(map[string]*pflag.Flag)
+	given := make(map[string]bool)
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Name != "from-env" && f.Name != "help" {
+			flags[envName(f.Name)] = f
+			given[f.Name] = f.Changed
+		}
+	})
+
+	var vars, unknown []string
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(key, envPrefix) {
+			continue
+		}
+		if key == envTaskfile {
+			if len(args) == 0 && value != "" {
+				args = []string{value}
+			}
+			continue
+		}
+		if name, ok := strings.CutPrefix(key, envVarPrefix); ok && name != "" {
+			vars = append(vars, name+"="+value)
+			continue
+		}
+		f, ok := flags[key]
+		if !ok {
+			unknown = append(unknown, key)
+			continue
+		}
+		if given[f.Name] || value == "" {
+			continue
+		}
+		values := []string{value}
+		if strings.HasSuffix(f.Value.Type(), "Array") {
+			values = strings.Split(value, ",")
+		}
+		for _, v := range values {
+			if err := cmd.Flags().Set(f.Name, strings.TrimSpace(v)); err != nil {
+				return nil, usageErrorf("invalid %s %q: %v", key, value, err)
+			}
+		}
+	}
+	// Task variables given on the command line replace those of the
+	// environment, like other flags.
+	if _, ok := given["var"]; ok && !given["var"] {
+		sort.Strings(vars)
+		for _, pair := range vars {
+			if err := cmd.Flags().Set("var", pair); err != nil {
+				return nil, withExitCode(exitUsage, err)
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		fmt.Fprintf(os.Stderr, "Warning: ignoring %s, which set no flag of %s\n", strings.Join(unknown, ", "), cmd.Name())
+	}
+	return args, nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+	"github.com/spf13/cobra"
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph [Taskfile]",
+	Short: "Print the dependency graph of a Taskfile's tasks.",
+	Long: `The graph command prints the tasks of a Taskfile and their deps as a graph,
+with an arrow from each task to each task it runs first. Choose the format with
+--format: dot for Graphviz, or mermaid for Markdown that GitHub and many editors
+render:
+
+  tmcp graph --format dot | dot -Tsvg > tasks.svg
+
+Deps on tasks of included Taskfiles are shown, but not their own deps.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runGraph,
+}
+
+func init() {
+	graphCmd.Flags().String("task-bin", "task", "Path to the task binary (default: 'task')")
+	graphCmd.Flags().String("format", "dot", "Output format: dot or mermaid")
+	mustRegisterFlagCompletion(graphCmd, "format", cobra.FixedCompletions([]string{"dot", "mermaid"}, cobra.ShellCompDirectiveNoFileComp))
+	graphCmd.ValidArgsFunction = completeTaskfile
+	rootCmd.AddCommand(graphCmd)
+}
+
+func runGraph(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	if format != "dot" && format != "mermaid" {
+		return usageErrorf("unknown --format %q (want dot or mermaid)", format)
+	}
+	taskfilePath, err := taskfileArg(cmd, args)
+	if err != nil {
+		return err
+	}
+	if err := checkTaskfile(taskfilePath); err != nil {
+		return err
+	}
+	taskBinPath, err := taskBinFor(cmd, taskfilePath)
+	if err != nil {
+		return err
+	}
+	i, err := inspector.New(inspector.WithTaskfile(taskfilePath), inspector.WithTaskBin(taskBinPath))
+	if err != nil {
+		return withExitCode(exitInspectionFailed, err)
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+	// Deps are read from the Taskfile, so listing the tasks is enough.
+	config, err := i.List(ctx)
+	if err != nil {
+		return withExitCode(exitInspectionFailed, err)
+	}
+	graph := config.Graph()
+	if format == "mermaid" {
+		return graph.WriteMermaid(os.Stdout)
+	}
+	return graph.WriteDOT(os.Stdout)
+}
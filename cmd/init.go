@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init [dir]",
+	Short: "Create a starter Taskfile for tmcp.",
+	Long: `The init command writes a Taskfile.yml with example tasks that follow the
+conventions tmcp turns into good MCP tools: a desc, a summary with Usage and
+Required lines, declared required variables and x-mcp metadata. With --config
+it also writes a .tmcp.yml listing the config options, all commented out.
+
+Existing files are left alone unless --force is set.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		return runInit(cmd, dir)
+	},
+}
+
+func init() {
+	initCmd.Flags().Bool("config", false, "Also write a "+config.DefaultFileName+" config file")
+	initCmd.Flags().Bool("force", false, "Overwrite existing files")
+	initCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	}
+	rootCmd.AddCommand(initCmd)
+}
+
+func runInit(cmd *cobra.Command, dir string) error {
+	force, _ := cmd.Flags().GetBool("force")
+	withConfig, _ := cmd.Flags().GetBool("config")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	taskfilePath := filepath.Join(dir, "Taskfile.yml")
+	if !force {
+		// task picks any of these names, so don't add a second Taskfile.
+		for _, name := range config.TaskfileNames {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return fmt.Errorf("%s already exists; use --force to overwrite it", filepath.Join(dir, name))
+			}
+		}
+	}
+	files := []scaffoldFile{{taskfilePath, starterTaskfile}}
+	if withConfig {
+		configPath := filepath.Join(dir, config.DefaultFileName)
+		if _, err := os.Stat(configPath); err == nil && !force {
+			return fmt.Errorf("%s already exists; use --force to overwrite it", configPath)
+		} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		files = append(files, scaffoldFile{configPath, starterConfig})
+	}
+
+	for _, f := range files {
+		if err := os.WriteFile(f.path, []byte(f.content), 0644); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s\n", f.path)
+	}
+	fmt.Println()
+	fmt.Println("Next steps:")
+	steps := [][2]string{
+		{"tmcp inspect " + taskfilePath, "check the tools tmcp generates"},
+		{"tmcp view " + taskfilePath, "browse and try them"},
+		{"tmcp setup", "register tmcp with your MCP clients"},
+	}
+	width := 0
+	for _, step := range steps {
+		width = max(width, len(step[0]))
+	}
+	for _, step := range steps {
+		fmt.Printf("  %-*s  # %s\n", width, step[0], step[1])
+	}
+	return nil
+}
+
+// scaffoldFile is a file written by init.
+type scaffoldFile struct {
+	path    string
+	content string
+}
+
+// starterTaskfile shows the conventions tmcp reads: the first summary
+// lines describe the tool, "Usage:" lists its parameters as NAME=<...>,
+// "Required:" and requires.vars mark the ones the model must send.
+const starterTaskfile = `# https://taskfile.dev
+#
+# Every task becomes an MCP tool. tmcp reads:
+#   desc      a one-line description, shown in task lists
+#   summary   the tool description. After it, "Usage:" lists the parameters
+#             as NAME=<...> and "Required:" names the ones that must be set.
+#   requires  the variables task itself refuses to run without
+#   x-mcp     tmcp-only settings such as read_only, title, cache_ttl, async,
+#             stdin, outputs and params; task ignores them
+
+version: '3'
+
+tasks:
+  hello:
+    desc: Say hello
+    summary: |
+      Print a friendly greeting. Useful to check that the bridge works.
+      Usage: task hello
+      Required: None
+    silent: true
+    x-mcp:
+      read_only: true
+    cmds:
+      - echo "Hello from tmcp!"
+
+  greet:
+    desc: Greet someone by name
+    summary: |
+      Greet a person by name, optionally with a custom greeting.
+      Usage: task greet NAME=<name> GREETING=<greeting>
+      Required: NAME
+    silent: true
+    requires:
+      vars: [NAME]
+    vars:
+      GREETING: '{{.GREETING | default "Hello"}}'
+    x-mcp:
+      read_only: true
+    cmds:
+      - echo "{{.GREETING}}, {{.NAME}}!"
+
+  disk-usage:
+    desc: Show disk usage of a directory
+    summary: |
+      Show how much disk space a directory and its largest entries use.
+      Usage: task disk-usage DIR=<directory>
+      Required: DIR
+    silent: true
+    requires:
+      vars: [DIR]
+    x-mcp:
+      read_only: true
+      cache_ttl: 1m
+    cmds:
+      - du -sh "{{.DIR}}"
+      - du -sh "{{.DIR}}"/* 2>/dev/null | sort -rh | head -n 10
+
+  word-count:
+    desc: Count the words of a text
+    summary: |
+      Count the lines, words and characters of the text sent as TEXT.
+      Usage: task word-count TEXT=<text>
+      Required: TEXT
+    silent: true
+    x-mcp:
+      read_only: true
+      stdin: TEXT
+    cmds:
+      - wc
+
+  report:
+    desc: Write a report file
+    summary: |
+      Write a short system report to build/report.txt. The file is returned
+      as an MCP resource.
+      Usage: task report
+      Required: None
+    silent: true
+    x-mcp:
+      outputs: [build/report.txt]
+    cmds:
+      - mkdir -p build
+      - '{ date; uname -a; } > build/report.txt'
+      - echo "Report written to build/report.txt"
+`
+
+// starterConfig lists the config options with examples, commented out.
+const starterConfig = `# tmcp configuration, read by 'tmcp serve' from next to the Taskfile.
+# Every option is optional; uncomment what you need.
+
+# Minimum level of log messages: debug, info, warn or error.
+# log_level: info
+
+# Cap on tool calls per minute across all clients; 0 means unlimited.
+# rate_limit: 60
+
+# Limits per client session, so runaway agents are stopped. cost adds up
+# the tasks' x-mcp.cost estimates.
+# budget:
+#   max_calls: 200
+#   max_runtime: 30m
+#   max_cost: 50
+
+# Pass request metadata to tasks as variables the model can't override.
+# inject:
+#   REQUESTED_BY: client.name
+
+# Fetch secrets when a task runs and pass them as environment variables.
+# secrets:
+#   API_KEY: env:API_KEY
+#   DB_PASSWORD: vault:kv/db#password
+
+# Tasks that run for real under 'tmcp serve --safe' though not read-only.
+# safe:
+#   allow: ["report"]
+
+# Retry failing tasks.
+# retry:
+#   "deploy:*":
+#     attempts: 3
+#     backoff: 2s
+
+# For clients that misbehave with newer MCP features: answer with an older
+# protocol version, and don't announce resources, prompts or logging.
+# protocol_version: 2024-11-05
+# disable_capabilities: [resources]
+
+# Reuse successful results for identical arguments.
+# cache:
+#   "greet": 10m
+
+# Reject tool calls that match a deny expression.
+# policies:
+#   - name: no-root
+#     deny: task == "disk-usage" && args.DIR == "/"
+#     message: Scanning / takes too long.
+
+# Wait for a human to approve calls of some tasks.
+# approval:
+#   tasks: ["deploy*"]
+#   notify: [terminal]
+
+# Tell whoever supervises the agent when long tasks finish.
+# notify:
+#   after: 1m
+#   desktop: true
+#   slack: ${SLACK_WEBHOOK_URL}
+
+# Let clients schedule tasks on cron expressions.
+# scheduler:
+#   file: schedules.json
+
+# Named sets of settings, picked with 'tmcp serve --profile ci'.
+# profiles:
+#   ci:
+#     include: ["hello", "greet"]
+#     env: {CI: "true"}
+#     pass_env: [GITHUB_TOKEN]
+#     read_timeout: 10m
+#   prod:
+#     safe: true
+#     approval:
+#       tasks: ["report"]
+
+# Serve some tasks only on some machines. Every part of a condition must hold.
+# when:
+#   "report":
+#     env: {CI: "true"}
+#     files: [/etc/prod-bastion]
+#     platforms: [linux]
+
+# Tools that run several tasks in order with shared arguments, stopping at
+# the first that fails. A step's 'compensate' task undoes it when a later
+# step fails, e.g. compensate: undeploy on a deploy step.
+# pipelines:
+#   check:
+#     description: Say hello, then write a report.
+#     steps:
+#       - hello
+#       - task: greet
+#         args: {NAME: team}
+#       - report
+
+# Serve the tools of other MCP servers alongside the tasks.
+# upstreams:
+#   github:
+#     command: [npx, -y, "@modelcontextprotocol/server-github"]
+#     secrets:
+#       GITHUB_PERSONAL_ACCESS_TOKEN: env:GITHUB_TOKEN
+`
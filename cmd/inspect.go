@@ -1,42 +1,378 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+	"text/tabwriter"
 
-	"github.com/sandwichlabs/mcp-task-bridge/internal/inspector"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
 	"github.com/spf13/cobra"
 )
 
 var inspectCmd = &cobra.Command{
 	Use:   "inspect [Taskfile]",
 	Short: "Inspect a Taskfile and output its MCP configuration.",
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		taskBinPath, _ := cmd.Flags().GetString("task-bin")
-		inspector, err := inspector.New(
-			inspector.WithTaskfile(args[0]),
+	Long: `The inspect command prints the MCP configuration of a Taskfile.
+
+Output is a table on a terminal and JSON otherwise; choose explicitly with
+--output table|json|yaml.
+
+With --diff, it instead reports the tasks added, removed or changed since an
+earlier inspection, given as a saved JSON output or another Taskfile, and exits
+with status 1 when there are any.
+
+With --watch, it keeps running and re-inspects the Taskfile whenever it changes,
+printing the tasks that changed and their new descriptions.
+
+Without a Taskfile argument it inspects the Taskfile task would use, found in
+the current directory or the closest parent directory that has one.
+
+With --workspace, or when no Taskfile is given and tmcp.workspace.yaml exists in
+the current directory, it prints every tool of the workspace along with the task
+and Taskfile behind it.
+
+With --schema, it prints the JSON Schema of the JSON output instead.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runInspect,
+}
+
+func runInspect(cmd *cobra.Command, args []string) error {
+	if schema, _ := cmd.Flags().GetBool("schema"); schema {
+		if len(args) > 0 {
+			return usageErrorf("--schema doesn't take a Taskfile")
+		}
+		_, err := os.Stdout.Write(inspector.JSONSchema())
+		return err
+	}
+	wsPath := workspacePath(cmd, args)
+	taskName, _ := cmd.Flags().GetString("task")
+	if taskName != "" && wsPath != "" {
+		return usageErrorf("--task needs a Taskfile and can't be used with a workspace")
+	}
+	diffPath, _ := cmd.Flags().GetString("diff")
+	if diffPath != "" && (wsPath != "" || taskName != "") {
+		return usageErrorf("--diff needs a Taskfile and can't be used with a workspace or --task")
+	}
+	watch, _ := cmd.Flags().GetBool("watch")
+	if watch && (wsPath != "" || diffPath != "") {
+		return usageErrorf("--watch needs a Taskfile and can't be used with a workspace or --diff")
+	}
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return withExitCode(exitUsage, err)
+	}
+	var taskfilePath string
+	if wsPath == "" {
+		if taskfilePath, err = taskfileArg(cmd, args); err != nil {
+			return err
+		}
+		if err := checkTaskfile(taskfilePath); err != nil {
+			return err
+		}
+	}
+	taskBinPath, err := taskBinFor(cmd, taskfilePath)
+	if err != nil {
+		return err
+	}
+	var usagePattern *regexp.Regexp
+	if pattern, _ := cmd.Flags().GetString("usage-pattern"); pattern != "" {
+		if usagePattern, err = inspector.ParseUsagePattern(pattern); err != nil {
+			return usageErrorf("--usage-pattern: %v", err)
+		}
+	}
+	// Ctrl+C also stops a slow inspection, along with the task it runs.
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	var output any
+	var table func(io.Writer)
+	// reinspect repeats the inspection for --watch.
+	var reinspect func(context.Context) (*inspector.MCPConfig, error)
+	cache := inspectCache(cmd)
+	if wsPath != "" {
+		opts := []server.Option{server.WithLogOutput(io.Discard), server.WithUsagePattern(usagePattern)}
+		if cache != nil {
+			opts = append(opts, server.WithInspectCache(cache))
+		}
+		bridge, err := newWorkspaceBridge(ctx, cmd, wsPath, "tasks", opts...)
+		if err != nil {
+			return err
+		}
+		tools := workspaceTools(bridge)
+		output = tools
+		table = func(w io.Writer) { printToolTable(w, tools["Tools"]) }
+	} else {
+		inspectorOpts := []inspector.Option{
+			inspector.WithTaskfile(taskfilePath),
 			inspector.WithTaskBin(taskBinPath),
-		)
+		}
+		if cache != nil {
+			inspectorOpts = append(inspectorOpts, inspector.WithCache(cache))
+		}
+		if usagePattern != nil {
+			inspectorOpts = append(inspectorOpts, inspector.WithUsagePattern(usagePattern))
+		}
+		inspector, err := inspector.New(inspectorOpts...)
 		if err != nil {
-			fmt.Println("Error:", err)
-			return
+			return withExitCode(exitInspectionFailed, err)
+		}
+		if taskName != "" {
+			task, err := inspector.InspectTask(ctx, taskName)
+			if err != nil {
+				return withExitCode(exitInspectionFailed, err)
+			}
+			output = task
+			table = func(w io.Writer) { printTaskDetail(w, task) }
+			reinspect = inspectTaskConfig(inspector, taskName)
+		} else {
+			config, err := inspector.Inspect(ctx)
+			if err != nil {
+				return withExitCode(exitInspectionFailed, err)
+			}
+			output = config
+			table = func(w io.Writer) { printTaskTable(w, config.Tasks) }
+			reinspect = inspector.Inspect
 		}
-		config, err := inspector.Inspect()
+	}
+
+	var changed bool
+	if diffPath != "" {
+		baseline, err := loadBaseline(ctx, diffPath, taskBinPath, usagePattern)
 		if err != nil {
-			fmt.Println("Error:", err)
-			return
+			return err
 		}
-		jsonConfig, err := json.MarshalIndent(config, "", "  ")
+		diff := inspector.Diff(baseline, output.(*inspector.MCPConfig))
+		changed = !diff.Empty()
+		output = diff
+		table = func(w io.Writer) { printDiff(w, diff) }
+	}
+
+	switch format {
+	case "table":
+		table(os.Stdout)
+	case "yaml":
+		err = printYAML(os.Stdout, output)
+	default:
+		err = printJSON(os.Stdout, output)
+	}
+	if err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	if changed {
+		// Lets CI fail when the MCP surface changes.
+		return &exitError{code: exitFailure, err: errors.New("tasks changed"), quiet: true}
+	}
+
+	if watch {
+		var last *inspector.MCPConfig
+		switch v := output.(type) {
+		case *inspector.MCPConfig:
+			last = v
+		case *inspector.TaskDefinition:
+			last = &inspector.MCPConfig{Tasks: []inspector.TaskDefinition{*v}}
+		}
+		watchTaskfile(ctx, os.Stdout, taskfilePath, last, reinspect)
+	}
+	return nil
+}
+
+// inspectTaskConfig inspects a single task as a one-task config, so it
+// can be compared like a full inspection.
+func inspectTaskConfig(i *inspector.Inspector, taskName string) func(context.Context) (*inspector.MCPConfig, error) {
+	return func(ctx context.Context) (*inspector.MCPConfig, error) {
+		task, err := i.InspectTask(ctx, taskName)
 		if err != nil {
-			fmt.Println("Error marshalling config to JSON:", err)
-			return
+			return nil, err
 		}
-		fmt.Println(string(jsonConfig))
-	},
+		return &inspector.MCPConfig{Tasks: []inspector.TaskDefinition{*task}}, nil
+	}
+}
+
+// loadBaseline reads what --diff compares against: the JSON output of an
+// earlier inspect, or another Taskfile to inspect now. Errors carry the code
+// tmcp exits with.
+func loadBaseline(ctx context.Context, path string, taskBinPath string, usagePattern *regexp.Regexp) (*inspector.MCPConfig, error) {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var config inspector.MCPConfig
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, withExitCode(exitUsage, fmt.Errorf("parsing %s: %w", path, err))
+		}
+		return &config, nil
+	}
+	if err := checkTaskfile(path); err != nil {
+		return nil, err
+	}
+	opts := []inspector.Option{inspector.WithTaskfile(path), inspector.WithTaskBin(taskBinPath)}
+	if usagePattern != nil {
+		opts = append(opts, inspector.WithUsagePattern(usagePattern))
+	}
+	baseline, err := inspector.New(opts...)
+	if err != nil {
+		return nil, withExitCode(exitInspectionFailed, err)
+	}
+	config, err := baseline.Inspect(ctx)
+	if err != nil {
+		return nil, withExitCode(exitInspectionFailed, fmt.Errorf("inspecting %s: %w", path, err))
+	}
+	return config, nil
+}
+
+// printDiff reports the changes in the style of a unified diff: '+' for
+// added tasks and parameters, '-' for removed ones and '~' for changed tasks.
+func printDiff(w io.Writer, diff inspector.ConfigDiff) {
+	if diff.Empty() {
+		fmt.Fprintln(w, "No changes.")
+		return
+	}
+	// located appends where a task is defined, so editors can jump there.
+	located := func(name string) string {
+		if location := diff.Locations[name]; location != "" {
+			return name + " (" + location + ")"
+		}
+		return name
+	}
+	for _, name := range diff.Added {
+		fmt.Fprintf(w, "+ %s\n", located(name))
+	}
+	for _, name := range diff.Removed {
+		fmt.Fprintf(w, "- %s\n", name)
+	}
+	for _, change := range diff.Changed {
+		fmt.Fprintf(w, "~ %s\n", located(change.Task))
+		if len(change.Fields) > 0 {
+			fmt.Fprintf(w, "    changed: %s\n", strings.Join(change.Fields, ", "))
+		}
+		for _, param := range change.AddedParameters {
+			fmt.Fprintf(w, "    + parameter %s\n", param)
+		}
+		for _, param := range change.RemovedParameters {
+			fmt.Fprintf(w, "    - parameter %s\n", param)
+		}
+	}
+}
+
+// printTaskTable lists tasks one per row.
+func printTaskTable(w io.Writer, tasks []inspector.TaskDefinition) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TASK\tPARAMETERS\tREAD-ONLY\tDESCRIPTION")
+	for _, task := range tasks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", task.Name, parameterNames(task), yesNo(task.ReadOnly), summaryLine(task.Description))
+	}
+	tw.Flush()
+}
+
+// printToolTable lists the tools of a workspace one per row.
+func printToolTable(w io.Writer, tools []workspaceTool) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOOL\tTASK\tTASKFILE\tPARAMETERS\tDESCRIPTION")
+	for _, tool := range tools {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", tool.Tool, tool.Task.Name, tool.Taskfile, parameterNames(tool.Task), summaryLine(tool.Task.Description))
+	}
+	tw.Flush()
+}
+
+// printTaskDetail shows every field of a single task, ending with its raw
+// summary.
+func printTaskDetail(w io.Writer, task *inspector.TaskDefinition) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Task:\t%s\n", task.Name)
+	if task.Title != "" {
+		fmt.Fprintf(tw, "Title:\t%s\n", task.Title)
+	}
+	fmt.Fprintf(tw, "Usage:\t%s\n", task.Usage)
+	fmt.Fprintf(tw, "Parameters:\t%s\n", parameterNames(*task))
+	fmt.Fprintf(tw, "Read-only:\t%s\n", yesNo(task.ReadOnly))
+	if task.Dir != "" {
+		fmt.Fprintf(tw, "Dir:\t%s\n", task.Dir)
+	}
+	if len(task.Deps) > 0 {
+		fmt.Fprintf(tw, "Deps:\t%s\n", strings.Join(task.Deps, ","))
+	}
+	if task.Docs != "" {
+		fmt.Fprintf(tw, "Docs:\t%s\n", task.Docs)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\nDescription:\n%s\n\nSummary:\n%s", task.Description, task.Summary)
+}
+
+func parameterNames(task inspector.TaskDefinition) string {
+	if len(task.Parameters) == 0 {
+		return "-"
+	}
+	names := make([]string, len(task.Parameters))
+	for i, param := range task.Parameters {
+		names[i] = param.Name
+		if param.Default != nil {
+			names[i] += fmt.Sprintf("=%v", param.Default)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// maxTableDescription caps descriptions so rows fit on one line.
+const maxTableDescription = 60
+
+// summaryLine shortens a description to its first line for table output.
+func summaryLine(description string) string {
+	line, _, _ := strings.Cut(description, "\n")
+	if runes := []rune(line); len(runes) > maxTableDescription {
+		line = string(runes[:maxTableDescription-1]) + "…"
+	}
+	return line
+}
+
+// workspaceTool is one tool of a workspace in inspect's output.
+type workspaceTool struct {
+	Tool     string
+	Taskfile string
+	Task     inspector.TaskDefinition
+}
+
+// workspaceTools lists the tools a workspace bridge exposes.
+func workspaceTools(bridge *server.Bridge) map[string][]workspaceTool {
+	names := bridge.ToolNames()
+	tools := []workspaceTool{}
+	for i, task := range bridge.Config().Tasks {
+		tools = append(tools, workspaceTool{Tool: names[i].Tool, Taskfile: names[i].Taskfile, Task: task})
+	}
+	return map[string][]workspaceTool{"Tools": tools}
 }
 
 func init() {
 	inspectCmd.Flags().String("task-bin", "task", "Path to the task binary (default: 'task')")
+	inspectCmd.Flags().String("diff", "", "Report changes against an earlier inspect JSON output or another Taskfile; exits 1 when there are any")
+	inspectCmd.Flags().Bool("watch", false, "Re-inspect whenever the Taskfile changes and print what changed")
+	inspectCmd.Flags().Bool("no-cache", false, "Inspect the Taskfile afresh instead of reusing a cached inspection")
+	inspectCmd.Flags().String("task", "", "Inspect only this task, including its raw summary")
+	inspectCmd.Flags().StringP("output", "o", "", "Output format: table, json or yaml (default: table on a terminal, json otherwise)")
+	inspectCmd.Flags().String("usage-pattern", "", "Regular expression matching the usage line of task summaries, with the usage as its one capture group (default: a line starting with 'Usage:')")
+	inspectCmd.Flags().Bool("schema", false, "Print the JSON Schema of the JSON output and exit")
+	inspectCmd.Flags().String("workspace", "", "Inspect every Taskfile of a workspace manifest (default: tmcp.workspace.yaml when no Taskfile is given)")
+	inspectCmd.ValidArgsFunction = completeTaskfile
+	mustRegisterFlagCompletion(inspectCmd, "task", completeTaskName)
+	mustRegisterFlagCompletion(inspectCmd, "diff", cobra.FixedCompletions([]string{"json", "yml", "yaml"}, cobra.ShellCompDirectiveFilterFileExt))
+	mustRegisterFlagCompletion(inspectCmd, "output", cobra.FixedCompletions([]string{"table", "json", "yaml"}, cobra.ShellCompDirectiveNoFileComp))
+	mustRegisterFlagCompletion(inspectCmd, "workspace", completeYAMLFile)
 	rootCmd.AddCommand(inspectCmd)
 }
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/lsp"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+	"github.com/spf13/cobra"
+)
+
+// maxLSPOutput caps the task output shown in the editor. The end of the
+// output is kept, as that's where errors are.
+const maxLSPOutput = 2000
+
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Run a language server for Taskfiles over stdio.",
+	Long: `The lsp command speaks the Language Server Protocol over stdin and stdout,
+for editors to start on Taskfile.yml. For each Taskfile the editor opens, it
+reports the problems tmcp validate finds as diagnostics, shows the MCP tool
+of a task, as clients see it in tools/list, when hovering the task, and puts
+a "Run as MCP tool" code lens above each task that calls the tool through
+tmcp and shows its result.
+
+Taskfiles are inspected as saved, so diagnostics update on save. The config
+file is looked up next to each Taskfile, as for serve, and tools run with
+the same validation, policies and limits.`,
+	Args: cobra.NoArgs,
+	RunE: runLSP,
+}
+
+func init() {
+	lspCmd.Flags().String("task-bin", "task", "Path to the task binary (default: 'task')")
+	lspCmd.Flags().Bool("no-cache", false, "Inspect Taskfiles afresh instead of reusing cached inspections")
+	lspCmd.Flags().String("config", "", "Path to the tmcp config file (default: .tmcp.yml next to each Taskfile)")
+	lspCmd.Flags().Bool("safe", false, "Strict arguments, clean env, redacted output, and dry runs for tasks not read-only or in safe.allow")
+	mustRegisterFlagCompletion(lspCmd, "config", completeYAMLFile)
+	rootCmd.AddCommand(lspCmd)
+}
+
+func runLSP(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signal.NotifyContext(cmd.Context(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+	taskfiles := &lspTaskfiles{cmd: cmd, bridges: make(map[string]*server.Bridge)}
+	defer taskfiles.close()
+	// stdout carries the protocol; logs go to stderr, which editors keep.
+	if err := lsp.New(taskfiles.analyze, taskfiles.run).Serve(ctx, os.Stdin, os.Stdout); err != nil {
+		return withExitCode(exitExecutionFailed, err)
+	}
+	return nil
+}
+
+// lspTaskfiles keeps a bridge of each Taskfile open in the editor, built
+// anew when the Taskfile is saved.
+type lspTaskfiles struct {
+	cmd *cobra.Command
+
+	mu      sync.Mutex
+	bridges map[string]*server.Bridge
+}
+
+// analyze inspects the Taskfile at path into the hovers, code lenses and
+// diagnostics of its tasks.
+func (t *lspTaskfiles) analyze(ctx context.Context, path string) (*lsp.Analysis, error) {
+	bridge, err := t.open(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	analysis := &lsp.Analysis{}
+	// Tools of included Taskfiles and upstream servers aren't in this file.
+	own := &inspector.MCPConfig{}
+	tools, tasks := bridge.Tools(), bridge.Config().Tasks
+	for i, name := range bridge.ToolNames() {
+		if name.Taskfile != abs {
+			continue
+		}
+		own.Tasks = append(own.Tasks, tasks[i])
+		analysis.Tasks = append(analysis.Tasks, lsp.Task{
+			Name:  name.Task,
+			Line:  tasks[i].Line,
+			Hover: toolHover(tools[i]),
+			Lens:  "Run as MCP tool",
+		})
+	}
+	problems, err := inspector.Validate(path, own)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range problems {
+		analysis.Diagnostics = append(analysis.Diagnostics, lsp.Diagnostic{
+			Line:    p.Line,
+			Error:   p.Severity == inspector.SeverityError,
+			Message: p.Task + ": " + p.Message,
+		})
+	}
+	return analysis, nil
+}
+
+// open inspects the Taskfile at path into a bridge, replacing the one of
+// its previous save.
+func (t *lspTaskfiles) open(ctx context.Context, path string) (*server.Bridge, error) {
+	taskBinPath, err := taskBinFor(t.cmd, path)
+	if err != nil {
+		return nil, err
+	}
+	configPath, _ := t.cmd.Flags().GetString("config")
+	cfg, err := config.Load(configPath, path)
+	if err != nil {
+		return nil, err
+	}
+	bridge, err := newBridge(ctx, path, taskBinPath, "tasks", serverOptions(t.cmd, cfg)...)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if old, ok := t.bridges[path]; ok {
+		old.Close()
+	}
+	t.bridges[path] = bridge
+	return bridge, nil
+}
+
+// run calls the tool of task in the Taskfile at path without arguments.
+func (t *lspTaskfiles) run(ctx context.Context, path string, task string) (string, error) {
+	t.mu.Lock()
+	bridge, ok := t.bridges[path]
+	t.mu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("%s isn't open", path)
+	}
+	tool := ""
+	for _, name := range bridge.ToolNames() {
+		if name.Task == task {
+			tool = name.Tool
+			break
+		}
+	}
+	if tool == "" {
+		return "", fmt.Errorf("no tool for task %s", task)
+	}
+	client, err := connectBridge(ctx, bridge)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+	request := mcp.CallToolRequest{}
+	request.Params.Name = tool
+	result, err := client.CallTool(ctx, request)
+	if err != nil {
+		return "", err
+	}
+	output := resultText(result)
+	if len(output) > maxLSPOutput {
+		output = "…" + strings.ToValidUTF8(output[len(output)-maxLSPOutput:], "")
+	}
+	if result.IsError {
+		return "", errors.New(output)
+	}
+	return fmt.Sprintf("%s finished:\n%s", tool, output), nil
+}
+
+func (t *lspTaskfiles) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, bridge := range t.bridges {
+		bridge.Close()
+	}
+}
+
+// toolHover renders tool as Markdown for hovers.
+func toolHover(tool mcp.Tool) string {
+	data, err := json.MarshalIndent(tool, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("**MCP tool `%s`**\n\n```json\n%s\n```", tool.Name, data)
+}
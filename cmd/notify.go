@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
+)
+
+// notifyTimeout bounds how long posting a notification may take.
+const notifyTimeout = 10 * time.Second
+
+// completionNotifier returns the notifier for the configured notification
+// channels, or nil when there are none. ${NAME} in the URLs is replaced by
+// the environment variable NAME, so webhook secrets can stay out of the
+// config file.
+func completionNotifier(cfg config.NotifyConfig) server.CompletionNotifier {
+	var notifiers []server.CompletionNotifier
+	if cfg.Desktop {
+		notifiers = append(notifiers, func(c server.Completion) {
+			if err := showDesktopNotification(describeCompletion(c)); err != nil {
+				slog.Warn("Could not show desktop notification", "task", c.Task, "error", err)
+			}
+		})
+	}
+	if target, ok := webhookURL(cfg.Slack); ok {
+		notifiers = append(notifiers, func(c server.Completion) {
+			postNotification(target, map[string]string{"text": describeCompletion(c)}, c)
+		})
+	}
+	for _, raw := range cfg.Webhooks {
+		if target, ok := webhookURL(raw); ok {
+			notifiers = append(notifiers, func(c server.Completion) {
+				postNotification(target, struct {
+					server.Completion
+					Text string `json:"text"`
+				}{c, describeCompletion(c)}, c)
+			})
+		}
+	}
+	if len(notifiers) == 0 {
+		return nil
+	}
+	return func(c server.Completion) {
+		for _, notify := range notifiers {
+			go notify(c)
+		}
+	}
+}
+
+// webhookURL expands the environment variables in a notification URL and
+// reports whether the result is an http(s) URL. Other URLs are logged and
+// skipped.
+func webhookURL(raw string) (string, bool) {
+	if raw == "" {
+		return "", false
+	}
+	target := os.ExpandEnv(raw)
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		// The expanded URL may hold a secret, so only raw is logged.
+		slog.Warn("Ignoring notify URL, not an http(s) URL once expanded", "url", raw)
+		return "", false
+	}
+	return target, true
+}
+
+// describeCompletion summarizes a finished task on one line.
+func describeCompletion(c server.Completion) string {
+	outcome := "finished"
+	if c.ExitCode != 0 {
+		outcome = fmt.Sprintf("failed (exit code %d)", c.ExitCode)
+	}
+	return fmt.Sprintf("%s: %s %s after %s", c.Server, c.Task, outcome, c.Duration().Round(time.Second))
+}
+
+// postNotification POSTs body as JSON to target, logging failures.
+func postNotification(target string, body any, c server.Completion) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		slog.Warn("Could not encode notification", "task", c.Task, "error", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		slog.Warn("Could not send notification", "task", c.Task, "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// Errors quote the URL, which may hold a secret, so only the host
+		// is logged.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		slog.Warn("Could not send notification", "task", c.Task, "host", req.URL.Host, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Warn("Notification was rejected", "task", c.Task, "host", req.URL.Host, "status", resp.Status)
+	}
+}
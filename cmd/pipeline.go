@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"sort"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
+)
+
+// pipelines maps the config's pipelines to the server's, in name order.
+func pipelines(cfg *config.Config) []server.Pipeline {
+	names := make([]string, 0, len(cfg.Pipelines))
+	for name := range cfg.Pipelines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]server.Pipeline, 0, len(names))
+	for _, name := range names {
+		p := cfg.Pipelines[name]
+		pipeline := server.Pipeline{Name: name, Description: p.Description}
+		for _, step := range p.Steps {
+			s := server.PipelineStep{Task: step.Task, Compensate: step.Compensate}
+			if len(step.Args) > 0 {
+				s.Args = make(map[string]any, len(step.Args))
+				for key, value := range step.Args {
+					s.Args[key] = value
+				}
+			}
+			pipeline.Steps = append(pipeline.Steps, s)
+		}
+		list = append(list, pipeline)
+	}
+	return list
+}
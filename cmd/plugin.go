@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
+)
+
+// plugins maps the plugins of the config file to bridge plugins, in name
+// order.
+func plugins(cfg *config.Config) []server.Plugin {
+	names := make([]string, 0, len(cfg.Plugins))
+	for name := range cfg.Plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]server.Plugin, 0, len(names))
+	for _, name := range names {
+		p := cfg.Plugins[name]
+		list = append(list, server.Plugin{
+			Name:      name,
+			Command:   p.Command,
+			Env:       p.Env,
+			Arguments: slices.Contains(p.Hooks, config.PluginHookArguments),
+			Result:    slices.Contains(p.Hooks, config.PluginHookResult),
+			Tools:     p.Tools,
+		})
+	}
+	return list
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
+	"github.com/spf13/cobra"
+)
+
+// applyProfile selects the config profile named by --profile, if any. The
+// profile's timeouts and safe mode become the values of the flags not
+// given on the command line; serverOptions maps the rest.
+func applyProfile(cmd *cobra.Command, cfg *config.Config) error {
+	name, _ := cmd.Flags().GetString("profile")
+	if name == "" {
+		return nil
+	}
+	if err := cfg.SelectProfile(name); err != nil {
+		return withExitCode(exitUsage, err)
+	}
+	p := cfg.Profile
+	defaults := map[string]string{}
+	if p.HandshakeTimeout > 0 {
+		defaults["handshake-timeout"] = p.HandshakeTimeout.String()
+	}
+	if p.ReadTimeout > 0 {
+		defaults["read-timeout"] = p.ReadTimeout.String()
+	}
+	if p.Safe {
+		defaults["safe"] = strconv.FormatBool(true)
+	}
+	for flag, value := range defaults {
+		if cmd.Flags().Changed(flag) {
+			continue
+		}
+		if err := cmd.Flags().Set(flag, value); err != nil {
+			return usageErrorf("profile %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// profileOptions maps the parts of the selected profile that have no flag.
+func profileOptions(cfg *config.Config) []server.Option {
+	p := cfg.Profile
+	if p == nil {
+		return nil
+	}
+	opts := []server.Option{server.WithProfileFilter(p.Include, p.Exclude), server.WithEnv(p.Env)}
+	if len(p.PassEnv) > 0 {
+		opts = append(opts, server.WithPassEnv(p.PassEnv))
+	}
+	return opts
+}
+
+// completeProfile completes the profiles of the config file.
+func completeProfile(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	configPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.Load(configPath, completionTaskfile(args))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return matchingPrefix(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
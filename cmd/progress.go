@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// spinnerFrames are drawn in turn while inspecting.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// inspectSpinner draws a spinner with the progress of an inspection on a
+// terminal, e.g. "⠋ Inspecting task 12/40 (lint)", until stop is called,
+// which clears it.
+func inspectSpinner(w io.Writer) (progress func(inspector.Progress), stop func()) {
+	var (
+		mu      sync.Mutex
+		current inspector.Progress
+		frame   int
+	)
+	draw := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		frame = (frame + 1) % len(spinnerFrames)
+		if current.Total == 0 {
+			fmt.Fprintf(w, "\r\033[K%s Listing tasks", spinnerFrames[frame])
+			return
+		}
+		fmt.Fprintf(w, "\r\033[K%s Inspecting task %d/%d (%s) in %s", spinnerFrames[frame], current.N, current.Total, current.Task, filepath.Base(filepath.Dir(current.Taskfile)))
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				fmt.Fprint(w, "\r\033[K")
+				return
+			case <-ticker.C:
+				draw()
+			}
+		}
+	}()
+	progress = func(p inspector.Progress) {
+		mu.Lock()
+		current = p
+		mu.Unlock()
+	}
+	stop = func() {
+		close(done)
+		<-finished
+	}
+	return progress, stop
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/release"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// repositoryURL is where tmcp is developed and released.
+	repositoryURL = "https://github.com/sandwichlabs/mcp-task-bridge"
+	// license is the SPDX identifier of tmcp's license.
+	license = "GPL-3.0-only"
+)
+
+var releaseManifestCmd = &cobra.Command{
+	Use:   "release-manifest",
+	Short: "Print the package manager metadata of a release build.",
+	Long: `The release-manifest command prints, as JSON, what package managers such as
+Homebrew and Scoop need to install this release: the version, commit and build
+date of this binary, and the name, platform, download URL and SHA-256 checksum
+of every archive in the goreleaser dist directory.
+
+Run it with the binary built for the release, e.g. after 'goreleaser release':
+
+  ./dist/tmcp_linux_amd64_v1/tmcp release-manifest --dist dist`,
+	Args:   cobra.NoArgs,
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if build.Version == "dev" {
+			return usageErrorf("this binary has no version; build it with -ldflags \"-X main.version=...\"")
+		}
+		dist, _ := cmd.Flags().GetString("dist")
+		baseURL, _ := cmd.Flags().GetString("base-url")
+		if baseURL == "" {
+			// goreleaser strips the v from the tag for the version.
+			baseURL = repositoryURL + "/releases/download/v" + strings.TrimPrefix(build.Version, "v")
+		}
+		artifacts, err := release.Artifacts(dist, "tmcp", baseURL)
+		if err != nil {
+			return err
+		}
+		return printJSON(os.Stdout, release.Manifest{
+			Name:        "tmcp",
+			Version:     build.Version,
+			Commit:      build.Commit,
+			Date:        build.Date,
+			Description: strings.TrimSuffix(rootCmd.Short, "."),
+			Homepage:    repositoryURL,
+			License:     license,
+			Artifacts:   artifacts,
+		})
+	},
+}
+
+func init() {
+	releaseManifestCmd.Flags().String("dist", "dist", "Directory with the release archives and checksums.txt")
+	releaseManifestCmd.Flags().String("base-url", "", "URL the archives are downloaded from (default: the GitHub release of this version)")
+	rootCmd.AddCommand(releaseManifestCmd)
+}
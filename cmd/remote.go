@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/remote"
+	"github.com/spf13/cobra"
+)
+
+// isRemoteArg reports whether args name a remote Taskfile. Its tasks run
+// from, and its config file is looked up in, the current directory rather
+// than the cache it is fetched to.
+func isRemoteArg(args []string) bool {
+	return len(args) > 0 && remote.IsRef(args[0])
+}
+
+// fetchTaskfile fetches a remote Taskfile into the cache and returns its
+// local path.
+func fetchTaskfile(cmd *cobra.Command, ref string) (string, error) {
+	parsed, err := remote.Parse(ref)
+	if err != nil {
+		return "", withExitCode(exitUsage, err)
+	}
+	cacheDir, err := remote.DefaultCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locating the cache: %w", err)
+	}
+	fetcher := &remote.Fetcher{CacheDir: cacheDir}
+	path, err := fetcher.Fetch(cmd.Context(), parsed)
+	if err != nil {
+		return "", withExitCode(exitTaskfileNotFound, err)
+	}
+	return path, nil
+}
+
+// configAnchor returns the path next to which the config file of the
+// Taskfile at taskfilePath is looked up.
+func configAnchor(args []string, taskfilePath string) string {
+	if isRemoteArg(args) {
+		return filepath.Base(taskfilePath)
+	}
+	return taskfilePath
+}
+
+// localTaskfileArg is taskfileArg for commands that need a Taskfile on disk.
+func localTaskfileArg(cmd *cobra.Command, args []string) (string, error) {
+	if isRemoteArg(args) {
+		return "", usageErrorf("%s needs a local Taskfile, not %s", cmd.CommandPath(), args[0])
+	}
+	return taskfileArg(cmd, args)
+}
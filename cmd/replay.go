@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
+	"github.com/spf13/cobra"
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <recording> [Taskfile]",
+	Short: "Re-run tool calls recorded with serve --record.",
+	Long: `The replay command re-runs every tool call of a recording made with
+serve --record against a Taskfile, in order, and reports the calls whose
+outcome changed: those that failed before and succeed now, or the other way
+around. With --strict a call also changes when its output differs.
+
+This checks a change to a Taskfile against the calls agents really made. The
+command exits with 1 when any call changed.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runReplay,
+}
+
+func init() {
+	replayCmd.Flags().String("task-bin", "task", "Path to the task binary (default: 'task')")
+	replayCmd.Flags().String("config", "", "Path to the tmcp config file (default: .tmcp.yml next to the Taskfile)")
+	replayCmd.Flags().String("tool-prefix", "", "Prefix for every tool name, as the recording server used")
+	replayCmd.Flags().Bool("strict", false, "Also report calls whose output differs from the recording")
+	mustRegisterFlagCompletion(replayCmd, "config", completeYAMLFile)
+	rootCmd.AddCommand(replayCmd)
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	f, err := os.Open(args[0])
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+	records, err := server.ReadRecords(f)
+	f.Close()
+	if err != nil {
+		return usageErrorf("reading %s: %v", args[0], err)
+	}
+
+	taskfileArgs := args[1:]
+	taskfilePath, err := taskfileArg(cmd, taskfileArgs)
+	if err != nil {
+		return err
+	}
+	var opts []server.Option
+	if isRemoteArg(taskfileArgs) {
+		opts = append(opts, server.WithDir("."))
+	}
+	client, closeClient, err := bridgeClient(cmd, taskfilePath, configAnchor(taskfileArgs, taskfilePath), opts...)
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	strict, _ := cmd.Flags().GetBool("strict")
+	changed := 0
+	for i, record := range records {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = record.Tool
+		request.Params.Arguments = record.Arguments
+		if len(record.Meta) > 0 {
+			request.Params.Meta = &mcp.Meta{AdditionalFields: record.Meta}
+		}
+		start := time.Now()
+		got := server.CallRecord{Tool: record.Tool}
+		result, err := client.CallTool(cmd.Context(), request)
+		if err != nil {
+			got.IsError, got.Result = true, err.Error()
+		} else {
+			got.IsError, got.Result = result.IsError, replayText(result)
+		}
+		took := time.Since(start).Round(time.Millisecond)
+
+		diff := replayDiff(record, got, strict)
+		if diff == "" {
+			fmt.Printf("ok      %d %s (%s)\n", i+1, record.Tool, took)
+			continue
+		}
+		changed++
+		fmt.Printf("CHANGED %d %s (%s): %s\n", i+1, record.Tool, took, diff)
+	}
+	fmt.Printf("\n%d calls, %d changed\n", len(records), changed)
+	if changed > 0 {
+		return &exitError{code: exitFailure, err: errors.New("calls changed"), quiet: true}
+	}
+	return nil
+}
+
+// bridgeClient serves the Taskfile in process, with the config file found
+// next to anchor, and connects an MCP client to it. The returned func
+// closes both.
+func bridgeClient(cmd *cobra.Command, taskfilePath string, anchor string, opts ...server.Option) (*mcpclient.Client, func(), error) {
+	taskBinPath, err := resolveTaskBin(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	configPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.Load(configPath, anchor)
+	if err != nil {
+		return nil, nil, withExitCode(exitUsage, err)
+	}
+	// The request log would mix with the report.
+	opts = append(append(serverOptions(cmd, cfg), server.WithLogOutput(io.Discard)), opts...)
+	bridge, err := newBridge(cmd.Context(), taskfilePath, taskBinPath, "tasks", opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := connectBridge(cmd.Context(), bridge)
+	if err != nil {
+		bridge.Close()
+		return nil, nil, withExitCode(exitExecutionFailed, fmt.Errorf("connecting to the bridge: %w", err))
+	}
+	return client, func() {
+		client.Close()
+		bridge.Close()
+	}, nil
+}
+
+// replayDiff describes how a replayed call differs from its recording, or
+// returns "" when it doesn't.
+func replayDiff(recorded server.CallRecord, got server.CallRecord, strict bool) string {
+	switch {
+	case recorded.IsError && !got.IsError:
+		return "failed before, succeeds now"
+	case !recorded.IsError && got.IsError:
+		return "succeeded before, fails now: " + strings.SplitN(got.Result, "\n", 2)[0]
+	case strict && recorded.Result != got.Result:
+		return "output differs"
+	}
+	return ""
+}
+
+// replayText joins the text content of a tool result, as recorded.
+func replayText(result *mcp.CallToolResult) string {
+	var text string
+	for _, content := range result.Content {
+		if c, ok := content.(mcp.TextContent); ok {
+			if text != "" {
+				text += "\n"
+			}
+			text += c.Text
+		}
+	}
+	return text
+}
@@ -4,34 +4,48 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
 	"github.com/spf13/cobra"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "tmcp [Taskfile]",
 	Short: "A CLI to bridge Taskfiles with MCP.",
-	Long:  `tmcp is a command-line tool that evaluates a Taskfile and exposes its tasks as MCP functions.`,
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		servername, _ := cmd.Flags().GetString("name")
-		if servername == "" {
-			servername = "tasks"
-		}
-		taskBinPath, _ := cmd.Flags().GetString("task-bin")
+	Long: `tmcp is a command-line tool that evaluates a Taskfile and exposes its tasks as MCP functions.
 
-		server.Run(args[0], taskBinPath, servername)
+Use 'tmcp serve [Taskfile]' to start the MCP server. Passing the Taskfile
+directly to tmcp still works but is deprecated.
+
+Exit codes:
+  0  success
+  1  other failures, and changes found by 'inspect --diff'
+  2  invalid arguments, flags or config file
+  3  Taskfile or workspace manifest not found
+  4  task binary missing or inspecting the Taskfile failed
+  5  serving MCP or running the agent failed
+  6  'agent --prompt' gave no answer within --max-iterations`,
+	Args: cobra.ExactArgs(1),
+	// Once a command runs, its errors are not about usage.
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		cmd.SilenceUsage = true
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(os.Stderr, "Warning: 'tmcp [Taskfile]' is deprecated, use 'tmcp serve [Taskfile]' instead.")
+		return runServe(cmd, args)
 	},
+	// Execute reports errors along with their exit code.
+	SilenceErrors: true,
 }
 
 func init() {
-	rootCmd.Flags().String("name", "", "Name of the MCP server (default: 'tasks')")
-	rootCmd.Flags().String("task-bin", "task", "Path to the task binary (default: 'task')")
+	addServeFlags(rootCmd.Flags())
+	addServeCompletions(rootCmd)
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	cmd, err := rootCmd.ExecuteC()
+	if err != nil && !cmd.SilenceUsage {
+		// The command never ran, so its arguments or flags were invalid.
+		err = withExitCode(exitUsage, err)
 	}
+	exit(err)
 }
@@ -0,0 +1,579 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve [Taskfile | directory]",
+	Short: "Serve a Taskfile's tasks as MCP tools.",
+	Long: `The serve command inspects a Taskfile and starts an MCP server exposing each task as a tool.
+
+Without a Taskfile argument it serves the Taskfile task would use: the first of
+Taskfile.yml, taskfile.yml, Taskfile.yaml, taskfile.yaml (or their .dist
+variants) in the current directory or the closest parent directory.
+
+The Taskfile can also be remote: an http(s) URL, or a git repository as
+git::<repo>//<path>?ref=<rev>. It is fetched into the user cache directory
+(the cached copy is used when offline) and its tasks run from the current
+directory. Append ?checksum=sha256:<hex> to pin its contents.
+
+With --workspace, or when no Taskfile is given and tmcp.workspace.yaml exists in
+the current directory, every Taskfile listed in the workspace manifest is served
+as one MCP server.
+
+With --recursive, every Taskfile under the directory given (or the current
+directory) is served as one MCP server, as in a monorepo where each package has
+its own. Tools are prefixed with their Taskfile's directory relative to it,
+e.g. packages_api_build, and tasks run from their Taskfile's directory.
+Hidden, node_modules and vendor directories are skipped.
+
+With --all, every server defined under 'servers' in the config file is served
+over one HTTP listener, each under its own base path (/<name>/mcp).
+
+With --from-env, as in a container entrypoint, the Taskfile is read from
+$TMCP_TASKFILE and every flag not given on the command line from
+$TMCP_<FLAG>, e.g. $TMCP_TOOL_PREFIX for --tool-prefix. List flags take
+comma-separated values, and $TMCP_VAR_<NAME> sets the task variable NAME.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runServe,
+}
+
+func init() {
+	addServeFlags(serveCmd.Flags())
+	serveCmd.Flags().Bool("all", false, "Serve every server defined in the config file over HTTP")
+	serveCmd.Flags().Bool("recursive", false, "Serve every Taskfile under the directory given (default: the current directory)")
+	serveCmd.Flags().Bool("from-env", false, "Read the Taskfile from $TMCP_TASKFILE and unset flags from $TMCP_<FLAG>, e.g. $TMCP_TOOL_PREFIX, for container entrypoints")
+	serveCmd.ValidArgsFunction = completeTaskfile
+	addServeCompletions(serveCmd)
+	rootCmd.AddCommand(serveCmd)
+}
+
+// addServeFlags registers the server flags. They live on serve and, for
+// backwards compatibility, on the deprecated root command.
+func addServeFlags(flags *pflag.FlagSet) {
+	flags.String("name", "", "Name of the MCP server (default: 'tasks')")
+	flags.String("task-bin", "task", "Path to the task binary (default: 'task')")
+	flags.String("workspace", "", "Serve every Taskfile of a workspace manifest (default: tmcp.workspace.yaml when no Taskfile is given)")
+	flags.String("config", "", "Path to the tmcp config file (default: .tmcp.yml next to the Taskfile)")
+	flags.String("profile", "", "Serve with a profile from the config file's profiles, e.g. ci")
+	flags.String("dir", "", "Working directory tasks run from (default: the Taskfile's directory)")
+	flags.StringArray("var", nil, "Task variable KEY=VALUE passed to every task (repeatable)")
+	flags.StringArray("dotenv", nil, "Environment file whose variables every task gets (repeatable)")
+	flags.String("transport", "stdio", "Transport to serve MCP over (stdio, http)")
+	flags.String("listen", "127.0.0.1:8080", "Address to listen on for the http transport, or unix:///path/to.sock for a Unix socket")
+	flags.String("listen-mode", "0600", "Permissions of the Unix socket --listen creates, which decide who may connect")
+	flags.Duration("handshake-timeout", 30*time.Second, "Drop clients that do not complete the initialize handshake in time (0 disables)")
+	flags.Duration("read-timeout", 0, "Drop clients that send no message for this long after initializing (0 disables)")
+	flags.String("admin-listen", "", "Serve the admin API on this address, e.g. 127.0.0.1:8081 (default: disabled)")
+	flags.String("pprof-addr", "", "Serve Go profiles at /debug/pprof/ on this address, e.g. 127.0.0.1:6060 (default: disabled)")
+	flags.String("admin-token", "", "Bearer token for the admin API (default: $TMCP_ADMIN_TOKEN)")
+	flags.String("hook-token", "", "Enable webhooks at /hooks/<tool> over http, authenticated with this token (default: $TMCP_HOOK_TOKEN)")
+	flags.String("tool-prefix", "", "Prefix for every tool name, e.g. 'api_'")
+	flags.Bool("auto-json", false, "Return the output of tasks that print a JSON object or array as structured content too")
+	flags.Bool("safe", false, "Strict arguments, clean env, redacted output, and dry runs for tasks not read-only or in safe.allow")
+	flags.String("record", "", "Append every tool call and its result to this file as JSON lines, for tmcp replay")
+	flags.Bool("no-cache", false, "Inspect the Taskfile afresh instead of reusing a cached inspection")
+	flags.Bool("lazy", false, "Start from a single task listing and inspect each task's parameters when it is first listed or called")
+	flags.String("protocol-version", "", "Answer every client with this MCP protocol version instead of the one it asks for (default: protocol_version from the config)")
+	flags.StringArray("disable-capability", nil, "Server capability not to announce or serve: resources, prompts or logging (repeatable)")
+	flags.Bool("sample-descriptions", false, "Have the model of stdio clients that support sampling describe tasks without a useful desc")
+}
+
+// addServeCompletions completes the values of the server flags of cmd.
+func addServeCompletions(cmd *cobra.Command) {
+	mustRegisterFlagCompletion(cmd, "config", completeYAMLFile)
+	mustRegisterFlagCompletion(cmd, "workspace", completeYAMLFile)
+	mustRegisterFlagCompletion(cmd, "profile", completeProfile)
+	mustRegisterFlagCompletion(cmd, "transport", cobra.FixedCompletions([]string{"stdio", "http"}, cobra.ShellCompDirectiveNoFileComp))
+	mustRegisterFlagCompletion(cmd, "protocol-version", cobra.FixedCompletions(server.ProtocolVersions(), cobra.ShellCompDirectiveNoFileComp))
+	mustRegisterFlagCompletion(cmd, "disable-capability", cobra.FixedCompletions(server.Capabilities, cobra.ShellCompDirectiveNoFileComp))
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	if fromEnv, _ := cmd.Flags().GetBool("from-env"); fromEnv {
+		var err error
+		if args, err = applyEnv(cmd, args, os.Environ()); err != nil {
+			return err
+		}
+	}
+	if err := checkNegotiationFlags(cmd); err != nil {
+		return err
+	}
+	all, _ := cmd.Flags().GetBool("all")
+	if all {
+		return serveAll(cmd)
+	}
+	// The config file is looked up next to the Taskfile or workspace manifest,
+	// or in the directory searched with --recursive.
+	var discovered *config.Workspace
+	var wsPath, anchor, taskfilePath string
+	if recursive, _ := cmd.Flags().GetBool("recursive"); recursive {
+		var err error
+		if discovered, err = discoverWorkspace(cmd, args); err != nil {
+			return err
+		}
+		anchor = filepath.Join(dirArg(args), config.DefaultFileName)
+	} else {
+		wsPath = workspacePath(cmd, args)
+		anchor = wsPath
+	}
+	if discovered == nil && wsPath == "" {
+		var err error
+		if taskfilePath, err = taskfileArg(cmd, args); err != nil {
+			return err
+		}
+		if err := checkTaskfile(taskfilePath); err != nil {
+			return err
+		}
+		anchor = configAnchor(args, taskfilePath)
+	}
+
+	servername, _ := cmd.Flags().GetString("name")
+	if servername == "" {
+		servername = "tasks"
+	}
+	taskBinPath, err := taskBinFor(cmd, taskfilePath)
+	if err != nil {
+		return err
+	}
+
+	configPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.Load(configPath, anchor)
+	if err != nil {
+		return withExitCode(exitUsage, err)
+	}
+	if err := applyProfile(cmd, cfg); err != nil {
+		return err
+	}
+	dir, _ := cmd.Flags().GetString("dir")
+	if dir == "" && isRemoteArg(args) {
+		dir = "."
+	}
+	globals, err := globalOptions(cmd)
+	if err != nil {
+		return err
+	}
+	opts := append(append(serverOptions(cmd, cfg), globals...), server.WithDir(dir))
+	record, stopRecording, err := recordOptions(cmd)
+	if err != nil {
+		return err
+	}
+	defer stopRecording()
+	opts = append(opts, record...)
+
+	transport, _ := cmd.Flags().GetString("transport")
+	if transport != "stdio" && transport != "http" {
+		return usageErrorf("unknown transport %q (want stdio or http)", transport)
+	}
+	httpOpts, err := httpOptions(cmd)
+	if err != nil {
+		return err
+	}
+	applyLogLevel(cfg)
+	// Set up before inspecting, so Ctrl+C also stops a slow inspection.
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+	startPprof(ctx, cmd)
+	var bridge *server.Bridge
+	switch {
+	case discovered != nil:
+		bridge, err = workspaceBridge(ctx, cmd, discovered, servername, opts...)
+	case wsPath != "":
+		bridge, err = newWorkspaceBridge(ctx, cmd, wsPath, servername, opts...)
+	default:
+		bridge, err = newBridge(ctx, taskfilePath, taskBinPath, servername, opts...)
+	}
+	if err != nil {
+		return err
+	}
+	defer bridge.Close()
+	mounts := []server.Mount{{Bridge: bridge}}
+
+	if err := startAdmin(ctx, cmd, cfg, mounts); err != nil {
+		return err
+	}
+	startSchedules(ctx, mounts)
+
+	if transport == "stdio" {
+		err = bridge.ServeStdio(ctx)
+	} else {
+		err = server.ServeHTTP(ctx, httpOpts, mounts)
+	}
+	if err != nil {
+		return withExitCode(exitExecutionFailed, fmt.Errorf("serving MCP: %w", err))
+	}
+	return nil
+}
+
+// newBridge checks that the Taskfile exists and inspects it into a bridge,
+// giving up when ctx is done. Errors carry the code tmcp exits with.
+func newBridge(ctx context.Context, taskfilePath string, taskBinPath string, serverName string, opts ...server.Option) (*server.Bridge, error) {
+	if err := checkTaskfile(taskfilePath); err != nil {
+		return nil, err
+	}
+	bridge, err := server.New(ctx, taskfilePath, taskBinPath, serverName, opts...)
+	return bridge, withExitCode(exitInspectionFailed, err)
+}
+
+// globalOptions maps --var and --dotenv to the variables and environment
+// of every task. As with task's dotenv, variables already set in the
+// environment win over the files, and earlier files over later ones.
+func globalOptions(cmd *cobra.Command) ([]server.Option, error) {
+	var opts []server.Option
+	pairs, _ := cmd.Flags().GetStringArray("var")
+	if len(pairs) > 0 {
+		vars := make(map[string]string, len(pairs))
+		for _, pair := range pairs {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok || key == "" {
+				return nil, usageErrorf("invalid --var %q (want KEY=VALUE)", pair)
+			}
+			vars[key] = value
+		}
+		opts = append(opts, server.WithVars(vars))
+	}
+	files, _ := cmd.Flags().GetStringArray("dotenv")
+	if len(files) > 0 {
+		env := make(map[string]string)
+		for _, file := range files {
+			fileEnv, err := config.LoadDotenv(file)
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, usageErrorf("--dotenv: %v", err)
+			}
+			if err != nil {
+				return nil, withExitCode(exitUsage, err)
+			}
+			for key, value := range fileEnv {
+				if _, set := os.LookupEnv(key); set {
+					continue
+				}
+				if _, set := env[key]; !set {
+					env[key] = value
+				}
+			}
+		}
+		opts = append(opts, server.WithEnv(env))
+	}
+	return opts, nil
+}
+
+// checkNegotiationFlags rejects --protocol-version and --disable-capability
+// values the server doesn't know.
+func checkNegotiationFlags(cmd *cobra.Command) error {
+	if version, _ := cmd.Flags().GetString("protocol-version"); version != "" && !slices.Contains(server.ProtocolVersions(), version) {
+		return usageErrorf("unknown --protocol-version %q (want one of %s)", version, strings.Join(server.ProtocolVersions(), ", "))
+	}
+	disabled, _ := cmd.Flags().GetStringArray("disable-capability")
+	for _, name := range disabled {
+		if !slices.Contains(server.Capabilities, name) {
+			return usageErrorf("unknown --disable-capability %q (want one of %s)", name, strings.Join(server.Capabilities, ", "))
+		}
+	}
+	return nil
+}
+
+// recordOptions maps --record to an observer appending every tool call to
+// the file. The returned func closes it once serving stopped.
+func recordOptions(cmd *cobra.Command) ([]server.Option, func(), error) {
+	path, _ := cmd.Flags().GetString("record")
+	if path == "" {
+		return nil, func() {}, nil
+	}
+	// Recordings hold arguments and output, which may be sensitive.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, nil, usageErrorf("--record: %v", err)
+	}
+	recorder := server.NewRecorder(f)
+	stop := func() {
+		if err := recorder.Err(); err != nil {
+			slog.Warn("Recording tool calls failed", "file", path, "error", err)
+		}
+		f.Close()
+	}
+	return []server.Option{server.WithCallObserver(recorder.Observe)}, stop, nil
+}
+
+// serverOptions maps the flags and config shared by every server.
+func serverOptions(cmd *cobra.Command, cfg *config.Config) []server.Option {
+	handshakeTimeout, _ := cmd.Flags().GetDuration("handshake-timeout")
+	readTimeout, _ := cmd.Flags().GetDuration("read-timeout")
+	toolPrefix, _ := cmd.Flags().GetString("tool-prefix")
+	opts := []server.Option{
+		server.WithToolPrefix(toolPrefix),
+		server.WithHandshakeTimeout(handshakeTimeout),
+		server.WithIdleTimeout(readTimeout),
+		server.WithInjectedVars(cfg.Inject),
+		server.WithSecrets(cfg.Secrets),
+		server.WithRateLimit(cfg.RateLimit),
+		server.WithBudget(server.Budget{MaxCalls: cfg.Budget.MaxCalls, MaxRuntime: cfg.Budget.MaxRuntime, MaxCost: cfg.Budget.MaxCost}),
+		server.WithRetries(cfg.Retry),
+		server.WithConcurrencyGroups(cfg.Concurrency),
+		server.WithResultCache(cfg.Cache),
+		server.WithPolicies(cfg.Policies),
+		server.WithMaxOutput(cfg.MaxOutput),
+		server.WithPageSize(cfg.PageSize),
+		server.WithUsagePattern(cfg.UsageRegexp),
+	}
+	// --protocol-version overrides protocol_version, and
+	// --disable-capability adds to disable_capabilities.
+	protocolVersion := cfg.ProtocolVersion
+	if version, _ := cmd.Flags().GetString("protocol-version"); version != "" {
+		protocolVersion = version
+	}
+	if protocolVersion != "" {
+		opts = append(opts, server.WithProtocolVersion(protocolVersion))
+	}
+	disabled, _ := cmd.Flags().GetStringArray("disable-capability")
+	if disabled = append(append([]string{}, cfg.DisableCapabilities...), disabled...); len(disabled) > 0 {
+		opts = append(opts, server.WithoutCapabilities(disabled...))
+	}
+	if cfg.Scheduler.File != "" {
+		opts = append(opts, server.WithSchedules(cfg.Scheduler.File, cfg.Scheduler.Allow))
+	}
+	if len(cfg.Plugins) > 0 {
+		opts = append(opts, server.WithPlugins(plugins(cfg)))
+	}
+	opts = append(opts, profileOptions(cfg)...)
+	if len(cfg.When) > 0 {
+		opts = append(opts, server.WithConditions(cfg.When))
+	}
+	if len(cfg.Pipelines) > 0 {
+		opts = append(opts, server.WithPipelines(pipelines(cfg)))
+	}
+	if len(cfg.Upstreams) > 0 {
+		opts = append(opts, server.WithUpstreams(upstreams(cfg)))
+	}
+	if len(cfg.Approval.Tasks) > 0 {
+		if adminAddr, _ := cmd.Flags().GetString("admin-listen"); adminAddr == "" && len(cfg.Approval.Notify) == 0 {
+			slog.Warn("Calls of tasks in approval.tasks can't be approved without approval.notify or --admin-listen and will time out")
+		}
+		opts = append(opts, server.WithApprovals(cfg.Approval.Tasks, cfg.Approval.Timeout, approvalNotifier(cfg.Approval)))
+	}
+	if cfg.Notify.Enabled() {
+		opts = append(opts, server.WithCompletionNotifier(cfg.Notify.After, cfg.Notify.Tasks, completionNotifier(cfg.Notify)))
+	}
+	if len(cfg.Sessions.Dirs) > 0 || len(cfg.Sessions.Profiles) > 0 {
+		opts = append(opts, server.WithSessions(cfg.Sessions.Dirs, cfg.Sessions.Profiles))
+	}
+	if autoJSON, _ := cmd.Flags().GetBool("auto-json"); autoJSON {
+		opts = append(opts, server.WithAutoJSON())
+	}
+	if cache := inspectCache(cmd); cache != nil {
+		opts = append(opts, server.WithInspectCache(cache))
+	}
+	if lazy, _ := cmd.Flags().GetBool("lazy"); lazy {
+		opts = append(opts, server.WithLazyDetails())
+	}
+	if sample, _ := cmd.Flags().GetBool("sample-descriptions"); sample {
+		opts = append(opts, server.WithSampledDescriptions(descriptionCacheDir(cmd)))
+	}
+	if cfg.Safe.DryRun {
+		opts = append(opts, server.WithDryRunUnless(cfg.Safe.Allow))
+	}
+	if safe, _ := cmd.Flags().GetBool("safe"); safe {
+		opts = append(opts, server.WithSafeMode(cfg.Safe.Allow))
+	}
+	return opts
+}
+
+// serveAll runs every server from the config file on one HTTP listener.
+func serveAll(cmd *cobra.Command) error {
+	configPath, _ := cmd.Flags().GetString("config")
+	if configPath == "" {
+		configPath = config.DefaultFileName
+	}
+	cfg, err := config.Load(configPath, "")
+	if err != nil {
+		return withExitCode(exitUsage, err)
+	}
+	if len(cfg.Servers) == 0 {
+		return usageErrorf("no servers defined in %s", configPath)
+	}
+	if err := applyProfile(cmd, cfg); err != nil {
+		return err
+	}
+	if transport, _ := cmd.Flags().GetString("transport"); cmd.Flags().Changed("transport") && transport != "http" {
+		return usageErrorf("--all only supports the http transport")
+	}
+	httpOpts, err := httpOptions(cmd)
+	if err != nil {
+		return err
+	}
+	taskBinPath, err := resolveTaskBin(cmd)
+	if err != nil {
+		return err
+	}
+	globals, err := globalOptions(cmd)
+	if err != nil {
+		return err
+	}
+	record, stopRecording, err := recordOptions(cmd)
+	if err != nil {
+		return err
+	}
+	defer stopRecording()
+	globals = append(globals, record...)
+	applyLogLevel(cfg)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+	startPprof(ctx, cmd)
+
+	names := make([]string, 0, len(cfg.Servers))
+	for name := range cfg.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var mounts []server.Mount
+	for _, name := range names {
+		srv := cfg.Servers[name]
+		opts := append(append(serverOptions(cmd, cfg), globals...),
+			server.WithTaskFilter(srv.Include, srv.Exclude),
+			server.WithEnv(srv.Env),
+		)
+		if srv.ToolPrefix != "" {
+			opts = append(opts, server.WithToolPrefix(srv.ToolPrefix))
+		}
+		if cfg.Scheduler.File != "" {
+			opts = append(opts, server.WithSchedules(cfg.Scheduler.ScheduleFile(name), cfg.Scheduler.Allow))
+		}
+		bridge, err := newBridge(ctx, srv.Taskfile, taskBinPath, name, opts...)
+		if err != nil {
+			return fmt.Errorf("server %s: %w", name, err)
+		}
+		defer bridge.Close()
+		mounts = append(mounts, server.Mount{BasePath: name, Bridge: bridge})
+	}
+
+	if err := startAdmin(ctx, cmd, cfg, mounts); err != nil {
+		return err
+	}
+	startSchedules(ctx, mounts)
+	if err := server.ServeHTTP(ctx, httpOpts, mounts); err != nil {
+		return withExitCode(exitExecutionFailed, fmt.Errorf("serving MCP: %w", err))
+	}
+	return nil
+}
+
+// httpOptions reads the options of the HTTP listener from the flags.
+func httpOptions(cmd *cobra.Command) (server.HTTPOptions, error) {
+	addr, _ := cmd.Flags().GetString("listen")
+	mode, err := socketMode(cmd)
+	if err != nil {
+		return server.HTTPOptions{}, err
+	}
+	handshakeTimeout, _ := cmd.Flags().GetDuration("handshake-timeout")
+	readTimeout, _ := cmd.Flags().GetDuration("read-timeout")
+	hookToken, _ := cmd.Flags().GetString("hook-token")
+	if hookToken == "" {
+		hookToken = os.Getenv("TMCP_HOOK_TOKEN")
+	}
+	return server.HTTPOptions{
+		Addr:             addr,
+		SocketMode:       mode,
+		HandshakeTimeout: handshakeTimeout,
+		ReadTimeout:      readTimeout,
+		HookToken:        hookToken,
+	}, nil
+}
+
+// socketMode parses --listen-mode, an octal permission mode like 0660.
+func socketMode(cmd *cobra.Command) (os.FileMode, error) {
+	value, _ := cmd.Flags().GetString("listen-mode")
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, usageErrorf("invalid --listen-mode %q: want an octal mode like 0660", value)
+	}
+	return os.FileMode(mode), nil
+}
+
+// applyLogLevel sets the process log level from the config file.
+func applyLogLevel(cfg *config.Config) {
+	if cfg.LogLevel == "" {
+		return
+	}
+	var level slog.Level
+	// Load already validated the level.
+	_ = level.UnmarshalText([]byte(cfg.LogLevel))
+	slog.SetLogLoggerLevel(level)
+}
+
+// startSchedules runs the scheduled tasks of every mounted bridge in the
+// background.
+func startSchedules(ctx context.Context, mounts []server.Mount) {
+	for _, m := range mounts {
+		go m.Bridge.RunSchedules(ctx)
+	}
+}
+
+// startPprof serves Go profiles in the background when --pprof-addr is set.
+// It starts before inspection, so slow inspections can be profiled too.
+func startPprof(ctx context.Context, cmd *cobra.Command) {
+	addr, _ := cmd.Flags().GetString("pprof-addr")
+	if addr == "" {
+		return
+	}
+	go func() {
+		if err := server.ServePprof(ctx, addr); err != nil {
+			slog.Error("Profiling server stopped", "error", err)
+		}
+	}()
+}
+
+// startAdmin serves the admin API in the background when --admin-listen
+// is set. Changes can be persisted to the config file tmcp was started with.
+func startAdmin(ctx context.Context, cmd *cobra.Command, cfg *config.Config, mounts []server.Mount) error {
+	addr, _ := cmd.Flags().GetString("admin-listen")
+	if addr == "" {
+		return nil
+	}
+	token, _ := cmd.Flags().GetString("admin-token")
+	if token == "" {
+		token = os.Getenv("TMCP_ADMIN_TOKEN")
+	}
+	if token == "" {
+		return usageErrorf("--admin-listen requires --admin-token or $TMCP_ADMIN_TOKEN")
+	}
+
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(cfg.LogLevel))
+	opts := server.AdminOptions{
+		Addr:     addr,
+		Token:    token,
+		LogLevel: level,
+		Persist: func(s server.AdminSettings) error {
+			return config.SaveRuntime(cfg.Path, config.Runtime{
+				LogLevel:  s.LogLevel,
+				RateLimit: s.RateLimit,
+				DryRun:    s.DryRun,
+				Allow:     s.Allow,
+			})
+		},
+	}
+	go func() {
+		if err := server.ServeAdmin(ctx, opts, mounts); err != nil {
+			slog.Error("Admin API stopped", "error", err)
+		}
+	}()
+	return nil
+}
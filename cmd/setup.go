@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/clients"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+	"github.com/spf13/cobra"
+)
+
+// transports lists the ways tmcp serve can serve MCP: over stdio, and over
+// HTTP on a TCP address or on a Unix socket.
+var transports = []string{"stdio", "http", "unix"}
+
+var setupCmd = &cobra.Command{
+	Use:   "setup",
+	Short: "Interactively set up tmcp for your MCP clients.",
+	Long: `The setup command walks through selecting a Taskfile, choosing a transport,
+registering tmcp with the MCP clients found on this machine, and verifying the
+bridge with a test call.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+		return runSetup(cmd, p)
+	},
+}
+
+func init() {
+	setupCmd.Flags().String("task-bin", "task", "Path to the task binary (default: 'task')")
+	rootCmd.AddCommand(setupCmd)
+}
+
+func runSetup(cmd *cobra.Command, p *prompter) error {
+	fmt.Fprintln(p.out, "tmcp setup")
+	fmt.Fprintln(p.out)
+
+	// 1. Taskfile
+	taskfilePath, err := filepath.Abs(p.ask("Taskfile", defaultTaskfile()))
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(taskfilePath); err != nil {
+		return fmt.Errorf("taskfile not found: %s", taskfilePath)
+	}
+	taskBinPath, err := resolveTaskBin(cmd)
+	if err != nil {
+		return err
+	}
+	inspector, err := inspector.New(
+		inspector.WithTaskfile(taskfilePath),
+		inspector.WithTaskBin(taskBinPath),
+	)
+	if err != nil {
+		return err
+	}
+	config, err := inspector.Inspect(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("inspecting Taskfile: %w", err)
+	}
+	fmt.Fprintf(p.out, "Found %d tasks.\n\n", len(config.Tasks))
+
+	// 2. Server name and transport
+	name := p.ask("Server name", "tasks")
+	transport := p.choose("Transport", transports, 0)
+	var listen string
+	switch transport {
+	case "http":
+		listen = p.ask("Listen address", "127.0.0.1:8080")
+	case "unix":
+		socket, err := filepath.Abs(p.ask("Socket path", filepath.Join(filepath.Dir(taskfilePath), "tmcp.sock")))
+		if err != nil {
+			return err
+		}
+		listen = "unix://" + socket
+	}
+	fmt.Fprintln(p.out)
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	entry := clients.ServerEntry{Command: exe, Args: []string{"serve", taskfilePath}}
+	if name != "tasks" {
+		entry.Args = append(entry.Args, "--name", name)
+	}
+	// Pin the resolved binary: desktop clients often launch with a minimal PATH.
+	entry.Args = append(entry.Args, "--task-bin", taskBinPath)
+	if transport != "stdio" {
+		// Clients connect to a running HTTP server rather than start it, so
+		// there is nothing to register or verify by starting it.
+		entry.Args = append(entry.Args, "--transport", "http", "--listen", listen)
+		fmt.Fprintln(p.out, "Start the server with:")
+		fmt.Fprintf(p.out, "  %s %s\n", entry.Command, strings.Join(entry.Args, " "))
+		fmt.Fprintf(p.out, "and point your MCP clients at %s/mcp.\n", server.ListenURL(listen))
+		return nil
+	}
+
+	// 3. Client configuration
+	detected := clients.Detect()
+	if len(detected) == 0 {
+		fmt.Fprintln(p.out, "No MCP clients detected. Add this to your client's MCP configuration:")
+		fmt.Fprintln(p.out, clients.Snippet(name, entry))
+	}
+	for _, c := range detected {
+		if !p.confirm(fmt.Sprintf("Register with %s?", c.Name), true) {
+			continue
+		}
+		if c.ConfigPath == "" {
+			command := c.RegisterCommand(name, entry)
+			// #nosec G204
+			out, err := exec.Command(command[0], command[1:]...).CombinedOutput()
+			if err != nil {
+				fmt.Fprintf(p.out, "  Failed: %v\n  %s\n  Run it yourself: %s\n", err, strings.TrimSpace(string(out)), strings.Join(command, " "))
+				continue
+			}
+			fmt.Fprintf(p.out, "  Registered via %s\n", strings.Join(command, " "))
+			continue
+		}
+		if err := c.Register(name, entry); err != nil {
+			fmt.Fprintf(p.out, "  Failed: %v\n", err)
+			continue
+		}
+		fmt.Fprintf(p.out, "  Updated %s (previous version saved as .bak)\n", c.ConfigPath)
+	}
+	fmt.Fprintln(p.out)
+
+	// 4. Verification
+	if !p.confirm("Verify the bridge now?", true) {
+		return nil
+	}
+	return verifyBridge(p, entry)
+}
+
+// verifyBridge starts tmcp the way a client would, lists its tools and
+// optionally calls one that needs no arguments.
+func verifyBridge(p *prompter, entry clients.ServerEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	c, err := mcpclient.NewStdioMCPClient(entry.Command, nil, entry.Args...)
+	if err != nil {
+		return fmt.Errorf("starting tmcp: %w", err)
+	}
+	defer c.Close()
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "tmcp-setup", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, initRequest); err != nil {
+		return fmt.Errorf("initializing MCP session: %w", err)
+	}
+
+	tools, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		return fmt.Errorf("listing tools: %w", err)
+	}
+	fmt.Fprintf(p.out, "Bridge is up and exposes %d tools.\n", len(tools.Tools))
+
+	var callable []string
+	for _, tool := range tools.Tools {
+		if len(tool.InputSchema.Required) == 0 {
+			callable = append(callable, tool.Name)
+		}
+	}
+	if len(callable) == 0 {
+		return nil
+	}
+	options := append([]string{"(skip)"}, callable...)
+	choice := p.choose("Test call a tool", options, 0)
+	if choice == "(skip)" {
+		return nil
+	}
+
+	callRequest := mcp.CallToolRequest{}
+	callRequest.Params.Name = choice
+	result, err := c.CallTool(ctx, callRequest)
+	if err != nil {
+		return fmt.Errorf("calling %s: %w", choice, err)
+	}
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			fmt.Fprintln(p.out, text.Text)
+		}
+	}
+	if result.IsError {
+		return fmt.Errorf("test call to %s failed", choice)
+	}
+	fmt.Fprintln(p.out, "Test call succeeded.")
+	return nil
+}
+
+// defaultTaskfile returns the Taskfile task would use from the current
+// directory, falling back to Taskfile.yml.
+func defaultTaskfile() string {
+	if path, err := config.FindTaskfile("."); err == nil {
+		return path
+	}
+	return "Taskfile.yml"
+}
+
+// prompter asks line-based questions for interactive commands.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask prompts for a value, returning def when the answer is empty.
+func (p *prompter) ask(question string, def string) string {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	line, _ := p.in.ReadString('\n')
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer
+	}
+	return def
+}
+
+// confirm asks a yes/no question.
+func (p *prompter) confirm(question string, def bool) bool {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	answer := strings.ToLower(p.ask(fmt.Sprintf("%s (%s)", question, hint), ""))
+	if answer == "" {
+		return def
+	}
+	return answer == "y" || answer == "yes"
+}
+
+// choose asks the user to pick one of options by number or name.
+func (p *prompter) choose(question string, options []string, def int) string {
+	if len(options) == 1 {
+		fmt.Fprintf(p.out, "%s: %s\n", question, options[0])
+		return options[0]
+	}
+	fmt.Fprintf(p.out, "%s:\n", question)
+	for i, option := range options {
+		fmt.Fprintf(p.out, "  %d) %s\n", i+1, option)
+	}
+	for {
+		answer := p.ask("Choice", strconv.Itoa(def+1))
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(options) {
+			return options[n-1]
+		}
+		for _, option := range options {
+			if option == answer {
+				return option
+			}
+		}
+		fmt.Fprintln(p.out, "Please pick one of the listed options.")
+	}
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/slack"
+	"github.com/spf13/cobra"
+	"github.com/tmc/langchaingo/agents"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// maxSlackOutput caps the task output posted to Slack. The end of the
+// output is kept, as that's where errors are.
+const maxSlackOutput = 3000
+
+var slackCmd = &cobra.Command{
+	Use:   "slack [Taskfile]",
+	Short: "Run a Taskfile's tasks from Slack.",
+	Long: `The slack command connects to a Slack app over Socket Mode and runs the
+Taskfile's tools for the app's slash command and for mentions of its bot, e.g.
+"/tmcp deploy ENV=prod" or "@tmcp deploy ENV=prod". The first word names the
+tool and the KEY=value pairs after it are its arguments. Results are posted to
+the channel; "help" lists the tools.
+
+With --agent, requests that don't start with a tool name are answered by the
+agent, which picks the tools to call itself. The provider and API keys work as
+for the agent command.
+
+The app-level token (xapp-..., with the connections:write scope) is read from
+$SLACK_APP_TOKEN and the bot token (xoxb-..., with the app_mentions:read,
+chat:write and commands scopes) from $SLACK_BOT_TOKEN. Tools go through the
+same validation, policies, approvals and limits as for MCP clients.
+
+The bot only serves the users and channels given with --users and --channels.
+Pass --allow-everyone instead to serve everyone who can reach it.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSlack,
+}
+
+func init() {
+	slackCmd.Flags().String("task-bin", "task", "Path to the task binary (default: 'task')")
+	slackCmd.Flags().Bool("no-cache", false, "Inspect the Taskfile afresh instead of reusing a cached inspection")
+	slackCmd.Flags().String("config", "", "Path to the tmcp config file (default: .tmcp.yml next to the Taskfile)")
+	slackCmd.Flags().Bool("safe", false, "Strict arguments, clean env, redacted output, and dry runs for tasks not read-only or in safe.allow")
+	slackCmd.Flags().StringSlice("users", nil, "Only serve these Slack user IDs")
+	slackCmd.Flags().StringSlice("channels", nil, "Only serve requests in these Slack channel IDs")
+	slackCmd.Flags().Bool("allow-everyone", false, "Serve everyone who can reach the bot, when neither --users nor --channels is given")
+	slackCmd.Flags().Bool("agent", false, "Let the agent answer requests that don't start with a tool name")
+	slackCmd.Flags().StringVar(&provider, "provider", "anthropic", "LLM provider of the agent (e.g., anthropic, openai)")
+	slackCmd.Flags().StringVar(&modelName, "model-name", defaultModelName, "Name of the model of the agent")
+	slackCmd.Flags().IntVar(&maxIterations, "max-iterations", 10, "Maximum number of reasoning steps of the agent")
+	slackCmd.ValidArgsFunction = completeTaskfile
+	mustRegisterFlagCompletion(slackCmd, "config", completeYAMLFile)
+	mustRegisterFlagCompletion(slackCmd, "provider", completeProvider)
+	rootCmd.AddCommand(slackCmd)
+}
+
+func runSlack(cmd *cobra.Command, args []string) error {
+	appToken, botToken := os.Getenv("SLACK_APP_TOKEN"), os.Getenv("SLACK_BOT_TOKEN")
+	if appToken == "" || botToken == "" {
+		return usageErrorf("SLACK_APP_TOKEN and SLACK_BOT_TOKEN must be set")
+	}
+	users, _ := cmd.Flags().GetStringSlice("users")
+	channels, _ := cmd.Flags().GetStringSlice("channels")
+	everyone, _ := cmd.Flags().GetBool("allow-everyone")
+	if len(users) == 0 && len(channels) == 0 && !everyone {
+		return usageErrorf("--users or --channels must be set, or --allow-everyone to serve everyone who can reach the bot")
+	}
+	var llm llms.Model
+	if useAgent, _ := cmd.Flags().GetBool("agent"); useAgent {
+		if maxIterations < 1 {
+			return usageErrorf("--max-iterations must be at least 1")
+		}
+		var err error
+		if llm, err = newLLM(provider, modelName); err != nil {
+			return withExitCode(exitUsage, fmt.Errorf("initializing the %s LLM: %w", provider, err))
+		}
+	}
+	bridge, client, err := openBridge(cmd, args)
+	if err != nil {
+		return err
+	}
+	defer bridge.Close()
+	defer client.Close()
+
+	opts := []slack.Option{slack.WithUsers(users), slack.WithChannels(channels)}
+	if len(users) == 0 && len(channels) == 0 {
+		slog.Warn("Anyone in the Slack workspace who can reach the bot can run its tasks; limit it with --users or --channels")
+		opts = append(opts, slack.WithEveryone())
+	}
+	bot := slack.New(appToken, botToken, slackHandler(client, llm), opts...)
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return withExitCode(exitExecutionFailed, bot.Run(ctx))
+}
+
+// slackHandler answers Slack requests by calling the tool named by their
+// first word, or else by asking the agent of llm, if not nil.
+func slackHandler(client *mcpclient.Client, llm llms.Model) slack.Handler {
+	return func(ctx context.Context, r slack.Request) string {
+		// The tools are listed for every request, so they follow reloads.
+		listed, err := client.ListTools(ctx, mcp.ListToolsRequest{})
+		if err != nil {
+			return ":x: Could not list the tools: " + err.Error()
+		}
+		name, input, _ := strings.Cut(r.Text, " ")
+		for _, tool := range listed.Tools {
+			if tool.Name == name {
+				return callFromSlack(ctx, client, name, input)
+			}
+		}
+		if llm != nil && r.Text != "" && name != "help" {
+			return askFromSlack(ctx, client, llm, listed.Tools, r.Text)
+		}
+		return slackHelp(listed.Tools, name)
+	}
+}
+
+// callFromSlack calls the tool name with the arguments in input and
+// describes the result.
+func callFromSlack(ctx context.Context, client *mcpclient.Client, name, input string) string {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = toolArguments(input)
+	result, err := client.CallTool(ctx, request)
+	if err != nil {
+		return fmt.Sprintf(":x: `%s` failed: %v", name, err)
+	}
+	status := fmt.Sprintf(":white_check_mark: `%s` finished", name)
+	if result.IsError {
+		status = fmt.Sprintf(":x: `%s` failed", name)
+	}
+	output := resultText(result)
+	if output == "" {
+		return status + " with no output."
+	}
+	return status + ":\n" + codeBlock(output)
+}
+
+// askFromSlack lets the agent answer input with the tools, and shows the
+// tool calls it made.
+func askFromSlack(ctx context.Context, client *mcpclient.Client, llm llms.Model, listed []mcp.Tool, input string) string {
+	langchainTools := make([]tools.Tool, len(listed))
+	for i, tool := range listed {
+		langchainTools[i] = &mcpTool{client: client, tool: tool}
+	}
+	var trace bytes.Buffer
+	answer, err := askAgent(ctx, llm, langchainTools, input, &trace)
+	if errors.Is(err, agents.ErrNotFinished) {
+		answer = fmt.Sprintf(":x: The agent gave no answer within %d steps.", maxIterations)
+	} else if err != nil {
+		answer = ":x: The agent failed: " + err.Error()
+	}
+	if trace.Len() == 0 {
+		return answer
+	}
+	return answer + "\n\nTool calls:\n" + codeBlock(trace.String())
+}
+
+// slackHelp lists the tools, after saying name isn't one if given.
+func slackHelp(listed []mcp.Tool, name string) string {
+	var b strings.Builder
+	if name != "" && name != "help" {
+		fmt.Fprintf(&b, "There is no tool `%s`. ", name)
+	}
+	b.WriteString("Send a tool name followed by its KEY=value arguments. Tools:")
+	for _, tool := range listed {
+		summary, _, _ := strings.Cut(tool.Description, "\n")
+		fmt.Fprintf(&b, "\n• `%s` %s", tool.Name, summary)
+	}
+	return b.String()
+}
+
+// codeBlock formats output as a Slack code block, keeping its end if it is
+// too long.
+func codeBlock(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) > maxSlackOutput {
+		output = "…" + strings.ToValidUTF8(output[len(output)-maxSlackOutput:], "")
+	}
+	return "```\n" + strings.ReplaceAll(output, "```", "` ` `") + "\n```"
+}
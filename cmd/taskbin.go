@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"log/slog"
+	"os/exec"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/taskbin"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+	"github.com/spf13/cobra"
+)
+
+// resolveTaskBin locates the task binary named by --task-bin and makes sure
+// it is new enough for tmcp. Versions that can't be determined only warn.
+func resolveTaskBin(cmd *cobra.Command) (string, error) {
+	name, _ := cmd.Flags().GetString("task-bin")
+	return checkTaskBin(name)
+}
+
+// checkTaskBin locates the named task binary and checks its version. Its
+// errors exit with exitInspectionFailed.
+func checkTaskBin(name string) (string, error) {
+	path, err := taskbin.Resolve(name)
+	if err != nil {
+		return "", withExitCode(exitInspectionFailed, err)
+	}
+
+	version, err := taskbin.DetectVersion(path)
+	if err != nil {
+		slog.Warn("Could not determine task version, continuing anyway", "task_bin", path, "error", err)
+		return path, nil
+	}
+	if err := taskbin.CheckVersion(version); err != nil {
+		return "", withExitCode(exitInspectionFailed, err)
+	}
+	slog.Debug("Using task binary", "path", path, "version", version)
+	return path, nil
+}
+
+// taskBinFor is resolveTaskBin for the file at path, which is empty for
+// workspaces.
+func taskBinFor(cmd *cobra.Command, path string) (string, error) {
+	name, _ := cmd.Flags().GetString("task-bin")
+	return checkTaskBinFor(name, inspector.SourceOf(path), path)
+}
+
+// checkTaskBinFor is checkTaskBin for the file at path, of the given
+// source. Files other than Taskfiles, such as Makefiles, run with their own
+// program instead, which name doesn't change.
+func checkTaskBinFor(name string, source inspector.Source, path string) (string, error) {
+	if path == "" || source == inspector.Taskfile {
+		return checkTaskBin(name)
+	}
+	bin, err := exec.LookPath(source.Bin(path))
+	if err != nil {
+		return "", withExitCode(exitInspectionFailed, err)
+	}
+	return bin, nil
+}
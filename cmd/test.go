@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/tooltest"
+	"github.com/spf13/cobra"
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test [test file]",
+	Short: "Run test cases against a Taskfile's tools.",
+	Long: `The test command runs the test cases of a test file (default: ` + tooltest.DefaultFileName + `)
+against the tools of a Taskfile. Each case calls a tool with arguments through
+the same server tmcp serve runs, and checks its exit code and output:
+
+  taskfile: Taskfile.yml   # default: the Taskfile task would use there
+  tests:
+    - name: greets by name
+      tool: greet
+      args: {NAME: Ada}
+      expect:
+        output: Hello, Ada   # a regular expression
+    - tool: deploy
+      args: {ENV: nowhere}
+      expect:
+        exit_code: 2
+        not_output: deployed
+
+Without exit_code or error: true, a call must succeed. The command exits with 1
+when any case fails. With --ci github, failed cases are also reported as
+GitHub Actions annotations on their line of the test file.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTest,
+}
+
+func init() {
+	testCmd.Flags().String("task-bin", "task", "Path to the task binary (default: 'task')")
+	testCmd.Flags().String("config", "", "Path to the tmcp config file (default: .tmcp.yml next to the Taskfile)")
+	testCmd.Flags().String("run", "", "Only run the cases whose name matches this regular expression")
+	testCmd.Flags().BoolP("verbose", "v", false, "Print the output of passing cases too")
+	addCIFlag(testCmd)
+	testCmd.ValidArgsFunction = completeYAMLFile
+	mustRegisterFlagCompletion(testCmd, "config", completeYAMLFile)
+	rootCmd.AddCommand(testCmd)
+}
+
+func runTest(cmd *cobra.Command, args []string) error {
+	path := tooltest.DefaultFileName
+	if len(args) > 0 {
+		path = args[0]
+	}
+	suite, err := tooltest.Load(path)
+	if errors.Is(err, os.ErrNotExist) && len(args) == 0 {
+		return usageErrorf("no %s in the current directory; pass a test file", tooltest.DefaultFileName)
+	}
+	if err != nil {
+		return withExitCode(exitUsage, err)
+	}
+	ci, err := newAnnotator(cmd)
+	if err != nil {
+		return err
+	}
+	var run *regexp.Regexp
+	if pattern, _ := cmd.Flags().GetString("run"); pattern != "" {
+		if run, err = regexp.Compile(pattern); err != nil {
+			return usageErrorf("invalid --run: %v", err)
+		}
+	}
+
+	taskfilePath := suite.Taskfile
+	if taskfilePath == "" {
+		if taskfilePath, err = config.FindTaskfile(filepath.Dir(path)); err != nil {
+			return withExitCode(exitTaskfileNotFound, err)
+		}
+	}
+	client, closeClient, err := bridgeClient(cmd, taskfilePath, taskfilePath)
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	ran, failed := 0, 0
+	for _, c := range suite.Tests {
+		if run != nil && !run.MatchString(c.Name) {
+			continue
+		}
+		ran++
+		request := mcp.CallToolRequest{}
+		request.Params.Name = c.Tool
+		request.Params.Arguments = c.Arguments
+		start := time.Now()
+		result, err := client.CallTool(cmd.Context(), request)
+		took := time.Since(start)
+
+		outcome := tooltest.Outcome{}
+		if err != nil {
+			outcome = tooltest.Outcome{IsError: true, ExitCode: -1, Output: err.Error()}
+		} else {
+			outcome = tooltest.Outcome{IsError: result.IsError, ExitCode: exitCodeOf(result), Output: replayText(result)}
+		}
+		failures := c.Check(outcome)
+		status := "PASS"
+		if len(failures) > 0 {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Printf("--- %s: %s (%.2fs)\n", status, c.Name, took.Seconds())
+		for _, failure := range failures {
+			fmt.Printf("    %s\n", failure)
+		}
+		if len(failures) > 0 {
+			ci.annotate("error", suite.Path, c.Line, "tmcp test: "+c.Name, strings.Join(failures, "\n"))
+		}
+		if len(failures) > 0 || verbose {
+			printIndented(outcome.Output)
+		}
+	}
+
+	if failed > 0 {
+		fmt.Printf("FAIL: %d of %d tests failed\n", failed, ran)
+		return &exitError{code: exitFailure, err: errors.New("tests failed"), quiet: true}
+	}
+	fmt.Printf("PASS: %d tests\n", ran)
+	return nil
+}
+
+// exitCodeOf returns the exit code of the task a tool result came from: 0
+// for a successful call, and -1 when a failed one has no execution details,
+// e.g. because its arguments were invalid.
+func exitCodeOf(result *mcp.CallToolResult) int {
+	if !result.IsError {
+		return 0
+	}
+	// The details arrive decoded from JSON, as a map.
+	data, err := json.Marshal(result.Meta["tmcp/execution"])
+	if err != nil {
+		return -1
+	}
+	var execution struct {
+		ExitCode *int `json:"exit_code"`
+	}
+	if json.Unmarshal(data, &execution) != nil || execution.ExitCode == nil {
+		return -1
+	}
+	return *execution.ExitCode
+}
+
+// printIndented prints the output of a case below its status line.
+func printIndented(output string) {
+	output = strings.TrimRight(output, "\n")
+	if output == "" {
+		return
+	}
+	for _, line := range strings.Split(output, "\n") {
+		fmt.Printf("        %s\n", line)
+	}
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/secrets"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
+)
+
+// upstreams maps the upstreams of the config file to bridge upstreams.
+func upstreams(cfg *config.Config) []server.Upstream {
+	names := make([]string, 0, len(cfg.Upstreams))
+	for name := range cfg.Upstreams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]server.Upstream, 0, len(names))
+	for _, name := range names {
+		u := cfg.Upstreams[name]
+		list = append(list, server.Upstream{
+			Name:    name,
+			Prefix:  u.ToolPrefix,
+			Include: u.Include,
+			Exclude: u.Exclude,
+			Connect: func(ctx context.Context) (mcpclient.MCPClient, error) {
+				return connectUpstream(ctx, name, u)
+			},
+		})
+	}
+	return list
+}
+
+// connectUpstream starts a client for the upstream and initializes it. The
+// upstream's secrets are resolved once, when it is connected.
+func connectUpstream(ctx context.Context, name string, u config.UpstreamConfig) (*mcpclient.Client, error) {
+	secretEnv, err := secrets.Resolve(ctx, u.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	var c *mcpclient.Client
+	if len(u.Command) > 0 {
+		env := os.Environ()
+		for key, value := range u.Env {
+			env = append(env, key+"="+value)
+		}
+		env = append(env, secretEnv...)
+		c, err = mcpclient.NewStdioMCPClient(u.Command[0], env, u.Command[1:]...)
+		if err != nil {
+			return nil, fmt.Errorf("starting %s: %w", u.Command[0], err)
+		}
+		// The upstream blocks once its stderr pipe is full, so pass its
+		// logs on.
+		if stderr, ok := mcpclient.GetStderr(c); ok {
+			go io.Copy(os.Stderr, stderr)
+		}
+	} else {
+		values := make(map[string]string, len(secretEnv))
+		for _, entry := range secretEnv {
+			key, value, _ := strings.Cut(entry, "=")
+			values[key] = value
+		}
+		headers := make(map[string]string, len(u.Headers))
+		for key, value := range u.Headers {
+			headers[key] = os.Expand(value, func(name string) string {
+				if value, ok := values[name]; ok {
+					return value
+				}
+				return os.Getenv(name)
+			})
+		}
+		c, err = mcpclient.NewStreamableHttpClient(u.URL, transport.WithHTTPHeaders(headers))
+		if err != nil {
+			return nil, fmt.Errorf("connecting to %s: %w", u.URL, err)
+		}
+		if err := c.Start(ctx); err != nil {
+			return nil, fmt.Errorf("connecting to %s: %w", u.URL, err)
+		}
+	}
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "tmcp", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, initRequest); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("initializing MCP session with upstream %s: %w", name, err)
+	}
+	return c, nil
+}
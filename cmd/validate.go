@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [Taskfile]",
+	Short: "Check a Taskfile for problems with its tools.",
+	Long: `The validate command inspects a Taskfile and reports what makes its tools
+hard for agents to use, such as tasks without a desc or summary, and x-mcp
+metadata tmcp ignores, such as a retry with too many attempts or an unknown
+key. Problems are printed as file:line: severity: task: message.
+
+The command exits with 1 when there are errors, and with --strict also when
+there are warnings. With --ci github, problems are also reported as GitHub
+Actions annotations, so they show up on the Taskfile in pull requests:
+
+  - run: tmcp validate --ci github`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runValidate,
+}
+
+func init() {
+	validateCmd.Flags().String("task-bin", "task", "Path to the task binary (default: 'task')")
+	validateCmd.Flags().Bool("no-cache", false, "Inspect the Taskfile afresh instead of reusing a cached inspection")
+	validateCmd.Flags().Bool("strict", false, "Exit with 1 on warnings too")
+	addCIFlag(validateCmd)
+	validateCmd.ValidArgsFunction = completeTaskfile
+	rootCmd.AddCommand(validateCmd)
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	ci, err := newAnnotator(cmd)
+	if err != nil {
+		return err
+	}
+	taskfilePath, err := taskfileArg(cmd, args)
+	if err != nil {
+		return err
+	}
+	if err := checkTaskfile(taskfilePath); err != nil {
+		return err
+	}
+	taskBinPath, err := taskBinFor(cmd, taskfilePath)
+	if err != nil {
+		return err
+	}
+	opts := []inspector.Option{inspector.WithTaskfile(taskfilePath), inspector.WithTaskBin(taskBinPath)}
+	if cache := inspectCache(cmd); cache != nil {
+		opts = append(opts, inspector.WithCache(cache))
+	}
+	i, err := inspector.New(opts...)
+	if err != nil {
+		return withExitCode(exitInspectionFailed, err)
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+	config, err := i.Inspect(ctx)
+	if err != nil {
+		return withExitCode(exitInspectionFailed, err)
+	}
+	problems, err := inspector.Validate(taskfilePath, config)
+	if err != nil {
+		return withExitCode(exitInspectionFailed, err)
+	}
+
+	errorCount, warningCount := 0, 0
+	for _, p := range problems {
+		fmt.Println(p)
+		ci.annotate(p.Severity, p.File, p.Line, "tmcp validate: "+p.Task, p.Message)
+		if p.Severity == inspector.SeverityError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+	}
+	if len(problems) == 0 {
+		fmt.Printf("OK: %d tasks\n", len(config.Tasks))
+		return nil
+	}
+	fmt.Printf("%s, %s in %d tasks\n", count(errorCount, "error"), count(warningCount, "warning"), len(config.Tasks))
+	if strict, _ := cmd.Flags().GetBool("strict"); errorCount > 0 || strict {
+		return &exitError{code: exitFailure, err: errors.New("validation failed"), quiet: true}
+	}
+	return nil
+}
+
+// count formats n things, e.g. "1 error" or "2 errors".
+func count(n int, thing string) string {
+	if n == 1 {
+		return "1 " + thing
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
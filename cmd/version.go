@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"text/tabwriter"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/taskbin"
+	"github.com/spf13/cobra"
+)
+
+// buildInfo describes the tmcp binary and the task binary it would use.
+type buildInfo struct {
+	Version     string `json:"version"`
+	Commit      string `json:"commit"`
+	Date        string `json:"date"`
+	GoVersion   string `json:"go_version"`
+	Platform    string `json:"platform"`
+	TaskBin     string `json:"task_bin,omitempty"`
+	TaskVersion string `json:"task_version,omitempty"`
+	// TaskError says why the task binary or its version wasn't found.
+	TaskError string `json:"task_error,omitempty"`
+}
+
+// build is the build metadata of tmcp, set by SetBuildInfo.
+var build = buildInfo{Version: "dev", Commit: "unknown", Date: "unknown"}
+
+// SetBuildInfo records the version, commit and build date that releases
+// inject with ldflags. Empty values fall back to what the Go toolchain
+// recorded, e.g. for `go install`.
+func SetBuildInfo(version string, commit string, date string) {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v := info.Main.Version; v != "" && v != "(devel)" {
+			build.Version = v
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				build.Commit = s.Value
+			case "vcs.time":
+				build.Date = s.Value
+			}
+		}
+	}
+	if version != "" {
+		build.Version = version
+	}
+	if commit != "" {
+		build.Commit = commit
+	}
+	if date != "" {
+		build.Date = date
+	}
+	rootCmd.Version = build.Version
+}
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version of tmcp and of the task binary it uses.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info := build
+		info.GoVersion = runtime.Version()
+		info.Platform = runtime.GOOS + "/" + runtime.GOARCH
+		name, _ := cmd.Flags().GetString("task-bin")
+		if path, err := taskbin.Resolve(name); err != nil {
+			info.TaskError = err.Error()
+		} else if v, err := taskbin.DetectVersion(path); err != nil {
+			info.TaskBin = path
+			info.TaskError = err.Error()
+		} else {
+			info.TaskBin = path
+			info.TaskVersion = v.String()
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			return printJSON(os.Stdout, info)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "tmcp:\t%s\n", info.Version)
+		fmt.Fprintf(tw, "Commit:\t%s\n", info.Commit)
+		fmt.Fprintf(tw, "Built:\t%s\n", info.Date)
+		fmt.Fprintf(tw, "Go:\t%s %s\n", info.GoVersion, info.Platform)
+		switch {
+		case info.TaskVersion != "":
+			fmt.Fprintf(tw, "task:\t%s (%s)\n", info.TaskVersion, info.TaskBin)
+		case info.TaskBin != "":
+			fmt.Fprintf(tw, "task:\tunknown version (%s)\n", info.TaskBin)
+		default:
+			fmt.Fprintf(tw, "task:\tnot found\n")
+		}
+		return tw.Flush()
+	},
+}
+
+func init() {
+	versionCmd.Flags().String("task-bin", "task", "Path to the task binary (default: 'task')")
+	versionCmd.Flags().Bool("json", false, "Print the version information as JSON")
+	rootCmd.AddCommand(versionCmd)
+}
@@ -1,62 +1,265 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/sandwichlabs/mcp-task-bridge/internal/inspector"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/clients"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
 	"github.com/sandwichlabs/mcp-task-bridge/internal/tui"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
 	"github.com/spf13/cobra"
 )
 
 var viewCmd = &cobra.Command{
 	Use:   "view [Taskfile]",
 	Short: "View the MCP configuration in an interactive TUI.",
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		taskfilePath := args[0]
-		taskBinPath, _ := cmd.Flags().GetString("task-bin")
-		if _, err := os.Stat(taskfilePath); os.IsNotExist(err) {
-			slog.Error("Taskfile not found", "path", taskfilePath)
-			os.Exit(1)
-		}
+	Long: `The view command shows the tasks tmcp would expose in an interactive TUI.
 
-		inspector, err := inspector.New(
-			inspector.WithTaskfile(taskfilePath),
-			inspector.WithTaskBin(taskBinPath),
-		)
-		if err != nil {
-			slog.Error("Error creating inspector", "error", err)
-			os.Exit(1)
+With --serve, it also serves them over HTTP (at http://<listen>/mcp) and shows
+a live log of incoming tool calls, their arguments and results next to the
+task list.
+
+Without a Taskfile argument it shows the Taskfile task would use, found in the
+current directory or the closest parent directory that has one.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runView,
+}
+
+func runView(cmd *cobra.Command, args []string) error {
+	wsPath := workspacePath(cmd, args)
+	taskfilePath := ""
+	if wsPath == "" {
+		var err error
+		if taskfilePath, err = taskfileArg(cmd, args); err != nil {
+			return err
 		}
-		config, err := inspector.Inspect()
-		if err != nil {
-			slog.Error("Error inspecting Taskfile", "error", err, "inspectorConfig", inspector)
-			os.Exit(1)
+		if err := checkTaskfile(taskfilePath); err != nil {
+			return err
 		}
+	}
+	taskBinPath, err := taskBinFor(cmd, taskfilePath)
+	if err != nil {
+		return err
+	}
+	themeName, _ := cmd.Flags().GetString("theme")
+	theme, err := tui.ResolveTheme(themeName)
+	if err != nil {
+		return usageErrorf("invalid --theme: %w", err)
+	}
+	if theme == tui.ThemeNone {
+		// Also strips the colors bubbles components bring themselves.
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
 
-		if len(config.Tasks) == 0 {
+	if serve, _ := cmd.Flags().GetBool("serve"); serve {
+		return viewAndServe(cmd, taskfilePath, wsPath, taskBinPath, theme)
+	}
+
+	bridge, err := viewBridge(cmd, taskfilePath, wsPath, taskBinPath)
+	if err != nil {
+		return err
+	}
+	if len(bridge.Config().Tasks) == 0 {
+		if wsPath != "" {
+			fmt.Println("No tasks found in the workspace.")
+		} else {
 			fmt.Println("No tasks found in the Taskfile.")
-			return
 		}
+		return nil
+	}
+	var model tea.Model
+	if wsPath != "" {
+		// Workspace tasks come from several Taskfiles, so they can't be
+		// run from the viewer.
+		model = tui.NewModel(toolConfig(bridge), viewOptions(bridge, taskfilePath, wsPath, taskBinPath, theme)...)
+	} else {
+		model = tui.NewModel(bridge.Config(), viewOptions(bridge, taskfilePath, wsPath, taskBinPath, theme)...)
+	}
 
-		model := tui.NewModel(config)
-		// Initialize Bubble Tea program.
-		// It's good practice to use tea.WithOutput(os.Stderr) if you want to log to stdout
-		// or if other parts of your app print to stdout.
-		// tea.WithAltScreen() provides a better TUI experience.
-		p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithOutput(os.Stderr))
+	// Initialize Bubble Tea program.
+	// It's good practice to use tea.WithOutput(os.Stderr) if you want to log to stdout
+	// or if other parts of your app print to stdout.
+	// tea.WithAltScreen() provides a better TUI experience.
+	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithOutput(os.Stderr))
 
-		if _, err := p.Run(); err != nil {
-			slog.Error("Error running TUI", "error", err)
-			os.Exit(1)
-		}
-	},
+	if _, err := p.Run(); err != nil {
+		return withExitCode(exitExecutionFailed, fmt.Errorf("running TUI: %w", err))
+	}
+	return nil
 }
 
 func init() {
 	viewCmd.Flags().String("task-bin", "task", "Path to the task binary (default: 'task')")
+	viewCmd.Flags().Bool("serve", false, "Serve the tasks over HTTP and show a live log of tool calls")
+	viewCmd.Flags().String("listen", "127.0.0.1:8080", "Address to listen on with --serve")
+	viewCmd.Flags().String("workspace", "", "View every Taskfile of a workspace manifest (default: tmcp.workspace.yaml when no Taskfile is given)")
+	viewCmd.Flags().Bool("no-cache", false, "Inspect the Taskfile afresh instead of reusing a cached inspection")
+	viewCmd.Flags().String("theme", "auto", "Color theme: light, dark or auto (NO_COLOR disables colors)")
+	viewCmd.Flags().String("config", "", "Path to the tmcp config file (default: .tmcp.yml next to the Taskfile)")
+	viewCmd.ValidArgsFunction = completeTaskfile
+	mustRegisterFlagCompletion(viewCmd, "workspace", completeYAMLFile)
+	mustRegisterFlagCompletion(viewCmd, "config", completeYAMLFile)
+	mustRegisterFlagCompletion(viewCmd, "theme", cobra.FixedCompletions([]string{"light", "dark", "auto"}, cobra.ShellCompDirectiveNoFileComp))
 	rootCmd.AddCommand(viewCmd)
 }
+
+// viewBridge builds the bridge whose tasks and tools the viewer shows.
+// Errors carry the code tmcp exits with.
+func viewBridge(cmd *cobra.Command, taskfilePath string, wsPath string, taskBinPath string, opts ...server.Option) (*server.Bridge, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+	args := cmd.Flags().Args()
+	anchor := configAnchor(args, taskfilePath)
+	if wsPath != "" {
+		anchor = wsPath
+	}
+	cfg, err := config.Load(configPath, anchor)
+	if err != nil {
+		return nil, withExitCode(exitUsage, err)
+	}
+
+	opts = append([]server.Option{
+		server.WithInjectedVars(cfg.Inject),
+		server.WithSecrets(cfg.Secrets),
+		server.WithUsagePattern(cfg.UsageRegexp),
+		// The TUI draws on stderr, so the request log has to go.
+		server.WithLogOutput(io.Discard),
+	}, opts...)
+	if isRemoteArg(args) {
+		opts = append(opts, server.WithDir("."))
+	}
+	if cache := inspectCache(cmd); cache != nil {
+		opts = append(opts, server.WithInspectCache(cache))
+	}
+	// Large Taskfiles take a while to inspect before the viewer can start.
+	if isTerminal(os.Stderr) {
+		progress, stop := inspectSpinner(os.Stderr)
+		defer stop()
+		opts = append(opts, server.WithInspectProgress(progress))
+	}
+	if wsPath != "" {
+		return newWorkspaceBridge(cmd.Context(), cmd, wsPath, "tasks", opts...)
+	}
+	return newBridge(cmd.Context(), taskfilePath, taskBinPath, "tasks", opts...)
+}
+
+// viewOptions returns the viewer features available for bridge.
+func viewOptions(bridge *server.Bridge, taskfilePath string, wsPath string, taskBinPath string, theme tui.Theme) []tui.Option {
+	opts := []tui.Option{
+		tui.WithTheme(theme),
+		tui.WithToolPreview(bridge.Tools()),
+		tui.WithCopyActions(taskInvocations(bridge, taskBinPath), clientSnippet(taskfilePath, wsPath, taskBinPath)),
+	}
+	if wsPath == "" {
+		opts = append(opts, tui.WithTaskRunner(taskfilePath, taskBinPath))
+	}
+	return opts
+}
+
+// taskInvocations returns the shell command that runs each task of the
+// bridge, with a placeholder for every parameter.
+func taskInvocations(bridge *server.Bridge, taskBinPath string) []string {
+	names := bridge.ToolNames()
+	var invocations []string
+	for i, task := range bridge.Config().Tasks {
+		run := inspector.Run{File: names[i].Taskfile, Task: task.Name, Parameters: inspector.ParameterNames(task)}
+		for _, param := range task.Parameters {
+			run.Vars = append(run.Vars, fmt.Sprintf("%s=<%s>", param.Name, param.Name))
+		}
+		args := append([]string{taskBinPath}, names[i].Source.Command(run)...)
+		for j, arg := range args {
+			args[j] = shellQuote(arg)
+		}
+		invocations = append(invocations, strings.Join(args, " "))
+	}
+	return invocations
+}
+
+// safeShellWord matches arguments that need no quoting in a POSIX shell.
+var safeShellWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes s for a POSIX shell when needed.
+func shellQuote(s string) string {
+	if safeShellWord.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// clientSnippet returns the MCP client config that serves what the viewer
+// shows, in the same form as `tmcp setup` prints it.
+func clientSnippet(taskfilePath string, wsPath string, taskBinPath string) string {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "tmcp"
+	}
+	entry := clients.ServerEntry{Command: exe}
+	if wsPath != "" {
+		absWorkspace, _ := filepath.Abs(wsPath)
+		entry.Args = []string{"serve", "--workspace", absWorkspace}
+	} else {
+		absTaskfile, _ := filepath.Abs(taskfilePath)
+		entry.Args = []string{"serve", absTaskfile, "--task-bin", taskBinPath}
+	}
+	return clients.Snippet("tasks", entry)
+}
+
+// viewAndServe runs the bridge over HTTP while the TUI shows its tool calls.
+// stdio is not an option since the TUI owns the terminal.
+func viewAndServe(cmd *cobra.Command, taskfilePath string, wsPath string, taskBinPath string, theme tui.Theme) error {
+	calls := make(chan server.ToolCall, 64)
+	bridge, err := viewBridge(cmd, taskfilePath, wsPath, taskBinPath,
+		server.WithCallObserver(func(call server.ToolCall) {
+			select {
+			case calls <- call:
+			default:
+				// Drop calls rather than stall clients if the TUI falls behind.
+			}
+		}),
+	)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+	addr, _ := cmd.Flags().GetString("listen")
+	url := "http://" + addr + "/mcp"
+	model := tui.NewServeModel(bridge.Config(), url, calls, viewOptions(bridge, taskfilePath, wsPath, taskBinPath, theme)...)
+	if wsPath != "" {
+		model = tui.NewServeModel(toolConfig(bridge), url, calls, viewOptions(bridge, taskfilePath, wsPath, taskBinPath, theme)...)
+	}
+	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithOutput(os.Stderr))
+
+	serveErr := make(chan error, 1)
+	go func() {
+		err := server.ServeHTTP(ctx, server.HTTPOptions{Addr: addr}, []server.Mount{{Bridge: bridge}})
+		if err != nil {
+			// Leave the TUI if the listener fails, e.g. because the port is taken.
+			p.Quit()
+		}
+		serveErr <- err
+	}()
+
+	if _, err := p.Run(); err != nil {
+		return withExitCode(exitExecutionFailed, fmt.Errorf("running TUI: %w", err))
+	}
+	cancel()
+	if err := <-serveErr; err != nil {
+		return withExitCode(exitExecutionFailed, fmt.Errorf("serving MCP: %w", err))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// watchInterval is how often --watch checks the Taskfile for changes.
+const watchInterval = time.Second
+
+// watchFile calls onChange whenever the modification time or size of path
+// changes, until ctx is cancelled. Stat errors are ignored since editors
+// often replace files rather than write them in place.
+func watchFile(ctx context.Context, path string, onChange func()) {
+	var lastMod time.Time
+	var lastSize int64
+	if info, err := os.Stat(path); err == nil {
+		lastMod, lastSize = info.ModTime(), info.Size()
+	}
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(path)
+		if err != nil || (info.ModTime().Equal(lastMod) && info.Size() == lastSize) {
+			continue
+		}
+		lastMod, lastSize = info.ModTime(), info.Size()
+		onChange()
+	}
+}
+
+// watchTaskfile re-inspects the Taskfile at path whenever it changes and
+// prints what changed since the previous inspection, until ctx is cancelled.
+func watchTaskfile(ctx context.Context, w io.Writer, path string, last *inspector.MCPConfig, inspect func(context.Context) (*inspector.MCPConfig, error)) {
+	fmt.Fprintf(w, "\nWatching %s for changes (Ctrl+C to stop)...\n", path)
+	watchFile(ctx, path, func() {
+		stamp := time.Now().Format("15:04:05")
+		config, err := inspect(ctx)
+		if err != nil {
+			fmt.Fprintf(w, "\n%s Error: %v\n", stamp, err)
+			return
+		}
+		diff := inspector.Diff(last, config)
+		fmt.Fprintf(w, "\n%s %s changed\n", stamp, filepath.Base(path))
+		printDiff(w, diff)
+		printNewDescriptions(w, diff, config)
+		last = config
+	})
+}
+
+// printNewDescriptions shows the new description of every changed task
+// whose description changed, which is usually what an author is iterating on.
+func printNewDescriptions(w io.Writer, diff inspector.ConfigDiff, config *inspector.MCPConfig) {
+	for _, change := range diff.Changed {
+		for _, field := range change.Fields {
+			if field != "Description" {
+				continue
+			}
+			for _, task := range config.Tasks {
+				if task.Name == change.Task {
+					fmt.Fprintf(w, "\n  %s:\n%s\n", task.Name, indent(task.Description, "    "))
+				}
+			}
+		}
+	}
+}
+
+// indent prefixes every line of s.
+func indent(s string, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
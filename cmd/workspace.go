@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+	"github.com/spf13/cobra"
+)
+
+// workspacePath returns the workspace manifest to use: the one given with
+// --workspace, or tmcp.workspace.yaml in the current directory when no
+// Taskfile was given. It returns "" when not using a workspace.
+func workspacePath(cmd *cobra.Command, args []string) string {
+	if path, _ := cmd.Flags().GetString("workspace"); path != "" {
+		return path
+	}
+	if len(args) == 0 {
+		if _, err := os.Stat(config.WorkspaceFileName); err == nil {
+			return config.WorkspaceFileName
+		}
+	}
+	return ""
+}
+
+// taskfileArg returns the Taskfile given as argument, fetching remote ones
+// into the cache, or, without one, the Taskfile task would use: the closest
+// one in the current directory or its parents.
+func taskfileArg(cmd *cobra.Command, args []string) (string, error) {
+	if isRemoteArg(args) {
+		return fetchTaskfile(cmd, args[0])
+	}
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	path, err := config.FindTaskfile(".")
+	if err != nil {
+		return "", withExitCode(exitTaskfileNotFound, err)
+	}
+	return path, nil
+}
+
+// workspaceSources maps the Taskfiles of a workspace to bridge sources.
+// Taskfiles without their own task binary use --task-bin.
+func workspaceSources(cmd *cobra.Command, ws *config.Workspace) ([]server.Source, error) {
+	defaultBin, _ := cmd.Flags().GetString("task-bin")
+	var sources []server.Source
+	for _, tf := range ws.Taskfiles {
+		if err := checkTaskfile(tf.Path); err != nil {
+			return nil, err
+		}
+		bin := tf.Executor.TaskBin
+		if bin == "" {
+			bin = defaultBin
+		}
+		taskBinPath, err := checkTaskBinFor(bin, tf.Source(), tf.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", tf.Path, err)
+		}
+
+		opts := []server.Option{
+			server.WithTaskFilter(tf.Include, tf.Exclude),
+			server.WithEnv(tf.Executor.Env),
+		}
+		if tf.Prefix != "" {
+			opts = append(opts, server.WithToolPrefix(tf.Prefix))
+		}
+		if tf.Executor.Dir != "" {
+			opts = append(opts, server.WithDir(tf.Executor.Dir))
+		}
+		switch tf.Policy {
+		case config.PolicySafe:
+			opts = append(opts, server.WithSafeMode(tf.Allow))
+		case config.PolicyReadOnly:
+			opts = append(opts, server.WithReadOnlyTasks())
+		}
+		sources = append(sources, server.Source{Taskfile: tf.Path, TaskBin: taskBinPath, Kind: tf.Source(), Options: opts})
+	}
+	return sources, nil
+}
+
+// newWorkspaceBridge loads the workspace manifest at path and builds one
+// bridge serving all of its Taskfiles. Errors carry the code tmcp exits
+// with.
+func newWorkspaceBridge(ctx context.Context, cmd *cobra.Command, path string, serverName string, opts ...server.Option) (*server.Bridge, error) {
+	ws, err := config.LoadWorkspace(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, withExitCode(exitTaskfileNotFound, err)
+	}
+	if err != nil {
+		return nil, withExitCode(exitUsage, err)
+	}
+	return workspaceBridge(ctx, cmd, ws, serverName, opts...)
+}
+
+// workspaceBridge builds one bridge serving all Taskfiles of ws. Errors
+// carry the code tmcp exits with.
+func workspaceBridge(ctx context.Context, cmd *cobra.Command, ws *config.Workspace, serverName string, opts ...server.Option) (*server.Bridge, error) {
+	sources, err := workspaceSources(cmd, ws)
+	if err != nil {
+		return nil, err
+	}
+	bridge, err := server.NewWorkspace(ctx, serverName, sources, opts...)
+	return bridge, withExitCode(exitInspectionFailed, err)
+}
+
+// dirArg returns the directory given as argument, or the current one.
+func dirArg(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return "."
+}
+
+// discoverWorkspace builds the workspace of every Taskfile under the
+// directory given as argument, or the current directory, for --recursive.
+func discoverWorkspace(cmd *cobra.Command, args []string) (*config.Workspace, error) {
+	if path, _ := cmd.Flags().GetString("workspace"); path != "" {
+		return nil, usageErrorf("--recursive can't be used with --workspace")
+	}
+	root := dirArg(args)
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return nil, usageErrorf("--recursive needs a directory, not %s", root)
+	}
+	ws, err := config.DiscoverWorkspace(root)
+	if errors.Is(err, config.ErrNoTaskfile) {
+		return nil, withExitCode(exitTaskfileNotFound, err)
+	}
+	return ws, err
+}
+
+// toolConfig returns the bridge's tasks named after the tools they are
+// exposed as, which is how a workspace is best browsed.
+func toolConfig(bridge *server.Bridge) *inspector.MCPConfig {
+	names := bridge.ToolNames()
+	config := &inspector.MCPConfig{}
+	for i, task := range bridge.Config().Tasks {
+		task.Name = names[i].Tool
+		config.Tasks = append(config.Tasks, task)
+	}
+	return config
+}
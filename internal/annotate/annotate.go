@@ -0,0 +1,238 @@
+// Package annotate drafts the desc and summary of Taskfile tasks that lack
+// them with an LLM and writes them back into the Taskfile, leaving the rest
+// of the file untouched.
+package annotate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+	"gopkg.in/yaml.v3"
+)
+
+// Task is a task of a Taskfile that lacks a desc or a summary.
+type Task struct {
+	Name       string
+	HasDesc    bool
+	HasSummary bool
+	// Source is the task's YAML as written in the Taskfile.
+	Source string
+
+	// line is the 1-based line of the task's name; indent is the column
+	// of its keys.
+	line   int
+	indent int
+}
+
+// Annotation is the drafted documentation of a task.
+type Annotation struct {
+	// Desc is a one-line description.
+	Desc string `json:"desc"`
+	// Description is the first part of the summary, before the Usage and
+	// Required lines.
+	Description string      `json:"description"`
+	Parameters  []Parameter `json:"parameters"`
+}
+
+// Parameter is a variable the task reads.
+type Parameter struct {
+	Name     string `json:"name"`
+	Required bool   `json:"required"`
+}
+
+// Edit inserts Lines after line After of the Taskfile.
+type Edit struct {
+	Task  string
+	After int
+	Lines []string
+}
+
+// Find returns the tasks of the Taskfile in data that lack a desc or a
+// summary, in file order. Tasks written in the short string, list or flow
+// forms are skipped, since keys can't be added to them in place.
+func Find(data []byte) ([]Task, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("not a Taskfile: expected a mapping")
+	}
+	lines := strings.Split(string(data), "\n")
+	var tasks *yaml.Node
+	// tasksEnd is the last line of the tasks section.
+	tasksEnd := len(lines)
+	doc := root.Content[0]
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value == "tasks" {
+			tasks = doc.Content[i+1]
+			if i+2 < len(doc.Content) {
+				tasksEnd = doc.Content[i+2].Line - 1
+			}
+		}
+	}
+	if tasks == nil || tasks.Kind != yaml.MappingNode {
+		return nil, errors.New("not a Taskfile: no tasks")
+	}
+
+	var found []Task
+	for i := 0; i+1 < len(tasks.Content); i += 2 {
+		key, body := tasks.Content[i], tasks.Content[i+1]
+		if body.Kind != yaml.MappingNode || body.Style&yaml.FlowStyle != 0 || len(body.Content) == 0 {
+			continue
+		}
+		t := Task{Name: key.Value, line: key.Line, indent: body.Content[0].Column - 1}
+		for j := 0; j+1 < len(body.Content); j += 2 {
+			switch body.Content[j].Value {
+			case "desc":
+				t.HasDesc = strings.TrimSpace(body.Content[j+1].Value) != ""
+			case "summary":
+				t.HasSummary = strings.TrimSpace(body.Content[j+1].Value) != ""
+			}
+		}
+		if t.HasDesc && t.HasSummary {
+			continue
+		}
+		// The task's source runs up to the next task or the end of tasks.
+		end := tasksEnd
+		if i+2 < len(tasks.Content) {
+			end = tasks.Content[i+2].Line - 1
+		}
+		t.Source = strings.TrimRight(strings.Join(lines[key.Line-1:end], "\n"), "\n ")
+		found = append(found, t)
+	}
+	return found, nil
+}
+
+// variableName matches the names of Taskfile variables.
+var variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// jsonObject matches the outermost JSON object of a reply, which models
+// sometimes wrap in prose or code fences.
+var jsonObject = regexp.MustCompile(`(?s)\{.*\}`)
+
+// Draft asks llm to document task.
+func Draft(ctx context.Context, llm llms.Model, task Task, options ...llms.CallOption) (Annotation, error) {
+	prompt := fmt.Sprintf(`You document tasks of a Taskfile (https://taskfile.dev) so AI agents can use them as tools.
+
+Here is the task %q:
+
+%s
+
+Reply with only a JSON object with these fields:
+- "desc": one short sentence saying what the task does, without a trailing period.
+- "description": one to three sentences for an agent deciding whether to call the task: what it does, what it returns and any side effects.
+- "parameters": the variables a caller can set, as {"name": "...", "required": true|false}. Only list variables the task reads, e.g. as {{.NAME}}; required ones have no default.
+`, task.Name, task.Source)
+	reply, err := llms.GenerateFromSinglePrompt(ctx, llm, prompt, options...)
+	if err != nil {
+		return Annotation{}, err
+	}
+	var a Annotation
+	if err := json.Unmarshal([]byte(jsonObject.FindString(reply)), &a); err != nil {
+		return Annotation{}, fmt.Errorf("reading the model's reply: %w", err)
+	}
+	a.Desc = strings.TrimSpace(strings.ReplaceAll(a.Desc, "\n", " "))
+	a.Description = strings.TrimSpace(a.Description)
+	if a.Desc == "" || a.Description == "" {
+		return Annotation{}, errors.New("the model's reply has no desc or description")
+	}
+	// Drop parameters the model made up.
+	var params []Parameter
+	for _, p := range a.Parameters {
+		if variableName.MatchString(p.Name) && regexp.MustCompile(`\b`+p.Name+`\b`).MatchString(task.Source) {
+			params = append(params, p)
+		}
+	}
+	a.Parameters = params
+	return a, nil
+}
+
+// Summary renders the summary of task in the form tmcp parses: the
+// description, then a Usage line listing the parameters and a Required line.
+func (a Annotation) Summary(task string) string {
+	usage := []string{"task", task}
+	var required []string
+	for _, p := range a.Parameters {
+		usage = append(usage, fmt.Sprintf("%s=<%s>", p.Name, strings.ToLower(p.Name)))
+		if p.Required {
+			required = append(required, p.Name)
+		}
+	}
+	if len(required) == 0 {
+		required = []string{"None"}
+	}
+	return fmt.Sprintf("%s\nUsage: %s\nRequired: %s", a.Description, strings.Join(usage, " "), strings.Join(required, ", "))
+}
+
+// Plan returns the edits adding the drafted desc and summary to each task
+// that lacks them, in file order.
+func Plan(tasks []Task, annotations map[string]Annotation) ([]Edit, error) {
+	var edits []Edit
+	for _, t := range tasks {
+		a, ok := annotations[t.Name]
+		if !ok {
+			continue
+		}
+		pad := strings.Repeat(" ", t.indent)
+		var lines []string
+		if !t.HasDesc {
+			desc, err := yaml.Marshal(map[string]string{"desc": a.Desc})
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, pad+strings.TrimRight(string(desc), "\n"))
+		}
+		if !t.HasSummary {
+			lines = append(lines, pad+"summary: |")
+			for _, line := range strings.Split(a.Summary(t.Name), "\n") {
+				line = strings.TrimRight(line, " \t")
+				if line == "" {
+					lines = append(lines, "")
+					continue
+				}
+				lines = append(lines, pad+"  "+strings.TrimLeft(line, " \t"))
+			}
+		}
+		edits = append(edits, Edit{Task: t.Name, After: t.line, Lines: lines})
+	}
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].After < edits[j].After })
+	return edits, nil
+}
+
+// Apply returns data with the edits made.
+func Apply(data []byte, edits []Edit) []byte {
+	lines := strings.Split(string(data), "\n")
+	var out []string
+	next := 0
+	for _, e := range edits {
+		out = append(out, lines[next:e.After]...)
+		out = append(out, e.Lines...)
+		next = e.After
+	}
+	out = append(out, lines[next:]...)
+	return []byte(strings.Join(out, "\n"))
+}
+
+// Diff renders the edits as a unified diff of the file at path.
+func Diff(path string, data []byte, edits []Edit) string {
+	lines := strings.Split(string(data), "\n")
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", path, path)
+	added := 0
+	for _, e := range edits {
+		fmt.Fprintf(&b, "@@ -%d +%d,%d @@ %s\n", e.After, e.After+added, len(e.Lines)+1, e.Task)
+		fmt.Fprintf(&b, " %s\n", lines[e.After-1])
+		for _, line := range e.Lines {
+			fmt.Fprintf(&b, "+%s\n", line)
+		}
+		added += len(e.Lines)
+	}
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package annotate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms/fake"
+	"gopkg.in/yaml.v3"
+)
+
+const taskfile = `version: '3'
+
+tasks:
+  # Builds the binary.
+  build:
+    cmds:
+      - go build -o {{.OUT}} ./...
+
+  test:
+    desc: Run the tests
+    cmds:
+      - go test ./...
+
+  lint: golangci-lint run
+
+  docs:
+    desc: Serve the docs
+    summary: |
+      Serve the docs on localhost.
+      Usage: task docs
+      Required: None
+    cmds:
+      - mkdocs serve
+
+vars:
+  OUT: bin/app
+`
+
+func TestFind(t *testing.T) {
+	tasks, err := Find([]byte(taskfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 2 || tasks[0].Name != "build" || tasks[1].Name != "test" {
+		t.Fatalf("Find() = %+v, want build and test", tasks)
+	}
+	if tasks[0].HasDesc || tasks[0].HasSummary || !tasks[1].HasDesc || tasks[1].HasSummary {
+		t.Errorf("Find() = %+v", tasks)
+	}
+	if want := "  build:\n    cmds:\n      - go build -o {{.OUT}} ./..."; tasks[0].Source != want {
+		t.Errorf("build source = %q, want %q", tasks[0].Source, want)
+	}
+	if strings.Contains(tasks[1].Source, "lint") || strings.Contains(tasks[1].Source, "vars:") {
+		t.Errorf("test source = %q, want only the test task", tasks[1].Source)
+	}
+
+	if _, err := Find([]byte("version: '3'\n")); err == nil {
+		t.Error("Find() error = nil for a file without tasks")
+	}
+}
+
+func TestDraft(t *testing.T) {
+	tasks, err := Find([]byte(taskfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	llm := fake.NewFakeLLM([]string{"Here you go:\n```json\n" + `{"desc": "Build the binary", "description": "Compile the app.\nWrites the binary to OUT.", "parameters": [{"name": "OUT", "required": false}, {"name": "GOOS", "required": true}]}` + "\n```"})
+	a, err := Draft(context.Background(), llm, tasks[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Desc != "Build the binary" || len(a.Parameters) != 1 || a.Parameters[0].Name != "OUT" {
+		t.Errorf("Draft() = %+v, want GOOS dropped as it isn't used", a)
+	}
+	if want := "Compile the app.\nWrites the binary to OUT.\nUsage: task build OUT=<out>\nRequired: None"; a.Summary("build") != want {
+		t.Errorf("Summary() = %q, want %q", a.Summary("build"), want)
+	}
+
+	if _, err := Draft(context.Background(), fake.NewFakeLLM([]string{"I can't help with that."}), tasks[0]); err == nil {
+		t.Error("Draft() error = nil for a reply without JSON")
+	}
+}
+
+func TestPlanApply(t *testing.T) {
+	tasks, err := Find([]byte(taskfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations := map[string]Annotation{
+		"build": {Desc: "Build: the binary", Description: "Compile the app.", Parameters: []Parameter{{Name: "OUT", Required: true}}},
+		"test":  {Desc: "ignored", Description: "Run all tests."},
+	}
+	edits, err := Plan(tasks, annotations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(Apply([]byte(taskfile), edits))
+	for _, want := range []string{
+		"  build:\n    desc: 'Build: the binary'\n    summary: |\n      Compile the app.\n      Usage: task build OUT=<out>\n      Required: OUT\n    cmds:",
+		"  test:\n    summary: |\n      Run all tests.\n      Usage: task test\n      Required: None\n    desc: Run the tests",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Apply() =\n%s\nwant it to contain\n%s", got, want)
+		}
+	}
+	var parsed struct {
+		Tasks map[string]any `yaml:"tasks"`
+	}
+	if err := yaml.Unmarshal([]byte(got), &parsed); err != nil {
+		t.Fatalf("annotated Taskfile doesn't parse: %v", err)
+	}
+	build, _ := parsed.Tasks["build"].(map[string]any)
+	test, _ := parsed.Tasks["test"].(map[string]any)
+	if build["desc"] != "Build: the binary" || test["desc"] != "Run the tests" {
+		t.Errorf("parsed tasks = %+v", parsed.Tasks)
+	}
+
+	diff := Diff("Taskfile.yml", []byte(taskfile), edits)
+	if !strings.HasPrefix(diff, "--- Taskfile.yml\n+++ Taskfile.yml\n@@ -5 +5,6 @@ build\n   build:\n+    desc:") {
+		t.Errorf("Diff() =\n%s", diff)
+	}
+	if !strings.Contains(diff, "@@ -9 +14,5 @@ test\n   test:\n+    summary: |") {
+		t.Errorf("Diff() =\n%s", diff)
+	}
+}
@@ -0,0 +1,121 @@
+package clients
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// ServerEntry is an MCP server launch entry as stored under "mcpServers" in
+// client config files.
+type ServerEntry struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// Client is an MCP client application installed on this machine.
+type Client struct {
+	Name string
+	// ConfigPath is the JSON file holding the client's "mcpServers" object.
+	// Empty for clients registered through their own CLI.
+	ConfigPath string
+	// CLI is the binary used to register servers when ConfigPath is empty.
+	CLI string
+}
+
+// Detect returns the MCP clients that appear to be installed.
+func Detect() []Client {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	var found []Client
+	for _, c := range knownConfigClients(home) {
+		// The client is considered installed once its config directory exists.
+		if info, err := os.Stat(filepath.Dir(c.ConfigPath)); err == nil && info.IsDir() {
+			found = append(found, c)
+		}
+	}
+	if path, err := exec.LookPath("claude"); err == nil {
+		found = append(found, Client{Name: "Claude Code", CLI: path})
+	}
+	return found
+}
+
+func knownConfigClients(home string) []Client {
+	var desktopDir string
+	switch runtime.GOOS {
+	case "darwin":
+		desktopDir = filepath.Join(home, "Library", "Application Support", "Claude")
+	case "windows":
+		desktopDir = filepath.Join(os.Getenv("APPDATA"), "Claude")
+	default:
+		desktopDir = filepath.Join(home, ".config", "Claude")
+	}
+	return []Client{
+		{Name: "Claude Desktop", ConfigPath: filepath.Join(desktopDir, "claude_desktop_config.json")},
+		{Name: "Cursor", ConfigPath: filepath.Join(home, ".cursor", "mcp.json")},
+		{Name: "Windsurf", ConfigPath: filepath.Join(home, ".codeium", "windsurf", "mcp_config.json")},
+	}
+}
+
+// RegisterCommand returns the command line that registers the server with a
+// CLI-managed client such as Claude Code.
+func (c Client) RegisterCommand(name string, entry ServerEntry) []string {
+	cmd := []string{c.CLI, "mcp", "add", name, "--", entry.Command}
+	return append(cmd, entry.Args...)
+}
+
+// Register adds or replaces the server entry in the client's config file,
+// keeping every other setting intact. The previous file is kept as a .bak.
+func (c Client) Register(name string, entry ServerEntry) error {
+	if c.ConfigPath == "" {
+		return fmt.Errorf("%s is configured through its CLI, not a config file", c.Name)
+	}
+
+	doc := map[string]any{}
+	data, err := os.ReadFile(c.ConfigPath)
+	switch {
+	case err == nil:
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &doc); err != nil {
+				return fmt.Errorf("parsing %s: %w", c.ConfigPath, err)
+			}
+		}
+		if err := os.WriteFile(c.ConfigPath+".bak", data, 0600); err != nil {
+			return fmt.Errorf("backing up %s: %w", c.ConfigPath, err)
+		}
+	case errors.Is(err, os.ErrNotExist):
+		if err := os.MkdirAll(filepath.Dir(c.ConfigPath), 0755); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	servers, ok := doc["mcpServers"].(map[string]any)
+	if !ok {
+		servers = map[string]any{}
+	}
+	servers[name] = entry
+	doc["mcpServers"] = servers
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.ConfigPath, append(out, '\n'), 0600)
+}
+
+// Snippet renders the "mcpServers" JSON for manual installation.
+func Snippet(name string, entry ServerEntry) string {
+	out, _ := json.MarshalIndent(map[string]any{
+		"mcpServers": map[string]any{name: entry},
+	}, "", "  ")
+	return string(out)
+}
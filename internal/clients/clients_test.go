@@ -0,0 +1,78 @@
+package clients
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	entry := ServerEntry{Command: "/usr/local/bin/tmcp", Args: []string{"/work/Taskfile.yml"}}
+
+	t.Run("creates a new config file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "nested", "mcp.json")
+		c := Client{Name: "Test", ConfigPath: path}
+		if err := c.Register("tasks", entry); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+
+		var doc map[string]map[string]ServerEntry
+		data, _ := os.ReadFile(path)
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("config is not valid JSON: %v", err)
+		}
+		if !reflect.DeepEqual(doc["mcpServers"]["tasks"], entry) {
+			t.Errorf("mcpServers.tasks = %+v, want %+v", doc["mcpServers"]["tasks"], entry)
+		}
+	})
+
+	t.Run("keeps existing settings and servers", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "mcp.json")
+		existing := `{"theme": "dark", "mcpServers": {"other": {"command": "other-server"}}}`
+		if err := os.WriteFile(path, []byte(existing), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		c := Client{Name: "Test", ConfigPath: path}
+		if err := c.Register("tasks", entry); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+
+		var doc map[string]any
+		data, _ := os.ReadFile(path)
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("config is not valid JSON: %v", err)
+		}
+		if doc["theme"] != "dark" {
+			t.Errorf("theme = %v, want dark", doc["theme"])
+		}
+		servers := doc["mcpServers"].(map[string]any)
+		if _, ok := servers["other"]; !ok {
+			t.Errorf("existing server entry was dropped")
+		}
+		if _, ok := servers["tasks"]; !ok {
+			t.Errorf("new server entry missing")
+		}
+		if backup, err := os.ReadFile(path + ".bak"); err != nil || string(backup) != existing {
+			t.Errorf("backup = %q, %v, want original content", backup, err)
+		}
+	})
+
+	t.Run("CLI clients cannot be written", func(t *testing.T) {
+		c := Client{Name: "Claude Code", CLI: "claude"}
+		if err := c.Register("tasks", entry); err == nil {
+			t.Fatal("Register() error = nil, want error")
+		}
+	})
+}
+
+func TestRegisterCommand(t *testing.T) {
+	c := Client{Name: "Claude Code", CLI: "claude"}
+	got := c.RegisterCommand("tasks", ServerEntry{Command: "tmcp", Args: []string{"Taskfile.yml"}})
+	want := []string{"claude", "mcp", "add", "tasks", "--", "tmcp", "Taskfile.yml"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RegisterCommand() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,756 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/policy"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/secrets"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFileName is the config file tmcp looks for next to the Taskfile.
+const DefaultFileName = ".tmcp.yml"
+
+// Config is the optional tmcp configuration file.
+type Config struct {
+	// Inject maps task variable names to request metadata sources, e.g.
+	// REQUESTED_BY: client.name. Injected variables always override
+	// arguments of the same name sent by the model.
+	Inject map[string]string `yaml:"inject"`
+	// Secrets maps environment variable names to secret sources such as
+	// vault:kv/db#password. They are fetched on every task execution.
+	Secrets map[string]string `yaml:"secrets"`
+	// Servers defines named MCP servers for `tmcp serve --all`, each
+	// backed by its own Taskfile.
+	Servers map[string]ServerConfig `yaml:"servers"`
+	// Safe configures `tmcp serve --safe`.
+	Safe SafeConfig `yaml:"safe"`
+	// LogLevel is the minimum level of log messages (debug, info, warn, error).
+	LogLevel string `yaml:"log_level"`
+	// RateLimit caps tool calls per minute; zero means unlimited.
+	RateLimit int `yaml:"rate_limit"`
+	// Budget bounds what each client session may use up, so runaway agents
+	// are stopped.
+	Budget BudgetConfig `yaml:"budget"`
+	// MaxOutput is the size in bytes above which task output is saved to a
+	// file and only previewed in results. Zero uses the default.
+	MaxOutput int `yaml:"max_output"`
+	// PageSize caps the tools, resources and prompts returned per list
+	// request; clients fetch the rest with the next cursor. Zero returns
+	// everything at once.
+	PageSize int `yaml:"page_size"`
+	// ProtocolVersion pins the MCP protocol version every client is
+	// answered with, for clients that misbehave with newer versions.
+	ProtocolVersion string `yaml:"protocol_version"`
+	// DisableCapabilities lists the server capabilities not to announce
+	// or serve: resources, prompts or logging.
+	DisableCapabilities []string `yaml:"disable_capabilities"`
+	// Retry maps path.Match patterns of task names to retry policies, for
+	// tasks that don't set x-mcp.retry themselves.
+	Retry map[string]inspector.RetryPolicy `yaml:"retry"`
+	// Concurrency maps group names to path.Match patterns of task names.
+	// Tasks in the same group never run concurrently; later calls queue.
+	Concurrency map[string][]string `yaml:"concurrency"`
+	// Cache maps path.Match patterns of task names to how long their
+	// successful results are reused for identical arguments, for tasks that
+	// don't set x-mcp.cache_ttl themselves.
+	Cache map[string]time.Duration `yaml:"cache"`
+	// When maps path.Match patterns of task names to the conditions under
+	// which they are served as tools. Tasks whose conditions don't hold are
+	// left out, as if excluded.
+	When map[string]Condition `yaml:"when"`
+	// Policies are checked before every task tool call. The first policy
+	// whose deny expression holds rejects the call.
+	Policies []Policy `yaml:"policies"`
+	// Approval parks calls of some tasks until a human approves them.
+	Approval ApprovalConfig `yaml:"approval"`
+	// Notify tells humans when long-running tasks finish.
+	Notify NotifyConfig `yaml:"notify"`
+	// Scheduler lets clients schedule tasks on cron expressions.
+	Scheduler SchedulerConfig `yaml:"scheduler"`
+	// Upstreams are other MCP servers whose tools are served alongside the
+	// tasks, keyed by a name that also prefixes their tool names.
+	Upstreams map[string]UpstreamConfig `yaml:"upstreams"`
+	// Sessions lets each client pick its own working directory and
+	// environment with the configure_session tool.
+	Sessions SessionsConfig `yaml:"sessions"`
+	// Plugins are external programs that hook into task tool calls, keyed
+	// by name. Their hooks run in name order.
+	Plugins map[string]PluginConfig `yaml:"plugins"`
+	// Profiles are named sets of settings, selected with --profile, e.g.
+	// for dev laptops, CI and production.
+	Profiles map[string]ProfileConfig `yaml:"profiles"`
+	// Pipelines are tools that run several tasks in order with shared
+	// arguments, keyed by tool name.
+	Pipelines map[string]PipelineConfig `yaml:"pipelines"`
+	// UsagePattern is a regular expression matching the usage line of task
+	// summaries, for summaries not written in English. Its one capture group
+	// is the usage, e.g. (?i)^\s*uso\s*:\s*(.*)$.
+	UsagePattern string `yaml:"usage_pattern"`
+	// UsageRegexp is UsagePattern as parsed by Validate; nil when it isn't
+	// set.
+	UsageRegexp *regexp.Regexp `yaml:"-"`
+	// Profile is the profile chosen with SelectProfile; nil when there is
+	// none.
+	Profile *ProfileConfig `yaml:"-"`
+
+	// Path is the file the config was loaded from, or would be loaded
+	// from when it doesn't exist yet.
+	Path string `yaml:"-"`
+}
+
+// Condition is a check of the machine tmcp runs on. Every part that is set
+// must hold.
+type Condition struct {
+	// Env maps environment variable names to the values they must have.
+	Env map[string]string `yaml:"env"`
+	// Files must all exist. Relative paths are resolved against the config
+	// file's directory.
+	Files []string `yaml:"files"`
+	// Platforms are the operating systems, as GOOS, on which the condition
+	// holds, e.g. linux.
+	Platforms []string `yaml:"platforms"`
+}
+
+// Unmet returns why the condition doesn't hold, or "" when it does.
+// getenv looks up environment variables.
+func (c Condition) Unmet(getenv func(string) string) string {
+	if len(c.Platforms) > 0 && !slices.Contains(c.Platforms, runtime.GOOS) {
+		return fmt.Sprintf("the platform is %s, not %s", runtime.GOOS, strings.Join(c.Platforms, " or "))
+	}
+	names := make([]string, 0, len(c.Env))
+	for name := range c.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if getenv(name) != c.Env[name] {
+			return fmt.Sprintf("%s is not %q", name, c.Env[name])
+		}
+	}
+	for _, file := range c.Files {
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Sprintf("%s does not exist", file)
+		}
+	}
+	return ""
+}
+
+// SafeConfig configures safe mode.
+type SafeConfig struct {
+	// Allow lists path.Match patterns of tasks that run for real in safe
+	// mode even though they are not marked read-only.
+	Allow []string `yaml:"allow"`
+	// DryRun dry-runs tasks that are neither read-only nor allowed, even
+	// without --safe.
+	DryRun bool `yaml:"dry_run"`
+}
+
+// BudgetConfig bounds the task tool calls of each client session. Zero
+// fields are unlimited.
+type BudgetConfig struct {
+	// MaxCalls is the number of task tool calls.
+	MaxCalls int `yaml:"max_calls"`
+	// MaxRuntime is the total time the session's tasks run.
+	MaxRuntime time.Duration `yaml:"max_runtime"`
+	// MaxCost is the total of the tasks' x-mcp.cost estimates.
+	MaxCost float64 `yaml:"max_cost"`
+}
+
+// Policy rejects the tool calls its Deny expression holds for.
+type Policy struct {
+	Name string `yaml:"name"`
+	// Deny is an expression of package policy. Besides task, tool and
+	// args.<name>, it can use the inject sources, e.g. client.name.
+	Deny string `yaml:"deny"`
+	// Message is returned to the client along with the policy name.
+	Message string `yaml:"message"`
+
+	// Expr is Deny as parsed by Validate.
+	Expr *policy.Expr `yaml:"-"`
+}
+
+// policyIdentifiers are the identifiers of a policy expression that aren't
+// inject sources.
+var policyIdentifiers = map[string]bool{"task": true, "tool": true}
+
+// ApprovalConfig configures human approval of tool calls.
+type ApprovalConfig struct {
+	// Tasks lists path.Match patterns of the tasks whose calls need approval.
+	Tasks []string `yaml:"tasks"`
+	// Timeout denies calls nobody decided on in time; five minutes when
+	// zero.
+	Timeout time.Duration `yaml:"timeout"`
+	// Notify lists how humans are asked: "terminal" prompts on the
+	// terminal tmcp runs in, "desktop" shows a desktop notification.
+	// Waiting calls can always be decided via the admin API.
+	Notify []string `yaml:"notify"`
+}
+
+// disableableCapabilities are the valid values of DisableCapabilities.
+var disableableCapabilities = map[string]bool{"resources": true, "prompts": true, "logging": true}
+
+// approvalNotifiers are the valid values of ApprovalConfig.Notify.
+var approvalNotifiers = map[string]bool{"terminal": true, "desktop": true}
+
+// NotifyConfig configures notifications of finished tasks. They are sent
+// when any of Desktop, Slack and Webhooks is set.
+type NotifyConfig struct {
+	// After is how long a task must have run to be notified about; 30
+	// seconds when zero.
+	After time.Duration `yaml:"after"`
+	// Tasks lists path.Match patterns of the tasks to notify about; all
+	// tasks when empty.
+	Tasks []string `yaml:"tasks"`
+	// Desktop shows a desktop notification.
+	Desktop bool `yaml:"desktop"`
+	// Slack is the URL of a Slack incoming webhook to post to.
+	Slack string `yaml:"slack"`
+	// Webhooks are URLs each finished task is POSTed to as JSON.
+	Webhooks []string `yaml:"webhooks"`
+}
+
+// Enabled reports whether n sends any notifications.
+func (n NotifyConfig) Enabled() bool {
+	return n.Desktop || n.Slack != "" || len(n.Webhooks) > 0
+}
+
+// validateWebhookURL checks that raw is an http(s) URL. URLs taken from the
+// environment, starting with ${NAME}, are checked once expanded.
+func validateWebhookURL(raw string) error {
+	if strings.HasPrefix(raw, "$") {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http(s) URL", raw)
+	}
+	return nil
+}
+
+// SchedulerConfig configures the schedule_task tool.
+type SchedulerConfig struct {
+	// File stores the schedules and enables the scheduler. It is resolved
+	// relative to the config file. In multi-server mode each server keeps
+	// its schedules in a file named after it, see ScheduleFile.
+	File string `yaml:"file"`
+	// Allow lists path.Match patterns of the tasks that may be scheduled;
+	// all tasks when empty.
+	Allow []string `yaml:"allow"`
+}
+
+// ScheduleFile returns the schedules file of the named server in
+// multi-server mode, e.g. schedules.api.json for schedules.json.
+func (s SchedulerConfig) ScheduleFile(server string) string {
+	ext := filepath.Ext(s.File)
+	return strings.TrimSuffix(s.File, ext) + "." + server + ext
+}
+
+// SessionsConfig configures the configure_session tool, which is offered
+// when Dirs or Profiles is set.
+type SessionsConfig struct {
+	// Dirs lists path.Match patterns of the directories a session may run
+	// tasks from, e.g. /home/me/src/*. Relative patterns are resolved
+	// relative to the config file.
+	Dirs []string `yaml:"dirs"`
+	// Profiles are named sets of environment variables. A session's profile
+	// is added to the environment of every task it runs.
+	Profiles map[string]map[string]string `yaml:"profiles"`
+}
+
+// ServerConfig is one named server in multi-server mode.
+type ServerConfig struct {
+	// Taskfile is resolved relative to the config file.
+	Taskfile string `yaml:"taskfile"`
+	// Include and Exclude filter the exposed tasks with path.Match patterns.
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+	// Env sets extra environment variables for this server's tasks.
+	Env map[string]string `yaml:"env"`
+	// ToolPrefix is prepended to this server's tool names.
+	ToolPrefix string `yaml:"tool_prefix"`
+}
+
+// Plugin hooks, see PluginConfig.Hooks.
+const (
+	PluginHookArguments = "arguments"
+	PluginHookResult    = "result"
+)
+
+// PluginConfig is an external program speaking tmcp's plugin protocol.
+type PluginConfig struct {
+	// Command runs the plugin, e.g. [./plugins/audit, --verbose]. A
+	// relative path is resolved against the config file's directory.
+	Command []string `yaml:"command"`
+	// Env sets extra environment variables for Command.
+	Env map[string]string `yaml:"env"`
+	// Hooks are the hooks the plugin takes part in: arguments, to rewrite
+	// or reject a call's arguments, and result, to rewrite its result.
+	Hooks []string `yaml:"hooks"`
+	// Tools limits the plugin to tools matching these path.Match patterns;
+	// all tools when empty.
+	Tools []string `yaml:"tools"`
+}
+
+// ProfileConfig is a named set of settings, so that serving for CI or
+// production takes one flag instead of many.
+type ProfileConfig struct {
+	// Include and Exclude filter the served tasks with path.Match patterns,
+	// on top of any other filter.
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+	// Env sets extra environment variables for every task.
+	Env map[string]string `yaml:"env"`
+	// PassEnv lists the variables tasks get from tmcp's environment. When
+	// set, tasks run with a minimal environment plus these, as in safe
+	// mode, instead of all of tmcp's.
+	PassEnv []string `yaml:"pass_env"`
+	// HandshakeTimeout and ReadTimeout replace the defaults of
+	// --handshake-timeout and --read-timeout when set.
+	HandshakeTimeout time.Duration `yaml:"handshake_timeout"`
+	ReadTimeout      time.Duration `yaml:"read_timeout"`
+	// Approval replaces the approval section, e.g. to confirm deploys only
+	// in production.
+	Approval *ApprovalConfig `yaml:"approval"`
+	// Safe serves in safe mode, as with --safe.
+	Safe bool `yaml:"safe"`
+}
+
+// PipelineConfig is a tool that runs tasks one after the other, stopping at
+// the first that fails.
+type PipelineConfig struct {
+	// Description is the tool's description.
+	Description string `yaml:"description"`
+	// Steps are the tasks to run, in order.
+	Steps []PipelineStep `yaml:"steps"`
+}
+
+// PipelineStep is a task of a pipeline. A plain string is the task's name.
+type PipelineStep struct {
+	Task string `yaml:"task"`
+	// Args are fixed arguments of the task, which the pipeline's callers
+	// can't set for this step.
+	Args map[string]string `yaml:"args"`
+	// Compensate is the task that undoes this step when a later step
+	// fails, e.g. undeploy for deploy.
+	Compensate string `yaml:"compensate"`
+}
+
+// UnmarshalYAML decodes a step from a task name or a mapping.
+func (s *PipelineStep) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&s.Task)
+	}
+	type plain PipelineStep
+	return node.Decode((*plain)(s))
+}
+
+// UpstreamConfig is an MCP server whose tools tmcp serves. Exactly one of
+// Command and URL must be set.
+type UpstreamConfig struct {
+	// Command starts a stdio server, e.g. [npx, -y, @modelcontextprotocol/server-github].
+	Command []string `yaml:"command"`
+	// Env sets extra environment variables for Command.
+	Env map[string]string `yaml:"env"`
+	// URL is the endpoint of a streamable HTTP server.
+	URL string `yaml:"url"`
+	// Headers are sent with every request to URL. ${NAME} in a value is
+	// replaced by the secret or environment variable NAME.
+	Headers map[string]string `yaml:"headers"`
+	// Secrets maps variable names to secret sources. They are passed to
+	// Command as environment variables and can be used in Headers, so
+	// clients never need the upstream's credentials.
+	Secrets map[string]string `yaml:"secrets"`
+	// ToolPrefix is prepended to the upstream's tool names; the upstream's
+	// name followed by "_" when empty.
+	ToolPrefix string `yaml:"tool_prefix"`
+	// Include and Exclude filter the upstream's tools with path.Match patterns.
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+// serverNamePattern keeps server names usable as URL path segments.
+var serverNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Load reads the config file at path. An empty path looks for
+// DefaultFileName in the Taskfile's directory and returns an empty Config
+// when it doesn't exist.
+func Load(path string, taskfilePath string) (*Config, error) {
+	explicit := path != ""
+	if !explicit {
+		path = filepath.Join(filepath.Dir(taskfilePath), DefaultFileName)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return &Config{Path: path}, nil
+		}
+		return nil, fmt.Errorf("reading config %s: %w", path, err)
+	}
+
+	cfg := &Config{Path: path}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	if cfg.Scheduler.File != "" && !filepath.IsAbs(cfg.Scheduler.File) {
+		cfg.Scheduler.File = filepath.Join(filepath.Dir(path), cfg.Scheduler.File)
+	}
+	for _, cond := range cfg.When {
+		for i, file := range cond.Files {
+			if !filepath.IsAbs(file) {
+				cond.Files[i] = filepath.Join(filepath.Dir(path), file)
+			}
+		}
+	}
+	for i, pattern := range cfg.Sessions.Dirs {
+		if !filepath.IsAbs(pattern) {
+			cfg.Sessions.Dirs[i] = filepath.Join(filepath.Dir(path), pattern)
+		}
+	}
+	for name, p := range cfg.Plugins {
+		p.Command = resolveCommand(filepath.Dir(path), p.Command)
+		cfg.Plugins[name] = p
+	}
+	for name, srv := range cfg.Servers {
+		if srv.Taskfile != "" && !filepath.IsAbs(srv.Taskfile) {
+			srv.Taskfile = filepath.Join(filepath.Dir(path), srv.Taskfile)
+			cfg.Servers[name] = srv
+		}
+	}
+	return cfg, nil
+}
+
+// Validate checks the config for values tmcp can't act on.
+func (c *Config) Validate() error {
+	for name, source := range c.Inject {
+		if err := ValidateInjectSource(source); err != nil {
+			return fmt.Errorf("inject %s: %w", name, err)
+		}
+	}
+	for name, source := range c.Secrets {
+		if err := secrets.Validate(source); err != nil {
+			return fmt.Errorf("secrets %s: %w", name, err)
+		}
+	}
+	if c.LogLevel != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+			return fmt.Errorf("log_level: %w", err)
+		}
+	}
+	if c.RateLimit < 0 {
+		return errors.New("rate_limit must not be negative")
+	}
+	if c.Budget.MaxCalls < 0 || c.Budget.MaxRuntime < 0 || c.Budget.MaxCost < 0 {
+		return errors.New("budget: limits must not be negative")
+	}
+	if c.MaxOutput < 0 {
+		return errors.New("max_output must not be negative")
+	}
+	if c.PageSize < 0 {
+		return errors.New("page_size must not be negative")
+	}
+	if c.ProtocolVersion != "" && !slices.Contains(mcp.ValidProtocolVersions, c.ProtocolVersion) {
+		return fmt.Errorf("protocol_version: unknown version %q (want one of %s)", c.ProtocolVersion, strings.Join(mcp.ValidProtocolVersions, ", "))
+	}
+	for _, name := range c.DisableCapabilities {
+		if !disableableCapabilities[name] {
+			return fmt.Errorf("disable_capabilities: unknown capability %q (want resources, prompts or logging)", name)
+		}
+	}
+	for _, pattern := range c.Safe.Allow {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("safe: bad allow pattern %q: %w", pattern, err)
+		}
+	}
+	for pattern, policy := range c.Retry {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("retry: bad pattern %q: %w", pattern, err)
+		}
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("retry %s: %w", pattern, err)
+		}
+	}
+	for group, patterns := range c.Concurrency {
+		if len(patterns) == 0 {
+			return fmt.Errorf("concurrency %s: at least one task pattern is required", group)
+		}
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("concurrency %s: bad pattern %q: %w", group, pattern, err)
+			}
+		}
+	}
+	for pattern, ttl := range c.Cache {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("cache: bad pattern %q: %w", pattern, err)
+		}
+		if ttl <= 0 {
+			return fmt.Errorf("cache %s: ttl must be positive", pattern)
+		}
+	}
+	for pattern, cond := range c.When {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("when: bad pattern %q: %w", pattern, err)
+		}
+		for _, platform := range cond.Platforms {
+			if platform == "" || strings.ToLower(platform) != platform {
+				return fmt.Errorf("when %s: bad platform %q (want a GOOS such as linux, darwin or windows)", pattern, platform)
+			}
+		}
+	}
+	for i := range c.Policies {
+		p := &c.Policies[i]
+		if p.Name == "" {
+			return fmt.Errorf("policies[%d]: name is required", i)
+		}
+		expr, err := policy.Parse(p.Deny)
+		if err != nil {
+			return fmt.Errorf("policies %s: deny: %w", p.Name, err)
+		}
+		for _, name := range expr.Identifiers() {
+			if policyIdentifiers[name] || strings.HasPrefix(name, "args.") {
+				continue
+			}
+			if err := ValidateInjectSource(name); err != nil {
+				return fmt.Errorf("policies %s: unknown identifier %s", p.Name, name)
+			}
+		}
+		p.Expr = expr
+	}
+	if err := c.Approval.validate(); err != nil {
+		return fmt.Errorf("approval: %w", err)
+	}
+	for _, pattern := range c.Notify.Tasks {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("notify: bad task pattern %q: %w", pattern, err)
+		}
+	}
+	if c.Notify.After < 0 {
+		return errors.New("notify: after must not be negative")
+	}
+	for _, raw := range append([]string{c.Notify.Slack}, c.Notify.Webhooks...) {
+		if raw == "" {
+			continue
+		}
+		if err := validateWebhookURL(raw); err != nil {
+			return fmt.Errorf("notify: %w", err)
+		}
+	}
+	for _, pattern := range c.Scheduler.Allow {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("scheduler: bad allow pattern %q: %w", pattern, err)
+		}
+	}
+	for _, pattern := range c.Sessions.Dirs {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("sessions: bad dir pattern %q: %w", pattern, err)
+		}
+	}
+	for name := range c.Sessions.Profiles {
+		if !serverNamePattern.MatchString(name) {
+			return fmt.Errorf("sessions: profile %s: name may only contain letters, digits, '-' and '_'", name)
+		}
+	}
+	for name, u := range c.Upstreams {
+		if !serverNamePattern.MatchString(name) {
+			return fmt.Errorf("upstreams %s: name may only contain letters, digits, '-' and '_'", name)
+		}
+		if err := u.validate(); err != nil {
+			return fmt.Errorf("upstreams %s: %w", name, err)
+		}
+	}
+	for name, p := range c.Plugins {
+		if !serverNamePattern.MatchString(name) {
+			return fmt.Errorf("plugins %s: name may only contain letters, digits, '-' and '_'", name)
+		}
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("plugins %s: %w", name, err)
+		}
+	}
+	for name, p := range c.Profiles {
+		if !serverNamePattern.MatchString(name) {
+			return fmt.Errorf("profiles %s: name may only contain letters, digits, '-' and '_'", name)
+		}
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("profiles %s: %w", name, err)
+		}
+	}
+	for name, p := range c.Pipelines {
+		if !serverNamePattern.MatchString(name) {
+			return fmt.Errorf("pipelines %s: name may only contain letters, digits, '-' and '_'", name)
+		}
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("pipelines %s: %w", name, err)
+		}
+	}
+	if c.UsagePattern != "" {
+		re, err := inspector.ParseUsagePattern(c.UsagePattern)
+		if err != nil {
+			return fmt.Errorf("usage_pattern: %w", err)
+		}
+		c.UsageRegexp = re
+	}
+	for name, srv := range c.Servers {
+		if !serverNamePattern.MatchString(name) {
+			return fmt.Errorf("servers %s: name may only contain letters, digits, '-' and '_'", name)
+		}
+		if srv.ToolPrefix != "" && !serverNamePattern.MatchString(srv.ToolPrefix) {
+			return fmt.Errorf("servers %s: tool_prefix may only contain letters, digits, '-' and '_'", name)
+		}
+		if srv.Taskfile == "" {
+			return fmt.Errorf("servers %s: taskfile is required", name)
+		}
+		for _, pattern := range append(append([]string{}, srv.Include...), srv.Exclude...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("servers %s: bad filter pattern %q: %w", name, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (p PluginConfig) validate() error {
+	if len(p.Command) == 0 {
+		return errors.New("command is required")
+	}
+	if len(p.Hooks) == 0 {
+		return errors.New("hooks are required")
+	}
+	for _, hook := range p.Hooks {
+		if hook != PluginHookArguments && hook != PluginHookResult {
+			return fmt.Errorf("unknown hook %q (want %s or %s)", hook, PluginHookArguments, PluginHookResult)
+		}
+	}
+	for _, pattern := range p.Tools {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad tool pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// resolveCommand resolves the program of command against base when it is
+// a relative path, like ./plugins/audit, rather than a name to look up in
+// PATH.
+func resolveCommand(base string, command []string) []string {
+	if len(command) == 0 || filepath.IsAbs(command[0]) || !strings.ContainsRune(command[0], '/') {
+		return command
+	}
+	resolved := append([]string{}, command...)
+	resolved[0] = filepath.Join(base, filepath.FromSlash(command[0]))
+	return resolved
+}
+
+func (a ApprovalConfig) validate() error {
+	for _, pattern := range a.Tasks {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad task pattern %q: %w", pattern, err)
+		}
+	}
+	if a.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	for _, notify := range a.Notify {
+		if !approvalNotifiers[notify] {
+			return fmt.Errorf("unknown notify %q (want terminal or desktop)", notify)
+		}
+	}
+	return nil
+}
+
+func (p ProfileConfig) validate() error {
+	for _, pattern := range append(append([]string{}, p.Include...), p.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad filter pattern %q: %w", pattern, err)
+		}
+	}
+	if p.HandshakeTimeout < 0 || p.ReadTimeout < 0 {
+		return errors.New("timeouts must not be negative")
+	}
+	if p.Approval != nil {
+		if err := p.Approval.validate(); err != nil {
+			return fmt.Errorf("approval: %w", err)
+		}
+	}
+	return nil
+}
+
+// SelectProfile applies the profile name to the config: its approval
+// section, if any, replaces the config's, and Profile is set for the
+// settings the config has no place for.
+func (c *Config) SelectProfile(name string) error {
+	p, ok := c.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(c.Profiles))
+		for name := range c.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return fmt.Errorf("unknown profile %q: %s defines no profiles", name, c.Path)
+		}
+		return fmt.Errorf("unknown profile %q (want one of %s)", name, strings.Join(names, ", "))
+	}
+	if p.Approval != nil {
+		c.Approval = *p.Approval
+	}
+	c.Profile = &p
+	return nil
+}
+
+func (p PipelineConfig) validate() error {
+	if len(p.Steps) == 0 {
+		return errors.New("at least one step is required")
+	}
+	for i, step := range p.Steps {
+		if step.Task == "" {
+			return fmt.Errorf("steps[%d]: task is required", i)
+		}
+	}
+	return nil
+}
+
+func (u UpstreamConfig) validate() error {
+	if (len(u.Command) == 0) == (u.URL == "") {
+		return errors.New("exactly one of command and url is required")
+	}
+	if u.URL != "" && !strings.HasPrefix(u.URL, "http://") && !strings.HasPrefix(u.URL, "https://") {
+		return fmt.Errorf("url %q must start with http:// or https://", u.URL)
+	}
+	if u.ToolPrefix != "" && !prefixPattern.MatchString(u.ToolPrefix) {
+		return errors.New("tool_prefix may only contain letters, digits, '-' and '_'")
+	}
+	for name, source := range u.Secrets {
+		if err := secrets.Validate(source); err != nil {
+			return fmt.Errorf("secrets %s: %w", name, err)
+		}
+	}
+	for _, pattern := range append(append([]string{}, u.Include...), u.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad filter pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,644 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("missing default config is empty", func(t *testing.T) {
+		cfg, err := Load("", filepath.Join(t.TempDir(), "Taskfile.yml"))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if len(cfg.Inject) != 0 {
+			t.Errorf("Load() Inject = %v, want empty", cfg.Inject)
+		}
+	})
+
+	t.Run("missing explicit config fails", func(t *testing.T) {
+		_, err := Load(filepath.Join(t.TempDir(), "nope.yml"), "Taskfile.yml")
+		if err == nil {
+			t.Fatal("Load() error = nil, want error")
+		}
+	})
+
+	t.Run("default config next to Taskfile", func(t *testing.T) {
+		dir := t.TempDir()
+		content := "inject:\n  REQUESTED_BY: client.name\n  OPERATOR: env.USER\n"
+		if err := os.WriteFile(filepath.Join(dir, DefaultFileName), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := Load("", filepath.Join(dir, "Taskfile.yml"))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.Inject["REQUESTED_BY"] != "client.name" || cfg.Inject["OPERATOR"] != "env.USER" {
+			t.Errorf("Load() Inject = %v", cfg.Inject)
+		}
+	})
+
+	t.Run("invalid inject source", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tmcp.yml")
+		if err := os.WriteFile(path, []byte("inject:\n  WHO: client.email\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path, "Taskfile.yml"); err == nil {
+			t.Fatal("Load() error = nil, want error")
+		}
+	})
+}
+
+func TestLoadRetry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tmcp.yml")
+	if err := os.WriteFile(path, []byte("retry:\n  'net:*':\n    attempts: 3\n    backoff: 2s\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path, "Taskfile.yml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Retry["net:*"]; got.Attempts != 3 || got.Backoff != 2*time.Second {
+		t.Errorf("Load() Retry = %+v", cfg.Retry)
+	}
+
+	if err := os.WriteFile(path, []byte("retry:\n  'net:*':\n    attempts: 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path, "Taskfile.yml"); err == nil {
+		t.Fatal("Load() error = nil, want error for zero attempts")
+	}
+}
+
+func TestLoadConcurrency(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tmcp.yml")
+	if err := os.WriteFile(path, []byte("concurrency:\n  deploy: ['deploy:*', db:migrate]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path, "Taskfile.yml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Concurrency["deploy"]; len(got) != 2 || got[1] != "db:migrate" {
+		t.Errorf("Load() Concurrency = %v", cfg.Concurrency)
+	}
+
+	if err := os.WriteFile(path, []byte("concurrency:\n  deploy: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path, "Taskfile.yml"); err == nil {
+		t.Fatal("Load() error = nil, want error for an empty group")
+	}
+}
+
+func TestParseInjectSource(t *testing.T) {
+	tests := []struct {
+		source    string
+		kind      string
+		field     string
+		wantError bool
+	}{
+		{source: "client.name", kind: "client", field: "name"},
+		{source: "session.id", kind: "session", field: "id"},
+		{source: "meta.user.email", kind: "meta", field: "user.email"},
+		{source: "env.USER", kind: "env", field: "USER"},
+		{source: "client", wantError: true},
+		{source: "header.X-User", wantError: true},
+		{source: "session.token", wantError: true},
+	}
+	for _, tt := range tests {
+		kind, field, err := ParseInjectSource(tt.source)
+		if (err != nil) != tt.wantError {
+			t.Errorf("ParseInjectSource(%q) error = %v, wantError %v", tt.source, err, tt.wantError)
+			continue
+		}
+		if kind != tt.kind || field != tt.field {
+			t.Errorf("ParseInjectSource(%q) = %q, %q, want %q, %q", tt.source, kind, field, tt.kind, tt.field)
+		}
+	}
+}
+
+func TestLoadServers(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, DefaultFileName)
+	content := `
+servers:
+  api:
+    taskfile: services/api/Taskfile.yml
+    include: ["build", "test:*"]
+    env:
+      SERVICE: api
+  web:
+    taskfile: /abs/web/Taskfile.yml
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path, "")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got, want := cfg.Servers["api"].Taskfile, filepath.Join(dir, "services/api/Taskfile.yml"); got != want {
+		t.Errorf("api taskfile = %q, want %q", got, want)
+	}
+	if got := cfg.Servers["web"].Taskfile; got != "/abs/web/Taskfile.yml" {
+		t.Errorf("web taskfile = %q, want absolute path kept", got)
+	}
+	if cfg.Servers["api"].Env["SERVICE"] != "api" {
+		t.Errorf("api env = %v", cfg.Servers["api"].Env)
+	}
+
+	for name, bad := range map[string]string{
+		"bad name":        "servers:\n  \"a/b\":\n    taskfile: T.yml\n",
+		"missing file":    "servers:\n  api: {}\n",
+		"bad filter glob": "servers:\n  api:\n    taskfile: T.yml\n    include: [\"[\"]\n",
+		"bad tool prefix": "servers:\n  api:\n    taskfile: T.yml\n    tool_prefix: \"api:\"\n",
+		"bad safe glob":   "safe:\n  allow: [\"[\"]\n",
+	} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path, ""); err == nil {
+			t.Errorf("%s: Load() error = nil, want error", name)
+		}
+	}
+}
+
+func TestLoadCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tmcp.yml")
+	if err := os.WriteFile(path, []byte("cache:\n  status: 30s\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path, "Taskfile.yml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Cache["status"]; got != 30*time.Second {
+		t.Errorf("Load() Cache = %v", cfg.Cache)
+	}
+
+	if err := os.WriteFile(path, []byte("cache:\n  status: 0s\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path, "Taskfile.yml"); err == nil {
+		t.Fatal("Load() error = nil, want error for a zero ttl")
+	}
+}
+
+func TestLoadMaxOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tmcp.yml")
+	if err := os.WriteFile(path, []byte("max_output: 65536\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path, "Taskfile.yml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.MaxOutput != 65536 {
+		t.Errorf("Load() MaxOutput = %d, want 65536", cfg.MaxOutput)
+	}
+
+	if err := os.WriteFile(path, []byte("max_output: -1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path, "Taskfile.yml"); err == nil {
+		t.Fatal("Load() error = nil, want error for a negative max_output")
+	}
+}
+
+func TestLoadUsagePattern(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tmcp.yml")
+	if err := os.WriteFile(path, []byte("usage_pattern: '(?i)^uso:\\s*(.*)$'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path, "Taskfile.yml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.UsageRegexp == nil || !cfg.UsageRegexp.MatchString("Uso: task deploy") {
+		t.Errorf("Load() UsageRegexp = %v, want a pattern matching Uso:", cfg.UsageRegexp)
+	}
+
+	if err := os.WriteFile(path, []byte("usage_pattern: '^uso: .*$'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path, "Taskfile.yml"); err == nil {
+		t.Fatal("Load() error = nil, want error for a usage_pattern without a capture group")
+	}
+}
+
+func TestLoadScheduler(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tmcp.yml")
+	if err := os.WriteFile(path, []byte("scheduler:\n  file: .tmcp/schedules.json\n  allow: ['report:*']\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path, "Taskfile.yml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if want := filepath.Join(dir, ".tmcp", "schedules.json"); cfg.Scheduler.File != want {
+		t.Errorf("Load() Scheduler.File = %q, want %q", cfg.Scheduler.File, want)
+	}
+	if want := filepath.Join(dir, ".tmcp", "schedules.api.json"); cfg.Scheduler.ScheduleFile("api") != want {
+		t.Errorf("ScheduleFile(api) = %q, want %q", cfg.Scheduler.ScheduleFile("api"), want)
+	}
+}
+
+func TestLoadSessions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tmcp.yml")
+	sessions := `sessions:
+  dirs: [/src/*, worktrees/*]
+  profiles:
+    staging:
+      API_URL: https://staging.example.com
+`
+	if err := os.WriteFile(path, []byte(sessions), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path, "Taskfile.yml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if want := []string{"/src/*", filepath.Join(dir, "worktrees", "*")}; !reflect.DeepEqual(cfg.Sessions.Dirs, want) {
+		t.Errorf("Load() Sessions.Dirs = %q, want %q", cfg.Sessions.Dirs, want)
+	}
+	if cfg.Sessions.Profiles["staging"]["API_URL"] != "https://staging.example.com" {
+		t.Errorf("Load() Sessions.Profiles = %v", cfg.Sessions.Profiles)
+	}
+
+	for _, bad := range []string{"sessions:\n  dirs: ['[']\n", "sessions:\n  profiles:\n    'a b': {X: y}\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path, "Taskfile.yml"); err == nil {
+			t.Errorf("Load(%q) error = nil", bad)
+		}
+	}
+}
+
+func TestLoadPolicies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tmcp.yml")
+	policies := `policies:
+  - name: prod-deploys
+    deny: 'task matches "deploy*" && args.ENV == "prod" && client.name != "release-bot"'
+    message: Only release-bot deploys to production.
+`
+	if err := os.WriteFile(path, []byte(policies), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path, "Taskfile.yml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.Policies) != 1 || cfg.Policies[0].Expr == nil {
+		t.Fatalf("Load() Policies = %+v", cfg.Policies)
+	}
+
+	for _, deny := range []string{`task ==`, `user.name == "bob"`, `client.email == "a"`} {
+		if err := os.WriteFile(path, []byte("policies:\n  - name: p\n    deny: '"+deny+"'\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path, "Taskfile.yml"); err == nil {
+			t.Errorf("Load() error = nil for deny %q", deny)
+		}
+	}
+}
+
+func TestLoadApproval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tmcp.yml")
+	if err := os.WriteFile(path, []byte("approval:\n  tasks: ['deploy:*']\n  timeout: 2m\n  notify: [terminal, desktop]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path, "Taskfile.yml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Approval.Timeout != 2*time.Minute || len(cfg.Approval.Notify) != 2 {
+		t.Errorf("Load() Approval = %+v", cfg.Approval)
+	}
+
+	if err := os.WriteFile(path, []byte("approval:\n  tasks: ['deploy:*']\n  notify: [pager]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path, "Taskfile.yml"); err == nil {
+		t.Fatal("Load() error = nil, want error for an unknown notifier")
+	}
+}
+
+func TestLoadUpstreams(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tmcp.yml")
+	content := `
+upstreams:
+  github:
+    command: [npx, -y, "@modelcontextprotocol/server-github"]
+    secrets:
+      GITHUB_PERSONAL_ACCESS_TOKEN: env:GITHUB_TOKEN
+    exclude: ["delete_*"]
+  search:
+    url: https://search.example.com/mcp
+    headers:
+      Authorization: Bearer ${SEARCH_TOKEN}
+    tool_prefix: web_
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path, "Taskfile.yml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Upstreams["github"]; len(got.Command) != 3 || got.Secrets["GITHUB_PERSONAL_ACCESS_TOKEN"] != "env:GITHUB_TOKEN" {
+		t.Errorf("github upstream = %+v", got)
+	}
+	if got := cfg.Upstreams["search"]; got.URL != "https://search.example.com/mcp" || got.ToolPrefix != "web_" {
+		t.Errorf("search upstream = %+v", got)
+	}
+
+	for name, bad := range map[string]string{
+		"bad name":        "upstreams:\n  \"a/b\":\n    url: http://localhost/mcp\n",
+		"no target":       "upstreams:\n  gh: {}\n",
+		"two targets":     "upstreams:\n  gh:\n    command: [gh-mcp]\n    url: http://localhost/mcp\n",
+		"bad url":         "upstreams:\n  gh:\n    url: localhost:8080\n",
+		"bad secret":      "upstreams:\n  gh:\n    command: [gh-mcp]\n    secrets:\n      TOKEN: nope\n",
+		"bad filter glob": "upstreams:\n  gh:\n    command: [gh-mcp]\n    include: [\"[\"]\n",
+	} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path, "Taskfile.yml"); err == nil {
+			t.Errorf("%s: Load() error = nil, want error", name)
+		}
+	}
+}
+
+func TestLoadPlugins(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tmcp.yml")
+	content := `
+plugins:
+  audit:
+    command: [./plugins/audit, --verbose]
+    hooks: [arguments, result]
+    tools: ["deploy*"]
+  redact:
+    command: [tmcp-redact]
+    hooks: [result]
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path, "Taskfile.yml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := cfg.Plugins["audit"].Command; got[0] != filepath.Join(dir, "plugins", "audit") || got[1] != "--verbose" {
+		t.Errorf("audit command = %q, want it resolved against the config", got)
+	}
+	if got := cfg.Plugins["redact"].Command; got[0] != "tmcp-redact" {
+		t.Errorf("redact command = %q, want it left to PATH", got)
+	}
+
+	for name, bad := range map[string]string{
+		"bad name":     "plugins:\n  \"a/b\":\n    command: [x]\n    hooks: [result]\n",
+		"no command":   "plugins:\n  a:\n    hooks: [result]\n",
+		"no hooks":     "plugins:\n  a:\n    command: [x]\n",
+		"unknown hook": "plugins:\n  a:\n    command: [x]\n    hooks: [before]\n",
+		"bad pattern":  "plugins:\n  a:\n    command: [x]\n    hooks: [result]\n    tools: [\"[\"]\n",
+	} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path, "Taskfile.yml"); err == nil {
+			t.Errorf("%s: Load() error = nil, want error", name)
+		}
+	}
+}
+
+func TestLoadBudget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tmcp.yml")
+	if err := os.WriteFile(path, []byte("budget:\n  max_calls: 100\n  max_runtime: 30m\n  max_cost: 2.5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path, "Taskfile.yml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if want := (BudgetConfig{MaxCalls: 100, MaxRuntime: 30 * time.Minute, MaxCost: 2.5}); cfg.Budget != want {
+		t.Errorf("Load() Budget = %+v, want %+v", cfg.Budget, want)
+	}
+
+	if err := os.WriteFile(path, []byte("budget:\n  max_cost: -1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path, "Taskfile.yml"); err == nil {
+		t.Fatal("Load() error = nil, want error for a negative max_cost")
+	}
+}
+
+func TestLoadNotify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tmcp.yml")
+	data := "notify:\n  after: 2m\n  tasks: [\"deploy:*\"]\n  slack: ${SLACK_WEBHOOK_URL}\n  webhooks: [https://ci.example.com/tmcp]\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path, "Taskfile.yml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.Notify.Enabled() || cfg.Notify.After != 2*time.Minute || cfg.Notify.Slack != "${SLACK_WEBHOOK_URL}" {
+		t.Errorf("Load() Notify = %+v", cfg.Notify)
+	}
+
+	for _, data := range []string{"notify:\n  webhooks: [ftp://example.com]\n", "notify:\n  after: -1s\n", "notify:\n  tasks: [\"[\"]\n"} {
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path, "Taskfile.yml"); err == nil {
+			t.Errorf("Load(%q) error = nil, want an error", data)
+		}
+	}
+}
+
+func TestLoadNegotiation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tmcp.yml")
+	if err := os.WriteFile(path, []byte("protocol_version: 2024-11-05\ndisable_capabilities: [resources, logging]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path, "Taskfile.yml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.ProtocolVersion != "2024-11-05" || len(cfg.DisableCapabilities) != 2 {
+		t.Errorf("Load() = %q, %v", cfg.ProtocolVersion, cfg.DisableCapabilities)
+	}
+
+	for _, bad := range []string{"protocol_version: '2023-01-01'\n", "disable_capabilities: [tools]\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path, "Taskfile.yml"); err == nil {
+			t.Errorf("Load(%q) error = nil, want error", bad)
+		}
+	}
+}
+
+func TestLoadPipelines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tmcp.yml")
+	data := `
+pipelines:
+  ship:
+    description: Build, test and deploy.
+    steps:
+      - build
+      - test
+      - task: deploy
+        args: {ENV: prod}
+        compensate: undeploy
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path, "Taskfile.yml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := PipelineConfig{
+		Description: "Build, test and deploy.",
+		Steps:       []PipelineStep{{Task: "build"}, {Task: "test"}, {Task: "deploy", Args: map[string]string{"ENV": "prod"}, Compensate: "undeploy"}},
+	}
+	if got := cfg.Pipelines["ship"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("pipelines ship = %+v, want %+v", got, want)
+	}
+
+	for _, bad := range []string{"pipelines: {ship: {}}\n", "pipelines: {ship: {steps: [{args: {A: b}}]}}\n", "pipelines: {'ship it': {steps: [build]}}\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path, "Taskfile.yml"); err == nil {
+			t.Errorf("Load(%q) error = nil, want error", bad)
+		}
+	}
+}
+
+func TestLoadWhen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tmcp.yml")
+	data := `
+when:
+  "deploy:*":
+    env: {TMCP_ROLE: bastion}
+    files: [bastion.marker]
+    platforms: [linux, darwin]
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path, "Taskfile.yml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	cond := cfg.When["deploy:*"]
+	if want := []string{filepath.Join(dir, "bastion.marker")}; !reflect.DeepEqual(cond.Files, want) {
+		t.Errorf("files = %q, want %q", cond.Files, want)
+	}
+
+	for _, bad := range []string{"when: {'[': {}}\n", "when: {deploy: {platforms: [Linux]}}\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path, "Taskfile.yml"); err == nil {
+			t.Errorf("Load(%q) error = nil, want error", bad)
+		}
+	}
+}
+
+func TestConditionUnmet(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "marker")
+	if err := os.WriteFile(marker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"CI": "true"}
+	getenv := func(name string) string { return env[name] }
+
+	tests := []struct {
+		name string
+		cond Condition
+		want string
+	}{
+		{name: "empty", cond: Condition{}},
+		{name: "all hold", cond: Condition{Env: map[string]string{"CI": "true"}, Files: []string{marker}, Platforms: []string{runtime.GOOS}}},
+		{name: "env", cond: Condition{Env: map[string]string{"CI": "false"}}, want: `CI is not "false"`},
+		{name: "unset env", cond: Condition{Env: map[string]string{"ROLE": "prod"}}, want: `ROLE is not "prod"`},
+		{name: "file", cond: Condition{Files: []string{filepath.Join(dir, "missing")}}, want: filepath.Join(dir, "missing") + " does not exist"},
+		{name: "platform", cond: Condition{Platforms: []string{"plan9"}}, want: "the platform is " + runtime.GOOS + ", not plan9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cond.Unmet(getenv); got != tt.want {
+				t.Errorf("Unmet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tmcp.yml")
+	data := `
+approval:
+  tasks: ["db:*"]
+profiles:
+  ci:
+    include: ["build", "test:*"]
+    env: {CI: "true"}
+    pass_env: [GITHUB_TOKEN]
+    read_timeout: 10m
+  prod:
+    safe: true
+    approval:
+      tasks: ["deploy:*"]
+      notify: [desktop]
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path, "Taskfile.yml")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := cfg.SelectProfile("ci"); err != nil {
+		t.Fatalf("SelectProfile(ci) error = %v", err)
+	}
+	if p := cfg.Profile; p == nil || p.ReadTimeout != 10*time.Minute || !reflect.DeepEqual(p.PassEnv, []string{"GITHUB_TOKEN"}) {
+		t.Errorf("Profile = %+v", p)
+	}
+	if want := []string{"db:*"}; !reflect.DeepEqual(cfg.Approval.Tasks, want) {
+		t.Errorf("approval tasks = %q, want %q: ci has no approval section", cfg.Approval.Tasks, want)
+	}
+	if err := cfg.SelectProfile("prod"); err != nil {
+		t.Fatalf("SelectProfile(prod) error = %v", err)
+	}
+	if want := []string{"deploy:*"}; !cfg.Profile.Safe || !reflect.DeepEqual(cfg.Approval.Tasks, want) {
+		t.Errorf("prod profile = %+v, approval tasks = %q", cfg.Profile, cfg.Approval.Tasks)
+	}
+	if err := cfg.SelectProfile("qa"); err == nil || !strings.Contains(err.Error(), "ci, prod") {
+		t.Errorf("SelectProfile(qa) error = %v, want the known profiles", err)
+	}
+
+	for _, bad := range []string{"profiles: {'a b': {}}\n", "profiles: {ci: {exclude: ['[']}}\n", "profiles: {ci: {read_timeout: -1s}}\n", "profiles: {ci: {approval: {notify: [pager]}}}\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path, "Taskfile.yml"); err == nil {
+			t.Errorf("Load(%q) error = nil, want error", bad)
+		}
+	}
+}
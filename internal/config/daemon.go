@@ -0,0 +1,97 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DaemonStateFileName is the file `tmcp daemon` keeps its registered
+// projects in, under the user's config directory.
+const DaemonStateFileName = "daemon.yaml"
+
+// DaemonProject is a Taskfile registered with `tmcp daemon`.
+type DaemonProject struct {
+	// Name is the base path the project is served under, /<name>/mcp.
+	Name     string `yaml:"name" json:"name"`
+	Taskfile string `yaml:"taskfile" json:"taskfile"`
+}
+
+// DaemonState lists the projects registered with `tmcp daemon`, so they are
+// served again after a restart.
+type DaemonState struct {
+	Projects []DaemonProject `yaml:"projects"`
+}
+
+// DefaultDaemonStatePath returns the state file in the user's config
+// directory, e.g. ~/.config/tmcp/daemon.yaml.
+func DefaultDaemonStatePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tmcp", DaemonStateFileName), nil
+}
+
+// ValidateProjectName checks that name can be used as a URL path segment.
+func ValidateProjectName(name string) error {
+	if !serverNamePattern.MatchString(name) {
+		return fmt.Errorf("project name %q may only contain letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// LoadDaemonState reads the state file at path. A missing file is an empty
+// state.
+func LoadDaemonState(path string) (*DaemonState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &DaemonState{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading daemon state %s: %w", path, err)
+	}
+	var state DaemonState
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parsing daemon state %s: %w", path, err)
+	}
+	seen := make(map[string]bool, len(state.Projects))
+	for _, p := range state.Projects {
+		if err := ValidateProjectName(p.Name); err != nil {
+			return nil, fmt.Errorf("invalid daemon state %s: %w", path, err)
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("invalid daemon state %s: project %s is listed twice", path, p.Name)
+		}
+		seen[p.Name] = true
+	}
+	return &state, nil
+}
+
+// SaveDaemonState writes the state file at path, creating its directory.
+// The file is replaced atomically so a crash can't leave it half written.
+func SaveDaemonState(path string, state *DaemonState) error {
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
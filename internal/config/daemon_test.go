@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDaemonState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tmcp", DaemonStateFileName)
+	state, err := LoadDaemonState(path)
+	if err != nil || len(state.Projects) != 0 {
+		t.Fatalf("LoadDaemonState() of a missing file = %+v, %v, want an empty state", state, err)
+	}
+
+	state.Projects = []DaemonProject{{Name: "api", Taskfile: "/src/api/Taskfile.yml"}, {Name: "web", Taskfile: "/src/web/Taskfile.yml"}}
+	if err := SaveDaemonState(path, state); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadDaemonState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, state) {
+		t.Errorf("LoadDaemonState() = %+v, want %+v", loaded, state)
+	}
+
+	for name, content := range map[string]string{
+		"bad name":  "projects:\n  - name: a/b\n    taskfile: Taskfile.yml\n",
+		"duplicate": "projects:\n  - name: a\n    taskfile: x.yml\n  - name: a\n    taskfile: y.yml\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadDaemonState(path); err == nil {
+			t.Errorf("LoadDaemonState() error = nil for a state with a %s", name)
+		}
+	}
+}
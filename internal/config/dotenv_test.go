@@ -0,0 +1,48 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadDotenv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := `# database
+DB_HOST=localhost # the default
+export DB_USER = app
+DB_PASS='p#ss "word"'
+GREETING="hello\n\"world\""
+EMPTY=
+URL=https://example.com/#anchor
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	env, err := LoadDotenv(path)
+	if err != nil {
+		t.Fatalf("LoadDotenv() error = %v", err)
+	}
+	want := map[string]string{
+		"DB_HOST":  "localhost",
+		"DB_USER":  "app",
+		"DB_PASS":  `p#ss "word"`,
+		"GREETING": "hello\n\"world\"",
+		"EMPTY":    "",
+		"URL":      "https://example.com/#anchor",
+	}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("LoadDotenv() = %q, want %q", env, want)
+	}
+
+	for _, bad := range []string{"NO_VALUE", "1KEY=x", `QUOTE="open`, "QUOTE='open"} {
+		if err := os.WriteFile(path, []byte(bad+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadDotenv(path); err == nil || !strings.Contains(err.Error(), ":1:") {
+			t.Errorf("LoadDotenv(%q) error = %v, want one for line 1", bad, err)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sources a task variable can be injected from. The part after the dot
+// selects the field, e.g. meta.user or env.USER.
+const (
+	SourceClient  = "client"  // client.name, client.version from initialize
+	SourceSession = "session" // session.id
+	SourceMeta    = "meta"    // any field of the request's _meta object
+	SourceEnv     = "env"     // an environment variable of the tmcp process
+)
+
+// ParseInjectSource splits a source such as "client.name" into its kind and
+// field.
+func ParseInjectSource(source string) (kind string, field string, err error) {
+	kind, field, ok := strings.Cut(source, ".")
+	if !ok || field == "" {
+		return "", "", fmt.Errorf("source %q must look like <kind>.<field>", source)
+	}
+	switch kind {
+	case SourceClient:
+		if field != "name" && field != "version" {
+			return "", "", fmt.Errorf("source %q: client only provides name and version", source)
+		}
+	case SourceSession:
+		if field != "id" {
+			return "", "", fmt.Errorf("source %q: session only provides id", source)
+		}
+	case SourceMeta, SourceEnv:
+	default:
+		return "", "", fmt.Errorf("source %q: unknown kind %q (want client, session, meta or env)", source, kind)
+	}
+	return kind, field, nil
+}
+
+// ValidateInjectSource reports whether source can be resolved.
+func ValidateInjectSource(source string) error {
+	_, _, err := ParseInjectSource(source)
+	return err
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Runtime holds the settings the admin API can change while tmcp runs.
+type Runtime struct {
+	LogLevel  string
+	RateLimit int
+	DryRun    bool
+	Allow     []string
+}
+
+// SaveRuntime writes r into the config file at path, creating it if needed.
+// Only the runtime keys are touched; other settings and comments are kept.
+func SaveRuntime(path string, r Runtime) error {
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("reading config %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("config %s is not a mapping", path)
+	}
+
+	if err := setKey(root, "log_level", r.LogLevel); err != nil {
+		return err
+	}
+	if err := setKey(root, "rate_limit", r.RateLimit); err != nil {
+		return err
+	}
+	safe := mappingValue(root, "safe")
+	if err := setKey(safe, "dry_run", r.DryRun); err != nil {
+		return err
+	}
+	allow := r.Allow
+	if allow == nil {
+		allow = []string{}
+	}
+	if err := setKey(safe, "allow", allow); err != nil {
+		return err
+	}
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return os.WriteFile(path, out, mode)
+}
+
+// setKey sets key in the mapping node m to value, keeping the position and
+// comments of an existing key.
+func setKey(m *yaml.Node, key string, value any) error {
+	var v yaml.Node
+	if err := v.Encode(value); err != nil {
+		return fmt.Errorf("encoding %s: %w", key, err)
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			v.HeadComment = m.Content[i+1].HeadComment
+			v.LineComment = m.Content[i+1].LineComment
+			v.FootComment = m.Content[i+1].FootComment
+			m.Content[i+1] = &v
+			return nil
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, &v)
+	return nil
+}
+
+// mappingValue returns the mapping stored under key in m, adding an empty
+// one if key is missing or not a mapping.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key && m.Content[i+1].Kind == yaml.MappingNode {
+			return m.Content[i+1]
+		}
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = child
+			return child
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+	return child
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSaveRuntime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFileName)
+	existing := `# tmcp settings
+inject:
+  CLIENT: client.name
+rate_limit: 10 # calls per minute
+safe:
+  allow: [test]
+`
+	if err := os.WriteFile(path, []byte(existing), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	r := Runtime{LogLevel: "DEBUG", RateLimit: 30, DryRun: true, Allow: []string{"lint:*"}}
+	if err := SaveRuntime(path, r); err != nil {
+		t.Fatalf("SaveRuntime() error = %v", err)
+	}
+
+	cfg, err := Load(path, "")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.LogLevel != "DEBUG" || cfg.RateLimit != 30 || !cfg.Safe.DryRun || !reflect.DeepEqual(cfg.Safe.Allow, r.Allow) {
+		t.Errorf("saved config = %+v", cfg)
+	}
+	if cfg.Inject["CLIENT"] != "client.name" {
+		t.Errorf("inject = %v, want it kept", cfg.Inject)
+	}
+	data, _ := os.ReadFile(path)
+	for _, comment := range []string{"# tmcp settings", "# calls per minute"} {
+		if !strings.Contains(string(data), comment) {
+			t.Errorf("comment %q was dropped:\n%s", comment, data)
+		}
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600 kept", info.Mode().Perm())
+	}
+
+	t.Run("creates a missing file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), DefaultFileName)
+		if err := SaveRuntime(path, Runtime{LogLevel: "INFO"}); err != nil {
+			t.Fatalf("SaveRuntime() error = %v", err)
+		}
+		if cfg, err := Load(path, ""); err != nil || cfg.LogLevel != "INFO" {
+			t.Errorf("Load() = %+v, %v", cfg, err)
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// TaskfileNames are the names task looks for when no Taskfile is given, in
+// its order of preference.
+var TaskfileNames = []string{
+	"Taskfile.yml",
+	"taskfile.yml",
+	"Taskfile.yaml",
+	"taskfile.yaml",
+	"Taskfile.dist.yml",
+	"taskfile.dist.yml",
+	"Taskfile.dist.yaml",
+	"taskfile.dist.yaml",
+}
+
+// ErrNoTaskfile is returned by FindTaskfile when there is no Taskfile to
+// be found.
+var ErrNoTaskfile = errors.New("no Taskfile found")
+
+// FindTaskfile returns the Taskfile task would use when run in dir: the
+// first of TaskfileNames in dir, or else in the closest parent directory
+// that has one.
+func FindTaskfile(dir string) (string, error) {
+	start, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for dir := start; ; dir = filepath.Dir(dir) {
+		for _, name := range TaskfileNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+		if filepath.Dir(dir) == dir {
+			return "", fmt.Errorf("%w in %s or any parent directory", ErrNoTaskfile, start)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindTaskfile(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FindTaskfile(nested); !errors.Is(err, ErrNoTaskfile) {
+		t.Fatalf("FindTaskfile() without Taskfiles error = %v, want ErrNoTaskfile", err)
+	}
+
+	for _, name := range []string{"Taskfile.dist.yml", "Taskfile.yaml"} {
+		if err := os.WriteFile(filepath.Join(root, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, err := FindTaskfile(nested); err != nil || got != filepath.Join(root, "Taskfile.yaml") {
+		t.Errorf("FindTaskfile() = %q, %v, want the Taskfile.yaml of the parent", got, err)
+	}
+
+	// A directory named like a Taskfile doesn't count.
+	if err := os.Mkdir(filepath.Join(nested, "Taskfile.yml"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// The closest directory with a Taskfile wins.
+	if err := os.WriteFile(filepath.Join(root, "a", "taskfile.yml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := FindTaskfile(nested); err != nil || got != filepath.Join(root, "a", "taskfile.yml") {
+		t.Errorf("FindTaskfile() = %q, %v, want the closest Taskfile", got, err)
+	}
+}
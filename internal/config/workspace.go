@@ -0,0 +1,236 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+	"gopkg.in/yaml.v3"
+)
+
+// WorkspaceFileName is the workspace manifest tmcp looks for in the current
+// directory.
+const WorkspaceFileName = "tmcp.workspace.yaml"
+
+// WorkspaceVersion is the newest manifest version this tmcp understands.
+const WorkspaceVersion = 1
+
+// Policies a workspace Taskfile can use.
+const (
+	// PolicyStandard runs tasks as they are.
+	PolicyStandard = "standard"
+	// PolicySafe applies `tmcp serve --safe` to the Taskfile.
+	PolicySafe = "safe"
+	// PolicyReadOnly exposes only the tasks marked read-only.
+	PolicyReadOnly = "read-only"
+)
+
+// Workspace is a manifest describing every Taskfile tmcp exposes, served
+// together as one MCP server. Besides Taskfiles, it can combine any of the
+// files tmcp serves, such as Makefiles and tools.yml manifests.
+type Workspace struct {
+	// Version is the manifest format version; it defaults to 1.
+	Version   int                 `yaml:"version"`
+	Taskfiles []WorkspaceTaskfile `yaml:"taskfiles"`
+	// Sources lists files of any kind, like Taskfiles does. LoadWorkspace
+	// appends them to Taskfiles.
+	Sources []WorkspaceTaskfile `yaml:"sources,omitempty"`
+
+	// Path is the file the manifest was loaded from.
+	Path string `yaml:"-"`
+}
+
+// WorkspaceTaskfile is one Taskfile of a workspace.
+type WorkspaceTaskfile struct {
+	// Path is resolved relative to the manifest.
+	Path string `yaml:"path"`
+	// Kind is the kind of file Path is: task, make, npm, just or tools.
+	// By default it is told from the file's name.
+	Kind string `yaml:"kind,omitempty"`
+	// Plugin serves Path with a plugin, an external program run as this
+	// command, instead; see inspector.PluginSource. A relative path is
+	// resolved against the manifest.
+	Plugin []string `yaml:"plugin,omitempty"`
+	// Prefix is prepended to the Taskfile's tool names.
+	Prefix string `yaml:"prefix"`
+	// Include and Exclude filter the exposed tasks with path.Match patterns.
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+	// Policy is standard (the default), safe or read-only.
+	Policy string `yaml:"policy"`
+	// Allow lists tasks that run for real under the safe policy.
+	Allow []string `yaml:"allow"`
+	// Executor configures how the Taskfile's tasks are run.
+	Executor Executor `yaml:"executor"`
+}
+
+// Executor configures how tasks run.
+type Executor struct {
+	// TaskBin pins the task binary, e.g. for a Taskfile that needs a
+	// different task version than the rest of the workspace.
+	TaskBin string `yaml:"task_bin"`
+	// Dir is the working directory, resolved relative to the manifest.
+	Dir string `yaml:"dir"`
+	// Env sets extra environment variables.
+	Env map[string]string `yaml:"env"`
+}
+
+// prefixPattern keeps prefixes valid in MCP tool names.
+var prefixPattern = regexp.MustCompile(`^[A-Za-z0-9_-]*$`)
+
+// invalidPrefixChars matches the characters of a directory path that can't
+// be part of a prefix.
+var invalidPrefixChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// skippedDirs are never searched for Taskfiles by DiscoverWorkspace, besides
+// hidden directories.
+var skippedDirs = map[string]bool{"node_modules": true, "vendor": true}
+
+// DiscoverWorkspace builds a workspace of every Taskfile under root, as in a
+// monorepo where each package has its own. A directory's Taskfile is the one
+// task would pick there; its tools are prefixed with the directory's path
+// relative to root, e.g. packages_api_ for packages/api, and its tasks run
+// from its directory. Hidden, node_modules and vendor directories are
+// skipped.
+func DiscoverWorkspace(root string) (*Workspace, error) {
+	ws := &Workspace{Version: WorkspaceVersion}
+	err := filepath.WalkDir(root, func(dir string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if dir != root && (strings.HasPrefix(d.Name(), ".") || skippedDirs[d.Name()]) {
+			return filepath.SkipDir
+		}
+		for _, name := range TaskfileNames {
+			taskfile := filepath.Join(dir, name)
+			if info, err := os.Stat(taskfile); err != nil || info.IsDir() {
+				continue
+			}
+			rel, err := filepath.Rel(root, dir)
+			if err != nil {
+				return err
+			}
+			prefix := ""
+			if rel != "." {
+				prefix = invalidPrefixChars.ReplaceAllString(filepath.ToSlash(rel), "_") + "_"
+			}
+			ws.Taskfiles = append(ws.Taskfiles, WorkspaceTaskfile{Path: taskfile, Prefix: prefix, Policy: PolicyStandard})
+			break
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("searching %s for Taskfiles: %w", root, err)
+	}
+	if len(ws.Taskfiles) == 0 {
+		return nil, fmt.Errorf("%w under %s", ErrNoTaskfile, root)
+	}
+	return ws, nil
+}
+
+// LoadWorkspace reads and validates the workspace manifest at path.
+func LoadWorkspace(path string) (*Workspace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading workspace %s: %w", path, err)
+	}
+
+	ws := &Workspace{Path: path}
+	if err := yaml.Unmarshal(data, ws); err != nil {
+		return nil, fmt.Errorf("parsing workspace %s: %w", path, err)
+	}
+	if ws.Version == 0 {
+		ws.Version = 1
+	}
+	if err := ws.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid workspace %s: %w", path, err)
+	}
+	ws.Taskfiles, ws.Sources = append(ws.Taskfiles, ws.Sources...), nil
+
+	base := filepath.Dir(path)
+	for i := range ws.Taskfiles {
+		tf := &ws.Taskfiles[i]
+		if tf.Policy == "" {
+			tf.Policy = PolicyStandard
+		}
+		tf.Path = resolvePath(base, tf.Path)
+		tf.Plugin = resolveCommand(base, tf.Plugin)
+		tf.Executor.Dir = resolvePath(base, tf.Executor.Dir)
+	}
+	return ws, nil
+}
+
+// Source returns the kind of file the entry's path is.
+func (tf WorkspaceTaskfile) Source() inspector.Source {
+	if len(tf.Plugin) > 0 {
+		return inspector.PluginSource(tf.Plugin)
+	}
+	if source, ok := inspector.SourceNamed(tf.Kind); ok {
+		return source
+	}
+	return inspector.SourceOf(tf.Path)
+}
+
+// sourceNames lists the kinds of files a workspace can list.
+func sourceNames() string {
+	var names []string
+	for _, source := range inspector.Sources {
+		names = append(names, source.Name())
+	}
+	return strings.Join(names, ", ")
+}
+
+// resolvePath resolves a non-empty relative path against base.
+func resolvePath(base string, p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(base, p)
+}
+
+// Validate checks the manifest for values tmcp can't act on.
+func (w *Workspace) Validate() error {
+	if w.Version > WorkspaceVersion {
+		return fmt.Errorf("version %d requires a newer tmcp (this one understands up to version %d)", w.Version, WorkspaceVersion)
+	}
+	if len(w.Taskfiles)+len(w.Sources) == 0 {
+		return fmt.Errorf("no taskfiles or sources listed")
+	}
+	for i, tf := range append(w.Taskfiles[:len(w.Taskfiles):len(w.Taskfiles)], w.Sources...) {
+		name := fmt.Sprintf("taskfiles[%d]", i)
+		if i >= len(w.Taskfiles) {
+			name = fmt.Sprintf("sources[%d]", i-len(w.Taskfiles))
+		}
+		if tf.Path == "" {
+			return fmt.Errorf("%s: path is required", name)
+		}
+		if tf.Kind != "" && len(tf.Plugin) > 0 {
+			return fmt.Errorf("%s: only one of kind and plugin can be set", name)
+		}
+		if _, ok := inspector.SourceNamed(tf.Kind); tf.Kind != "" && !ok {
+			return fmt.Errorf("%s: unknown kind %q (want %s)", name, tf.Kind, sourceNames())
+		}
+		if !prefixPattern.MatchString(tf.Prefix) {
+			return fmt.Errorf("%s: prefix may only contain letters, digits, '-' and '_'", name)
+		}
+		switch tf.Policy {
+		case "", PolicyStandard, PolicySafe, PolicyReadOnly:
+		default:
+			return fmt.Errorf("%s: unknown policy %q (want %s, %s or %s)", name, tf.Policy, PolicyStandard, PolicySafe, PolicyReadOnly)
+		}
+		for _, pattern := range append(append(append([]string{}, tf.Include...), tf.Exclude...), tf.Allow...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%s: bad pattern %q: %w", name, pattern, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, WorkspaceFileName)
+	manifest := `
+taskfiles:
+  - path: services/api/Taskfile.yml
+    prefix: api_
+    policy: safe
+    allow: [test]
+    executor:
+      task_bin: /opt/task-3.30/task
+      dir: services/api
+      env:
+        SERVICE: api
+  - path: /abs/web/Taskfile.yml
+`
+	if err := os.WriteFile(path, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ws, err := LoadWorkspace(path)
+	if err != nil {
+		t.Fatalf("LoadWorkspace() error = %v", err)
+	}
+	if ws.Version != 1 {
+		t.Errorf("version = %d, want default 1", ws.Version)
+	}
+	api, web := ws.Taskfiles[0], ws.Taskfiles[1]
+	if want := filepath.Join(dir, "services/api/Taskfile.yml"); api.Path != want {
+		t.Errorf("api path = %q, want %q", api.Path, want)
+	}
+	if want := filepath.Join(dir, "services/api"); api.Executor.Dir != want {
+		t.Errorf("api dir = %q, want %q", api.Executor.Dir, want)
+	}
+	if api.Policy != PolicySafe || api.Executor.TaskBin != "/opt/task-3.30/task" || api.Executor.Env["SERVICE"] != "api" {
+		t.Errorf("api = %+v", api)
+	}
+	if web.Path != "/abs/web/Taskfile.yml" || web.Policy != PolicyStandard || web.Executor.Dir != "" {
+		t.Errorf("web = %+v", web)
+	}
+
+	for name, tt := range map[string]struct {
+		manifest string
+		wantErr  string
+	}{
+		"newer version":   {"version: 2\ntaskfiles:\n  - path: T.yml\n", "requires a newer tmcp"},
+		"no taskfiles":    {"version: 1\n", "no taskfiles"},
+		"missing path":    {"taskfiles:\n  - prefix: a_\n", "path is required"},
+		"bad prefix":      {"taskfiles:\n  - path: T.yml\n    prefix: \"a:\"\n", "prefix"},
+		"unknown policy":  {"taskfiles:\n  - path: T.yml\n    policy: yolo\n", "unknown policy"},
+		"bad pattern":     {"taskfiles:\n  - path: T.yml\n    allow: [\"[\"]\n", "bad pattern"},
+		"unknown kind":    {"sources:\n  - path: ci.yml\n    kind: ant\n", "sources[0]: unknown kind"},
+		"kind and plugin": {"sources:\n  - path: R\n    kind: make\n    plugin: [rake]\n", "only one of kind and plugin"},
+	} {
+		if err := os.WriteFile(path, []byte(tt.manifest), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadWorkspace(path); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: LoadWorkspace() error = %v, want %q", name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadWorkspaceSources(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, WorkspaceFileName)
+	manifest := `
+taskfiles:
+  - path: Taskfile.yml
+sources:
+  - path: Makefile
+    prefix: make_
+  - path: ops/commands.yml
+    kind: tools
+  - path: Rakefile
+    plugin: [./plugins/rake, --quiet]
+`
+	if err := os.WriteFile(path, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ws, err := LoadWorkspace(path)
+	if err != nil {
+		t.Fatalf("LoadWorkspace() error = %v", err)
+	}
+	if len(ws.Taskfiles) != 4 || ws.Sources != nil {
+		t.Fatalf("taskfiles = %+v, sources = %+v, want the sources after the Taskfile", ws.Taskfiles, ws.Sources)
+	}
+	var kinds []string
+	for _, tf := range ws.Taskfiles {
+		kinds = append(kinds, tf.Source().Name())
+	}
+	if got := strings.Join(kinds, " "); got != "task make tools plugin" {
+		t.Errorf("kinds = %s", got)
+	}
+	if want := filepath.Join(dir, "ops/commands.yml"); ws.Taskfiles[2].Path != want {
+		t.Errorf("path = %q, want %q", ws.Taskfiles[2].Path, want)
+	}
+	if want := filepath.Join(dir, "plugins/rake"); ws.Taskfiles[3].Source().Bin("") != want {
+		t.Errorf("plugin = %q, want %q", ws.Taskfiles[3].Plugin, want)
+	}
+}
+
+func TestDiscoverWorkspace(t *testing.T) {
+	root := t.TempDir()
+	if _, err := DiscoverWorkspace(root); !errors.Is(err, ErrNoTaskfile) {
+		t.Fatalf("DiscoverWorkspace() without Taskfiles error = %v, want ErrNoTaskfile", err)
+	}
+	for _, path := range []string{
+		"Taskfile.yml",
+		"packages/api/Taskfile.yml",
+		"packages/api/Taskfile.dist.yml",
+		"packages/web.app/taskfile.yaml",
+		"packages/web.app/node_modules/dep/Taskfile.yml",
+		".git/Taskfile.yml",
+	} {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ws, err := DiscoverWorkspace(root)
+	if err != nil {
+		t.Fatalf("DiscoverWorkspace() error = %v", err)
+	}
+	want := []WorkspaceTaskfile{
+		{Path: filepath.Join(root, "Taskfile.yml"), Policy: PolicyStandard},
+		{Path: filepath.Join(root, "packages/api/Taskfile.yml"), Prefix: "packages_api_", Policy: PolicyStandard},
+		{Path: filepath.Join(root, "packages/web.app/taskfile.yaml"), Prefix: "packages_web_app_", Policy: PolicyStandard},
+	}
+	if len(ws.Taskfiles) != len(want) {
+		t.Fatalf("DiscoverWorkspace() = %+v, want %+v", ws.Taskfiles, want)
+	}
+	for i := range want {
+		got := ws.Taskfiles[i]
+		if got.Path != want[i].Path || got.Prefix != want[i].Prefix || got.Policy != want[i].Policy {
+			t.Errorf("Taskfiles[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if err := ws.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
@@ -0,0 +1,506 @@
+// Package lsp is a minimal language server for Taskfiles. It speaks the
+// Language Server Protocol over a stream, such as stdio, and gives editors
+// diagnostics, hovers and code lenses for the tasks of the Taskfiles they
+// open. What it knows about a Taskfile comes from an Analyzer, so the
+// server itself knows nothing about inspection or MCP.
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RunCommand is the command of the code lens that runs a task's tool. Its
+// arguments are the document URI and the task name.
+const RunCommand = "tmcp.runTool"
+
+// Task is a task of an analyzed Taskfile.
+type Task struct {
+	Name string
+	// Line is the 1-based line of the task's name; tasks without one get
+	// no hover or code lens.
+	Line int
+	// Hover is the Markdown shown when hovering the task.
+	Hover string
+	// Lens is the title of the code lens that runs the task.
+	Lens string
+}
+
+// Diagnostic is a problem found in a Taskfile.
+type Diagnostic struct {
+	// Line is 1-based; 0 puts the diagnostic on the first line.
+	Line int
+	// Error is set for errors; others are warnings.
+	Error   bool
+	Message string
+}
+
+// Analysis is what an Analyzer knows about a Taskfile.
+type Analysis struct {
+	Tasks       []Task
+	Diagnostics []Diagnostic
+}
+
+// Analyzer analyzes the Taskfile at path as saved on disk.
+type Analyzer func(ctx context.Context, path string) (*Analysis, error)
+
+// Runner runs the tool of task in the Taskfile at path and returns what to
+// tell the user.
+type Runner func(ctx context.Context, path string, task string) (string, error)
+
+// Server is a language server for Taskfiles.
+type Server struct {
+	analyze Analyzer
+	run     Runner
+
+	writeMu sync.Mutex
+	w       io.Writer
+
+	mu sync.Mutex
+	// documents are the open documents by URI.
+	documents map[string]*document
+	// refreshLenses is set when the client can be asked to request code
+	// lenses again.
+	refreshLenses bool
+	requests      int
+}
+
+// document is an open Taskfile.
+type document struct {
+	text string
+	// generation counts the analyses started, so a slow analysis can't
+	// replace a later one.
+	generation int
+	analysis   *Analysis
+}
+
+// New returns a server that analyzes Taskfiles with analyze and runs
+// their tools with run.
+func New(analyze Analyzer, run Runner) *Server {
+	return &Server{analyze: analyze, run: run, documents: map[string]*document{}}
+}
+
+// message is a JSON-RPC request, notification or response.
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  any              `json:"result"`
+}
+
+type errorResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Error   rpcError         `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSON-RPC error codes.
+const (
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeRequestFailed  = -32803
+)
+
+// errMethodNotFound is returned by handle for requests the server doesn't
+// know.
+var errMethodNotFound = errors.New("method not found")
+
+// Serve reads messages from r and writes to w until the client sends exit,
+// r ends or ctx is done.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s.w = w
+	reader := bufio.NewReader(r)
+	for {
+		data, err := readMessage(reader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var msg message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			slog.Warn("Ignoring malformed LSP message", "error", err)
+			continue
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		// Responses to the server's requests need no handling.
+		if msg.Method == "" {
+			continue
+		}
+		if msg.ID == nil {
+			s.notified(ctx, msg)
+			continue
+		}
+		// Running a tool takes a while; the editor can carry on meanwhile.
+		if msg.Method == "workspace/executeCommand" {
+			go s.reply(msg, func() (any, error) { return s.handle(ctx, msg) })
+			continue
+		}
+		s.reply(msg, func() (any, error) { return s.handle(ctx, msg) })
+	}
+}
+
+// readMessage reads a message framed by a Content-Length header.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if len(header) == 0 && errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("reading LSP header: %w", err)
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("reading LSP message: %w", err)
+	}
+	return data, nil
+}
+
+// write sends v as a message to the client.
+func (s *Server) write(v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		slog.Warn("Could not encode LSP message", "error", err)
+		return
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if _, err := fmt.Fprintf(s.w, "Content-Length: %d\r\n\r\n%s", len(data), data); err != nil {
+		slog.Warn("Could not send LSP message", "error", err)
+	}
+}
+
+// notify sends a notification to the client.
+func (s *Server) notify(method string, params any) {
+	s.write(struct {
+		JSONRPC string `json:"jsonrpc"`
+		Method  string `json:"method"`
+		Params  any    `json:"params"`
+	}{"2.0", method, params})
+}
+
+// reply answers the request msg with the result of handle.
+func (s *Server) reply(msg message, handle func() (any, error)) {
+	result, err := handle()
+	if err == nil {
+		s.write(response{JSONRPC: "2.0", ID: msg.ID, Result: result})
+		return
+	}
+	code := codeRequestFailed
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, errMethodNotFound):
+		code = codeMethodNotFound
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		code = codeInvalidParams
+	}
+	s.write(errorResponse{JSONRPC: "2.0", ID: msg.ID, Error: rpcError{Code: code, Message: err.Error()}})
+}
+
+// Protocol types, the parts of them the server uses.
+type (
+	position struct {
+		Line      int `json:"line"`
+		Character int `json:"character"`
+	}
+	lspRange struct {
+		Start position `json:"start"`
+		End   position `json:"end"`
+	}
+	textDocumentIdentifier struct {
+		URI string `json:"uri"`
+	}
+	textDocumentPositionParams struct {
+		TextDocument textDocumentIdentifier `json:"textDocument"`
+		Position     position               `json:"position"`
+	}
+	command struct {
+		Title     string `json:"title"`
+		Command   string `json:"command"`
+		Arguments []any  `json:"arguments,omitempty"`
+	}
+)
+
+// handle answers a request.
+func (s *Server) handle(ctx context.Context, msg message) (any, error) {
+	switch msg.Method {
+	case "initialize":
+		var params struct {
+			Capabilities struct {
+				Workspace struct {
+					CodeLens struct {
+						RefreshSupport bool `json:"refreshSupport"`
+					} `json:"codeLens"`
+				} `json:"workspace"`
+			} `json:"capabilities"`
+		}
+		if len(msg.Params) > 0 {
+			if err := json.Unmarshal(msg.Params, &params); err != nil {
+				return nil, err
+			}
+		}
+		s.mu.Lock()
+		s.refreshLenses = params.Capabilities.Workspace.CodeLens.RefreshSupport
+		s.mu.Unlock()
+		return map[string]any{
+			"capabilities": map[string]any{
+				// Full document sync.
+				"textDocumentSync":       map[string]any{"openClose": true, "change": 1, "save": true},
+				"hoverProvider":          true,
+				"codeLensProvider":       map[string]any{"resolveProvider": false},
+				"executeCommandProvider": map[string]any{"commands": []string{RunCommand}},
+			},
+			"serverInfo": map[string]any{"name": "tmcp"},
+		}, nil
+	case "shutdown":
+		return nil, nil
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		task, ok := s.taskAt(params.TextDocument.URI, params.Position.Line+1)
+		if !ok || task.Hover == "" {
+			return nil, nil
+		}
+		return map[string]any{"contents": map[string]any{"kind": "markdown", "value": task.Hover}}, nil
+	case "textDocument/codeLens":
+		var params struct {
+			TextDocument textDocumentIdentifier `json:"textDocument"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.codeLenses(params.TextDocument.URI), nil
+	case "workspace/executeCommand":
+		var params struct {
+			Command   string            `json:"command"`
+			Arguments []json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		var uri, task string
+		if params.Command != RunCommand || len(params.Arguments) != 2 ||
+			json.Unmarshal(params.Arguments[0], &uri) != nil || json.Unmarshal(params.Arguments[1], &task) != nil {
+			return nil, fmt.Errorf("unknown command %s", params.Command)
+		}
+		return s.runTool(ctx, uri, task)
+	}
+	return nil, errMethodNotFound
+}
+
+// notified handles a notification.
+func (s *Server) notified(ctx context.Context, msg message) {
+	var params struct {
+		TextDocument struct {
+			URI  string `json:"uri"`
+			Text string `json:"text"`
+		} `json:"textDocument"`
+		ContentChanges []struct {
+			Text string `json:"text"`
+		} `json:"contentChanges"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil && msg.Params != nil {
+		slog.Warn("Ignoring malformed LSP notification", "method", msg.Method, "error", err)
+		return
+	}
+	uri := params.TextDocument.URI
+	switch msg.Method {
+	case "textDocument/didOpen":
+		s.mu.Lock()
+		s.documents[uri] = &document{text: params.TextDocument.Text}
+		s.mu.Unlock()
+		s.reanalyze(ctx, uri)
+	case "textDocument/didChange":
+		s.mu.Lock()
+		if doc, ok := s.documents[uri]; ok && len(params.ContentChanges) > 0 {
+			doc.text = params.ContentChanges[len(params.ContentChanges)-1].Text
+		}
+		s.mu.Unlock()
+	case "textDocument/didSave":
+		// The Taskfile is inspected from disk, so only saves change it.
+		s.reanalyze(ctx, uri)
+	case "textDocument/didClose":
+		s.mu.Lock()
+		delete(s.documents, uri)
+		s.mu.Unlock()
+		s.notify("textDocument/publishDiagnostics", map[string]any{"uri": uri, "diagnostics": []any{}})
+	}
+}
+
+// reanalyze analyzes the document at uri in the background and publishes
+// its diagnostics.
+func (s *Server) reanalyze(ctx context.Context, uri string) {
+	s.mu.Lock()
+	doc, ok := s.documents[uri]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	doc.generation++
+	generation := doc.generation
+	s.mu.Unlock()
+
+	go func() {
+		analysis, err := s.analyzeURI(ctx, uri)
+		if err != nil {
+			analysis = &Analysis{Diagnostics: []Diagnostic{{Error: true, Message: err.Error()}}}
+		}
+		s.mu.Lock()
+		if doc.generation != generation || s.documents[uri] != doc {
+			s.mu.Unlock()
+			return
+		}
+		doc.analysis = analysis
+		diagnostics := s.diagnostics(doc)
+		refresh := s.refreshLenses
+		s.requests++
+		id := s.requests
+		s.mu.Unlock()
+		s.notify("textDocument/publishDiagnostics", map[string]any{"uri": uri, "diagnostics": diagnostics})
+		// Lenses move with the tasks.
+		if refresh {
+			s.write(struct {
+				JSONRPC string `json:"jsonrpc"`
+				ID      int    `json:"id"`
+				Method  string `json:"method"`
+			}{"2.0", id, "workspace/codeLens/refresh"})
+		}
+	}()
+}
+
+func (s *Server) analyzeURI(ctx context.Context, uri string) (*Analysis, error) {
+	path, err := URIPath(uri)
+	if err != nil {
+		return nil, err
+	}
+	return s.analyze(ctx, path)
+}
+
+// diagnostics converts the diagnostics of doc's analysis for the client.
+// The caller holds s.mu.
+func (s *Server) diagnostics(doc *document) []map[string]any {
+	lines := strings.Split(doc.text, "\n")
+	diagnostics := []map[string]any{}
+	for _, d := range doc.analysis.Diagnostics {
+		line := max(d.Line-1, 0)
+		end := 0
+		if line < len(lines) {
+			end = len(strings.TrimRight(lines[line], "\r"))
+		}
+		severity := 2
+		if d.Error {
+			severity = 1
+		}
+		diagnostics = append(diagnostics, map[string]any{
+			"range":    lspRange{Start: position{Line: line}, End: position{Line: line, Character: end}},
+			"severity": severity,
+			"source":   "tmcp",
+			"message":  d.Message,
+		})
+	}
+	return diagnostics
+}
+
+// taskAt returns the task whose definition spans line, the last task
+// starting at or before it.
+func (s *Server) taskAt(uri string, line int) (Task, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, ok := s.documents[uri]
+	if !ok || doc.analysis == nil {
+		return Task{}, false
+	}
+	var found Task
+	for _, task := range doc.analysis.Tasks {
+		if task.Line > 0 && task.Line <= line && task.Line > found.Line {
+			found = task
+		}
+	}
+	return found, found.Line > 0
+}
+
+// codeLenses returns a lens running each task of the document at uri.
+func (s *Server) codeLenses(uri string) []map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lenses := []map[string]any{}
+	doc, ok := s.documents[uri]
+	if !ok || doc.analysis == nil {
+		return lenses
+	}
+	for _, task := range doc.analysis.Tasks {
+		if task.Line == 0 || task.Lens == "" {
+			continue
+		}
+		at := position{Line: task.Line - 1}
+		lenses = append(lenses, map[string]any{
+			"range":   lspRange{Start: at, End: at},
+			"command": command{Title: task.Lens, Command: RunCommand, Arguments: []any{uri, task.Name}},
+		})
+	}
+	return lenses
+}
+
+// runTool runs the tool of task and shows the outcome to the user.
+func (s *Server) runTool(ctx context.Context, uri, task string) (any, error) {
+	path, err := URIPath(uri)
+	if err != nil {
+		return nil, err
+	}
+	output, err := s.run(ctx, path, task)
+	if err != nil {
+		s.notify("window/showMessage", map[string]any{"type": 1, "message": fmt.Sprintf("%s failed: %v", task, err)})
+		return nil, err
+	}
+	s.notify("window/showMessage", map[string]any{"type": 3, "message": output})
+	return output, nil
+}
+
+// URIPath returns the path of a file URI.
+func URIPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", fmt.Errorf("not a file URI: %s", uri)
+	}
+	path := u.Path
+	// file:///C:/dir on Windows.
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.FromSlash(path), nil
+}
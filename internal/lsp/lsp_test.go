@@ -0,0 +1,249 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testClient talks to a server like an editor.
+type testClient struct {
+	t      *testing.T
+	w      io.Writer
+	r      *bufio.Reader
+	nextID int
+	// messages are those read while waiting for something else.
+	messages []map[string]any
+}
+
+func startServer(t *testing.T, analyze Analyzer, run Runner) (*testClient, chan error) {
+	t.Helper()
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- New(analyze, run).Serve(context.Background(), serverR, serverW)
+		serverW.Close()
+	}()
+	t.Cleanup(func() { clientW.Close() })
+	return &testClient{t: t, w: clientW, r: bufio.NewReader(clientR)}, done
+}
+
+func (c *testClient) send(msg map[string]any) {
+	c.t.Helper()
+	msg["jsonrpc"] = "2.0"
+	data, err := json.Marshal(msg)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(data), data); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// read returns the next message of the server.
+func (c *testClient) read() map[string]any {
+	c.t.Helper()
+	type result struct {
+		data []byte
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		data, err := readMessage(c.r)
+		ch <- result{data, err}
+	}()
+	select {
+	case res := <-ch:
+		if res.err != nil {
+			c.t.Fatal(res.err)
+		}
+		var msg map[string]any
+		if err := json.Unmarshal(res.data, &msg); err != nil {
+			c.t.Fatal(err)
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for the server")
+		return nil
+	}
+}
+
+// call sends a request and returns its response.
+func (c *testClient) call(method string, params any) map[string]any {
+	c.t.Helper()
+	c.nextID++
+	c.send(map[string]any{"id": c.nextID, "method": method, "params": params})
+	for {
+		msg := c.read()
+		if id, ok := msg["id"].(float64); ok && int(id) == c.nextID {
+			return msg
+		}
+		c.messages = append(c.messages, msg)
+	}
+}
+
+// notification waits for a notification of method.
+func (c *testClient) notification(method string) map[string]any {
+	c.t.Helper()
+	for i, msg := range c.messages {
+		if msg["method"] == method {
+			c.messages = append(c.messages[:i], c.messages[i+1:]...)
+			return msg
+		}
+	}
+	for {
+		msg := c.read()
+		if msg["method"] == method {
+			return msg
+		}
+		c.messages = append(c.messages, msg)
+	}
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Taskfile.yml")
+	uri := "file://" + filepath.ToSlash(path)
+	analyze := func(ctx context.Context, got string) (*Analysis, error) {
+		if got != path {
+			t.Errorf("analyzed %s, want %s", got, path)
+		}
+		return &Analysis{
+			Tasks: []Task{
+				{Name: "build", Line: 3, Hover: "build tool", Lens: "Run"},
+				{Name: "lint", Line: 6, Hover: "lint tool", Lens: "Run"},
+			},
+			Diagnostics: []Diagnostic{{Line: 6, Message: "lint: no desc"}, {Line: 4, Error: true, Message: "build: bad"}},
+		}, nil
+	}
+	run := func(ctx context.Context, got string, task string) (string, error) {
+		if task == "lint" {
+			return "", fmt.Errorf("exit status 1")
+		}
+		return task + " ran", nil
+	}
+	c, done := startServer(t, analyze, run)
+
+	initialized := c.call("initialize", map[string]any{"capabilities": map[string]any{
+		"workspace": map[string]any{"codeLens": map[string]any{"refreshSupport": true}},
+	}})
+	capabilities, _ := initialized["result"].(map[string]any)["capabilities"].(map[string]any)
+	if capabilities["hoverProvider"] != true || capabilities["codeLensProvider"] == nil {
+		t.Errorf("capabilities = %v", capabilities)
+	}
+	c.send(map[string]any{"method": "initialized", "params": map[string]any{}})
+
+	text := "version: '3'\ntasks:\n  build:\n    cmds: [go build]\n\n  lint:\n"
+	c.send(map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+		"textDocument": map[string]any{"uri": uri, "languageId": "yaml", "version": 1, "text": text},
+	}})
+	published := c.notification("textDocument/publishDiagnostics")
+	refresh := c.notification("workspace/codeLens/refresh")
+	c.send(map[string]any{"id": refresh["id"], "result": nil})
+	diagnostics, _ := published["params"].(map[string]any)["diagnostics"].([]any)
+	if len(diagnostics) != 2 {
+		t.Fatalf("diagnostics = %v, want 2", diagnostics)
+	}
+	first := diagnostics[0].(map[string]any)
+	if first["severity"] != 2.0 || first["message"] != "lint: no desc" {
+		t.Errorf("diagnostics[0] = %v", first)
+	}
+	if got, want := fmt.Sprint(first["range"]), "map[end:map[character:7 line:5] start:map[character:0 line:5]]"; got != want {
+		t.Errorf("diagnostics[0].range = %s, want %s", got, want)
+	}
+	if second := diagnostics[1].(map[string]any); second["severity"] != 1.0 {
+		t.Errorf("diagnostics[1] = %v, want an error", second)
+	}
+
+	hover := func(line int) any {
+		return c.call("textDocument/hover", map[string]any{
+			"textDocument": map[string]any{"uri": uri},
+			"position":     map[string]any{"line": line, "character": 4},
+		})["result"]
+	}
+	if got := hover(3); fmt.Sprint(got) != "map[contents:map[kind:markdown value:build tool]]" {
+		t.Errorf("hover on build = %v", got)
+	}
+	if got := hover(6); fmt.Sprint(got) != "map[contents:map[kind:markdown value:lint tool]]" {
+		t.Errorf("hover on lint = %v", got)
+	}
+	if got := hover(0); got != nil {
+		t.Errorf("hover before the tasks = %v, want nil", got)
+	}
+
+	lenses, _ := c.call("textDocument/codeLens", map[string]any{"textDocument": map[string]any{"uri": uri}})["result"].([]any)
+	if len(lenses) != 2 {
+		t.Fatalf("code lenses = %v, want 2", lenses)
+	}
+	lens := lenses[0].(map[string]any)
+	if got, want := fmt.Sprint(lens["command"]), fmt.Sprintf("map[arguments:[%s build] command:tmcp.runTool title:Run]", uri); got != want {
+		t.Errorf("code lens command = %s, want %s", got, want)
+	}
+
+	ran := c.call("workspace/executeCommand", map[string]any{"command": RunCommand, "arguments": []any{uri, "build"}})
+	if ran["result"] != "build ran" {
+		t.Errorf("executeCommand = %v", ran)
+	}
+	if shown := c.notification("window/showMessage"); fmt.Sprint(shown["params"]) != "map[message:build ran type:3]" {
+		t.Errorf("showMessage = %v", shown)
+	}
+	failed := c.call("workspace/executeCommand", map[string]any{"command": RunCommand, "arguments": []any{uri, "lint"}})
+	if failed["error"] == nil {
+		t.Errorf("executeCommand of a failing task = %v, want an error", failed)
+	}
+	if shown := c.notification("window/showMessage"); !strings.Contains(fmt.Sprint(shown["params"]), "lint failed: exit status 1") {
+		t.Errorf("showMessage = %v", shown)
+	}
+
+	if unknown := c.call("textDocument/definition", map[string]any{}); unknown["error"].(map[string]any)["code"] != float64(codeMethodNotFound) {
+		t.Errorf("unknown method = %v", unknown)
+	}
+	if shutdown := c.call("shutdown", nil); shutdown["error"] != nil {
+		t.Errorf("shutdown = %v", shutdown)
+	}
+	c.send(map[string]any{"method": "exit"})
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve() = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve() didn't return on exit")
+	}
+}
+
+func TestAnalysisError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Taskfile.yml")
+	analyze := func(ctx context.Context, path string) (*Analysis, error) {
+		return nil, fmt.Errorf("task: invalid Taskfile")
+	}
+	c, _ := startServer(t, analyze, nil)
+	c.send(map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+		"textDocument": map[string]any{"uri": "file://" + filepath.ToSlash(path), "text": "tasks: ["},
+	}})
+	published := c.notification("textDocument/publishDiagnostics")
+	diagnostics, _ := published["params"].(map[string]any)["diagnostics"].([]any)
+	if len(diagnostics) != 1 || diagnostics[0].(map[string]any)["message"] != "task: invalid Taskfile" {
+		t.Errorf("diagnostics = %v, want the analysis error", diagnostics)
+	}
+}
+
+func TestURIPath(t *testing.T) {
+	got, err := URIPath("file:///home/me/my%20project/Taskfile.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.FromSlash("/home/me/my project/Taskfile.yml"); got != want {
+		t.Errorf("URIPath() = %q, want %q", got, want)
+	}
+	if _, err := URIPath("untitled:Untitled-1"); err == nil {
+		t.Error("URIPath() of an untitled document succeeded, want an error")
+	}
+}
@@ -0,0 +1,361 @@
+// Package policy implements the small expression language of tool call
+// policies, e.g.
+//
+//	task matches "deploy*" && args.ENV == "prod" && !(client.name in ["release-bot"])
+//
+// Identifiers such as task or args.ENV stand for strings, which are
+// compared with ==, !=, matches (a path.Match pattern) and in (a list of
+// strings). Comparisons combine with &&, || and !, and parentheses group.
+package policy
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// Expr is a parsed policy expression.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse parses a boolean expression.
+func Parse(src string) (*Expr, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %s at offset %d", tok, tok.pos)
+	}
+	if root.typ() != typeBool {
+		return nil, fmt.Errorf("expression must be a condition, not a %s", root.typ())
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval evaluates the expression, looking up identifiers with lookup.
+func (e *Expr) Eval(lookup func(name string) string) bool {
+	return e.root.eval(lookup).b
+}
+
+// Identifiers returns the identifiers used in the expression, in order of
+// appearance.
+func (e *Expr) Identifiers() []string {
+	var names []string
+	e.root.walk(func(n node) {
+		if id, ok := n.(identNode); ok {
+			names = append(names, string(id))
+		}
+	})
+	return names
+}
+
+type valueType int
+
+const (
+	typeString valueType = iota
+	typeList
+	typeBool
+)
+
+func (t valueType) String() string {
+	return [...]string{"string", "list", "condition"}[t]
+}
+
+type value struct {
+	s    string
+	list []string
+	b    bool
+}
+
+type node interface {
+	typ() valueType
+	eval(lookup func(string) string) value
+	walk(fn func(node))
+}
+
+type identNode string
+
+func (n identNode) typ() valueType                        { return typeString }
+func (n identNode) eval(lookup func(string) string) value { return value{s: lookup(string(n))} }
+func (n identNode) walk(fn func(node))                    { fn(n) }
+
+type stringNode string
+
+func (n stringNode) typ() valueType                 { return typeString }
+func (n stringNode) eval(func(string) string) value { return value{s: string(n)} }
+func (n stringNode) walk(fn func(node))             { fn(n) }
+
+type listNode []string
+
+func (n listNode) typ() valueType                 { return typeList }
+func (n listNode) eval(func(string) string) value { return value{list: n} }
+func (n listNode) walk(fn func(node))             { fn(n) }
+
+type boolNode bool
+
+func (n boolNode) typ() valueType                 { return typeBool }
+func (n boolNode) eval(func(string) string) value { return value{b: bool(n)} }
+func (n boolNode) walk(fn func(node))             { fn(n) }
+
+type notNode struct{ x node }
+
+func (n notNode) typ() valueType { return typeBool }
+func (n notNode) eval(lookup func(string) string) value {
+	return value{b: !n.x.eval(lookup).b}
+}
+func (n notNode) walk(fn func(node)) { fn(n); n.x.walk(fn) }
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n binaryNode) typ() valueType { return typeBool }
+
+func (n binaryNode) eval(lookup func(string) string) value {
+	switch n.op {
+	case "&&":
+		return value{b: n.left.eval(lookup).b && n.right.eval(lookup).b}
+	case "||":
+		return value{b: n.left.eval(lookup).b || n.right.eval(lookup).b}
+	}
+	left, right := n.left.eval(lookup), n.right.eval(lookup)
+	switch n.op {
+	case "==":
+		return value{b: left.s == right.s}
+	case "!=":
+		return value{b: left.s != right.s}
+	case "matches":
+		ok, _ := path.Match(right.s, left.s)
+		return value{b: ok}
+	case "in":
+		for _, item := range right.list {
+			if item == left.s {
+				return value{b: true}
+			}
+		}
+	}
+	return value{}
+}
+
+func (n binaryNode) walk(fn func(node)) { fn(n); n.left.walk(fn); n.right.walk(fn) }
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return fmt.Sprintf("string %q", t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+func tokenize(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexRune(src[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			tokens = append(tokens, token{kind: tokString, text: src[i+1 : i+1+end], pos: i})
+			i += end + 2
+		case strings.ContainsRune("()[],!", c):
+			if c == '!' && strings.HasPrefix(src[i:], "!=") {
+				tokens = append(tokens, token{kind: tokOp, text: "!=", pos: i})
+				i += 2
+				continue
+			}
+			tokens = append(tokens, token{kind: tokOp, text: string(c), pos: i})
+			i++
+		case strings.HasPrefix(src[i:], "&&"), strings.HasPrefix(src[i:], "||"), strings.HasPrefix(src[i:], "=="):
+			tokens = append(tokens, token{kind: tokOp, text: src[i : i+2], pos: i})
+			i += 2
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] == '.' || src[i] == '-' || unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i]))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+		default:
+			return nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// isOp reports whether the next token is the operator or keyword op.
+func (p *parser) isOp(op string) bool {
+	tok := p.peek()
+	return (tok.kind == tokOp || tok.kind == tokIdent) && tok.text == op
+}
+
+func (p *parser) expect(op string) error {
+	if tok := p.next(); tok.kind != tokOp || tok.text != op {
+		return fmt.Errorf("expected %q at offset %d, got %s", op, tok.pos, tok)
+	}
+	return nil
+}
+
+func (p *parser) or() (node, error) {
+	return p.logical("||", p.and)
+}
+
+func (p *parser) and() (node, error) {
+	return p.logical("&&", p.unary)
+}
+
+// logical parses operand (op operand)*, where both operands must be
+// conditions.
+func (p *parser) logical(op string, operand func() (node, error)) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp(op) {
+		pos := p.next().pos
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if left.typ() != typeBool || right.typ() != typeBool {
+			return nil, fmt.Errorf("%s at offset %d needs conditions on both sides", op, pos)
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if p.isOp("!") {
+		pos := p.next().pos
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if x.typ() != typeBool {
+			return nil, fmt.Errorf("! at offset %d needs a condition", pos)
+		}
+		return notNode{x: x}, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (node, error) {
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "matches", "in"} {
+		if !p.isOp(op) {
+			continue
+		}
+		pos := p.next().pos
+		right, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		want := typeString
+		if op == "in" {
+			want = typeList
+		}
+		if left.typ() != typeString || right.typ() != want {
+			return nil, fmt.Errorf("%s at offset %d compares a %s with a %s", op, pos, left.typ(), right.typ())
+		}
+		if op == "matches" {
+			pattern, ok := right.(stringNode)
+			if !ok {
+				return nil, fmt.Errorf("matches at offset %d needs a quoted pattern", pos)
+			}
+			if _, err := path.Match(string(pattern), ""); err != nil {
+				return nil, fmt.Errorf("bad pattern %q: %w", pattern, err)
+			}
+		}
+		return binaryNode{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) operand() (node, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokString:
+		return stringNode(tok.text), nil
+	case tok.kind == tokIdent && (tok.text == "true" || tok.text == "false"):
+		return boolNode(tok.text == "true"), nil
+	case tok.kind == tokIdent && tok.text != "matches" && tok.text != "in":
+		return identNode(tok.text), nil
+	case tok.kind == tokOp && tok.text == "(":
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return x, p.expect(")")
+	case tok.kind == tokOp && tok.text == "[":
+		var list listNode
+		for !p.isOp("]") {
+			item := p.next()
+			if item.kind != tokString {
+				return nil, fmt.Errorf("lists may only hold quoted strings, got %s at offset %d", item, item.pos)
+			}
+			list = append(list, item.text)
+			if !p.isOp("]") {
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+		}
+		p.next()
+		return list, nil
+	}
+	return nil, fmt.Errorf("unexpected %s at offset %d", tok, tok.pos)
+}
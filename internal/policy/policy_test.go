@@ -0,0 +1,68 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	vars := map[string]string{"task": "deploy:api", "args.ENV": "prod", "client.name": "cursor"}
+	lookup := func(name string) string { return vars[name] }
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`task == "deploy:api"`, true},
+		{`task matches "deploy:*" && args.ENV == 'prod'`, true},
+		{`task matches "deploy:*" && args.ENV == "prod" && !(client.name in ["release-bot", "ci"])`, true},
+		{`client.name in ["release-bot", "ci"]`, false},
+		{`args.MISSING == ""`, true},
+		{`args.ENV != "prod" || client.name == "cursor"`, true},
+		{`!true || false`, false},
+		{`task matches "build*" || args.ENV == "staging" && false`, false},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if got := expr.Eval(lookup); got != tt.want {
+			t.Errorf("Eval(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`task`,
+		`task ==`,
+		`task == "a" &&`,
+		`(task == "a"`,
+		`task == "a`,
+		`task in "a"`,
+		`task matches args.PATTERN`,
+		`task matches "[a"`,
+		`!task`,
+		`task == "a" && "b"`,
+		`task = "a"`,
+		`task in ["a" "b"]`,
+		`[] == []`,
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) error = nil, want error", expr)
+		}
+	}
+}
+
+func TestIdentifiers(t *testing.T) {
+	expr, err := Parse(`task matches "deploy*" && (args.ENV == "prod" || !(client.name in ["ci"]))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := expr.Identifiers(), []string{"task", "args.ENV", "client.name"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Identifiers() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,152 @@
+// Package release describes the archives of a tmcp release for package
+// managers such as Homebrew and Scoop.
+package release
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ChecksumsFile is the name of the checksums file of a release.
+const ChecksumsFile = "checksums.txt"
+
+// archiveExtensions are the archive formats a release may ship.
+var archiveExtensions = []string{".tar.gz", ".zip"}
+
+// Manifest is the metadata package managers need to install a release.
+type Manifest struct {
+	Name        string     `json:"name"`
+	Version     string     `json:"version"`
+	Commit      string     `json:"commit"`
+	Date        string     `json:"date"`
+	Description string     `json:"description"`
+	Homepage    string     `json:"homepage"`
+	License     string     `json:"license"`
+	Artifacts   []Artifact `json:"artifacts"`
+}
+
+// Artifact is an archive of the release for one platform.
+type Artifact struct {
+	Name   string `json:"name"`
+	OS     string `json:"os"`
+	Arch   string `json:"arch"`
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+	// Binary is the path of the executable inside the archive.
+	Binary string `json:"binary"`
+}
+
+// Artifacts lists the archives of project in dir, named
+// <project>_<os>_<arch>.tar.gz or .zip, with their SHA-256 checksums and
+// download URLs under baseURL. When dir has a checksums.txt, every archive
+// must match it.
+func Artifacts(dir string, project string, baseURL string) ([]Artifact, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	checksums, err := readChecksums(filepath.Join(dir, ChecksumsFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	var artifacts []Artifact
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		goos, arch, ok := parseArchiveName(e.Name(), project)
+		if !ok {
+			continue
+		}
+		sum, err := fileSHA256(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if want, ok := checksums[e.Name()]; checksums != nil && (!ok || want != sum) {
+			return nil, fmt.Errorf("%s doesn't match %s", e.Name(), ChecksumsFile)
+		}
+		binary := project
+		if goos == "windows" {
+			binary += ".exe"
+		}
+		artifacts = append(artifacts, Artifact{
+			Name:   e.Name(),
+			OS:     goos,
+			Arch:   arch,
+			URL:    strings.TrimSuffix(baseURL, "/") + "/" + e.Name(),
+			SHA256: sum,
+			Binary: binary,
+		})
+	}
+	if len(artifacts) == 0 {
+		return nil, fmt.Errorf("no %s archives in %s", project, dir)
+	}
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].Name < artifacts[j].Name })
+	return artifacts, nil
+}
+
+// parseArchiveName returns the platform of an archive named
+// <project>_<os>_<arch> with an archive extension.
+func parseArchiveName(name string, project string) (goos string, arch string, ok bool) {
+	base := ""
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(name, ext) {
+			base = strings.TrimSuffix(name, ext)
+		}
+	}
+	rest, found := strings.CutPrefix(base, project+"_")
+	if base == "" || !found {
+		return "", "", false
+	}
+	goos, arch, found = strings.Cut(rest, "_")
+	if !found || goos == "" || arch == "" || strings.Contains(arch, "_") {
+		return "", "", false
+	}
+	return goos, arch, true
+}
+
+// readChecksums reads a file of "<sha256>  <name>" lines, as written by
+// sha256sum and goreleaser.
+func readChecksums(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s: malformed line %q", path, scanner.Text())
+		}
+		// sha256sum marks binary mode with a '*' before the name.
+		checksums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return checksums, scanner.Err()
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,58 @@
+package release
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	linux := writeFile(t, dir, "tmcp_linux_amd64.tar.gz", "linux")
+	windows := writeFile(t, dir, "tmcp_windows_arm64.zip", "windows")
+	writeFile(t, dir, "tmcp_linux_amd64.tar.gz.sbom", "not an archive")
+	writeFile(t, dir, "other_linux_amd64.tar.gz", "another project")
+	if err := os.Mkdir(filepath.Join(dir, "tmcp_linux_amd64_v1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	artifacts, err := Artifacts(dir, "tmcp", "https://example.com/v1.2.0/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(artifacts) != 2 {
+		t.Fatalf("Artifacts() = %+v, want the two tmcp archives", artifacts)
+	}
+	want := Artifact{Name: "tmcp_linux_amd64.tar.gz", OS: "linux", Arch: "amd64", URL: "https://example.com/v1.2.0/tmcp_linux_amd64.tar.gz", SHA256: linux, Binary: "tmcp"}
+	if artifacts[0] != want {
+		t.Errorf("Artifacts()[0] = %+v, want %+v", artifacts[0], want)
+	}
+	if a := artifacts[1]; a.OS != "windows" || a.Arch != "arm64" || a.SHA256 != windows || a.Binary != "tmcp.exe" {
+		t.Errorf("Artifacts()[1] = %+v", a)
+	}
+
+	writeFile(t, dir, ChecksumsFile, linux+"  tmcp_linux_amd64.tar.gz\n"+windows+" *tmcp_windows_arm64.zip\n")
+	if _, err := Artifacts(dir, "tmcp", ""); err != nil {
+		t.Errorf("Artifacts() with matching checksums: %v", err)
+	}
+	writeFile(t, dir, ChecksumsFile, linux+"  tmcp_linux_amd64.tar.gz\n"+linux+"  tmcp_windows_arm64.zip\n")
+	if _, err := Artifacts(dir, "tmcp", ""); err == nil || !strings.Contains(err.Error(), "tmcp_windows_arm64.zip") {
+		t.Errorf("Artifacts() error = %v, want a checksum mismatch", err)
+	}
+
+	if _, err := Artifacts(t.TempDir(), "tmcp", ""); err == nil {
+		t.Error("Artifacts() error = nil for a directory without archives")
+	}
+}
@@ -0,0 +1,354 @@
+// Package remote fetches Taskfiles published over HTTP or in git
+// repositories into a local cache, so they can be inspected and run like
+// local ones.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+)
+
+// gitPrefix marks a Taskfile in a git repository, as in
+// git::https://github.com/org/repo.git//tasks/Taskfile.yml?ref=v1.2.0.
+const gitPrefix = "git::"
+
+// maxTaskfileSize bounds the size of a Taskfile fetched over HTTP.
+const maxTaskfileSize = 10 << 20
+
+// Ref is a parsed reference to a remote Taskfile.
+type Ref struct {
+	// URL is the Taskfile's URL, or the repository's when Git is set.
+	URL string
+	Git bool
+	// Path is the Taskfile within the repository. When empty, the first of
+	// config.TaskfileNames in its root is used.
+	Path string
+	// Rev is the branch, tag or commit to check out; the repository's
+	// default branch when empty.
+	Rev string
+	// SHA256 pins the hex-encoded checksum of the Taskfile. Empty for none.
+	SHA256 string
+}
+
+// IsRef reports whether s refers to a remote Taskfile rather than a file.
+func IsRef(s string) bool {
+	return strings.HasPrefix(s, gitPrefix) || strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
+
+// Parse parses a remote Taskfile reference: an http(s) URL, or a git
+// repository prefixed with git:: and followed by //<path> to the Taskfile
+// and ?ref=<rev>. Both take ?checksum=sha256:<hex> to pin the Taskfile's
+// contents.
+func Parse(s string) (Ref, error) {
+	if !IsRef(s) {
+		return Ref{}, fmt.Errorf("%s is not a remote Taskfile (want http(s):// or git::)", s)
+	}
+	ref := Ref{URL: s}
+	if rest, ok := strings.CutPrefix(s, gitPrefix); ok {
+		ref.Git = true
+		ref.URL = rest
+	}
+	base, rawQuery, _ := strings.Cut(ref.URL, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return Ref{}, fmt.Errorf("%s: %w", s, err)
+	}
+	if checksum := query.Get("checksum"); checksum != "" {
+		sum, ok := strings.CutPrefix(checksum, "sha256:")
+		if _, err := hex.DecodeString(sum); !ok || err != nil || len(sum) != 2*sha256.Size {
+			return Ref{}, fmt.Errorf("%s: checksum must be sha256:<64 hex digits>", s)
+		}
+		ref.SHA256 = strings.ToLower(sum)
+		query.Del("checksum")
+	}
+
+	if !ref.Git {
+		ref.URL = base
+		if len(query) > 0 {
+			ref.URL += "?" + query.Encode()
+		}
+		return ref, nil
+	}
+	ref.Rev = query.Get("ref")
+	query.Del("ref")
+	// git would read these as options.
+	if strings.HasPrefix(ref.Rev, "-") {
+		return Ref{}, fmt.Errorf("%s: ref must not start with -", s)
+	}
+	if len(query) > 0 {
+		return Ref{}, fmt.Errorf("%s: unknown parameters %s (want ref and checksum)", s, query.Encode())
+	}
+	// The path follows the first // after the scheme's.
+	start := 0
+	if i := strings.Index(base, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	ref.URL = base
+	if i := strings.Index(base[start:], "//"); i >= 0 {
+		ref.URL = base[:start+i]
+		ref.Path = path.Clean(base[start+i+2:])
+		if ref.Path == "." || path.IsAbs(ref.Path) || ref.Path == ".." || strings.HasPrefix(ref.Path, "../") {
+			return Ref{}, fmt.Errorf("%s: the Taskfile path must stay inside the repository", s)
+		}
+	}
+	if ref.URL == "" {
+		return Ref{}, fmt.Errorf("%s: missing repository", s)
+	}
+	if strings.HasPrefix(ref.URL, "-") {
+		return Ref{}, fmt.Errorf("%s: repository must not start with -", s)
+	}
+	return ref, nil
+}
+
+// DefaultCacheDir returns tmcp/remote in the user's cache directory.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tmcp", "remote"), nil
+}
+
+// Fetcher fetches remote Taskfiles into a cache directory. A Taskfile whose
+// checksum is pinned is only fetched when the cached copy doesn't match. Any
+// other is fetched every time, but the cached copy is used when the fetch
+// fails, e.g. offline.
+type Fetcher struct {
+	// CacheDir holds the fetched Taskfiles and repositories.
+	CacheDir string
+	// Client fetches over HTTP; http.DefaultClient with a timeout when nil.
+	Client *http.Client
+	// Git is the git binary; "git" when empty.
+	Git string
+}
+
+// Fetch returns the local path of the Taskfile ref refers to.
+func (f *Fetcher) Fetch(ctx context.Context, ref Ref) (string, error) {
+	if ref.Git {
+		return f.fetchGit(ctx, ref)
+	}
+	return f.fetchURL(ctx, ref)
+}
+
+// cacheDir returns the directory under which kind of remote Taskfile is
+// cached for key.
+func (f *Fetcher) cacheDir(kind string, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.CacheDir, kind, hex.EncodeToString(sum[:16]))
+}
+
+func (f *Fetcher) fetchURL(ctx context.Context, ref Ref) (string, error) {
+	name := path.Base(strings.SplitN(ref.URL, "?", 2)[0])
+	if ext := path.Ext(name); ext != ".yml" && ext != ".yaml" {
+		name = config.TaskfileNames[0]
+	}
+	cached := filepath.Join(f.cacheDir("url", ref.URL), name)
+	if ref.SHA256 != "" && checkSum(cached, ref.SHA256) == nil {
+		return cached, nil
+	}
+
+	data, err := f.download(ctx, ref.URL)
+	var netErr *fetchError
+	if errors.As(err, &netErr) && ref.SHA256 == "" {
+		if _, statErr := os.Stat(cached); statErr == nil {
+			slog.Warn("Fetching the remote Taskfile failed, using the cached copy", "url", ref.URL, "error", err)
+			return cached, nil
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	if ref.SHA256 != "" {
+		if got := sum(data); got != ref.SHA256 {
+			return "", checksumMismatch(ref.URL, got, ref.SHA256)
+		}
+	}
+	if err := writeAtomic(cached, data); err != nil {
+		return "", fmt.Errorf("caching %s: %w", ref.URL, err)
+	}
+	return cached, nil
+}
+
+// fetchError is a failure to reach the server, after which a cached copy
+// may be used.
+type fetchError struct{ err error }
+
+func (e *fetchError) Error() string { return e.err.Error() }
+func (e *fetchError) Unwrap() error { return e.err }
+
+// download fetches a Taskfile over HTTP.
+func (f *Fetcher) download(ctx context.Context, rawURL string) ([]byte, error) {
+	client := f.Client
+	if client == nil {
+		client = &http.Client{Timeout: time.Minute}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &fetchError{fmt.Errorf("fetching %s: %w", rawURL, err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", rawURL, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTaskfileSize+1))
+	if err != nil {
+		return nil, &fetchError{fmt.Errorf("fetching %s: %w", rawURL, err)}
+	}
+	if len(data) > maxTaskfileSize {
+		return nil, fmt.Errorf("fetching %s: larger than %d bytes", rawURL, maxTaskfileSize)
+	}
+	return data, nil
+}
+
+func (f *Fetcher) fetchGit(ctx context.Context, ref Ref) (string, error) {
+	dir := f.cacheDir("git", ref.URL+"@"+ref.Rev)
+	_, statErr := os.Stat(filepath.Join(dir, ".git"))
+	cloned := statErr == nil
+	if cloned && ref.SHA256 != "" {
+		if taskfile, err := repoTaskfile(dir, ref); err == nil && checkSum(taskfile, ref.SHA256) == nil {
+			return taskfile, nil
+		}
+	}
+
+	if err := f.checkout(ctx, dir, ref, cloned); err != nil {
+		if !cloned {
+			os.RemoveAll(dir)
+			return "", err
+		}
+		if ref.SHA256 != "" {
+			return "", err
+		}
+		slog.Warn("Fetching the remote Taskfile failed, using the cached copy", "repository", ref.URL, "error", err)
+	}
+	taskfile, err := repoTaskfile(dir, ref)
+	if err != nil {
+		return "", err
+	}
+	if ref.SHA256 != "" {
+		if err := checkSum(taskfile, ref.SHA256); err != nil {
+			return "", err
+		}
+	}
+	return taskfile, nil
+}
+
+// checkout fetches the revision of ref into the repository at dir, creating
+// it unless it was cloned before. Only the revision itself is fetched.
+func (f *Fetcher) checkout(ctx context.Context, dir string, ref Ref, cloned bool) error {
+	if !cloned {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		if err := f.git(ctx, dir, "init", "--quiet"); err != nil {
+			return err
+		}
+		if err := f.git(ctx, dir, "remote", "add", "origin", ref.URL); err != nil {
+			return err
+		}
+	}
+	rev := ref.Rev
+	if rev == "" {
+		rev = "HEAD"
+	}
+	if err := f.git(ctx, dir, "fetch", "--quiet", "--depth", "1", "origin", rev); err != nil {
+		return err
+	}
+	return f.git(ctx, dir, "checkout", "--quiet", "--force", "FETCH_HEAD")
+}
+
+// git runs a git command in dir.
+func (f *Fetcher) git(ctx context.Context, dir string, args ...string) error {
+	bin := f.Git
+	if bin == "" {
+		bin = "git"
+	}
+	var stderr bytes.Buffer
+	// #nosec G204
+	cmd := exec.CommandContext(ctx, bin, append([]string{"-C", dir}, args...)...)
+	cmd.Stderr = &stderr
+	// Never wait for credentials on a terminal nobody watches.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// repoTaskfile returns the Taskfile of ref in the repository at dir.
+func repoTaskfile(dir string, ref Ref) (string, error) {
+	if ref.Path != "" {
+		taskfile := filepath.Join(dir, filepath.FromSlash(ref.Path))
+		if _, err := os.Stat(taskfile); err != nil {
+			return "", fmt.Errorf("%s has no %s", ref.URL, ref.Path)
+		}
+		return taskfile, nil
+	}
+	for _, name := range config.TaskfileNames {
+		taskfile := filepath.Join(dir, name)
+		if _, err := os.Stat(taskfile); err == nil {
+			return taskfile, nil
+		}
+	}
+	return "", fmt.Errorf("%w in the root of %s", config.ErrNoTaskfile, ref.URL)
+}
+
+// checkSum checks the SHA-256 checksum of the file at path.
+func checkSum(path string, want string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if got := sum(data); got != want {
+		return checksumMismatch(path, got, want)
+	}
+	return nil
+}
+
+func sum(data []byte) string {
+	s := sha256.Sum256(data)
+	return hex.EncodeToString(s[:])
+}
+
+func checksumMismatch(what string, got string, want string) error {
+	return fmt.Errorf("checksum mismatch for %s: got sha256:%s, want sha256:%s", what, got, want)
+}
+
+// writeAtomic writes data to path so readers never see a partial file.
+func writeAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".fetch-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
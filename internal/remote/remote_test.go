@@ -0,0 +1,144 @@
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	pin := strings.Repeat("ab", sha256.Size)
+	tests := []struct {
+		in   string
+		want Ref
+	}{
+		{"https://example.com/Taskfile.yml", Ref{URL: "https://example.com/Taskfile.yml"}},
+		{"https://example.com/t.yml?token=x&checksum=sha256:" + pin, Ref{URL: "https://example.com/t.yml?token=x", SHA256: pin}},
+		{"git::https://github.com/org/repo.git", Ref{URL: "https://github.com/org/repo.git", Git: true}},
+		{"git::https://github.com/org/repo.git//tasks/Taskfile.yml?ref=v1.2.0", Ref{URL: "https://github.com/org/repo.git", Git: true, Path: "tasks/Taskfile.yml", Rev: "v1.2.0"}},
+		{"git::git@github.com:org/repo.git//Taskfile.yml?checksum=sha256:" + pin, Ref{URL: "git@github.com:org/repo.git", Git: true, Path: "Taskfile.yml", SHA256: pin}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %+v, %v, want %+v", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{
+		"Taskfile.yml",
+		"https://example.com/t.yml?checksum=md5:abc",
+		"https://example.com/t.yml?checksum=sha256:abc",
+		"git::https://github.com/org/repo.git//../Taskfile.yml",
+		"git::https://github.com/org/repo.git?branch=main",
+		"git::",
+		"git::https://github.com/org/repo.git?ref=--upload-pack=touch%20/tmp/pwned",
+		"git::--upload-pack=touch /tmp/pwned",
+	} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) error = nil", bad)
+		}
+	}
+}
+
+func checksum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestFetchURL(t *testing.T) {
+	content := "version: '3'\ntasks:\n  lint: echo lint\n"
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/shared/Taskfile.yml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	f := &Fetcher{CacheDir: t.TempDir()}
+	ctx := context.Background()
+
+	path, err := f.Fetch(ctx, Ref{URL: ts.URL + "/shared/Taskfile.yml"})
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != content || filepath.Base(path) != "Taskfile.yml" {
+		t.Errorf("Fetch() = %s with %q", path, data)
+	}
+
+	pinned := Ref{URL: ts.URL + "/shared/Taskfile.yml", SHA256: checksum(content)}
+	if _, err := f.Fetch(ctx, pinned); err != nil {
+		t.Fatalf("Fetch() with a matching checksum error = %v", err)
+	}
+	before := requests
+	if _, err := f.Fetch(ctx, pinned); err != nil || requests != before {
+		t.Errorf("Fetch() of a pinned, cached Taskfile = %v after %d requests, want no request", err, requests-before)
+	}
+	if _, err := f.Fetch(ctx, Ref{URL: pinned.URL, SHA256: checksum("other")}); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Fetch() with a wrong checksum error = %v, want a mismatch", err)
+	}
+	if _, err := f.Fetch(ctx, Ref{URL: ts.URL + "/missing.yml"}); err == nil {
+		t.Error("Fetch() of a missing Taskfile error = nil")
+	}
+
+	// Offline, the cached copy is used.
+	ts.Close()
+	if got, err := f.Fetch(ctx, Ref{URL: ts.URL + "/shared/Taskfile.yml"}); err != nil || got != path {
+		t.Errorf("Fetch() offline = %q, %v, want the cached %q", got, err, path)
+	}
+}
+
+func TestFetchGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	content := "version: '3'\ntasks:\n  test: echo test\n"
+	if err := os.MkdirAll(filepath.Join(repo, "tasks"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "tasks", "Taskfile.yml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "--quiet", "-m", "tasks"},
+		{"tag", "v1"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", args[0], err, out)
+		}
+	}
+
+	f := &Fetcher{CacheDir: t.TempDir()}
+	ref := Ref{URL: "file://" + repo, Git: true, Path: "tasks/Taskfile.yml", Rev: "v1", SHA256: checksum(content)}
+	path, err := f.Fetch(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Errorf("Fetch() = %s with %q", path, data)
+	}
+
+	// A pinned, cached checkout is used even when the repository is gone.
+	if err := os.RemoveAll(repo); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := f.Fetch(context.Background(), ref); err != nil || got != path {
+		t.Errorf("Fetch() of a pinned, cached Taskfile = %q, %v, want %q", got, err, path)
+	}
+	ref.Rev = "v2"
+	if _, err := f.Fetch(context.Background(), ref); err == nil {
+		t.Error("Fetch() of a missing repository error = nil")
+	}
+}
@@ -0,0 +1,113 @@
+// Package runner runs the task binary. The server and the viewer run tasks
+// through a Runner, so tests can swap in one that doesn't start processes.
+package runner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// killWaitDelay is how long a killed task's output is still read.
+const killWaitDelay = 2 * time.Second
+
+// Request is a run of the task binary.
+type Request struct {
+	// Bin is the task binary.
+	Bin string
+	// Args are the arguments to task: --taskfile, the task's name, its
+	// variables and so on.
+	Args []string
+	// Dir is the working directory; the current one when empty.
+	Dir string
+	// Env is the environment; the current process's when nil.
+	Env []string
+	// Stdin, Stdout and Stderr are connected to the task when set.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Command returns the command line of the run, for display.
+func (r Request) Command() []string {
+	return append([]string{r.Bin}, r.Args...)
+}
+
+// Runner runs the task binary until it exits or ctx is done, in which case
+// it is killed. A task that exited with a non-zero code, or was killed,
+// returns an *ExitError; any other error means it could not be run.
+type Runner interface {
+	Run(ctx context.Context, req Request) error
+}
+
+// Func adapts a function to a Runner, e.g. to fake task in tests.
+type Func func(ctx context.Context, req Request) error
+
+// Run calls f.
+func (f Func) Run(ctx context.Context, req Request) error {
+	return f(ctx, req)
+}
+
+// ExitError reports a run of task that didn't succeed.
+type ExitError struct {
+	// Code is the exit code, or -1 when task was killed.
+	Code int
+	// Err is the underlying error, if any.
+	Err error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	if e.Code == -1 {
+		return "killed"
+	}
+	return "exit status " + strconv.Itoa(e.Code)
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code err reports: 0 for nil, the code of an
+// *ExitError, and -1 for any other error.
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	default:
+		return -1
+	}
+}
+
+// Exec runs task as a child process.
+type Exec struct{}
+
+// Run implements Runner.
+func (Exec) Run(ctx context.Context, req Request) error {
+	// #nosec G204
+	cmd := exec.CommandContext(ctx, req.Bin, req.Args...)
+	cmd.Dir = req.Dir
+	cmd.Env = req.Env
+	cmd.Stdin = req.Stdin
+	cmd.Stdout = req.Stdout
+	cmd.Stderr = req.Stderr
+	if ctx.Done() != nil {
+		// Processes started by the task may keep its output open after the
+		// task itself has been killed.
+		cmd.WaitDelay = killWaitDelay
+	}
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &ExitError{Code: exitErr.ExitCode(), Err: err}
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExec(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "task")
+	script := "#!/bin/sh\npwd\necho \"$@\"\ncat\necho \"$GREETING\" >&2\nexit $1\n"
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		code string
+		want int
+	}{{"0", 0}, {"3", 3}} {
+		var stdout, stderr bytes.Buffer
+		err := Exec{}.Run(context.Background(), Request{
+			Bin:    bin,
+			Args:   []string{tc.code, "build"},
+			Dir:    dir,
+			Env:    []string{"GREETING=hello"},
+			Stdin:  strings.NewReader("input\n"),
+			Stdout: &stdout,
+			Stderr: &stderr,
+		})
+		if got := ExitCode(err); got != tc.want {
+			t.Errorf("exit %s: ExitCode() = %d (err %v), want %d", tc.code, got, err, tc.want)
+		}
+		real, _ := filepath.EvalSymlinks(dir)
+		if want := real + "\n" + tc.code + " build\ninput\n"; stdout.String() != want {
+			t.Errorf("stdout = %q, want %q", stdout.String(), want)
+		}
+		if stderr.String() != "hello\n" {
+			t.Errorf("stderr = %q, want the environment passed", stderr.String())
+		}
+	}
+}
+
+func TestExecNotFound(t *testing.T) {
+	err := Exec{}.Run(context.Background(), Request{Bin: filepath.Join(t.TempDir(), "missing")})
+	var exitErr *ExitError
+	if err == nil || errors.As(err, &exitErr) {
+		t.Fatalf("Run() error = %v, want a failure to start", err)
+	}
+	if got := ExitCode(err); got != -1 {
+		t.Errorf("ExitCode() = %d, want -1", got)
+	}
+}
+
+func TestFunc(t *testing.T) {
+	var got Request
+	r := Func(func(_ context.Context, req Request) error {
+		got = req
+		return &ExitError{Code: 2}
+	})
+	err := r.Run(context.Background(), Request{Bin: "task", Args: []string{"lint"}})
+	if ExitCode(err) != 2 || err.Error() != "exit status 2" {
+		t.Errorf("Run() error = %v, want exit status 2", err)
+	}
+	if strings.Join(got.Command(), " ") != "task lint" {
+		t.Errorf("Command() = %q", got.Command())
+	}
+}
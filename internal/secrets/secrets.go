@@ -0,0 +1,127 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Provider fetches a secret value from a reference understood by that
+// provider, e.g. "kv/db#password" for Vault.
+type Provider interface {
+	Fetch(ctx context.Context, ref string) (string, error)
+}
+
+// ProviderFunc adapts a function to the Provider interface.
+type ProviderFunc func(ctx context.Context, ref string) (string, error)
+
+// Fetch calls f.
+func (f ProviderFunc) Fetch(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// runCommand runs an external secret CLI and returns its stdout. It is a
+// variable so tests can replace it.
+var runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	// #nosec G204
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// providers maps the scheme prefix of a secret source to its provider.
+var providers = map[string]Provider{
+	// env:NAME reads an environment variable of the tmcp process.
+	"env": ProviderFunc(func(ctx context.Context, ref string) (string, error) {
+		value, ok := os.LookupEnv(ref)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", ref)
+		}
+		return value, nil
+	}),
+	// file:/path reads a file, dropping a single trailing newline.
+	"file": ProviderFunc(func(ctx context.Context, ref string) (string, error) {
+		data, err := os.ReadFile(ref)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r"), nil
+	}),
+	// op:op://vault/item/field reads from 1Password via the op CLI.
+	"op": ProviderFunc(func(ctx context.Context, ref string) (string, error) {
+		out, err := runCommand(ctx, "op", "read", "--no-newline", ref)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(string(out), "\n"), nil
+	}),
+	// vault:path#field reads a KV field from HashiCorp Vault via the vault CLI.
+	"vault": ProviderFunc(func(ctx context.Context, ref string) (string, error) {
+		path, field, ok := strings.Cut(ref, "#")
+		if !ok || path == "" || field == "" {
+			return "", fmt.Errorf("vault reference %q must look like <path>#<field>", ref)
+		}
+		out, err := runCommand(ctx, "vault", "kv", "get", "-field="+field, path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(string(out), "\n"), nil
+	}),
+}
+
+// Register adds or replaces the provider for scheme.
+func Register(scheme string, p Provider) {
+	providers[scheme] = p
+}
+
+// Parse splits a secret source like "vault:kv/db#password" into its scheme
+// and provider reference.
+func Parse(source string) (scheme string, ref string, err error) {
+	scheme, ref, ok := strings.Cut(source, ":")
+	if !ok || ref == "" {
+		return "", "", fmt.Errorf("secret source %q must look like <provider>:<reference>", source)
+	}
+	if _, ok := providers[scheme]; !ok {
+		return "", "", fmt.Errorf("secret source %q: unknown provider %q", source, scheme)
+	}
+	return scheme, ref, nil
+}
+
+// Validate reports whether source names a known provider.
+func Validate(source string) error {
+	_, _, err := Parse(source)
+	return err
+}
+
+// Resolve fetches every secret and returns them as KEY=VALUE environment
+// entries, sorted by name. Errors name the variable but never the value.
+func Resolve(ctx context.Context, sources map[string]string) ([]string, error) {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	env := make([]string, 0, len(names))
+	for _, name := range names {
+		scheme, ref, err := Parse(sources[name])
+		if err != nil {
+			return nil, fmt.Errorf("secret %s: %w", name, err)
+		}
+		value, err := providers[scheme].Fetch(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("secret %s: %w", name, err)
+		}
+		env = append(env, name+"="+value)
+	}
+	return env, nil
+}
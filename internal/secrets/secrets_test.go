@@ -0,0 +1,77 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("TMCP_TEST_TOKEN", "s3cr3t")
+	secretFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(secretFile, []byte("hunter2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls [][]string
+	original := runCommand
+	runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		calls = append(calls, append([]string{name}, args...))
+		return []byte("from-" + name + "\n"), nil
+	}
+	t.Cleanup(func() {
+		runCommand = original
+	})
+
+	env, err := Resolve(context.Background(), map[string]string{
+		"TOKEN":       "env:TMCP_TEST_TOKEN",
+		"PASSWORD":    "file:" + secretFile,
+		"DB_PASSWORD": "vault:kv/db#password",
+		"API_KEY":     "op:op://dev/api/key",
+	})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	want := []string{
+		"API_KEY=from-op",
+		"DB_PASSWORD=from-vault",
+		"PASSWORD=hunter2",
+		"TOKEN=s3cr3t",
+	}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("Resolve() = %q, want %q", env, want)
+	}
+
+	wantCalls := [][]string{
+		{"op", "read", "--no-newline", "op://dev/api/key"},
+		{"vault", "kv", "get", "-field=password", "kv/db"},
+	}
+	if !reflect.DeepEqual(calls, wantCalls) {
+		t.Errorf("secret CLI calls = %v, want %v", calls, wantCalls)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := map[string]string{
+		"missing env":        "env:TMCP_TEST_DEFINITELY_UNSET",
+		"missing file":       "file:/nonexistent/tmcp/secret",
+		"unknown provider":   "aws:secret/db",
+		"no reference":       "env:",
+		"vault without hash": "vault:kv/db",
+	}
+	for name, source := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Resolve(context.Background(), map[string]string{"SECRET": source})
+			if err == nil {
+				t.Fatalf("Resolve(%q) error = nil, want error", source)
+			}
+			if !strings.Contains(err.Error(), "SECRET") {
+				t.Errorf("Resolve(%q) error = %v, want it to name the variable", source, err)
+			}
+		})
+	}
+}
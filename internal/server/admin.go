@@ -0,0 +1,245 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+)
+
+// AdminSettings are the settings exposed by the admin API.
+type AdminSettings struct {
+	LogLevel string `json:"log_level"`
+	Runtime
+}
+
+// AdminOptions configures the admin API.
+type AdminOptions struct {
+	Addr string
+	// Token must be sent as "Authorization: Bearer <token>". It is required.
+	Token string
+	// LogLevel is the log level tmcp started with.
+	LogLevel slog.Level
+	// Persist writes settings back to the config file. Requests with
+	// ?persist=true fail when it is nil.
+	Persist func(AdminSettings) error
+}
+
+// settingsPatch is a partial update; nil fields are left unchanged.
+type settingsPatch struct {
+	LogLevel *string `json:"log_level"`
+	RuntimePatch
+}
+
+// admin serves the admin API for a set of bridges, which all share the
+// same runtime settings.
+type admin struct {
+	opts    AdminOptions
+	bridges []*Bridge
+	// setLogLevel is a field so tests don't change the process log level.
+	setLogLevel func(slog.Level)
+	handler     http.Handler
+
+	mu       sync.Mutex
+	logLevel slog.Level
+	// patchMu serializes updates so concurrent patches don't undo each other.
+	patchMu sync.Mutex
+}
+
+// newAdmin returns the admin API:
+//
+//	GET   /admin/settings                  current settings
+//	PATCH /admin/settings[?persist=true]   change settings, optionally saving them
+//	GET   /admin/approvals                 tool calls waiting for approval
+//	POST  /admin/approvals/{id}            approve or deny a waiting call
+//	GET   /admin/sessions                  initialized client sessions
+func newAdmin(opts AdminOptions, mounts []Mount) (*admin, error) {
+	if opts.Token == "" {
+		return nil, errors.New("the admin API requires a token")
+	}
+	a := &admin{opts: opts, logLevel: opts.LogLevel, setLogLevel: func(l slog.Level) { slog.SetLogLoggerLevel(l) }}
+	for _, m := range mounts {
+		a.bridges = append(a.bridges, m.Bridge)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/settings", a.getSettings)
+	mux.HandleFunc("PATCH /admin/settings", a.patchSettings)
+	mux.HandleFunc("GET /admin/approvals", a.getApprovals)
+	mux.HandleFunc("POST /admin/approvals/{id}", a.decideApproval)
+	mux.HandleFunc("GET /admin/sessions", a.getSessions)
+	a.handler = a.authenticate(mux)
+	return a, nil
+}
+
+func (a *admin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.handler.ServeHTTP(w, r)
+}
+
+// authenticate rejects requests without the admin token.
+func (a *admin) authenticate(next http.Handler) http.Handler {
+	want := []byte("Bearer " + a.opts.Token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// current returns the settings of the first bridge. Patches change all
+// bridges alike, but sources may keep dry-run settings of their own, see
+// Bridge.Runtime.
+func (a *admin) current() AdminSettings {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := AdminSettings{LogLevel: a.logLevel.String()}
+	if len(a.bridges) > 0 {
+		s.Runtime = a.bridges[0].Runtime()
+	}
+	return s
+}
+
+func (a *admin) getSettings(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, a.current())
+}
+
+func (a *admin) patchSettings(w http.ResponseWriter, r *http.Request) {
+	a.patchMu.Lock()
+	defer a.patchMu.Unlock()
+
+	var patch settingsPatch
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patch); err != nil {
+		http.Error(w, fmt.Sprintf("invalid settings: %v", err), http.StatusBadRequest)
+		return
+	}
+	persist := r.URL.Query().Get("persist") == "true"
+	if persist && a.opts.Persist == nil {
+		http.Error(w, "no config file to persist settings to", http.StatusBadRequest)
+		return
+	}
+
+	settings := a.current()
+	if err := patch.apply(&settings); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if persist {
+		if err := a.opts.Persist(settings); err != nil {
+			http.Error(w, fmt.Sprintf("persisting settings: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	a.mu.Lock()
+	if err := a.logLevel.UnmarshalText([]byte(settings.LogLevel)); err == nil {
+		a.setLogLevel(a.logLevel)
+	}
+	for _, b := range a.bridges {
+		b.SetRuntime(patch.RuntimePatch)
+	}
+	a.mu.Unlock()
+
+	slog.Info("Runtime settings changed via admin API", "settings", settings, "persisted", persist)
+	writeJSON(w, http.StatusOK, settings)
+}
+
+func (a *admin) getApprovals(w http.ResponseWriter, r *http.Request) {
+	pending := []Approval{}
+	for _, b := range a.bridges {
+		pending = append(pending, b.PendingApprovals()...)
+	}
+	writeJSON(w, http.StatusOK, pending)
+}
+
+func (a *admin) getSessions(w http.ResponseWriter, r *http.Request) {
+	sessions := []Session{}
+	for _, b := range a.bridges {
+		sessions = append(sessions, b.Sessions()...)
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+// decision is the body of POST /admin/approvals/{id}.
+type decision struct {
+	Approve *bool `json:"approve"`
+}
+
+func (a *admin) decideApproval(w http.ResponseWriter, r *http.Request) {
+	var d decision
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil || d.Approve == nil {
+		http.Error(w, `invalid decision: want {"approve": true} or {"approve": false}`, http.StatusBadRequest)
+		return
+	}
+	id := r.PathValue("id")
+	for _, b := range a.bridges {
+		if err := b.Decide(id, *d.Approve); err == nil {
+			slog.Info("Approval decided via admin API", "approval", id, "approved", *d.Approve)
+			writeJSON(w, http.StatusOK, map[string]any{"id": id, "approved": *d.Approve})
+			return
+		}
+	}
+	http.Error(w, ErrNotPending.Error(), http.StatusNotFound)
+}
+
+// apply validates the patch and merges it into s.
+func (p settingsPatch) apply(s *AdminSettings) error {
+	if p.LogLevel != nil {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(*p.LogLevel)); err != nil {
+			return fmt.Errorf("log_level: %w", err)
+		}
+		s.LogLevel = level.String()
+	}
+	if p.RateLimit != nil {
+		if *p.RateLimit < 0 {
+			return errors.New("rate_limit must not be negative")
+		}
+		s.RateLimit = *p.RateLimit
+	}
+	if p.DryRun != nil {
+		s.DryRun = *p.DryRun
+	}
+	if p.Allow != nil {
+		for _, pattern := range *p.Allow {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("allow: bad pattern %q: %w", pattern, err)
+			}
+		}
+		s.Allow = *p.Allow
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// ServeAdmin serves the admin API for the mounted bridges until ctx is
+// cancelled.
+func ServeAdmin(ctx context.Context, opts AdminOptions, mounts []Mount) error {
+	handler, err := newAdmin(opts, mounts)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              opts.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	slog.Info("Serving admin API", "url", "http://"+opts.Addr+"/admin/settings")
+
+	return listenAndServe(ctx, srv)
+}
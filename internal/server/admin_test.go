@@ -0,0 +1,116 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestAdmin(t *testing.T) {
+	newBridge := func() *Bridge {
+		cfg := newSettings(nil)
+		return &Bridge{cfg: cfg, sources: []*settings{cfg}}
+	}
+	bridges := []*Bridge{newBridge(), newBridge()}
+	var persisted []AdminSettings
+	a, err := newAdmin(AdminOptions{
+		Token:    "s3cr3t",
+		LogLevel: slog.LevelInfo,
+		Persist: func(s AdminSettings) error {
+			persisted = append(persisted, s)
+			return nil
+		},
+	}, []Mount{{BasePath: "api", Bridge: bridges[0]}, {BasePath: "web", Bridge: bridges[1]}})
+	if err != nil {
+		t.Fatalf("newAdmin() error = %v", err)
+	}
+	var logLevel slog.Level
+	a.setLogLevel = func(l slog.Level) { logLevel = l }
+
+	do := func(method string, target string, token string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do("GET", "/admin/settings", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET without token status = %d, want 401", rec.Code)
+	}
+	if rec := do("GET", "/admin/settings", "wrong", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET with wrong token status = %d, want 401", rec.Code)
+	}
+
+	rec := do("PATCH", "/admin/settings?persist=true", "s3cr3t", `{"log_level": "debug", "rate_limit": 30, "dry_run": true, "allow": ["lint:*"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PATCH status = %d: %s", rec.Code, rec.Body)
+	}
+	want := Runtime{RateLimit: 30, DryRun: true, Allow: []string{"lint:*"}}
+	for i, b := range bridges {
+		if got := b.Runtime(); !reflect.DeepEqual(got, want) {
+			t.Errorf("bridge %d runtime = %+v, want %+v", i, got, want)
+		}
+	}
+	for i, b := range bridges {
+		if !b.cfg.strictArguments() {
+			t.Errorf("bridge %d checks arguments loosely with dry runs on", i)
+		}
+	}
+	if logLevel != slog.LevelDebug {
+		t.Errorf("log level = %v, want DEBUG", logLevel)
+	}
+	if len(persisted) != 1 || persisted[0].LogLevel != "DEBUG" {
+		t.Errorf("persisted = %+v, want one DEBUG save", persisted)
+	}
+
+	rec = do("GET", "/admin/settings", "s3cr3t", "")
+	var got AdminSettings
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("GET body: %v", err)
+	}
+	if got.LogLevel != "DEBUG" || !reflect.DeepEqual(got.Runtime, want) {
+		t.Errorf("GET settings = %+v", got)
+	}
+
+	for name, body := range map[string]string{
+		"bad level":     `{"log_level": "loud"}`,
+		"negative rate": `{"rate_limit": -1}`,
+		"bad pattern":   `{"allow": ["["]}`,
+		"unknown field": `{"verbose": true}`,
+	} {
+		if rec := do("PATCH", "/admin/settings", "s3cr3t", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: PATCH status = %d, want 400", name, rec.Code)
+		}
+	}
+
+	// Fields a patch leaves out keep each source's own value.
+	standard, safe := newSettings(nil), newSettings([]Option{WithDryRunUnless([]string{"lint"})})
+	workspace := &Bridge{cfg: standard, sources: []*settings{standard, safe}}
+	wa, _ := newAdmin(AdminOptions{Token: "s3cr3t"}, []Mount{{Bridge: workspace}})
+	req := httptest.NewRequest("PATCH", "/admin/settings", strings.NewReader(`{"rate_limit": 5}`))
+	req.Header.Set("Authorization", "Bearer s3cr3t")
+	wa.ServeHTTP(httptest.NewRecorder(), req)
+	if standard.dryRun || !safe.dryRun || !reflect.DeepEqual(safe.allow, []string{"lint"}) || workspace.cfg.limiter.limit() != 5 {
+		t.Errorf("after a rate_limit patch, dry runs = %v and %v, allow = %v, want only the second source's unchanged", standard.dryRun, safe.dryRun, safe.allow)
+	}
+
+	bridges[1].cfg.sessions.open("s1", mcp.Implementation{Name: "editor", Version: "1.2"})
+	rec = do("GET", "/admin/sessions", "s3cr3t", "")
+	var sessions []Session
+	if err := json.NewDecoder(rec.Body).Decode(&sessions); err != nil || len(sessions) != 1 || sessions[0].Client != "editor" || sessions[0].ClientVersion != "1.2" {
+		t.Errorf("GET sessions = %+v, %v, want the session of web", sessions, err)
+	}
+
+	if _, err := newAdmin(AdminOptions{}, nil); err == nil {
+		t.Error("newAdmin() without token error = nil, want error")
+	}
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// toolAnnotations returns the annotations of a task's tool. Read-only tasks
+// are neither destructive nor change anything when repeated; otherwise the
+// MCP defaults apply, destructive and not idempotent, unless the Taskfile
+// says otherwise.
+func toolAnnotations(task inspector.TaskDefinition) []mcp.ToolOption {
+	var opts []mcp.ToolOption
+	if task.Title != "" {
+		opts = append(opts, mcp.WithTitleAnnotation(task.Title))
+	}
+	if task.ReadOnly {
+		opts = append(opts,
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+		)
+	}
+	if task.Destructive != nil {
+		opts = append(opts, mcp.WithDestructiveHintAnnotation(*task.Destructive))
+	}
+	if task.Idempotent != nil {
+		opts = append(opts, mcp.WithIdempotentHintAnnotation(*task.Idempotent))
+	}
+	return opts
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestToolAnnotations(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name string
+		task inspector.TaskDefinition
+		want mcp.ToolAnnotation
+	}{
+		{
+			name: "defaults",
+			task: inspector.TaskDefinition{Name: "deploy"},
+			want: mcp.ToolAnnotation{ReadOnlyHint: &no, DestructiveHint: &yes, IdempotentHint: &no, OpenWorldHint: &yes},
+		},
+		{
+			name: "read-only",
+			task: inspector.TaskDefinition{Name: "status", Title: "Repository status", ReadOnly: true},
+			want: mcp.ToolAnnotation{Title: "Repository status", ReadOnlyHint: &yes, DestructiveHint: &no, IdempotentHint: &yes, OpenWorldHint: &yes},
+		},
+		{
+			name: "explicit hints",
+			task: inspector.TaskDefinition{Name: "fmt", Destructive: &no, Idempotent: &yes},
+			want: mcp.ToolAnnotation{ReadOnlyHint: &no, DestructiveHint: &no, IdempotentHint: &yes, OpenWorldHint: &yes},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &inspector.MCPConfig{Tasks: []inspector.TaskDefinition{tt.task}}
+			tool := TranslateTtmcpTools(config, []ToolName{{Tool: tt.task.Name}}, nil)[0]
+			got, _ := json.Marshal(tool.Annotations)
+			want, _ := json.Marshal(tt.want)
+			if string(got) != string(want) {
+				t.Errorf("annotations = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestToolDescriptionDeps(t *testing.T) {
+	task := inspector.TaskDefinition{Name: "deploy", Description: "Deploy it.", Deps: []string{"build", "migrate"}}
+	want := "Deploy it.\n\nRuns the tasks it depends on first: build, migrate. There's no need to call them before it."
+	if got := toolDescription(task); got != want {
+		t.Errorf("toolDescription() = %q, want %q", got, want)
+	}
+}
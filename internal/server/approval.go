@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// DefaultApprovalTimeout is how long a parked call waits for a decision
+// when WithApprovals gets no timeout.
+const DefaultApprovalTimeout = 5 * time.Minute
+
+// Approval is a tool call waiting for a human to approve it.
+type Approval struct {
+	ID        string         `json:"id"`
+	Task      string         `json:"task"`
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	// Client and ClientVersion identify the client that made the call.
+	Client        string    `json:"client,omitempty"`
+	ClientVersion string    `json:"client_version,omitempty"`
+	RequestedAt   time.Time `json:"requested_at"`
+	Expires       time.Time `json:"expires"`
+}
+
+// ApprovalNotifier tells a human about a parked call. decide approves or
+// denies it and fails once the call is no longer waiting.
+type ApprovalNotifier func(a Approval, decide func(approve bool) error)
+
+// ErrNotPending is returned when deciding on a call that isn't waiting for
+// approval, e.g. because it timed out.
+var ErrNotPending = errors.New("no such call waiting for approval")
+
+// WithApprovals parks calls of the tasks matching one of the patterns until
+// a human approves them with Bridge.Decide. notify, if set, is called for
+// every parked call. Calls that aren't approved within timeout are denied.
+func WithApprovals(patterns []string, timeout time.Duration, notify ApprovalNotifier) Option {
+	return func(s *settings) {
+		s.approvalTasks = patterns
+		s.approvalTimeout = timeout
+		s.approvalNotify = notify
+	}
+}
+
+// needsApproval reports whether calls of task must be approved.
+func (s *settings) needsApproval(task inspector.TaskDefinition) bool {
+	return matchesAny(task.Name, s.approvalTasks)
+}
+
+// approvalIDs numbers approvals across all bridges, so the admin API can
+// tell them apart in multi-server mode.
+var approvalIDs atomic.Int64
+
+// approvals holds the calls waiting for a decision.
+type approvals struct {
+	mu      sync.Mutex
+	pending map[string]*pendingApproval
+}
+
+type pendingApproval struct {
+	Approval
+	decision chan bool
+}
+
+func newApprovals() *approvals {
+	return &approvals{pending: make(map[string]*pendingApproval)}
+}
+
+// requestApproval parks the call of task until a human approved it, and
+// returns the denial result otherwise.
+func (s *settings) requestApproval(ctx context.Context, task string, request mcp.CallToolRequest) *mcp.CallToolResult {
+	client := s.sessions.client(ctx)
+	a := Approval{Task: task, Tool: request.Params.Name, Arguments: request.GetArguments(), Client: client.Name, ClientVersion: client.Version}
+	return s.approvals.request(ctx, s.logger(ctx), a, s.approvalTimeout, s.approvalNotify)
+}
+
+// request parks a until it is decided, it expires or ctx is done, and
+// returns the denial result, or nil when the call was approved.
+func (as *approvals) request(ctx context.Context, log *slog.Logger, a Approval, timeout time.Duration, notify ApprovalNotifier) *mcp.CallToolResult {
+	if timeout <= 0 {
+		timeout = DefaultApprovalTimeout
+	}
+	a.RequestedAt = time.Now()
+	a.Expires = a.RequestedAt.Add(timeout)
+	a.ID = fmt.Sprintf("approval-%d", approvalIDs.Add(1))
+	p := &pendingApproval{Approval: a, decision: make(chan bool, 1)}
+
+	as.mu.Lock()
+	as.pending[p.ID] = p
+	as.mu.Unlock()
+	defer as.remove(p.ID)
+
+	log.Info("Tool call waiting for approval", "approval", p.ID, "task", a.Task)
+	if notify != nil {
+		go notify(p.Approval, func(approve bool) error { return as.decide(p.ID, approve) })
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case approved := <-p.decision:
+		if approved {
+			log.Info("Tool call approved", "approval", p.ID, "task", a.Task)
+			return nil
+		}
+		log.Info("Tool call denied", "approval", p.ID, "task", a.Task)
+		return mcp.NewToolResultError(fmt.Sprintf("Not approved: a human denied running %s.", a.Task))
+	case <-timer.C:
+		log.Info("Tool call approval timed out", "approval", p.ID, "task", a.Task)
+		return mcp.NewToolResultError(fmt.Sprintf("Not approved: nobody approved running %s within %s, so it was not run.", a.Task, timeout))
+	case <-ctx.Done():
+		return mcp.NewToolResultError(fmt.Sprintf("Cancelled while waiting for approval to run %s.", a.Task))
+	}
+}
+
+func (as *approvals) remove(id string) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	delete(as.pending, id)
+}
+
+// decide approves or denies the call id.
+func (as *approvals) decide(id string, approve bool) error {
+	as.mu.Lock()
+	p, ok := as.pending[id]
+	delete(as.pending, id)
+	as.mu.Unlock()
+	if !ok {
+		return ErrNotPending
+	}
+	p.decision <- approve
+	return nil
+}
+
+// list returns the waiting calls, oldest first.
+func (as *approvals) list() []Approval {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	list := make([]Approval, 0, len(as.pending))
+	for _, p := range as.pending {
+		list = append(list, p.Approval)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].RequestedAt.Before(list[j].RequestedAt) })
+	return list
+}
+
+// PendingApprovals returns the calls waiting for approval.
+func (b *Bridge) PendingApprovals() []Approval {
+	return b.cfg.approvals.list()
+}
+
+// Decide approves or denies the waiting call id. It returns ErrNotPending
+// when there is no such call.
+func (b *Bridge) Decide(id string, approve bool) error {
+	return b.cfg.approvals.decide(id, approve)
+}
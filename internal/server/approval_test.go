@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestTaskHandlerApprovals(t *testing.T) {
+	tests := []struct {
+		name string
+		// decision is what the notifier decides: "approve", "deny" or
+		// nothing.
+		decision string
+		wantErr  bool
+		wantText string
+	}{
+		{name: "approved", decision: "approve", wantText: "deployed"},
+		{name: "denied", decision: "deny", wantErr: true, wantText: "Not approved: a human denied running deploy."},
+		{name: "timeout", wantErr: true, wantText: "Not approved: nobody approved running deploy within 50ms, so it was not run."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notified := make(chan Approval, 1)
+			notify := func(a Approval, decide func(bool) error) {
+				notified <- a
+				if tt.decision != "" {
+					if err := decide(tt.decision == "approve"); err != nil {
+						t.Errorf("decide() error = %v", err)
+					}
+				}
+			}
+			cfg := newSettings([]Option{WithApprovals([]string{"deploy*"}, 50*time.Millisecond, notify)})
+			cfg.taskBin = fakeTaskBin(t, "echo deployed\n")
+			handler := createTaskHandler("Taskfile.yml", t.TempDir(), inspector.TaskDefinition{Name: "deploy"}, cfg)
+
+			request := mcp.CallToolRequest{}
+			request.Params.Name = "deploy"
+			request.Params.Arguments = map[string]any{"ENV": "prod"}
+			result, err := handler(context.Background(), request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.IsError != tt.wantErr || !strings.HasPrefix(resultText(result), tt.wantText) {
+				t.Errorf("result = %q (error %v), want %q", resultText(result), result.IsError, tt.wantText)
+			}
+			a := <-notified
+			if a.Task != "deploy" || a.Arguments["ENV"] != "prod" || !strings.HasPrefix(a.ID, "approval-") {
+				t.Errorf("notified approval = %+v", a)
+			}
+			if err := cfg.approvals.decide(a.ID, true); !errors.Is(err, ErrNotPending) {
+				t.Errorf("deciding again error = %v, want ErrNotPending", err)
+			}
+		})
+	}
+}
+
+func TestTaskHandlerApprovalsSkipOtherTasks(t *testing.T) {
+	cfg := newSettings([]Option{WithApprovals([]string{"deploy*"}, time.Millisecond, nil)})
+	cfg.taskBin = fakeTaskBin(t, "echo linted\n")
+	handler := createTaskHandler("Taskfile.yml", t.TempDir(), inspector.TaskDefinition{Name: "lint"}, cfg)
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsError || !strings.HasPrefix(resultText(result), "linted") {
+		t.Errorf("result = %q", resultText(result))
+	}
+}
+
+func TestAdminApprovals(t *testing.T) {
+	cfg := newSettings([]Option{WithApprovals([]string{"*"}, time.Minute, nil)})
+	b := &Bridge{cfg: cfg, sources: []*settings{cfg}}
+	a, err := newAdmin(AdminOptions{Token: "s3cr3t"}, []Mount{{Bridge: b}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	do := func(method string, target string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer s3cr3t")
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, req)
+		return rec
+	}
+
+	denial := make(chan *mcp.CallToolResult)
+	go func() {
+		denial <- cfg.approvals.request(context.Background(), slog.Default(), Approval{Task: "deploy", Tool: "deploy"}, time.Minute, nil)
+	}()
+	var pending []Approval
+	for deadline := time.Now().Add(time.Second); len(pending) == 0 && time.Now().Before(deadline); {
+		rec := do("GET", "/admin/approvals", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET status = %d", rec.Code)
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &pending); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(pending) != 1 || pending[0].Task != "deploy" {
+		t.Fatalf("pending = %+v", pending)
+	}
+
+	if rec := do("POST", "/admin/approvals/"+pending[0].ID, `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("POST without approve status = %d, want 400", rec.Code)
+	}
+	if rec := do("POST", "/admin/approvals/"+pending[0].ID, `{"approve": false}`); rec.Code != http.StatusOK {
+		t.Errorf("POST status = %d: %s", rec.Code, rec.Body)
+	}
+	if result := <-denial; result == nil || !result.IsError {
+		t.Errorf("request() = %v, want denial", result)
+	}
+	if rec := do("POST", "/admin/approvals/"+pending[0].ID, `{"approve": true}`); rec.Code != http.StatusNotFound {
+		t.Errorf("POST after decision status = %d, want 404", rec.Code)
+	}
+}
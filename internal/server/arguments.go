@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// argumentName matches the names of arguments that may be passed to a task.
+// Anything else could be read as an option of task or make, like --dry=false
+// or --eval, so it is rejected whether or not arguments are checked strictly.
+var argumentName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// joinSeparators are the separators of the joins of array parameters that
+// pass their values as one variable.
+var joinSeparators = map[string]string{"": " ", "space": " ", "comma": ","}
+
+// argumentVars returns the task variables, as KEY=VALUE pairs, of an
+// argument for param. The values of array parameters are joined as param
+// says, or passed as one variable each for the repeat join.
+func argumentVars(param inspector.TaskParameter, value any) ([]string, error) {
+	list, ok := value.([]any)
+	if param.Type != "array" || !ok {
+		formatted, err := formatArgument(param, value)
+		if err != nil {
+			return nil, err
+		}
+		return []string{param.Name + "=" + formatted}, nil
+	}
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		switch item.(type) {
+		case string, bool, float64:
+		default:
+			return nil, fmt.Errorf("argument %q must be a list of strings, numbers or booleans", param.Name)
+		}
+		formatted, err := formatArgument(param, item)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, formatted)
+	}
+	if param.Join == "repeat" {
+		vars := make([]string, len(values))
+		for i, v := range values {
+			vars[i] = param.Name + "=" + v
+		}
+		return vars, nil
+	}
+	return []string{param.Name + "=" + strings.Join(values, joinSeparators[param.Join])}, nil
+}
+
+// formatArgument returns the task variable value of an argument for param.
+// Numbers print in full without exponents or trailing zeros, so 1e6 is
+// 1000000 and 2.0 is 2, and must be whole for integer parameters. Booleans
+// print as true or false, and anything else as JSON.
+func formatArgument(param inspector.TaskParameter, value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		if param.Type == "integer" && v != math.Trunc(v) {
+			return "", fmt.Errorf("argument %q must be an integer", param.Name)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("argument %q: %w", param.Name, err)
+		}
+		return string(data), nil
+	}
+}
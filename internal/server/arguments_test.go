@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestFormatArgument(t *testing.T) {
+	tests := []struct {
+		name  string
+		typ   string
+		value any
+		want  string
+	}{
+		{"string", "", "prod", "prod"},
+		{"true", "boolean", true, "true"},
+		{"false", "", false, "false"},
+		{"integer", "integer", float64(3), "3"},
+		{"whole float", "number", 2.0, "2"},
+		{"float", "number", 0.25, "0.25"},
+		{"large", "", 1e21, "1000000000000000000000"},
+		{"negative", "integer", float64(-40), "-40"},
+		{"list", "", []any{"a", 1.0}, `["a",1]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatArgument(inspector.TaskParameter{Name: "X", Type: tt.typ}, tt.value)
+			if err != nil || got != tt.want {
+				t.Errorf("formatArgument(%v) = %q, %v, want %q", tt.value, got, err, tt.want)
+			}
+		})
+	}
+	if _, err := formatArgument(inspector.TaskParameter{Name: "X", Type: "integer"}, 2.5); err == nil {
+		t.Error("formatArgument(2.5) for an integer error = nil")
+	}
+}
+
+func TestArgumentVars(t *testing.T) {
+	files := []any{"a.go", "b c.go", 3.0}
+	tests := []struct {
+		param inspector.TaskParameter
+		value any
+		want  []string
+	}{
+		{inspector.TaskParameter{Name: "FILES", Type: "array"}, files, []string{"FILES=a.go b c.go 3"}},
+		{inspector.TaskParameter{Name: "FILES", Type: "array", Join: "comma"}, files, []string{"FILES=a.go,b c.go,3"}},
+		{inspector.TaskParameter{Name: "FILES", Type: "array", Join: "repeat"}, files, []string{"FILES=a.go", "FILES=b c.go", "FILES=3"}},
+		{inspector.TaskParameter{Name: "FILES", Type: "array", Join: "repeat"}, []any{}, []string{}},
+		// A single value stands for a list of one.
+		{inspector.TaskParameter{Name: "FILES", Type: "array"}, "a.go", []string{"FILES=a.go"}},
+		{inspector.TaskParameter{Name: "ENV"}, "prod", []string{"ENV=prod"}},
+	}
+	for _, tt := range tests {
+		got, err := argumentVars(tt.param, tt.value)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("argumentVars(%+v, %v) = %q, %v, want %q", tt.param, tt.value, got, err, tt.want)
+		}
+	}
+	if _, err := argumentVars(inspector.TaskParameter{Name: "FILES", Type: "array"}, []any{[]any{"nested"}}); err == nil {
+		t.Error("argumentVars() with a nested list error = nil")
+	}
+}
+
+func TestTaskHandlerArgumentTypes(t *testing.T) {
+	cfg := newSettings(nil)
+	cfg.taskBin = fakeTaskBin(t, "for arg in \"$@\"; do echo \"[$arg]\"; done\n")
+	task := inspector.TaskDefinition{Name: "scale", Parameters: []inspector.TaskParameter{
+		{Name: "REPLICAS", Type: "integer"},
+		{Name: "DRY", Type: "boolean", Default: false},
+		{Name: "REGION", Default: "eu-west-1"},
+	}}
+	handler := createTaskHandler("Taskfile.yml", t.TempDir(), task, cfg)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"REPLICAS": 1e6, "DRY": true, "REGION": nil}
+	result, err := handler(context.Background(), request)
+	if err != nil || result.IsError {
+		t.Fatalf("handler = %+v, %v", result, err)
+	}
+	got := resultText(result)
+	for _, want := range []string{"[REPLICAS=1000000]", "[DRY=true]"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("task ran with\n%s\nwant %s", got, want)
+		}
+	}
+	if strings.Contains(got, "REGION") {
+		t.Errorf("task ran with\n%s\nwant no REGION for a null argument", got)
+	}
+
+	request.Params.Arguments = map[string]any{"REPLICAS": 1.5}
+	if result, _ := handler(context.Background(), request); !result.IsError {
+		t.Errorf("handler with a fractional integer = %q, want an error", resultText(result))
+	}
+}
+
+func TestTaskHandlerArgumentNames(t *testing.T) {
+	cfg := newSettings(nil)
+	cfg.taskBin = fakeTaskBin(t, "echo ran\n")
+	handler := createTaskHandler("Taskfile.yml", t.TempDir(), inspector.TaskDefinition{Name: "build"}, cfg)
+
+	for _, name := range []string{"--eval", "--dry", "-n", "A B", "A=B", "1ENV"} {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{name: "false"}
+		result, _ := handler(context.Background(), request)
+		if !result.IsError || !strings.Contains(resultText(result), "not a valid argument name") {
+			t.Errorf("handler with argument %q = %q, want it rejected", name, resultText(result))
+		}
+	}
+
+	// Dry runs check arguments strictly, so unknown ones aren't passed on.
+	cfg = newSettings([]Option{WithDryRunUnless(nil)})
+	cfg.taskBin = fakeTaskBin(t, "echo ran\n")
+	handler = createTaskHandler("Taskfile.yml", t.TempDir(), inspector.TaskDefinition{Name: "build"}, cfg)
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"FORCE": "true"}
+	if result, _ := handler(context.Background(), request); !result.IsError || !strings.Contains(resultText(result), `unknown argument "FORCE"`) {
+		t.Errorf("dry-run handler with an unknown argument = %q, want it rejected", resultText(result))
+	}
+}
+
+func TestArrayParameterSchema(t *testing.T) {
+	task := inspector.TaskDefinition{Name: "lint", Parameters: []inspector.TaskParameter{{Name: "FILES", Type: "array", Join: "comma"}}}
+	tool := TranslateTtmcpTools(&inspector.MCPConfig{Tasks: []inspector.TaskDefinition{task}}, []ToolName{{Tool: "lint"}}, nil)[0]
+	files := tool.InputSchema.Properties["FILES"].(map[string]any)
+	if files["type"] != "array" || !reflect.DeepEqual(files["items"], map[string]any{"type": "string"}) {
+		t.Errorf("FILES schema = %v, want an array of strings", files)
+	}
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// artifactsMetaKey is the _meta key under which a task result lists the
+// artifacts the run produced.
+const artifactsMetaKey = "tmcp/artifacts"
+
+// maxArtifacts caps how many files one run registers, so a broad glob
+// can't flood the resource list.
+const maxArtifacts = 100
+
+// maxArtifactSize caps the size of an artifact returned by resources/read.
+const maxArtifactSize = 10 << 20
+
+// artifact is a file produced by a task run.
+type artifact struct {
+	URI  string `json:"uri"`
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// artifacts registers the files produced by task runs as MCP resources.
+type artifacts struct {
+	mu  sync.Mutex
+	mcp *server.MCPServer
+	// saved holds the output files written by saveOutput, oldest first.
+	saved []savedOutput
+}
+
+func newArtifacts() *artifacts {
+	return &artifacts{}
+}
+
+// attach sets the server artifacts are registered with. Until then they
+// are only reported in results.
+func (as *artifacts) attach(s *server.MCPServer) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+	as.mcp = s
+}
+
+// collect finds the files matching the task's output globs in dir and
+// registers them. Registering a file again keeps its URI, so clients can
+// re-read it after every run.
+func (as *artifacts) collect(dir string, task inspector.TaskDefinition) []artifact {
+	files := findArtifacts(dir, task.Outputs)
+	found := make([]artifact, 0, len(files))
+	var resources []server.ServerResource
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		name, err := filepath.Rel(dir, file)
+		if err != nil {
+			name = file
+		}
+		a := artifact{URI: fileURI(file), Name: filepath.ToSlash(name), Size: info.Size()}
+		found = append(found, a)
+		resources = append(resources, server.ServerResource{
+			Resource: mcp.NewResource(a.URI, a.Name,
+				mcp.WithResourceDescription(fmt.Sprintf("Output of task %s", task.Name)),
+				mcp.WithMIMEType(mime.TypeByExtension(filepath.Ext(file))),
+			),
+			Handler: readArtifact(file),
+		})
+	}
+	as.register(resources...)
+	return found
+}
+
+// register adds resources to the attached server, if any.
+func (as *artifacts) register(resources ...server.ServerResource) {
+	as.mu.Lock()
+	s := as.mcp
+	as.mu.Unlock()
+	if s != nil && len(resources) > 0 {
+		s.AddResources(resources...)
+	}
+}
+
+// findArtifacts returns the regular files in dir matching globs, sorted
+// and at most maxArtifacts. Besides path.Match syntax, globs may use "**"
+// for any number of directories. Files outside dir are never returned.
+func findArtifacts(dir string, globs []string) []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, glob := range globs {
+		glob = path.Clean(filepath.ToSlash(glob))
+		if path.IsAbs(glob) || glob == ".." || strings.HasPrefix(glob, "../") {
+			continue
+		}
+		// Only walk below the part of the glob without wildcards.
+		parts := strings.Split(glob, "/")
+		static := 0
+		for static < len(parts) && !strings.ContainsAny(parts[static], `*?[\`) {
+			static++
+		}
+		root := filepath.Join(dir, filepath.FromSlash(path.Join(parts[:static]...)))
+		_ = filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() || seen[file] {
+				return nil
+			}
+			if len(files) >= maxArtifacts {
+				return filepath.SkipAll
+			}
+			if rel, err := filepath.Rel(dir, file); err == nil && matchGlob(glob, filepath.ToSlash(rel)) {
+				seen[file] = true
+				files = append(files, file)
+			}
+			return nil
+		})
+	}
+	sort.Strings(files)
+	return files
+}
+
+// matchGlob reports whether the slash-separated name matches glob, where
+// a "**" segment matches any number of segments.
+func matchGlob(glob string, name string) bool {
+	globParts, nameParts := strings.Split(glob, "/"), strings.Split(name, "/")
+	var match func(g, n []string) bool
+	match = func(g, n []string) bool {
+		for len(g) > 0 {
+			if g[0] == "**" {
+				for i := 0; i <= len(n); i++ {
+					if match(g[1:], n[i:]) {
+						return true
+					}
+				}
+				return false
+			}
+			if len(n) == 0 {
+				return false
+			}
+			if ok, _ := path.Match(g[0], n[0]); !ok {
+				return false
+			}
+			g, n = g[1:], n[1:]
+		}
+		return len(n) == 0
+	}
+	return match(globParts, nameParts)
+}
+
+// readArtifact reads the artifact at file when a client asks for it, so
+// clients get the content of the latest run.
+func readArtifact(file string) server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading artifact: %w", err)
+		}
+		if info.Size() > maxArtifactSize {
+			return nil, fmt.Errorf("artifact %s is %d bytes, more than the %d bytes that can be read", file, info.Size(), maxArtifactSize)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading artifact: %w", err)
+		}
+		uri := request.Params.URI
+		mimeType := mimeType(file, data)
+		if utf8.Valid(data) {
+			return []mcp.ResourceContents{mcp.TextResourceContents{URI: uri, MIMEType: mimeType, Text: string(data)}}, nil
+		}
+		return []mcp.ResourceContents{mcp.BlobResourceContents{URI: uri, MIMEType: mimeType, Blob: base64.StdEncoding.EncodeToString(data)}}, nil
+	}
+}
+
+// mimeType guesses the MIME type of file from its extension and, failing
+// that, from whether data is text.
+func mimeType(file string, data []byte) string {
+	if t := mime.TypeByExtension(filepath.Ext(file)); t != "" {
+		return t
+	}
+	if !utf8.Valid(data) {
+		return "application/octet-stream"
+	}
+	return "text/plain"
+}
+
+// fileURI returns the file:// URI of an absolute path.
+func fileURI(file string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(file)}).String()
+}
+
+// describeArtifacts is the note appended to results that produced
+// artifacts.
+func describeArtifacts(found []artifact) string {
+	lines := make([]string, len(found))
+	for i, a := range found {
+		lines[i] = fmt.Sprintf("- %s (%d bytes): %s", a.Name, a.Size, a.URI)
+	}
+	return "Artifacts, readable as MCP resources:\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		glob, name string
+		want       bool
+	}{
+		{"report.xml", "report.xml", true},
+		{"*.xml", "report.xml", true},
+		{"*.xml", "out/report.xml", false},
+		{"out/**/*.xml", "out/report.xml", true},
+		{"out/**/*.xml", "out/a/b/report.xml", true},
+		{"**", "a/b/c", true},
+		{"out/**", "other/a", false},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.glob, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.glob, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFindArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"bin/app", "reports/a.xml", "reports/nested/b.xml", "reports/c.txt"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := findArtifacts(dir, []string{"./bin/app", "reports/**/*.xml", "../outside", "missing/*"})
+	want := []string{filepath.Join(dir, "bin/app"), filepath.Join(dir, "reports/a.xml"), filepath.Join(dir, "reports/nested/b.xml")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findArtifacts() = %q, want %q", got, want)
+	}
+}
+
+func TestTaskHandlerArtifacts(t *testing.T) {
+	cfg := newSettings(nil)
+	cfg.taskBin = fakeTaskBin(t, "mkdir -p out && echo '<testsuite/>' > out/report.xml && echo done\n")
+	s := server.NewMCPServer("test", "1.0.0", server.WithResourceCapabilities(false, true))
+	cfg.artifacts.attach(s)
+	dir := t.TempDir()
+	handler := createTaskHandler("Taskfile.yml", dir, inspector.TaskDefinition{Name: "test", Outputs: []string{"out/*.xml"}}, cfg)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	uri := fileURI(filepath.Join(dir, "out", "report.xml"))
+	if text := resultText(result); !strings.HasPrefix(text, "done") || !strings.Contains(text, uri) {
+		t.Errorf("result = %q, want output and artifact %s", text, uri)
+	}
+	if found, _ := result.Meta[artifactsMetaKey].([]artifact); len(found) != 1 || found[0].Name != "out/report.xml" {
+		t.Errorf("meta artifacts = %+v", result.Meta[artifactsMetaKey])
+	}
+
+	msg := s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"`+uri+`"}}`))
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `testsuite/`) {
+		t.Errorf("resources/read = %s, want the report", data)
+	}
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// budgetMetaKey is the _meta key under which budget denials describe the
+// session's usage and budget.
+const budgetMetaKey = "tmcp/budget"
+
+// Budget bounds what each client session may use up. Zero fields are
+// unlimited.
+type Budget struct {
+	// MaxCalls is the number of task tool calls.
+	MaxCalls int
+	// MaxRuntime is the total time the session's tasks run.
+	MaxRuntime time.Duration
+	// MaxCost is the total of the tasks' x-mcp cost estimates.
+	MaxCost float64
+}
+
+// Usage is what a session's task tool calls have used up of its budget.
+type Usage struct {
+	Calls   int
+	Runtime time.Duration
+	Cost    float64
+}
+
+// MarshalJSON encodes u with its runtime in milliseconds, like the other
+// durations of results.
+func (u Usage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{"calls": u.Calls, "runtime_ms": u.Runtime.Milliseconds(), "cost": u.Cost})
+}
+
+// WithBudget bounds the task tool calls of every client session, so a
+// runaway agent can't run tasks indefinitely. Calls beyond the budget are
+// rejected. Cached results and calls outside a client session, such as
+// scheduled runs, aren't counted.
+func WithBudget(b Budget) Option {
+	return func(s *settings) {
+		s.budget = b
+	}
+}
+
+// limited reports whether b limits anything.
+func (b Budget) limited() bool {
+	return b.MaxCalls > 0 || b.MaxRuntime > 0 || b.MaxCost > 0
+}
+
+// exceeded describes what of b a call of task would exceed, after usage,
+// or returns "" if it fits. Tasks that are estimated to take longer, or to
+// cost more, than what remains don't fit.
+func (b Budget) exceeded(usage Usage, task inspector.TaskDefinition, dryRun bool) string {
+	switch {
+	case b.MaxCalls > 0 && usage.Calls >= b.MaxCalls:
+		return fmt.Sprintf("this session has used %d of %d tool calls", usage.Calls, b.MaxCalls)
+	case b.MaxRuntime > 0 && usage.Runtime >= b.MaxRuntime:
+		return fmt.Sprintf("this session's tasks have run for %s of %s", usage.Runtime.Round(time.Second), b.MaxRuntime)
+	case b.MaxRuntime > 0 && !dryRun && usage.Runtime+task.Duration > b.MaxRuntime:
+		return fmt.Sprintf("%s is estimated to run for %s, and this session has %s of %s left", task.Name, task.Duration, (b.MaxRuntime - usage.Runtime).Round(time.Second), b.MaxRuntime)
+	case b.MaxCost > 0 && !dryRun && usage.Cost+task.Cost > b.MaxCost:
+		return fmt.Sprintf("%s costs %s, and this session has used %s of %s", task.Name, formatCost(task.Cost), formatCost(usage.Cost), formatCost(b.MaxCost))
+	}
+	return ""
+}
+
+// describeEstimates tells the model what a run of task is estimated to
+// cost and how long it takes, so it can plan within its budget.
+func describeEstimates(task inspector.TaskDefinition) string {
+	var estimates []string
+	if task.Duration > 0 {
+		estimates = append(estimates, "take about "+task.Duration.String())
+	}
+	if task.Cost > 0 {
+		estimates = append(estimates, "cost "+formatCost(task.Cost))
+	}
+	if len(estimates) == 0 {
+		return ""
+	}
+	return "Estimated to " + strings.Join(estimates, " and ") + " per run."
+}
+
+func formatCost(cost float64) string {
+	return strconv.FormatFloat(cost, 'f', -1, 64)
+}
+
+// chargeBudget counts a call of task against the budget of the session of
+// ctx, and returns a denial instead if the call exceeds it. Dry runs count
+// as calls but cost nothing.
+func (s *settings) chargeBudget(ctx context.Context, task inspector.TaskDefinition, dryRun bool) *mcp.CallToolResult {
+	session := server.ClientSessionFromContext(ctx)
+	if !s.budget.limited() || session == nil {
+		return nil
+	}
+	var reason string
+	usage, ok := s.sessions.update(session.SessionID(), func(current *Session) {
+		if reason = s.budget.exceeded(current.Usage, task, dryRun); reason != "" {
+			return
+		}
+		current.Usage.Calls++
+		if !dryRun {
+			current.Usage.Cost += task.Cost
+		}
+	})
+	if !ok || reason == "" {
+		return nil
+	}
+	result := mcp.NewToolResultError(fmt.Sprintf("Budget exceeded: %s. Start a new session to continue.", reason))
+	budget := map[string]any{"max_calls": s.budget.MaxCalls, "max_runtime_ms": s.budget.MaxRuntime.Milliseconds(), "max_cost": s.budget.MaxCost}
+	result.Meta = map[string]any{budgetMetaKey: map[string]any{"usage": usage.Usage, "budget": budget}}
+	return result
+}
+
+// addRuntime counts d of task runtime against the budget of the session
+// with the given ID.
+func (ss *sessions) addRuntime(id string, d time.Duration) {
+	if id == "" {
+		return
+	}
+	ss.update(id, func(s *Session) {
+		s.Usage.Runtime += d
+	})
+}
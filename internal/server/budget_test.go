@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestBudget(t *testing.T) {
+	tests := []struct {
+		name   string
+		budget Budget
+		task   inspector.TaskDefinition
+		// allowed is the number of calls before the budget is exceeded.
+		allowed int
+		want    string
+	}{
+		{"calls", Budget{MaxCalls: 2}, inspector.TaskDefinition{Name: "build"}, 2, "used 2 of 2 tool calls"},
+		{"cost", Budget{MaxCost: 5}, inspector.TaskDefinition{Name: "build", Cost: 2}, 2, "build costs 2, and this session has used 4 of 5"},
+		{"estimated duration", Budget{MaxRuntime: time.Minute}, inspector.TaskDefinition{Name: "build", Duration: 2 * time.Minute}, 0, "build is estimated to run for 2m0s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newSettings([]Option{WithBudget(tt.budget)})
+			cfg.taskBin = fakeTaskBin(t, "echo built\n")
+			handler := createTaskHandler("Taskfile.yml", t.TempDir(), tt.task, cfg)
+			ctx := sessionContext(cfg, "client")
+
+			for i := 0; i < tt.allowed; i++ {
+				if result, _ := handler(ctx, mcp.CallToolRequest{}); result.IsError {
+					t.Fatalf("call %d: %v, want it within the budget", i+1, result.Content)
+				}
+			}
+			result, _ := handler(ctx, mcp.CallToolRequest{})
+			text := result.Content[0].(mcp.TextContent).Text
+			if !result.IsError || !strings.Contains(text, tt.want) {
+				t.Errorf("call beyond the budget = %q, want %q", text, tt.want)
+			}
+			if _, ok := result.Meta[budgetMetaKey]; !ok {
+				t.Errorf("_meta = %v, want %s", result.Meta, budgetMetaKey)
+			}
+
+			// Other sessions have budgets of their own.
+			if result, _ := handler(sessionContext(cfg, "other"), mcp.CallToolRequest{}); result.IsError != (tt.allowed == 0) {
+				t.Errorf("call of another session = %v", result.Content)
+			}
+		})
+	}
+}
+
+func TestBudgetRuntime(t *testing.T) {
+	cfg := newSettings([]Option{WithBudget(Budget{MaxRuntime: 50 * time.Millisecond})})
+	cfg.taskBin = fakeTaskBin(t, "sleep 0.1\n")
+	handler := createTaskHandler("Taskfile.yml", t.TempDir(), inspector.TaskDefinition{Name: "build"}, cfg)
+	ctx := sessionContext(cfg, "client")
+
+	if result, _ := handler(ctx, mcp.CallToolRequest{}); result.IsError {
+		t.Fatalf("first call = %v", result.Content)
+	}
+	session, _ := cfg.sessions.get(ctx)
+	if session.Usage.Calls != 1 || session.Usage.Runtime < 100*time.Millisecond {
+		t.Errorf("usage = %+v, want 1 call of at least 100ms", session.Usage)
+	}
+	data, _ := json.Marshal(session.Usage)
+	if !strings.Contains(string(data), `"runtime_ms":1`) {
+		t.Errorf("usage JSON = %s, want the runtime in milliseconds", data)
+	}
+	result, _ := handler(ctx, mcp.CallToolRequest{})
+	if text := result.Content[0].(mcp.TextContent).Text; !result.IsError || !strings.Contains(text, "have run for") {
+		t.Errorf("call after the runtime ran out = %q", text)
+	}
+
+	// Calls outside a client session aren't counted.
+	if result, _ := handler(context.Background(), mcp.CallToolRequest{}); result.IsError {
+		t.Errorf("call without a session = %v", result.Content)
+	}
+}
+
+func TestToolDescriptionEstimates(t *testing.T) {
+	task := inspector.TaskDefinition{Name: "deploy", Description: "Deploy it.", Cost: 0.5, Duration: 2 * time.Minute}
+	want := "Deploy it.\n\nEstimated to take about 2m0s and cost 0.5 per run."
+	if got := toolDescription(task); got != want {
+		t.Errorf("toolDescription() = %q, want %q", got, want)
+	}
+}
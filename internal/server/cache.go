@@ -0,0 +1,104 @@
+package server
+
+import (
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// cachedAtMetaKey is the _meta key under which cached tool results report
+// when the task actually ran.
+const cachedAtMetaKey = "tmcp/cached_at"
+
+// WithResultCache caches the output of successful runs of the tasks matching
+// a pattern for the given TTL, unless the task sets its own x-mcp
+// cache_ttl. Calls with identical arguments within the TTL get the cached
+// output without running the task. When several patterns match, the first
+// in sorted order wins.
+func WithResultCache(ttls map[string]time.Duration) Option {
+	return func(s *settings) {
+		s.cacheTTLs = ttls
+	}
+}
+
+// cacheTTL returns how long results of task are cached; zero for not at all.
+func (s *settings) cacheTTL(task inspector.TaskDefinition) time.Duration {
+	if task.CacheTTL > 0 {
+		return task.CacheTTL
+	}
+	patterns := make([]string, 0, len(s.cacheTTLs))
+	for pattern := range s.cacheTTLs {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, task.Name); ok {
+			return s.cacheTTLs[pattern]
+		}
+	}
+	return 0
+}
+
+// cacheKey identifies a run by the command line source runs it with, its
+// stdin and env. The variables are ordered by name first, since their order
+// comes from map iteration; variables of the same name, and the other
+// words, keep their order, which the task sees.
+func cacheKey(source inspector.Source, call inspector.Run, stdin string, env []string) string {
+	vars := append([]string{}, call.Vars...)
+	sort.SliceStable(vars, func(i, j int) bool {
+		a, _, _ := strings.Cut(vars[i], "=")
+		b, _, _ := strings.Cut(vars[j], "=")
+		return a < b
+	})
+	call.Vars = vars
+	key, _ := json.Marshal([][]string{source.Command(call), {stdin}, env})
+	return string(key)
+}
+
+// resultCache holds task output by cacheKey until it expires.
+type resultCache struct {
+	// now is a field so tests can move time forward.
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	output  string
+	ranAt   time.Time
+	expires time.Time
+}
+
+func newResultCache() *resultCache {
+	return &resultCache{now: time.Now, entries: make(map[string]cacheEntry)}
+}
+
+// get returns the unexpired output stored under key and when it was produced.
+func (c *resultCache) get(key string) (string, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expires) {
+		return "", time.Time{}, false
+	}
+	return entry.output, entry.ranAt, true
+}
+
+// put stores output under key for ttl and drops expired entries.
+func (c *resultCache) put(key string, output string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for k, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cacheEntry{output: output, ranAt: now, expires: now.Add(ttl)}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestCacheTTLPrecedence(t *testing.T) {
+	cfg := newSettings([]Option{WithResultCache(map[string]time.Duration{
+		"git:*": time.Minute,
+		"g*":    time.Second,
+	})})
+
+	tests := []struct {
+		task inspector.TaskDefinition
+		want time.Duration
+	}{
+		{inspector.TaskDefinition{Name: "git:status", CacheTTL: time.Hour}, time.Hour},
+		{inspector.TaskDefinition{Name: "git:status"}, time.Second},
+		{inspector.TaskDefinition{Name: "build"}, 0},
+	}
+	for _, tt := range tests {
+		if got := cfg.cacheTTL(tt.task); got != tt.want {
+			t.Errorf("cacheTTL(%s) = %v, want %v", tt.task.Name, got, tt.want)
+		}
+	}
+}
+
+func TestTaskHandlerCache(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "runs")
+	cfg := newSettings(nil)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg.cache.now = func() time.Time { return now }
+	cfg.taskBin = fakeTaskBin(t, `echo x >> `+counter+`
+echo "run $(wc -l < `+counter+` | tr -d ' ')"
+`)
+	task := inspector.TaskDefinition{Name: "status", CacheTTL: time.Minute}
+	handler := createTaskHandler("Taskfile.yml", t.TempDir(), task, cfg)
+	call := func(args map[string]any) *mcp.CallToolResult {
+		t.Helper()
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+		result, err := handler(context.Background(), request)
+		if err != nil || result.IsError {
+			t.Fatalf("handler = %+v, %v", result, err)
+		}
+		return result
+	}
+
+	first := call(map[string]any{"A": "1", "B": "2"})
+	now = now.Add(30 * time.Second)
+	second := call(map[string]any{"B": "2", "A": "1"})
+	if resultText(second) != resultText(first) || second.Meta[cachedAtMetaKey] != "2024-01-01T12:00:00Z" {
+		t.Errorf("second call = %q %v, want cached %q", resultText(second), second.Meta, resultText(first))
+	}
+	if got := resultText(call(map[string]any{"A": "other"})); got != "run 2\n" {
+		t.Errorf("call with other arguments = %q, want a fresh run", got)
+	}
+	// The order of extra arguments matters to the task.
+	if got := resultText(call(map[string]any{cliArgsArgument: "--from A --to B"})); got != "run 3\n" {
+		t.Errorf("call with extra arguments = %q, want a fresh run", got)
+	}
+	if got := resultText(call(map[string]any{cliArgsArgument: "--from B --to A"})); got != "run 4\n" {
+		t.Errorf("call with reordered extra arguments = %q, want a fresh run", got)
+	}
+
+	now = now.Add(time.Minute)
+	if got := resultText(call(map[string]any{"A": "1", "B": "2"})); got != "run 5\n" {
+		t.Errorf("call after the ttl = %q, want a fresh run", got)
+	}
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// The server capabilities WithoutCapabilities can turn off. Tools are what
+// the bridge is for, so they can't be.
+const (
+	CapabilityResources = "resources"
+	CapabilityPrompts   = "prompts"
+	CapabilityLogging   = "logging"
+)
+
+// Capabilities lists the server capabilities WithoutCapabilities accepts.
+var Capabilities = []string{CapabilityResources, CapabilityPrompts, CapabilityLogging}
+
+// ProtocolVersions returns the MCP protocol versions WithProtocolVersion
+// accepts, oldest first.
+func ProtocolVersions() []string {
+	return slices.Clone(mcp.ValidProtocolVersions)
+}
+
+// WithProtocolVersion answers every client's initialize with version
+// instead of the version the client asked for, for clients that misbehave
+// with the features of newer versions. Clients that don't support version
+// disconnect.
+func WithProtocolVersion(version string) Option {
+	return func(s *settings) {
+		s.protocolVersion = version
+	}
+}
+
+// WithoutCapabilities keeps the server from announcing and serving the
+// named capabilities, see Capabilities. Without resources, the artifacts
+// and saved output of tasks are only reported in results.
+func WithoutCapabilities(names ...string) Option {
+	return func(s *settings) {
+		s.disabledCapabilities = append(s.disabledCapabilities, names...)
+	}
+}
+
+// checkNegotiation checks the options of WithProtocolVersion and
+// WithoutCapabilities.
+func (s *settings) checkNegotiation() error {
+	if s.protocolVersion != "" && !slices.Contains(mcp.ValidProtocolVersions, s.protocolVersion) {
+		return fmt.Errorf("unknown MCP protocol version %q (want one of %s)", s.protocolVersion, strings.Join(mcp.ValidProtocolVersions, ", "))
+	}
+	for _, name := range s.disabledCapabilities {
+		if !slices.Contains(Capabilities, name) {
+			return fmt.Errorf("unknown capability %q (want one of %s)", name, strings.Join(Capabilities, ", "))
+		}
+	}
+	return nil
+}
+
+// capabilityDisabled reports whether WithoutCapabilities turned off name.
+func (s *settings) capabilityDisabled(name string) bool {
+	return slices.Contains(s.disabledCapabilities, name)
+}
+
+// registerNegotiation pins the protocol version of initialize results and
+// logs what each client and the server agreed on.
+func (s *settings) registerNegotiation(hooks *server.Hooks) {
+	hooks.AddAfterInitialize(func(ctx context.Context, id any, message *mcp.InitializeRequest, result *mcp.InitializeResult) {
+		requested := message.Params.ProtocolVersion
+		if s.protocolVersion != "" {
+			result.ProtocolVersion = s.protocolVersion
+		}
+		// mcp-go announces capabilities once anything is registered for
+		// them.
+		if s.capabilityDisabled(CapabilityResources) {
+			result.Capabilities.Resources = nil
+		}
+		if s.capabilityDisabled(CapabilityPrompts) {
+			result.Capabilities.Prompts = nil
+		}
+		if s.capabilityDisabled(CapabilityLogging) {
+			result.Capabilities.Logging = nil
+		}
+		log := s.logger(ctx)
+		if requested != result.ProtocolVersion {
+			log.Warn("Client asked for another MCP protocol version", "requested", requested, "protocol", result.ProtocolVersion)
+		}
+		clientCapabilities := capabilityNames(message.Params.Capabilities)
+		// mcp-go drops the capabilities it doesn't know, which the stdio
+		// client's peer keeps.
+		if peer := s.peerFor(ctx); peer != nil {
+			clientCapabilities = peer.declared()
+		}
+		log.Info("Negotiated MCP session",
+			"protocol", result.ProtocolVersion,
+			"client_capabilities", clientCapabilities,
+			"server_capabilities", capabilityNames(result.Capabilities),
+		)
+	})
+}
+
+// capabilityNames returns the capabilities set in capabilities, a
+// ClientCapabilities or ServerCapabilities.
+func capabilityNames(capabilities any) []string {
+	data, err := json.Marshal(capabilities)
+	if err != nil {
+		return nil
+	}
+	var set map[string]json.RawMessage
+	if json.Unmarshal(data, &set) != nil {
+		return nil
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
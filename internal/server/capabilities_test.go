@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestNegotiation(t *testing.T) {
+	dir := t.TempDir()
+	taskfile := filepath.Join(dir, "Taskfile.yml")
+	if err := os.WriteFile(taskfile, []byte("version: '3'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bin := fakeTaskBin(t, `echo '{"tasks": [{"name": "greet", "desc": "Say hello"}]}'`)
+
+	for _, tc := range []struct {
+		name         string
+		opts         []Option
+		protocol     string
+		capabilities []string
+	}{
+		{"default", nil, "2025-03-26", []string{"logging", "resources", "tools"}},
+		{"pinned", []Option{WithProtocolVersion("2024-11-05")}, "2024-11-05", []string{"logging", "resources", "tools"}},
+		{"disabled", []Option{WithoutCapabilities(CapabilityResources, CapabilityLogging)}, "2025-03-26", []string{"tools"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			bridge, err := New(context.Background(), taskfile, bin, "tasks", append(tc.opts, WithLogOutput(io.Discard))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer bridge.Close()
+			handle := func(message string) []byte {
+				response, _ := json.Marshal(bridge.MCPServer().HandleMessage(context.Background(), json.RawMessage(message)))
+				return response
+			}
+			var msg struct {
+				Result mcp.InitializeResult `json:"result"`
+			}
+			if err := json.Unmarshal(handle(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","clientInfo":{"name":"test","version":"1.0"},"capabilities":{}}}`), &msg); err != nil {
+				t.Fatal(err)
+			}
+			if msg.Result.ProtocolVersion != tc.protocol {
+				t.Errorf("protocol version = %q, want %q", msg.Result.ProtocolVersion, tc.protocol)
+			}
+			if got := capabilityNames(msg.Result.Capabilities); !reflect.DeepEqual(got, tc.capabilities) {
+				t.Errorf("capabilities = %v, want %v", got, tc.capabilities)
+			}
+			resources := string(handle(`{"jsonrpc":"2.0","id":2,"method":"resources/list"}`))
+			if disabled := !strings.Contains(resources, `"result"`); disabled != !slices.Contains(tc.capabilities, "resources") {
+				t.Errorf("resources/list = %s", resources)
+			}
+		})
+	}
+}
+
+func TestNegotiationOptions(t *testing.T) {
+	for _, opt := range []Option{WithProtocolVersion("2023-01-01"), WithoutCapabilities("tools")} {
+		if err := newSettings([]Option{opt}).checkNegotiation(); err == nil {
+			t.Error("checkNegotiation() = nil, want an error")
+		}
+	}
+	if err := newSettings([]Option{WithProtocolVersion("2024-11-05"), WithoutCapabilities(Capabilities...)}).checkNegotiation(); err != nil {
+		t.Errorf("checkNegotiation() = %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// cliArgsArgument is the argument every task accepts whose value is passed
+// to task after --, which makes it the task's CLI_ARGS.
+const cliArgsArgument = "_cli_args"
+
+// cliArgsDescription describes the CLI args parameter of a tool.
+const cliArgsDescription = "Extra arguments for the command, passed to the task as CLI_ARGS, e.g. -run TestFoo -v. Quote arguments with spaces."
+
+// cliArgs returns the words of the _cli_args argument, split like a shell
+// would but without any expansion.
+func cliArgs(args map[string]any) ([]string, error) {
+	value, ok := args[cliArgsArgument]
+	if !ok || value == nil {
+		return nil, nil
+	}
+	words, err := splitWords(fmt.Sprint(value))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", cliArgsArgument, err)
+	}
+	return words, nil
+}
+
+// splitWords splits s at unquoted whitespace. Single quotes keep everything
+// up to the next single quote; double quotes and backslashes work as in a
+// POSIX shell.
+func splitWords(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+			continue
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`", s[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, errors.New("unterminated double quote")
+			}
+		case c == '\\':
+			if i+1 >= len(s) {
+				return nil, errors.New("trailing backslash")
+			}
+			i++
+			word.WriteByte(s[i])
+		default:
+			word.WriteByte(c)
+		}
+		inWord = true
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := map[string][]string{
+		"":                         nil,
+		"-run TestFoo -v":          {"-run", "TestFoo", "-v"},
+		`  -run 'Test Foo'  `:      {"-run", "Test Foo"},
+		`-m "a \"b\" $x" c\ d`:     {"-m", `a "b" $x`, "c d"},
+		`$(rm -rf /) ; echo; 'x'y`: {"$(rm", "-rf", "/)", ";", "echo;", "xy"},
+		`--name=""`:                {"--name="},
+	}
+	for in, want := range tests {
+		if got, err := splitWords(in); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("splitWords(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{`'open`, `"open`, `trailing\`} {
+		if _, err := splitWords(bad); err == nil {
+			t.Errorf("splitWords(%q) error = nil", bad)
+		}
+	}
+}
+
+func TestTaskHandlerCLIArgs(t *testing.T) {
+	cfg := newSettings([]Option{WithStrictArguments()})
+	cfg.taskBin = fakeTaskBin(t, "for arg in \"$@\"; do echo \"[$arg]\"; done\n")
+	task := inspector.TaskDefinition{Name: "test", CLIArgs: true}
+	dir := t.TempDir()
+	handler := createTaskHandler("Taskfile.yml", dir, task, cfg)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{cliArgsArgument: `-run 'TestFoo|TestBar' -v`}
+	result, err := handler(context.Background(), request)
+	if err != nil || result.IsError {
+		t.Fatalf("handler = %+v, %v", result, err)
+	}
+	want := "[--taskfile]\n[Taskfile.yml]\n[--dir]\n[" + dir + "]\n[test]\n[--]\n[-run]\n[TestFoo|TestBar]\n[-v]\n"
+	if got := resultText(result); got != want {
+		t.Errorf("task ran with\n%s\nwant\n%s", got, want)
+	}
+
+	request.Params.Arguments = map[string]any{cliArgsArgument: `'unterminated`}
+	if result, _ := handler(context.Background(), request); !result.IsError {
+		t.Errorf("handler with a bad %s = %q, want an error", cliArgsArgument, resultText(result))
+	}
+
+	tool := TranslateTtmcpTools(&inspector.MCPConfig{Tasks: []inspector.TaskDefinition{task}}, []ToolName{{Tool: "test"}}, nil)[0]
+	if _, ok := tool.InputSchema.Properties[cliArgsArgument]; !ok {
+		t.Errorf("schema of a task using CLI_ARGS = %v, want %s", tool.InputSchema.Properties, cliArgsArgument)
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"time"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/runner"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// DefaultNotifyAfter is how long a task must run before its completion is
+// notified when WithCompletionNotifier gets no duration.
+const DefaultNotifyAfter = 30 * time.Second
+
+// Completion is a finished run of a task.
+type Completion struct {
+	// Server is the name of the bridge that ran the task.
+	Server string `json:"server"`
+	Task   string `json:"task"`
+	// ExitCode is the task's exit code; -1 when it couldn't be started or
+	// was killed.
+	ExitCode   int       `json:"exit_code"`
+	DurationMS int64     `json:"duration_ms"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// Duration returns how long the task ran, retries included.
+func (c Completion) Duration() time.Duration {
+	return time.Duration(c.DurationMS) * time.Millisecond
+}
+
+// CompletionNotifier tells a human that a task finished. It runs on its own
+// goroutine.
+type CompletionNotifier func(c Completion)
+
+// WithCompletionNotifier calls notify whenever a run of a task matching one
+// of the patterns (all tasks when empty) finishes after at least after,
+// e.g. so someone supervising an agent knows a deploy is done. Dry runs
+// are never notified.
+func WithCompletionNotifier(after time.Duration, patterns []string, notify CompletionNotifier) Option {
+	return func(s *settings) {
+		if after <= 0 {
+			after = DefaultNotifyAfter
+		}
+		s.notifyAfter = after
+		s.notifyTasks = patterns
+		s.notifyCompletion = notify
+	}
+}
+
+// completed notifies the completion of a run of task that took d and ended
+// with err, if it is long enough.
+func (s *settings) completed(task inspector.TaskDefinition, err error, d time.Duration) {
+	if s.notifyCompletion == nil || d < s.notifyAfter {
+		return
+	}
+	if len(s.notifyTasks) > 0 && !matchesAny(task.Name, s.notifyTasks) {
+		return
+	}
+	go s.notifyCompletion(Completion{Server: s.serverName, Task: task.Name, ExitCode: runner.ExitCode(err), DurationMS: d.Milliseconds(), FinishedAt: time.Now().UTC()})
+}
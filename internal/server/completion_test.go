@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestCompletionNotifier(t *testing.T) {
+	notified := make(chan Completion, 10)
+	cfg := newSettings([]Option{WithCompletionNotifier(50*time.Millisecond, []string{"deploy", "quick"}, func(c Completion) { notified <- c })})
+	cfg.serverName = "tasks"
+	cfg.taskBin = fakeTaskBin(t, `case "$*" in
+*deploy*) sleep 0.1; exit 3 ;;
+esac
+`)
+	dir := t.TempDir()
+	for _, name := range []string{"quick", "deploy"} {
+		handler := createTaskHandler("Taskfile.yml", dir, inspector.TaskDefinition{Name: name}, cfg)
+		if _, err := handler(context.Background(), mcp.CallToolRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case c := <-notified:
+		if c.Server != "tasks" || c.Task != "deploy" || c.ExitCode != 3 || c.Duration() < 100*time.Millisecond {
+			t.Errorf("completion = %+v, want deploy exiting with 3 after at least 100ms", c)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("deploy's completion was not notified")
+	}
+	select {
+	case c := <-notified:
+		t.Errorf("got a second completion %+v, want quick tasks left out", c)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// WithConcurrencyGroups makes the tasks of each group run one at a time;
+// overlapping calls queue until the running task finishes. groups maps a
+// group name to path.Match patterns of task names. A task may belong to
+// several groups.
+func WithConcurrencyGroups(groups map[string][]string) Option {
+	return func(s *settings) {
+		s.groups = groups
+	}
+}
+
+// taskGroups returns the sorted names of the groups task belongs to.
+func (s *settings) taskGroups(task inspector.TaskDefinition) []string {
+	var groups []string
+	for name, patterns := range s.groups {
+		if matchesAny(task.Name, patterns) {
+			groups = append(groups, name)
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// groupLocks holds a named mutex per concurrency group. The mutexes are
+// channels so waiting can be abandoned when a call is cancelled.
+type groupLocks struct {
+	mu    sync.Mutex
+	locks map[string]chan struct{}
+}
+
+func newGroupLocks() *groupLocks {
+	return &groupLocks{locks: make(map[string]chan struct{})}
+}
+
+func (g *groupLocks) lock(name string) chan struct{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	l, ok := g.locks[name]
+	if !ok {
+		l = make(chan struct{}, 1)
+		g.locks[name] = l
+	}
+	return l
+}
+
+// acquire locks every group in names, which must be sorted so that calls
+// sharing several groups can't deadlock. It returns a function releasing
+// them all, or ctx's error if ctx is done first.
+func (g *groupLocks) acquire(ctx context.Context, names []string) (func(), error) {
+	var held []chan struct{}
+	release := func() {
+		for _, l := range held {
+			<-l
+		}
+	}
+	for _, name := range names {
+		l := g.lock(name)
+		select {
+		case l <- struct{}{}:
+			held = append(held, l)
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestTaskGroups(t *testing.T) {
+	cfg := newSettings([]Option{WithConcurrencyGroups(map[string][]string{
+		"deploy": {"deploy:*"},
+		"db":     {"db:*", "deploy:prod"},
+	})})
+	tests := map[string][]string{
+		"deploy:prod":    {"db", "deploy"},
+		"deploy:staging": {"deploy"},
+		"build":          nil,
+	}
+	for name, want := range tests {
+		if got := cfg.taskGroups(inspector.TaskDefinition{Name: name}); !reflect.DeepEqual(got, want) {
+			t.Errorf("taskGroups(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestGroupLocks(t *testing.T) {
+	locks := newGroupLocks()
+	release, err := locks.acquire(context.Background(), []string{"db", "deploy"})
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	// A call sharing a group queues until the first one is done.
+	acquired := make(chan func())
+	go func() {
+		second, err := locks.acquire(context.Background(), []string{"deploy"})
+		if err != nil {
+			t.Errorf("second acquire() error = %v", err)
+		}
+		acquired <- second
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("second call ran while the group was held")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// A cancelled call gives up waiting and holds nothing.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := locks.acquire(ctx, []string{"db"}); err == nil {
+		t.Fatal("acquire() with a cancelled context error = nil")
+	}
+
+	release()
+	select {
+	case second := <-acquired:
+		second()
+	case <-time.After(time.Second):
+		t.Fatal("second call never ran")
+	}
+	// Everything is free again.
+	release, err = locks.acquire(context.Background(), []string{"db", "deploy"})
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	release()
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Project is a Taskfile registered with the daemon.
+type Project struct {
+	Name     string `json:"name"`
+	Taskfile string `json:"taskfile"`
+	// URL is the MCP endpoint serving the project.
+	URL   string `json:"url"`
+	Tools int    `json:"tools"`
+	// Error says why a registered project isn't served, e.g. because its
+	// Taskfile no longer inspects after a restart.
+	Error string `json:"error,omitempty"`
+}
+
+// ControlOptions configures the control API of the daemon.
+type ControlOptions struct {
+	// Load inspects the Taskfile of a project into a bridge, giving up when
+	// ctx is done.
+	Load func(ctx context.Context, name string, taskfile string) (*Bridge, error)
+	// Persist saves the registered projects after every change. It may be
+	// nil.
+	Persist func([]Project) error
+	// BaseURL is where the registry is served, e.g. http://127.0.0.1:8080.
+	BaseURL string
+}
+
+// Control registers and unregisters the projects of a registry at runtime.
+type Control struct {
+	opts     ControlOptions
+	registry *Registry
+	handler  http.Handler
+
+	// mu serializes changes so projects and the registry stay in step.
+	mu       sync.Mutex
+	projects map[string]Project
+}
+
+// registration is the body of POST /projects.
+type registration struct {
+	Name     string `json:"name"`
+	Taskfile string `json:"taskfile"`
+}
+
+// NewControl returns the control API of registry:
+//
+//	GET    /projects          registered projects
+//	POST   /projects          register {"name": ..., "taskfile": ...}
+//	DELETE /projects/{name}   unregister
+func NewControl(registry *Registry, opts ControlOptions) *Control {
+	c := &Control{opts: opts, registry: registry, projects: make(map[string]Project)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /projects", c.getProjects)
+	mux.HandleFunc("POST /projects", c.postProject)
+	mux.HandleFunc("DELETE /projects/{name}", c.deleteProject)
+	c.handler = mux
+	return c
+}
+
+func (c *Control) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.handler.ServeHTTP(w, r)
+}
+
+// ErrRegistered is returned when registering a name that is taken.
+var ErrRegistered = errors.New("already registered")
+
+// Register inspects the project's Taskfile and serves it. Projects that fail
+// to load are not registered.
+func (c *Control) Register(ctx context.Context, name string, taskfile string) (Project, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.projects[name]; ok {
+		return Project{}, fmt.Errorf("%s: %w", name, ErrRegistered)
+	}
+	p, err := c.load(ctx, name, taskfile)
+	if err != nil {
+		return Project{}, err
+	}
+	c.projects[name] = p
+	c.persist()
+	slog.Info("Registered project", "project", name, "taskfile", taskfile, "url", p.URL)
+	return p, nil
+}
+
+// Restore registers projects saved by an earlier daemon. Projects that fail
+// to load stay registered with an error, so they aren't forgotten.
+func (c *Control) Restore(ctx context.Context, name string, taskfile string) Project {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, err := c.load(ctx, name, taskfile)
+	if err != nil {
+		slog.Warn("Project not served", "project", name, "taskfile", taskfile, "error", err)
+		p = Project{Name: name, Taskfile: taskfile, Error: err.Error()}
+	}
+	c.projects[name] = p
+	return p
+}
+
+// load serves the project's Taskfile.
+func (c *Control) load(ctx context.Context, name string, taskfile string) (Project, error) {
+	bridge, err := c.opts.Load(ctx, name, taskfile)
+	if err != nil {
+		return Project{}, err
+	}
+	if err := c.registry.Add(name, bridge); err != nil {
+		bridge.Close()
+		return Project{}, err
+	}
+	return Project{Name: name, Taskfile: taskfile, URL: c.opts.BaseURL + endpointPath(name), Tools: len(bridge.Tools())}, nil
+}
+
+// Unregister stops serving the project and forgets it.
+func (c *Control) Unregister(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.projects[name]
+	if !ok {
+		return fmt.Errorf("%s: %w", name, ErrNotMounted)
+	}
+	delete(c.projects, name)
+	c.persist()
+	if p.Error == "" {
+		if err := c.registry.Remove(name); err != nil {
+			slog.Warn("Closing project failed", "project", name, "error", err)
+		}
+	}
+	slog.Info("Unregistered project", "project", name)
+	return nil
+}
+
+// Projects returns the registered projects, sorted by name.
+func (c *Control) Projects() []Project {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	projects := make([]Project, 0, len(c.projects))
+	for _, p := range c.projects {
+		projects = append(projects, p)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	return projects
+}
+
+// persist saves the projects. A failure only warns: the daemon keeps
+// serving, but the change won't survive a restart.
+func (c *Control) persist() {
+	if c.opts.Persist == nil {
+		return
+	}
+	projects := make([]Project, 0, len(c.projects))
+	for _, p := range c.projects {
+		projects = append(projects, p)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	if err := c.opts.Persist(projects); err != nil {
+		slog.Warn("Saving the registered projects failed", "error", err)
+	}
+}
+
+func (c *Control) getProjects(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, c.Projects())
+}
+
+func (c *Control) postProject(w http.ResponseWriter, r *http.Request) {
+	var reg registration
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&reg); err != nil || reg.Name == "" || reg.Taskfile == "" {
+		http.Error(w, `invalid registration: want {"name": ..., "taskfile": ...}`, http.StatusBadRequest)
+		return
+	}
+	p, err := c.Register(r.Context(), reg.Name, reg.Taskfile)
+	if errors.Is(err, ErrRegistered) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	writeJSON(w, http.StatusCreated, p)
+}
+
+func (c *Control) deleteProject(w http.ResponseWriter, r *http.Request) {
+	if err := c.Unregister(r.PathValue("name")); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListenControl listens for the control API on a Unix socket at path that
+// only the user running the daemon may connect to.
+func ListenControl(path string) (net.Listener, error) {
+	return listenUnix(path, DefaultSocketMode)
+}
+
+// ServeControl serves the control API on listener until ctx is cancelled.
+// Closing a Unix listener removes its socket.
+func ServeControl(ctx context.Context, listener net.Listener, c *Control) error {
+	srv := &http.Server{Handler: c, ReadHeaderTimeout: 10 * time.Second}
+	slog.Info("Serving the daemon control API", "socket", listener.Addr().String())
+	return serveUntilDone(ctx, srv, func() error { return srv.Serve(listener) })
+}
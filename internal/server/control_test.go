@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestControl(t *testing.T) {
+	var persisted []Project
+	c := NewControl(NewRegistry(""), ControlOptions{
+		Load: func(_ context.Context, name string, taskfile string) (*Bridge, error) {
+			if taskfile == "broken.yml" {
+				return nil, errors.New("task: invalid Taskfile")
+			}
+			return &Bridge{mcp: server.NewMCPServer(name, "1.0.0")}, nil
+		},
+		Persist: func(projects []Project) error {
+			persisted = projects
+			return nil
+		},
+		BaseURL: "http://127.0.0.1:8080",
+	})
+	ts := httptest.NewServer(c)
+	defer ts.Close()
+
+	post := func(body string) int {
+		resp, err := http.Post(ts.URL+"/projects", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := post(`{"name": "api", "taskfile": "/src/api/Taskfile.yml"}`); status != http.StatusCreated {
+		t.Fatalf("POST /projects status = %d, want 201", status)
+	}
+	if status := post(`{"name": "api", "taskfile": "/src/other/Taskfile.yml"}`); status != http.StatusConflict {
+		t.Errorf("POST /projects with a taken name: status = %d, want 409", status)
+	}
+	if status := post(`{"name": "bad", "taskfile": "broken.yml"}`); status != http.StatusUnprocessableEntity {
+		t.Errorf("POST /projects with a broken Taskfile: status = %d, want 422", status)
+	}
+	if status := post(`{"name": "api"}`); status != http.StatusBadRequest {
+		t.Errorf("POST /projects without a Taskfile: status = %d, want 400", status)
+	}
+	if len(persisted) != 1 || persisted[0].Name != "api" || persisted[0].URL != "http://127.0.0.1:8080/api/mcp" {
+		t.Errorf("persisted = %+v, want the api project", persisted)
+	}
+
+	// Restored projects that fail to load are kept, with their error.
+	if p := c.Restore(context.Background(), "old", "broken.yml"); p.Error == "" {
+		t.Errorf("Restore() = %+v, want an error", p)
+	}
+	resp, err := http.Get(ts.URL + "/projects")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var projects []Project
+	err = json.NewDecoder(resp.Body).Decode(&projects)
+	resp.Body.Close()
+	if err != nil || len(projects) != 2 || projects[0].Name != "api" || projects[1].Error == "" {
+		t.Fatalf("GET /projects = %+v, %v, want api and the failed old project", projects, err)
+	}
+
+	for _, name := range []string{"api", "old"} {
+		req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/projects/"+name, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			t.Errorf("DELETE /projects/%s status = %d, want 204", name, resp.StatusCode)
+		}
+	}
+	if len(c.Projects()) != 0 || len(persisted) != 0 || len(c.registry.Mounts()) != 0 {
+		t.Errorf("after unregistering: projects = %+v, persisted = %+v", c.Projects(), persisted)
+	}
+	if err := c.Unregister("api"); !errors.Is(err, ErrNotMounted) {
+		t.Errorf("Unregister() error = %v, want ErrNotMounted", err)
+	}
+}
+
+func TestServeControl(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tmcp.sock")
+	listener, err := ListenControl(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- ServeControl(ctx, listener, NewControl(NewRegistry(""), ControlOptions{})) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://tmcp/projects")
+	if err != nil {
+		t.Fatalf("GET /projects over the socket: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /projects status = %d, want 200", resp.StatusCode)
+	}
+
+	if _, err := ListenControl(path); err == nil || !strings.Contains(err.Error(), "already listening") {
+		t.Errorf("second ListenControl() error = %v, want already listening", err)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("ServeControl() error = %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("socket still exists after the daemon stopped: %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// sampleTimeout is how long the client gets to write a description, which
+// it may show the user for approval first.
+const sampleTimeout = 2 * time.Minute
+
+// terseWords is the number of words below which a description says too
+// little for agents to tell when to use the tool.
+const terseWords = 4
+
+// describePrompt is the system prompt of description requests.
+const describePrompt = `You write the descriptions of MCP tools, which AI agents read to decide which tool to call. Reply with the description only: one to three sentences on what the tool does and when to use it, without repeating its name or inventing behaviour the task doesn't show.`
+
+// WithSampledDescriptions asks clients that support MCP sampling to have
+// their model write a description for each task without one, or with a
+// terse one, once they initialize. The tools are then listed with those
+// descriptions. Descriptions are cached as JSON files in cacheDir, unless
+// it is empty, so each is only written once until the task changes.
+func WithSampledDescriptions(cacheDir string) Option {
+	return func(s *settings) {
+		s.sampleDescriptions = true
+		s.descriptionCache = cacheDir
+	}
+}
+
+// sampledDescription is a cached description.
+type sampledDescription struct {
+	Task        string `json:"task"`
+	Description string `json:"description"`
+	// Model is the model that wrote the description, as the client
+	// reported it.
+	Model string `json:"model,omitempty"`
+}
+
+// terse reports whether the description of task is missing or too short.
+// The commands task --summary lists after it don't count.
+func terse(task inspector.TaskDefinition) bool {
+	if !task.Described() {
+		return true
+	}
+	prose, _ := splitCommands(task.Description)
+	return len(strings.Fields(prose)) < terseWords
+}
+
+// splitCommands splits a description from the list of commands task
+// --summary ends it with, if any.
+func splitCommands(description string) (prose string, commands string) {
+	if i := strings.Index("\n"+description, "\ncommands:"); i >= 0 {
+		return description[:i], description[i:]
+	}
+	return description, ""
+}
+
+// sampleDescriptions replaces the terse descriptions of the bridge's tools
+// with descriptions written by the client's model. Tools are replaced at
+// once, so the client is told to list them again once.
+func (b *Bridge) sampleDescriptions(ctx context.Context) {
+	peer := b.cfg.peer
+	if !peer.supports("sampling") {
+		return
+	}
+	// Lazily inspected tasks would otherwise be described without their
+	// parameters, and replaced without them.
+	b.resolveLazy()
+	var updated []server.ServerTool
+	for i, task := range b.config.Tasks {
+		target, ok := b.taskTools[b.tools[i].Name]
+		if !ok || !terse(task) {
+			continue
+		}
+		description, err := b.cfg.sampledDescription(ctx, peer, b.tools[i].Name, task)
+		if err != nil {
+			// The client or its user declined; asking again for each task
+			// would only nag.
+			slog.Warn("Could not have the client describe tasks, keeping their descriptions", "task", task.Name, "error", err)
+			break
+		}
+		if _, commands := splitCommands(task.Description); commands != "" {
+			description += "\n\n" + commands
+		}
+		task.Description = description
+		tool := b.tools[i]
+		tool.Description = toolDescription(task)
+		updated = append(updated, server.ServerTool{Tool: tool, Handler: target.handler})
+	}
+	if len(updated) > 0 {
+		b.mcp.AddTools(updated...)
+	}
+}
+
+// sampledDescription returns the cached description of task, or has the
+// client's model write one.
+func (s *settings) sampledDescription(ctx context.Context, peer *clientPeer, tool string, task inspector.TaskDefinition) (string, error) {
+	path := ""
+	if s.descriptionCache != "" {
+		path = filepath.Join(s.descriptionCache, descriptionKey(task)+".json")
+		var cached sampledDescription
+		if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &cached) == nil && cached.Description != "" {
+			return cached.Description, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sampleTimeout)
+	defer cancel()
+	params := mcp.CreateMessageParams{
+		Messages:       []mcp.SamplingMessage{{Role: mcp.RoleUser, Content: mcp.NewTextContent(describeRequest(tool, task))}},
+		SystemPrompt:   describePrompt,
+		IncludeContext: "none",
+		MaxTokens:      200,
+	}
+	var result struct {
+		Content struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Model string `json:"model"`
+	}
+	if err := peer.request(ctx, "sampling/createMessage", params, &result); err != nil {
+		return "", err
+	}
+	description := strings.Trim(strings.TrimSpace(result.Content.Text), `"`)
+	if result.Content.Type != "text" || description == "" {
+		return "", errors.New("the client's model wrote no description")
+	}
+
+	if path != "" {
+		data, err := json.Marshal(sampledDescription{Task: task.Name, Description: description, Model: result.Model})
+		if err == nil {
+			err = os.MkdirAll(s.descriptionCache, 0755)
+		}
+		if err == nil {
+			err = os.WriteFile(path, data, 0644)
+		}
+		if err != nil {
+			slog.Warn("Could not cache the description of a task", "task", task.Name, "error", err)
+		}
+	}
+	return description, nil
+}
+
+// describeRequest is what the client's model is told about task.
+func describeRequest(tool string, task inspector.TaskDefinition) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Describe the tool %q, which runs the task %q of a Taskfile.\n", tool, task.Name)
+	description := task.Description
+	// The summary lists the commands again.
+	if task.Summary != "" {
+		description, _ = splitCommands(description)
+	}
+	if task.Described() && strings.TrimSpace(description) != "" {
+		fmt.Fprintf(&b, "\nIts current description: %s\n", strings.TrimSpace(description))
+	}
+	if len(task.Parameters) > 0 {
+		names := make([]string, len(task.Parameters))
+		for i, param := range task.Parameters {
+			names[i] = param.Name
+		}
+		fmt.Fprintf(&b, "\nIts parameters: %s\n", strings.Join(names, ", "))
+	}
+	if task.Usage != "" {
+		fmt.Fprintf(&b, "\nIts usage: %s\n", task.Usage)
+	}
+	if summary := strings.TrimSpace(task.Summary); summary != "" {
+		fmt.Fprintf(&b, "\nWhat `task --summary` prints for it, including its commands:\n%s\n", summary)
+	}
+	return b.String()
+}
+
+// descriptionKey identifies a cached description by what the model was told
+// about the task, so it is written again when that changes.
+func descriptionKey(task inspector.TaskDefinition) string {
+	sum := sha256.Sum256([]byte(describeRequest("", task)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestSampledDescriptions(t *testing.T) {
+	dir := t.TempDir()
+	taskfile := filepath.Join(dir, "Taskfile.yml")
+	if err := os.WriteFile(taskfile, []byte("version: '3'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bin := fakeTaskBin(t, `case "$*" in
+*--list*) echo '{"tasks": [{"name": "greet", "desc": "Say hello"}, {"name": "build", "desc": "Build the binaries for every platform"}]}' ;;
+*--summary*greet*|*greet*--summary*) echo "task: greet"; echo "Say hello"; echo; echo "commands:"; echo " - echo hello" ;;
+*--summary*) echo "task: build"; echo "Build the binaries for every platform" ;;
+esac
+`)
+	cacheDir := filepath.Join(dir, "descriptions")
+	bridge, err := New(context.Background(), taskfile, bin, "tasks", WithSampledDescriptions(cacheDir), WithLogOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bridge.Close()
+
+	requests, answers := stdioClient(t, bridge.cfg, `{"sampling":{}}`)
+	if _, err := io.WriteString(answers, `{"jsonrpc":"2.0","method":"notifications/initialized"}`+"\n"); err != nil {
+		t.Fatal(err)
+	}
+	line, err := requests.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var asked struct {
+		ID     string                  `json:"id"`
+		Method string                  `json:"method"`
+		Params mcp.CreateMessageParams `json:"params"`
+	}
+	if err := json.Unmarshal([]byte(line), &asked); err != nil {
+		t.Fatal(err)
+	}
+	if asked.Method != "sampling/createMessage" || !strings.Contains(line, `"greet\"`) || !strings.Contains(line, "echo hello") {
+		t.Errorf("request = %s, want greet described with its commands", line)
+	}
+	if _, err := io.WriteString(answers, `{"jsonrpc":"2.0","id":"`+asked.ID+`","result":{"role":"assistant","content":{"type":"text","text":"\"Prints a greeting to check that tasks run.\""},"model":"test-model"}}`+"\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	descriptions := func() map[string]string {
+		response, _ := json.Marshal(bridge.MCPServer().HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)))
+		var msg struct {
+			Result mcp.ListToolsResult `json:"result"`
+		}
+		if err := json.Unmarshal(response, &msg); err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]string{}
+		for _, tool := range msg.Result.Tools {
+			got[tool.Name] = tool.Description
+		}
+		return got
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for descriptions()["greet"] != "Prints a greeting to check that tasks run.\n\ncommands:\n - echo hello" {
+		if time.Now().After(deadline) {
+			t.Fatalf("descriptions = %v, want greet's sampled", descriptions())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := descriptions()["build"]; got != "Build the binaries for every platform" {
+		t.Errorf("build description = %q, want it kept", got)
+	}
+
+	// The next server finds the description in the cache, without asking.
+	greet := bridge.Config().Tasks[0]
+	cached, err := bridge.cfg.sampledDescription(context.Background(), newClientPeer(), "greet", greet)
+	if err != nil || cached != "Prints a greeting to check that tasks run." {
+		t.Errorf("cached description = %q, %v", cached, err)
+	}
+}
+
+func TestTerse(t *testing.T) {
+	for description, want := range map[string]bool{
+		"":          true,
+		"Build it.": true,
+		"Build it.\n\ncommands:\n - go build ./...\n - go vet ./...": true,
+		"(task does not have description or summary)":                true,
+		"Build the binaries for every platform.":                     false,
+		"Deploy the site to the environment ENV.\n\nMore.":           false,
+	} {
+		if got := terse(inspector.TaskDefinition{Name: "build", Description: description}); got != want {
+			t.Errorf("terse(%q) = %v, want %v", description, got, want)
+		}
+	}
+}
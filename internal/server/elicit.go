@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// elicitTimeout is how long a tool call waits for the user to fill in the
+// missing parameters.
+const elicitTimeout = 10 * time.Minute
+
+// elicitResult is the client's answer to elicitation/create.
+type elicitResult struct {
+	// Action is accept, decline or cancel.
+	Action  string         `json:"action"`
+	Content map[string]any `json:"content"`
+}
+
+// missingParameters returns the required parameters of task that args
+// lacks. Parameters in fixed are supplied by the server.
+func missingParameters(task inspector.TaskDefinition, args map[string]any, fixed map[string]string) []inspector.TaskParameter {
+	var missing []inspector.TaskParameter
+	for _, param := range task.Parameters {
+		if _, ok := fixed[param.Name]; ok || param.Default != nil {
+			continue
+		}
+		if value, ok := args[param.Name]; !ok || value == nil {
+			missing = append(missing, param)
+		}
+	}
+	return missing
+}
+
+// elicitArguments asks the user, through MCP elicitation, for the required
+// parameters of task missing from the call, so under-specified calls run
+// instead of failing. It returns the arguments with the user's answers, or
+// a result ending the call when the user declines. Calls are left as they
+// are when nothing is missing, or the client can't be asked.
+func (s *settings) elicitArguments(ctx context.Context, task inspector.TaskDefinition, request mcp.CallToolRequest, fixed map[string]string) (map[string]any, *mcp.CallToolResult) {
+	args := request.GetArguments()
+	missing := missingParameters(task, args, fixed)
+	peer := s.peerFor(ctx)
+	if len(missing) == 0 || peer == nil || !peer.supports("elicitation") {
+		return args, nil
+	}
+
+	properties := make(map[string]any, len(missing))
+	names := make([]string, len(missing))
+	for i, param := range missing {
+		properties[param.Name] = elicitSchema(param)
+		names[i] = param.Name
+	}
+	params := map[string]any{
+		"message": fmt.Sprintf("%s needs %s to run.", request.Params.Name, strings.Join(names, ", ")),
+		"requestedSchema": map[string]any{
+			"type":       "object",
+			"properties": properties,
+			"required":   names,
+		},
+	}
+	ctx, cancel := context.WithTimeout(ctx, elicitTimeout)
+	defer cancel()
+	var answer elicitResult
+	if err := peer.request(ctx, "elicitation/create", params, &answer); err != nil {
+		s.logger(ctx).Warn("Could not ask for missing arguments", "tool", request.Params.Name, "error", err)
+		return args, nil
+	}
+	switch answer.Action {
+	case "accept":
+		merged := maps.Clone(args)
+		if merged == nil {
+			merged = make(map[string]any)
+		}
+		for _, param := range missing {
+			value, ok := answer.Content[param.Name]
+			if !ok {
+				continue
+			}
+			if list, isString := value.(string); isString && param.Type == "array" {
+				value = fieldList(list)
+			}
+			merged[param.Name] = value
+		}
+		return merged, nil
+	case "decline":
+		return nil, mcp.NewToolResultError(fmt.Sprintf("Cancelled: the user declined to give %s", strings.Join(names, ", ")))
+	default:
+		return nil, mcp.NewToolResultError(fmt.Sprintf("Cancelled: the user dismissed the request for %s", strings.Join(names, ", ")))
+	}
+}
+
+// fieldList splits a string of values given for a list parameter.
+func fieldList(s string) []any {
+	var values []any
+	for _, field := range strings.Fields(s) {
+		values = append(values, field)
+	}
+	return values
+}
+
+// elicitSchema is the schema of param in an elicitation request, which
+// only allows primitive types. Lists are asked for as a string of values.
+func elicitSchema(param inspector.TaskParameter) map[string]any {
+	schema := map[string]any{"type": "string", "title": param.Name}
+	switch param.Type {
+	case "number", "integer", "boolean":
+		schema["type"] = param.Type
+	}
+	description := param.Description
+	if param.Type == "array" {
+		description = strings.TrimSpace(description + " Separate values with spaces.")
+	}
+	if description != "" {
+		schema["description"] = description
+	}
+	return schema
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// stdioClient attaches cfg's peer to a client that declares capabilities,
+// and returns the requests the client receives and the writer of its
+// answers.
+func stdioClient(t *testing.T, cfg *settings, capabilities string) (*bufio.Reader, io.WriteCloser) {
+	t.Helper()
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	t.Cleanup(func() { clientOut.Close() })
+	in, _ := cfg.peer.attach(serverIn, serverOut)
+	if _, err := io.WriteString(clientOut, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":`+capabilities+`}}`+"\n"); err != nil {
+		t.Fatal(err)
+	}
+	// Wait for mcp-go to see initialize.
+	if line, err := bufio.NewReader(in).ReadString('\n'); err != nil || !strings.Contains(line, "initialize") {
+		t.Fatalf("mcp-go read %q, %v", line, err)
+	}
+	return bufio.NewReader(clientIn), clientOut
+}
+
+func TestElicitArguments(t *testing.T) {
+	task := inspector.TaskDefinition{Name: "deploy", Parameters: []inspector.TaskParameter{
+		{Name: "ENV", Description: "Target environment."},
+		{Name: "TAGS", Type: "array"},
+		{Name: "REGION", Default: "eu"},
+	}}
+	for _, tc := range []struct {
+		name   string
+		answer string
+		want   []string
+	}{
+		// Arguments are passed in no particular order.
+		{"accept", `{"action":"accept","content":{"ENV":"prod","TAGS":"a b"}}`, []string{" deploy ", "ENV=prod", "TAGS=a b"}},
+		{"decline", `{"action":"decline"}`, []string{"Cancelled: the user declined to give ENV, TAGS"}},
+		{"cancel", `{"action":"cancel"}`, []string{"Cancelled: the user dismissed the request for ENV, TAGS"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newSettings(nil)
+			cfg.taskBin = fakeTaskBin(t, `echo "$@"`)
+			requests, answers := stdioClient(t, cfg, `{"elicitation":{}}`)
+			handler := createTaskHandler("Taskfile.yml", t.TempDir(), task, cfg)
+
+			done := make(chan *mcp.CallToolResult, 1)
+			go func() {
+				request := mcp.CallToolRequest{}
+				request.Params.Name = "deploy"
+				result, _ := handler(sessionContext(cfg, stdioSessionID), request)
+				done <- result
+			}()
+
+			line, err := requests.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			var asked struct {
+				ID     string `json:"id"`
+				Method string `json:"method"`
+				Params struct {
+					Message         string         `json:"message"`
+					RequestedSchema map[string]any `json:"requestedSchema"`
+				} `json:"params"`
+			}
+			if err := json.Unmarshal([]byte(line), &asked); err != nil {
+				t.Fatal(err)
+			}
+			if asked.Method != "elicitation/create" || asked.Params.Message != "deploy needs ENV, TAGS to run." {
+				t.Errorf("request = %s", line)
+			}
+			if got := asked.Params.RequestedSchema["required"]; !reflect.DeepEqual(got, []any{"ENV", "TAGS"}) {
+				t.Errorf("required = %v, want ENV and TAGS", got)
+			}
+			if _, err := io.WriteString(answers, `{"jsonrpc":"2.0","id":"`+asked.ID+`","result":`+tc.answer+"}\n"); err != nil {
+				t.Fatal(err)
+			}
+
+			result := <-done
+			got := strings.TrimSpace(resultText(result))
+			for _, want := range tc.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("result = %q, want %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestElicitArgumentsUnsupported(t *testing.T) {
+	cfg := newSettings(nil)
+	cfg.taskBin = fakeTaskBin(t, `echo ran`)
+	stdioClient(t, cfg, `{}`)
+	task := inspector.TaskDefinition{Name: "deploy", Parameters: []inspector.TaskParameter{{Name: "ENV"}}}
+	// Calls of clients without elicitation run as before.
+	result, err := createTaskHandler("Taskfile.yml", t.TempDir(), task, cfg)(sessionContext(cfg, stdioSessionID), mcp.CallToolRequest{})
+	if err != nil || result.IsError || !strings.Contains(resultText(result), "ran") {
+		t.Errorf("result = %v, %v, want the task run", result, err)
+	}
+}
+
+func TestClientPeerDisconnect(t *testing.T) {
+	cfg := newSettings(nil)
+	requests, answers := stdioClient(t, cfg, `{"elicitation":{}}`)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- cfg.peer.request(t.Context(), "elicitation/create", map[string]any{}, &elicitResult{})
+	}()
+	if _, err := requests.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+	answers.Close()
+	if err := <-errs; err != errClientGone {
+		t.Errorf("request() = %v, want %v", err, errClientGone)
+	}
+}
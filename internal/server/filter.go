@@ -0,0 +1,123 @@
+package server
+
+import (
+	"log/slog"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// filterTasks keeps the tasks matching one of the include patterns (or all
+// tasks when include is empty) and none of the exclude patterns.
+func filterTasks(tasks []inspector.TaskDefinition, include []string, exclude []string) []inspector.TaskDefinition {
+	if len(include) == 0 && len(exclude) == 0 {
+		return tasks
+	}
+	var kept []inspector.TaskDefinition
+	for _, task := range tasks {
+		if matchesFilter(task.Name, include, exclude) {
+			kept = append(kept, task)
+		}
+	}
+	return kept
+}
+
+// matchesFilter reports whether name matches one of the include patterns
+// (or include is empty) and none of the exclude patterns.
+func matchesFilter(name string, include []string, exclude []string) bool {
+	if len(include) > 0 && !matchesAny(name, include) {
+		return false
+	}
+	return !matchesAny(name, exclude)
+}
+
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// WithProfileFilter is a second task filter, like WithTaskFilter, that
+// tasks must pass too. It lets a profile narrow the filters of servers and
+// workspace Taskfiles.
+func WithProfileFilter(include []string, exclude []string) Option {
+	return func(s *settings) {
+		s.profileInclude = include
+		s.profileExclude = exclude
+	}
+}
+
+// WithReadOnlyTasks exposes only the tasks marked read-only in the Taskfile.
+func WithReadOnlyTasks() Option {
+	return func(s *settings) {
+		s.readOnlyOnly = true
+	}
+}
+
+// readOnlyTasks returns the tasks marked read-only.
+func readOnlyTasks(tasks []inspector.TaskDefinition) []inspector.TaskDefinition {
+	var out []inspector.TaskDefinition
+	for _, task := range tasks {
+		if task.ReadOnly {
+			out = append(out, task)
+		}
+	}
+	return out
+}
+
+// WithConditions serves the tasks and pipelines whose names match a pattern
+// of when only if its condition holds at startup. Variables set with
+// WithEnv count as part of the environment.
+func WithConditions(when map[string]config.Condition) Option {
+	return func(s *settings) {
+		s.when = when
+	}
+}
+
+// unmetCondition returns why a condition of name doesn't hold, or "" when
+// all of them hold.
+func (s *settings) unmetCondition(name string) (pattern string, reason string) {
+	getenv := func(key string) string {
+		if value, ok := s.env[key]; ok {
+			return value
+		}
+		return os.Getenv(key)
+	}
+	patterns := make([]string, 0, len(s.when))
+	for pattern := range s.when {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); !ok {
+			continue
+		}
+		if reason := s.when[pattern].Unmet(getenv); reason != "" {
+			return pattern, reason
+		}
+	}
+	return "", ""
+}
+
+// exposedTasks splits tasks into those whose conditions hold and the names
+// of the others.
+func (s *settings) exposedTasks(tasks []inspector.TaskDefinition) (exposed []inspector.TaskDefinition, hidden []string) {
+	if len(s.when) == 0 {
+		return tasks, nil
+	}
+	for _, task := range tasks {
+		if pattern, reason := s.unmetCondition(task.Name); reason != "" {
+			slog.Info("Not serving task, its condition doesn't hold", "task", task.Name, "when", pattern, "reason", reason)
+			hidden = append(hidden, task.Name)
+			continue
+		}
+		exposed = append(exposed, task)
+	}
+	return exposed, hidden
+}
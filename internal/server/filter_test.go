@@ -0,0 +1,65 @@
+package server
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestFilterTasks(t *testing.T) {
+	tasks := []inspector.TaskDefinition{{Name: "build"}, {Name: "test"}, {Name: "db:migrate"}, {Name: "db:drop"}}
+	names := func(tasks []inspector.TaskDefinition) []string {
+		var out []string
+		for _, task := range tasks {
+			out = append(out, task.Name)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{name: "no filters", want: []string{"build", "test", "db:migrate", "db:drop"}},
+		{name: "include", include: []string{"db:*"}, want: []string{"db:migrate", "db:drop"}},
+		{name: "exclude", exclude: []string{"db:drop", "test"}, want: []string{"build", "db:migrate"}},
+		{name: "include and exclude", include: []string{"db:*"}, exclude: []string{"db:drop"}, want: []string{"db:migrate"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := names(filterTasks(tasks, tt.include, tt.exclude))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterTasks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExposedTasks(t *testing.T) {
+	t.Setenv("TMCP_TEST_ROLE", "laptop")
+	cfg := newSettings([]Option{
+		WithConditions(map[string]config.Condition{
+			"deploy:*": {Env: map[string]string{"TMCP_TEST_ROLE": "bastion"}},
+			"lint":     {Platforms: []string{runtime.GOOS}},
+			"ci:*":     {Env: map[string]string{"CI": "true"}},
+		}),
+		WithEnv(map[string]string{"CI": "true"}),
+	})
+	tasks := []inspector.TaskDefinition{{Name: "build"}, {Name: "deploy:api"}, {Name: "lint"}, {Name: "ci:report"}}
+	exposed, hidden := cfg.exposedTasks(tasks)
+	var names []string
+	for _, task := range exposed {
+		names = append(names, task.Name)
+	}
+	if want := []string{"build", "lint", "ci:report"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("exposed = %v, want %v", names, want)
+	}
+	if want := []string{"deploy:api"}; !reflect.DeepEqual(hidden, want) {
+		t.Errorf("hidden = %v, want %v", hidden, want)
+	}
+}
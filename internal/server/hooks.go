@@ -0,0 +1,156 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxHookBody caps the size of webhook request bodies.
+const maxHookBody = 1 << 20
+
+// hookResponse is the JSON body of a webhook response.
+type hookResponse struct {
+	Tool    string         `json:"tool"`
+	IsError bool           `json:"is_error"`
+	Output  string         `json:"output"`
+	Meta    map[string]any `json:"meta,omitempty"`
+}
+
+// hookAuth is the way a webhook request authenticated.
+type hookAuth int
+
+const (
+	hookUnauthorized hookAuth = iota
+	hookBearer
+	// hookSignature only vouches for the body, so arguments are only taken
+	// from it.
+	hookSignature
+)
+
+// hooksPath returns the webhook path prefix for a base path.
+func hooksPath(basePath string) string {
+	basePath = strings.Trim(basePath, "/")
+	if basePath == "" {
+		return "/hooks/"
+	}
+	return "/" + basePath + "/hooks/"
+}
+
+// newHookHandler triggers the bridge's task tools with POST
+// <prefix><tool>. Requests authenticate with the token either as
+// "Authorization: Bearer <token>" or, like GitHub webhooks, with an
+// X-Hub-Signature-256 HMAC of the body keyed with the token. Query
+// parameters and the "arguments" object of a JSON body become the tool
+// arguments, but signed requests may only pass them in the body; the call
+// goes through the same pipeline as an MCP tool call.
+func newHookHandler(token string, b *Bridge, prefix string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHookBody))
+		if err != nil {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		auth := hookAuthorized(r, body, token)
+		if auth == hookUnauthorized {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		name := strings.TrimPrefix(r.URL.Path, prefix)
+		tool, ok := b.taskTools[name]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown tool %q", name), http.StatusNotFound)
+			return
+		}
+		args, err := hookArguments(r, body, auth)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		slog.Info("Webhook triggered tool", "tool", name, "remote", r.RemoteAddr)
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = args
+		result, err := tool.handler(r.Context(), request)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		status := http.StatusOK
+		if result.IsError {
+			status = http.StatusInternalServerError
+		}
+		writeJSON(w, status, hookResponse{Tool: name, IsError: result.IsError, Output: resultText(result), Meta: result.Meta})
+	})
+}
+
+// hookAuthorized checks the bearer token or the body signature of r, and
+// reports which of them authenticated it.
+func hookAuthorized(r *http.Request, body []byte, token string) hookAuth {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+token)) != 1 {
+			return hookUnauthorized
+		}
+		return hookBearer
+	}
+	signature, ok := strings.CutPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256=")
+	if !ok {
+		return hookUnauthorized
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return hookUnauthorized
+	}
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return hookUnauthorized
+	}
+	return hookSignature
+}
+
+// hookArguments collects the tool arguments of a webhook request. Bodies
+// that aren't JSON objects, such as most webhook payloads, are ignored.
+// Signed requests can't have query parameters, since the signature doesn't
+// cover them and they could be added to a captured delivery.
+func hookArguments(r *http.Request, body []byte, auth hookAuth) (map[string]any, error) {
+	if auth == hookSignature && r.URL.RawQuery != "" {
+		return nil, fmt.Errorf("signed requests must pass their arguments in the body, not the query")
+	}
+	args := make(map[string]any)
+	for key, values := range r.URL.Query() {
+		args[key] = values[len(values)-1]
+	}
+	var payload struct {
+		Arguments map[string]any `json:"arguments"`
+	}
+	if len(bytes.TrimSpace(body)) == 0 || json.Unmarshal(body, &payload) != nil {
+		return args, nil
+	}
+	for key, value := range payload.Arguments {
+		switch value.(type) {
+		case string, bool, float64:
+		default:
+			return nil, fmt.Errorf("argument %q must be a string, number or boolean", key)
+		}
+		args[key] = value
+	}
+	return args, nil
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestHookHandler(t *testing.T) {
+	var got map[string]any
+	b := &Bridge{
+		mcp: server.NewMCPServer("tasks", "1.0.0"),
+		taskTools: map[string]taskTool{
+			"deploy": {
+				task: inspector.TaskDefinition{Name: "deploy"},
+				handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+					got = request.GetArguments()
+					if got["ENV"] == "broken" {
+						return mcp.NewToolResultError("deploy failed"), nil
+					}
+					return mcp.NewToolResultText("deployed"), nil
+				},
+			},
+		},
+	}
+	handler, err := newHTTPHandler([]Mount{{Bridge: b}}, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	post := func(path string, body string, header http.Header) (*http.Response, hookResponse) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+path, strings.NewReader(body))
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var decoded hookResponse
+		_ = json.NewDecoder(resp.Body).Decode(&decoded)
+		return resp, decoded
+	}
+	bearer := http.Header{"Authorization": {"Bearer s3cret"}}
+
+	resp, result := post("/hooks/deploy?ENV=prod", `{"arguments": {"VERSION": "1.2"}}`, bearer)
+	if resp.StatusCode != http.StatusOK || result.Output != "deployed" || got["ENV"] != "prod" || got["VERSION"] != "1.2" {
+		t.Errorf("bearer hook = %d %+v, arguments %v", resp.StatusCode, result, got)
+	}
+
+	// GitHub-style signature over a payload without arguments.
+	payload := `{"ref": "refs/heads/main"}`
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(payload))
+	signed := http.Header{"X-Hub-Signature-256": {"sha256=" + hex.EncodeToString(mac.Sum(nil))}}
+	if resp, _ := post("/hooks/deploy", payload, signed); resp.StatusCode != http.StatusOK || len(got) != 0 {
+		t.Errorf("signed hook = %d, arguments %v", resp.StatusCode, got)
+	}
+
+	// The signature doesn't cover the query, so a signed delivery can't be
+	// replayed with arguments added to it.
+	got = nil
+	if resp, _ := post("/hooks/deploy?ENV=prod", payload, signed); resp.StatusCode != http.StatusBadRequest || got != nil {
+		t.Errorf("signed hook with a query = %d, arguments %v, want 400 without a call", resp.StatusCode, got)
+	}
+	withArguments := `{"arguments": {"ENV": "staging"}}`
+	mac = hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(withArguments))
+	signedArguments := http.Header{"X-Hub-Signature-256": {"sha256=" + hex.EncodeToString(mac.Sum(nil))}}
+	if resp, _ := post("/hooks/deploy", withArguments, signedArguments); resp.StatusCode != http.StatusOK || got["ENV"] != "staging" {
+		t.Errorf("signed hook with body arguments = %d, arguments %v", resp.StatusCode, got)
+	}
+
+	if resp, result := post("/hooks/deploy?ENV=broken", "", bearer); resp.StatusCode != http.StatusInternalServerError || !result.IsError {
+		t.Errorf("failing hook = %d %+v", resp.StatusCode, result)
+	}
+	for _, tt := range []struct {
+		path   string
+		header http.Header
+		want   int
+	}{
+		{"/hooks/deploy", nil, http.StatusUnauthorized},
+		{"/hooks/deploy", http.Header{"Authorization": {"Bearer wrong"}}, http.StatusUnauthorized},
+		{"/hooks/deploy", http.Header{"X-Hub-Signature-256": {"sha256=00"}}, http.StatusUnauthorized},
+		{"/hooks/missing", bearer, http.StatusNotFound},
+	} {
+		if resp, _ := post(tt.path, payload, tt.header); resp.StatusCode != tt.want {
+			t.Errorf("POST %s with %v = %d, want %d", tt.path, tt.header, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestNewHTTPHandlerWithoutHookToken(t *testing.T) {
+	b := &Bridge{mcp: server.NewMCPServer("tasks", "1.0.0"), taskTools: map[string]taskTool{"deploy": {}}}
+	handler, err := newHTTPHandler([]Mount{{Bridge: b}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/hooks/deploy", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("POST /hooks/deploy without a hook token = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Mount places a bridge under a base path of the HTTP transport. The bridge
+// answers MCP requests at <BasePath>/mcp.
+type Mount struct {
+	BasePath string
+	Bridge   *Bridge
+}
+
+// HTTPOptions configures the HTTP transport.
+type HTTPOptions struct {
+	// Addr is a host:port, or a Unix socket path prefixed with unix://.
+	Addr string
+	// SocketMode is the permissions of a Unix socket, which decide who may
+	// connect. Zero means DefaultSocketMode.
+	SocketMode os.FileMode
+	// HandshakeTimeout bounds how long a client may take to send request
+	// headers. Zero disables it.
+	HandshakeTimeout time.Duration
+	// ReadTimeout bounds how long a client may take to send a full message.
+	// Zero disables it.
+	ReadTimeout time.Duration
+	// HookToken enables the webhook endpoints at <BasePath>/hooks/<tool>
+	// and authenticates their requests. Empty disables them.
+	HookToken string
+}
+
+// endpointPath returns the MCP endpoint for a base path.
+func endpointPath(basePath string) string {
+	basePath = strings.Trim(basePath, "/")
+	if basePath == "" {
+		return "/mcp"
+	}
+	return "/" + basePath + "/mcp"
+}
+
+// newHTTPHandler multiplexes the mounted bridges onto one handler, with
+// webhook endpoints when hookToken is set.
+func newHTTPHandler(mounts []Mount, hookToken string) (http.Handler, error) {
+	mux := http.NewServeMux()
+	seen := make(map[string]bool, len(mounts))
+	for _, m := range mounts {
+		endpoint := endpointPath(m.BasePath)
+		if seen[endpoint] {
+			return nil, fmt.Errorf("two servers mounted at %s", endpoint)
+		}
+		seen[endpoint] = true
+		mux.Handle(endpoint, endSessions(server.NewStreamableHTTPServer(m.Bridge.mcp, server.WithEndpointPath(endpoint)), m.Bridge))
+		if hookToken != "" {
+			prefix := hooksPath(m.BasePath)
+			mux.Handle(prefix, newHookHandler(hookToken, m.Bridge, prefix))
+		}
+	}
+	return mux, nil
+}
+
+// ServeHTTP serves the mounted bridges over streamable HTTP until ctx is
+// cancelled.
+func ServeHTTP(ctx context.Context, opts HTTPOptions, mounts []Mount) error {
+	handler, err := newHTTPHandler(mounts, opts.HookToken)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              opts.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: opts.HandshakeTimeout,
+		ReadTimeout:       opts.ReadTimeout,
+	}
+	listener, err := listen(opts.Addr, opts.SocketMode)
+	if err != nil {
+		return err
+	}
+	for _, m := range mounts {
+		slog.Info("Serving MCP over HTTP", "server", m.BasePath, "url", ListenURL(opts.Addr)+endpointPath(m.BasePath))
+		if opts.HookToken != "" {
+			slog.Info("Serving webhooks", "server", m.BasePath, "url", ListenURL(opts.Addr)+hooksPath(m.BasePath)+"<tool>")
+		}
+	}
+
+	return serveUntilDone(ctx, srv, func() error { return srv.Serve(listener) })
+}
+
+// listenAndServe runs srv until ctx is cancelled, then shuts it down.
+func listenAndServe(ctx context.Context, srv *http.Server) error {
+	return serveUntilDone(ctx, srv, srv.ListenAndServe)
+}
+
+// serveUntilDone runs serve until ctx is cancelled, then shuts srv down.
+func serveUntilDone(ctx context.Context, srv *http.Server, serve func() error) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- serve()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestNewHTTPHandler(t *testing.T) {
+	newBridge := func(name string) *Bridge {
+		return &Bridge{mcp: server.NewMCPServer(name, "1.0.0", server.WithToolCapabilities(true))}
+	}
+
+	handler, err := newHTTPHandler([]Mount{
+		{BasePath: "api", Bridge: newBridge("api")},
+		{BasePath: "web", Bridge: newBridge("web")},
+	}, "")
+	if err != nil {
+		t.Fatalf("newHTTPHandler() error = %v", err)
+	}
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","clientInfo":{"name":"test","version":"1.0.0"},"capabilities":{}}}`
+	for _, name := range []string{"api", "web"} {
+		resp, err := http.Post(ts.URL+"/"+name+"/mcp", "application/json", strings.NewReader(initialize))
+		if err != nil {
+			t.Fatalf("POST /%s/mcp error = %v", name, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST /%s/mcp status = %d, want 200", name, resp.StatusCode)
+		}
+		if !strings.Contains(string(body), `"name":"`+name+`"`) {
+			t.Errorf("POST /%s/mcp answered by the wrong server: %s", name, body)
+		}
+	}
+
+	resp, err := http.Post(ts.URL+"/other/mcp", "application/json", strings.NewReader(initialize))
+	if err != nil {
+		t.Fatalf("POST /other/mcp error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("POST /other/mcp status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestNewHTTPHandlerDuplicateMount(t *testing.T) {
+	b := &Bridge{mcp: server.NewMCPServer("tasks", "1.0.0")}
+	_, err := newHTTPHandler([]Mount{{BasePath: "a", Bridge: b}, {BasePath: "/a/", Bridge: b}}, "")
+	if err == nil {
+		t.Fatal("newHTTPHandler() error = nil, want error for duplicate mount")
+	}
+}
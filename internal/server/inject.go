@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+)
+
+// injectedVars resolves the configured inject sources for a tool call.
+// Sources without a value (e.g. a client that sent no _meta) are omitted.
+func injectedVars(ctx context.Context, request mcp.CallToolRequest, inject map[string]string) map[string]string {
+	vars := make(map[string]string, len(inject))
+	for name, source := range inject {
+		if value, ok := resolveInjectSource(ctx, request, source); ok {
+			vars[name] = value
+		}
+	}
+	return vars
+}
+
+func resolveInjectSource(ctx context.Context, request mcp.CallToolRequest, source string) (string, bool) {
+	kind, field, err := config.ParseInjectSource(source)
+	if err != nil {
+		return "", false
+	}
+
+	switch kind {
+	case config.SourceClient:
+		var info mcp.Implementation
+		if owner, ok := scheduleOwnerFrom(ctx); ok {
+			// Scheduled runs act for the client that scheduled them.
+			info = owner.Client
+		} else if session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo); ok {
+			info = session.GetClientInfo()
+		} else {
+			return "", false
+		}
+		if field == "name" {
+			return info.Name, info.Name != ""
+		}
+		return info.Version, info.Version != ""
+	case config.SourceSession:
+		if owner, ok := scheduleOwnerFrom(ctx); ok {
+			return owner.Session, owner.Session != ""
+		}
+		session := server.ClientSessionFromContext(ctx)
+		if session == nil {
+			return "", false
+		}
+		return session.SessionID(), true
+	case config.SourceMeta:
+		if request.Params.Meta == nil {
+			return "", false
+		}
+		value, ok := request.Params.Meta.AdditionalFields[field]
+		if !ok || value == nil {
+			return "", false
+		}
+		return fmt.Sprint(value), true
+	case config.SourceEnv:
+		return os.LookupEnv(field)
+	}
+	return "", false
+}
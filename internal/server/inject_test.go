@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestInjectedVars(t *testing.T) {
+	t.Setenv("TMCP_TEST_OPERATOR", "ops")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Meta = &mcp.Meta{AdditionalFields: map[string]any{"user": "alice"}}
+
+	inject := map[string]string{
+		"REQUESTED_BY": "meta.user",
+		"OPERATOR":     "env.TMCP_TEST_OPERATOR",
+		"TEAM":         "meta.team",
+		"CLIENT":       "client.name",
+	}
+	got := injectedVars(context.Background(), request, inject)
+	want := map[string]string{
+		"REQUESTED_BY": "alice",
+		"OPERATOR":     "ops",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("injectedVars() = %v, want %v", got, want)
+	}
+}
+
+func TestGlobalVarsAndEnv(t *testing.T) {
+	cfg := newSettings([]Option{
+		WithVars(map[string]string{"STAGE": "dev", "REGION": "eu"}),
+		WithEnv(map[string]string{"FROM_DOTENV": "a", "SHARED": "dotenv"}),
+		WithEnv(map[string]string{"SHARED": "server"}),
+		WithStrictArguments(),
+	})
+	cfg.taskBin = fakeTaskBin(t, "echo \"$* $FROM_DOTENV $SHARED\"\n")
+	task := inspector.TaskDefinition{Name: "deploy", Parameters: []inspector.TaskParameter{{Name: "STAGE"}, {Name: "VERSION"}}}
+	dir := t.TempDir()
+	handler := createTaskHandler("Taskfile.yml", dir, task, cfg)
+
+	// The model can't set STAGE, and doesn't need to.
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"VERSION": "1.2.0"}
+	result, err := handler(context.Background(), request)
+	if err != nil || result.IsError {
+		t.Fatalf("handler = %+v, %v", result, err)
+	}
+	want := "--taskfile Taskfile.yml --dir " + dir + " deploy VERSION=1.2.0 REGION=eu STAGE=dev a server\n"
+	if got := resultText(result); got != want {
+		t.Errorf("task ran with %q, want %q", got, want)
+	}
+	request.Params.Arguments = map[string]any{"VERSION": "1.2.0", "STAGE": "prod"}
+	if result, _ := handler(context.Background(), request); !result.IsError {
+		t.Errorf("handler with STAGE set by the model = %q, want an error", resultText(result))
+	}
+
+	tool := TranslateTtmcpTools(&inspector.MCPConfig{Tasks: []inspector.TaskDefinition{task}}, []ToolName{{Tool: "deploy"}}, cfg.fixedVars())[0]
+	if _, ok := tool.InputSchema.Properties["STAGE"]; ok || len(tool.InputSchema.Required) != 1 {
+		t.Errorf("schema = %+v, want only VERSION", tool.InputSchema)
+	}
+}
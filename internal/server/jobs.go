@@ -0,0 +1,350 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// jobMetaKey and jobsMetaKey are the _meta keys under which the job tools
+// describe one job or all of them; logsMetaKey carries the log position.
+const (
+	jobMetaKey  = "tmcp/job"
+	jobsMetaKey = "tmcp/jobs"
+	logsMetaKey = "tmcp/logs"
+)
+
+// maxFinishedJobs is how many finished jobs are kept for jobs_status and
+// jobs_logs. Older ones are forgotten when new jobs start.
+const maxFinishedJobs = 100
+
+// maxJobLogBytes is how much output of a job is kept for jobs_logs. Older
+// output is dropped as new output comes in.
+const maxJobLogBytes = 1 << 20
+
+// jobState is the state of a background job.
+type jobState string
+
+const (
+	jobRunning   jobState = "running"
+	jobSucceeded jobState = "succeeded"
+	jobFailed    jobState = "failed"
+	jobCancelled jobState = "cancelled"
+)
+
+// jobInfo describes a job to clients.
+type jobInfo struct {
+	ID         string     `json:"id"`
+	Task       string     `json:"task"`
+	State      jobState   `json:"state"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// job is a run of an async task in the background.
+type job struct {
+	id   string
+	task string
+	// session is the ID of the client session that started the job. Only
+	// that session can see it.
+	session string
+	started time.Time
+	cancel  context.CancelFunc
+	// redact masks secrets in the logs, or is nil.
+	redact func(string) string
+
+	mu sync.Mutex
+	// logs holds the last maxJobLogBytes of output; written counts all of
+	// it, so offsets stay valid once older output is dropped.
+	logs      []byte
+	written   int
+	state     jobState
+	finished  time.Time
+	cancelled bool
+	result    *mcp.CallToolResult
+}
+
+// Write appends task output to the job's logs.
+func (j *job) Write(p []byte) (int, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.written += len(p)
+	j.logs = append(j.logs, p...)
+	if over := len(j.logs) - maxJobLogBytes; over > 0 {
+		// Copy so the dropped output doesn't stay in the backing array.
+		j.logs = append(make([]byte, 0, maxJobLogBytes), j.logs[over:]...)
+	}
+	return len(p), nil
+}
+
+// logsFrom returns the output from offset on, the offset it actually starts
+// at, which is later when older output was dropped, and the offset of the
+// end.
+func (j *job) logsFrom(offset int) (string, int, int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	first := j.written - len(j.logs)
+	offset = min(max(offset, first), j.written)
+	return string(j.logs[offset-first:]), offset, j.written
+}
+
+func (j *job) info() jobInfo {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	info := jobInfo{ID: j.id, Task: j.task, State: j.state, StartedAt: j.started}
+	if j.state != jobRunning {
+		finished := j.finished
+		info.FinishedAt = &finished
+	}
+	return info
+}
+
+// finish records the result of the job's run.
+func (j *job) finish(result *mcp.CallToolResult) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.finished = time.Now()
+	j.result = result
+	switch {
+	case j.cancelled:
+		j.state = jobCancelled
+	case result.IsError:
+		j.state = jobFailed
+	default:
+		j.state = jobSucceeded
+	}
+}
+
+// jobs tracks the background jobs of a bridge.
+type jobs struct {
+	mu    sync.Mutex
+	next  int
+	byID  map[string]*job
+	order []*job
+}
+
+func newJobs() *jobs {
+	return &jobs{byID: make(map[string]*job)}
+}
+
+// start runs r in the background and returns the tool result announcing
+// the job.
+func (js *jobs) start(task string, r *taskExec) *mcp.CallToolResult {
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{task: task, session: r.session, started: time.Now(), cancel: cancel, state: jobRunning}
+	if r.cfg.redact {
+		j.redact = func(s string) string { return redact(s, r.secretEnv) }
+	}
+
+	js.mu.Lock()
+	js.next++
+	j.id = fmt.Sprintf("job-%d", js.next)
+	js.byID[j.id] = j
+	js.order = append(js.order, j)
+	js.prune()
+	js.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		j.finish(r.execute(ctx, ctx, j))
+	}()
+
+	result := mcp.NewToolResultText(fmt.Sprintf("Started %s as background job %s. Follow it with jobs_status or jobs_logs, or stop it with jobs_cancel.", task, j.id))
+	result.Meta = map[string]any{jobMetaKey: j.info()}
+	return result
+}
+
+// prune forgets the oldest finished jobs beyond maxFinishedJobs. js.mu must
+// be held.
+func (js *jobs) prune() {
+	finished := 0
+	for _, j := range js.order {
+		if j.info().State != jobRunning {
+			finished++
+		}
+	}
+	kept := js.order[:0]
+	for _, j := range js.order {
+		if finished > maxFinishedJobs && j.info().State != jobRunning {
+			delete(js.byID, j.id)
+			finished--
+			continue
+		}
+		kept = append(kept, j)
+	}
+	js.order = kept
+}
+
+// get returns the job with the given ID if session started it.
+func (js *jobs) get(session string, id string) (*job, bool) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	j, ok := js.byID[id]
+	if !ok || j.session != session {
+		return nil, false
+	}
+	return j, true
+}
+
+// list describes the jobs session started.
+func (js *jobs) list(session string) []jobInfo {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	infos := []jobInfo{}
+	for _, j := range js.order {
+		if j.session == session {
+			infos = append(infos, j.info())
+		}
+	}
+	return infos
+}
+
+// callerSession returns the ID of the client session of ctx, or "" outside
+// of one.
+func callerSession(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+// jobTools returns the tools that inspect and control background jobs.
+func (js *jobs) jobTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.NewTool("jobs_status",
+				mcp.WithDescription("Show the state of a background job and, once it has finished, its result. Without job_id, list all jobs."),
+				mcp.WithString("job_id", mcp.Description("ID of the job, as returned when it was started.")),
+				mcp.WithReadOnlyHintAnnotation(true),
+			),
+			Handler: js.handleStatus,
+		},
+		{
+			Tool: mcp.NewTool("jobs_logs",
+				mcp.WithDescription("Return the output of a background job so far. Pass the returned next offset to only get new output."),
+				mcp.WithString("job_id", mcp.Required(), mcp.Description("ID of the job, as returned when it was started.")),
+				mcp.WithNumber("offset", mcp.Min(0), mcp.Description("Byte offset to continue from, 0 for the start.")),
+				mcp.WithReadOnlyHintAnnotation(true),
+			),
+			Handler: js.handleLogs,
+		},
+		{
+			Tool: mcp.NewTool("jobs_cancel",
+				mcp.WithDescription("Stop a running background job."),
+				mcp.WithString("job_id", mcp.Required(), mcp.Description("ID of the job, as returned when it was started.")),
+			),
+			Handler: js.handleCancel,
+		},
+	}
+}
+
+// lookup returns the job named by the job_id argument, or an error result.
+// Jobs of other client sessions are reported as unknown.
+func (js *jobs) lookup(ctx context.Context, request mcp.CallToolRequest) (*job, *mcp.CallToolResult) {
+	id, err := request.RequireString("job_id")
+	if err != nil {
+		return nil, mcp.NewToolResultError(fmt.Sprintf("Invalid arguments: %v", err))
+	}
+	j, ok := js.get(callerSession(ctx), id)
+	if !ok {
+		return nil, mcp.NewToolResultError(fmt.Sprintf("Unknown job %q", id))
+	}
+	return j, nil
+}
+
+func (js *jobs) handleStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if request.GetString("job_id", "") == "" {
+		infos := js.list(callerSession(ctx))
+		if len(infos) == 0 {
+			return mcp.NewToolResultText("No jobs."), nil
+		}
+		lines := make([]string, len(infos))
+		for i, info := range infos {
+			lines[i] = describeJob(info)
+		}
+		result := mcp.NewToolResultText(strings.Join(lines, "\n"))
+		result.Meta = map[string]any{jobsMetaKey: infos}
+		return result, nil
+	}
+
+	j, errResult := js.lookup(ctx, request)
+	if errResult != nil {
+		return errResult, nil
+	}
+	info := j.info()
+	text := describeJob(info)
+	meta := map[string]any{}
+	if info.State != jobRunning {
+		j.mu.Lock()
+		final := j.result
+		j.mu.Unlock()
+		text += "\n\n" + resultText(final)
+		for key, value := range final.Meta {
+			meta[key] = value
+		}
+	}
+	meta[jobMetaKey] = info
+	result := mcp.NewToolResultText(text)
+	result.Meta = meta
+	return result, nil
+}
+
+func (js *jobs) handleLogs(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	j, errResult := js.lookup(ctx, request)
+	if errResult != nil {
+		return errResult, nil
+	}
+	offset := request.GetInt("offset", 0)
+	info := j.info()
+	chunk, from, size := j.logsFrom(offset)
+	if j.redact != nil {
+		chunk = j.redact(chunk)
+	}
+
+	text := chunk
+	if from > offset {
+		text = fmt.Sprintf("[%d bytes of earlier output were dropped]\n", from-offset) + text
+	}
+	if info.State == jobRunning {
+		text += fmt.Sprintf("\n[%s is still running; call jobs_logs with offset %d for more output]", j.id, size)
+	} else {
+		text += fmt.Sprintf("\n[%s %s]", j.id, info.State)
+	}
+	result := mcp.NewToolResultText(text)
+	result.Meta = map[string]any{
+		jobMetaKey:  info,
+		logsMetaKey: map[string]any{"next_offset": size, "done": info.State != jobRunning},
+	}
+	return result, nil
+}
+
+func (js *jobs) handleCancel(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	j, errResult := js.lookup(ctx, request)
+	if errResult != nil {
+		return errResult, nil
+	}
+	j.mu.Lock()
+	running := j.state == jobRunning
+	if running {
+		j.cancelled = true
+	}
+	j.mu.Unlock()
+	if !running {
+		return mcp.NewToolResultError(fmt.Sprintf("Job %s already %s", j.id, j.info().State)), nil
+	}
+	j.cancel()
+	return mcp.NewToolResultText(fmt.Sprintf("Cancelling job %s (%s).", j.id, j.task)), nil
+}
+
+// describeJob is a one-line summary of a job.
+func describeJob(info jobInfo) string {
+	if info.FinishedAt == nil {
+		return fmt.Sprintf("%s (%s): running for %s", info.ID, info.Task, time.Since(info.StartedAt).Round(time.Second))
+	}
+	return fmt.Sprintf("%s (%s): %s after %s", info.ID, info.Task, info.State, info.FinishedAt.Sub(info.StartedAt).Round(time.Millisecond))
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// callJobTool calls the job tool name with args.
+func callJobTool(t *testing.T, js *jobs, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	for _, tool := range js.jobTools() {
+		if tool.Tool.Name != name {
+			continue
+		}
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = args
+		result, err := tool.Handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("%s error = %v", name, err)
+		}
+		return result
+	}
+	t.Fatalf("no job tool %s", name)
+	return nil
+}
+
+// waitForJob polls jobs_status until the job has finished.
+func waitForJob(t *testing.T, js *jobs, id string) *mcp.CallToolResult {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		result := callJobTool(t, js, "jobs_status", map[string]any{"job_id": id})
+		if result.Meta[jobMetaKey].(jobInfo).State != jobRunning {
+			return result
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return nil
+}
+
+func TestAsyncTaskJob(t *testing.T) {
+	cfg := newSettings(nil)
+	cfg.taskBin = fakeTaskBin(t, "echo building; echo warning >&2; exit 2\n")
+	handler := createTaskHandler("Taskfile.yml", t.TempDir(), inspector.TaskDefinition{Name: "build", Async: true}, cfg)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil || result.IsError {
+		t.Fatalf("handler = %+v, %v", result, err)
+	}
+	id := result.Meta[jobMetaKey].(jobInfo).ID
+	if id != "job-1" || !strings.Contains(resultText(result), "job-1") {
+		t.Errorf("start result = %q, id %q", resultText(result), id)
+	}
+
+	status := waitForJob(t, cfg.jobs, id)
+	if text := resultText(status); !strings.HasPrefix(text, "job-1 (build): failed") || !strings.Contains(text, "exit code 2") {
+		t.Errorf("status = %q", text)
+	}
+	if _, ok := status.Meta[executionMetaKey]; !ok {
+		t.Errorf("status meta = %v, want the execution details", status.Meta)
+	}
+
+	logs := callJobTool(t, cfg.jobs, "jobs_logs", map[string]any{"job_id": id})
+	if text := resultText(logs); !strings.Contains(text, "building\n") || !strings.Contains(text, "warning\n") {
+		t.Errorf("logs = %q", text)
+	}
+	next := logs.Meta[logsMetaKey].(map[string]any)["next_offset"].(int)
+	logs = callJobTool(t, cfg.jobs, "jobs_logs", map[string]any{"job_id": id, "offset": float64(next)})
+	if text := resultText(logs); text != "\n[job-1 failed]" {
+		t.Errorf("logs from offset %d = %q", next, text)
+	}
+
+	if list := callJobTool(t, cfg.jobs, "jobs_status", nil); len(list.Meta[jobsMetaKey].([]jobInfo)) != 1 {
+		t.Errorf("job list = %q", resultText(list))
+	}
+	if result := callJobTool(t, cfg.jobs, "jobs_status", map[string]any{"job_id": "job-9"}); !result.IsError {
+		t.Errorf("status of an unknown job = %q, want error", resultText(result))
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	cfg := newSettings(nil)
+	cfg.taskBin = fakeTaskBin(t, "echo started; sleep 30\n")
+	handler := createTaskHandler("Taskfile.yml", t.TempDir(), inspector.TaskDefinition{Name: "deploy", Async: true}, cfg)
+
+	result, _ := handler(context.Background(), mcp.CallToolRequest{})
+	id := result.Meta[jobMetaKey].(jobInfo).ID
+	if result := callJobTool(t, cfg.jobs, "jobs_cancel", map[string]any{"job_id": id}); result.IsError {
+		t.Fatalf("jobs_cancel = %q", resultText(result))
+	}
+	if state := waitForJob(t, cfg.jobs, id).Meta[jobMetaKey].(jobInfo).State; state != jobCancelled {
+		t.Errorf("state = %s, want cancelled", state)
+	}
+	if result := callJobTool(t, cfg.jobs, "jobs_cancel", map[string]any{"job_id": id}); !result.IsError {
+		t.Errorf("cancelling a finished job = %q, want error", resultText(result))
+	}
+}
+
+func TestAsyncDryRunIsSynchronous(t *testing.T) {
+	cfg := newSettings([]Option{WithDryRunUnless(nil)})
+	cfg.taskBin = fakeTaskBin(t, "echo 'task: [deploy] ./deploy.sh' >&2\n")
+	handler := createTaskHandler("Taskfile.yml", t.TempDir(), inspector.TaskDefinition{Name: "deploy", Async: true}, cfg)
+
+	result, _ := handler(context.Background(), mcp.CallToolRequest{})
+	if _, ok := result.Meta[jobMetaKey]; ok || !strings.HasPrefix(resultText(result), "Dry run") {
+		t.Errorf("dry run result = %q %v, want it inline", resultText(result), result.Meta)
+	}
+}
+
+func TestJobsOfOtherSessions(t *testing.T) {
+	cfg := newSettings(nil)
+	cfg.taskBin = fakeTaskBin(t, "echo built\n")
+	handler := createTaskHandler("Taskfile.yml", t.TempDir(), inspector.TaskDefinition{Name: "build", Async: true}, cfg)
+
+	result, _ := handler(sessionContext(cfg, "s1"), mcp.CallToolRequest{})
+	id := result.Meta[jobMetaKey].(jobInfo).ID
+	other := sessionContext(cfg, "s2")
+	for _, name := range []string{"jobs_status", "jobs_logs", "jobs_cancel"} {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"job_id": id}
+		var handle func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
+		for _, tool := range cfg.jobs.jobTools() {
+			if tool.Tool.Name == name {
+				handle = tool.Handler
+			}
+		}
+		if result, _ := handle(other, request); !result.IsError || !strings.Contains(resultText(result), "Unknown job") {
+			t.Errorf("%s from another session = %q, want an unknown job", name, resultText(result))
+		}
+	}
+	if infos := cfg.jobs.list("s2"); len(infos) != 0 {
+		t.Errorf("jobs of s2 = %v, want none", infos)
+	}
+	if infos := cfg.jobs.list("s1"); len(infos) != 1 {
+		t.Errorf("jobs of s1 = %v, want the build", infos)
+	}
+}
+
+func TestJobLogsAreCapped(t *testing.T) {
+	j := &job{id: "job-1", state: jobRunning}
+	line := strings.Repeat("x", 1023) + "\n"
+	for range 2 * maxJobLogBytes / len(line) {
+		_, _ = j.Write([]byte(line))
+	}
+	if len(j.logs) > maxJobLogBytes {
+		t.Errorf("logs hold %d bytes, want at most %d", len(j.logs), maxJobLogBytes)
+	}
+	chunk, from, end := j.logsFrom(0)
+	if from != maxJobLogBytes || end != 2*maxJobLogBytes || len(chunk) != maxJobLogBytes {
+		t.Errorf("logsFrom(0) = %d bytes from %d to %d", len(chunk), from, end)
+	}
+	if chunk, from, _ := j.logsFrom(end - 5); chunk != "xxxx\n" || from != end-5 {
+		t.Errorf("logsFrom(%d) = %q from %d", end-5, chunk, from)
+	}
+
+	js := newJobs()
+	js.byID[j.id], js.order = j, []*job{j}
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"job_id": j.id}
+	result, _ := js.handleLogs(context.Background(), request)
+	if text := resultText(result); !strings.HasPrefix(text, "[1048576 bytes of earlier output were dropped]\n") {
+		t.Errorf("logs = %.80q, want a note about the dropped output", text)
+	}
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// lazyDetails inspects the tasks registered from a listing of their
+// Taskfile (see WithLazyDetails) when they are first needed, and replaces
+// their tools with complete ones.
+type lazyDetails struct {
+	s      *server.MCPServer
+	config *inspector.MCPConfig
+	// tools is the bridge's tool of each task in config.
+	tools []mcp.Tool
+	build func(i int) (mcp.Tool, server.ToolHandlerFunc)
+
+	mu sync.Mutex
+	// pending holds the inspector of each task not inspected yet, by its
+	// index in config.
+	pending map[int]*inspector.Inspector
+	// handlers holds the handler of each task, built from its inspected
+	// details once it is resolved.
+	handlers map[int]server.ToolHandlerFunc
+	// registered holds the handler each task's tool is registered with:
+	// the one add returns, wrapped like other tools' handlers.
+	registered map[int]server.ToolHandlerFunc
+}
+
+func newLazyDetails(s *server.MCPServer, config *inspector.MCPConfig, build func(i int) (mcp.Tool, server.ToolHandlerFunc)) *lazyDetails {
+	return &lazyDetails{
+		s:          s,
+		config:     config,
+		build:      build,
+		pending:    make(map[int]*inspector.Inspector),
+		handlers:   make(map[int]server.ToolHandlerFunc),
+		registered: make(map[int]server.ToolHandlerFunc),
+	}
+}
+
+// add adds the i-th task, built from the listing with handler, and returns
+// the handler to register its tool with, which inspects the task before its
+// first call.
+func (l *lazyDetails) add(i int, insp *inspector.Inspector, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	l.pending[i] = insp
+	l.handlers[i] = handler
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		handler, err := l.resolve(ctx, i)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return handler(ctx, request)
+	}
+}
+
+// register resolves all tasks before tools are listed, so clients see their
+// parameters.
+func (l *lazyDetails) register(hooks *server.Hooks) {
+	hooks.AddBeforeListTools(func(ctx context.Context, id any, message *mcp.ListToolsRequest) {
+		if err := l.resolveAll(ctx); err != nil {
+			slog.Warn("Could not inspect tasks, listing them without their parameters", "error", err)
+		}
+	})
+}
+
+// resolve inspects the i-th task unless it has been, and returns its
+// handler.
+func (l *lazyDetails) resolve(ctx context.Context, i int) (server.ToolHandlerFunc, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	tool, err := l.resolveLocked(ctx, i)
+	if err != nil {
+		return nil, err
+	}
+	if tool != nil {
+		l.s.AddTools(*tool)
+	}
+	return l.handlers[i], nil
+}
+
+// resolveAll inspects all tasks that haven't been.
+func (l *lazyDetails) resolveAll(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var indexes []int
+	for i := range l.pending {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	var tools []server.ServerTool
+	var errs []error
+	for _, i := range indexes {
+		tool, err := l.resolveLocked(ctx, i)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		tools = append(tools, *tool)
+	}
+	// Replacing the tools notifies clients once.
+	if len(tools) > 0 {
+		l.s.AddTools(tools...)
+	}
+	return errors.Join(errs...)
+}
+
+// resolveLocked inspects the i-th task if it is pending and returns its new
+// tool, or nil if it was inspected before.
+func (l *lazyDetails) resolveLocked(ctx context.Context, i int) (*server.ServerTool, error) {
+	insp, ok := l.pending[i]
+	if !ok {
+		return nil, nil
+	}
+	name := l.config.Tasks[i].Name
+	task, err := insp.InspectTask(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("inspecting task %s: %w", name, err)
+	}
+	// Full inspections don't keep the summary either.
+	task.Summary = ""
+	l.config.Tasks[i] = *task
+
+	tool, handler := l.build(i)
+	l.tools[i] = tool
+	l.handlers[i] = handler
+	delete(l.pending, i)
+	return &server.ServerTool{Tool: tool, Handler: l.registered[i]}, nil
+}
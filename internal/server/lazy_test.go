@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestLazyDetails(t *testing.T) {
+	dir := t.TempDir()
+	taskfile := filepath.Join(dir, "Taskfile.yml")
+	if err := os.WriteFile(taskfile, []byte("version: '3'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	summaries := filepath.Join(dir, "summaries")
+	bin := fakeTaskBin(t, `case "$*" in
+*--list*) echo '{"tasks": [{"name": "greet", "desc": "Say hello"}, {"name": "build", "desc": "Build it"}]}' ;;
+*--summary*) echo "$1" >> `+summaries+`; echo "task: $1"; echo "Say hello"; echo; echo "Usage: task $1 NAME=<name>" ;;
+*) echo "ran $*" ;;
+esac
+`)
+	bridge, err := New(context.Background(), taskfile, bin, "tasks", WithLazyDetails(), WithLogOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bridge.Close()
+	inspected := func() []string {
+		data, _ := os.ReadFile(summaries)
+		return strings.Fields(string(data))
+	}
+	if got := inspected(); len(got) != 0 {
+		t.Fatalf("inspected %v at startup, want none", got)
+	}
+
+	send := func(method string, params any, result any) {
+		t.Helper()
+		request, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+		response, _ := json.Marshal(bridge.MCPServer().HandleMessage(context.Background(), request))
+		msg := struct {
+			Result any `json:"result"`
+		}{Result: result}
+		if err := json.Unmarshal(response, &msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var call json.RawMessage
+	send("tools/call", map[string]any{"name": "greet", "arguments": map[string]any{"NAME": "Ada"}}, &call)
+	if !strings.Contains(string(call), "greet NAME=Ada") || strings.Contains(string(call), `"isError":true`) {
+		t.Errorf("call result = %s, want the task run with its parameter", call)
+	}
+	if got := inspected(); strings.Join(got, " ") != "greet" {
+		t.Errorf("inspected %v after calling greet, want only greet", got)
+	}
+
+	var list mcp.ListToolsResult
+	send("tools/list", map[string]any{}, &list)
+	if got := inspected(); strings.Join(got, " ") != "greet build" {
+		t.Errorf("inspected %v after listing tools, want greet, then build", got)
+	}
+	for _, tool := range list.Tools {
+		if _, ok := tool.InputSchema.Properties["NAME"]; !ok {
+			t.Errorf("tool %s has no NAME parameter after listing", tool.Name)
+		}
+	}
+
+	send("tools/list", map[string]any{}, &list)
+	if got := inspected(); len(got) != 2 {
+		t.Errorf("inspected %v after listing tools again, want each task once", got)
+	}
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// maxToolNameLength is the longest tool name MCP clients accept.
+const maxToolNameLength = 64
+
+// invalidToolNameChars matches characters outside the MCP tool name
+// alphabet, such as the ':' of namespaced tasks.
+var invalidToolNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// WithToolPrefix prepends prefix to every tool name, so tools from several
+// Taskfiles can be told apart by clients that connect to all of them.
+func WithToolPrefix(prefix string) Option {
+	return func(s *settings) {
+		s.toolPrefix = prefix
+	}
+}
+
+// sanitizeToolName replaces characters MCP clients reject with '_' and
+// truncates the name to maxToolNameLength.
+func sanitizeToolName(name string) string {
+	name = invalidToolNameChars.ReplaceAllString(name, "_")
+	if len(name) > maxToolNameLength {
+		name = name[:maxToolNameLength]
+	}
+	return name
+}
+
+// ToolName pairs a task with the tool name it is exposed as.
+type ToolName struct {
+	Task string
+	Tool string
+	// Taskfile is the absolute path of the task's Taskfile.
+	Taskfile string
+	// Source is the kind of file Taskfile is.
+	Source inspector.Source
+}
+
+// resolveToolNames returns a unique tool name for every task, in task
+// order. Tasks whose prefixed name is already valid keep it; the others
+// are sanitized and, in task name order, given the first free numeric
+// suffix ("_2", "_3", ...) on collision. The result doesn't depend on the
+// order tasks were listed in.
+func resolveToolNames(tasks []inspector.TaskDefinition, prefix string) []ToolName {
+	wanted := make([]string, len(tasks))
+	for i, task := range tasks {
+		wanted[i] = prefix + task.Name
+	}
+	return resolveNames(tasks, wanted)
+}
+
+// resolveNames is resolveToolNames with the wanted name of each task given
+// explicitly, for tasks from several Taskfiles with different prefixes.
+func resolveNames(tasks []inspector.TaskDefinition, wanted []string) []ToolName {
+	names := make([]ToolName, len(tasks))
+	taken := make(map[string]bool, len(tasks))
+	var renamed []int
+	for i, task := range tasks {
+		names[i].Task = task.Name
+		if name := wanted[i]; sanitizeToolName(name) == name && !taken[name] {
+			names[i].Tool = name
+			taken[name] = true
+			continue
+		}
+		renamed = append(renamed, i)
+	}
+
+	sort.SliceStable(renamed, func(a, b int) bool {
+		return wanted[renamed[a]] < wanted[renamed[b]]
+	})
+	for _, i := range renamed {
+		base := sanitizeToolName(wanted[i])
+		name := base
+		for n := 2; taken[name]; n++ {
+			suffix := "_" + strconv.Itoa(n)
+			if len(base)+len(suffix) > maxToolNameLength {
+				name = base[:maxToolNameLength-len(suffix)] + suffix
+			} else {
+				name = base + suffix
+			}
+		}
+		names[i].Tool = name
+		taken[name] = true
+	}
+	return names
+}
+
+// reportToolNames writes the tasks whose tool name differs from the task
+// name, so the mapping is visible at startup.
+func reportToolNames(w io.Writer, names []ToolName) {
+	for _, n := range names {
+		if n.Task != n.Tool {
+			fmt.Fprintf(w, "Task %q is exposed as tool %q\n", n.Task, n.Tool)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestResolveToolNames(t *testing.T) {
+	tools := func(names []ToolName) []string {
+		var out []string
+		for _, n := range names {
+			out = append(out, n.Tool)
+		}
+		return out
+	}
+	tasks := func(names ...string) []inspector.TaskDefinition {
+		var out []inspector.TaskDefinition
+		for _, name := range names {
+			out = append(out, inspector.TaskDefinition{Name: name})
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		tasks  []inspector.TaskDefinition
+		prefix string
+		want   []string
+	}{
+		{name: "valid names kept", tasks: tasks("build", "test-all"), want: []string{"build", "test-all"}},
+		{name: "sanitized", tasks: tasks("db:migrate"), want: []string{"db_migrate"}},
+		{name: "slashes and spaces", tasks: tasks("docs/build site"), want: []string{"docs_build_site"}},
+		{name: "valid name wins collision", tasks: tasks("db:migrate", "db_migrate"), want: []string{"db_migrate_2", "db_migrate"}},
+		{name: "suffix in task name order", tasks: tasks("db.migrate", "db:migrate", "db_migrate"), want: []string{"db_migrate_2", "db_migrate_3", "db_migrate"}},
+		{name: "order independent", tasks: tasks("db:migrate", "db.migrate", "db_migrate"), want: []string{"db_migrate_3", "db_migrate_2", "db_migrate"}},
+		{name: "prefix", tasks: tasks("build", "db:migrate"), prefix: "api_", want: []string{"api_build", "api_db_migrate"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tools(resolveToolNames(tt.tasks, tt.prefix))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveToolNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("long names stay within the limit", func(t *testing.T) {
+		long := strings.Repeat("a", 70)
+		got := tools(resolveToolNames(tasks(long+":x", long+":y"), ""))
+		if got[0] == got[1] {
+			t.Fatalf("resolveToolNames() returned duplicate %q", got[0])
+		}
+		for _, name := range got {
+			if len(name) > maxToolNameLength {
+				t.Errorf("tool name %q is %d characters long", name, len(name))
+			}
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ToolCall describes a completed tool call.
+type ToolCall struct {
+	Time      time.Time
+	Tool      string
+	Arguments map[string]any
+	// Meta holds the request's _meta fields other than the progress token.
+	Meta map[string]any
+	// Session is the ID of the client session that made the call, and
+	// Client and ClientVersion identify its client, when known.
+	Session       string
+	Client        string
+	ClientVersion string
+	Result        string
+	IsError       bool
+	Duration      time.Duration
+}
+
+// WithCallObserver calls fn after every tool call, e.g. to show a live log.
+// fn runs on the request goroutine and should not block.
+func WithCallObserver(fn func(ToolCall)) Option {
+	return func(s *settings) {
+		s.observer = fn
+	}
+}
+
+// WithLogOutput sets where the request log is written. It defaults to
+// stderr; pass io.Discard when stderr is in use, e.g. by a TUI.
+func WithLogOutput(w io.Writer) Option {
+	return func(s *settings) {
+		s.logOutput = w
+	}
+}
+
+// observeCalls reports every call handled by next to fn.
+func observeCalls(next server.ToolHandlerFunc, fn func(ToolCall), ss *sessions) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		result, err := next(ctx, request)
+
+		call := ToolCall{
+			Time:      start,
+			Tool:      request.Params.Name,
+			Arguments: request.GetArguments(),
+			Duration:  time.Since(start),
+		}
+		if request.Params.Meta != nil {
+			call.Meta = request.Params.Meta.AdditionalFields
+		}
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			client := ss.client(ctx)
+			call.Session, call.Client, call.ClientVersion = session.SessionID(), client.Name, client.Version
+		}
+		switch {
+		case err != nil:
+			call.Result = err.Error()
+			call.IsError = true
+		case result != nil:
+			call.Result = resultText(result)
+			call.IsError = result.IsError
+		}
+		fn(call)
+		return result, err
+	}
+}
+
+// resultText joins the text content of a tool result.
+func resultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestObserveCalls(t *testing.T) {
+	var got []ToolCall
+	observe := func(call ToolCall) {
+		got = append(got, call)
+	}
+
+	ok := observeCalls(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("built"), nil
+	}, observe, newSessions())
+	failed := observeCalls(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return nil, errors.New("boom")
+	}, observe, newSessions())
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "build"
+	request.Params.Arguments = map[string]any{"TARGET": "linux"}
+	if _, err := ok(context.Background(), request); err != nil {
+		t.Fatalf("handler error = %v", err)
+	}
+	if _, err := failed(context.Background(), request); err == nil {
+		t.Fatal("handler error = nil, want error")
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("observed %d calls, want 2", len(got))
+	}
+	if got[0].Tool != "build" || got[0].Arguments["TARGET"] != "linux" || got[0].Result != "built" || got[0].IsError {
+		t.Errorf("first call = %+v", got[0])
+	}
+	if got[1].Result != "boom" || !got[1].IsError {
+		t.Errorf("second call = %+v", got[1])
+	}
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// outputMetaKey is the _meta key under which results list the output
+// streams that were saved to files.
+const outputMetaKey = "tmcp/output"
+
+// DefaultMaxOutput is the output size above which results only carry a
+// preview, when WithMaxOutput is not set.
+const DefaultMaxOutput = 256 << 10
+
+// outputPreviewSize is how much of saved text output the result shows.
+const outputPreviewSize = 4 << 10
+
+// maxSavedOutputs is how many saved output files are kept. Older ones are
+// deleted as new ones are written.
+const maxSavedOutputs = 50
+
+// WithMaxOutput saves output streams larger than n bytes, or that aren't
+// UTF-8 text, to temporary files readable as MCP resources. Results then
+// carry the end of the output and the resource URI instead.
+func WithMaxOutput(n int) Option {
+	return func(s *settings) {
+		s.maxOutput = n
+	}
+}
+
+// savedOutput is an output stream saved to a file.
+type savedOutput struct {
+	Stream string `json:"stream"`
+	URI    string `json:"uri"`
+	Size   int    `json:"size"`
+	Binary bool   `json:"binary"`
+
+	path string
+}
+
+// limitOutput returns data itself if it is UTF-8 text of at most the
+// maximum output size. Otherwise it saves data and returns a preview
+// pointing to the file, and the saved output.
+func (s *settings) limitOutput(task string, stream string, data string) (string, *savedOutput) {
+	limit := s.maxOutput
+	if limit <= 0 {
+		limit = DefaultMaxOutput
+	}
+	binary := !utf8.ValidString(data)
+	if len(data) <= limit && !binary {
+		return data, nil
+	}
+	saved, err := s.artifacts.saveOutput(task, stream, data, binary)
+	if err != nil {
+		slog.Warn("Could not save task output", "task", task, "stream", stream, "error", err)
+		if binary {
+			return fmt.Sprintf("[%d bytes of binary output, not shown]", len(data)), nil
+		}
+		return fmt.Sprintf("[%d bytes of output, showing the last %d]\n%s", len(data), outputPreviewSize, tail(data, outputPreviewSize)), nil
+	}
+	if binary {
+		return fmt.Sprintf("[%d bytes of binary output saved to %s, readable as an MCP resource]", len(data), saved.URI), saved
+	}
+	return fmt.Sprintf("[%d bytes of output saved to %s, readable as an MCP resource; showing the last %d]\n%s", len(data), saved.URI, outputPreviewSize, tail(data, outputPreviewSize)), saved
+}
+
+// saveOutput writes data to a temporary file and registers it as a
+// resource. The oldest files are deleted beyond maxSavedOutputs.
+func (as *artifacts) saveOutput(task string, stream string, data string, binary bool) (*savedOutput, error) {
+	ext := ".log"
+	if binary {
+		ext = ".bin"
+	}
+	name := strings.NewReplacer(":", "-", "/", "-", `\`, "-").Replace(task)
+	f, err := os.CreateTemp("", fmt.Sprintf("tmcp-%s-%s-*%s", name, stream, ext))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.WriteString(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+	saved := savedOutput{Stream: stream, URI: fileURI(f.Name()), Size: len(data), Binary: binary, path: f.Name()}
+
+	as.register(server.ServerResource{
+		Resource: mcp.NewResource(saved.URI, fmt.Sprintf("%s %s", task, stream),
+			mcp.WithResourceDescription(fmt.Sprintf("Full %s of a run of task %s", stream, task)),
+		),
+		Handler: readArtifact(saved.path),
+	})
+
+	as.mu.Lock()
+	as.saved = append(as.saved, saved)
+	var expired []savedOutput
+	if len(as.saved) > maxSavedOutputs {
+		expired = append(expired, as.saved[:len(as.saved)-maxSavedOutputs]...)
+		as.saved = append([]savedOutput{}, as.saved[len(as.saved)-maxSavedOutputs:]...)
+	}
+	s := as.mcp
+	as.mu.Unlock()
+	for _, old := range expired {
+		if s != nil {
+			s.RemoveResource(old.URI)
+		}
+		os.Remove(old.path)
+	}
+	return &saved, nil
+}
+
+// tail returns about the last n bytes of s, starting at a rune boundary.
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	start := len(s) - n
+	for start < len(s) && !utf8.RuneStart(s[start]) {
+		start++
+	}
+	return "..." + s[start:]
+}
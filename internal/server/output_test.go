@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestTaskHandlerLargeOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		script     string
+		wantBinary bool
+		wantText   string
+	}{
+		{name: "large", script: "i=0; while [ $i -lt 100 ]; do echo line $i; i=$((i+1)); done\n", wantText: "line 99"},
+		{name: "binary", script: "printf 'PNG\\377\\376'\n", wantBinary: true, wantText: "binary output saved to"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newSettings([]Option{WithMaxOutput(100)})
+			cfg.taskBin = fakeTaskBin(t, tt.script)
+			handler := createTaskHandler("Taskfile.yml", t.TempDir(), inspector.TaskDefinition{Name: "build:docs"}, cfg)
+			result, err := handler(context.Background(), mcp.CallToolRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			saved, _ := result.Meta[outputMetaKey].([]*savedOutput)
+			if len(saved) != 1 || saved[0].Binary != tt.wantBinary {
+				t.Fatalf("meta output = %+v", result.Meta[outputMetaKey])
+			}
+			t.Cleanup(func() { os.Remove(saved[0].path) })
+			if text := resultText(result); result.IsError || !strings.Contains(text, tt.wantText) || !strings.Contains(text, saved[0].URI) || len(text) > outputPreviewSize+200 {
+				t.Errorf("result = %q", text)
+			}
+			u, err := url.Parse(saved[0].URI)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(u.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(data) != saved[0].Size {
+				t.Errorf("saved %d bytes, want %d", len(data), saved[0].Size)
+			}
+		})
+	}
+}
+
+func TestTaskHandlerSmallOutput(t *testing.T) {
+	cfg := newSettings(nil)
+	cfg.taskBin = fakeTaskBin(t, "echo ok\n")
+	handler := createTaskHandler("Taskfile.yml", t.TempDir(), inspector.TaskDefinition{Name: "build"}, cfg)
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resultText(result) != "ok\n" || result.Meta != nil {
+		t.Errorf("result = %q, meta %v", resultText(result), result.Meta)
+	}
+}
+
+func TestSavedOutputsExpire(t *testing.T) {
+	as := newArtifacts()
+	var paths []string
+	for i := 0; i < maxSavedOutputs+2; i++ {
+		saved, err := as.saveOutput("build", "stdout", "output", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, saved.path)
+	}
+	t.Cleanup(func() {
+		for _, path := range paths {
+			os.Remove(path)
+		}
+	})
+	for i, path := range paths {
+		_, err := os.Stat(path)
+		if exists := err == nil; exists != (i >= 2) {
+			t.Errorf("file %d exists = %v", i, exists)
+		}
+	}
+}
+
+func TestTail(t *testing.T) {
+	if got := tail("short", 10); got != "short" {
+		t.Errorf("tail() = %q", got)
+	}
+	if got := tail("aaaé", 1); got != "..." {
+		t.Errorf("tail() = %q, want no partial rune", got)
+	}
+	if got := tail("abcdef", 3); got != "...def" {
+		t.Errorf("tail() = %q", got)
+	}
+}
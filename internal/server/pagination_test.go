@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestPageSize(t *testing.T) {
+	taskfile := filepath.Join(t.TempDir(), "Taskfile.yml")
+	if err := os.WriteFile(taskfile, []byte("version: '3'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bin := fakeTaskBin(t, `case "$*" in
+*--list*) echo '{"tasks": [{"name": "a"}, {"name": "b"}, {"name": "c"}, {"name": "d"}, {"name": "e"}]}' ;;
+*) echo "task: $1" ;;
+esac
+`)
+	bridge, err := New(context.Background(), taskfile, bin, "tasks", WithPageSize(2), WithLogOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bridge.Close()
+
+	var pages [][]string
+	cursor := ""
+	for len(pages) < 5 {
+		params := map[string]any{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		request, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "tools/list", "params": params})
+		response, _ := json.Marshal(bridge.MCPServer().HandleMessage(context.Background(), request))
+		var msg struct {
+			Result mcp.ListToolsResult `json:"result"`
+		}
+		if err := json.Unmarshal(response, &msg); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, tool := range msg.Result.Tools {
+			names = append(names, tool.Name)
+		}
+		pages = append(pages, names)
+		if cursor = string(msg.Result.NextCursor); cursor == "" {
+			break
+		}
+	}
+	if len(pages) != 3 || len(pages[0]) != 2 || len(pages[1]) != 2 || len(pages[2]) != 1 {
+		t.Fatalf("pages = %v, want 2, 2 and 1 tools", pages)
+	}
+	if pages[0][0] != "a" || pages[2][0] != "e" {
+		t.Errorf("pages = %v, want the tools in name order", pages)
+	}
+}
@@ -0,0 +1,290 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// stdioSessionID is the ID of mcp-go's one stdio session.
+const stdioSessionID = "stdio"
+
+// errClientGone is returned for requests to a client that disconnected
+// before answering.
+var errClientGone = errors.New("client disconnected")
+
+// clientPeer sends requests to the client of the stdio session, for the
+// client features of MCP such as elicitation. mcp-go only sends
+// notifications to clients, and neither passes on their responses nor the
+// capabilities it doesn't know, so the peer sits between it and stdin and
+// stdout.
+type clientPeer struct {
+	mu sync.Mutex
+	// out is nil until the peer is attached to the stdio client.
+	out *lockedWriter
+	// capabilities are those the client declared in initialize.
+	capabilities map[string]json.RawMessage
+	nextID       int
+	pending      map[string]chan peerResponse
+	closed       bool
+	// handlers are called on the client's notifications, by method.
+	handlers map[string][]func()
+}
+
+// peerResponse is the client's answer to a request.
+type peerResponse struct {
+	Result json.RawMessage
+	Error  *peerError
+}
+
+type peerError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// lockedWriter serializes the messages of mcp-go and the peer on stdout.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+func newClientPeer() *clientPeer {
+	return &clientPeer{pending: make(map[string]chan peerResponse), handlers: make(map[string][]func())}
+}
+
+// attach connects the peer to the stdio client on in and out, and returns
+// the streams for mcp-go to serve the client on instead.
+func (p *clientPeer) attach(in io.Reader, out io.Writer) (io.Reader, io.Writer) {
+	p.mu.Lock()
+	p.out = &lockedWriter{w: out}
+	p.mu.Unlock()
+	return p.filter(in), p.out
+}
+
+// filter returns the messages of in for mcp-go, without the client's
+// responses to the peer's requests.
+func (p *clientPeer) filter(in io.Reader) io.Reader {
+	q := newMessageQueue()
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			// mcp-go reads a message per line too.
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 && !p.intercept(line) {
+				q.Write(line)
+			}
+			if err != nil {
+				p.close()
+				q.close(err)
+				return
+			}
+		}
+	}()
+	return q
+}
+
+// messageQueue passes the client's messages on to mcp-go. Unlike a pipe,
+// it never blocks writes: mcp-go handles a tool call before it reads the
+// next message, and a call asking the client something waits for its
+// response behind them.
+type messageQueue struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	buf  bytes.Buffer
+	err  error
+}
+
+func newMessageQueue() *messageQueue {
+	q := &messageQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *messageQueue) Write(p []byte) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.buf.Write(p)
+	q.cond.Broadcast()
+	return len(p), nil
+}
+
+// Read blocks until there are messages, then returns the end of input
+// once they are read.
+func (q *messageQueue) Read(p []byte) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.buf.Len() == 0 && q.err == nil {
+		q.cond.Wait()
+	}
+	if q.buf.Len() > 0 {
+		return q.buf.Read(p)
+	}
+	return 0, q.err
+}
+
+// close ends the input with err, io.EOF when the client closed stdin.
+func (q *messageQueue) close(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.err = err
+	q.cond.Broadcast()
+}
+
+// intercept takes the messages of the client meant for the peer, and notes
+// its capabilities when it initializes. It reports whether mcp-go should
+// not see line.
+func (p *clientPeer) intercept(line []byte) bool {
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+		Result json.RawMessage `json:"result"`
+		Error  *peerError      `json:"error"`
+	}
+	if json.Unmarshal(line, &msg) != nil {
+		return false
+	}
+	switch {
+	case msg.Method == "initialize":
+		var params struct {
+			Capabilities map[string]json.RawMessage `json:"capabilities"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		p.mu.Lock()
+		p.capabilities = params.Capabilities
+		p.mu.Unlock()
+	case strings.HasPrefix(msg.Method, "notifications/"):
+		p.mu.Lock()
+		handlers := p.handlers[msg.Method]
+		p.mu.Unlock()
+		// They may send requests, whose responses this goroutine reads.
+		for _, fn := range handlers {
+			go fn()
+		}
+	case msg.Method == "" && len(msg.ID) > 0:
+		// mcp-go has no use for responses, even to requests it didn't send.
+		var id string
+		if json.Unmarshal(msg.ID, &id) == nil {
+			p.mu.Lock()
+			ch, ok := p.pending[id]
+			delete(p.pending, id)
+			p.mu.Unlock()
+			if ok {
+				ch <- peerResponse{Result: msg.Result, Error: msg.Error}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// close fails the requests still waiting for the client.
+func (p *clientPeer) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for id, ch := range p.pending {
+		close(ch)
+		delete(p.pending, id)
+	}
+}
+
+// on calls fn on each notification of method from the client, such as
+// notifications/initialized, after which it can be sent requests.
+func (p *clientPeer) on(method string, fn func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers[method] = append(p.handlers[method], fn)
+}
+
+// supports reports whether the client declared capability, e.g.
+// "elicitation", when it initialized.
+func (p *clientPeer) supports(capability string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.capabilities[capability]
+	return ok
+}
+
+// declared returns the capabilities the client declared, sorted.
+func (p *clientPeer) declared() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	names := make([]string, 0, len(p.capabilities))
+	for name := range p.capabilities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// request sends a request to the client and decodes its result into
+// result, waiting until the client answers or ctx is done.
+func (p *clientPeer) request(ctx context.Context, method string, params any, result any) error {
+	p.mu.Lock()
+	out := p.out
+	if p.closed || out == nil {
+		p.mu.Unlock()
+		return errClientGone
+	}
+	p.nextID++
+	id := fmt.Sprintf("tmcp-%d", p.nextID)
+	ch := make(chan peerResponse, 1)
+	p.pending[id] = ch
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.pending, id)
+		p.mu.Unlock()
+	}()
+
+	data, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	select {
+	case response, ok := <-ch:
+		if !ok {
+			return errClientGone
+		}
+		if response.Error != nil {
+			return fmt.Errorf("%s: %s", method, response.Error.Message)
+		}
+		return json.Unmarshal(response.Result, result)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// peerFor returns the peer of the client calling a tool, or nil when it
+// can't be sent requests, e.g. over HTTP.
+func (s *settings) peerFor(ctx context.Context) *clientPeer {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil || session.SessionID() != stdioSessionID {
+		return nil
+	}
+	s.peer.mu.Lock()
+	defer s.peer.mu.Unlock()
+	if s.peer.out == nil || s.peer.closed {
+		return nil
+	}
+	return s.peer
+}
@@ -0,0 +1,365 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// pipelineMetaKey is the _meta key under which pipeline results list the
+// outcome of each step.
+const pipelineMetaKey = "tmcp/pipeline"
+
+// Pipeline is a tool that runs several tasks one after the other.
+type Pipeline struct {
+	// Name is the tool's name, prefixed like the task tools.
+	Name string
+	// Description is the tool's description; one listing the steps is
+	// used when empty.
+	Description string
+	Steps       []PipelineStep
+}
+
+// PipelineStep is a task of a pipeline.
+type PipelineStep struct {
+	// Task is the name of the task, or of its tool when several Taskfiles
+	// of a workspace have a task of that name.
+	Task string
+	// Args are fixed arguments of the step. Callers of the pipeline can't
+	// set them for it.
+	Args map[string]any
+	// Compensate names the task that undoes the step, if any. It runs when
+	// a later step fails, with the arguments it declares and Args.
+	Compensate string
+}
+
+// WithPipelines adds a tool for each pipeline. Its input schema combines
+// the parameters of its steps, and each step gets the arguments it
+// declares. Steps run like calls of their own tools, with the same
+// policies, approvals and limits, and the pipeline stops at the first step
+// that fails. The steps that succeeded before it are then compensated, last
+// first.
+func WithPipelines(pipelines []Pipeline) Option {
+	return func(s *settings) {
+		s.pipelines = pipelines
+	}
+}
+
+// pipelineStepResult is the outcome of a pipeline step, as listed in the
+// result's _meta.
+type pipelineStepResult struct {
+	Task string `json:"task"`
+	Tool string `json:"tool"`
+	// Status is succeeded, failed or skipped.
+	Status     string `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+	// Compensation is the outcome of the step's compensating task, if it
+	// ran.
+	Compensation *pipelineStepResult `json:"compensation,omitempty"`
+}
+
+// pipelineTool is a pipeline with the tools of its steps resolved.
+type pipelineTool struct {
+	name  string
+	steps []pipelineTarget
+}
+
+// pipelineTarget is a step of a pipelineTool.
+type pipelineTarget struct {
+	task    string
+	tool    string
+	handler server.ToolHandlerFunc
+	// params are the parameters the step takes from the pipeline's
+	// arguments.
+	params []string
+	args   map[string]any
+	// compensation undoes the step, if set.
+	compensation *pipelineTarget
+}
+
+// newPipelineTool builds the tool of p from the tools of the bridge's tasks
+// and their handlers. With WithLazyDetails, the tasks of the steps and their
+// compensations are inspected first so their parameters are known.
+func newPipelineTool(ctx context.Context, p Pipeline, prefix string, tasks []inspector.TaskDefinition, tools []mcp.Tool, targets map[string]taskTool, lazy *lazyDetails) (mcp.Tool, *pipelineTool, error) {
+	pt := &pipelineTool{name: prefix + p.Name}
+	properties := make(map[string]any)
+	required := make(map[string]bool)
+	readOnly := true
+	// target resolves a task of the pipeline and adds its parameters, less
+	// the fixed args, to the pipeline's schema.
+	target := func(name string, args map[string]any) (pipelineTarget, error) {
+		i, err := pipelineStepIndex(name, tasks, tools)
+		if err != nil {
+			return pipelineTarget{}, err
+		}
+		if lazy != nil {
+			if _, err := lazy.resolve(ctx, i); err != nil {
+				return pipelineTarget{}, err
+			}
+		}
+		task, tool := tasks[i], tools[i]
+		if task.Async {
+			return pipelineTarget{}, fmt.Errorf("task %s runs as a background job, which pipelines can't wait for", task.Name)
+		}
+		t := pipelineTarget{task: task.Name, tool: tool.Name, handler: targets[tool.Name].handler, args: args}
+		for name, schema := range tool.InputSchema.Properties {
+			if _, fixed := args[name]; fixed {
+				continue
+			}
+			if _, ok := properties[name]; !ok {
+				properties[name] = schema
+			}
+			t.params = append(t.params, name)
+		}
+		for _, name := range tool.InputSchema.Required {
+			if _, fixed := args[name]; !fixed {
+				required[name] = true
+			}
+		}
+		readOnly = readOnly && task.ReadOnly
+		return t, nil
+	}
+	var names, compensated []string
+	for _, step := range p.Steps {
+		t, err := target(step.Task, step.Args)
+		if err != nil {
+			return mcp.Tool{}, nil, fmt.Errorf("pipeline %s: %w", p.Name, err)
+		}
+		if step.Compensate != "" {
+			c, err := target(step.Compensate, step.Args)
+			if err != nil {
+				return mcp.Tool{}, nil, fmt.Errorf("pipeline %s: compensation of %s: %w", p.Name, step.Task, err)
+			}
+			t.compensation = &c
+			compensated = append(compensated, fmt.Sprintf("%s by %s", t.task, c.task))
+		}
+		names = append(names, t.task)
+		pt.steps = append(pt.steps, t)
+	}
+
+	description := fmt.Sprintf("Runs %s in order, and stops at the first that fails.", strings.Join(names, ", then "))
+	if len(compensated) > 0 {
+		description += fmt.Sprintf(" When a step fails, the steps before it are undone, last first: %s.", strings.Join(compensated, ", "))
+	}
+	if p.Description != "" {
+		description = p.Description + "\n\n" + description
+	}
+	opts := []mcp.ToolOption{mcp.WithDescription(description)}
+	if readOnly {
+		opts = append(opts,
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithIdempotentHintAnnotation(true),
+		)
+	}
+	tool := mcp.NewTool(pt.name, opts...)
+	tool.InputSchema.Properties = properties
+	for name := range required {
+		tool.InputSchema.Required = append(tool.InputSchema.Required, name)
+	}
+	sort.Strings(tool.InputSchema.Required)
+	return tool, pt, nil
+}
+
+// pipelineStepIndex returns the index of the task a step names, by tool
+// name or by task name when that is unique.
+func pipelineStepIndex(name string, tasks []inspector.TaskDefinition, tools []mcp.Tool) (int, error) {
+	for i, tool := range tools {
+		if tool.Name == name {
+			return i, nil
+		}
+	}
+	found := -1
+	for i, task := range tasks {
+		if task.Name != name {
+			continue
+		}
+		if found >= 0 {
+			return 0, fmt.Errorf("task %s is ambiguous, name its tool: %s or %s", name, tools[found].Name, tools[i].Name)
+		}
+		found = i
+	}
+	if found < 0 {
+		return 0, fmt.Errorf("unknown task %s", name)
+	}
+	return found, nil
+}
+
+// handle runs the steps in order until one fails, then runs the
+// compensations of the steps that succeeded, last first. The result holds
+// the output of everything that ran, and is an error if a step failed.
+func (p *pipelineTool) handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	steps := make([]pipelineStepResult, len(p.steps))
+	var parts, skipped []string
+	stopped := ""
+	ran := 0
+	for i, step := range p.steps {
+		steps[i] = pipelineStepResult{Task: step.task, Tool: step.tool, Status: "skipped"}
+		if stopped == "" && ctx.Err() != nil {
+			stopped = fmt.Sprintf("Pipeline %s was cancelled", p.name)
+		}
+		if stopped != "" {
+			skipped = append(skipped, step.task)
+			continue
+		}
+		var text string
+		steps[i], text = step.run(ctx, request)
+		parts = append(parts, text)
+		if steps[i].Status == "failed" {
+			stopped = fmt.Sprintf("Pipeline %s stopped at %s", p.name, step.task)
+			continue
+		}
+		ran = i + 1
+	}
+	if len(skipped) > 0 {
+		parts = append(parts, fmt.Sprintf("%s; %s did not run.", stopped, strings.Join(skipped, ", ")))
+	}
+
+	if stopped != "" {
+		// Compensations run even when the call was cancelled, so nothing is
+		// left half done.
+		undo := context.WithoutCancel(ctx)
+		var undone, failed []string
+		for i := ran - 1; i >= 0; i-- {
+			c := p.steps[i].compensation
+			if c == nil {
+				continue
+			}
+			result, text := c.run(undo, request)
+			steps[i].Compensation = &result
+			parts = append(parts, "Compensating "+p.steps[i].task+": "+text)
+			if result.Status == "failed" {
+				failed = append(failed, c.task)
+			} else {
+				undone = append(undone, p.steps[i].task)
+			}
+		}
+		if len(undone) > 0 {
+			parts = append(parts, fmt.Sprintf("Undid %s.", strings.Join(undone, ", ")))
+		}
+		if len(failed) > 0 {
+			parts = append(parts, fmt.Sprintf("Compensation failed: %s. Check what is left to clean up.", strings.Join(failed, ", ")))
+		}
+	}
+	result := mcp.NewToolResultText(strings.Join(parts, "\n\n"))
+	result.IsError = stopped != ""
+	result.Meta = map[string]any{pipelineMetaKey: steps}
+	return result, nil
+}
+
+// run calls the target's tool with the pipeline arguments it declares and
+// its fixed args, and returns its outcome and a summary of its result.
+func (t *pipelineTarget) run(ctx context.Context, request mcp.CallToolRequest) (pipelineStepResult, string) {
+	arguments := request.GetArguments()
+	call := mcp.CallToolRequest{}
+	call.Params.Name = t.tool
+	call.Params.Meta = request.Params.Meta
+	callArguments := make(map[string]any, len(t.params)+len(t.args))
+	for _, name := range t.params {
+		if value, ok := arguments[name]; ok {
+			callArguments[name] = value
+		}
+	}
+	for name, value := range t.args {
+		callArguments[name] = value
+	}
+	call.Params.Arguments = callArguments
+
+	start := time.Now()
+	result, err := t.handler(ctx, call)
+	if err != nil {
+		result = mcp.NewToolResultError(err.Error())
+	}
+	elapsed := time.Since(start)
+	outcome := pipelineStepResult{Task: t.task, Tool: t.tool, Status: "succeeded", DurationMS: elapsed.Milliseconds()}
+	if result.IsError {
+		outcome.Status = "failed"
+	}
+	return outcome, fmt.Sprintf("%s %s (%s):\n%s", t.task, outcome.Status, elapsed.Round(time.Millisecond), strings.TrimRight(resultText(result), "\n"))
+}
+
+// addPipelines adds the tools of the configured pipelines. Their names must
+// not be taken by other tools. Pipelines left out by WithConditions, or with
+// a step whose task in all isn't served, are skipped.
+func addPipelines(ctx context.Context, s *server.MCPServer, cfg *settings, all pipelineTasks, tasks []inspector.TaskDefinition, tools []mcp.Tool, targets map[string]taskTool, lazy *lazyDetails) error {
+	for _, p := range cfg.pipelines {
+		if pattern, reason := cfg.unmetCondition(p.Name); reason != "" {
+			slog.Info("Not serving pipeline, its condition doesn't hold", "pipeline", p.Name, "when", pattern, "reason", reason)
+			continue
+		}
+		if task := hiddenStep(p, all, tasks, tools); task != "" {
+			slog.Info("Not serving pipeline, one of its tasks isn't served", "pipeline", p.Name, "task", task)
+			continue
+		}
+		tool, pt, err := newPipelineTool(ctx, p, cfg.toolPrefix, tasks, tools, targets, lazy)
+		if err != nil {
+			return err
+		}
+		if _, taken := targets[tool.Name]; taken {
+			return fmt.Errorf("pipeline %s: tool %s is taken by a task", p.Name, tool.Name)
+		}
+		handler := pt.handle
+		if cfg.observer != nil {
+			handler = observeCalls(handler, cfg.observer, cfg.sessions)
+		}
+		s.AddTool(tool, handler)
+	}
+	return nil
+}
+
+// pipelineTasks are all tasks of the Taskfiles, served or not, with the
+// tool names they are or would be served as.
+type pipelineTasks struct {
+	tasks  []inspector.TaskDefinition
+	tools  []mcp.Tool
+	served []bool
+}
+
+// allTasks returns all tasks of the loaded sources.
+func allTasks(loaded []*loadedSource) pipelineTasks {
+	var all pipelineTasks
+	var wanted []string
+	for _, l := range loaded {
+		served := make(map[string]bool, len(l.tasks))
+		for _, task := range l.tasks {
+			served[task.Name] = true
+		}
+		for _, task := range l.all {
+			all.tasks = append(all.tasks, task)
+			all.served = append(all.served, served[task.Name])
+			wanted = append(wanted, l.cfg.toolPrefix+task.Name)
+		}
+	}
+	for _, name := range resolveNames(all.tasks, wanted) {
+		all.tools = append(all.tools, mcp.Tool{Name: name.Tool})
+	}
+	return all
+}
+
+// hiddenStep returns the first task of p's steps and compensations that
+// isn't among the served tasks and tools but resolves to a task of all that
+// isn't served, or "" if there is none. Other steps are left for
+// newPipelineTool to resolve or report.
+func hiddenStep(p Pipeline, all pipelineTasks, tasks []inspector.TaskDefinition, tools []mcp.Tool) string {
+	for _, step := range p.Steps {
+		for _, task := range []string{step.Task, step.Compensate} {
+			if task == "" {
+				continue
+			}
+			if _, err := pipelineStepIndex(task, tasks, tools); err == nil {
+				continue
+			}
+			if i, err := pipelineStepIndex(task, all.tasks, all.tools); err == nil && !all.served[i] {
+				return task
+			}
+		}
+	}
+	return ""
+}
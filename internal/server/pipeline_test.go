@@ -0,0 +1,238 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestPipeline(t *testing.T) {
+	tasks := []inspector.TaskDefinition{{Name: "build", ReadOnly: true}, {Name: "test", ReadOnly: true}, {Name: "deploy"}, {Name: "watch", Async: true}}
+	tools := []mcp.Tool{
+		mcp.NewTool("build", mcp.WithString("TARGET", mcp.Required())),
+		mcp.NewTool("test", mcp.WithString("TARGET"), mcp.WithString("RACE")),
+		mcp.NewTool("deploy", mcp.WithString("TARGET", mcp.Required()), mcp.WithString("ENV", mcp.Required())),
+		mcp.NewTool("watch"),
+	}
+	var calls []string
+	var received []map[string]any
+	failing := ""
+	targets := make(map[string]taskTool)
+	for i, task := range tasks {
+		name := task.Name
+		targets[tools[i].Name] = taskTool{task: task, handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			calls = append(calls, name)
+			received = append(received, request.GetArguments())
+			if name == failing {
+				return mcp.NewToolResultError(name + " broke"), nil
+			}
+			return mcp.NewToolResultText(name + " done"), nil
+		}}
+	}
+
+	p := Pipeline{Name: "ship", Description: "Ship it.", Steps: []PipelineStep{{Task: "build"}, {Task: "test"}, {Task: "deploy", Args: map[string]any{"ENV": "prod"}}}}
+	tool, pt, err := newPipelineTool(context.Background(), p, "x_", tasks, tools, targets, nil)
+	if err != nil {
+		t.Fatalf("newPipelineTool() error = %v", err)
+	}
+	if tool.Name != "x_ship" || !strings.HasPrefix(tool.Description, "Ship it.\n\nRuns build, then test, then deploy in order") {
+		t.Errorf("tool = %q, %q", tool.Name, tool.Description)
+	}
+	if got := len(tool.InputSchema.Properties); got != 2 {
+		t.Errorf("properties = %v, want TARGET and RACE", tool.InputSchema.Properties)
+	}
+	if want := []string{"TARGET"}; !reflect.DeepEqual(tool.InputSchema.Required, want) {
+		t.Errorf("required = %v, want %v", tool.InputSchema.Required, want)
+	}
+	if tool.Annotations.ReadOnlyHint != nil && *tool.Annotations.ReadOnlyHint {
+		t.Error("ReadOnlyHint = true, but deploy isn't read-only")
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"TARGET": "linux", "RACE": "true", "ENV": "dev"}
+	result, _ := pt.handle(context.Background(), request)
+	if result.IsError {
+		t.Fatalf("result is an error: %s", resultText(result))
+	}
+	if want := []string{"build", "test", "deploy"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+	wantArgs := []map[string]any{{"TARGET": "linux"}, {"TARGET": "linux", "RACE": "true"}, {"TARGET": "linux", "ENV": "prod"}}
+	if !reflect.DeepEqual(received, wantArgs) {
+		t.Errorf("arguments = %v, want %v", received, wantArgs)
+	}
+
+	calls, failing = nil, "test"
+	result, _ = pt.handle(context.Background(), request)
+	if !result.IsError {
+		t.Fatal("IsError = false, want true")
+	}
+	if want := []string{"build", "test"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+	text := resultText(result)
+	for _, want := range []string{"build succeeded", "test failed", "test broke", "Pipeline x_ship stopped at test; deploy did not run."} {
+		if !strings.Contains(text, want) {
+			t.Errorf("result text %q does not contain %q", text, want)
+		}
+	}
+	steps, _ := result.Meta[pipelineMetaKey].([]pipelineStepResult)
+	var statuses []string
+	for _, step := range steps {
+		statuses = append(statuses, step.Status)
+	}
+	if want := []string{"succeeded", "failed", "skipped"}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("statuses = %v, want %v", statuses, want)
+	}
+
+	for _, bad := range []PipelineStep{{Task: "missing"}, {Task: "watch"}} {
+		if _, _, err := newPipelineTool(context.Background(), Pipeline{Name: "bad", Steps: []PipelineStep{bad}}, "", tasks, tools, targets, nil); err == nil {
+			t.Errorf("newPipelineTool(%s) error = nil, want error", bad.Task)
+		}
+	}
+	cfg := newSettings([]Option{WithPipelines([]Pipeline{{Name: "build", Steps: []PipelineStep{{Task: "test"}}}})})
+	if err := addPipelines(context.Background(), server.NewMCPServer("t", "1"), cfg, pipelineTasks{}, tasks, tools, targets, nil); err == nil {
+		t.Error("addPipelines() with a taken name error = nil, want error")
+	}
+
+	// Pipelines whose tasks or own conditions rule them out are skipped.
+	s := server.NewMCPServer("t", "1", server.WithToolCapabilities(true))
+	cfg = newSettings([]Option{
+		WithPipelines([]Pipeline{{Name: "ship", Steps: []PipelineStep{{Task: "build"}, {Task: "gone"}}}, {Name: "release", Steps: []PipelineStep{{Task: "build"}}}}),
+		WithConditions(map[string]config.Condition{"release": {Platforms: []string{"plan9"}}}),
+	})
+	gone := pipelineTasks{tasks: []inspector.TaskDefinition{{Name: "gone"}}, tools: []mcp.Tool{{Name: "gone"}}, served: []bool{false}}
+	if err := addPipelines(context.Background(), s, cfg, gone, tasks, tools, targets, nil); err != nil {
+		t.Fatalf("addPipelines() error = %v", err)
+	}
+	response, _ := json.Marshal(s.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)))
+	if strings.Contains(string(response), "ship") || strings.Contains(string(response), "release") {
+		t.Errorf("tools/list = %s, want no pipelines", response)
+	}
+}
+
+func TestPipelineCompensation(t *testing.T) {
+	var tasks []inspector.TaskDefinition
+	var tools []mcp.Tool
+	var calls []string
+	failing := map[string]bool{"migrate": true}
+	targets := make(map[string]taskTool)
+	for _, name := range []string{"build", "clean", "deploy", "undeploy", "migrate", "notify"} {
+		task := inspector.TaskDefinition{Name: name}
+		tasks = append(tasks, task)
+		tools = append(tools, mcp.NewTool(name, mcp.WithString("ENV")))
+		targets[name] = taskTool{task: task, handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			calls = append(calls, fmt.Sprintf("%s %v", name, request.GetArguments()["ENV"]))
+			if failing[name] {
+				return mcp.NewToolResultError(name + " broke"), nil
+			}
+			return mcp.NewToolResultText(name + " done"), nil
+		}}
+	}
+	p := Pipeline{Name: "ship", Steps: []PipelineStep{
+		{Task: "build", Compensate: "clean"},
+		{Task: "deploy", Args: map[string]any{"ENV": "prod"}, Compensate: "undeploy"},
+		{Task: "migrate"},
+		{Task: "notify"},
+	}}
+	tool, pt, err := newPipelineTool(context.Background(), p, "", tasks, tools, targets, nil)
+	if err != nil {
+		t.Fatalf("newPipelineTool() error = %v", err)
+	}
+	if !strings.Contains(tool.Description, "undone, last first: build by clean, deploy by undeploy.") {
+		t.Errorf("description = %q, want the compensations", tool.Description)
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"ENV": "dev"}
+	result, _ := pt.handle(context.Background(), request)
+	if !result.IsError {
+		t.Fatal("IsError = false, want true")
+	}
+	if want := []string{"build dev", "deploy prod", "migrate dev", "undeploy prod", "clean dev"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+	steps := result.Meta[pipelineMetaKey].([]pipelineStepResult)
+	for i, want := range []string{"succeeded", "succeeded", "", ""} {
+		got := ""
+		if c := steps[i].Compensation; c != nil {
+			got = c.Status
+		}
+		if got != want {
+			t.Errorf("step %s compensation = %q, want %q", steps[i].Task, got, want)
+		}
+	}
+	if text := resultText(result); !strings.Contains(text, "Compensating deploy: undeploy succeeded") || !strings.Contains(text, "Undid deploy, build.") {
+		t.Errorf("result text %q does not show the compensations", text)
+	}
+
+	calls, failing["undeploy"] = nil, true
+	result, _ = pt.handle(context.Background(), request)
+	if want := []string{"build dev", "deploy prod", "migrate dev", "undeploy prod", "clean dev"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q: a failed compensation must not stop the others", calls, want)
+	}
+	if text := resultText(result); !strings.Contains(text, "Compensation failed: undeploy.") {
+		t.Errorf("result text %q does not report the failed compensation", text)
+	}
+
+	p.Steps[0].Compensate = "missing"
+	if _, _, err := newPipelineTool(context.Background(), p, "", tasks, tools, targets, nil); err == nil {
+		t.Error("newPipelineTool() with an unknown compensation error = nil, want error")
+	}
+}
+
+func TestPipelineOfFilteredTask(t *testing.T) {
+	taskfile := func() string {
+		path := filepath.Join(t.TempDir(), "Taskfile.yml")
+		if err := os.WriteFile(path, []byte("version: '3'\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	bin := fakeTaskBin(t, `echo '{"tasks": [{"name": "build"}, {"name": "deploy:prod"}]}'`)
+	listed := func(bridge *Bridge) string {
+		response, _ := json.Marshal(bridge.MCPServer().HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)))
+		return string(response)
+	}
+
+	// Steps naming a filtered task by task or tool name are skipped.
+	bridge, err := New(context.Background(), taskfile(), bin, "tasks",
+		WithProfileFilter(nil, []string{"deploy:*"}),
+		WithPipelines([]Pipeline{
+			{Name: "ship", Steps: []PipelineStep{{Task: "build"}, {Task: "deploy:prod"}}},
+			{Name: "release", Steps: []PipelineStep{{Task: "build"}, {Task: "deploy_prod"}}},
+		}),
+		WithLogOutput(io.Discard))
+	if err != nil {
+		t.Fatalf("New() error = %v, want the pipelines skipped", err)
+	}
+	defer bridge.Close()
+	if response := listed(bridge); !strings.Contains(response, `"build"`) || strings.Contains(response, "ship") || strings.Contains(response, "release") {
+		t.Errorf("tools/list = %s, want build and no pipelines", response)
+	}
+
+	// A task filtered out of one Taskfile doesn't hide the same task served
+	// from another.
+	workspace, err := NewWorkspace(context.Background(), "all", []Source{
+		{Taskfile: taskfile(), TaskBin: bin, Options: []Option{WithToolPrefix("a_"), WithTaskFilter(nil, []string{"deploy:*"})}},
+		{Taskfile: taskfile(), TaskBin: bin, Options: []Option{WithToolPrefix("b_"), WithTaskFilter([]string{"deploy:*"}, nil)}},
+	}, WithPipelines([]Pipeline{{Name: "ship", Steps: []PipelineStep{{Task: "a_build"}, {Task: "deploy:prod"}}}}), WithLogOutput(io.Discard))
+	if err != nil {
+		t.Fatalf("NewWorkspace() error = %v", err)
+	}
+	defer workspace.Close()
+	if response := listed(workspace); !strings.Contains(response, `"ship"`) {
+		t.Errorf("tools/list = %s, want the pipeline", response)
+	}
+}
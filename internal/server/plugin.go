@@ -0,0 +1,158 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// pluginTimeout bounds a single run of a plugin hook.
+const pluginTimeout = 30 * time.Second
+
+// Plugin is an external program that hooks into task tool calls. It is run
+// once per hook and call with the hook's name as its last argument, a JSON
+// request on stdin and TMCP_PLUGIN_PROTOCOL in its environment, and prints
+// a JSON response.
+//
+// The arguments hook gets {"tool", "task", "arguments"} before the task
+// runs. It prints {"arguments": {...}} to replace the arguments,
+// {"error": "..."} to reject the call, or {} to leave it be.
+//
+// The result hook gets {"tool", "task", "arguments", "result": {"text",
+// "is_error"}} after the task ran. It prints {"result": {"text",
+// "is_error"}} to replace the result's text and error flag, or {} to leave
+// it be.
+type Plugin struct {
+	Name    string
+	Command []string
+	Env     map[string]string
+	// Arguments and Result are the hooks the plugin takes part in.
+	Arguments bool
+	Result    bool
+	// Tools limits the plugin to the tools matching one of these
+	// path.Match patterns; all tools when empty.
+	Tools []string
+}
+
+// WithPlugins runs plugins' hooks around every task tool call, in order.
+// A plugin that fails, or prints something that isn't a response, fails
+// the call.
+func WithPlugins(plugins []Plugin) Option {
+	return func(s *settings) {
+		s.plugins = plugins
+	}
+}
+
+// pluginArgumentsRequest and pluginResultRequest are what the hooks get on
+// stdin.
+type pluginArgumentsRequest struct {
+	Tool      string         `json:"tool"`
+	Task      string         `json:"task"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+type pluginResultRequest struct {
+	pluginArgumentsRequest
+	Result *pluginResult `json:"result"`
+}
+
+type pluginResult struct {
+	Text    string `json:"text"`
+	IsError bool   `json:"is_error"`
+}
+
+// pluginResponse is what the hooks print.
+type pluginResponse struct {
+	Arguments map[string]any `json:"arguments"`
+	Error     string         `json:"error"`
+	Result    *pluginResult  `json:"result"`
+}
+
+// withPlugins wraps the handler of tool, which runs task, in the hooks of
+// the plugins that apply to it.
+func withPlugins(handler server.ToolHandlerFunc, tool string, task string, plugins []Plugin) server.ToolHandlerFunc {
+	var applied []Plugin
+	for _, p := range plugins {
+		if len(p.Tools) == 0 || matchesAny(tool, p.Tools) {
+			applied = append(applied, p)
+		}
+	}
+	if len(applied) == 0 {
+		return handler
+	}
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		call := pluginArgumentsRequest{Tool: tool, Task: task, Arguments: request.GetArguments()}
+		for _, p := range applied {
+			if !p.Arguments {
+				continue
+			}
+			var resp pluginResponse
+			if err := p.run(ctx, "arguments", call, &resp); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if resp.Error != "" {
+				return mcp.NewToolResultError(fmt.Sprintf("Rejected by plugin %s: %s", p.Name, resp.Error)), nil
+			}
+			if resp.Arguments != nil {
+				call.Arguments = resp.Arguments
+			}
+		}
+		request.Params.Arguments = call.Arguments
+
+		result, err := handler(ctx, request)
+		if err != nil || result == nil {
+			return result, err
+		}
+		for _, p := range applied {
+			if !p.Result {
+				continue
+			}
+			var resp pluginResponse
+			req := pluginResultRequest{call, &pluginResult{Text: resultText(result), IsError: result.IsError}}
+			if err := p.run(ctx, "result", req, &resp); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			if resp.Result != nil {
+				result.Content = []mcp.Content{mcp.NewTextContent(resp.Result.Text)}
+				result.IsError = resp.Result.IsError
+			}
+		}
+		return result, nil
+	}
+}
+
+// run runs the plugin's hook with req on stdin and decodes its response.
+func (p Plugin) run(ctx context.Context, hook string, req any, resp *pluginResponse) error {
+	ctx, cancel := context.WithTimeout(ctx, pluginTimeout)
+	defer cancel()
+	input, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("plugin %s: %w", p.Name, err)
+	}
+	cmd := exec.CommandContext(ctx, p.Command[0], append(append([]string{}, p.Command[1:]...), hook)...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("TMCP_PLUGIN_PROTOCOL=%d", inspector.PluginProtocol))
+	cmd.Env = append(cmd.Env, envPairs(p.Env)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("plugin %s failed in its %s hook: %v: %s", p.Name, hook, err, strings.TrimSpace(stderr.String()))
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(out, resp); err != nil {
+		return fmt.Errorf("plugin %s printed an invalid %s response: %v", p.Name, hook, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// testPlugin rejects calls for prod, moves every other call to staging and
+// marks results as audited.
+const testPlugin = `#!/bin/sh
+[ "$TMCP_PLUGIN_PROTOCOL" = 1 ] || exit 1
+input=$(cat)
+case "$1" in
+arguments)
+	case "$input" in
+	*'"ENV":"prod"'*) echo '{"error": "no deploys to prod"}' ;;
+	*) echo '{"arguments": {"ENV": "staging"}}' ;;
+	esac ;;
+result)
+	case "$input" in
+	*'"text":"deployed staging"'*) echo '{"result": {"text": "audited: deployed staging"}}' ;;
+	*) echo '{}' ;;
+	esac ;;
+esac
+`
+
+func TestPlugins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit")
+	if err := os.WriteFile(path, []byte(testPlugin), 0755); err != nil {
+		t.Fatal(err)
+	}
+	handler := func(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("deployed " + request.GetString("ENV", "")), nil
+	}
+	plugins := []Plugin{{Name: "audit", Command: []string{path}, Arguments: true, Result: true, Tools: []string{"deploy*"}}}
+
+	deploy := withPlugins(handler, "deploy", "deploy", plugins)
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"ENV": "dev"}
+	result, err := deploy(context.Background(), request)
+	if err != nil || result.IsError || resultText(result) != "audited: deployed staging" {
+		t.Errorf("deploy = %q, %v, want the audited staging deploy", resultText(result), err)
+	}
+
+	request.Params.Arguments = map[string]any{"ENV": "prod"}
+	result, _ = deploy(context.Background(), request)
+	if !result.IsError || !strings.Contains(resultText(result), "Rejected by plugin audit: no deploys to prod") {
+		t.Errorf("deploy to prod = %q, want it rejected", resultText(result))
+	}
+
+	// Tools the plugin doesn't apply to are left alone.
+	build := withPlugins(handler, "build", "build", plugins)
+	if result, _ := build(context.Background(), request); resultText(result) != "deployed prod" {
+		t.Errorf("build = %q", resultText(result))
+	}
+
+	broken := withPlugins(handler, "deploy", "deploy", []Plugin{{Name: "broken", Command: []string{filepath.Join(t.TempDir(), "missing")}, Result: true}})
+	if result, _ := broken(context.Background(), request); !result.IsError || !strings.Contains(resultText(result), "plugin broken failed") {
+		t.Errorf("broken plugin = %q, want the call to fail", resultText(result))
+	}
+}
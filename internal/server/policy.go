@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// WithPolicies rejects task tool calls for which a policy's deny expression
+// holds. The policies must have been validated, see config.Config.Validate.
+func WithPolicies(policies []config.Policy) Option {
+	return func(s *settings) {
+		s.policies = policies
+	}
+}
+
+// deniedBy returns the first policy that rejects the call, if any.
+func (s *settings) deniedBy(ctx context.Context, request mcp.CallToolRequest, task inspector.TaskDefinition) (config.Policy, bool) {
+	lookup := func(name string) string {
+		switch {
+		case name == "task":
+			return task.Name
+		case name == "tool":
+			return request.Params.Name
+		case strings.HasPrefix(name, "args."):
+			value, ok := request.GetArguments()[strings.TrimPrefix(name, "args.")]
+			if !ok {
+				return ""
+			}
+			return fmt.Sprint(value)
+		}
+		value, _ := resolveInjectSource(ctx, request, name)
+		return value
+	}
+	for _, p := range s.policies {
+		if p.Expr != nil && p.Expr.Eval(lookup) {
+			s.logger(ctx).Warn("Tool call denied by policy", "policy", p.Name, "task", task.Name)
+			return p, true
+		}
+	}
+	return config.Policy{}, false
+}
+
+// policyDenial is the tool result for a call rejected by p.
+func policyDenial(p config.Policy) *mcp.CallToolResult {
+	text := fmt.Sprintf("Denied by policy %s", p.Name)
+	if p.Message != "" {
+		text += ": " + p.Message
+	}
+	return mcp.NewToolResultError(text)
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/policy"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestTaskHandlerPolicies(t *testing.T) {
+	expr, err := policy.Parse(`task matches "deploy*" && args.ENV == "prod" && tool != "release_deploy"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := newSettings([]Option{WithPolicies([]config.Policy{{Name: "prod-deploys", Deny: expr.String(), Message: "Ask a human.", Expr: expr}})})
+	cfg.taskBin = fakeTaskBin(t, "echo deployed\n")
+	handler := createTaskHandler("Taskfile.yml", t.TempDir(), inspector.TaskDefinition{Name: "deploy"}, cfg)
+
+	call := func(tool string, env string) *mcp.CallToolResult {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = tool
+		request.Params.Arguments = map[string]any{"ENV": env}
+		result, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	if result := call("deploy", "prod"); !result.IsError || resultText(result) != "Denied by policy prod-deploys: Ask a human." {
+		t.Errorf("prod deploy = %q, want denial", resultText(result))
+	}
+	if result := call("deploy", "staging"); result.IsError || !strings.HasPrefix(resultText(result), "deployed") {
+		t.Errorf("staging deploy = %q", resultText(result))
+	}
+	if result := call("release_deploy", "prod"); result.IsError {
+		t.Errorf("prod deploy via release_deploy = %q", resultText(result))
+	}
+}
+
+func TestScheduledRunPolicies(t *testing.T) {
+	expr, err := policy.Parse(`client.name == "untrusted"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := newSettings([]Option{WithPolicies([]config.Policy{{Name: "untrusted", Deny: expr.String(), Expr: expr}})})
+	cfg.taskBin = fakeTaskBin(t, "echo ran\n")
+	handler := createTaskHandler("Taskfile.yml", t.TempDir(), inspector.TaskDefinition{Name: "deploy"}, cfg)
+
+	owner := &scheduleOwner{Client: mcp.Implementation{Name: "untrusted"}}
+	result, _ := handler(context.WithValue(context.Background(), scheduleOwnerKey{}, owner), mcp.CallToolRequest{})
+	if !result.IsError || !strings.HasPrefix(resultText(result), "Denied by policy untrusted") {
+		t.Errorf("scheduled run of an untrusted client = %q, want denial", resultText(result))
+	}
+	owner.Client.Name = "editor"
+	if result, _ := handler(context.WithValue(context.Background(), scheduleOwnerKey{}, owner), mcp.CallToolRequest{}); result.IsError {
+		t.Errorf("scheduled run of another client = %q", resultText(result))
+	}
+}
+
+func TestScheduledCallerAttributes(t *testing.T) {
+	expr, err := policy.Parse(`meta.user == "intern" || session.id == "blocked"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := newSettings([]Option{WithPolicies([]config.Policy{{Name: "caller", Deny: expr.String(), Expr: expr}})})
+	cfg.taskBin = fakeTaskBin(t, "echo ran\n")
+	task := inspector.TaskDefinition{Name: "deploy"}
+	src := &loadedSource{cfg: cfg, taskfilePath: "Taskfile.yml", dir: t.TempDir()}
+	targets := map[string]taskTool{"deploy": {task: task, handler: createTaskHandler(src.taskfilePath, src.dir, task, cfg), check: src.checkCaller(task)}}
+	sched, err := newScheduler(filepath.Join(t.TempDir(), "schedules.json"), nil, targets, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := func(user string) *mcp.Meta {
+		return &mcp.Meta{AdditionalFields: map[string]any{"user": user}}
+	}
+
+	// Calls the client would be denied directly can't be scheduled.
+	if _, err := sched.add(sessionContext(cfg, "a"), meta("intern"), "deploy", "@daily", nil); err == nil || !strings.Contains(err.Error(), "Denied by policy caller") {
+		t.Errorf("scheduling with denied meta error = %v, want a denial", err)
+	}
+	if _, err := sched.add(sessionContext(cfg, "blocked"), nil, "deploy", "@daily", nil); err == nil || !strings.Contains(err.Error(), "Denied by policy caller") {
+		t.Errorf("scheduling from a denied session error = %v, want a denial", err)
+	}
+
+	// Runs see the meta and session the schedule was created with.
+	st, err := sched.add(sessionContext(cfg, "a"), meta("lead"), "deploy", "@daily", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Owner.Session != "a" || st.Owner.Meta["user"] != "lead" {
+		t.Errorf("schedule owner = %+v, want the caller's session and meta", st.Owner)
+	}
+	sched.run(context.Background(), *st)
+	if last := sched.list(st.Owner)[0].LastRun; last == nil || !last.Succeeded {
+		t.Errorf("scheduled run = %+v, want it allowed", last)
+	}
+	st.Owner.Meta["user"] = "intern"
+	sched.run(context.Background(), *st)
+	if last := sched.list(st.Owner)[0].LastRun; last == nil || last.Succeeded || !strings.Contains(last.Output, "Denied by policy caller") {
+		t.Errorf("scheduled run with denied meta = %+v, want a denial", last)
+	}
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"time"
+)
+
+// ServePprof serves the net/http/pprof profiles at /debug/pprof/ on addr
+// until ctx is cancelled. Profiles reveal the tasks and their arguments, so
+// addr should be a loopback address.
+func ServePprof(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	slog.Info("Serving profiles", "url", "http://"+addr+"/debug/pprof/")
+
+	return listenAndServe(ctx, srv)
+}
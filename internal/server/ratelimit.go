@@ -0,0 +1,63 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// WithRateLimit limits tool calls to perMinute per minute across all
+// clients, allowing bursts of up to perMinute calls. Zero disables it.
+func WithRateLimit(perMinute int) Option {
+	return func(s *settings) {
+		s.limiter.setLimit(perMinute)
+	}
+}
+
+// rateLimiter is a token bucket refilled at perMinute tokens per minute.
+type rateLimiter struct {
+	mu        sync.Mutex
+	perMinute int
+	tokens    float64
+	last      time.Time
+	now       func() time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{now: time.Now}
+}
+
+// setLimit changes the limit and starts over with a full bucket.
+func (l *rateLimiter) setLimit(perMinute int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.perMinute = perMinute
+	l.tokens = float64(perMinute)
+	l.last = l.now()
+}
+
+// limit returns the current limit.
+func (l *rateLimiter) limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.perMinute
+}
+
+// allow reports whether a call may proceed and, if so, uses up a token.
+func (l *rateLimiter) allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.perMinute <= 0 {
+		return true
+	}
+	now := l.now()
+	l.tokens += now.Sub(l.last).Minutes() * float64(l.perMinute)
+	if l.tokens > float64(l.perMinute) {
+		l.tokens = float64(l.perMinute)
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
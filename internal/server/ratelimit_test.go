@@ -0,0 +1,32 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter()
+	l.now = func() time.Time { return now }
+
+	if !l.allow() {
+		t.Fatal("allow() = false without a limit")
+	}
+
+	l.setLimit(2)
+	if !l.allow() || !l.allow() {
+		t.Fatal("allow() = false within the burst")
+	}
+	if l.allow() {
+		t.Fatal("allow() = true after the burst was used up")
+	}
+
+	now = now.Add(30 * time.Second)
+	if !l.allow() {
+		t.Fatal("allow() = false after a token was refilled")
+	}
+	if l.allow() {
+		t.Fatal("allow() = true with only one token refilled")
+	}
+}
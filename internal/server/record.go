@@ -0,0 +1,96 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// CallRecord is a recorded tool call, one JSON line of a recording.
+type CallRecord struct {
+	Time      time.Time      `json:"time"`
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	// Meta holds the request's _meta fields, which injected variables may
+	// be taken from.
+	Meta map[string]any `json:"meta,omitempty"`
+	// Session, Client and ClientVersion attribute the call to the client
+	// session that made it.
+	Session       string `json:"session,omitempty"`
+	Client        string `json:"client,omitempty"`
+	ClientVersion string `json:"client_version,omitempty"`
+	IsError       bool   `json:"is_error"`
+	Result        string `json:"result"`
+	DurationMS    int64  `json:"duration_ms"`
+}
+
+// Recorder writes the tool calls it observes as JSON lines, see
+// WithCallObserver.
+type Recorder struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+// NewRecorder returns a Recorder writing to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// Observe records call. Once a write failed, calls are no longer recorded;
+// Err returns the error.
+func (r *Recorder) Observe(call ToolCall) {
+	line, err := json.Marshal(CallRecord{
+		Time:          call.Time.UTC(),
+		Tool:          call.Tool,
+		Arguments:     call.Arguments,
+		Meta:          call.Meta,
+		Session:       call.Session,
+		Client:        call.Client,
+		ClientVersion: call.ClientVersion,
+		IsError:       call.IsError,
+		Result:        call.Result,
+		DurationMS:    call.Duration.Milliseconds(),
+	})
+	if err != nil {
+		// Arguments came from JSON, so this doesn't happen.
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		_, r.err = r.w.Write(append(line, '\n'))
+	}
+}
+
+// Err returns the error that stopped the recording, if any.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// ReadRecords reads a recording written by a Recorder.
+func ReadRecords(r io.Reader) ([]CallRecord, error) {
+	var records []CallRecord
+	scanner := bufio.NewScanner(r)
+	// Results can be long; saved output is capped by max_output.
+	scanner.Buffer(nil, 64<<20)
+	for n := 1; scanner.Scan(); n++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record CallRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if record.Tool == "" {
+			return nil, fmt.Errorf("line %d: no tool", n)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestRecorder(t *testing.T) {
+	cfg := newSettings(nil)
+	cfg.taskBin = fakeTaskBin(t, "echo \"ran $5\"\n")
+	var buf bytes.Buffer
+	recorder := NewRecorder(&buf)
+	handler := observeCalls(createTaskHandler("Taskfile.yml", t.TempDir(), inspector.TaskDefinition{Name: "build"}, cfg), recorder.Observe, cfg.sessions)
+	ctx := sessionContext(cfg, "s1")
+	cfg.sessions.open("s1", mcp.Implementation{Name: "cursor", Version: "1.2"})
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "build"
+	request.Params.Arguments = map[string]any{"TARGET": "linux"}
+	request.Params.Meta = &mcp.Meta{AdditionalFields: map[string]any{"user": "alice"}}
+	if _, err := handler(ctx, request); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	records, err := ReadRecords(&buf)
+	if err != nil {
+		t.Fatalf("ReadRecords() error = %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("ReadRecords() = %+v, want one call", records)
+	}
+	got := records[0]
+	if got.Tool != "build" || !reflect.DeepEqual(got.Arguments, request.Params.Arguments) || !reflect.DeepEqual(got.Meta, request.Params.Meta.AdditionalFields) ||
+		got.IsError || got.Result != "ran build\n" || got.Time.IsZero() || got.Session != "s1" || got.Client != "cursor" || got.ClientVersion != "1.2" {
+		t.Errorf("record = %+v", got)
+	}
+
+	if _, err := ReadRecords(bytes.NewBufferString("{\"tool\": \"build\"}\n\nnot json\n")); err == nil {
+		t.Error("ReadRecords() of a broken recording error = nil")
+	}
+}
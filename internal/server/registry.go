@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNotMounted is returned for names no bridge is mounted under.
+var ErrNotMounted = errors.New("not mounted")
+
+// Registry serves bridges that are mounted and unmounted while it runs, each
+// at /<name>/mcp. It backs `tmcp daemon`.
+type Registry struct {
+	hookToken string
+
+	mu      sync.RWMutex
+	entries map[string]*registryEntry
+}
+
+type registryEntry struct {
+	bridge  *Bridge
+	handler http.Handler
+	// stop ends the bridge's schedules.
+	stop context.CancelFunc
+}
+
+// NewRegistry returns an empty registry. A non-empty hookToken enables the
+// webhooks of every bridge, as for ServeHTTP.
+func NewRegistry(hookToken string) *Registry {
+	return &Registry{hookToken: hookToken, entries: make(map[string]*registryEntry)}
+}
+
+// Add mounts bridge under name and runs its schedules until it is removed.
+func (r *Registry) Add(name string, bridge *Bridge) error {
+	if name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("invalid mount name %q", name)
+	}
+	handler, err := newHTTPHandler([]Mount{{BasePath: name, Bridge: bridge}}, r.hookToken)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.entries[name]; ok {
+		return fmt.Errorf("%s is already mounted", name)
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	r.entries[name] = &registryEntry{bridge: bridge, handler: handler, stop: stop}
+	go bridge.RunSchedules(ctx)
+	return nil
+}
+
+// Remove unmounts the bridge under name and closes it. Sessions of its
+// clients end with it.
+func (r *Registry) Remove(name string) error {
+	r.mu.Lock()
+	e, ok := r.entries[name]
+	delete(r.entries, name)
+	r.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%s: %w", name, ErrNotMounted)
+	}
+	e.stop()
+	return e.bridge.Close()
+}
+
+// Mounts returns the mounted bridges, sorted by name.
+func (r *Registry) Mounts() []Mount {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	mounts := make([]Mount, 0, len(r.entries))
+	for name, e := range r.entries {
+		mounts = append(mounts, Mount{BasePath: name, Bridge: e.bridge})
+	}
+	sort.Slice(mounts, func(i, j int) bool { return mounts[i].BasePath < mounts[j].BasePath })
+	return mounts
+}
+
+// Close unmounts and closes every bridge.
+func (r *Registry) Close() {
+	for _, m := range r.Mounts() {
+		if err := r.Remove(m.BasePath); err != nil {
+			slog.Warn("Closing bridge failed", "server", m.BasePath, "error", err)
+		}
+	}
+}
+
+// ServeHTTP routes requests to the bridge named by the first path segment.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	name, _, _ := strings.Cut(strings.TrimPrefix(req.URL.Path, "/"), "/")
+	r.mu.RLock()
+	e, ok := r.entries[name]
+	r.mu.RUnlock()
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	e.handler.ServeHTTP(w, req)
+}
+
+// ServeRegistry serves the bridges of the registry over streamable HTTP
+// until ctx is cancelled, including those mounted later.
+func ServeRegistry(ctx context.Context, opts HTTPOptions, r *Registry) error {
+	srv := &http.Server{
+		Addr:              opts.Addr,
+		Handler:           r,
+		ReadHeaderTimeout: opts.HandshakeTimeout,
+		ReadTimeout:       opts.ReadTimeout,
+	}
+	listener, err := listen(opts.Addr, opts.SocketMode)
+	if err != nil {
+		return err
+	}
+	slog.Info("Serving MCP over HTTP", "url", ListenURL(opts.Addr)+"/<name>/mcp")
+	return serveUntilDone(ctx, srv, func() error { return srv.Serve(listener) })
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const initializeRequest = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","clientInfo":{"name":"test","version":"1.0.0"},"capabilities":{}}}`
+
+// postInitialize initializes a session at url and returns the status and
+// body of the response.
+func postInitialize(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", strings.NewReader(initializeRequest))
+	if err != nil {
+		t.Fatalf("POST %s error = %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry("")
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	if status, _ := postInitialize(t, ts.URL+"/api/mcp"); status != http.StatusNotFound {
+		t.Errorf("POST /api/mcp before mounting: status = %d, want 404", status)
+	}
+
+	for _, name := range []string{"api", "web"} {
+		if err := r.Add(name, &Bridge{mcp: server.NewMCPServer(name, "1.0.0")}); err != nil {
+			t.Fatalf("Add(%s) error = %v", name, err)
+		}
+	}
+	if err := r.Add("api", &Bridge{mcp: server.NewMCPServer("api", "1.0.0")}); err == nil {
+		t.Error("Add() error = nil for a name already mounted")
+	}
+	if err := r.Add("a/b", &Bridge{mcp: server.NewMCPServer("a", "1.0.0")}); err == nil {
+		t.Error("Add() error = nil for a name with a slash")
+	}
+	for _, name := range []string{"api", "web"} {
+		status, body := postInitialize(t, ts.URL+"/"+name+"/mcp")
+		if status != http.StatusOK || !strings.Contains(body, `"name":"`+name+`"`) {
+			t.Errorf("POST /%s/mcp = %d %s, want the %s server", name, status, body, name)
+		}
+	}
+	if mounts := r.Mounts(); len(mounts) != 2 || mounts[0].BasePath != "api" || mounts[1].BasePath != "web" {
+		t.Errorf("Mounts() = %+v, want api and web", mounts)
+	}
+
+	if err := r.Remove("api"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if status, _ := postInitialize(t, ts.URL+"/api/mcp"); status != http.StatusNotFound {
+		t.Errorf("POST /api/mcp after removing it: status = %d, want 404", status)
+	}
+	if err := r.Remove("api"); err == nil {
+		t.Error("Remove() error = nil for a name not mounted")
+	}
+
+	r.Close()
+	if len(r.Mounts()) != 0 {
+		t.Errorf("Mounts() after Close() = %+v, want none", r.Mounts())
+	}
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// unknownToolMetaKey is the _meta field describing a call to a tool whose
+// task no longer exists.
+const unknownToolMetaKey = "tmcp/unknown_tool"
+
+// unknownTool is the unknownToolMetaKey field of a result.
+type unknownTool struct {
+	Tool           string   `json:"tool"`
+	Task           string   `json:"task"`
+	Taskfile       string   `json:"taskfile"`
+	AvailableTools []string `json:"available_tools"`
+}
+
+// removedTools tracks the tasks removed from their Taskfile since the bridge
+// started. Their tools stay registered, so calls to them are answered with
+// the tools that are available instead of task's own error, but are no
+// longer listed.
+type removedTools struct {
+	s         *server.MCPServer
+	logOutput io.Writer
+
+	mu sync.Mutex
+	// tools holds the names of all task tools, and removed the task and
+	// Taskfile of those whose task was removed, by tool name.
+	tools   []string
+	removed map[string]unknownTool
+}
+
+func newRemovedTools(logOutput io.Writer) *removedTools {
+	return &removedTools{logOutput: logOutput, removed: make(map[string]unknownTool)}
+}
+
+// wrap returns the handler to register the tool of a task of l with. When
+// a call fails, it lists the Taskfile again and, if the task is gone, stops
+// listing the tool and notifies clients that the tool list changed. Calls
+// of a removed tool list the Taskfile again too, and bring the tool back
+// if the task is.
+func (r *removedTools) wrap(handler server.ToolHandlerFunc, tool string, task string, l *loadedSource) server.ToolHandlerFunc {
+	r.tools = append(r.tools, tool)
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if result := r.result(tool); result != nil {
+			if exists, err := taskExists(ctx, l, task); err != nil || !exists {
+				return result, nil
+			}
+			r.restore(tool, task, l.taskfilePath)
+		}
+		result, err := handler(ctx, request)
+		if err != nil || result == nil || !result.IsError || !taskRemoved(ctx, l, task) {
+			return result, err
+		}
+		r.remove(tool, task, l.taskfilePath)
+		return r.result(tool), nil
+	}
+}
+
+// taskRemoved reports whether task is no longer in l's Taskfile. Tasks of
+// sessions that moved to another Taskfile are never reported removed.
+func taskRemoved(ctx context.Context, l *loadedSource, task string) bool {
+	if taskfilePath, _, _, _ := l.cfg.sessionRun(ctx, l.taskfilePath, l.dir, inspector.TaskDefinition{Name: task}); taskfilePath != l.taskfilePath {
+		return false
+	}
+	exists, err := taskExists(ctx, l, task)
+	return err == nil && !exists
+}
+
+// taskExists reports whether task is in l's Taskfile, listing it again.
+func taskExists(ctx context.Context, l *loadedSource, task string) (bool, error) {
+	names, err := l.inspector.DiscoverTasks(ctx)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(names, task), nil
+}
+
+// remove marks tool removed and notifies clients, unless it already was.
+func (r *removedTools) remove(tool string, task string, taskfile string) {
+	r.mu.Lock()
+	_, ok := r.removed[tool]
+	if !ok {
+		r.removed[tool] = unknownTool{Tool: tool, Task: task, Taskfile: taskfile}
+	}
+	r.mu.Unlock()
+	if ok {
+		return
+	}
+	fmt.Fprintf(r.logOutput, "Task %q was removed from %s, no longer listing tool %q\n", task, taskfile, tool)
+	r.s.SendNotificationToAllClients(mcp.MethodNotificationToolsListChanged, nil)
+}
+
+// restore lists tool again and notifies clients, unless it already is.
+func (r *removedTools) restore(tool string, task string, taskfile string) {
+	r.mu.Lock()
+	_, ok := r.removed[tool]
+	delete(r.removed, tool)
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+	fmt.Fprintf(r.logOutput, "Task %q is back in %s, listing tool %q again\n", task, taskfile, tool)
+	r.s.SendNotificationToAllClients(mcp.MethodNotificationToolsListChanged, nil)
+}
+
+// result returns the result of a call to tool if it was removed, and nil
+// otherwise.
+func (r *removedTools) result(tool string) *mcp.CallToolResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	unknown, ok := r.removed[tool]
+	if !ok {
+		return nil
+	}
+	unknown.AvailableTools = []string{}
+	for _, name := range r.tools {
+		if _, removed := r.removed[name]; !removed {
+			unknown.AvailableTools = append(unknown.AvailableTools, name)
+		}
+	}
+	sort.Strings(unknown.AvailableTools)
+
+	text := fmt.Sprintf("Unknown tool %q: task %s no longer exists in %s.", tool, unknown.Task, filepath.Base(unknown.Taskfile))
+	if len(unknown.AvailableTools) > 0 {
+		text += " Available tools are: " + strings.Join(unknown.AvailableTools, ", ")
+	} else {
+		text += " No task tools are available."
+	}
+	result := mcp.NewToolResultError(text)
+	result.Meta = map[string]any{unknownToolMetaKey: unknown}
+	return result
+}
+
+// filter leaves the removed tools out of tools/list.
+func (r *removedTools) filter(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.removed) == 0 {
+		return tools
+	}
+	listed := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if _, ok := r.removed[tool.Name]; !ok {
+			listed = append(listed, tool)
+		}
+	}
+	return listed
+}
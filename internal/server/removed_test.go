@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// notifiedSession is a client session that keeps its notifications.
+type notifiedSession struct {
+	testSession
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s notifiedSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func TestRemovedTask(t *testing.T) {
+	dir := t.TempDir()
+	taskfile := filepath.Join(dir, "Taskfile.yml")
+	if err := os.WriteFile(taskfile, []byte("version: '3'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The listing is read from a file, so tests can remove tasks.
+	listing := filepath.Join(dir, "tasks.json")
+	writeListing := func(names ...string) {
+		var tasks []map[string]string
+		for _, name := range names {
+			tasks = append(tasks, map[string]string{"name": name})
+		}
+		data, _ := json.Marshal(map[string]any{"tasks": tasks})
+		if err := os.WriteFile(listing, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeListing("build", "lint", "test")
+	bin := fakeTaskBin(t, `case "$*" in
+*--list*) cat `+listing+` ;;
+*--summary*) echo "task: $1" ;;
+*) grep -q "\"$5\"" `+listing+` || { echo "task: Task \"$5\" does not exist" >&2; exit 200; }; [ "$5" != test ] || exit 1; echo "ran $5" ;;
+esac
+`)
+	bridge, err := New(context.Background(), taskfile, bin, "tasks", WithLogOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bridge.Close()
+	session := notifiedSession{testSession{id: "client"}, make(chan mcp.JSONRPCNotification, 10)}
+	if err := bridge.MCPServer().RegisterSession(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(tool string) *mcp.CallToolResult {
+		t.Helper()
+		request, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": map[string]any{"name": tool}})
+		response, _ := json.Marshal(bridge.MCPServer().HandleMessage(context.Background(), request))
+		var msg struct {
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(response, &msg); err != nil {
+			t.Fatal(err)
+		}
+		result, err := mcp.ParseCallToolResult(&msg.Result)
+		if err != nil {
+			t.Fatalf("call %s: %v in %s", tool, err, response)
+		}
+		return result
+	}
+
+	if result := call("lint"); result.IsError {
+		t.Fatalf("lint failed before being removed: %v", result.Content)
+	}
+	writeListing("build", "test")
+	for range 2 {
+		result := call("lint")
+		text := result.Content[0].(mcp.TextContent).Text
+		want := `Unknown tool "lint": task lint no longer exists in Taskfile.yml. Available tools are: build, test`
+		if !result.IsError || text != want {
+			t.Errorf("call to removed tool = %q, want %q", text, want)
+		}
+		meta, _ := json.Marshal(result.Meta)
+		if !strings.Contains(string(meta), `"available_tools":["build","test"]`) {
+			t.Errorf("_meta = %s, want the available tools", meta)
+		}
+	}
+
+	// Clients are told once, and no longer see the tool.
+	if len(session.notifications) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(session.notifications))
+	}
+	if n := <-session.notifications; n.Method != mcp.MethodNotificationToolsListChanged {
+		t.Errorf("notification = %s, want %s", n.Method, mcp.MethodNotificationToolsListChanged)
+	}
+	request, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "tools/list"})
+	response, _ := json.Marshal(bridge.MCPServer().HandleMessage(context.Background(), request))
+	if strings.Contains(string(response), `"lint"`) {
+		t.Errorf("tools/list = %s, want lint left out", response)
+	}
+
+	// Once the task is back, so is its tool.
+	writeListing("build", "lint", "test")
+	if result := call("lint"); result.IsError {
+		t.Errorf("call to restored tool = %v, want it run", result.Content)
+	}
+	if n := <-session.notifications; n.Method != mcp.MethodNotificationToolsListChanged {
+		t.Errorf("notification = %s, want %s", n.Method, mcp.MethodNotificationToolsListChanged)
+	}
+	response, _ = json.Marshal(bridge.MCPServer().HandleMessage(context.Background(), request))
+	if !strings.Contains(string(response), `"lint"`) {
+		t.Errorf("tools/list = %s, want lint listed again", response)
+	}
+
+	// Tasks that still exist fail as usual.
+	if result := call("test"); !result.IsError || strings.Contains(result.Content[0].(mcp.TextContent).Text, "Unknown tool") {
+		t.Errorf("failing call to test = %v, want the task's failure", result.Content)
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/runner"
+)
+
+// executionMetaKey is the _meta key under which failed tool results carry
+// the details of the execution for clients that process them.
+const executionMetaKey = "tmcp/execution"
+
+// execError describes a task execution that failed.
+type execError struct {
+	Command []string `json:"command"`
+	// ExitCode is -1 when task could not be started or was killed.
+	ExitCode int    `json:"exit_code"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	// Error is set when task could not be run at all.
+	Error string `json:"error,omitempty"`
+	// Attempts lists every run when the task was retried.
+	Attempts []attempt `json:"attempts,omitempty"`
+}
+
+func newExecError(command []string, err error, stdout string, stderr string) execError {
+	e := execError{Command: command, ExitCode: -1, Stdout: stdout, Stderr: stderr}
+	var exitErr *runner.ExitError
+	if errors.As(err, &exitErr) {
+		e.ExitCode = exitErr.Code
+	} else {
+		e.Error = err.Error()
+	}
+	return e
+}
+
+// redacted returns e with secrets masked in the command and output.
+func (e execError) redacted(secretEnv []string) execError {
+	command := make([]string, len(e.Command))
+	for i, arg := range e.Command {
+		command[i] = redact(arg, secretEnv)
+	}
+	e.Command = command
+	e.Stdout = redact(e.Stdout, secretEnv)
+	e.Stderr = redact(e.Stderr, secretEnv)
+	return e
+}
+
+// toolResult renders e as an error result. The text is meant for the
+// model; the same details are attached as _meta for programs.
+func (e execError) toolResult(task string) *mcp.CallToolResult {
+	var b strings.Builder
+	if e.Error != "" {
+		fmt.Fprintf(&b, "Task %s could not be run: %s\n", task, e.Error)
+	} else {
+		fmt.Fprintf(&b, "Task %s failed with exit code %d.\n", task, e.ExitCode)
+	}
+	fmt.Fprintf(&b, "Command: %s\n", quoteCommand(e.Command))
+	if len(e.Attempts) > 0 {
+		fmt.Fprintf(&b, "%s\n", describeAttempts(e.Attempts))
+	}
+	fmt.Fprintf(&b, "\nstdout:\n%s\n", orNone(e.Stdout))
+	fmt.Fprintf(&b, "\nstderr:\n%s", orNone(e.Stderr))
+
+	result := mcp.NewToolResultError(b.String())
+	result.Meta = map[string]any{executionMetaKey: e}
+	return result
+}
+
+func orNone(output string) string {
+	if strings.TrimSpace(output) == "" {
+		return "(none)"
+	}
+	return strings.TrimRight(output, "\n")
+}
+
+// quoteCommand joins command for display, quoting arguments with spaces or
+// quotes so it can be pasted into a shell.
+func quoteCommand(command []string) string {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
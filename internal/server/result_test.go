@@ -0,0 +1,281 @@
+package server
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/runner"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// fakeTaskBin writes a shell script standing in for the task binary.
+func fakeTaskBin(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "task")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTaskHandlerFailure(t *testing.T) {
+	cfg := newSettings([]Option{WithRedaction()})
+	cfg.taskBin = fakeTaskBin(t, "echo partial output; echo 'token=hunter22' >&2; exit 3\n")
+	dir := t.TempDir()
+	handler := createTaskHandler("Taskfile.yml", dir, inspector.TaskDefinition{Name: "build"}, cfg)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("handler error = %v", err)
+	}
+	if !result.IsError {
+		t.Fatal("IsError = false, want true")
+	}
+
+	text := resultText(result)
+	for _, want := range []string{"failed with exit code 3", "--taskfile Taskfile.yml --dir " + dir + " build", "partial output", "token=[REDACTED]"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("result text %q does not contain %q", text, want)
+		}
+	}
+
+	failure, ok := result.Meta[executionMetaKey].(execError)
+	if !ok {
+		t.Fatalf("_meta[%s] = %#v, want execError", executionMetaKey, result.Meta[executionMetaKey])
+	}
+	if failure.ExitCode != 3 || failure.Stdout != "partial output\n" || strings.Contains(failure.Stderr, "hunter22") {
+		t.Errorf("execution = %+v", failure)
+	}
+	if failure.Command[0] != cfg.taskBin || failure.Command[len(failure.Command)-1] != "build" {
+		t.Errorf("command = %v", failure.Command)
+	}
+}
+
+func TestTaskHandlerNotStarted(t *testing.T) {
+	cfg := newSettings(nil)
+	cfg.taskBin = filepath.Join(t.TempDir(), "missing")
+	handler := createTaskHandler("Taskfile.yml", t.TempDir(), inspector.TaskDefinition{Name: "build"}, cfg)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("handler error = %v", err)
+	}
+	failure := result.Meta[executionMetaKey].(execError)
+	if !result.IsError || failure.ExitCode != -1 || failure.Error == "" {
+		t.Errorf("result = %+v, execution = %+v", result, failure)
+	}
+}
+
+func TestTaskHandlerRunner(t *testing.T) {
+	var got []runner.Request
+	fake := runner.Func(func(_ context.Context, req runner.Request) error {
+		got = append(got, req)
+		req.Stdout.Write([]byte("half built\n"))
+		return &runner.ExitError{Code: 4}
+	})
+	cfg := newSettings([]Option{WithTaskRunner(fake)})
+	cfg.taskBin = "task"
+	dir := t.TempDir()
+	handler := createTaskHandler("Taskfile.yml", dir, inspector.TaskDefinition{Name: "build"}, cfg)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("handler error = %v", err)
+	}
+	if len(got) != 1 || got[0].Dir != dir {
+		t.Fatalf("runs = %+v, want one in %s", got, dir)
+	}
+	if want := "task --taskfile Taskfile.yml --dir " + dir + " build"; strings.Join(got[0].Command(), " ") != want {
+		t.Errorf("command = %q, want %q", got[0].Command(), want)
+	}
+	failure := result.Meta[executionMetaKey].(execError)
+	if !result.IsError || failure.ExitCode != 4 || failure.Stdout != "half built\n" {
+		t.Errorf("result = %+v, execution = %+v", result, failure)
+	}
+}
+
+func TestMakefileSource(t *testing.T) {
+	makefile := filepath.Join(t.TempDir(), "Makefile")
+	if err := os.WriteFile(makefile, []byte("ENV ?= dev\n\ndeploy: ## Deploy it\n\t./deploy.sh $(ENV)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var got runner.Request
+	fake := runner.Func(func(_ context.Context, req runner.Request) error {
+		got = req
+		return nil
+	})
+	bridge, err := New(context.Background(), makefile, "", "tasks", WithTaskRunner(fake), WithLogOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bridge.Close()
+	if tools := bridge.Tools(); len(tools) != 1 || tools[0].Name != "deploy" || tools[0].Description != "Deploy it" {
+		t.Fatalf("tools = %+v, want the deploy target", tools)
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"ENV": "prod"}
+	if _, err := bridge.taskTools["deploy"].handler(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	want := "make -f " + makefile + " -C " + filepath.Dir(makefile) + " --no-print-directory deploy ENV=prod"
+	if strings.Join(got.Command(), " ") != want {
+		t.Errorf("command = %q, want %q", got.Command(), want)
+	}
+}
+
+func TestPackageJSONSource(t *testing.T) {
+	dir := t.TempDir()
+	pkg := filepath.Join(dir, "package.json")
+	if err := os.WriteFile(pkg, []byte(`{"scripts": {"//test": "Run the tests", "test": "vitest run", "deploy": "./deploy.sh"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pnpm-lock.yaml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	fake := runner.Func(func(_ context.Context, req runner.Request) error {
+		got = req.Command()
+		return nil
+	})
+	bridge, err := New(context.Background(), pkg, "", "scripts", WithTaskRunner(fake), WithSafeMode([]string{"test"}), WithLogOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bridge.Close()
+	if tools := bridge.Tools(); len(tools) != 2 || tools[0].Description != "Run the tests" {
+		t.Fatalf("tools = %+v, want the test and deploy scripts", tools)
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"_cli_args": "--coverage"}
+	if _, err := bridge.taskTools["test"].handler(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	if want := "pnpm --dir " + dir + " run test --coverage"; strings.Join(got, " ") != want {
+		t.Errorf("command = %q, want %q", got, want)
+	}
+
+	// package managers can't dry-run, so safe mode runs nothing.
+	got = nil
+	result, err := bridge.taskTools["deploy"].handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("deploy ran %q in safe mode", got)
+	}
+	if text := resultText(result); !strings.Contains(text, "was not executed") || !strings.Contains(text, "pnpm --dir "+dir+" run deploy") {
+		t.Errorf("result = %q, want the command it would run", text)
+	}
+}
+
+func TestManifestSource(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "tools.yml")
+	data := `tools:
+  - name: repeat
+    description: Repeat a word
+    command: for i in $(seq {{.times}}); do printf %s {{.word}}; done{{if eq .newline "true"}}; echo{{end}}
+    params:
+      - {name: word}
+      - {name: times, type: integer, default: 2}
+      - {name: newline, type: boolean, default: false}
+`
+	if err := os.WriteFile(manifest, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bridge, err := New(context.Background(), manifest, "", "tools", WithStrictArguments(), WithLogOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bridge.Close()
+	tool := bridge.Tools()[0]
+	if !reflect.DeepEqual(tool.InputSchema.Required, []string{"word"}) {
+		t.Errorf("required = %v, want only word", tool.InputSchema.Required)
+	}
+	if times := tool.InputSchema.Properties["times"].(map[string]any); times["type"] != "integer" || times["default"] != 2 {
+		t.Errorf("schema of times = %v", times)
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"word": "a b", "times": float64(3), "newline": true}
+	result, err := bridge.taskTools["repeat"].handler(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resultText(result); got != "a ba ba b\n" {
+		t.Errorf("result = %q, want the word three times", got)
+	}
+}
+
+func TestComposedSources(t *testing.T) {
+	dir := t.TempDir()
+	makefile := filepath.Join(dir, "Makefile")
+	if err := os.WriteFile(makefile, []byte("build: ## Build it\n\tgo build\nclean:\n\trm -rf bin\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Named so that only Kind tells it's a manifest.
+	commands := filepath.Join(dir, "commands.yml")
+	if err := os.WriteFile(commands, []byte("tools:\n  - {name: build, command: echo built}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var bins []string
+	fake := runner.Func(func(_ context.Context, req runner.Request) error {
+		bins = append(bins, req.Bin)
+		return nil
+	})
+	sources := []Source{
+		{Taskfile: makefile, Options: []Option{WithToolPrefix("make_"), WithTaskFilter(nil, []string{"clean"})}},
+		{Taskfile: commands, Kind: inspector.Manifest},
+	}
+	bridge, err := NewWorkspace(context.Background(), "all", sources, WithTaskRunner(fake), WithLogOutput(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bridge.Close()
+	var tools []string
+	for _, name := range bridge.ToolNames() {
+		tools = append(tools, name.Tool+":"+name.Source.Name())
+	}
+	if got := strings.Join(tools, " "); got != "make_build:make build:tools" {
+		t.Fatalf("tools = %s", got)
+	}
+	for _, tool := range []string{"make_build", "build"} {
+		if _, err := bridge.taskTools[tool].handler(context.Background(), mcp.CallToolRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := strings.Join(bins, " "); got != "make sh" {
+		t.Errorf("ran %s, want make then sh", got)
+	}
+}
+
+// BenchmarkTaskHandler measures the overhead of a tool call without the
+// task itself, which a fake runner stands in for.
+func BenchmarkTaskHandler(b *testing.B) {
+	fake := runner.Func(func(_ context.Context, req runner.Request) error {
+		req.Stdout.Write([]byte("built\n"))
+		return nil
+	})
+	cfg := newSettings([]Option{WithTaskRunner(fake), WithLogOutput(io.Discard)})
+	cfg.taskBin = "task"
+	task := inspector.TaskDefinition{Name: "build", Parameters: []inspector.TaskParameter{{Name: "TARGET"}}}
+	handler := createTaskHandler("Taskfile.yml", b.TempDir(), task, cfg)
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"TARGET": "linux"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		result, err := handler(context.Background(), request)
+		if err != nil || result.IsError {
+			b.Fatalf("handler() = %v, %v", result, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sandwichlabs/mcp-task-bridge/internal/runner"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// maxRetryBackoff caps the wait between two attempts.
+const maxRetryBackoff = time.Minute
+
+// attemptsMetaKey is the _meta key under which retried tool results list
+// their attempts.
+const attemptsMetaKey = "tmcp/attempts"
+
+// WithRetries retries failed runs of the tasks matching a pattern, unless
+// the task sets its own x-mcp retry policy. When several patterns match,
+// the first in sorted order wins.
+func WithRetries(policies map[string]inspector.RetryPolicy) Option {
+	return func(s *settings) {
+		s.retries = policies
+	}
+}
+
+// retryPolicy returns the policy for task; a single attempt when it has none.
+func (s *settings) retryPolicy(task inspector.TaskDefinition) inspector.RetryPolicy {
+	if task.Retry != nil {
+		return *task.Retry
+	}
+	patterns := make([]string, 0, len(s.retries))
+	for pattern := range s.retries {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, task.Name); ok {
+			return s.retries[pattern]
+		}
+	}
+	return inspector.RetryPolicy{Attempts: 1}
+}
+
+// retryDelay is the wait before retry n (starting at 1) under policy.
+func retryDelay(policy inspector.RetryPolicy, n int) time.Duration {
+	delay := policy.Backoff
+	for i := 1; i < n && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryBackoff)
+}
+
+// sleep waits for d and reports whether it did so before ctx was cancelled.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// retryable reports whether a failed run is worth repeating. A task binary
+// that can't be started won't start on the next attempt either.
+func retryable(err error) bool {
+	var exitErr *runner.ExitError
+	return errors.As(err, &exitErr)
+}
+
+// attempt is one run of a task with a retry policy.
+type attempt struct {
+	ExitCode   int   `json:"exit_code"`
+	DurationMS int64 `json:"duration_ms"`
+}
+
+func newAttempt(err error, d time.Duration) attempt {
+	return attempt{ExitCode: runner.ExitCode(err), DurationMS: d.Milliseconds()}
+}
+
+// describeAttempts summarizes the attempts of a task for the model.
+func describeAttempts(attempts []attempt) string {
+	parts := make([]string, len(attempts))
+	for i, a := range attempts {
+		status := "succeeded"
+		if a.ExitCode != 0 {
+			status = fmt.Sprintf("exit code %d", a.ExitCode)
+		}
+		parts[i] = fmt.Sprintf("#%d %s (%s)", i+1, status, time.Duration(a.DurationMS)*time.Millisecond)
+	}
+	return fmt.Sprintf("Attempts: %s", strings.Join(parts, ", "))
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestRetryDelay(t *testing.T) {
+	policy := inspector.RetryPolicy{Attempts: 10, Backoff: 10 * time.Second}
+	want := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}
+	for i, w := range want {
+		if got := retryDelay(policy, i+1); got != w {
+			t.Errorf("retryDelay(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestRetryPolicyPrecedence(t *testing.T) {
+	cfg := newSettings([]Option{WithRetries(map[string]inspector.RetryPolicy{
+		"net:*": {Attempts: 3},
+		"n*":    {Attempts: 2},
+	})})
+	own := &inspector.RetryPolicy{Attempts: 5}
+
+	tests := []struct {
+		task inspector.TaskDefinition
+		want int
+	}{
+		{inspector.TaskDefinition{Name: "net:fetch", Retry: own}, 5},
+		{inspector.TaskDefinition{Name: "net:fetch"}, 2},
+		{inspector.TaskDefinition{Name: "build:app"}, 1},
+	}
+	for _, tt := range tests {
+		if got := cfg.retryPolicy(tt.task).Attempts; got != tt.want {
+			t.Errorf("retryPolicy(%s).Attempts = %d, want %d", tt.task.Name, got, tt.want)
+		}
+	}
+}
+
+func TestTaskHandlerRetries(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "runs")
+	cfg := newSettings(nil)
+	// Fails twice, then succeeds.
+	cfg.taskBin = fakeTaskBin(t, `echo x >> `+counter+`
+if [ $(wc -l < `+counter+`) -lt 3 ]; then echo flaky >&2; exit 1; fi
+echo fetched
+`)
+	task := inspector.TaskDefinition{Name: "fetch", Retry: &inspector.RetryPolicy{Attempts: 3, Backoff: time.Millisecond}}
+	handler := createTaskHandler("Taskfile.yml", t.TempDir(), task, cfg)
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("handler error = %v", err)
+	}
+	text := resultText(result)
+	if result.IsError || !strings.HasPrefix(text, "fetched") || !strings.Contains(text, "#1 exit code 1") || !strings.Contains(text, "#3 succeeded") {
+		t.Errorf("result = %q, IsError %v", text, result.IsError)
+	}
+	if attempts := result.Meta[attemptsMetaKey].([]attempt); len(attempts) != 3 {
+		t.Errorf("attempts = %+v, want 3", attempts)
+	}
+
+	// Out of attempts: the failure reports all of them.
+	task.Retry.Attempts = 2
+	cfg.taskBin = fakeTaskBin(t, "exit 4\n")
+	handler = createTaskHandler("Taskfile.yml", t.TempDir(), task, cfg)
+	result, _ = handler(context.Background(), mcp.CallToolRequest{})
+	if failure := result.Meta[executionMetaKey].(execError); !result.IsError || len(failure.Attempts) != 2 {
+		t.Errorf("failure = %+v", failure)
+	}
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// rootsTimeout is how long the client gets to list its roots.
+const rootsTimeout = 30 * time.Second
+
+// clientRoots are the directories the stdio client declared as MCP roots,
+// the parts of the file system its user shares with the server. Tool calls
+// that run in or name paths outside them are denied.
+type clientRoots struct {
+	mu sync.RWMutex
+	// known is set once the client has listed its roots; clients without
+	// roots don't restrict calls.
+	known bool
+	dirs  []string
+}
+
+func newClientRoots() *clientRoots {
+	return &clientRoots{}
+}
+
+// register fetches the roots of clients that declare them when they
+// initialize, and again when they change.
+func (r *clientRoots) register(peer *clientPeer) {
+	fetch := func() {
+		if !peer.supports("roots") {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), rootsTimeout)
+		defer cancel()
+		if err := r.fetch(ctx, peer); err != nil {
+			slog.Warn("Could not list the client's roots", "error", err)
+		}
+	}
+	peer.on("notifications/initialized", fetch)
+	peer.on("notifications/roots/list_changed", fetch)
+}
+
+// fetch replaces the roots with those the client lists.
+func (r *clientRoots) fetch(ctx context.Context, peer *clientPeer) error {
+	var result struct {
+		Roots []mcp.Root `json:"roots"`
+	}
+	if err := peer.request(ctx, "roots/list", map[string]any{}, &result); err != nil {
+		return err
+	}
+	var dirs []string
+	for _, root := range result.Roots {
+		u, err := url.Parse(root.URI)
+		if err != nil || u.Scheme != "file" {
+			slog.Warn("Ignoring a client root that isn't a directory", "uri", root.URI)
+			continue
+		}
+		dirs = append(dirs, resolvePath(filepath.FromSlash(u.Path)))
+	}
+	r.set(dirs)
+	slog.Info("Client roots", "roots", dirs)
+	return nil
+}
+
+func (r *clientRoots) set(dirs []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.known = true
+	r.dirs = dirs
+}
+
+// callerRoots returns the roots a call from ctx is held to, and false if it
+// isn't held to any: those stored with the schedule for scheduled runs, and
+// those the stdio client declared otherwise.
+func (s *settings) callerRoots(ctx context.Context) ([]string, bool) {
+	if owner, ok := scheduleOwnerFrom(ctx); ok {
+		return owner.Roots, owner.Roots != nil
+	}
+	if s.peerFor(ctx) == nil {
+		return nil, false
+	}
+	s.roots.mu.RLock()
+	defer s.roots.mu.RUnlock()
+	return s.roots.dirs, s.roots.known
+}
+
+// checkRoots denies a call of task from the stdio client, or a scheduled
+// run, that runs in dir or names a path in args outside the caller's roots.
+// The directory of the Taskfile counts as a root, since its tasks are what
+// the client was given.
+func (s *settings) checkRoots(ctx context.Context, taskfilePath string, dir string, task inspector.TaskDefinition, args map[string]any) *mcp.CallToolResult {
+	dirs, known := s.callerRoots(ctx)
+	if !known {
+		return nil
+	}
+	if abs, err := filepath.Abs(filepath.Dir(taskfilePath)); err == nil {
+		dirs = append(dirs[:len(dirs):len(dirs)], resolvePath(abs))
+	}
+	if task.Dir != "" {
+		dir = task.Dir
+	}
+	paths := append([]string{dir}, argumentPaths(task, args)...)
+	for _, path := range paths {
+		target := path
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(dir, target)
+		}
+		if !withinRoots(resolvePath(target), dirs) {
+			return mcp.NewToolResultError(fmt.Sprintf("Denied: %s is outside the client's roots (%s)", path, strings.Join(dirs, ", ")))
+		}
+	}
+	return nil
+}
+
+// argumentPaths returns the arguments of a call that look like file paths:
+// file URIs, . and .., and those starting with ~ or holding a path
+// separator, such as build/../../etc/passwd, also as words of _cli_args and
+// items of lists. Input piped to stdin isn't checked.
+func argumentPaths(task inspector.TaskDefinition, args map[string]any) []string {
+	var paths []string
+	add := func(value any) {
+		s, ok := value.(string)
+		if !ok {
+			return
+		}
+		if u, err := url.Parse(s); err == nil && u.Scheme == "file" {
+			paths = append(paths, filepath.FromSlash(u.Path))
+			return
+		}
+		if home, err := os.UserHomeDir(); err == nil && (s == "~" || strings.HasPrefix(s, "~/")) {
+			s = filepath.Join(home, strings.TrimPrefix(s, "~"))
+		}
+		if filepath.IsAbs(s) || s == "." || s == ".." || strings.ContainsAny(s, "/"+string(filepath.Separator)) {
+			paths = append(paths, s)
+		}
+	}
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch {
+		case isStdinArgument(task, name):
+		case name == cliArgsArgument:
+			words, _ := cliArgs(args)
+			for _, word := range words {
+				// --file=/etc/passwd
+				if _, value, ok := strings.Cut(word, "="); ok && strings.HasPrefix(word, "-") {
+					word = value
+				}
+				add(word)
+			}
+		default:
+			if list, ok := args[name].([]any); ok {
+				for _, item := range list {
+					add(item)
+				}
+				continue
+			}
+			add(args[name])
+		}
+	}
+	return paths
+}
+
+// withinRoots reports whether path is one of dirs or inside one.
+func withinRoots(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePath cleans path and resolves its symlinks, so a link can't lead
+// out of a root. Paths that don't exist yet are resolved as far as they do.
+func resolvePath(path string) string {
+	path = filepath.Clean(path)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path
+	}
+	return filepath.Join(resolvePath(parent), filepath.Base(path))
+}
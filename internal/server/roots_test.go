@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestClientRoots(t *testing.T) {
+	root := t.TempDir()
+	project := filepath.Join(root, "project")
+	outside := filepath.Join(root, "outside")
+	for _, dir := range []string{project, outside} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(project, "link")); err != nil {
+		t.Fatal(err)
+	}
+	taskfiles := t.TempDir()
+
+	cfg := newSettings(nil)
+	cfg.taskBin = fakeTaskBin(t, `echo ran`)
+	cfg.roots.register(cfg.peer)
+	requests, answers := stdioClient(t, cfg, `{"roots":{"listChanged":true}}`)
+	if _, err := io.WriteString(answers, `{"jsonrpc":"2.0","method":"notifications/initialized"}`+"\n"); err != nil {
+		t.Fatal(err)
+	}
+	line, err := requests.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var asked struct {
+		ID     string `json:"id"`
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal([]byte(line), &asked); err != nil || asked.Method != "roots/list" {
+		t.Fatalf("request = %s, %v", line, err)
+	}
+	roots, _ := json.Marshal(map[string]any{"roots": []mcp.Root{{URI: "file://" + filepath.ToSlash(project), Name: "project"}, {URI: "https://example.com/"}}})
+	if _, err := io.WriteString(answers, `{"jsonrpc":"2.0","id":"`+asked.ID+`","result":`+string(roots)+"}\n"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		cfg.roots.mu.RLock()
+		known := cfg.roots.known
+		cfg.roots.mu.RUnlock()
+		if known {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("roots were never listed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	task := inspector.TaskDefinition{Name: "lint", Parameters: []inspector.TaskParameter{{Name: "FILE"}, {Name: "FILES", Type: "array"}}}
+	for _, tc := range []struct {
+		name   string
+		dir    string
+		args   map[string]any
+		denied string
+	}{
+		{"inside", project, map[string]any{"FILE": "./main.go", "FILES": []any{project + "/a.go"}}, ""},
+		{"plain words", project, map[string]any{"FILE": "main.go"}, ""},
+		{"taskfile directory", taskfiles, map[string]any{"FILE": filepath.Join(taskfiles, "x")}, ""},
+		{"directory outside", outside, nil, outside},
+		{"absolute argument", project, map[string]any{"FILE": "/etc/passwd"}, "/etc/passwd"},
+		{"relative argument", project, map[string]any{"FILE": "../outside/x"}, "../outside/x"},
+		{"nested relative argument", project, map[string]any{"FILE": "cmd/../main.go"}, ""},
+		{"dotdot in the middle", project, map[string]any{"FILE": "build/../../outside/x"}, "build/../../outside/x"},
+		{"file uri", project, map[string]any{"FILE": "file:///etc/hosts"}, "/etc/hosts"},
+		{"list item", project, map[string]any{"FILES": []any{"./a.go", "/etc/hosts"}}, "/etc/hosts"},
+		{"cli args", project, map[string]any{cliArgsArgument: "-v --config=/etc/app.yml"}, "/etc/app.yml"},
+		{"symlink", project, map[string]any{"FILE": "./link/secret"}, "link/secret"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := createTaskHandler(filepath.Join(taskfiles, "Taskfile.yml"), tc.dir, task, cfg)
+			request := mcp.CallToolRequest{}
+			request.Params.Name = "lint"
+			request.Params.Arguments = tc.args
+			result, err := handler(sessionContext(cfg, stdioSessionID), request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			text := resultText(result)
+			if tc.denied == "" {
+				if result.IsError {
+					t.Errorf("result = %q, want the call allowed", text)
+				}
+				return
+			}
+			if !result.IsError || !strings.HasPrefix(text, "Denied: ") || !strings.Contains(text, tc.denied) || !strings.Contains(text, project) {
+				t.Errorf("result = %q, want %s denied", text, tc.denied)
+			}
+		})
+	}
+
+	// Schedules are held to the roots of the client that created them, when
+	// they are created and when they run.
+	src := &loadedSource{cfg: cfg, taskfilePath: filepath.Join(taskfiles, "Taskfile.yml"), dir: project}
+	targets := map[string]taskTool{"lint": {task: task, handler: createTaskHandler(src.taskfilePath, project, task, cfg), check: src.checkCaller(task)}}
+	sched, err := newScheduler(filepath.Join(t.TempDir(), "schedules.json"), nil, targets, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdio := sessionContext(cfg, stdioSessionID)
+	if _, err := sched.add(stdio, nil, "lint", "@daily", map[string]any{"FILE": "/etc/passwd"}); err == nil || !strings.Contains(err.Error(), "outside the client's roots") {
+		t.Errorf("scheduling outside the roots error = %v, want a denial", err)
+	}
+	st, err := sched.add(stdio, nil, "lint", "@daily", map[string]any{"FILE": "./main.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Owner == nil || len(st.Owner.Roots) != 1 || st.Owner.Roots[0] != resolvePath(project) {
+		t.Errorf("schedule owner = %+v, want the client's roots", st.Owner)
+	}
+	st.Arguments = map[string]any{"FILE": "/etc/passwd"}
+	sched.run(context.Background(), *st)
+	if last := sched.list(st.Owner)[0].LastRun; last == nil || last.Succeeded || !strings.Contains(last.Output, "Denied: /etc/passwd") {
+		t.Errorf("scheduled run outside the roots = %+v, want a denial", last)
+	}
+
+	// Calls from other clients aren't restricted.
+	handler := createTaskHandler(filepath.Join(taskfiles, "Taskfile.yml"), outside, task, cfg)
+	result, err := handler(sessionContext(cfg, "http-session"), mcp.CallToolRequest{})
+	if err != nil || result.IsError {
+		t.Errorf("result = %q, %v, want an HTTP call allowed", resultText(result), err)
+	}
+}
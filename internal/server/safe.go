@@ -0,0 +1,193 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// WithSafeMode enables every safeguard at once: strict argument checks, a
+// clean environment, output redaction, and dry runs for any task that is
+// neither marked read-only in the Taskfile nor matched by allow.
+func WithSafeMode(allow []string) Option {
+	return func(s *settings) {
+		s.strict = true
+		s.cleanEnv = true
+		s.redact = true
+		s.dryRun = true
+		s.allow = allow
+	}
+}
+
+// WithStrictArguments rejects tool calls with unknown, missing or
+// non-scalar arguments instead of passing them on to task.
+func WithStrictArguments() Option {
+	return func(s *settings) {
+		s.strict = true
+	}
+}
+
+// WithCleanEnv runs tasks with a minimal environment instead of inheriting
+// the tmcp process environment. Variables set with WithEnv and WithSecrets
+// are still passed.
+func WithCleanEnv() Option {
+	return func(s *settings) {
+		s.cleanEnv = true
+	}
+}
+
+// WithPassEnv runs tasks with a minimal environment, as WithCleanEnv, plus
+// the named variables of the tmcp process environment.
+func WithPassEnv(names []string) Option {
+	return func(s *settings) {
+		s.cleanEnv = true
+		s.passEnv = names
+	}
+}
+
+// WithDryRunUnless runs tasks with task's --dry flag unless they are marked
+// read-only in the Taskfile or match one of the allow patterns. Arguments are
+// checked as with WithStrictArguments, so they can't turn the dry run off.
+func WithDryRunUnless(allow []string) Option {
+	return func(s *settings) {
+		s.strict = true
+		s.dryRun = true
+		s.allow = allow
+	}
+}
+
+// WithRedaction masks secret values and common credential patterns in task
+// output before it is returned to the client.
+func WithRedaction() Option {
+	return func(s *settings) {
+		s.redact = true
+	}
+}
+
+// checkArguments validates model arguments against the task's declared
+// parameters. Injected parameters are supplied by the server and skipped.
+func checkArguments(task inspector.TaskDefinition, args map[string]any, inject map[string]string) error {
+	declared := map[string]bool{stdinArgument: true, cliArgsArgument: true}
+	arrays := map[string]bool{}
+	if task.Stdin != "" {
+		declared[task.Stdin] = true
+	}
+	for _, param := range task.Parameters {
+		if _, ok := inject[param.Name]; ok {
+			continue
+		}
+		declared[param.Name] = true
+		arrays[param.Name] = param.Type == "array"
+		if value, ok := args[param.Name]; (!ok || value == nil) && param.Default == nil {
+			return fmt.Errorf("missing required argument %q", param.Name)
+		}
+	}
+
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !declared[name] {
+			return fmt.Errorf("unknown argument %q", name)
+		}
+		// The items of lists are checked when they are joined.
+		if _, ok := args[name].([]any); ok && arrays[name] {
+			continue
+		}
+		switch args[name].(type) {
+		case string, bool, float64, nil:
+		default:
+			return fmt.Errorf("argument %q must be a string, number or boolean", name)
+		}
+	}
+	return nil
+}
+
+// strictArguments reports whether tool calls must pass checkArguments.
+func (s *settings) strictArguments() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.strict
+}
+
+// shouldDryRun reports whether task must run with --dry.
+func (s *settings) shouldDryRun(task inspector.TaskDefinition) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dryRun && !task.ReadOnly && !matchesAny(task.Name, s.allow)
+}
+
+// cleanEnvKeys are the variables kept from the tmcp environment in clean
+// env mode; task and most shells need them to work at all.
+var cleanEnvKeys = []string{"PATH", "HOME", "USER", "LOGNAME", "SHELL", "LANG", "LC_ALL", "TMPDIR", "TERM"}
+
+// cleanEnvKeysWindows are additionally kept on Windows.
+var cleanEnvKeysWindows = []string{"SystemRoot", "ComSpec", "PATHEXT", "TEMP", "TMP"}
+
+// baseEnv returns the minimal environment for clean env mode.
+func baseEnv() []string {
+	keys := cleanEnvKeys
+	if runtime.GOOS == "windows" {
+		keys = append(append([]string{}, keys...), cleanEnvKeysWindows...)
+	}
+	var env []string
+	for _, key := range keys {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, key+"="+value)
+		}
+	}
+	return env
+}
+
+// passedEnv returns the named variables of the tmcp environment that are
+// set, as KEY=VALUE pairs.
+func passedEnv(names []string) []string {
+	var env []string
+	for _, name := range names {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+// credentialPatterns match credentials that commonly leak into command
+// output. The first submatch, if any, is kept so the context stays readable.
+var credentialPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`),
+	regexp.MustCompile(`(?i)\b((?:password|passwd|secret|token|api[_-]?key)\s*[=:]\s*)[^\s"']+`),
+}
+
+// redactionMask replaces redacted values.
+const redactionMask = "[REDACTED]"
+
+// redact masks the given secret values and anything matching
+// credentialPatterns in output.
+func redact(output string, secretEnv []string) string {
+	for _, entry := range secretEnv {
+		_, value, _ := strings.Cut(entry, "=")
+		// Very short values would mask unrelated text.
+		if len(value) < 4 {
+			continue
+		}
+		output = strings.ReplaceAll(output, value, redactionMask)
+	}
+	for _, pattern := range credentialPatterns {
+		if pattern.NumSubexp() > 0 {
+			output = pattern.ReplaceAllString(output, "${1}"+redactionMask)
+		} else {
+			output = pattern.ReplaceAllString(output, redactionMask)
+		}
+	}
+	return output
+}
@@ -0,0 +1,444 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/cron"
+)
+
+// schedulesMetaKey is the _meta key under which schedules_list returns the
+// schedules.
+const schedulesMetaKey = "tmcp/schedules"
+
+// scheduleTick is how often due schedules are looked for.
+const scheduleTick = time.Second
+
+// maxScheduledOutput caps the output kept from the last run of a schedule.
+const maxScheduledOutput = 4096
+
+// WithSchedules lets clients schedule tasks on cron expressions with the
+// schedule_task, schedules_list and schedules_cancel tools. Schedules are
+// saved to file so they survive restarts, and run while RunSchedules does.
+// Only tasks matching one of the allow patterns may be scheduled, or every
+// task when allow is empty.
+func WithSchedules(file string, allow []string) Option {
+	return func(s *settings) {
+		s.scheduleFile = file
+		s.scheduleAllow = allow
+	}
+}
+
+// scheduledTask is a task an agent scheduled.
+type scheduledTask struct {
+	ID        string         `json:"id"`
+	Tool      string         `json:"tool"`
+	Cron      string         `json:"cron"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	NextRun   time.Time      `json:"next_run"`
+	LastRun   *scheduledRun  `json:"last_run,omitempty"`
+	// Owner is the client that created the schedule. Its runs are held to
+	// the same policies and roots as the client's own calls.
+	Owner *scheduleOwner `json:"owner,omitempty"`
+
+	spec cron.Schedule
+}
+
+// scheduleOwner is the client that created a schedule, as far as checks of
+// its calls depend on it.
+type scheduleOwner struct {
+	Client mcp.Implementation `json:"client"`
+	// Roots are the roots the client declared, or nil if it declared none.
+	// An empty list still restricts runs to the Taskfile's directory.
+	Roots []string `json:"roots"`
+	// Session is the ID of the session that created the schedule, and Meta
+	// the _meta of its schedule_task call. Runs are checked and injected
+	// with them as if the client had made the call itself.
+	Session string         `json:"session,omitempty"`
+	Meta    map[string]any `json:"meta,omitempty"`
+}
+
+// owns reports whether o is the client of other, which may see and cancel
+// o's schedules: the same client with the same roots. Schedules saved
+// without an owner belong to no client.
+func (o *scheduleOwner) owns(other *scheduleOwner) bool {
+	if o == nil || other == nil {
+		return false
+	}
+	return o.Client == other.Client && (o.Roots == nil) == (other.Roots == nil) && slices.Equal(o.Roots, other.Roots)
+}
+
+// request returns the request of a call of tool with args made by o.
+func (o *scheduleOwner) request(tool string, args map[string]any) mcp.CallToolRequest {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = tool
+	request.Params.Arguments = args
+	if o != nil && len(o.Meta) > 0 {
+		request.Params.Meta = &mcp.Meta{AdditionalFields: o.Meta}
+	}
+	return request
+}
+
+// scheduleOwnerKey is the context key of the owner of a scheduled run.
+type scheduleOwnerKey struct{}
+
+// scheduleOwnerFrom returns the owner of the scheduled run of ctx, if it is
+// one.
+func scheduleOwnerFrom(ctx context.Context) (*scheduleOwner, bool) {
+	owner, ok := ctx.Value(scheduleOwnerKey{}).(*scheduleOwner)
+	return owner, ok && owner != nil
+}
+
+// scheduledRun is the outcome of a scheduled run.
+type scheduledRun struct {
+	StartedAt time.Time `json:"started_at"`
+	Succeeded bool      `json:"succeeded"`
+	// Output is the end of the result text, at most maxScheduledOutput bytes.
+	Output string `json:"output"`
+}
+
+// scheduleState is the content of the schedules file.
+type scheduleState struct {
+	// Next is the number of the last schedule ID handed out.
+	Next      int              `json:"next"`
+	Schedules []*scheduledTask `json:"schedules"`
+}
+
+// scheduler runs scheduled tool calls and persists them.
+type scheduler struct {
+	path    string
+	allow   []string
+	targets map[string]taskTool
+	// cfg provides the roots of the clients that create schedules.
+	cfg *settings
+	// now is a field so tests can move time forward.
+	now func() time.Time
+
+	mu    sync.Mutex
+	state scheduleState
+}
+
+// newScheduler loads the schedules saved at path, if any. Runs missed while
+// tmcp wasn't running are skipped.
+func newScheduler(path string, allow []string, targets map[string]taskTool, cfg *settings) (*scheduler, error) {
+	s := &scheduler{path: path, allow: allow, targets: targets, cfg: cfg, now: time.Now}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading schedules: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return nil, fmt.Errorf("parsing schedules %s: %w", path, err)
+	}
+	now := s.now()
+	for _, st := range s.state.Schedules {
+		if st.spec, err = cron.Parse(st.Cron); err != nil {
+			return nil, fmt.Errorf("schedules %s: %s: %w", path, st.ID, err)
+		}
+		st.NextRun = st.spec.Next(now)
+		if _, ok := targets[st.Tool]; !ok {
+			slog.Warn("Scheduled tool no longer exists", "schedule", st.ID, "tool", st.Tool)
+		}
+	}
+	return s, nil
+}
+
+// save writes the schedules to disk. s.mu must be held.
+func (s *scheduler) save() error {
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// add validates and saves a new schedule for the client of ctx, which sent
+// meta with its request. The call must pass the client's policies and roots
+// now, and its runs are checked against them again.
+func (s *scheduler) add(ctx context.Context, meta *mcp.Meta, tool string, expr string, args map[string]any) (*scheduledTask, error) {
+	target, ok := s.targets[tool]
+	if !ok {
+		return nil, fmt.Errorf("unknown tool %q", tool)
+	}
+	if len(s.allow) > 0 && !matchesAny(target.task.Name, s.allow) {
+		return nil, fmt.Errorf("task %s may not be scheduled", target.task.Name)
+	}
+	spec, err := cron.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range args {
+		switch value.(type) {
+		case string, bool, float64:
+		default:
+			return nil, fmt.Errorf("argument %q must be a string, number or boolean", name)
+		}
+	}
+	now := s.now()
+	next := spec.Next(now)
+	if next.IsZero() {
+		return nil, fmt.Errorf("cron expression %q never matches", expr)
+	}
+	owner := s.owner(ctx)
+	owner.Session = callerSession(ctx)
+	if meta != nil && len(meta.AdditionalFields) > 0 {
+		owner.Meta = meta.AdditionalFields
+	}
+	if target.check != nil {
+		if denied := target.check(context.WithValue(ctx, scheduleOwnerKey{}, owner), owner.request(tool, args)); denied != nil {
+			return nil, errors.New(resultText(denied))
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Next++
+	st := &scheduledTask{
+		ID:        fmt.Sprintf("schedule-%d", s.state.Next),
+		Tool:      tool,
+		Cron:      expr,
+		Arguments: args,
+		CreatedAt: now,
+		NextRun:   next,
+		Owner:     owner,
+		spec:      spec,
+	}
+	s.state.Schedules = append(s.state.Schedules, st)
+	if err := s.save(); err != nil {
+		s.state.Schedules = s.state.Schedules[:len(s.state.Schedules)-1]
+		return nil, fmt.Errorf("saving schedules: %w", err)
+	}
+	return st, nil
+}
+
+// owner returns the client of ctx, as the owner of the schedules it creates
+// and sees.
+func (s *scheduler) owner(ctx context.Context) *scheduleOwner {
+	owner := &scheduleOwner{}
+	if session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo); ok {
+		owner.Client = session.GetClientInfo()
+	}
+	if s.cfg != nil {
+		if dirs, ok := s.cfg.callerRoots(ctx); ok {
+			owner.Roots = append([]string{}, dirs...)
+		}
+	}
+	return owner
+}
+
+// remove deletes the schedule id of owner. Other clients' schedules are
+// unknown.
+func (s *scheduler) remove(owner *scheduleOwner, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, st := range s.state.Schedules {
+		if st.ID != id || !st.Owner.owns(owner) {
+			continue
+		}
+		s.state.Schedules = append(s.state.Schedules[:i:i], s.state.Schedules[i+1:]...)
+		if err := s.save(); err != nil {
+			return fmt.Errorf("saving schedules: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown schedule %q", id)
+}
+
+// list returns copies of the schedules of owner.
+func (s *scheduler) list(owner *scheduleOwner) []scheduledTask {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := []scheduledTask{}
+	for _, st := range s.state.Schedules {
+		if st.Owner.owns(owner) {
+			list = append(list, *st)
+		}
+	}
+	return list
+}
+
+// due advances every schedule whose time has come and returns them.
+func (s *scheduler) due() []scheduledTask {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	var due []scheduledTask
+	for _, st := range s.state.Schedules {
+		if st.NextRun.IsZero() || st.NextRun.After(now) {
+			continue
+		}
+		due = append(due, *st)
+		st.NextRun = st.spec.Next(now)
+	}
+	if len(due) > 0 {
+		if err := s.save(); err != nil {
+			slog.Error("Could not save schedules", "path", s.path, "error", err)
+		}
+	}
+	return due
+}
+
+// run calls the scheduled tool on behalf of the schedule's owner and
+// records the outcome.
+func (s *scheduler) run(ctx context.Context, st scheduledTask) {
+	slog.Info("Running scheduled task", "schedule", st.ID, "tool", st.Tool)
+	last := &scheduledRun{StartedAt: s.now()}
+	if target, ok := s.targets[st.Tool]; ok {
+		result, err := target.handler(context.WithValue(ctx, scheduleOwnerKey{}, st.Owner), st.Owner.request(st.Tool, st.Arguments))
+		switch {
+		case err != nil:
+			last.Output = err.Error()
+		default:
+			last.Succeeded = !result.IsError
+			last.Output = resultText(result)
+		}
+	} else {
+		last.Output = fmt.Sprintf("Tool %s no longer exists", st.Tool)
+	}
+	if len(last.Output) > maxScheduledOutput {
+		last.Output = "..." + last.Output[len(last.Output)-maxScheduledOutput:]
+	}
+	if !last.Succeeded {
+		slog.Warn("Scheduled task failed", "schedule", st.ID, "tool", st.Tool)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, current := range s.state.Schedules {
+		if current.ID == st.ID {
+			current.LastRun = last
+			if err := s.save(); err != nil {
+				slog.Error("Could not save schedules", "path", s.path, "error", err)
+			}
+			return
+		}
+	}
+}
+
+// RunSchedules runs scheduled tasks until ctx is cancelled. It returns
+// right away when schedules are not enabled.
+func (b *Bridge) RunSchedules(ctx context.Context) {
+	if b.scheduler == nil {
+		return
+	}
+	ticker := time.NewTicker(scheduleTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, st := range b.scheduler.due() {
+				go b.scheduler.run(ctx, st)
+			}
+		}
+	}
+}
+
+// scheduleTools returns the tools that manage schedules.
+func (s *scheduler) scheduleTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.NewTool("schedule_task",
+				mcp.WithDescription("Run a tool repeatedly on a cron schedule, e.g. \"0 2 * * *\" for every night at 2:00 server time. Schedules are kept until cancelled."),
+				mcp.WithString("tool", mcp.Required(), mcp.Description("Name of the tool to run.")),
+				mcp.WithString("cron", mcp.Required(), mcp.Description("Five-field cron expression (minute hour day-of-month month day-of-week) or a macro such as @hourly or @daily.")),
+				mcp.WithObject("arguments", mcp.Description("Arguments to call the tool with.")),
+			),
+			Handler: s.handleSchedule,
+		},
+		{
+			Tool: mcp.NewTool("schedules_list",
+				mcp.WithDescription("List the tasks this client scheduled with their next run and the outcome of their last run."),
+				mcp.WithReadOnlyHintAnnotation(true),
+			),
+			Handler: s.handleList,
+		},
+		{
+			Tool: mcp.NewTool("schedules_cancel",
+				mcp.WithDescription("Delete a scheduled task. A run in progress is not stopped."),
+				mcp.WithString("schedule_id", mcp.Required(), mcp.Description("ID of the schedule, as returned by schedule_task.")),
+			),
+			Handler: s.handleCancel,
+		},
+	}
+}
+
+func (s *scheduler) handleSchedule(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	tool, err := request.RequireString("tool")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid arguments: %v", err)), nil
+	}
+	expr, err := request.RequireString("cron")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid arguments: %v", err)), nil
+	}
+	var args map[string]any
+	if raw, ok := request.GetArguments()["arguments"]; ok && raw != nil {
+		if args, ok = raw.(map[string]any); !ok {
+			return mcp.NewToolResultError("Invalid arguments: arguments must be an object"), nil
+		}
+	}
+
+	st, err := s.add(ctx, request.Params.Meta, tool, expr, args)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Could not schedule %s: %v", tool, err)), nil
+	}
+	result := mcp.NewToolResultText(fmt.Sprintf("Scheduled %s as %s. Next run: %s.", tool, st.ID, st.NextRun.Format(time.RFC3339)))
+	result.Meta = map[string]any{schedulesMetaKey: []scheduledTask{*st}}
+	return result, nil
+}
+
+func (s *scheduler) handleList(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	list := s.list(s.owner(ctx))
+	if len(list) == 0 {
+		return mcp.NewToolResultText("No scheduled tasks."), nil
+	}
+	lines := make([]string, len(list))
+	for i, st := range list {
+		line := fmt.Sprintf("%s: %s %q, next run %s", st.ID, st.Tool, st.Cron, st.NextRun.Format(time.RFC3339))
+		if st.LastRun != nil {
+			outcome := "failed"
+			if st.LastRun.Succeeded {
+				outcome = "succeeded"
+			}
+			line += fmt.Sprintf(", last run %s %s", st.LastRun.StartedAt.Format(time.RFC3339), outcome)
+		}
+		lines[i] = line
+	}
+	result := mcp.NewToolResultText(strings.Join(lines, "\n"))
+	result.Meta = map[string]any{schedulesMetaKey: list}
+	return result, nil
+}
+
+func (s *scheduler) handleCancel(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := request.RequireString("schedule_id")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid arguments: %v", err)), nil
+	}
+	if err := s.remove(s.owner(ctx), id); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Cancelled %s.", id)), nil
+}
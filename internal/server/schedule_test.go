@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// callScheduleTool calls the schedule tool name with args.
+func callScheduleTool(t *testing.T, s *scheduler, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	for _, tool := range s.scheduleTools() {
+		if tool.Tool.Name != name {
+			continue
+		}
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = args
+		result, err := tool.Handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("%s error = %v", name, err)
+		}
+		return result
+	}
+	t.Fatalf("no schedule tool %s", name)
+	return nil
+}
+
+func TestScheduler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "schedules.json")
+	var calls []map[string]any
+	targets := map[string]taskTool{
+		"report_daily": {
+			task: inspector.TaskDefinition{Name: "report:daily"},
+			handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				calls = append(calls, request.GetArguments())
+				return mcp.NewToolResultText("report sent"), nil
+			},
+		},
+		"deploy": {task: inspector.TaskDefinition{Name: "deploy"}},
+	}
+	s, err := newScheduler(path, []string{"report:*"}, targets, newSettings(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 5, 15, 1, 59, 30, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	result := callScheduleTool(t, s, "schedule_task", map[string]any{"tool": "report_daily", "cron": "0 2 * * *", "arguments": map[string]any{"TO": "ops"}})
+	if result.IsError || !strings.Contains(resultText(result), "schedule-1") || !strings.Contains(resultText(result), "2024-05-15T02:00:00Z") {
+		t.Fatalf("schedule_task = %q", resultText(result))
+	}
+	for _, args := range []map[string]any{
+		{"tool": "deploy", "cron": "@daily"},
+		{"tool": "missing", "cron": "@daily"},
+		{"tool": "report_daily", "cron": "0 2 * *"},
+		{"tool": "report_daily", "cron": "0 0 30 2 *"},
+		{"tool": "report_daily", "cron": "@daily", "arguments": map[string]any{"TO": []any{"a"}}},
+	} {
+		if result := callScheduleTool(t, s, "schedule_task", args); !result.IsError {
+			t.Errorf("schedule_task(%v) = %q, want error", args, resultText(result))
+		}
+	}
+
+	if due := s.due(); len(due) != 0 {
+		t.Errorf("due() before 2:00 = %v", due)
+	}
+	now = now.Add(time.Minute)
+	due := s.due()
+	if len(due) != 1 {
+		t.Fatalf("due() at 2:00 = %v, want one schedule", due)
+	}
+	s.run(context.Background(), due[0])
+	if len(calls) != 1 || calls[0]["TO"] != "ops" {
+		t.Errorf("calls = %v", calls)
+	}
+
+	// Schedules and their last run survive a restart.
+	reloaded, err := newScheduler(path, nil, targets, newSettings(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := reloaded.list(&scheduleOwner{})
+	if len(list) != 1 || list[0].LastRun == nil || !list[0].LastRun.Succeeded || list[0].LastRun.Output != "report sent" {
+		t.Fatalf("reloaded schedules = %+v", list)
+	}
+	if text := resultText(callScheduleTool(t, reloaded, "schedules_list", nil)); !strings.Contains(text, "succeeded") {
+		t.Errorf("schedules_list = %q", text)
+	}
+
+	if result := callScheduleTool(t, reloaded, "schedules_cancel", map[string]any{"schedule_id": "schedule-1"}); result.IsError {
+		t.Errorf("schedules_cancel = %q", resultText(result))
+	}
+	if result := callScheduleTool(t, reloaded, "schedules_cancel", map[string]any{"schedule_id": "schedule-1"}); !result.IsError {
+		t.Errorf("second schedules_cancel = %q, want error", resultText(result))
+	}
+	if reloaded, err = newScheduler(path, nil, targets, newSettings(nil)); err != nil || len(reloaded.list(&scheduleOwner{})) != 0 {
+		t.Errorf("schedules after cancel = %v, %v", reloaded.list(&scheduleOwner{}), err)
+	}
+}
+
+// clientSession is a session of a client that identified itself.
+type clientSession struct {
+	testSession
+	info mcp.Implementation
+}
+
+func (s clientSession) GetClientInfo() mcp.Implementation     { return s.info }
+func (s clientSession) SetClientInfo(info mcp.Implementation) {}
+
+// clientContext returns a context of a session of the client name.
+func clientContext(id string, name string) context.Context {
+	return server.NewMCPServer("tasks", "1.0.0").WithContext(context.Background(), clientSession{testSession{id}, mcp.Implementation{Name: name}})
+}
+
+func TestSchedulesOfOtherClients(t *testing.T) {
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	}
+	targets := map[string]taskTool{"build": {task: inspector.TaskDefinition{Name: "build"}, handler: handler}}
+	s, err := newScheduler(filepath.Join(t.TempDir(), "schedules.json"), nil, targets, newSettings(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(ctx context.Context, name string, args map[string]any) *mcp.CallToolResult {
+		for _, tool := range s.scheduleTools() {
+			if tool.Tool.Name == name {
+				request := mcp.CallToolRequest{}
+				request.Params.Name = name
+				request.Params.Arguments = args
+				result, _ := tool.Handler(ctx, request)
+				return result
+			}
+		}
+		t.Fatalf("no schedule tool %s", name)
+		return nil
+	}
+	editor, other := clientContext("a", "editor"), clientContext("b", "other")
+	if result := call(editor, "schedule_task", map[string]any{"tool": "build", "cron": "@daily", "arguments": map[string]any{"TOKEN": "secret"}}); result.IsError {
+		t.Fatalf("schedule_task = %q", resultText(result))
+	}
+
+	// A later session of the same client sees its schedules.
+	if text := resultText(call(clientContext("c", "editor"), "schedules_list", nil)); !strings.Contains(text, "schedule-1") {
+		t.Errorf("schedules_list of the same client = %q", text)
+	}
+	result := call(other, "schedules_list", nil)
+	if text := resultText(result); text != "No scheduled tasks." || strings.Contains(fmt.Sprint(result.Meta), "secret") {
+		t.Errorf("schedules_list of another client = %q, %v", text, result.Meta)
+	}
+	if result := call(other, "schedules_cancel", map[string]any{"schedule_id": "schedule-1"}); !result.IsError || !strings.Contains(resultText(result), "unknown schedule") {
+		t.Errorf("schedules_cancel of another client = %q, want unknown", resultText(result))
+	}
+	if result := call(editor, "schedules_cancel", map[string]any{"schedule_id": "schedule-1"}); result.IsError {
+		t.Errorf("schedules_cancel = %q", resultText(result))
+	}
+}
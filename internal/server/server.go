@@ -4,114 +4,1106 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
-	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	mcpclient "github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
-	"github.com/sandwichlabs/mcp-task-bridge/internal/inspector"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/runner"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/secrets"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
 )
 
-var taskBin = "task"
+// settings holds the optional runtime configuration for Run.
+type settings struct {
+	handshakeTimeout time.Duration
+	idleTimeout      time.Duration
+	dir              string
+	inject           map[string]string
+	vars             map[string]string
+	secrets          map[string]string
+	env              map[string]string
+	include          []string
+	exclude          []string
+	profileInclude   []string
+	profileExclude   []string
+	passEnv          []string
+	strict           bool
+	cleanEnv         bool
+	redact           bool
+	limiter          *rateLimiter
+	observer         func(ToolCall)
+	logOutput        io.Writer
+	toolPrefix       string
+	taskBin          string
+	source           inspector.Source
+	runner           runner.Runner
+	inspectProgress  func(inspector.Progress)
+	lazy             bool
+	inspectCache     *inspector.Cache
+	usagePattern     *regexp.Regexp
+	readOnlyOnly     bool
+	retries          map[string]inspector.RetryPolicy
+	groups           map[string][]string
+	locks            *groupLocks
+	jobs             *jobs
+	cacheTTLs        map[string]time.Duration
+	cache            *resultCache
+	policies         []config.Policy
+	approvalTasks    []string
+	approvalTimeout  time.Duration
+	approvalNotify   ApprovalNotifier
+	approvals        *approvals
+	artifacts        *artifacts
+	maxOutput        int
+	pageSize         int
+	autoJSON         bool
+	scheduleFile     string
+	scheduleAllow    []string
+	upstreams        []Upstream
+	plugins          []Plugin
+	pipelines        []Pipeline
+	when             map[string]config.Condition
+	sessionDirs      []string
+	profiles         map[string]map[string]string
+	sessions         *sessions
+	budget           Budget
+	notifyAfter      time.Duration
+	notifyTasks      []string
+	notifyCompletion CompletionNotifier
+	// serverName is the name the bridge reports to clients.
+	serverName string
+	// sampleDescriptions and descriptionCache are set by
+	// WithSampledDescriptions.
+	sampleDescriptions bool
+	descriptionCache   string
+	// peer sends requests to the stdio client, see ServeStdio.
+	peer *clientPeer
+	// roots are the directories the stdio client shares, see checkRoots.
+	roots *clientRoots
+	// protocolVersion and disabledCapabilities are set by
+	// WithProtocolVersion and WithoutCapabilities.
+	protocolVersion      string
+	disabledCapabilities []string
 
-func TranslateTtmcpTools(config *inspector.MCPConfig) []*mcp.Tool {
+	// mu guards the settings that can change at runtime, see SetRuntime.
+	mu     sync.RWMutex
+	dryRun bool
+	allow  []string
+}
+
+// Option is a function that configures the server started by Run.
+type Option func(*settings)
+
+// WithHandshakeTimeout drops clients that have not completed the initialize
+// handshake within d. Zero disables the check.
+func WithHandshakeTimeout(d time.Duration) Option {
+	return func(s *settings) {
+		s.handshakeTimeout = d
+	}
+}
+
+// WithTaskRunner runs tasks with r instead of starting the task binary,
+// e.g. to fake it in tests.
+func WithTaskRunner(r runner.Runner) Option {
+	return func(s *settings) {
+		s.runner = r
+	}
+}
+
+// WithInspectProgress calls fn as each task is inspected at startup, e.g.
+// to show a spinner. By default, progress is logged every few seconds.
+func WithInspectProgress(fn func(inspector.Progress)) Option {
+	return func(s *settings) {
+		s.inspectProgress = fn
+	}
+}
+
+// WithInspectCache reuses inspections of unchanged Taskfiles from c, and
+// stores new ones there.
+func WithInspectCache(c *inspector.Cache) Option {
+	return func(s *settings) {
+		s.inspectCache = c
+	}
+}
+
+// WithUsagePattern finds the usage line of task summaries with re instead
+// of inspector.DefaultUsagePattern. nil keeps the default.
+func WithUsagePattern(re *regexp.Regexp) Option {
+	return func(s *settings) {
+		s.usagePattern = re
+	}
+}
+
+// WithLazyDetails registers tools from a single listing of the Taskfile at
+// startup instead of inspecting every task. A task's usage and parameters
+// are inspected the first time it is called or tools are listed, which
+// keeps startup fast for Taskfiles with many tasks.
+func WithLazyDetails() Option {
+	return func(s *settings) {
+		s.lazy = true
+	}
+}
+
+// WithPageSize paginates tools/list, and the other list requests, with at
+// most n entries per page. Zero, the default, returns everything at once.
+func WithPageSize(n int) Option {
+	return func(s *settings) {
+		s.pageSize = n
+	}
+}
+
+// WithDir sets the working directory tasks run from. It defaults to the
+// Taskfile's directory.
+func WithDir(dir string) Option {
+	return func(s *settings) {
+		s.dir = dir
+	}
+}
+
+// WithInjectedVars sets task variables that are filled from request
+// metadata rather than model arguments. See config.Config.Inject.
+func WithInjectedVars(inject map[string]string) Option {
+	return func(s *settings) {
+		s.inject = inject
+	}
+}
+
+// WithVars sets task variables passed to every task, like `task` itself
+// takes them on its command line. As with injected variables, models can't
+// set them and they are left out of the tool schemas.
+func WithVars(vars map[string]string) Option {
+	return func(s *settings) {
+		s.vars = vars
+	}
+}
+
+// fixedVars returns the task variables the server sets itself, which
+// models can't.
+func (s *settings) fixedVars() map[string]string {
+	if len(s.vars) == 0 {
+		return s.inject
+	}
+	fixed := make(map[string]string, len(s.inject)+len(s.vars))
+	for name, value := range s.vars {
+		fixed[name] = value
+	}
+	for name, source := range s.inject {
+		fixed[name] = source
+	}
+	return fixed
+}
+
+// WithSecrets sets environment variables whose values are fetched from a
+// secret provider each time a task runs. See config.Config.Secrets.
+func WithSecrets(sources map[string]string) Option {
+	return func(s *settings) {
+		s.secrets = sources
+	}
+}
+
+// WithEnv sets extra environment variables for every task execution. It
+// adds to, and overrides, the variables of earlier WithEnv options.
+func WithEnv(env map[string]string) Option {
+	return func(s *settings) {
+		if len(env) == 0 {
+			return
+		}
+		merged := make(map[string]string, len(s.env)+len(env))
+		for key, value := range s.env {
+			merged[key] = value
+		}
+		for key, value := range env {
+			merged[key] = value
+		}
+		s.env = merged
+	}
+}
+
+// WithTaskFilter limits the exposed tasks to those matching one of the
+// include patterns (all tasks when empty) and none of the exclude patterns.
+// Patterns use path.Match syntax, e.g. "db:*".
+func WithTaskFilter(include []string, exclude []string) Option {
+	return func(s *settings) {
+		s.include = include
+		s.exclude = exclude
+	}
+}
+
+// WithIdleTimeout drops initialized clients that send no message for longer
+// than d. Zero disables the check.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(s *settings) {
+		s.idleTimeout = d
+	}
+}
+
+// TranslateTtmcpTools builds one MCP tool per task, named after the
+// matching entry of names. Parameters named in injected are filled in by the
+// server, so they are left out of the schema.
+func TranslateTtmcpTools(config *inspector.MCPConfig, names []ToolName, injected map[string]string) []*mcp.Tool {
 	var tools []*mcp.Tool
-	for _, task := range config.Tasks {
+	for i, task := range config.Tasks {
 		var toolOptions []mcp.ToolOption
-		toolOptions = append(toolOptions, mcp.WithDescription(task.Description))
+		toolOptions = append(toolOptions, mcp.WithDescription(toolDescription(task)))
+		toolOptions = append(toolOptions, toolAnnotations(task)...)
+		stdinDeclared := false
 		for _, param := range task.Parameters {
-			toolOptions = append(toolOptions, mcp.WithString(param.Name, mcp.Required()))
+			if _, ok := injected[param.Name]; ok {
+				continue
+			}
+			if param.Name == task.Stdin {
+				stdinDeclared = true
+				toolOptions = append(toolOptions, mcp.WithString(param.Name, mcp.Required(), mcp.Description(stdinDescription)))
+				continue
+			}
+			toolOptions = append(toolOptions, parameterOption(param))
+		}
+		if _, ok := injected[task.Stdin]; task.Stdin != "" && !stdinDeclared && !ok {
+			toolOptions = append(toolOptions, mcp.WithString(task.Stdin, mcp.Description(stdinDescription)))
 		}
-		tool := mcp.NewTool(task.Name, toolOptions...)
+		if _, ok := injected[cliArgsArgument]; task.CLIArgs && !ok {
+			toolOptions = append(toolOptions, mcp.WithString(cliArgsArgument, mcp.Description(cliArgsDescription)))
+		}
+		tool := mcp.NewTool(names[i].Tool, toolOptions...)
 		tools = append(tools, &tool) // Take address of tool
 	}
 	return tools
 }
 
-func createTaskHandler(taskfilePath string) server.ToolHandlerFunc {
+// parameterOption declares param in a tool's input schema. Parameters are
+// strings unless their source gives them a type, and required unless they
+// have a default.
+func parameterOption(param inspector.TaskParameter) mcp.ToolOption {
+	var opts []mcp.PropertyOption
+	if param.Default == nil {
+		opts = append(opts, mcp.Required())
+	} else {
+		opts = append(opts, func(schema map[string]any) { schema["default"] = param.Default })
+	}
+	if param.Description != "" {
+		opts = append(opts, mcp.Description(param.Description))
+	}
+	switch param.Type {
+	case "number":
+		return mcp.WithNumber(param.Name, opts...)
+	case "integer":
+		return mcp.WithNumber(param.Name, append(opts, func(schema map[string]any) { schema["type"] = "integer" })...)
+	case "boolean":
+		return mcp.WithBoolean(param.Name, opts...)
+	case "array":
+		return mcp.WithArray(param.Name, append(opts, mcp.Items(map[string]any{"type": "string"}))...)
+	default:
+		return mcp.WithString(param.Name, opts...)
+	}
+}
+
+// toolDescription is the task description followed by its cost and
+// duration estimates, a note for async tasks and its documentation link, if
+// any. MCP tool annotations have no
+// field for links, so the description is the only place clients are sure to
+// show it.
+func toolDescription(task inspector.TaskDefinition) string {
+	var parts []string
+	if task.Description != "" {
+		parts = append(parts, task.Description)
+	}
+	if len(task.Deps) > 0 {
+		parts = append(parts, fmt.Sprintf("Runs the tasks it depends on first: %s. There's no need to call them before it.", strings.Join(task.Deps, ", ")))
+	}
+	if estimate := describeEstimates(task); estimate != "" {
+		parts = append(parts, estimate)
+	}
+	if task.Async {
+		parts = append(parts, "Runs as a background job: the result is a job ID for jobs_status, jobs_logs and jobs_cancel.")
+	}
+	if task.OutputSchema != nil {
+		parts = append(parts, outputSchemaDescription(task.OutputSchema))
+	}
+	if task.Docs != "" {
+		parts = append(parts, "Documentation: "+task.Docs)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// createTaskHandler returns the handler for a single task. The task binary
+// runs from the task's own dir when the Taskfile sets one, and from dir
+// otherwise; dir is always passed through as task's --dir. Client sessions
+// may move both, see WithSessions.
+func createTaskHandler(taskfilePath string, dir string, task inspector.TaskDefinition, cfg *settings) server.ToolHandlerFunc {
+	inject, fixed := cfg.inject, cfg.fixedVars()
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		var args []string
-		args = append(args, "--taskfile", taskfilePath, request.Params.Name)
+		taskfilePath, dir, task, profileEnv := cfg.sessionRun(ctx, taskfilePath, dir, task)
+		if !cfg.limiter.allow() {
+			return mcp.NewToolResultError(fmt.Sprintf("Rate limit exceeded: at most %d tool calls per minute", cfg.limiter.limit())), nil
+		}
+		elicited, cancelled := cfg.elicitArguments(ctx, task, request, fixed)
+		if cancelled != nil {
+			return cancelled, nil
+		}
+		request.Params.Arguments = elicited
+		if p, denied := cfg.deniedBy(ctx, request, task); denied {
+			return policyDenial(p), nil
+		}
+		if cfg.strictArguments() {
+			if err := checkArguments(task, request.GetArguments(), fixed); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid arguments: %v", err)), nil
+			}
+		}
+		if denied := cfg.checkRoots(ctx, taskfilePath, dir, task, request.GetArguments()); denied != nil {
+			return denied, nil
+		}
+
+		dryRun := cfg.shouldDryRun(task)
+		call := inspector.Run{File: taskfilePath, Dir: dir, Task: task.Name, Parameters: inspector.ParameterNames(task), DryRun: dryRun}
+		params := make(map[string]inspector.TaskParameter, len(task.Parameters))
+		for _, param := range task.Parameters {
+			params[param.Name] = param
+		}
 		for key, value := range request.GetArguments() {
-			args = append(args, fmt.Sprintf("%s=%s", key, value))
+			// Injected and global variables are never taken from the model.
+			if _, ok := fixed[key]; ok {
+				continue
+			}
+			// A null argument is left out, so the task's default applies.
+			if isStdinArgument(task, key) || key == cliArgsArgument || value == nil {
+				continue
+			}
+			if !argumentName.MatchString(key) {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid arguments: %q is not a valid argument name", key)), nil
+			}
+			param, ok := params[key]
+			if !ok {
+				param = inspector.TaskParameter{Name: key}
+			}
+			vars, err := argumentVars(param, value)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid arguments: %v", err)), nil
+			}
+			call.Vars = append(call.Vars, vars...)
 		}
-		cmd := exec.Command(taskBin, args...)
-		var out bytes.Buffer
-		cmd.Stdout = &out
-		var stderr bytes.Buffer
-		cmd.Stderr = &stderr
+		call.Vars = append(call.Vars, envPairs(cfg.vars)...)
+		for key, value := range injectedVars(ctx, request, inject) {
+			call.Vars = append(call.Vars, fmt.Sprintf("%s=%s", key, value))
+		}
+		if _, ok := fixed[cliArgsArgument]; !ok {
+			extra, err := cliArgs(request.GetArguments())
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid arguments: %v", err)), nil
+			}
+			call.Args = extra
+		}
+		args := cfg.source.Command(call)
+		if args == nil {
+			// Sources that can't dry-run, like package.json, show the
+			// command instead of running anything.
+			call.DryRun = false
+			command := append([]string{cfg.taskBin}, cfg.source.Command(call)...)
+			return mcp.NewToolResultText(fmt.Sprintf("Dry run (safe mode): %s was not executed. It would run:\n%s\n", task.Name, strings.Join(command, " "))), nil
+		}
+		var ttl time.Duration
+		if !dryRun {
+			ttl = cfg.cacheTTL(task)
+		}
+		stdin := stdinContent(task, request.GetArguments())
+		// Sessions with different env profiles don't share results.
+		key := cacheKey(cfg.source, call, stdin, envPairs(profileEnv))
+		if ttl > 0 {
+			if output, ranAt, ok := cfg.cache.get(key); ok {
+				result := mcp.NewToolResultText(output)
+				result.Meta = map[string]any{cachedAtMetaKey: ranAt.UTC().Format(time.RFC3339)}
+				// Only output that matched the schema was cached.
+				structured, _ := cfg.structuredOutput(task, output)
+				addStructured(result, structured)
+				return result, nil
+			}
+		}
+		if denial := cfg.chargeBudget(ctx, task, dryRun); denial != nil {
+			return denial, nil
+		}
+		if !dryRun && cfg.needsApproval(task) {
+			if denial := cfg.requestApproval(ctx, task.Name, request); denial != nil {
+				return denial, nil
+			}
+		}
+		secretEnv, err := secrets.Resolve(ctx, cfg.secrets)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error resolving secrets: %v", err)), nil
+		}
+		var env []string
+		if cfg.cleanEnv || len(cfg.env) > 0 || len(profileEnv) > 0 || len(secretEnv) > 0 {
+			if cfg.cleanEnv {
+				env = append(baseEnv(), passedEnv(cfg.passEnv)...)
+			} else {
+				env = os.Environ()
+			}
+			for key, value := range cfg.env {
+				env = append(env, key+"="+value)
+			}
+			for key, value := range profileEnv {
+				env = append(env, key+"="+value)
+			}
+			env = append(env, secretEnv...)
+		}
+
+		run := &taskExec{cfg: cfg, task: task, dir: dir, args: args, env: env, secretEnv: secretEnv, stdin: stdin, dryRun: dryRun, ttl: ttl, cacheKey: key}
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			run.session = session.SessionID()
+		}
+		if task.Async && !dryRun {
+			return cfg.jobs.start(task.Name, run), nil
+		}
+		// Synchronous runs are not killed when the call is cancelled.
+		return run.execute(ctx, context.WithoutCancel(ctx), nil), nil
+	}
+}
 
-		err := cmd.Run()
+// taskExec is a prepared task run.
+type taskExec struct {
+	cfg       *settings
+	task      inspector.TaskDefinition
+	dir       string
+	args      []string
+	env       []string
+	secretEnv []string
+	// stdin is piped to the task, see stdinContent.
+	stdin    string
+	dryRun   bool
+	ttl      time.Duration
+	cacheKey string
+	// session is the ID of the client session the run counts against, if
+	// any, see WithBudget.
+	session string
+}
+
+// execute runs the task and returns its tool result. Waiting for
+// concurrency groups and between retries ends when ctx is done; the task
+// itself is killed when kill is done. Output is also copied to logs, if set.
+func (r *taskExec) execute(ctx context.Context, kill context.Context, logs io.Writer) *mcp.CallToolResult {
+	cfg, task := r.cfg, r.task
+	policy := cfg.retryPolicy(task)
+	if r.dryRun {
+		policy = inspector.RetryPolicy{Attempts: 1}
+	} else if groups := cfg.taskGroups(task); len(groups) > 0 {
+		release, err := cfg.locks.acquire(ctx, groups)
 		if err != nil {
-			return mcp.NewToolResultError(stderr.String()), nil
+			return mcp.NewToolResultError(fmt.Sprintf("Cancelled while waiting for another task in concurrency group %s to finish", strings.Join(groups, ", ")))
+		}
+		defer release()
+	}
+	req := runner.Request{Bin: cfg.taskBin, Args: r.args, Dir: r.dir, Env: r.env}
+	if task.Dir != "" {
+		req.Dir = task.Dir
+	}
+	var (
+		out      bytes.Buffer
+		stderr   bytes.Buffer
+		attempts []attempt
+		err      error
+	)
+	began := time.Now()
+	for n := 1; ; n++ {
+		if r.stdin != "" {
+			req.Stdin = strings.NewReader(r.stdin)
+		}
+		out.Reset()
+		stderr.Reset()
+		req.Stdout = &out
+		req.Stderr = &stderr
+		if logs != nil {
+			req.Stdout = io.MultiWriter(&out, logs)
+			req.Stderr = io.MultiWriter(&stderr, logs)
+		}
+
+		start := time.Now()
+		err = cfg.runner.Run(kill, req)
+		attempts = append(attempts, newAttempt(err, time.Since(start)))
+		cfg.sessions.addRuntime(r.session, time.Since(start))
+		if err == nil || n >= policy.Attempts || !retryable(err) || !sleep(ctx, retryDelay(policy, n)) {
+			break
+		}
+	}
+	if !r.dryRun {
+		cfg.completed(task, err, time.Since(began))
+	}
+	if len(attempts) == 1 {
+		attempts = nil
+	}
+
+	if err != nil {
+		failure := newExecError(req.Command(), err, out.String(), stderr.String())
+		failure.Attempts = attempts
+		if cfg.redact {
+			failure = failure.redacted(r.secretEnv)
 		}
+		var saved []*savedOutput
+		var stdoutFile, stderrFile *savedOutput
+		failure.Stdout, stdoutFile = cfg.limitOutput(task.Name, "stdout", failure.Stdout)
+		failure.Stderr, stderrFile = cfg.limitOutput(task.Name, "stderr", failure.Stderr)
+		for _, file := range []*savedOutput{stdoutFile, stderrFile} {
+			if file != nil {
+				saved = append(saved, file)
+			}
+		}
+		result := failure.toolResult(task.Name)
+		if saved != nil {
+			result.Meta[outputMetaKey] = saved
+		}
+		// Reports of failing runs, such as test results, are often the most
+		// useful artifacts.
+		if failure.Error == "" {
+			r.addArtifacts(result, req.Dir)
+		}
+		return result
+	}
+	output := out.String()
+	if r.dryRun {
+		// task prints the commands it would run to stderr, make to stdout.
+		output = fmt.Sprintf("Dry run (safe mode): %s was not executed. It would run:\n%s%s", task.Name, stderr.String(), output)
+	}
+	if cfg.redact {
+		output = redact(output, r.secretEnv)
+	}
+	var structured any
+	if !r.dryRun {
+		structured, err = cfg.structuredOutput(task, output)
+	}
+	output, saved := cfg.limitOutput(task.Name, "stdout", output)
+	if err != nil {
+		return schemaMismatch(task.Name, err, output)
+	}
+	// Saved output files are deleted eventually, so results pointing to
+	// them are not cached.
+	if r.ttl > 0 && saved == nil {
+		cfg.cache.put(r.cacheKey, output, r.ttl)
+	}
+	result := mcp.NewToolResultText(output)
+	if attempts != nil {
+		result = mcp.NewToolResultText(output + "\n" + describeAttempts(attempts))
+		result.Meta = map[string]any{attemptsMetaKey: attempts}
+	}
+	if saved != nil {
+		if result.Meta == nil {
+			result.Meta = map[string]any{}
+		}
+		result.Meta[outputMetaKey] = []*savedOutput{saved}
+	}
+	addStructured(result, structured)
+	if !r.dryRun {
+		r.addArtifacts(result, req.Dir)
+	}
+	return result
+}
 
-		return mcp.NewToolResultText(out.String()), nil
+// addArtifacts registers the task's output files found in dir and lists
+// them in result.
+func (r *taskExec) addArtifacts(result *mcp.CallToolResult, dir string) {
+	if len(r.task.Outputs) == 0 {
+		return
+	}
+	found := r.cfg.artifacts.collect(dir, r.task)
+	if len(found) == 0 {
+		return
+	}
+	result.Content = append(result.Content, mcp.NewTextContent(describeArtifacts(found)))
+	if result.Meta == nil {
+		result.Meta = map[string]any{}
 	}
+	result.Meta[artifactsMetaKey] = found
+}
+
+// Bridge is one or more Taskfiles exposed as an MCP server.
+type Bridge struct {
+	mcp   *server.MCPServer
+	hooks *server.Hooks
+	// cfg holds the server-wide settings; sources holds the settings of
+	// each Taskfile.
+	cfg     *settings
+	sources []*settings
+	config  *inspector.MCPConfig
+	names   []ToolName
+	tools   []mcp.Tool
+	// taskTools holds the task tools by name.
+	taskTools map[string]taskTool
+	// scheduler is nil unless WithSchedules is set.
+	scheduler *scheduler
+	// upstreams holds the clients of the upstream MCP servers.
+	upstreams []mcpclient.MCPClient
+	// lazy is nil unless WithLazyDetails is set.
+	lazy *lazyDetails
+}
+
+// taskTool is the tool of a task with its complete call pipeline.
+type taskTool struct {
+	task    inspector.TaskDefinition
+	handler server.ToolHandlerFunc
+	// check runs the checks of a call that depend on the caller, without
+	// running the task, or is nil.
+	check func(ctx context.Context, request mcp.CallToolRequest) *mcp.CallToolResult
 }
 
-func Run(taskfilePath string, taskBinPath string, serverName string) {
-	if taskBinPath != "" {
-		taskBin = taskBinPath
+// Source is one Taskfile of a bridge with its own task binary and options.
+// Other kinds of files, such as Makefiles, run with their own program
+// unless TaskBin is set. Settings that concern the whole server (timeouts, log output, the call
+// observer and the rate limit) are taken from the bridge options instead.
+// Concurrency groups of the same name are shared by all sources.
+type Source struct {
+	Taskfile string
+	TaskBin  string
+	// Kind is the kind of file Taskfile is. When nil it is told from the
+	// file's name, see inspector.SourceOf.
+	Kind    inspector.Source
+	Options []Option
+}
+
+func newSettings(opts []Option) *settings {
+	cfg := &settings{logOutput: os.Stderr, runner: runner.Exec{}, source: inspector.Taskfile, limiter: newRateLimiter(), locks: newGroupLocks(), jobs: newJobs(), approvals: newApprovals(), artifacts: newArtifacts(), cache: newResultCache(), sessions: newSessions(), peer: newClientPeer(), roots: newClientRoots()}
+	for _, opt := range opts {
+		opt(cfg)
 	}
+	return cfg
+}
+
+// New inspects the Taskfile and builds an MCP server exposing its tasks.
+// Inspection stops when ctx is done.
+func New(ctx context.Context, taskfilePath string, taskBinPath string, serverName string, opts ...Option) (*Bridge, error) {
+	return NewWorkspace(ctx, serverName, []Source{{Taskfile: taskfilePath, TaskBin: taskBinPath}}, opts...)
+}
+
+// loadedSource is an inspected Source.
+type loadedSource struct {
+	cfg          *settings
+	taskfilePath string
+	dir          string
+	tasks        []inspector.TaskDefinition
+	// all are all tasks of the Taskfile, including those left out by the
+	// task and profile filters, WithReadOnlyTasks or WithConditions.
+	all []inspector.TaskDefinition
+	// inspector inspects the tasks, which are only listed with
+	// WithLazyDetails.
+	inspector *inspector.Inspector
+}
 
-	inspector, err := inspector.New(
+// checkCaller returns the checks of calls of task that depend on the
+// caller: its policies and roots.
+func (l *loadedSource) checkCaller(task inspector.TaskDefinition) func(context.Context, mcp.CallToolRequest) *mcp.CallToolResult {
+	return func(ctx context.Context, request mcp.CallToolRequest) *mcp.CallToolResult {
+		if p, denied := l.cfg.deniedBy(ctx, request, task); denied {
+			return policyDenial(p)
+		}
+		return l.cfg.checkRoots(ctx, l.taskfilePath, l.dir, task, request.GetArguments())
+	}
+}
+
+// loadSource resolves the paths of a Source and inspects its Taskfile.
+func loadSource(ctx context.Context, src Source, cfg *settings) (*loadedSource, error) {
+	cfg.source = src.Kind
+	if cfg.source == nil {
+		cfg.source = inspector.SourceOf(src.Taskfile)
+	}
+	cfg.taskBin = src.TaskBin
+	if cfg.taskBin == "" {
+		cfg.taskBin = cfg.source.Bin(src.Taskfile)
+	}
+	// A relative task binary path would otherwise be resolved against each
+	// task's working directory.
+	if strings.ContainsRune(cfg.taskBin, filepath.Separator) {
+		if absBin, err := filepath.Abs(cfg.taskBin); err == nil {
+			cfg.taskBin = absBin
+		}
+	}
+
+	// Resolve paths up front so tasks behave the same no matter where tmcp
+	// was launched from.
+	taskfilePath, err := filepath.Abs(src.Taskfile)
+	if err != nil {
+		return nil, fmt.Errorf("resolving Taskfile path: %w", err)
+	}
+	if cfg.dir == "" {
+		cfg.dir = filepath.Dir(taskfilePath)
+	}
+	dir, err := filepath.Abs(cfg.dir)
+	if err != nil {
+		return nil, fmt.Errorf("resolving working directory: %w", err)
+	}
+
+	progress := cfg.inspectProgress
+	if progress == nil {
+		progress = logProgress(progressLogInterval)
+	}
+	inspectorOpts := []inspector.Option{
 		inspector.WithTaskfile(taskfilePath),
-		inspector.WithTaskBin(taskBinPath),
-	)
+		inspector.WithTaskBin(cfg.taskBin),
+		inspector.WithSource(cfg.source),
+		inspector.WithProgress(progress),
+	}
+	if cfg.inspectCache != nil {
+		inspectorOpts = append(inspectorOpts, inspector.WithCache(cfg.inspectCache))
+	}
+	if cfg.usagePattern != nil {
+		inspectorOpts = append(inspectorOpts, inspector.WithUsagePattern(cfg.usagePattern))
+	}
+	inspector, err := inspector.New(inspectorOpts...)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating inspector: %v\n", err)
-		return
+		return nil, fmt.Errorf("creating inspector: %w", err)
+	}
+	inspect := inspector.Inspect
+	if cfg.lazy {
+		inspect = inspector.List
 	}
-	config, err := inspector.Inspect()
+	config, err := inspect(ctx)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error inspecting Taskfile: %v\n", err)
-		return
+		return nil, fmt.Errorf("inspecting Taskfile: %w", err)
+	}
+	tasks := filterTasks(filterTasks(config.Tasks, cfg.include, cfg.exclude), cfg.profileInclude, cfg.profileExclude)
+	if cfg.readOnlyOnly {
+		tasks = readOnlyTasks(tasks)
+	}
+	tasks, _ = cfg.exposedTasks(tasks)
+	return &loadedSource{cfg: cfg, taskfilePath: taskfilePath, dir: dir, tasks: tasks, all: config.Tasks, inspector: inspector}, nil
+}
+
+// progressLogInterval is how often the progress of a slow inspection is
+// logged.
+const progressLogInterval = 2 * time.Second
+
+// logProgress returns an inspection progress callback that logs at most
+// once per interval, so only inspections slow enough to look hung log
+// anything.
+func logProgress(interval time.Duration) func(inspector.Progress) {
+	start := time.Now()
+	last := start
+	return func(p inspector.Progress) {
+		if now := time.Now(); now.Sub(last) >= interval {
+			last = now
+			slog.Info("Inspecting tasks", "taskfile", p.Taskfile, "task", p.Task, "done", p.N-1, "total", p.Total, "elapsed", now.Sub(start).Round(time.Second))
+		}
+	}
+}
+
+// NewWorkspace inspects every source and builds one MCP server exposing all
+// of their tasks. Tool names are made unique across sources. Inspection
+// stops when ctx is done.
+func NewWorkspace(ctx context.Context, serverName string, sources []Source, opts ...Option) (*Bridge, error) {
+	cfg := newSettings(opts)
+	if err := cfg.checkNegotiation(); err != nil {
+		return nil, err
 	}
 
+	var loaded []*loadedSource
+	for _, src := range sources {
+		srcCfg := newSettings(append(append([]Option{}, opts...), src.Options...))
+		srcCfg.limiter = cfg.limiter
+		srcCfg.locks = cfg.locks
+		srcCfg.jobs = cfg.jobs
+		srcCfg.approvals = cfg.approvals
+		srcCfg.artifacts = cfg.artifacts
+		srcCfg.sessions = cfg.sessions
+		srcCfg.peer = cfg.peer
+		srcCfg.roots = cfg.roots
+		srcCfg.serverName = serverName
+		l, err := loadSource(ctx, src, srcCfg)
+		if err != nil {
+			if len(sources) > 1 {
+				return nil, fmt.Errorf("%s: %w", src.Taskfile, err)
+			}
+			return nil, err
+		}
+		loaded = append(loaded, l)
+	}
 
 	hooks := &server.Hooks{}
 
 	hooks.AddBeforeAny(func(ctx context.Context, id any, method mcp.MCPMethod, message any) {
-		fmt.Fprintf(os.Stderr, "beforeAny: %s, %v, %v\n", method, id, message)
+		fmt.Fprintf(cfg.logOutput, "beforeAny: %s, %v, %v%s\n", method, id, message, cfg.sessions.logSuffix(ctx))
 	})
 	hooks.AddOnSuccess(func(ctx context.Context, id any, method mcp.MCPMethod, message any, result any) {
-		fmt.Fprintf(os.Stderr, "onSuccess: %s, %v, %v, %v\n", method, id, message, result)
+		fmt.Fprintf(cfg.logOutput, "onSuccess: %s, %v, %v, %v%s\n", method, id, message, result, cfg.sessions.logSuffix(ctx))
 	})
 	hooks.AddOnError(func(ctx context.Context, id any, method mcp.MCPMethod, message any, err error) {
-		fmt.Fprintf(os.Stderr, "onError: %s, %v, %v, %v\n", method, id, message, err)
+		fmt.Fprintf(cfg.logOutput, "onError: %s, %v, %v, %v%s\n", method, id, message, err, cfg.sessions.logSuffix(ctx))
 	})
 	hooks.AddBeforeInitialize(func(ctx context.Context, id any, message *mcp.InitializeRequest) {
-		fmt.Fprintf(os.Stderr, "beforeInitialize: %v, %v\n", id, message)
+		fmt.Fprintf(cfg.logOutput, "beforeInitialize: %v, %v\n", id, message)
 	})
 	hooks.AddOnRequestInitialization(func(ctx context.Context, id any, message any) error {
-		fmt.Fprintf(os.Stderr, "AddOnRequestInitialization: %v, %v\n", id, message)
+		fmt.Fprintf(cfg.logOutput, "AddOnRequestInitialization: %v, %v\n", id, message)
 		// authorization verification and other preprocessing tasks are performed.
 		return nil
 	})
 	hooks.AddAfterInitialize(func(ctx context.Context, id any, message *mcp.InitializeRequest, result *mcp.InitializeResult) {
-		fmt.Fprintf(os.Stderr, "afterInitialize: %v, %v, %v\n", id, message, result)
+		fmt.Fprintf(cfg.logOutput, "afterInitialize: %v, %v, %v%s\n", id, message, result, cfg.sessions.logSuffix(ctx))
 	})
 	hooks.AddAfterCallTool(func(ctx context.Context, id any, message *mcp.CallToolRequest, result *mcp.CallToolResult) {
-		fmt.Fprintf(os.Stderr, "afterCallTool: %v, %v, %v\n", id, message, result)
+		fmt.Fprintf(cfg.logOutput, "afterCallTool: %v, %v, %v%s\n", id, message, result, cfg.sessions.logSuffix(ctx))
 	})
 	hooks.AddBeforeCallTool(func(ctx context.Context, id any, message *mcp.CallToolRequest) {
-		fmt.Fprintf(os.Stderr, "beforeCallTool: %v, %v\n", id, message)
+		fmt.Fprintf(cfg.logOutput, "beforeCallTool: %v, %v%s\n", id, message, cfg.sessions.logSuffix(ctx))
 	})
 
-	tools := TranslateTtmcpTools(config)
-	handler := createTaskHandler(taskfilePath)
+	cfg.sessions.register(hooks)
+	cfg.registerNegotiation(hooks)
+
+	config := &inspector.MCPConfig{}
+	var wanted []string
+	var owners []*loadedSource
+	for _, l := range loaded {
+		for _, task := range l.tasks {
+			config.Tasks = append(config.Tasks, task)
+			wanted = append(wanted, l.cfg.toolPrefix+task.Name)
+			owners = append(owners, l)
+		}
+	}
+	names := resolveNames(config.Tasks, wanted)
+	for i := range names {
+		names[i].Taskfile = owners[i].taskfilePath
+		names[i].Source = owners[i].cfg.source
+	}
+	reportToolNames(cfg.logOutput, names)
 
-	s := server.NewMCPServer(serverName, "1.0.0",
+	removed := newRemovedTools(cfg.logOutput)
+	// Resources are registered as tasks produce artifacts or large output.
+	serverOpts := []server.ServerOption{
 		server.WithToolCapabilities(true),
-		server.WithLogging(),
 		server.WithHooks(hooks),
-	)
-	for _, tool := range tools {
-		s.AddTool(*tool, handler) // Dereference tool
+		server.WithToolFilter(removed.filter),
+	}
+	if !cfg.capabilityDisabled(CapabilityResources) {
+		serverOpts = append(serverOpts, server.WithResourceCapabilities(false, true))
+	}
+	if !cfg.capabilityDisabled(CapabilityLogging) {
+		serverOpts = append(serverOpts, server.WithLogging())
+	}
+	if cfg.pageSize > 0 {
+		serverOpts = append(serverOpts, server.WithPaginationLimit(cfg.pageSize))
+	}
+	s := server.NewMCPServer(serverName, "1.0.0", serverOpts...)
+	removed.s = s
+	if !cfg.capabilityDisabled(CapabilityResources) {
+		cfg.artifacts.attach(s)
+	}
+	var srcCfgs []*settings
+	for _, l := range loaded {
+		srcCfgs = append(srcCfgs, l.cfg)
+	}
+	// buildTool builds the tool of the i-th task and its handler.
+	buildTool := func(i int) (mcp.Tool, server.ToolHandlerFunc) {
+		l := owners[i]
+		tool := TranslateTtmcpTools(&inspector.MCPConfig{Tasks: config.Tasks[i : i+1]}, names[i:i+1], l.cfg.fixedVars())[0]
+		return *tool, createTaskHandler(l.taskfilePath, l.dir, config.Tasks[i], l.cfg)
+	}
+	var lazy *lazyDetails
+	if cfg.lazy {
+		lazy = newLazyDetails(s, config, buildTool)
+		lazy.register(hooks)
+	}
+	var tools []mcp.Tool
+	targets := make(map[string]taskTool, len(config.Tasks))
+	for i, task := range config.Tasks {
+		tool, handler := buildTool(i)
+		if lazy != nil {
+			handler = lazy.add(i, owners[i].inspector, handler)
+		}
+		if len(cfg.plugins) > 0 {
+			handler = withPlugins(handler, tool.Name, task.Name, cfg.plugins)
+		}
+		handler = removed.wrap(handler, tool.Name, task.Name, owners[i])
+		if cfg.observer != nil {
+			handler = observeCalls(handler, cfg.observer, cfg.sessions)
+		}
+		if lazy != nil {
+			lazy.registered[i] = handler
+		}
+		tools = append(tools, tool)
+		s.AddTool(tool, handler)
+		targets[tool.Name] = taskTool{task: task, handler: handler, check: owners[i].checkCaller(task)}
+	}
+	upstreamTools, upstreams := connectUpstreams(cfg)
+	addBuiltinTools(s, cfg, names, upstreamTools)
+	addBuiltinTools(s, cfg, names, cfg.sessionTools())
+	if hasAsyncTasks(config.Tasks) {
+		addBuiltinTools(s, cfg, names, cfg.jobs.jobTools())
+	}
+	var sched *scheduler
+	if cfg.scheduleFile != "" {
+		var err error
+		if sched, err = newScheduler(cfg.scheduleFile, cfg.scheduleAllow, targets, cfg); err != nil {
+			closeClients(upstreams)
+			return nil, err
+		}
+		addBuiltinTools(s, cfg, names, sched.scheduleTools())
+	}
+
+	if lazy != nil {
+		lazy.tools = tools
+	}
+	if err := addPipelines(ctx, s, cfg, allTasks(loaded), config.Tasks, tools, targets, lazy); err != nil {
+		closeClients(upstreams)
+		return nil, err
+	}
+	b := &Bridge{mcp: s, hooks: hooks, cfg: cfg, sources: srcCfgs, config: config, names: names, tools: tools, taskTools: targets, scheduler: sched, upstreams: upstreams, lazy: lazy}
+	cfg.roots.register(cfg.peer)
+	if cfg.sampleDescriptions {
+		cfg.peer.on("notifications/initialized", func() { b.sampleDescriptions(context.Background()) })
+	}
+	return b, nil
+}
+
+// addBuiltinTools adds tools that tmcp provides itself, skipping any whose
+// name is taken by a task.
+func addBuiltinTools(s *server.MCPServer, cfg *settings, names []ToolName, builtins []server.ServerTool) {
+	taken := make(map[string]bool, len(names))
+	for _, n := range names {
+		taken[n.Tool] = true
+	}
+	for _, builtin := range builtins {
+		if taken[builtin.Tool.Name] {
+			fmt.Fprintf(cfg.logOutput, "Tool %q is taken by a task and not available as a built-in tool\n", builtin.Tool.Name)
+			continue
+		}
+		handler := builtin.Handler
+		if cfg.observer != nil {
+			handler = observeCalls(handler, cfg.observer, cfg.sessions)
+		}
+		s.AddTool(builtin.Tool, handler)
+	}
+}
+
+// hasAsyncTasks reports whether any of tasks runs as a background job.
+func hasAsyncTasks(tasks []inspector.TaskDefinition) bool {
+	for _, task := range tasks {
+		if task.Async {
+			return true
+		}
+	}
+	return false
+}
+
+// MCPServer returns the underlying MCP server.
+func (b *Bridge) MCPServer() *server.MCPServer {
+	return b.mcp
+}
+
+// Runtime holds the settings that can be changed while the bridge runs.
+type Runtime struct {
+	// RateLimit is the maximum number of tool calls per minute, 0 for none.
+	RateLimit int `json:"rate_limit"`
+	// DryRun runs tasks that are neither read-only nor allowed with --dry.
+	DryRun bool     `json:"dry_run"`
+	Allow  []string `json:"allow"`
+}
+
+// Runtime returns the bridge's current runtime settings. With several
+// sources, dry-run settings are reported for the first one.
+func (b *Bridge) Runtime() Runtime {
+	r := Runtime{RateLimit: b.cfg.limiter.limit()}
+	if len(b.sources) > 0 {
+		src := b.sources[0]
+		src.mu.RLock()
+		defer src.mu.RUnlock()
+		r.DryRun = src.dryRun
+		r.Allow = append([]string{}, src.allow...)
+	}
+	return r
+}
+
+// RuntimePatch changes some of the runtime settings; nil fields are left
+// unchanged.
+type RuntimePatch struct {
+	RateLimit *int      `json:"rate_limit"`
+	DryRun    *bool     `json:"dry_run"`
+	Allow     *[]string `json:"allow"`
+}
+
+// SetRuntime applies the fields p sets to every source, and leaves each
+// source's other settings as they are. They take effect with the next tool
+// call. Turning dry runs on also turns on strict argument checks, see
+// WithDryRunUnless.
+func (b *Bridge) SetRuntime(p RuntimePatch) {
+	if p.RateLimit != nil && *p.RateLimit != b.cfg.limiter.limit() {
+		b.cfg.limiter.setLimit(*p.RateLimit)
+	}
+	for _, src := range b.sources {
+		src.mu.Lock()
+		if p.DryRun != nil {
+			src.dryRun = *p.DryRun
+			src.strict = src.strict || *p.DryRun
+		}
+		if p.Allow != nil {
+			src.allow = append([]string{}, *p.Allow...)
+		}
+		src.mu.Unlock()
+	}
+}
+
+// Config returns the tasks the bridge exposes as tools. With
+// WithLazyDetails, the tasks not inspected yet are inspected first.
+func (b *Bridge) Config() *inspector.MCPConfig {
+	b.resolveLazy()
+	return b.config
+}
+
+// ToolNames returns the tool name of each task in Config, in the same order.
+func (b *Bridge) ToolNames() []ToolName {
+	return b.names
+}
+
+// Tools returns the MCP tool of each task in Config, in the same order, as
+// clients receive it from tools/list.
+func (b *Bridge) Tools() []mcp.Tool {
+	b.resolveLazy()
+	return b.tools
+}
+
+// resolveLazy inspects the tasks that were only listed at startup.
+func (b *Bridge) resolveLazy() {
+	if b.lazy == nil {
+		return
+	}
+	if err := b.lazy.resolveAll(context.Background()); err != nil {
+		slog.Warn("Could not inspect tasks", "error", err)
+	}
+}
+
+// ServeStdio serves the bridge over stdin/stdout until ctx is cancelled or
+// the client is dropped for stalling.
+func (b *Bridge) ServeStdio(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Stdio carries a single client, so dropping its session ends the server.
+	watchdog := newSessionWatchdog(b.cfg.handshakeTimeout, b.cfg.idleTimeout, func(sessionID string, reason string) {
+		fmt.Fprintf(os.Stderr, "Closing stdio session %s: %s\n", sessionID, reason)
+		cancel()
+	})
+	watchdog.register(b.hooks)
+
+	in, out := b.cfg.peer.attach(os.Stdin, os.Stdout)
+	return server.NewStdioServer(b.mcp).Listen(ctx, in, out)
+}
+
+// Run serves the Taskfile over stdin/stdout until interrupted.
+func Run(taskfilePath string, taskBinPath string, serverName string, opts ...Option) {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	bridge, err := New(ctx, taskfilePath, taskBinPath, serverName, opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
 	}
 
-	err = server.ServeStdio(s)
+	err = bridge.ServeStdio(ctx)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error serving MCP: %v\n", err)
 	}
@@ -0,0 +1,371 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// sessionMetaKey is the _meta key under which configure_session describes
+// the session.
+const sessionMetaKey = "tmcp/session"
+
+// sessionTTL is how long the state of a session that sends nothing is kept.
+// HTTP clients don't always end their sessions.
+const sessionTTL = 24 * time.Hour
+
+// WithSessions offers the configure_session tool, with which each client
+// session runs tasks from its own directory, one matching a pattern of
+// dirs, and with one of the env profiles added to their environment.
+func WithSessions(dirs []string, profiles map[string]map[string]string) Option {
+	return func(s *settings) {
+		s.sessionDirs = dirs
+		s.profiles = profiles
+	}
+}
+
+// Session is the state of an initialized client session.
+type Session struct {
+	ID string `json:"id"`
+	// Client and ClientVersion are the name and version the client gave
+	// when initializing.
+	Client        string `json:"client,omitempty"`
+	ClientVersion string `json:"client_version,omitempty"`
+	// Dir replaces the working directory of the session's tasks.
+	Dir string `json:"dir,omitempty"`
+	// Profile names the env profile of the session's tasks.
+	Profile   string    `json:"profile,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	LastSeen  time.Time `json:"last_seen"`
+	// Usage is what the session has used up of its budget, see WithBudget.
+	Usage Usage `json:"usage"`
+}
+
+// sessions tracks the client sessions of a bridge from initialize until the
+// client ends them.
+type sessions struct {
+	mu   sync.Mutex
+	byID map[string]*Session
+}
+
+func newSessions() *sessions {
+	return &sessions{byID: make(map[string]*Session)}
+}
+
+// register hooks session tracking into the server lifecycle. HTTP sessions
+// end with a DELETE request instead, see endSessions.
+func (ss *sessions) register(hooks *server.Hooks) {
+	hooks.AddAfterInitialize(func(ctx context.Context, id any, message *mcp.InitializeRequest, result *mcp.InitializeResult) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			ss.open(session.SessionID(), message.Params.ClientInfo)
+		}
+	})
+	hooks.AddBeforeAny(func(ctx context.Context, id any, method mcp.MCPMethod, message any) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			ss.touch(session.SessionID())
+		}
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		ss.close(session.SessionID())
+	})
+}
+
+// open starts tracking a session and forgets sessions gone quiet for longer
+// than sessionTTL. Initializing again resets the session.
+func (ss *sessions) open(id string, client mcp.Implementation) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	now := time.Now()
+	for other, s := range ss.byID {
+		if now.Sub(s.LastSeen) > sessionTTL {
+			delete(ss.byID, other)
+		}
+	}
+	ss.byID[id] = &Session{ID: id, Client: client.Name, ClientVersion: client.Version, StartedAt: now, LastSeen: now}
+}
+
+func (ss *sessions) touch(id string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if s, ok := ss.byID[id]; ok {
+		s.LastSeen = time.Now()
+	}
+}
+
+func (ss *sessions) close(id string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	delete(ss.byID, id)
+}
+
+// get returns the session of ctx, if it was initialized.
+func (ss *sessions) get(ctx context.Context) (Session, bool) {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return Session{}, false
+	}
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	s, ok := ss.byID[session.SessionID()]
+	if !ok {
+		return Session{}, false
+	}
+	return *s, true
+}
+
+// client returns the name and version the client of ctx gave when
+// initializing.
+func (ss *sessions) client(ctx context.Context) mcp.Implementation {
+	if s, ok := ss.get(ctx); ok {
+		return mcp.Implementation{Name: s.Client, Version: s.ClientVersion}
+	}
+	// Sessions are tracked by the bridge's hooks, which handlers called
+	// directly haven't run.
+	if session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo); ok {
+		return session.GetClientInfo()
+	}
+	return mcp.Implementation{}
+}
+
+// logAttrs identifies the session of ctx and its client in log records and
+// recorded calls, so operators can tell which client made a call.
+func (ss *sessions) logAttrs(ctx context.Context) []any {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return nil
+	}
+	attrs := []any{"session", session.SessionID()}
+	client := ss.client(ctx)
+	if client.Name != "" {
+		attrs = append(attrs, "client", client.Name)
+	}
+	if client.Version != "" {
+		attrs = append(attrs, "client_version", client.Version)
+	}
+	return attrs
+}
+
+// logSuffix renders logAttrs for the request log, e.g.
+// " session=stdio client=cursor client_version=1.2".
+func (ss *sessions) logSuffix(ctx context.Context) string {
+	attrs := ss.logAttrs(ctx)
+	var b strings.Builder
+	for i := 0; i+1 < len(attrs); i += 2 {
+		fmt.Fprintf(&b, " %s=%v", attrs[i], attrs[i+1])
+	}
+	return b.String()
+}
+
+// logger returns the default logger, adding the session of ctx and its
+// client to every record.
+func (s *settings) logger(ctx context.Context) *slog.Logger {
+	return slog.With(s.sessions.logAttrs(ctx)...)
+}
+
+// update changes the session with the given ID and returns the result.
+func (ss *sessions) update(id string, change func(*Session)) (Session, bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	s, ok := ss.byID[id]
+	if !ok {
+		return Session{}, false
+	}
+	change(s)
+	return *s, true
+}
+
+func (ss *sessions) list() []Session {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	list := make([]Session, 0, len(ss.byID))
+	for _, s := range ss.byID {
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].StartedAt.Before(list[j].StartedAt) })
+	return list
+}
+
+// Sessions returns the initialized client sessions, oldest first.
+func (b *Bridge) Sessions() []Session {
+	return b.cfg.sessions.list()
+}
+
+// endSessions forgets the state of the sessions HTTP clients end with a
+// DELETE request.
+func endSessions(next http.Handler, b *Bridge) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		if id := r.Header.Get("Mcp-Session-Id"); r.Method == http.MethodDelete && id != "" {
+			b.cfg.sessions.close(id)
+		}
+	})
+}
+
+// sessionRun returns the Taskfile, working directory and task to run for
+// the session of ctx, and the env of its profile. A session's dir stands in
+// for dir: the Taskfile and the task's own dir move to the same place under
+// it, as in another checkout of the same repository. The Taskfile stays
+// where it is when the session's dir has none.
+func (s *settings) sessionRun(ctx context.Context, taskfilePath string, dir string, task inspector.TaskDefinition) (string, string, inspector.TaskDefinition, map[string]string) {
+	session, ok := s.sessions.get(ctx)
+	if !ok {
+		return taskfilePath, dir, task, nil
+	}
+	env := s.profiles[session.Profile]
+	if session.Dir == "" {
+		return taskfilePath, dir, task, env
+	}
+	if moved := movePath(taskfilePath, dir, session.Dir); moved != taskfilePath {
+		if _, err := os.Stat(moved); err == nil {
+			taskfilePath = moved
+		}
+	}
+	if task.Dir != "" {
+		task.Dir = movePath(task.Dir, dir, session.Dir)
+	}
+	return taskfilePath, session.Dir, task, env
+}
+
+// envPairs returns env as sorted KEY=value pairs.
+func envPairs(env map[string]string) []string {
+	pairs := make([]string, 0, len(env))
+	for key, value := range env {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// movePath moves p from under the directory from to the same place under
+// to. Paths outside from are returned as they are.
+func movePath(p string, from string, to string) string {
+	rel, err := filepath.Rel(from, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return p
+	}
+	return filepath.Join(to, rel)
+}
+
+// sessionTools returns the configure_session tool, or nothing when there is
+// neither a directory nor a profile to choose.
+func (s *settings) sessionTools() []server.ServerTool {
+	if len(s.sessionDirs) == 0 && len(s.profiles) == 0 {
+		return nil
+	}
+	var profiles []string
+	for name := range s.profiles {
+		profiles = append(profiles, name)
+	}
+	sort.Strings(profiles)
+	return []server.ServerTool{
+		{
+			Tool: mcp.NewTool("configure_session",
+				mcp.WithDescription("Set the working directory and env profile of the tasks this session runs, e.g. to work on another checkout. Omitted arguments are left unchanged; an empty string restores the server's default. Call it without arguments to see the current settings."),
+				mcp.WithString("dir", mcp.Description(fmt.Sprintf("Absolute path of the directory to run tasks from. Allowed: %s.", describePatterns(s.sessionDirs)))),
+				mcp.WithString("profile", mcp.Description(fmt.Sprintf("Env profile to run tasks with. Available: %s.", describePatterns(profiles)))),
+			),
+			Handler: s.handleConfigureSession,
+		},
+	}
+}
+
+// describePatterns lists patterns for a tool description.
+func describePatterns(patterns []string) string {
+	if len(patterns) == 0 {
+		return "none"
+	}
+	return strings.Join(patterns, ", ")
+}
+
+func (s *settings) handleConfigureSession(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return mcp.NewToolResultError("configure_session can only be called by an initialized client session"), nil
+	}
+	args := request.GetArguments()
+	var dir, profile *string
+	for name, target := range map[string]**string{"dir": &dir, "profile": &profile} {
+		raw, ok := args[name]
+		if !ok || raw == nil {
+			continue
+		}
+		value, ok := raw.(string)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid arguments: %s must be a string", name)), nil
+		}
+		*target = &value
+	}
+	if dir != nil && *dir != "" {
+		resolved, err := s.sessionDir(*dir)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid dir: %v", err)), nil
+		}
+		dir = &resolved
+	}
+	if profile != nil && *profile != "" {
+		if _, ok := s.profiles[*profile]; !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("Unknown profile %q", *profile)), nil
+		}
+	}
+
+	updated, ok := s.sessions.update(session.SessionID(), func(state *Session) {
+		if dir != nil {
+			state.Dir = *dir
+		}
+		if profile != nil {
+			state.Profile = *profile
+		}
+	})
+	if !ok {
+		return mcp.NewToolResultError("configure_session can only be called by an initialized client session"), nil
+	}
+	result := mcp.NewToolResultText(describeSession(updated))
+	result.Meta = map[string]any{sessionMetaKey: updated}
+	return result, nil
+}
+
+// sessionDir checks that dir is a directory sessions may use and returns it
+// with symlinks resolved, so links can't lead out of the allowed ones.
+func (s *settings) sessionDir(dir string) (string, error) {
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("%s is not an absolute path", dir)
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+	if !matchesAny(resolved, s.sessionDirs) {
+		return "", fmt.Errorf("%s is not an allowed directory (allowed: %s)", dir, describePatterns(s.sessionDirs))
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+	return resolved, nil
+}
+
+// describeSession summarizes the settings of a session for the client.
+func describeSession(s Session) string {
+	dir, profile := s.Dir, s.Profile
+	if dir == "" {
+		dir = "server default"
+	}
+	if profile == "" {
+		profile = "none"
+	}
+	return fmt.Sprintf("Session %s runs tasks from: %s\nEnv profile: %s", s.ID, dir, profile)
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// testSession is a client session for calling handlers directly.
+type testSession struct{ id string }
+
+func (s testSession) Initialize()                                         {}
+func (s testSession) Initialized() bool                                   { return true }
+func (s testSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (s testSession) SessionID() string                                   { return s.id }
+
+// sessionContext returns a context of an initialized session with the given
+// ID.
+func sessionContext(cfg *settings, id string) context.Context {
+	cfg.sessions.open(id, mcp.Implementation{Name: "test"})
+	return server.NewMCPServer("tasks", "1.0.0").WithContext(context.Background(), testSession{id: id})
+}
+
+func configureSession(t *testing.T, cfg *settings, ctx context.Context, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "configure_session"
+	request.Params.Arguments = args
+	result, err := cfg.handleConfigureSession(ctx, request)
+	if err != nil {
+		t.Fatalf("configure_session error = %v", err)
+	}
+	return result
+}
+
+func TestSessionOverrides(t *testing.T) {
+	root := t.TempDir()
+	main, other := filepath.Join(root, "main"), filepath.Join(root, "other")
+	for _, dir := range []string{main, filepath.Join(other, "web")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(other, "Taskfile.yml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := newSettings([]Option{WithSessions([]string{root + "/*"}, map[string]map[string]string{"staging": {"TARGET": "staging"}})})
+	cfg.taskBin = fakeTaskBin(t, "echo \"$PWD $TARGET $*\"\n")
+	task := inspector.TaskDefinition{Name: "build", Dir: filepath.Join(main, "web")}
+	if err := os.MkdirAll(task.Dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	handler := createTaskHandler(filepath.Join(main, "Taskfile.yml"), main, task, cfg)
+	run := func(ctx context.Context) string {
+		t.Helper()
+		result, err := handler(ctx, mcp.CallToolRequest{})
+		if err != nil || result.IsError {
+			t.Fatalf("handler = %+v, %v", result, err)
+		}
+		return resultText(result)
+	}
+
+	a, b := sessionContext(cfg, "a"), sessionContext(cfg, "b")
+	result := configureSession(t, cfg, a, map[string]any{"dir": other, "profile": "staging"})
+	if result.IsError {
+		t.Fatalf("configure_session = %q", resultText(result))
+	}
+	if s := result.Meta[sessionMetaKey].(Session); s.Dir != other || s.Profile != "staging" {
+		t.Errorf("configure_session meta = %+v", s)
+	}
+
+	want := filepath.Join(other, "web") + " staging --taskfile " + filepath.Join(other, "Taskfile.yml") + " --dir " + other + " build"
+	if got := strings.TrimSpace(run(a)); got != want {
+		t.Errorf("session a runs %q, want %q", got, want)
+	}
+	want = task.Dir + "  --taskfile " + filepath.Join(main, "Taskfile.yml") + " --dir " + main + " build"
+	if got := strings.TrimSpace(run(b)); got != want {
+		t.Errorf("session b runs %q, want %q", got, want)
+	}
+	if got := strings.TrimSpace(run(context.Background())); got != want {
+		t.Errorf("call without a session runs %q, want %q", got, want)
+	}
+
+	// Empty strings restore the defaults.
+	configureSession(t, cfg, a, map[string]any{"dir": "", "profile": ""})
+	if got := strings.TrimSpace(run(a)); got != want {
+		t.Errorf("session a after resetting runs %q, want %q", got, want)
+	}
+
+	for _, args := range []map[string]any{
+		{"dir": "/etc"},
+		{"dir": "relative"},
+		{"dir": filepath.Join(root, "missing")},
+		{"profile": "prod"},
+	} {
+		if result := configureSession(t, cfg, a, args); !result.IsError {
+			t.Errorf("configure_session(%v) = %q, want an error", args, resultText(result))
+		}
+	}
+	if result := configureSession(t, cfg, context.Background(), nil); !result.IsError {
+		t.Error("configure_session without a session succeeded")
+	}
+
+	cfg.sessions.close("a")
+	if sessions := cfg.sessions.list(); len(sessions) != 1 || sessions[0].ID != "b" {
+		t.Errorf("sessions after closing a = %+v, want b", sessions)
+	}
+}
+
+func TestSessionToolsDisabled(t *testing.T) {
+	if tools := newSettings(nil).sessionTools(); tools != nil {
+		t.Errorf("sessionTools() = %+v without dirs or profiles, want none", tools)
+	}
+}
+
+func TestSessionsEndWithDelete(t *testing.T) {
+	b := &Bridge{cfg: newSettings(nil)}
+	b.cfg.sessions.open("s1", mcp.Implementation{Name: "test"})
+	handler := endSessions(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), b)
+	req := httptest.NewRequest(http.MethodDelete, "/mcp", nil)
+	req.Header.Set("Mcp-Session-Id", "s1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if sessions := b.Sessions(); len(sessions) != 0 {
+		t.Errorf("Sessions() after DELETE = %+v, want none", sessions)
+	}
+}
+
+func TestSessionLogAttrs(t *testing.T) {
+	cfg := newSettings(nil)
+	ctx := sessionContext(cfg, "s1")
+	cfg.sessions.open("s1", mcp.Implementation{Name: "cursor", Version: "1.2"})
+	want := []any{"session", "s1", "client", "cursor", "client_version", "1.2"}
+	if got := cfg.sessions.logAttrs(ctx); !reflect.DeepEqual(got, want) {
+		t.Errorf("logAttrs() = %v, want %v", got, want)
+	}
+	if got := cfg.sessions.logSuffix(ctx); got != " session=s1 client=cursor client_version=1.2" {
+		t.Errorf("logSuffix() = %q", got)
+	}
+	if got := cfg.sessions.logAttrs(context.Background()); got != nil {
+		t.Errorf("logAttrs() without a session = %v, want none", got)
+	}
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// unixScheme prefixes listen addresses that are Unix socket paths, as in
+// unix:///run/tmcp.sock.
+const unixScheme = "unix://"
+
+// DefaultSocketMode lets only the owner of a Unix socket connect to it.
+const DefaultSocketMode os.FileMode = 0600
+
+// listen listens on addr: a host:port, or a Unix socket path prefixed with
+// unix:// that gets the permissions mode.
+func listen(addr string, mode os.FileMode) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, unixScheme); ok {
+		return listenUnix(path, mode)
+	}
+	return net.Listen("tcp", addr)
+}
+
+// ListenURL returns the base URL of what is served on a listen address.
+func ListenURL(addr string) string {
+	if strings.HasPrefix(addr, unixScheme) {
+		return addr
+	}
+	return "http://" + addr
+}
+
+// listenUnix listens on a Unix socket at path with the permissions mode, or
+// DefaultSocketMode when it is zero. A socket left behind by a process that
+// died is replaced; one in use is an error, as is any other kind of file.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if path == "" {
+		return nil, errors.New("missing Unix socket path")
+	}
+	if mode == 0 {
+		mode = DefaultSocketMode
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another process is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	// The socket is created for its owner only, so no one can connect
+	// before it has its permissions.
+	var listener net.Listener
+	err := withUmask(0177, func() (err error) {
+		listener, err = net.Listen("unix", path)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
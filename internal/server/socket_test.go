@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestWithUmask(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no umask on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "private")
+	if err := withUmask(0177, func() error { return os.WriteFile(path, nil, 0666) }); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("file mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+}
+
+func TestListenUnix(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "default.sock")
+	listener, err := listen(unixScheme+path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != DefaultSocketMode {
+		t.Errorf("socket mode = %v, %v, want %v", info.Mode().Perm(), err, DefaultSocketMode)
+	}
+
+	path = filepath.Join(dir, "group.sock")
+	listener, err = listen(unixScheme+path, 0660)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0660 {
+		t.Errorf("socket mode = %v, %v, want 0660", info.Mode().Perm(), err)
+	}
+
+	// A socket left behind by a process that died is replaced.
+	path = filepath.Join(dir, "stale.sock")
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+	listener, err = listenUnix(path, 0)
+	if err != nil {
+		t.Fatalf("listenUnix() over a stale socket: %v", err)
+	}
+	listener.Close()
+
+	path = filepath.Join(dir, "file.sock")
+	if err := os.WriteFile(path, []byte("keep"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listenUnix(path, 0); err == nil || !strings.Contains(err.Error(), "not a socket") {
+		t.Errorf("listenUnix() over a regular file: error = %v, want not a socket", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "keep" {
+		t.Error("listenUnix() changed a regular file")
+	}
+}
+
+func TestListenURL(t *testing.T) {
+	if got := ListenURL("127.0.0.1:8080"); got != "http://127.0.0.1:8080" {
+		t.Errorf("ListenURL(tcp) = %q", got)
+	}
+	if got := ListenURL("unix:///run/tmcp.sock"); got != "unix:///run/tmcp.sock" {
+		t.Errorf("ListenURL(unix) = %q", got)
+	}
+}
+
+func TestServeHTTPUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp.sock")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- ServeHTTP(ctx, HTTPOptions{Addr: unixScheme + path}, []Mount{
+			{Bridge: &Bridge{mcp: server.NewMCPServer("tasks", "1.0.0")}},
+		})
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	var resp *http.Response
+	var err error
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if resp, err = client.Post("http://tmcp/mcp", "application/json", strings.NewReader(initializeRequest)); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("POST /mcp over the socket: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("POST /mcp status = %d, want 200", resp.StatusCode)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("ServeHTTP() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket still exists after serving stopped: %v", err)
+	}
+}
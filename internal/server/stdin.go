@@ -0,0 +1,31 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// stdinArgument is the argument every task accepts whose value is piped to
+// the task's stdin.
+const stdinArgument = "_stdin"
+
+// stdinDescription describes the stdin parameter of a tool.
+const stdinDescription = "Content piped to the task's standard input."
+
+// isStdinArgument reports whether the argument name is piped to the task's
+// stdin rather than passed as a variable.
+func isStdinArgument(task inspector.TaskDefinition, name string) bool {
+	return name == stdinArgument || (task.Stdin != "" && name == task.Stdin)
+}
+
+// stdinContent returns the content to pipe to the task's stdin. The
+// task's declared stdin parameter wins over _stdin.
+func stdinContent(task inspector.TaskDefinition, args map[string]any) string {
+	for _, name := range []string{task.Stdin, stdinArgument} {
+		if value, ok := args[name]; ok && name != "" && value != nil {
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
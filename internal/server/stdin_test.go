@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestTaskHandlerStdin(t *testing.T) {
+	tests := []struct {
+		name string
+		task inspector.TaskDefinition
+		args map[string]any
+		want string
+	}{
+		{
+			name: "_stdin",
+			task: inspector.TaskDefinition{Name: "lint-text", Parameters: []inspector.TaskParameter{{Name: "MODE"}}},
+			args: map[string]any{"_stdin": "hello", "MODE": "strict"},
+			want: "args: --taskfile Taskfile.yml --dir DIR lint-text MODE=strict\nstdin: hello\n",
+		},
+		{
+			name: "declared parameter",
+			task: inspector.TaskDefinition{Name: "lint-text", Stdin: "TEXT", Parameters: []inspector.TaskParameter{{Name: "TEXT"}}},
+			args: map[string]any{"TEXT": "from TEXT", "_stdin": "ignored"},
+			want: "args: --taskfile Taskfile.yml --dir DIR lint-text\nstdin: from TEXT\n",
+		},
+		{
+			name: "no stdin",
+			task: inspector.TaskDefinition{Name: "lint-text"},
+			args: map[string]any{},
+			want: "args: --taskfile Taskfile.yml --dir DIR lint-text\nstdin: \n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newSettings([]Option{WithStrictArguments()})
+			cfg.taskBin = fakeTaskBin(t, "echo \"args: $*\" | sed \"s|$4|DIR|\"; printf 'stdin: '; cat; echo\n")
+			handler := createTaskHandler("Taskfile.yml", t.TempDir(), tt.task, cfg)
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tt.args
+			result, err := handler(context.Background(), request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resultText(result); result.IsError || got != tt.want {
+				t.Errorf("result = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslateStdinParameter(t *testing.T) {
+	config := &inspector.MCPConfig{Tasks: []inspector.TaskDefinition{{Name: "lint-text", Stdin: "TEXT"}}}
+	tool := TranslateTtmcpTools(config, []ToolName{{Tool: "lint-text"}}, nil)[0]
+	prop, ok := tool.InputSchema.Properties["TEXT"].(map[string]any)
+	if !ok || prop["description"] != stdinDescription {
+		t.Errorf("TEXT property = %v, want the stdin description", tool.InputSchema.Properties["TEXT"])
+	}
+	if len(tool.InputSchema.Required) != 0 {
+		t.Errorf("required = %v, want none", tool.InputSchema.Required)
+	}
+}
@@ -0,0 +1,221 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// structuredMetaKey is the _meta key under which results carry the parsed
+// JSON output of a task. The MCP version tmcp speaks has no
+// structuredContent field yet.
+const structuredMetaKey = "tmcp/structured_content"
+
+// WithAutoJSON returns the output of every task that prints a JSON object
+// or array as structured content as well, unless the task opts out with
+// `x-mcp: {json: false}`.
+func WithAutoJSON() Option {
+	return func(s *settings) {
+		s.autoJSON = true
+	}
+}
+
+// structuredOutput parses the output of a task with an output schema and
+// checks it against the schema. Tasks without one that return JSON, see
+// WithAutoJSON, get their output parsed if it is a JSON object or array,
+// and keep just the text otherwise. It returns nil for other tasks.
+func (s *settings) structuredOutput(task inspector.TaskDefinition, output string) (any, error) {
+	if task.OutputSchema == nil {
+		if !s.returnsJSON(task) {
+			return nil, nil
+		}
+		var value any
+		if err := json.Unmarshal([]byte(output), &value); err != nil {
+			return nil, nil
+		}
+		switch value.(type) {
+		case map[string]any, []any:
+			return value, nil
+		}
+		return nil, nil
+	}
+	var value any
+	if err := json.Unmarshal([]byte(output), &value); err != nil {
+		return nil, fmt.Errorf("output is not JSON: %w", err)
+	}
+	if err := validateSchema(task.OutputSchema, value, "$"); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// returnsJSON reports whether task's output is returned as structured
+// content when it is JSON.
+func (s *settings) returnsJSON(task inspector.TaskDefinition) bool {
+	if task.JSON != nil {
+		return *task.JSON
+	}
+	return s.autoJSON
+}
+
+// addStructured attaches the structured output of a task to its result.
+func addStructured(result *mcp.CallToolResult, structured any) {
+	if structured == nil {
+		return
+	}
+	if result.Meta == nil {
+		result.Meta = map[string]any{}
+	}
+	result.Meta[structuredMetaKey] = structured
+}
+
+// schemaMismatch is the result of a task that ran but printed something
+// other than its output schema promises.
+func schemaMismatch(task string, err error, output string) *mcp.CallToolResult {
+	return mcp.NewToolResultError(fmt.Sprintf("Task %s ran, but its output doesn't match its output schema: %v\n\nstdout:\n%s", task, err, orNone(output)))
+}
+
+// outputSchemaDescription advertises a task's output schema in its tool
+// description.
+func outputSchemaDescription(schema map[string]any) string {
+	data, _ := json.Marshal(schema)
+	return "Prints JSON matching this schema: " + string(data)
+}
+
+// validateSchema checks value, decoded from JSON, against the type, enum,
+// const, properties, required, additionalProperties and items keywords of
+// schema. Other keywords are ignored. at locates value in the output for
+// error messages.
+func validateSchema(schema map[string]any, value any, at string) error {
+	if want, ok := schema["type"]; ok && !matchesType(want, value) {
+		return fmt.Errorf("%s: want %s, got %s", at, describeType(want), jsonType(value))
+	}
+	if want, ok := schema["const"]; ok && !jsonEqual(want, value) {
+		return fmt.Errorf("%s: want %s", at, jsonString(want))
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, option := range enum {
+			found = found || jsonEqual(option, value)
+		}
+		if !found {
+			return fmt.Errorf("%s: %s is not one of %s", at, jsonString(value), jsonString(enum))
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, ok := value[name]; !ok {
+					return fmt.Errorf("%s: missing property %q", at, name)
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sub, ok := properties[name].(map[string]any)
+			if !ok {
+				switch additional := schema["additionalProperties"].(type) {
+				case bool:
+					if _, declared := properties[name]; !additional && !declared {
+						return fmt.Errorf("%s: unexpected property %q", at, name)
+					}
+					continue
+				case map[string]any:
+					sub = additional
+				default:
+					continue
+				}
+			}
+			if err := validateSchema(sub, value[name], at+"."+name); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range value {
+				if err := validateSchema(items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// matchesType reports whether value has the type, or one of the types,
+// want names.
+func matchesType(want any, value any) bool {
+	switch want := want.(type) {
+	case string:
+		got := jsonType(value)
+		return got == want || (want == "number" && got == "integer")
+	case []any:
+		for _, t := range want {
+			if matchesType(t, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func describeType(want any) string {
+	if types, ok := want.([]any); ok {
+		names := make([]string, len(types))
+		for i, t := range types {
+			names[i] = fmt.Sprint(t)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(want)
+}
+
+// jsonType returns the JSON schema type of a value decoded from JSON.
+func jsonType(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if value == math.Trunc(value) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// jsonEqual compares values by their JSON encoding, so numbers from YAML
+// match the same numbers decoded from JSON.
+func jsonEqual(a any, b any) bool {
+	return jsonString(a) == jsonString(b)
+}
+
+func jsonString(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+	"gopkg.in/yaml.v3"
+)
+
+// yamlSchema decodes a schema the way the inspector reads it from a
+// Taskfile.
+func yamlSchema(t *testing.T, src string) map[string]any {
+	t.Helper()
+	var schema map[string]any
+	if err := yaml.Unmarshal([]byte(src), &schema); err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestValidateSchema(t *testing.T) {
+	schema := yamlSchema(t, `
+type: object
+required: [status, checks]
+additionalProperties: false
+properties:
+  status: {enum: [ok, failing]}
+  checks:
+    type: array
+    items:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string}
+        retries: {type: integer}
+        score: {type: [number, "null"]}
+`)
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{output: `{"status": "ok", "checks": [{"name": "lint", "retries": 2, "score": 0.5}, {"name": "test", "score": null}]}`},
+		{output: `[]`, want: "$: want object, got array"},
+		{output: `{"checks": []}`, want: `$: missing property "status"`},
+		{output: `{"status": "unknown", "checks": []}`, want: `$.status: "unknown" is not one of ["ok","failing"]`},
+		{output: `{"status": "ok", "checks": [{"name": 1}]}`, want: "$.checks[0].name: want string, got integer"},
+		{output: `{"status": "ok", "checks": [{"name": "lint", "retries": 1.5}]}`, want: "$.checks[0].retries: want integer, got number"},
+		{output: `{"status": "ok", "checks": [], "extra": true}`, want: `$: unexpected property "extra"`},
+	}
+	for _, tt := range tests {
+		var value any
+		if err := json.Unmarshal([]byte(tt.output), &value); err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if err := validateSchema(schema, value, "$"); err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("validateSchema(%s) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestTaskHandlerStructuredOutput(t *testing.T) {
+	schema := yamlSchema(t, "{type: object, required: [version], properties: {version: {type: string}}}")
+	task := inspector.TaskDefinition{Name: "version", OutputSchema: schema}
+	run := func(t *testing.T, stdout string) *mcp.CallToolResult {
+		t.Helper()
+		cfg := newSettings(nil)
+		cfg.taskBin = fakeTaskBin(t, "printf '%s' '"+stdout+"'\n")
+		result, err := createTaskHandler("Taskfile.yml", t.TempDir(), task, cfg)(context.Background(), mcp.CallToolRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	result := run(t, `{"version": "1.2.0"}`)
+	if result.IsError || resultText(result) != `{"version": "1.2.0"}` {
+		t.Fatalf("result = %q, want the output", resultText(result))
+	}
+	if got := result.Meta[structuredMetaKey]; !reflect.DeepEqual(got, map[string]any{"version": "1.2.0"}) {
+		t.Errorf("structured content = %#v", got)
+	}
+
+	for _, stdout := range []string{`{"version": 1}`, "v1.2.0"} {
+		result := run(t, stdout)
+		if !result.IsError || !strings.Contains(resultText(result), "doesn't match its output schema") {
+			t.Errorf("result for %q = %q, want a schema mismatch", stdout, resultText(result))
+		}
+	}
+
+	tool := TranslateTtmcpTools(&inspector.MCPConfig{Tasks: []inspector.TaskDefinition{task}}, []ToolName{{Tool: "version"}}, nil)[0]
+	if !strings.Contains(tool.Description, `Prints JSON matching this schema: {"properties":{"version":{"type":"string"}},"required":["version"],"type":"object"}`) {
+		t.Errorf("description = %q, want the output schema", tool.Description)
+	}
+}
+
+func TestTaskHandlerAutoJSON(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name   string
+		opts   []Option
+		json   *bool
+		stdout string
+		want   any
+	}{
+		{name: "off by default", stdout: `{"ok": true}`},
+		{name: "auto-json", opts: []Option{WithAutoJSON()}, stdout: `{"ok": true}`, want: map[string]any{"ok": true}},
+		{name: "array", opts: []Option{WithAutoJSON()}, stdout: `[1, 2]`, want: []any{1.0, 2.0}},
+		{name: "text", opts: []Option{WithAutoJSON()}, stdout: `ok`},
+		{name: "scalar", opts: []Option{WithAutoJSON()}, stdout: `42`},
+		{name: "task opts in", json: &yes, stdout: `{"ok": true}`, want: map[string]any{"ok": true}},
+		{name: "task opts out", opts: []Option{WithAutoJSON()}, json: &no, stdout: `{"ok": true}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newSettings(tt.opts)
+			cfg.taskBin = fakeTaskBin(t, "printf '%s' '"+tt.stdout+"'\n")
+			task := inspector.TaskDefinition{Name: "report", JSON: tt.json}
+			result, err := createTaskHandler("Taskfile.yml", t.TempDir(), task, cfg)(context.Background(), mcp.CallToolRequest{})
+			if err != nil || result.IsError {
+				t.Fatalf("handler = %+v, %v", result, err)
+			}
+			if got := resultText(result); got != tt.stdout {
+				t.Errorf("text = %q, want %q", got, tt.stdout)
+			}
+			if got := result.Meta[structuredMetaKey]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("structured content = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
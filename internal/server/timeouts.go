@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// sessionWatchdog drops client sessions that never complete the initialize
+// handshake, or that go quiet for longer than the idle timeout. The idle
+// timeout doesn't run while a request of the session is being handled, so
+// long tool calls aren't cut off.
+type sessionWatchdog struct {
+	handshakeTimeout time.Duration
+	idleTimeout      time.Duration
+	// drop is called with the session ID and a reason when a session stalls.
+	drop func(sessionID string, reason string)
+
+	mu       sync.Mutex
+	sessions map[string]*watchedSession
+}
+
+type watchedSession struct {
+	initialized bool
+	timer       *time.Timer
+	// inFlight holds the IDs of the requests being handled.
+	inFlight map[string]bool
+}
+
+func newSessionWatchdog(handshakeTimeout, idleTimeout time.Duration, drop func(string, string)) *sessionWatchdog {
+	return &sessionWatchdog{
+		handshakeTimeout: handshakeTimeout,
+		idleTimeout:      idleTimeout,
+		drop:             drop,
+		sessions:         make(map[string]*watchedSession),
+	}
+}
+
+// register hooks the watchdog into the server lifecycle.
+func (w *sessionWatchdog) register(hooks *server.Hooks) {
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		w.start(session.SessionID())
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		w.stop(session.SessionID())
+	})
+	hooks.AddBeforeAny(func(ctx context.Context, id any, method mcp.MCPMethod, message any) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			w.begin(session.SessionID(), id)
+		}
+	})
+	hooks.AddOnSuccess(func(ctx context.Context, id any, method mcp.MCPMethod, message any, result any) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			w.end(session.SessionID(), id)
+		}
+	})
+	hooks.AddOnError(func(ctx context.Context, id any, method mcp.MCPMethod, message any, err error) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			w.end(session.SessionID(), id)
+		}
+	})
+	hooks.AddAfterInitialize(func(ctx context.Context, id any, message *mcp.InitializeRequest, result *mcp.InitializeResult) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			w.initialized(session.SessionID())
+		}
+	})
+}
+
+// start begins watching a new session. Until it initializes, only the
+// handshake timeout applies.
+func (w *sessionWatchdog) start(sessionID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ws := &watchedSession{inFlight: make(map[string]bool)}
+	if w.handshakeTimeout > 0 {
+		ws.timer = time.AfterFunc(w.handshakeTimeout, func() {
+			w.expire(sessionID, "handshake timeout")
+		})
+	}
+	w.sessions[sessionID] = ws
+}
+
+// begin stops the idle deadline of a session while request id is handled.
+func (w *sessionWatchdog) begin(sessionID string, id any) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ws, ok := w.sessions[sessionID]
+	if !ok {
+		return
+	}
+	ws.inFlight[fmt.Sprint(id)] = true
+	if ws.initialized && w.idleTimeout > 0 {
+		ws.timer.Stop()
+	}
+}
+
+// end resets the idle deadline of a session once request id and all other
+// requests of the session are handled. Requests that failed before begin,
+// like unparsable ones, are ignored.
+func (w *sessionWatchdog) end(sessionID string, id any) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ws, ok := w.sessions[sessionID]
+	if !ok || !ws.inFlight[fmt.Sprint(id)] {
+		return
+	}
+	delete(ws.inFlight, fmt.Sprint(id))
+	if len(ws.inFlight) == 0 && ws.initialized && w.idleTimeout > 0 {
+		ws.timer.Reset(w.idleTimeout)
+	}
+}
+
+// initialized switches a session from the handshake deadline to the idle one.
+func (w *sessionWatchdog) initialized(sessionID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ws, ok := w.sessions[sessionID]
+	if !ok {
+		return
+	}
+	ws.initialized = true
+	if ws.timer != nil {
+		ws.timer.Stop()
+	}
+	if w.idleTimeout > 0 {
+		ws.timer = time.AfterFunc(w.idleTimeout, func() {
+			w.expire(sessionID, "read timeout")
+		})
+	}
+}
+
+func (w *sessionWatchdog) stop(sessionID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if ws, ok := w.sessions[sessionID]; ok && ws.timer != nil {
+		ws.timer.Stop()
+	}
+	delete(w.sessions, sessionID)
+}
+
+func (w *sessionWatchdog) expire(sessionID string, reason string) {
+	w.mu.Lock()
+	_, ok := w.sessions[sessionID]
+	delete(w.sessions, sessionID)
+	w.mu.Unlock()
+	if !ok {
+		return
+	}
+	slog.Warn("Dropping stalled client session", "session", sessionID, "reason", reason)
+	w.drop(sessionID, reason)
+}
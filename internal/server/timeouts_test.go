@@ -0,0 +1,96 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionWatchdog(t *testing.T) {
+	t.Run("drops session that never initializes", func(t *testing.T) {
+		dropped := make(chan string, 1)
+		w := newSessionWatchdog(20*time.Millisecond, 0, func(id string, reason string) {
+			dropped <- reason
+		})
+		w.start("s1")
+
+		select {
+		case reason := <-dropped:
+			if reason != "handshake timeout" {
+				t.Errorf("drop reason = %q, want %q", reason, "handshake timeout")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("session was not dropped after handshake timeout")
+		}
+	})
+
+	t.Run("initialized session is not dropped without idle timeout", func(t *testing.T) {
+		dropped := make(chan string, 1)
+		w := newSessionWatchdog(20*time.Millisecond, 0, func(id string, reason string) {
+			dropped <- reason
+		})
+		w.start("s1")
+		w.initialized("s1")
+
+		select {
+		case reason := <-dropped:
+			t.Fatalf("session dropped unexpectedly: %s", reason)
+		case <-time.After(60 * time.Millisecond):
+		}
+	})
+
+	t.Run("idle session is dropped", func(t *testing.T) {
+		dropped := make(chan string, 1)
+		w := newSessionWatchdog(0, 20*time.Millisecond, func(id string, reason string) {
+			dropped <- reason
+		})
+		w.start("s1")
+		w.initialized("s1")
+
+		select {
+		case reason := <-dropped:
+			if reason != "read timeout" {
+				t.Errorf("drop reason = %q, want %q", reason, "read timeout")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("session was not dropped after idle timeout")
+		}
+	})
+
+	t.Run("stopped session is not dropped", func(t *testing.T) {
+		dropped := make(chan string, 1)
+		w := newSessionWatchdog(20*time.Millisecond, 0, func(id string, reason string) {
+			dropped <- reason
+		})
+		w.start("s1")
+		w.stop("s1")
+
+		select {
+		case reason := <-dropped:
+			t.Fatalf("session dropped unexpectedly: %s", reason)
+		case <-time.After(60 * time.Millisecond):
+		}
+	})
+	t.Run("session is not dropped during a long request", func(t *testing.T) {
+		dropped := make(chan string, 1)
+		w := newSessionWatchdog(0, 20*time.Millisecond, func(id string, reason string) {
+			dropped <- reason
+		})
+		w.start("s1")
+		w.initialized("s1")
+		w.begin("s1", 1)
+		// An unparsable message fails without beginning.
+		w.end("s1", 2)
+
+		select {
+		case reason := <-dropped:
+			t.Fatalf("session dropped during a request: %s", reason)
+		case <-time.After(60 * time.Millisecond):
+		}
+		w.end("s1", 1)
+		select {
+		case <-dropped:
+		case <-time.After(time.Second):
+			t.Fatal("session was not dropped after the request and the idle timeout")
+		}
+	})
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package server
+
+// withUmask runs fn; systems without a umask create files as they are.
+func withUmask(mask int, fn func() error) error {
+	return fn()
+}
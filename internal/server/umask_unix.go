@@ -0,0 +1,13 @@
+//go:build unix
+
+package server
+
+import "syscall"
+
+// withUmask runs fn with the process umask set to mask, so the files fn
+// creates never have more permissions than it allows, not even briefly.
+func withUmask(mask int, fn func() error) error {
+	old := syscall.Umask(mask)
+	defer syscall.Umask(old)
+	return fn()
+}
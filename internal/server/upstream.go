@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// upstreamConnectTimeout bounds connecting to an upstream and listing its
+// tools, so a hung upstream doesn't keep the bridge from starting.
+const upstreamConnectTimeout = 30 * time.Second
+
+// Upstream is another MCP server whose tools a bridge serves alongside its
+// tasks.
+type Upstream struct {
+	Name string
+	// Prefix is prepended to the upstream's tool names; Name followed by
+	// "_" when empty.
+	Prefix string
+	// Include and Exclude filter the upstream's tools with path.Match
+	// patterns of their original names.
+	Include []string
+	Exclude []string
+	// Connect returns an initialized client of the upstream.
+	Connect func(ctx context.Context) (mcpclient.MCPClient, error)
+}
+
+// WithUpstreams serves the tools of other MCP servers alongside the tasks.
+// Calls of their tools go through the bridge's rate limit, policies and
+// approvals, with the prefixed tool name standing for the task name, and
+// are then forwarded to the upstream. Upstreams that can't be reached when
+// the bridge starts are skipped with a warning.
+func WithUpstreams(upstreams []Upstream) Option {
+	return func(s *settings) {
+		s.upstreams = upstreams
+	}
+}
+
+// connectUpstreams connects to every upstream and returns their tools and
+// clients. The clients must be closed when the bridge is done.
+func connectUpstreams(cfg *settings) ([]server.ServerTool, []mcpclient.MCPClient) {
+	var tools []server.ServerTool
+	var clients []mcpclient.MCPClient
+	for _, u := range cfg.upstreams {
+		c, listed, err := connectUpstream(u)
+		if err != nil {
+			slog.Warn("Skipping upstream MCP server", "upstream", u.Name, "error", err)
+			continue
+		}
+		clients = append(clients, c)
+		prefix := u.Prefix
+		if prefix == "" {
+			prefix = u.Name + "_"
+		}
+		served := 0
+		for _, tool := range listed {
+			if !matchesFilter(tool.Name, u.Include, u.Exclude) {
+				continue
+			}
+			name := tool.Name
+			tool.Name = prefix + name
+			tools = append(tools, server.ServerTool{Tool: tool, Handler: upstreamHandler(u.Name, c, name, tool.Name, cfg)})
+			served++
+		}
+		slog.Info("Serving tools of upstream MCP server", "upstream", u.Name, "tools", served)
+	}
+	return tools, clients
+}
+
+func connectUpstream(u Upstream) (mcpclient.MCPClient, []mcp.Tool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), upstreamConnectTimeout)
+	defer cancel()
+	c, err := u.Connect(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	listed, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		c.Close()
+		return nil, nil, fmt.Errorf("listing tools: %w", err)
+	}
+	return c, listed.Tools, nil
+}
+
+// upstreamHandler checks a call of the upstream tool served as tool and
+// forwards it to the upstream as name.
+func upstreamHandler(upstream string, c mcpclient.MCPClient, name string, tool string, cfg *settings) server.ToolHandlerFunc {
+	// Policies and approvals match the served tool name like a task name.
+	task := inspector.TaskDefinition{Name: tool}
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !cfg.limiter.allow() {
+			return mcp.NewToolResultError(fmt.Sprintf("Rate limit exceeded: at most %d tool calls per minute", cfg.limiter.limit())), nil
+		}
+		if p, denied := cfg.deniedBy(ctx, request, task); denied {
+			return policyDenial(p), nil
+		}
+		if cfg.needsApproval(task) {
+			if denial := cfg.requestApproval(ctx, task.Name, request); denial != nil {
+				return denial, nil
+			}
+		}
+
+		forward := mcp.CallToolRequest{}
+		forward.Params.Name = name
+		forward.Params.Arguments = request.Params.Arguments
+		forward.Params.Meta = request.Params.Meta
+		result, err := c.CallTool(ctx, forward)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error calling %s on upstream %s: %v", name, upstream, err)), nil
+		}
+		return result, nil
+	}
+}
+
+// Close disconnects from the upstream MCP servers.
+func (b *Bridge) Close() error {
+	return closeClients(b.upstreams)
+}
+
+func closeClients(clients []mcpclient.MCPClient) error {
+	var first error
+	for _, c := range clients {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/config"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/policy"
+)
+
+// testUpstream returns an upstream served in-process with an echo and a
+// drop_tables tool.
+func testUpstream(t *testing.T) Upstream {
+	s := server.NewMCPServer("upstream", "1.0.0", server.WithToolCapabilities(true))
+	s.AddTool(mcp.NewTool("echo", mcp.WithString("text")), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("echo: " + request.GetString("text", "")), nil
+	})
+	s.AddTool(mcp.NewTool("drop_tables"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("dropped"), nil
+	})
+	return Upstream{
+		Name: "db",
+		Connect: func(ctx context.Context) (mcpclient.MCPClient, error) {
+			c, err := mcpclient.NewInProcessClient(s)
+			if err != nil {
+				return nil, err
+			}
+			if err := c.Start(ctx); err != nil {
+				return nil, err
+			}
+			initRequest := mcp.InitializeRequest{}
+			initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+			if _, err := c.Initialize(ctx, initRequest); err != nil {
+				return nil, err
+			}
+			return c, nil
+		},
+	}
+}
+
+func callUpstreamTool(t *testing.T, tools []server.ServerTool, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	for _, tool := range tools {
+		if tool.Tool.Name != name {
+			continue
+		}
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = args
+		result, err := tool.Handler(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	t.Fatalf("no tool %s", name)
+	return nil
+}
+
+func TestConnectUpstreams(t *testing.T) {
+	expr, err := policy.Parse(`task matches "db_drop*"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	broken := Upstream{Name: "broken", Connect: func(ctx context.Context) (mcpclient.MCPClient, error) {
+		return nil, errors.New("connection refused")
+	}}
+	cfg := newSettings([]Option{
+		WithUpstreams([]Upstream{broken, testUpstream(t)}),
+		WithPolicies([]config.Policy{{Name: "no-drops", Deny: expr.String(), Expr: expr}}),
+	})
+	tools, clients := connectUpstreams(cfg)
+	defer closeClients(clients)
+
+	if len(clients) != 1 {
+		t.Fatalf("connected %d upstreams, want 1", len(clients))
+	}
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Tool.Name)
+	}
+	if strings.Join(names, ",") != "db_drop_tables,db_echo" {
+		t.Errorf("tools = %v", names)
+	}
+
+	result := callUpstreamTool(t, tools, "db_echo", map[string]any{"text": "hi"})
+	if result.IsError || resultText(result) != "echo: hi" {
+		t.Errorf("db_echo result = %q (error %v)", resultText(result), result.IsError)
+	}
+	result = callUpstreamTool(t, tools, "db_drop_tables", nil)
+	if !result.IsError || resultText(result) != "Denied by policy no-drops" {
+		t.Errorf("db_drop_tables result = %q (error %v)", resultText(result), result.IsError)
+	}
+}
+
+func TestConnectUpstreamsFilter(t *testing.T) {
+	u := testUpstream(t)
+	u.Prefix = "sql."
+	u.Exclude = []string{"drop_*"}
+	tools, clients := connectUpstreams(newSettings([]Option{WithUpstreams([]Upstream{u})}))
+	defer closeClients(clients)
+
+	if len(tools) != 1 || tools[0].Tool.Name != "sql.echo" {
+		t.Fatalf("tools = %+v", tools)
+	}
+	if result := callUpstreamTool(t, tools, "sql.echo", map[string]any{"text": "hi"}); resultText(result) != "echo: hi" {
+		t.Errorf("sql.echo result = %q", resultText(result))
+	}
+}
@@ -0,0 +1,342 @@
+// Package slack lets people run task tools from Slack. A Bot connects to
+// Slack over Socket Mode, so it needs no public URL, receives the app's
+// slash commands and the mentions of its bot user, and posts what its
+// Handler answers back to the channel.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/websocket"
+)
+
+// DefaultAPIURL is the base URL of the Slack Web API.
+const DefaultAPIURL = "https://slack.com/api/"
+
+// maxMessageLength bounds the text of a reply; Slack truncates longer
+// messages.
+const maxMessageLength = 3900
+
+// reconnectDelay is how long to wait before reconnecting after the
+// connection was lost.
+const reconnectDelay = 5 * time.Second
+
+// Request is a command a user sent the bot.
+type Request struct {
+	// Text is the command without the slash command or the mention of the
+	// bot, e.g. "deploy ENV=prod".
+	Text    string
+	Channel string
+	User    string
+	// Command is the slash command, e.g. /tmcp; empty for mentions.
+	Command string
+}
+
+// Handler answers a request with the text to post back. It runs on its own
+// goroutine.
+type Handler func(ctx context.Context, r Request) string
+
+// Bot serves the requests of a Slack app.
+type Bot struct {
+	appToken string
+	botToken string
+	handler  Handler
+	apiURL   string
+	client   *http.Client
+	users    []string
+	channels []string
+	everyone bool
+}
+
+// Option configures a Bot.
+type Option func(*Bot)
+
+// WithAPIURL sets the base URL of the Slack Web API, DefaultAPIURL by
+// default.
+func WithAPIURL(u string) Option {
+	return func(b *Bot) {
+		b.apiURL = strings.TrimSuffix(u, "/") + "/"
+	}
+}
+
+// WithUsers only serves requests of the users with the given IDs. Others
+// are told they aren't allowed.
+func WithUsers(ids []string) Option {
+	return func(b *Bot) {
+		b.users = ids
+	}
+}
+
+// WithChannels only serves requests in the channels with the given IDs.
+func WithChannels(ids []string) Option {
+	return func(b *Bot) {
+		b.channels = ids
+	}
+}
+
+// WithEveryone serves everyone who can reach the bot when neither users
+// nor channels are given. Without it, such a bot serves no one.
+func WithEveryone() Option {
+	return func(b *Bot) {
+		b.everyone = true
+	}
+}
+
+// New returns a bot that connects with the app-level token (xapp-...) and
+// posts with the bot token (xoxb-...).
+func New(appToken, botToken string, handler Handler, opts ...Option) *Bot {
+	b := &Bot{appToken: appToken, botToken: botToken, handler: handler, apiURL: DefaultAPIURL, client: http.DefaultClient}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// APIError is an error reported by the Slack Web API, such as
+// invalid_auth.
+type APIError struct {
+	Method string
+	Code   string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("slack %s: %s", e.Method, e.Code)
+}
+
+// Run serves requests until ctx is done, reconnecting whenever Slack
+// closes the connection. It returns early if Slack rejects the app token.
+func (b *Bot) Run(ctx context.Context) error {
+	for {
+		err := b.serve(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			return err
+		}
+		slog.Warn("Lost the Slack connection, reconnecting", "error", err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// envelope is a message of a Socket Mode connection.
+type envelope struct {
+	EnvelopeID   string          `json:"envelope_id"`
+	Type         string          `json:"type"`
+	Reason       string          `json:"reason"`
+	RetryAttempt int             `json:"retry_attempt"`
+	Payload      json.RawMessage `json:"payload"`
+}
+
+type slashCommand struct {
+	Command     string `json:"command"`
+	Text        string `json:"text"`
+	ChannelID   string `json:"channel_id"`
+	UserID      string `json:"user_id"`
+	ResponseURL string `json:"response_url"`
+}
+
+type eventCallback struct {
+	Event struct {
+		Type     string `json:"type"`
+		Text     string `json:"text"`
+		Channel  string `json:"channel"`
+		User     string `json:"user"`
+		TS       string `json:"ts"`
+		ThreadTS string `json:"thread_ts"`
+	} `json:"event"`
+}
+
+// serve serves one Socket Mode connection until it is closed.
+func (b *Bot) serve(ctx context.Context) error {
+	var opened struct {
+		URL string `json:"url"`
+	}
+	if err := b.call(ctx, "apps.connections.open", b.appToken, nil, &opened); err != nil {
+		return err
+	}
+	config, err := websocket.NewConfig(opened.URL, b.apiURL)
+	if err != nil {
+		return fmt.Errorf("connecting to Slack: %w", err)
+	}
+	conn, err := config.DialContext(ctx)
+	if err != nil {
+		return fmt.Errorf("connecting to Slack: %w", err)
+	}
+	defer conn.Close()
+	// Closing the connection ends the Receive below.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	for {
+		var env envelope
+		if err := websocket.JSON.Receive(conn, &env); err != nil {
+			return err
+		}
+		if env.EnvelopeID != "" {
+			if err := websocket.JSON.Send(conn, map[string]string{"envelope_id": env.EnvelopeID}); err != nil {
+				return err
+			}
+		}
+		switch env.Type {
+		case "hello":
+			slog.Info("Connected to Slack")
+		case "disconnect":
+			return fmt.Errorf("slack closed the connection: %s", env.Reason)
+		case "slash_commands":
+			var c slashCommand
+			if err := json.Unmarshal(env.Payload, &c); err != nil {
+				slog.Warn("Ignoring malformed slash command", "error", err)
+				continue
+			}
+			r := Request{Text: unescape(c.Text), Channel: c.ChannelID, User: c.UserID, Command: c.Command}
+			go b.answer(ctx, r, func(text string) error {
+				return b.respond(ctx, c.ResponseURL, text)
+			})
+		case "events_api":
+			// Slack retries events it thinks weren't acknowledged. Running
+			// them again could run a task twice.
+			if env.RetryAttempt > 0 {
+				continue
+			}
+			var e eventCallback
+			if err := json.Unmarshal(env.Payload, &e); err != nil || e.Event.Type != "app_mention" {
+				continue
+			}
+			thread := e.Event.ThreadTS
+			if thread == "" {
+				thread = e.Event.TS
+			}
+			r := Request{Text: mentionText(e.Event.Text), Channel: e.Event.Channel, User: e.Event.User}
+			go b.answer(ctx, r, func(text string) error {
+				return b.post(ctx, r.Channel, thread, text)
+			})
+		}
+	}
+}
+
+// answer replies to r with the handler's answer, unless r isn't allowed.
+func (b *Bot) answer(ctx context.Context, r Request, reply func(text string) error) {
+	slog.Info("Slack request", "user", r.User, "channel", r.Channel, "text", r.Text)
+	text := "You aren't allowed to run tasks here."
+	if b.allowed(r) {
+		text = b.handler(ctx, r)
+	}
+	if err := reply(truncate(text)); err != nil {
+		slog.Warn("Could not reply on Slack", "channel", r.Channel, "error", err)
+	}
+}
+
+// allowed reports whether r is from one of the users and in one of the
+// channels the bot serves. A bot limited to neither serves no one, unless
+// it was opened to everyone.
+func (b *Bot) allowed(r Request) bool {
+	if len(b.users) == 0 && len(b.channels) == 0 && !b.everyone {
+		return false
+	}
+	return (len(b.users) == 0 || slices.Contains(b.users, r.User)) &&
+		(len(b.channels) == 0 || slices.Contains(b.channels, r.Channel))
+}
+
+// post posts text to channel, in the thread of the message with the
+// timestamp thread.
+func (b *Bot) post(ctx context.Context, channel, thread, text string) error {
+	body := map[string]string{"channel": channel, "thread_ts": thread, "text": text}
+	return b.call(ctx, "chat.postMessage", b.botToken, body, nil)
+}
+
+// respond answers a slash command visibly to the whole channel.
+func (b *Bot) respond(ctx context.Context, responseURL, text string) error {
+	body := map[string]string{"response_type": "in_channel", "text": text}
+	resp, err := b.postJSON(ctx, responseURL, "", body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack rejected the response: %s", resp.Status)
+	}
+	return nil
+}
+
+// call calls a method of the Web API with token and decodes its result
+// into out, if not nil.
+func (b *Bot) call(ctx context.Context, method, token string, body any, out any) error {
+	resp, err := b.postJSON(ctx, b.apiURL+method, token, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var data json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return fmt.Errorf("slack %s: %s", method, resp.Status)
+	}
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &status); err != nil || !status.OK {
+		return &APIError{Method: method, Code: status.Error}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (b *Bot) postJSON(ctx context.Context, target, token string, body any) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return b.client.Do(req)
+}
+
+// mention matches a mention of a user, such as <@U012AB3CD>.
+var mention = regexp.MustCompile(`<@[A-Z0-9]+(\|[^>]*)?>`)
+
+// mentionText returns the text of a message without the mentions in it.
+func mentionText(text string) string {
+	return unescape(strings.Join(strings.Fields(mention.ReplaceAllString(text, " ")), " "))
+}
+
+// unescape undoes Slack's escaping of &, < and > in message text.
+func unescape(text string) string {
+	return strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&").Replace(strings.TrimSpace(text))
+}
+
+// truncate shortens text to what Slack shows in full.
+func truncate(text string) string {
+	if len(text) <= maxMessageLength {
+		return text
+	}
+	cut := maxMessageLength
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "\n… (truncated)"
+}
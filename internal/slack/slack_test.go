@@ -0,0 +1,191 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// fakeSlack serves the Web API and a Socket Mode connection that sends the
+// given envelopes. Messages posted to the API or to response URLs are sent
+// to posts, the acknowledged envelope IDs to acks.
+type fakeSlack struct {
+	*httptest.Server
+	envelopes []string
+	posts     chan map[string]string
+	acks      chan string
+}
+
+func newFakeSlack(t *testing.T, envelopes ...string) *fakeSlack {
+	f := &fakeSlack{envelopes: envelopes, posts: make(chan map[string]string, 10), acks: make(chan string, 10)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/apps.connections.open", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xapp-token" {
+			json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "invalid_auth"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "url": "ws" + strings.TrimPrefix(f.URL, "http") + "/socket"})
+	})
+	mux.Handle("/socket", websocket.Handler(func(conn *websocket.Conn) {
+		websocket.Message.Send(conn, `{"type":"hello"}`)
+		for _, env := range f.envelopes {
+			websocket.Message.Send(conn, env)
+		}
+		for {
+			var ack struct {
+				EnvelopeID string `json:"envelope_id"`
+			}
+			if err := websocket.JSON.Receive(conn, &ack); err != nil {
+				return
+			}
+			f.acks <- ack.EnvelopeID
+		}
+	}))
+	record := func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		body["path"] = r.URL.Path
+		body["authorization"] = r.Header.Get("Authorization")
+		f.posts <- body
+		w.Write([]byte(`{"ok":true}`))
+	}
+	mux.HandleFunc("/api/chat.postMessage", record)
+	mux.HandleFunc("/respond", record)
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeSlack) nextPost(t *testing.T) map[string]string {
+	t.Helper()
+	select {
+	case post := <-f.posts:
+		return post
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message was posted")
+		return nil
+	}
+}
+
+func echo(ctx context.Context, r Request) string {
+	return r.Command + " " + r.Text + " by " + r.User
+}
+
+func TestBot(t *testing.T) {
+	f := newFakeSlack(t,
+		`{"envelope_id":"1","type":"events_api","payload":{"event":{"type":"app_mention","text":"<@U0BOT> deploy ENV=prod &amp;&amp; more","channel":"C1","user":"U1","ts":"100.1"}}}`,
+		`{"envelope_id":"2","type":"events_api","retry_attempt":1,"payload":{"event":{"type":"app_mention","text":"<@U0BOT> deploy","channel":"C1","user":"U1","ts":"100.1"}}}`,
+	)
+	// The response URL is only known once the server runs.
+	f.envelopes = append(f.envelopes, `{"envelope_id":"3","type":"slash_commands","payload":{"command":"/tmcp","text":"build","channel_id":"C1","user_id":"U2","response_url":"`+f.URL+`/respond"}}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	bot := New("xapp-token", "xoxb-token", echo, WithAPIURL(f.URL+"/api"), WithUsers([]string{"U1", "U2"}))
+	go func() { done <- bot.Run(ctx) }()
+
+	posts := map[string]map[string]string{}
+	for range 2 {
+		post := f.nextPost(t)
+		posts[post["path"]] = post
+	}
+	mention := posts["/api/chat.postMessage"]
+	if mention["text"] != " deploy ENV=prod && more by U1" || mention["channel"] != "C1" || mention["thread_ts"] != "100.1" || mention["authorization"] != "Bearer xoxb-token" {
+		t.Errorf("reply to the mention = %v", mention)
+	}
+	command := posts["/respond"]
+	if command["text"] != "/tmcp build by U2" || command["response_type"] != "in_channel" {
+		t.Errorf("reply to the slash command = %v", command)
+	}
+	for _, want := range []string{"1", "2", "3"} {
+		if ack := <-f.acks; ack != want {
+			t.Errorf("acknowledged envelope %q, want %q", ack, want)
+		}
+	}
+	select {
+	case post := <-f.posts:
+		t.Errorf("retried event was answered: %v", post)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run() = %v after the context was canceled", err)
+	}
+}
+
+func TestBotNotAllowed(t *testing.T) {
+	f := newFakeSlack(t, `{"envelope_id":"1","type":"events_api","payload":{"event":{"type":"app_mention","text":"<@U0BOT> deploy","channel":"C2","user":"U1","ts":"1.1"}}}`)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	called := false
+	handler := func(ctx context.Context, r Request) string {
+		called = true
+		return ""
+	}
+	go New("xapp-token", "xoxb-token", handler, WithAPIURL(f.URL+"/api"), WithChannels([]string{"C1"})).Run(ctx)
+
+	if post := f.nextPost(t); !strings.Contains(post["text"], "aren't allowed") || called {
+		t.Errorf("reply = %q, want a refusal without calling the handler", post["text"])
+	}
+}
+
+func TestBotAllowed(t *testing.T) {
+	r := Request{User: "U1", Channel: "C1"}
+	tests := []struct {
+		name string
+		opts []Option
+		want bool
+	}{
+		{"no limits", nil, false},
+		{"everyone", []Option{WithEveryone()}, true},
+		{"listed user", []Option{WithUsers([]string{"U1"})}, true},
+		{"other user", []Option{WithUsers([]string{"U2"})}, false},
+		{"listed channel", []Option{WithChannels([]string{"C1"})}, true},
+		{"user outside the channels", []Option{WithUsers([]string{"U1"}), WithChannels([]string{"C2"})}, false},
+	}
+	for _, tt := range tests {
+		if got := New("xapp-token", "xoxb-token", echo, tt.opts...).allowed(r); got != tt.want {
+			t.Errorf("%s: allowed() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBotInvalidToken(t *testing.T) {
+	f := newFakeSlack(t)
+	err := New("xoxb-wrong", "xoxb-token", echo, WithAPIURL(f.URL+"/api")).Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid_auth") {
+		t.Errorf("Run() = %v, want invalid_auth", err)
+	}
+}
+
+func TestMentionText(t *testing.T) {
+	tests := map[string]string{
+		"<@U0BOT> deploy ENV=prod":          "deploy ENV=prod",
+		"hey <@U0BOT|tmcp>   run  test ":    "hey run test",
+		"<@U0BOT> lint DIR=a&lt;b&gt;&amp;": "lint DIR=a<b>&",
+	}
+	for text, want := range tests {
+		if got := mentionText(text); got != want {
+			t.Errorf("mentionText(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	text := strings.Repeat("é", maxMessageLength)
+	got := truncate(text)
+	if len(got) > maxMessageLength+len("\n… (truncated)") || !strings.HasSuffix(got, "(truncated)") {
+		t.Errorf("truncate() returned %d bytes", len(got))
+	}
+	if !strings.HasPrefix(got, "éé") || strings.ContainsRune(got, '�') {
+		t.Error("truncate() split a character")
+	}
+}
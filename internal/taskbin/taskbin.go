@@ -0,0 +1,142 @@
+package taskbin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+)
+
+// MinVersion is the oldest task release whose `--list --json` output tmcp
+// understands.
+var MinVersion = Version{Major: 3, Minor: 14, Patch: 0}
+
+// InstallHint points users at the go-task installation docs.
+const InstallHint = "install it from https://taskfile.dev/installation/ or pass its location with --task-bin"
+
+// Version is a parsed semantic version of the task binary.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Less reports whether v is older than other.
+func (v Version) Less(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor < other.Minor
+	}
+	return v.Patch < other.Patch
+}
+
+var versionPattern = regexp.MustCompile(`v?(\d+)\.(\d+)\.(\d+)`)
+
+// ParseVersion extracts the version from `task --version` output, which looks
+// like "Task version: v3.38.0 (h1:...)" on older releases and "3.44.0" on
+// newer ones.
+func ParseVersion(output string) (Version, error) {
+	m := versionPattern.FindStringSubmatch(output)
+	if m == nil {
+		return Version{}, fmt.Errorf("unrecognised task version output: %q", output)
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	patch, _ := strconv.Atoi(m[3])
+	return Version{Major: major, Minor: minor, Patch: patch}, nil
+}
+
+// ErrNotFound is returned by Resolve when no task binary can be located.
+var ErrNotFound = errors.New("task binary not found")
+
+// candidateDirs lists the usual install locations for task that are often
+// missing from PATH, e.g. when tmcp is launched by a desktop MCP client.
+func candidateDirs() []string {
+	var dirs []string
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs,
+			filepath.Join(home, "go", "bin"),
+			filepath.Join(home, ".local", "bin"),
+			filepath.Join(home, "bin"),
+		)
+	}
+	if gopath := os.Getenv("GOPATH"); gopath != "" {
+		dirs = append(dirs, filepath.Join(gopath, "bin"))
+	}
+	dirs = append(dirs,
+		"/usr/local/bin",
+		"/opt/homebrew/bin",
+		"/home/linuxbrew/.linuxbrew/bin",
+		"/snap/bin",
+		"/usr/bin",
+	)
+	return dirs
+}
+
+// Resolve locates the task binary. Explicit paths are checked as given;
+// bare names are looked up on PATH and then in common install locations,
+// also trying the "go-task" name some distributions use.
+func Resolve(name string) (string, error) {
+	if name == "" {
+		name = "task"
+	}
+	if filepath.Base(name) != name {
+		if _, err := os.Stat(name); err != nil {
+			return "", fmt.Errorf("%w at %s: %s", ErrNotFound, name, InstallHint)
+		}
+		return name, nil
+	}
+
+	names := []string{name}
+	if name == "task" {
+		names = append(names, "go-task")
+	}
+	for _, n := range names {
+		if path, err := exec.LookPath(n); err == nil {
+			return path, nil
+		}
+	}
+	for _, dir := range candidateDirs() {
+		for _, n := range names {
+			path := filepath.Join(dir, n)
+			if runtime.GOOS == "windows" {
+				path += ".exe"
+			}
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: %q is not on PATH or in any common install location; %s", ErrNotFound, name, InstallHint)
+}
+
+// DetectVersion runs `<path> --version` and parses the result.
+func DetectVersion(path string) (Version, error) {
+	var out bytes.Buffer
+	cmd := exec.Command(path, "--version")
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return Version{}, fmt.Errorf("running %s --version: %w", path, err)
+	}
+	return ParseVersion(out.String())
+}
+
+// CheckVersion returns an error when v is older than MinVersion.
+func CheckVersion(v Version) error {
+	if v.Less(MinVersion) {
+		return fmt.Errorf("task %s is too old: tmcp needs %s or newer for `task --list --json`; upgrade via https://taskfile.dev/installation/", v, MinVersion)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package taskbin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		output  string
+		want    Version
+		wantErr bool
+	}{
+		{output: "Task version: v3.38.0 (h1:abc)", want: Version{3, 38, 0}},
+		{output: "3.44.1\n", want: Version{3, 44, 1}},
+		{output: "Task version: v2.8.1", want: Version{2, 8, 1}},
+		{output: "Task version: devel", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.output)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseVersion(%q) error = %v, wantErr %v", tt.output, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseVersion(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	if err := CheckVersion(Version{3, 38, 0}); err != nil {
+		t.Errorf("CheckVersion(v3.38.0) error = %v, want nil", err)
+	}
+	if err := CheckVersion(MinVersion); err != nil {
+		t.Errorf("CheckVersion(MinVersion) error = %v, want nil", err)
+	}
+	if err := CheckVersion(Version{3, 9, 2}); err == nil {
+		t.Errorf("CheckVersion(v3.9.2) error = nil, want error")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	t.Run("explicit path that exists", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "task")
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		got, err := Resolve(path)
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if got != path {
+			t.Errorf("Resolve() = %q, want %q", got, path)
+		}
+	})
+
+	t.Run("explicit path that does not exist", func(t *testing.T) {
+		_, err := Resolve(filepath.Join(t.TempDir(), "missing", "task"))
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Resolve() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("unknown name", func(t *testing.T) {
+		_, err := Resolve("tmcp-definitely-not-a-real-binary")
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Resolve() error = %v, want ErrNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,161 @@
+// Package tooltest runs declarative test cases against the tools of a
+// Taskfile: each case calls a tool with arguments and checks its exit code
+// and output, so a repository can test in CI that its tools behave as
+// documented.
+package tooltest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFileName is the test file tmcp test looks for in the current
+// directory.
+const DefaultFileName = "tmcp.test.yaml"
+
+// Suite is a file of test cases for one Taskfile.
+type Suite struct {
+	// Taskfile is resolved relative to the test file. When empty, the
+	// Taskfile task would use from the test file's directory is tested.
+	Taskfile string `yaml:"taskfile"`
+	Tests    []Case `yaml:"tests"`
+
+	// Path is the file the suite was loaded from.
+	Path string `yaml:"-"`
+}
+
+// Case calls a tool and checks the result.
+type Case struct {
+	// Name defaults to the tool's name.
+	Name      string         `yaml:"name"`
+	Tool      string         `yaml:"tool"`
+	Arguments map[string]any `yaml:"args"`
+	Expect    Expect         `yaml:"expect"`
+
+	// Line is the line of the test file the case starts on.
+	Line int `yaml:"-"`
+}
+
+// UnmarshalYAML decodes a case, recording its line.
+func (c *Case) UnmarshalYAML(node *yaml.Node) error {
+	type plain Case
+	if err := node.Decode((*plain)(c)); err != nil {
+		return err
+	}
+	c.Line = node.Line
+	return nil
+}
+
+// Expect is what a case expects of its call. Without ExitCode or Error, the
+// call must succeed.
+type Expect struct {
+	// ExitCode is the exit code of the task.
+	ExitCode *int `yaml:"exit_code"`
+	// Error expects the call to fail, however it does.
+	Error bool `yaml:"error"`
+	// Output and NotOutput are regular expressions the result text must
+	// and must not match.
+	Output    string `yaml:"output"`
+	NotOutput string `yaml:"not_output"`
+
+	output    *regexp.Regexp
+	notOutput *regexp.Regexp
+}
+
+// Load reads a test file.
+func Load(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading tests %s: %w", path, err)
+	}
+	suite := &Suite{Path: path}
+	if err := yaml.Unmarshal(data, suite); err != nil {
+		return nil, fmt.Errorf("parsing tests %s: %w", path, err)
+	}
+	if err := suite.compile(); err != nil {
+		return nil, fmt.Errorf("invalid tests %s: %w", path, err)
+	}
+	if suite.Taskfile != "" && !filepath.IsAbs(suite.Taskfile) {
+		suite.Taskfile = filepath.Join(filepath.Dir(path), suite.Taskfile)
+	}
+	return suite, nil
+}
+
+// compile validates the cases and compiles their patterns.
+func (s *Suite) compile() error {
+	if len(s.Tests) == 0 {
+		return errors.New("no tests")
+	}
+	names := make(map[string]bool, len(s.Tests))
+	for i := range s.Tests {
+		c := &s.Tests[i]
+		if c.Tool == "" {
+			return fmt.Errorf("tests[%d]: tool is required", i)
+		}
+		if c.Name == "" {
+			c.Name = c.Tool
+		}
+		if names[c.Name] {
+			return fmt.Errorf("tests[%d]: duplicate name %q", i, c.Name)
+		}
+		names[c.Name] = true
+		if c.Expect.Error && c.Expect.ExitCode != nil && *c.Expect.ExitCode == 0 {
+			return fmt.Errorf("test %s: error contradicts exit_code 0", c.Name)
+		}
+		var err error
+		if c.Expect.output, err = compile(c.Expect.Output); err != nil {
+			return fmt.Errorf("test %s: output: %w", c.Name, err)
+		}
+		if c.Expect.notOutput, err = compile(c.Expect.NotOutput); err != nil {
+			return fmt.Errorf("test %s: not_output: %w", c.Name, err)
+		}
+	}
+	return nil
+}
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
+
+// Outcome is the result of a case's call.
+type Outcome struct {
+	IsError bool
+	// ExitCode is the task's exit code: 0 for a successful call, -1 when
+	// the call failed without running the task to completion.
+	ExitCode int
+	Output   string
+}
+
+// Check returns why outcome doesn't meet the expectations of c; none when
+// the case passes.
+func (c Case) Check(outcome Outcome) []string {
+	var failures []string
+	e := c.Expect
+	switch {
+	case e.ExitCode != nil:
+		if outcome.ExitCode != *e.ExitCode {
+			failures = append(failures, fmt.Sprintf("exit code %d, want %d", outcome.ExitCode, *e.ExitCode))
+		}
+	case e.Error:
+		if !outcome.IsError {
+			failures = append(failures, "call succeeded, want an error")
+		}
+	case outcome.IsError:
+		failures = append(failures, fmt.Sprintf("call failed with exit code %d, want success", outcome.ExitCode))
+	}
+	if e.output != nil && !e.output.MatchString(outcome.Output) {
+		failures = append(failures, fmt.Sprintf("output does not match %q", e.Output))
+	}
+	if e.notOutput != nil && e.notOutput.MatchString(outcome.Output) {
+		failures = append(failures, fmt.Sprintf("output matches %q", e.NotOutput))
+	}
+	return failures
+}
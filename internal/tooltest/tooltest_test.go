@@ -0,0 +1,94 @@
+package tooltest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeSuite(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), DefaultFileName)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeSuite(t, `taskfile: tasks/Taskfile.yml
+tests:
+  - tool: build
+  - name: greets by name
+    tool: greet
+    args: {NAME: Ada}
+    expect:
+      output: Hello, Ada
+`)
+	suite, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(filepath.Dir(path), "tasks", "Taskfile.yml"); suite.Taskfile != want {
+		t.Errorf("Taskfile = %q, want %q", suite.Taskfile, want)
+	}
+	if suite.Tests[0].Name != "build" {
+		t.Errorf("Name = %q, want the tool's name", suite.Tests[0].Name)
+	}
+	if got := suite.Tests[1].Arguments; !reflect.DeepEqual(got, map[string]any{"NAME": "Ada"}) {
+		t.Errorf("Arguments = %v", got)
+	}
+	if suite.Tests[0].Line != 3 || suite.Tests[1].Line != 4 {
+		t.Errorf("Lines = %d, %d, want 3, 4", suite.Tests[0].Line, suite.Tests[1].Line)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for name, tc := range map[string]struct {
+		content string
+		want    string
+	}{
+		"no tests":       {"taskfile: Taskfile.yml\n", "no tests"},
+		"no tool":        {"tests:\n  - name: x\n", "tool is required"},
+		"duplicate":      {"tests:\n  - tool: a\n  - tool: a\n", `duplicate name "a"`},
+		"bad pattern":    {"tests:\n  - tool: a\n    expect: {output: '('}\n", "output:"},
+		"error and zero": {"tests:\n  - tool: a\n    expect: {error: true, exit_code: 0}\n", "contradicts"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load(writeSuite(t, tc.content))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Load() error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	three := 3
+	for name, tc := range map[string]struct {
+		expect  string
+		outcome Outcome
+		want    []string
+	}{
+		"success":          {"{}", Outcome{Output: "ok"}, nil},
+		"unexpected error": {"{}", Outcome{IsError: true, ExitCode: 2}, []string{"call failed with exit code 2, want success"}},
+		"expected error":   {"{error: true}", Outcome{IsError: true, ExitCode: -1}, nil},
+		"missing error":    {"{error: true}", Outcome{}, []string{"call succeeded, want an error"}},
+		"exit code":        {"{exit_code: 3}", Outcome{IsError: true, ExitCode: three}, nil},
+		"wrong exit code":  {"{exit_code: 3}", Outcome{IsError: true, ExitCode: 1}, []string{"exit code 1, want 3"}},
+		"output":           {"{output: 'v\\d+'}", Outcome{Output: "built v12"}, nil},
+		"output mismatch":  {"{output: 'v\\d+', not_output: built}", Outcome{Output: "built"}, []string{`output does not match "v\\d+"`, `output matches "built"`}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			suite, err := Load(writeSuite(t, "tests:\n  - tool: build\n    expect: "+tc.expect+"\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := suite.Tests[0].Check(tc.outcome); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Check() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// maxResultLines caps how much of each result the call log shows.
+const maxResultLines = 5
+
+// callMsg delivers a tool call from the running server.
+type callMsg server.ToolCall
+
+// NewServeModel returns a model that shows the task list next to a live log
+// of the tool calls received from calls. url is where the server listens.
+func NewServeModel(config *inspector.MCPConfig, url string, calls <-chan server.ToolCall, opts ...Option) model {
+	m := NewModel(config, opts...)
+	m.calls = calls
+	m.serveURL = url
+	m.logView = viewport.New(0, 0)
+	m.logView.SetContent("Waiting for tool calls...")
+	return m
+}
+
+// waitForCall waits for the next tool call.
+func waitForCall(calls <-chan server.ToolCall) tea.Cmd {
+	return func() tea.Msg {
+		return callMsg(<-calls)
+	}
+}
+
+// resizeServe splits the window between the task list and the call log.
+func (m *model) resizeServe(width int, height int) {
+	m.listWidth = width / 2
+	m.list.SetSize(m.listWidth, height)
+	frameWidth, frameHeight := m.styles.logPane.GetFrameSize()
+	m.logView.Width = width - m.listWidth - frameWidth
+	// One line for the pane header.
+	m.logView.Height = height - frameHeight - 1
+}
+
+// serveView renders the task list and the call log side by side.
+func (m model) serveView(main string) string {
+	header := m.styles.heading.Render("Tool calls on "+m.serveURL) + m.styles.muted.Render(" ('[' / ']' to scroll)")
+	pane := m.styles.logPane.Render(header + "\n" + m.logView.View())
+	left := lipgloss.NewStyle().Width(m.listWidth).Render(main)
+	return lipgloss.JoinHorizontal(lipgloss.Top, left, pane)
+}
+
+// renderCalls formats the call log, oldest first.
+func renderCalls(calls []server.ToolCall) string {
+	var b strings.Builder
+	for _, call := range calls {
+		status := "ok"
+		if call.IsError {
+			status = "error"
+		}
+		fmt.Fprintf(&b, "%s %s (%s, %s)", call.Time.Format("15:04:05"), call.Tool, status, call.Duration.Round(time.Millisecond))
+		if call.Client != "" {
+			fmt.Fprintf(&b, " from %s", strings.TrimSpace(call.Client+" "+call.ClientVersion))
+		}
+		b.WriteString("\n")
+
+		names := make([]string, 0, len(call.Arguments))
+		for name := range call.Arguments {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&b, "  %s=%v\n", name, call.Arguments[name])
+		}
+
+		lines := strings.Split(strings.TrimRight(call.Result, "\n"), "\n")
+		if len(lines) > maxResultLines {
+			lines = append(lines[:maxResultLines], fmt.Sprintf("... (%d more lines)", len(lines)-maxResultLines))
+		}
+		for _, line := range lines {
+			fmt.Fprintf(&b, "  > %s\n", line)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/atotto/clipboard"
+)
+
+// writeClipboard is a variable so tests don't touch the real clipboard.
+var writeClipboard = clipboard.WriteAll
+
+// WithCopyActions lets the detail view copy the task's invocation command
+// ('c'), its MCP tool JSON ('t', needs WithToolPreview) and snippet, the
+// client config for this server ('s'), to the clipboard. invocations holds
+// the command of each task in the config passed to NewModel, in the same
+// order.
+func WithCopyActions(invocations []string, snippet string) Option {
+	return func(m *model) {
+		m.invocations = make(map[string]string, len(invocations))
+		for i, invocation := range invocations {
+			if i < len(m.taskConfig.Tasks) {
+				m.invocations[m.taskConfig.Tasks[i].Name] = invocation
+			}
+		}
+		m.snippet = snippet
+	}
+}
+
+// copyText returns what key copies for the selected task, and a label for
+// the status line. It returns "" when key is not a copy action.
+func (m model) copyText(key string) (text string, label string) {
+	switch key {
+	case "c":
+		return m.invocations[m.selectedTask.Name], "command"
+	case "t":
+		if tool, ok := m.tools[m.selectedTask.Name]; ok {
+			return toolJSON(tool), "tool JSON"
+		}
+	case "s":
+		return m.snippet, "client config snippet"
+	}
+	return "", ""
+}
+
+// copyToClipboard copies text and reports the outcome for the status line.
+func copyToClipboard(text string, label string) string {
+	if err := writeClipboard(text); err != nil {
+		return fmt.Sprintf("Could not copy the %s: %v", label, err)
+	}
+	return fmt.Sprintf("Copied the %s to the clipboard.", label)
+}
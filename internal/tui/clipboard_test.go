@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestCopyActions(t *testing.T) {
+	var copied string
+	defer func(orig func(string) error) { writeClipboard = orig }(writeClipboard)
+	writeClipboard = func(text string) error {
+		copied = text
+		return nil
+	}
+
+	config := &inspector.MCPConfig{Tasks: []inspector.TaskDefinition{{Name: "build"}}}
+	m := NewModel(config,
+		WithToolPreview([]mcp.Tool{mcp.NewTool("build")}),
+		WithCopyActions([]string{"task --taskfile Taskfile.yml build"}, `{"mcpServers":{}}`),
+	)
+	m.selectedTask = &config.Tasks[0]
+
+	press := func(key string) model {
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		return updated.(model)
+	}
+
+	if got := press("c"); copied != "task --taskfile Taskfile.yml build" || !strings.Contains(got.status, "Copied the command") {
+		t.Errorf("'c' copied %q, status %q", copied, got.status)
+	}
+	if press("t"); !strings.Contains(copied, `"name": "build"`) {
+		t.Errorf("'t' copied %q, want the tool JSON", copied)
+	}
+	if press("s"); copied != `{"mcpServers":{}}` {
+		t.Errorf("'s' copied %q, want the snippet", copied)
+	}
+
+	writeClipboard = func(string) error { return errors.New("no clipboard") }
+	if got := press("c"); !strings.Contains(got.status, "no clipboard") {
+		t.Errorf("status = %q, want the clipboard error", got.status)
+	}
+}
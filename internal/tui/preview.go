@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// detailTab is a tab of the task detail view.
+type detailTab int
+
+const (
+	tabDetails detailTab = iota
+	tabTool
+)
+
+// WithToolPreview adds an "MCP tool" tab to the detail view that shows each
+// task's tool exactly as clients receive it. tools holds the tool of each
+// task in the config passed to NewModel, in the same order.
+func WithToolPreview(tools []mcp.Tool) Option {
+	return func(m *model) {
+		m.tools = make(map[string]mcp.Tool, len(tools))
+		for i, tool := range tools {
+			if i < len(m.taskConfig.Tasks) {
+				m.tools[m.taskConfig.Tasks[i].Name] = tool
+			}
+		}
+	}
+}
+
+// toolJSON renders tool the way it appears in a tools/list response.
+func toolJSON(tool mcp.Tool) string {
+	data, err := json.MarshalIndent(tool, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("Error rendering tool: %v", err)
+	}
+	return string(data)
+}
+
+// tabBar renders the detail view tabs with active highlighted.
+func tabBar(st styles, active detailTab) string {
+	var tabs []string
+	for tab, title := range []string{"Details", "MCP tool"} {
+		style := st.inactiveTab
+		if detailTab(tab) == active {
+			style = st.activeTab
+		}
+		tabs = append(tabs, style.Render(title))
+	}
+	return strings.Join(tabs, " ")
+}
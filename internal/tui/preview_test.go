@@ -0,0 +1,46 @@
+package tui
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestToolJSON(t *testing.T) {
+	tool := mcp.NewTool("db_migrate",
+		mcp.WithDescription("Run migrations"),
+		mcp.WithString("TARGET", mcp.Required()),
+	)
+
+	var got struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		InputSchema struct {
+			Type       string                    `json:"type"`
+			Properties map[string]map[string]any `json:"properties"`
+			Required   []string                  `json:"required"`
+		} `json:"inputSchema"`
+	}
+	if err := json.Unmarshal([]byte(toolJSON(tool)), &got); err != nil {
+		t.Fatalf("toolJSON is not valid JSON: %v", err)
+	}
+	if got.Name != "db_migrate" || got.Description != "Run migrations" {
+		t.Errorf("name, description = %q, %q", got.Name, got.Description)
+	}
+	if got.InputSchema.Type != "object" || got.InputSchema.Properties["TARGET"]["type"] != "string" {
+		t.Errorf("inputSchema = %+v", got.InputSchema)
+	}
+	if len(got.InputSchema.Required) != 1 || got.InputSchema.Required[0] != "TARGET" {
+		t.Errorf("required = %v, want [TARGET]", got.InputSchema.Required)
+	}
+}
+
+func TestWithToolPreview(t *testing.T) {
+	config := &inspector.MCPConfig{Tasks: []inspector.TaskDefinition{{Name: "build"}, {Name: "db:migrate"}}}
+	m := NewModel(config, WithToolPreview([]mcp.Tool{mcp.NewTool("build"), mcp.NewTool("db_migrate")}))
+	if tool, ok := m.tools["db:migrate"]; !ok || tool.Name != "db_migrate" {
+		t.Errorf("tool for db:migrate = %+v, %v", tool, ok)
+	}
+}
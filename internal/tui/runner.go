@@ -0,0 +1,153 @@
+package tui
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/runner"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// Option configures the viewer model.
+type Option func(*model)
+
+// WithTaskRunner lets the viewer run tasks from the detail view with 'r'.
+func WithTaskRunner(taskfilePath string, taskBin string) Option {
+	return func(m *model) {
+		m.taskfilePath = taskfilePath
+		m.taskBin = taskBin
+		m.runner = runner.Exec{}
+	}
+}
+
+// runForm collects parameter values before a task runs.
+type runForm struct {
+	task   inspector.TaskDefinition
+	inputs []textinput.Model
+	focus  int
+}
+
+func newRunForm(task inspector.TaskDefinition) *runForm {
+	f := &runForm{task: task}
+	for _, param := range task.Parameters {
+		input := textinput.New()
+		input.Prompt = param.Name + ": "
+		input.Placeholder = param.Description
+		f.inputs = append(f.inputs, input)
+	}
+	if len(f.inputs) > 0 {
+		f.inputs[0].Focus()
+	}
+	return f
+}
+
+// vars returns the entered values as task CLI variables.
+func (f *runForm) vars() []string {
+	var vars []string
+	for i, param := range f.task.Parameters {
+		vars = append(vars, fmt.Sprintf("%s=%s", param.Name, f.inputs[i].Value()))
+	}
+	return vars
+}
+
+// move shifts focus by delta, wrapping around.
+func (f *runForm) move(delta int) tea.Cmd {
+	f.inputs[f.focus].Blur()
+	f.focus = (f.focus + delta + len(f.inputs)) % len(f.inputs)
+	return f.inputs[f.focus].Focus()
+}
+
+func (f *runForm) View(st styles) string {
+	var b strings.Builder
+	b.WriteString(st.title.Render("Run "+f.task.Name) + "\n\n")
+	for _, input := range f.inputs {
+		b.WriteString(input.View() + "\n")
+	}
+	b.WriteString("\n" + st.muted.Render("(tab/shift+tab to move, enter on the last field to run, esc to cancel)"))
+	return b.String()
+}
+
+// taskRun is a running or finished task whose output streams into a
+// viewport.
+type taskRun struct {
+	id     int
+	task   string
+	output strings.Builder
+	view   viewport.Model
+	done   bool
+	err    error
+	cancel context.CancelFunc
+}
+
+// runOutputMsg carries a line of output from run id.
+type runOutputMsg struct {
+	id   int
+	line string
+	next <-chan tea.Msg
+}
+
+// runDoneMsg reports that run id exited.
+type runDoneMsg struct {
+	id  int
+	err error
+}
+
+// startRun runs task with vars and returns the command that streams its
+// combined stdout and stderr.
+func (m *model) startRun(task inspector.TaskDefinition, vars []string, width int, height int) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.runs++
+	m.run = &taskRun{id: m.runs, task: task.Name, view: viewport.New(width, height), cancel: cancel}
+
+	args := inspector.SourceOf(m.taskfilePath).Command(inspector.Run{File: m.taskfilePath, Task: task.Name, Vars: vars, Parameters: inspector.ParameterNames(task)})
+	pr, pw := io.Pipe()
+	req := runner.Request{Bin: m.taskBin, Args: args, Stdout: pw, Stderr: pw}
+	run := m.runner
+
+	id := m.run.id
+	msgs := make(chan tea.Msg, 64)
+	waitErr := make(chan error, 1)
+	go func() {
+		err := run.Run(ctx, req)
+		pw.Close()
+		waitErr <- err
+	}()
+	go func() {
+		// Output and the final result go through one goroutine so the
+		// result always arrives last.
+		scanner := bufio.NewScanner(pr)
+		for scanner.Scan() {
+			msgs <- runOutputMsg{id: id, line: scanner.Text(), next: msgs}
+		}
+		// Keep draining so the task never blocks on a full pipe.
+		_, _ = io.Copy(io.Discard, pr)
+		msgs <- runDoneMsg{id: id, err: <-waitErr}
+		close(msgs)
+	}()
+	return waitForRun(msgs)
+}
+
+// waitForRun waits for the next message of a run.
+func waitForRun(msgs <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-msgs
+	}
+}
+
+func (r *taskRun) View(st styles) string {
+	status := st.muted.Render("running...")
+	if r.done {
+		status = st.ok.Render("finished")
+		if r.err != nil {
+			status = st.err.Render(fmt.Sprintf("failed: %v", r.err))
+		}
+	}
+	header := st.title.Render("Run "+r.task) + " " + status
+	return fmt.Sprintf("%s\n\n%s\n\n%s", header, r.view.View(), st.muted.Render("(up/down to scroll, esc to go back)"))
+}
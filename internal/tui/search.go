@@ -0,0 +1,158 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+// searchText is what the '/' search matches against: the name and usage
+// line shown in the list, followed by the description and parameter names.
+// Keeping the shown text first lets matches there be highlighted in place.
+func searchText(task inspector.TaskDefinition) string {
+	params := make([]string, len(task.Parameters))
+	for i, p := range task.Parameters {
+		params[i] = p.Name
+	}
+	return strings.Join([]string{task.Name, task.Usage, task.Description, strings.Join(params, " ")}, "\n")
+}
+
+// filterTasks fuzzy-matches the search term like list.DefaultFilter, but
+// reports matches as rune rather than byte offsets so highlighting stays
+// aligned in non-ASCII text.
+func filterTasks(term string, targets []string) []list.Rank {
+	ranks := list.DefaultFilter(term, targets)
+	for i := range ranks {
+		ranks[i].MatchedIndexes = runeIndexes(targets[ranks[i].Index], ranks[i].MatchedIndexes)
+	}
+	return ranks
+}
+
+// runeIndexes converts byte offsets into s to rune offsets.
+func runeIndexes(s string, byteIndexes []int) []int {
+	runeAt := make(map[int]int, len(s))
+	n := 0
+	for i := range s {
+		runeAt[i] = n
+		n++
+	}
+	out := make([]int, 0, len(byteIndexes))
+	for _, b := range byteIndexes {
+		out = append(out, runeAt[b])
+	}
+	return out
+}
+
+// taskMatches splits the matched rune offsets of a task's searchText into
+// offsets within its name and usage, and names the hidden fields that matched.
+func taskMatches(task inspector.TaskDefinition, matched []int) (name []int, usage []int, hidden []string) {
+	nameLen := len([]rune(task.Name))
+	usageStart := nameLen + 1
+	descStart := usageStart + len([]rune(task.Usage)) + 1
+	paramsStart := descStart + len([]rune(task.Description)) + 1
+
+	var inDesc, inParams bool
+	for _, i := range matched {
+		switch {
+		case i < nameLen:
+			name = append(name, i)
+		case i >= usageStart && i < descStart-1:
+			usage = append(usage, i-usageStart)
+		case i >= descStart && i < paramsStart-1:
+			inDesc = true
+		case i >= paramsStart:
+			inParams = true
+		}
+	}
+	if inDesc {
+		hidden = append(hidden, "description")
+	}
+	if inParams {
+		hidden = append(hidden, "parameters")
+	}
+	return name, usage, hidden
+}
+
+// taskDelegate renders tasks like list.DefaultDelegate, additionally
+// highlighting matches in the usage line and noting matches in fields the
+// list doesn't show.
+type taskDelegate struct {
+	list.DefaultDelegate
+}
+
+func newTaskDelegate() taskDelegate {
+	return taskDelegate{list.NewDefaultDelegate()}
+}
+
+func (d taskDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	li, ok := item.(listItem)
+	if !ok || m.Width() <= 0 {
+		return
+	}
+	s := &d.Styles
+	textWidth := m.Width() - s.NormalTitle.GetPaddingLeft() - s.NormalTitle.GetPaddingRight()
+
+	var (
+		isSelected  = index == m.Index()
+		emptyFilter = m.FilterState() == list.Filtering && m.FilterValue() == ""
+		isFiltered  = m.FilterState() == list.Filtering || m.FilterState() == list.FilterApplied
+	)
+	var nameMatches, usageMatches []int
+	var hidden []string
+	if isFiltered && !emptyFilter {
+		nameMatches, usageMatches, hidden = taskMatches(li.TaskDefinition, m.MatchesForItem(index))
+	}
+
+	title := truncate(li.Title(), textWidth)
+	desc := truncate(firstLine(li.Description()), textWidth)
+	if len(hidden) > 0 {
+		title += s.DimmedDesc.Inline(true).Render(fmt.Sprintf("  (matches %s)", strings.Join(hidden, ", ")))
+	}
+
+	titleStyle, descStyle := s.NormalTitle, s.NormalDesc
+	switch {
+	case emptyFilter:
+		titleStyle, descStyle = s.DimmedTitle, s.DimmedDesc
+	case isSelected && m.FilterState() != list.Filtering:
+		titleStyle, descStyle = s.SelectedTitle, s.SelectedDesc
+	}
+	if isFiltered && !emptyFilter {
+		title = highlight(title, nameMatches, titleStyle, s.FilterMatch)
+		desc = highlight(desc, usageMatches, descStyle, s.FilterMatch)
+	}
+	title = titleStyle.Render(title)
+	desc = descStyle.Render(desc)
+
+	if d.ShowDescription {
+		fmt.Fprintf(w, "%s\n%s", title, desc) //nolint: errcheck
+		return
+	}
+	fmt.Fprintf(w, "%s", title) //nolint: errcheck
+}
+
+// highlight styles the runes at matched with match on top of base.
+func highlight(s string, matched []int, base lipgloss.Style, match lipgloss.Style) string {
+	if len(matched) == 0 {
+		return s
+	}
+	unmatched := base.Inline(true)
+	return lipgloss.StyleRunes(s, matched, unmatched.Inherit(match), unmatched)
+}
+
+// truncate shortens s to width runes, ending in an ellipsis when cut.
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if width <= 0 || len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
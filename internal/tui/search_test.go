@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
+)
+
+func TestFilterTasks(t *testing.T) {
+	tasks := []inspector.TaskDefinition{
+		{Name: "build", Usage: "task build", Description: "Compile the binary"},
+		{Name: "deploy", Usage: "task deploy ENV=x", Description: "Ship to kubernetes", Parameters: []inspector.TaskParameter{{Name: "MAX_QPS"}}},
+	}
+	targets := make([]string, len(tasks))
+	for i, task := range tasks {
+		targets[i] = searchText(task)
+	}
+
+	tests := []struct {
+		term       string
+		wantTask   string
+		wantHidden []string
+	}{
+		{term: "kubernetes", wantTask: "deploy", wantHidden: []string{"description"}},
+		{term: "qps", wantTask: "deploy", wantHidden: []string{"parameters"}},
+		{term: "bld", wantTask: "build"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.term, func(t *testing.T) {
+			ranks := filterTasks(tt.term, targets)
+			if len(ranks) == 0 {
+				t.Fatalf("filterTasks(%q) found nothing", tt.term)
+			}
+			task := tasks[ranks[0].Index]
+			if task.Name != tt.wantTask {
+				t.Fatalf("filterTasks(%q) best match = %s, want %s", tt.term, task.Name, tt.wantTask)
+			}
+			_, _, hidden := taskMatches(task, ranks[0].MatchedIndexes)
+			if !reflect.DeepEqual(hidden, tt.wantHidden) {
+				t.Errorf("taskMatches() hidden = %v, want %v", hidden, tt.wantHidden)
+			}
+		})
+	}
+}
+
+func TestTaskMatches(t *testing.T) {
+	task := inspector.TaskDefinition{Name: "déploy", Usage: "task déploy"}
+	// "dé" in the name and "ta" at the start of the usage line.
+	name, usage, hidden := taskMatches(task, runeIndexes(searchText(task), []int{0, 1, 8, 9}))
+	if !reflect.DeepEqual(name, []int{0, 1}) {
+		t.Errorf("name matches = %v, want [0 1]", name)
+	}
+	if !reflect.DeepEqual(usage, []int{0, 1}) {
+		t.Errorf("usage matches = %v, want [0 1]", usage)
+	}
+	if hidden != nil {
+		t.Errorf("hidden = %v, want none", hidden)
+	}
+}
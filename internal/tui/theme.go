@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme is the color scheme of the viewer.
+type Theme string
+
+const (
+	// ThemeAuto picks light or dark colors from the terminal background.
+	ThemeAuto  Theme = "auto"
+	ThemeLight Theme = "light"
+	ThemeDark  Theme = "dark"
+	// ThemeNone renders without colors; it is used when NO_COLOR is set.
+	ThemeNone Theme = "none"
+)
+
+// ResolveTheme validates a --theme value. NO_COLOR (see https://no-color.org)
+// wins over any theme.
+func ResolveTheme(name string) (Theme, error) {
+	theme := Theme(name)
+	switch theme {
+	case ThemeAuto, ThemeLight, ThemeDark:
+	default:
+		return "", fmt.Errorf("unknown theme %q, want light, dark or auto", name)
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return ThemeNone, nil
+	}
+	return theme, nil
+}
+
+// palette holds the colors of a theme.
+type palette struct {
+	text       lipgloss.TerminalColor
+	muted      lipgloss.TerminalColor
+	accent     lipgloss.TerminalColor
+	accentText lipgloss.TerminalColor
+	err        lipgloss.TerminalColor
+	ok         lipgloss.TerminalColor
+}
+
+func themePalette(theme Theme) palette {
+	color := func(light string, dark string) lipgloss.TerminalColor {
+		switch theme {
+		case ThemeLight:
+			return lipgloss.Color(light)
+		case ThemeDark:
+			return lipgloss.Color(dark)
+		case ThemeNone:
+			return lipgloss.NoColor{}
+		default:
+			return lipgloss.AdaptiveColor{Light: light, Dark: dark}
+		}
+	}
+	return palette{
+		text:       color("#1A1A1A", "#EDEDED"),
+		muted:      color("#6B6B6B", "#8A8A8A"),
+		accent:     color("#5A3FC0", "#AD8CFF"),
+		accentText: color("#FFFFFF", "#1A1A1A"),
+		err:        color("#C0392B", "#FF6B6B"),
+		ok:         color("#2E7D32", "#7BD88F"),
+	}
+}
+
+// styles are the lipgloss styles the viewer renders with.
+type styles struct {
+	title       lipgloss.Style
+	heading     lipgloss.Style
+	text        lipgloss.Style
+	muted       lipgloss.Style
+	err         lipgloss.Style
+	ok          lipgloss.Style
+	activeTab   lipgloss.Style
+	inactiveTab lipgloss.Style
+	logPane     lipgloss.Style
+	list        list.Styles
+	items       list.DefaultItemStyles
+}
+
+func newStyles(theme Theme) styles {
+	p := themePalette(theme)
+	s := styles{
+		title:       lipgloss.NewStyle().Bold(true).Foreground(p.accent),
+		heading:     lipgloss.NewStyle().Bold(true).Foreground(p.text),
+		text:        lipgloss.NewStyle().Foreground(p.text),
+		muted:       lipgloss.NewStyle().Foreground(p.muted),
+		err:         lipgloss.NewStyle().Foreground(p.err),
+		ok:          lipgloss.NewStyle().Foreground(p.ok),
+		activeTab:   lipgloss.NewStyle().Bold(true).Reverse(true).Padding(0, 1),
+		inactiveTab: lipgloss.NewStyle().Foreground(p.muted).Padding(0, 1),
+		logPane:     lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(p.muted).Padding(0, 1),
+	}
+	if theme != ThemeNone {
+		s.activeTab = s.activeTab.Reverse(false).Background(p.accent).Foreground(p.accentText)
+	}
+
+	s.list = list.DefaultStyles()
+	s.list.Title = lipgloss.NewStyle().Bold(true).Background(p.accent).Foreground(p.accentText).Padding(0, 1)
+	if theme == ThemeNone {
+		s.list.Title = lipgloss.NewStyle().Bold(true).Reverse(true).Padding(0, 1)
+	}
+
+	s.items = list.NewDefaultItemStyles()
+	s.items.NormalTitle = s.items.NormalTitle.Foreground(p.text)
+	s.items.NormalDesc = s.items.NormalDesc.Foreground(p.muted)
+	s.items.SelectedTitle = s.items.SelectedTitle.Foreground(p.accent).BorderForeground(p.accent).Bold(true)
+	s.items.SelectedDesc = s.items.SelectedDesc.Foreground(p.accent).BorderForeground(p.accent)
+	s.items.DimmedTitle = s.items.DimmedTitle.Foreground(p.muted)
+	s.items.DimmedDesc = s.items.DimmedDesc.Foreground(p.muted)
+	return s
+}
+
+// WithTheme sets the viewer's color scheme. It defaults to ThemeAuto.
+func WithTheme(theme Theme) Option {
+	return func(m *model) {
+		m.setStyles(newStyles(theme))
+	}
+}
+
+// setStyles applies st to the model and its task list.
+func (m *model) setStyles(st styles) {
+	m.styles = st
+	m.list.Styles = st.list
+	d := newTaskDelegate()
+	d.Styles = st.items
+	m.list.SetDelegate(d)
+}
@@ -0,0 +1,31 @@
+package tui
+
+import "testing"
+
+func TestResolveTheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		noColor string
+		want    Theme
+		wantErr bool
+	}{
+		{name: "auto", want: ThemeAuto},
+		{name: "light", want: ThemeLight},
+		{name: "dark", want: ThemeDark},
+		{name: "dark", noColor: "1", want: ThemeNone},
+		{name: "solarized", wantErr: true},
+		{name: "solarized", noColor: "1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+"/"+tt.noColor, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			got, err := ResolveTheme(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveTheme(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveTheme(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,10 +2,16 @@ package tui
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/sandwichlabs/mcp-task-bridge/internal/inspector"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/runner"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
+	"github.com/sandwichlabs/mcp-task-bridge/pkg/inspector"
 )
 
 type model struct {
@@ -14,36 +20,163 @@ type model struct {
 	quitting     bool
 	taskConfig   *inspector.MCPConfig
 	selectedTask *inspector.TaskDefinition
+	styles       styles
+
+	// Set in serve mode only, see NewServeModel.
+	calls     <-chan server.ToolCall
+	serveURL  string
+	callLog   []server.ToolCall
+	logView   viewport.Model
+	listWidth int
+
+	// Set when tasks can be run from the detail view, see WithTaskRunner.
+	taskfilePath string
+	taskBin      string
+	runner       runner.Runner
+	form         *runForm
+	run          *taskRun
+	runs         int
+	width        int
+	height       int
+
+	// Set when the detail view previews MCP tools, see WithToolPreview.
+	tools    map[string]mcp.Tool
+	tab      detailTab
+	toolView viewport.Model
+
+	// Set when the detail view can copy to the clipboard, see WithCopyActions.
+	invocations map[string]string
+	snippet     string
+	status      string
 }
 
 func (m model) Init() tea.Cmd {
+	if m.calls != nil {
+		return waitForCall(m.calls)
+	}
 	return nil
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case callMsg:
+		m.callLog = append(m.callLog, server.ToolCall(msg))
+		m.logView.SetContent(renderCalls(m.callLog))
+		m.logView.GotoBottom()
+		return m, waitForCall(m.calls)
+	case runOutputMsg:
+		if m.run != nil && m.run.id == msg.id {
+			atBottom := m.run.view.AtBottom()
+			m.run.output.WriteString(msg.line + "\n")
+			m.run.view.SetContent(m.run.output.String())
+			if atBottom {
+				m.run.view.GotoBottom()
+			}
+		}
+		// Drain finished or abandoned runs too, so their goroutines exit.
+		return m, waitForRun(msg.next)
+	case runDoneMsg:
+		if m.run != nil && m.run.id == msg.id {
+			m.run.done = true
+			m.run.err = msg.err
+		}
+		return m, nil
 	case tea.KeyMsg:
+		if m.form != nil {
+			return m.updateForm(msg)
+		}
+		if m.run != nil {
+			return m.updateRun(msg)
+		}
+		m.status = ""
+		// While typing a search, keys belong to the search box.
+		if m.selectedTask == nil && m.list.FilterState() == list.Filtering && msg.String() != "ctrl+c" {
+			var cmd tea.Cmd
+			m.list, cmd = m.list.Update(msg)
+			return m, cmd
+		}
+		if m.calls != nil {
+			switch msg.String() {
+			case "[":
+				m.logView.ScrollUp(1)
+				return m, nil
+			case "]":
+				m.logView.ScrollDown(1)
+				return m, nil
+			}
+		}
 		switch msg.String() {
 		case "ctrl+c", "q":
 			m.quitting = true
 			return m, tea.Quit
 		case "enter":
+			if m.selectedTask != nil {
+				return m, nil
+			}
 			if item, ok := m.list.SelectedItem().(listItem); ok {
 				m.selectedTask = &item.TaskDefinition
+				m.tab = tabDetails
 			}
 			return m, nil
 		case "esc":
+			if m.selectedTask == nil {
+				// Let the list clear an applied search.
+				break
+			}
 			m.selectedTask = nil
 			return m, nil
+		case "tab":
+			if m.selectedTask == nil || m.tools == nil {
+				break
+			}
+			if m.tab == tabTool {
+				m.tab = tabDetails
+				return m, nil
+			}
+			m.tab = tabTool
+			m.toolView = viewport.New(m.width, m.toolHeight())
+			if tool, ok := m.tools[m.selectedTask.Name]; ok {
+				m.toolView.SetContent(toolJSON(tool))
+			} else {
+				m.toolView.SetContent("This task is not exposed as a tool.")
+			}
+			return m, nil
+		case "r":
+			if m.selectedTask == nil || m.taskBin == "" {
+				break
+			}
+			if len(m.selectedTask.Parameters) == 0 {
+				return m, m.startRun(*m.selectedTask, nil, m.width, m.runHeight())
+			}
+			m.form = newRunForm(*m.selectedTask)
+			return m, textinput.Blink
+		case "c", "t", "s":
+			if m.selectedTask == nil || m.invocations == nil {
+				break
+			}
+			if text, label := m.copyText(msg.String()); text != "" {
+				m.status = copyToClipboard(text, label)
+			}
+			return m, nil
 		}
 	case tea.WindowSizeMsg:
-		m.list.SetWidth(msg.Width)
+		m.width, m.height = msg.Width, msg.Height
+		if m.calls != nil {
+			m.resizeServe(msg.Width, msg.Height)
+			m.width = m.listWidth
+		} else {
+			m.list.SetWidth(msg.Width)
+		}
+		m.toolView.Width, m.toolView.Height = m.width, m.toolHeight()
 		return m, nil
 	}
 
 	var cmd tea.Cmd
-	if m.selectedTask == nil {
+	switch {
+	case m.selectedTask == nil:
 		m.list, cmd = m.list.Update(msg)
+	case m.tab == tabTool:
+		m.toolView, cmd = m.toolView.Update(msg)
 	}
 	return m, cmd
 }
@@ -52,24 +185,61 @@ func (m model) View() string {
 	if m.quitting {
 		return ""
 	}
-	if m.selectedTask != nil {
-		return selectedTaskView(m.selectedTask)
+	main := m.list.View()
+	switch {
+	case m.run != nil:
+		main = m.run.View(m.styles)
+	case m.form != nil:
+		main = m.form.View(m.styles)
+	case m.selectedTask != nil && m.tools == nil:
+		main = selectedTaskView(m.styles, m.selectedTask, m.taskBin != "", false)
+	case m.selectedTask != nil && m.tab == tabTool:
+		main = tabBar(m.styles, m.tab) + "\n\n" + m.toolView.View() + "\n\n" + m.styles.muted.Render("(up/down to scroll, 'tab' for details, 'esc' to go back)")
+	case m.selectedTask != nil:
+		main = tabBar(m.styles, m.tab) + "\n\n" + selectedTaskView(m.styles, m.selectedTask, m.taskBin != "", true)
 	}
-	return m.list.View()
+	if m.selectedTask != nil && m.invocations != nil && m.run == nil && m.form == nil {
+		main += "\n" + m.styles.muted.Render("(Copy: 'c' command, 't' tool JSON, 's' client config)")
+		if m.status != "" {
+			main += "\n" + m.styles.text.Render(m.status)
+		}
+	}
+	if m.calls != nil {
+		return m.serveView(main)
+	}
+	return main
 }
 
-func selectedTaskView(task *inspector.TaskDefinition) string {
+func selectedTaskView(st styles, task *inspector.TaskDefinition, canRun bool, canPreview bool) string {
 	var s string
-	s += fmt.Sprintf("Task: %s\n\n", task.Name)
-	s += fmt.Sprintf("Description:\n%s\n\n", task.Description)
-	s += fmt.Sprintf("Usage:\n%s\n\n", task.Usage)
+	section := func(heading string, body string) {
+		s += st.heading.Render(heading) + "\n" + st.text.Render(body) + "\n\n"
+	}
+	s += st.title.Render("Task: "+task.Name) + "\n\n"
+	section("Description:", task.Description)
+	section("Usage:", task.Usage)
+	if task.Docs != "" {
+		section("Docs:", task.Docs)
+	}
 	if len(task.Parameters) > 0 {
-		s += "Parameters:\n"
+		s += st.heading.Render("Parameters:") + "\n"
 		for _, p := range task.Parameters {
-			s += fmt.Sprintf("  - %s\n", p.Name)
+			line := "  - " + p.Name
+			if p.Default != nil {
+				line += fmt.Sprintf(" (default: %v)", p.Default)
+			}
+			s += st.text.Render(line) + "\n"
 		}
 	}
-	s += "\n(Press 'esc' to go back, 'q' to quit)"
+	var keys []string
+	if canRun {
+		keys = append(keys, "'r' to run")
+	}
+	if canPreview {
+		keys = append(keys, "'tab' for the MCP tool")
+	}
+	keys = append(keys, "'esc' to go back", "'q' to quit")
+	s += "\n" + st.muted.Render(fmt.Sprintf("(Press %s)", strings.Join(keys, ", ")))
 	return s
 }
 
@@ -81,16 +251,78 @@ type listItem struct {
 // Implement list.Item for listItem
 func (li listItem) Title() string       { return li.TaskDefinition.Name }
 func (li listItem) Description() string { return li.TaskDefinition.Usage }
-func (li listItem) FilterValue() string { return li.TaskDefinition.Name }
+func (li listItem) FilterValue() string { return searchText(li.TaskDefinition) }
 
-func NewModel(config *inspector.MCPConfig) model {
+func NewModel(config *inspector.MCPConfig, opts ...Option) model {
 	items := make([]list.Item, len(config.Tasks))
 	for i, task := range config.Tasks {
 		items[i] = listItem{task} // Wrap TaskDefinition in listItem
 	}
 
-	l := list.New(items, list.NewDefaultDelegate(), 0, 0)
+	l := list.New(items, newTaskDelegate(), 0, 0)
 	l.Title = "Available Tasks"
+	l.Filter = filterTasks
+	l.SetStatusBarItemName("task", "tasks")
+
+	m := model{list: l, taskConfig: config}
+	m.setStyles(newStyles(ThemeAuto))
+	for _, opt := range opts {
+		opt(&m)
+	}
+	return m
+}
+
+// updateForm handles keys while the parameter form is shown.
+func (m model) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.quitting = true
+		return m, tea.Quit
+	case "esc":
+		m.form = nil
+		return m, nil
+	case "tab", "down":
+		return m, m.form.move(1)
+	case "shift+tab", "up":
+		return m, m.form.move(-1)
+	case "enter":
+		if m.form.focus < len(m.form.inputs)-1 {
+			return m, m.form.move(1)
+		}
+		form := m.form
+		m.form = nil
+		return m, m.startRun(form.task, form.vars(), m.width, m.runHeight())
+	}
+	var cmd tea.Cmd
+	m.form.inputs[m.form.focus], cmd = m.form.inputs[m.form.focus].Update(msg)
+	return m, cmd
+}
+
+// updateRun handles keys while task output is shown.
+func (m model) updateRun(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.run.cancel()
+		m.quitting = true
+		return m, tea.Quit
+	case "esc":
+		m.run.cancel()
+		m.run = nil
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.run.view, cmd = m.run.view.Update(msg)
+	return m, cmd
+}
+
+// toolHeight is the height of the tool preview viewport, leaving room for
+// the tab bar and footer.
+func (m model) toolHeight() int {
+	return max(m.height-4, 1)
+}
 
-	return model{list: l, taskConfig: config}
+// runHeight is the height of the task output viewport, leaving room for
+// its header and footer.
+func (m model) runHeight() int {
+	return max(m.height-4, 1)
 }
@@ -4,6 +4,14 @@ import (
 	"github.com/sandwichlabs/mcp-task-bridge/cmd"
 )
 
+// Set by the release build with -ldflags "-X main.version=...".
+var (
+	version string
+	commit  string
+	date    string
+)
+
 func main() {
+	cmd.SetBuildInfo(version, commit, date)
 	cmd.Execute()
 }
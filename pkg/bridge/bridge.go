@@ -0,0 +1,191 @@
+// Package bridge embeds tmcp's Taskfile to MCP bridge in other Go programs.
+// It serves the tasks of a Taskfile, or of any other file tmcp can serve
+// such as a Makefile, as the tools of an mcp-go server, without shelling
+// out to the tmcp binary:
+//
+//	s, err := bridge.NewServer(ctx, "Taskfile.yml", bridge.WithSafeMode(nil))
+//	if err != nil {
+//		return err
+//	}
+//	return server.ServeStdio(s)
+//
+// The options are a stable subset of tmcp's own; anything configured in
+// .tmcp.yml isn't read.
+package bridge
+
+import (
+	"context"
+	"io"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"github.com/sandwichlabs/mcp-task-bridge/internal/server"
+)
+
+// DefaultServerName is the name the server reports to clients unless
+// WithServerName sets another.
+const DefaultServerName = "tmcp"
+
+// Option configures a bridge.
+type Option func(*options)
+
+type options struct {
+	serverName string
+	taskBin    string
+	server     []server.Option
+}
+
+// WithServerName sets the name the server reports to clients.
+func WithServerName(name string) Option {
+	return func(o *options) {
+		o.serverName = name
+	}
+}
+
+// WithTaskBin sets the task binary, or the program of another kind of
+// file, such as make. By default it is looked up in PATH.
+func WithTaskBin(path string) Option {
+	return func(o *options) {
+		o.taskBin = path
+	}
+}
+
+// WithDir sets the working directory tasks run from. It defaults to the
+// Taskfile's directory.
+func WithDir(dir string) Option {
+	return func(o *options) {
+		o.server = append(o.server, server.WithDir(dir))
+	}
+}
+
+// WithVars sets task variables passed to every task. Models can't set
+// them and they are left out of the tool schemas.
+func WithVars(vars map[string]string) Option {
+	return func(o *options) {
+		o.server = append(o.server, server.WithVars(vars))
+	}
+}
+
+// WithEnv sets extra environment variables for every task.
+func WithEnv(env map[string]string) Option {
+	return func(o *options) {
+		o.server = append(o.server, server.WithEnv(env))
+	}
+}
+
+// WithTaskFilter limits the tools to the tasks matching one of the include
+// patterns (all tasks when empty) and none of the exclude patterns.
+// Patterns use path.Match syntax, e.g. "db:*".
+func WithTaskFilter(include []string, exclude []string) Option {
+	return func(o *options) {
+		o.server = append(o.server, server.WithTaskFilter(include, exclude))
+	}
+}
+
+// WithToolPrefix prepends prefix to every tool name.
+func WithToolPrefix(prefix string) Option {
+	return func(o *options) {
+		o.server = append(o.server, server.WithToolPrefix(prefix))
+	}
+}
+
+// WithSafeMode enables every safeguard at once: strict argument checks, a
+// clean environment, output redaction, and dry runs for any task that is
+// neither marked read-only nor matched by one of the allow patterns.
+func WithSafeMode(allow []string) Option {
+	return func(o *options) {
+		o.server = append(o.server, server.WithSafeMode(allow))
+	}
+}
+
+// WithReadOnlyTasks serves only the tasks marked read-only.
+func WithReadOnlyTasks() Option {
+	return func(o *options) {
+		o.server = append(o.server, server.WithReadOnlyTasks())
+	}
+}
+
+// WithStrictArguments rejects tool calls with unknown, missing or
+// non-scalar arguments instead of passing them on to the task.
+func WithStrictArguments() Option {
+	return func(o *options) {
+		o.server = append(o.server, server.WithStrictArguments())
+	}
+}
+
+// WithRedaction masks secret values and common credential patterns in task
+// output.
+func WithRedaction() Option {
+	return func(o *options) {
+		o.server = append(o.server, server.WithRedaction())
+	}
+}
+
+// WithLazyDetails inspects each task the first time it is needed instead
+// of all of them up front, which keeps startup fast for large Taskfiles.
+func WithLazyDetails() Option {
+	return func(o *options) {
+		o.server = append(o.server, server.WithLazyDetails())
+	}
+}
+
+// WithLogOutput sets where the request log is written. It defaults to
+// io.Discard, unlike tmcp's, which logs to stderr.
+func WithLogOutput(w io.Writer) Option {
+	return func(o *options) {
+		o.server = append(o.server, server.WithLogOutput(w))
+	}
+}
+
+// Bridge serves the tasks of a file as MCP tools.
+type Bridge struct {
+	b *server.Bridge
+}
+
+// New inspects the file at path, a Taskfile or any other file tmcp can
+// serve, and returns a bridge with a tool per task. Inspection stops when
+// ctx is done.
+func New(ctx context.Context, path string, opts ...Option) (*Bridge, error) {
+	o := &options{serverName: DefaultServerName}
+	for _, opt := range opts {
+		opt(o)
+	}
+	serverOpts := append([]server.Option{server.WithLogOutput(io.Discard)}, o.server...)
+	b, err := server.New(ctx, path, o.taskBin, o.serverName, serverOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Bridge{b: b}, nil
+}
+
+// NewServer is New for programs that only need the MCP server, e.g. to
+// serve it alongside tools of their own.
+func NewServer(ctx context.Context, path string, opts ...Option) (*mcpserver.MCPServer, error) {
+	b, err := New(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return b.MCPServer(), nil
+}
+
+// MCPServer returns the bridge's MCP server, to serve over any transport
+// mcp-go supports or to add tools to.
+func (b *Bridge) MCPServer() *mcpserver.MCPServer {
+	return b.b.MCPServer()
+}
+
+// Tools returns the tools the bridge serves, one per task.
+func (b *Bridge) Tools() []mcp.Tool {
+	return b.b.Tools()
+}
+
+// ServeStdio serves the bridge on stdin and stdout until ctx is done or
+// the client disconnects.
+func (b *Bridge) ServeStdio(ctx context.Context) error {
+	return b.b.ServeStdio(ctx)
+}
+
+// Close releases the bridge's resources.
+func (b *Bridge) Close() error {
+	return b.b.Close()
+}
@@ -0,0 +1,95 @@
+package bridge
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const testManifest = `version: 1
+tools:
+  - name: greet
+    description: Greet someone
+    command: echo hello {{.who}}
+    read_only: true
+    params:
+      - name: who
+  - name: deploy
+    command: echo deployed
+`
+
+func TestNewServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tools.yml")
+	if err := os.WriteFile(path, []byte(testManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	s, err := NewServer(ctx, path, WithToolPrefix("demo_"), WithSafeMode(nil))
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	c, err := mcpclient.NewInProcessClient(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	init, err := c.Initialize(ctx, initRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if init.ServerInfo.Name != DefaultServerName {
+		t.Errorf("server name = %q, want %q", init.ServerInfo.Name, DefaultServerName)
+	}
+
+	tools, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tool := range tools.Tools {
+		names = append(names, tool.Name)
+	}
+	if got := strings.Join(names, ","); got != "demo_deploy,demo_greet" {
+		t.Errorf("tools = %s, want demo_deploy,demo_greet", got)
+	}
+
+	tests := []struct {
+		tool string
+		args map[string]any
+		want string
+	}{
+		{"demo_greet", map[string]any{"who": "Ada"}, "hello Ada"},
+		// Safe mode doesn't run tasks that aren't read-only.
+		{"demo_deploy", nil, "Dry run"},
+	}
+	for _, tt := range tests {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = tt.tool
+		request.Params.Arguments = tt.args
+		result, err := c.CallTool(ctx, request)
+		if err != nil {
+			t.Fatalf("CallTool(%s) error = %v", tt.tool, err)
+		}
+		text := result.Content[0].(mcp.TextContent).Text
+		if result.IsError || !strings.Contains(text, tt.want) {
+			t.Errorf("CallTool(%s) = %q (error %v), want %q", tt.tool, text, result.IsError, tt.want)
+		}
+	}
+}
+
+func TestNewMissingFile(t *testing.T) {
+	if _, err := New(context.Background(), filepath.Join(t.TempDir(), "tools.yml")); err == nil {
+		t.Error("New() error = nil, want an error for a missing file")
+	}
+}
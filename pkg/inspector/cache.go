@@ -0,0 +1,238 @@
+package inspector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Cache keeps the results of Inspect on disk, so inspecting a Taskfile
+// again is instant until it changes. Entries are keyed by a hash of the
+// Taskfile, the Taskfiles it includes, and the task and tmcp binaries that
+// inspected them.
+type Cache struct {
+	// Dir holds one <hash>.json file of MCPConfig JSON per entry.
+	Dir string
+}
+
+// DefaultCacheDir returns tmcp/inspect in the user's cache directory.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tmcp", "inspect"), nil
+}
+
+// WithCache looks up Inspect results in c before inspecting, and stores
+// them there after.
+func WithCache(c *Cache) Option {
+	return func(i *Inspector) {
+		i.cache = c
+	}
+}
+
+// load returns the cached inspection with key, if there is one.
+func (c *Cache) load(key string) (*MCPConfig, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var config MCPConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		slog.Warn("Ignoring unreadable inspection cache entry", "path", c.path(key), "error", err)
+		return nil, false
+	}
+	return &config, true
+}
+
+// store saves config under key. The file is replaced atomically, so
+// concurrent inspections of the same Taskfile can't leave it half written.
+func (c *Cache) store(key string, config *MCPConfig) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.Dir, "."+key+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+// Clear removes every entry and returns how many there were.
+func (c *Cache) Clear() (int, error) {
+	entries, err := os.ReadDir(c.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.Dir, entry.Name())); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key+".json")
+}
+
+// cacheKey returns the cache key of inspecting the Taskfile at
+// taskfilePath, as a file of source, with taskBin.
+func cacheKey(taskfilePath string, source Source, taskBin string, usagePattern *regexp.Regexp) (string, error) {
+	h := sha256.New()
+	abs, err := filepath.Abs(taskfilePath)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(h, "taskfile %s\n", abs)
+	fmt.Fprintf(h, "source %s\n", source.Name())
+	fmt.Fprintf(h, "usage %s\n", usagePattern)
+
+	// A new task or tmcp may inspect the same Taskfile differently.
+	bin, err := exec.LookPath(taskBin)
+	if err != nil {
+		return "", err
+	}
+	if err := hashBinary(h, "task", bin); err != nil {
+		return "", err
+	}
+	if self, err := os.Executable(); err == nil {
+		if err := hashBinary(h, "tmcp", self); err != nil {
+			return "", err
+		}
+	}
+
+	if err := hashTaskfiles(h, abs, map[string]bool{}); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashBinary hashes the path, size and modification time of a binary,
+// which change when it is replaced, without reading it.
+func hashBinary(h hash.Hash, name string, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(h, "%s %s %d %d\n", name, path, info.Size(), info.ModTime().UnixNano())
+	return nil
+}
+
+// taskfileNames are the names task looks for in an included directory.
+var taskfileNames = []string{"Taskfile.yml", "taskfile.yml", "Taskfile.yaml", "taskfile.yaml", "Taskfile.dist.yml", "taskfile.dist.yml", "Taskfile.dist.yaml", "taskfile.dist.yaml"}
+
+// rawIncludes is the part of a Taskfile that names the Taskfiles it
+// includes.
+type rawIncludes struct {
+	Includes map[string]yaml.Node `yaml:"includes"`
+}
+
+// hashTaskfiles hashes the contents of the Taskfile at path and of the
+// Taskfiles it includes, recursively. Includes that can't be resolved
+// without task, such as templated or remote ones, are hashed by name.
+func hashTaskfiles(h hash.Hash, path string, seen map[string]bool) error {
+	if seen[path] {
+		return nil
+	}
+	seen[path] = true
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(h, "file %s %d\n", path, len(data))
+	h.Write(data)
+
+	var raw rawIncludes
+	if yaml.Unmarshal(data, &raw) != nil {
+		// task will report it; the contents are hashed already.
+		return nil
+	}
+	names := make([]string, 0, len(raw.Includes))
+	for name := range raw.Includes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		node := raw.Includes[name]
+		include := node.Value
+		if node.Kind == yaml.MappingNode {
+			var entry struct {
+				Taskfile string `yaml:"taskfile"`
+			}
+			if node.Decode(&entry) != nil {
+				continue
+			}
+			include = entry.Taskfile
+		}
+		if include == "" || strings.Contains(include, "{{") || strings.Contains(include, "://") {
+			fmt.Fprintf(h, "include %s\n", include)
+			continue
+		}
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		if resolved, ok := resolveInclude(include); ok {
+			if err := hashTaskfiles(h, resolved, seen); err != nil {
+				return err
+			}
+			continue
+		}
+		// An optional include that doesn't exist yet.
+		fmt.Fprintf(h, "missing %s\n", include)
+	}
+	return nil
+}
+
+// resolveInclude returns the Taskfile an include refers to: the file
+// itself, or the Taskfile in the directory.
+func resolveInclude(path string) (string, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	if !info.IsDir() {
+		return path, true
+	}
+	for _, name := range taskfileNames {
+		candidate := filepath.Join(path, name)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,81 @@
+package inspector
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInspectCache(t *testing.T) {
+	dir := t.TempDir()
+	taskfilePath := filepath.Join(dir, "Taskfile.yml")
+	if err := os.WriteFile(taskfilePath, []byte("version: '3'\nincludes:\n  lib: ./lib\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	included := filepath.Join(dir, "lib", "Taskfile.yml")
+	if err := os.MkdirAll(filepath.Dir(included), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(included, []byte("version: '3'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	taskBin := filepath.Join(dir, "task")
+	if err := os.WriteFile(taskBin, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	runs := 0
+	mockExecutor := func(_ context.Context, command string, args ...string) *exec.Cmd {
+		runs++
+		output := "task: build\nBuild it"
+		if strings.Contains(strings.Join(args, " "), "--list --json") {
+			output = `{"tasks": [{"name": "build"}]}`
+		}
+		cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess", "--")
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1", "STDOUT="+output, "EXIT_CODE=0")
+		return cmd
+	}
+	cache := &Cache{Dir: filepath.Join(dir, "cache")}
+	inspect := func() *MCPConfig {
+		t.Helper()
+		inspector, err := New(WithTaskfile(taskfilePath), WithTaskBin(taskBin), WithCache(cache), withCmdExecutor(mockExecutor))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		config, err := inspector.Inspect(context.Background())
+		if err != nil {
+			t.Fatalf("Inspect() error = %v", err)
+		}
+		return config
+	}
+
+	first := inspect()
+	if runs != 2 {
+		t.Fatalf("first Inspect() ran task %d times, want 2", runs)
+	}
+	if second := inspect(); runs != 2 || !reflect.DeepEqual(first, second) {
+		t.Errorf("second Inspect() = %+v after %d runs, want %+v from the cache", second, runs, first)
+	}
+
+	// Changing an included Taskfile invalidates the entry.
+	if err := os.WriteFile(included, []byte("version: '3'\ntasks: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	inspect()
+	if runs != 4 {
+		t.Errorf("Inspect() after changing an include ran task %d times in total, want 4", runs)
+	}
+
+	removed, err := cache.Clear()
+	if err != nil || removed != 2 {
+		t.Errorf("Clear() = %d, %v, want 2 entries removed", removed, err)
+	}
+	inspect()
+	if runs != 6 {
+		t.Errorf("Inspect() after Clear() ran task %d times in total, want 6", runs)
+	}
+}
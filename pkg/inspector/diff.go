@@ -0,0 +1,130 @@
+package inspector
+
+import (
+	"reflect"
+	"sort"
+)
+
+// ConfigDiff lists how the tasks of one inspection differ from another's.
+type ConfigDiff struct {
+	Added   []string
+	Removed []string
+	Changed []TaskChange
+	// Locations are the locations of the added and changed tasks in the
+	// later inspection, as TaskDefinition.Location, where known.
+	Locations map[string]string `json:",omitempty"`
+}
+
+// TaskChange describes a task present in both inspections that changed.
+type TaskChange struct {
+	Task string
+	// Fields names the changed fields other than Parameters, e.g.
+	// Description or ReadOnly.
+	Fields            []string
+	AddedParameters   []string
+	RemovedParameters []string
+}
+
+// Empty reports whether the inspections expose the same tasks.
+func (d ConfigDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff compares two inspections of a Taskfile. Tasks are matched by name and
+// reported in name order.
+func Diff(before *MCPConfig, after *MCPConfig) ConfigDiff {
+	oldTasks := tasksByName(before)
+	newTasks := tasksByName(after)
+
+	var d ConfigDiff
+	for _, name := range sortedNames(newTasks) {
+		newTask := newTasks[name]
+		oldTask, ok := oldTasks[name]
+		if !ok {
+			d.Added = append(d.Added, name)
+		} else if change, changed := diffTask(oldTask, newTask); changed {
+			d.Changed = append(d.Changed, change)
+		} else {
+			continue
+		}
+		if location := newTask.Location(); location != "" {
+			if d.Locations == nil {
+				d.Locations = map[string]string{}
+			}
+			d.Locations[name] = location
+		}
+	}
+	for _, name := range sortedNames(oldTasks) {
+		if _, ok := newTasks[name]; !ok {
+			d.Removed = append(d.Removed, name)
+		}
+	}
+	return d
+}
+
+// diffTask compares the fields that make up a task's MCP tool.
+func diffTask(before TaskDefinition, after TaskDefinition) (TaskChange, bool) {
+	change := TaskChange{Task: after.Name}
+	fields := []struct {
+		name          string
+		before, after any
+	}{
+		{"Description", before.Description, after.Description},
+		{"Usage", before.Usage, after.Usage},
+		{"ReadOnly", before.ReadOnly, after.ReadOnly},
+		{"Title", before.Title, after.Title},
+		{"Destructive", before.Destructive, after.Destructive},
+		{"Idempotent", before.Idempotent, after.Idempotent},
+		{"Docs", before.Docs, after.Docs},
+		{"OutputSchema", before.OutputSchema, after.OutputSchema},
+	}
+	for _, f := range fields {
+		if !reflect.DeepEqual(f.before, f.after) {
+			change.Fields = append(change.Fields, f.name)
+		}
+	}
+
+	oldParams := parameterSet(before)
+	newParams := parameterSet(after)
+	for _, param := range after.Parameters {
+		if !oldParams[param.Name] {
+			change.AddedParameters = append(change.AddedParameters, param.Name)
+		}
+	}
+	for _, param := range before.Parameters {
+		if !newParams[param.Name] {
+			change.RemovedParameters = append(change.RemovedParameters, param.Name)
+		}
+	}
+
+	changed := len(change.Fields) > 0 || len(change.AddedParameters) > 0 || len(change.RemovedParameters) > 0
+	return change, changed
+}
+
+func tasksByName(config *MCPConfig) map[string]TaskDefinition {
+	tasks := make(map[string]TaskDefinition)
+	if config == nil {
+		return tasks
+	}
+	for _, task := range config.Tasks {
+		tasks[task.Name] = task
+	}
+	return tasks
+}
+
+func sortedNames(tasks map[string]TaskDefinition) []string {
+	names := make([]string, 0, len(tasks))
+	for name := range tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func parameterSet(task TaskDefinition) map[string]bool {
+	set := make(map[string]bool, len(task.Parameters))
+	for _, param := range task.Parameters {
+		set[param.Name] = true
+	}
+	return set
+}
@@ -0,0 +1,42 @@
+package inspector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	before := &MCPConfig{Tasks: []TaskDefinition{
+		{Name: "build", Description: "Build"},
+		{Name: "deploy", Description: "Deploy", Parameters: []TaskParameter{{Name: "ENV"}, {Name: "REGION"}}},
+		{Name: "legacy"},
+	}}
+	// Moving a task doesn't change it.
+	after := &MCPConfig{Tasks: []TaskDefinition{
+		{Name: "build", Description: "Build", File: "Taskfile.yml", Line: 3, Column: 3},
+		{Name: "deploy", Description: "Deploy to an environment", ReadOnly: true, Parameters: []TaskParameter{{Name: "ENV"}, {Name: "VERSION"}}, File: "Taskfile.yml", Line: 5, Column: 3},
+		{Name: "test"},
+	}}
+
+	want := ConfigDiff{
+		Added:   []string{"test"},
+		Removed: []string{"legacy"},
+		Changed: []TaskChange{{
+			Task:              "deploy",
+			Fields:            []string{"Description", "ReadOnly"},
+			AddedParameters:   []string{"VERSION"},
+			RemovedParameters: []string{"REGION"},
+		}},
+		Locations: map[string]string{"deploy": "Taskfile.yml:5:3"},
+	}
+	got := Diff(before, after)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+	if got.Empty() {
+		t.Error("Empty() = true, want false")
+	}
+	if d := Diff(before, before); !d.Empty() {
+		t.Errorf("Diff(before, before) = %+v, want no changes", d)
+	}
+}
@@ -0,0 +1,99 @@
+package inspector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Graph is the dependency graph of the tasks of an MCPConfig.
+type Graph struct {
+	// Tasks are the names of the tasks, in the order of the config,
+	// followed by the deps that aren't tasks of the config, such as tasks of
+	// included Taskfiles.
+	Tasks []string
+	// Deps maps task names to the tasks they run first.
+	Deps map[string][]string
+}
+
+// Graph returns the dependency graph of the tasks.
+func (c MCPConfig) Graph() Graph {
+	g := Graph{Deps: make(map[string][]string)}
+	known := make(map[string]bool, len(c.Tasks))
+	for _, task := range c.Tasks {
+		g.Tasks = append(g.Tasks, task.Name)
+		known[task.Name] = true
+		if len(task.Deps) > 0 {
+			g.Deps[task.Name] = task.Deps
+		}
+	}
+	for _, task := range c.Tasks {
+		for _, dep := range task.Deps {
+			if !known[dep] {
+				g.Tasks = append(g.Tasks, dep)
+				known[dep] = true
+			}
+		}
+	}
+	return g
+}
+
+// Requires returns the tasks that run before task, its deps and theirs in
+// turn, each once, deepest first. Dependency cycles, which task rejects,
+// are broken.
+func (g Graph) Requires(task string) []string {
+	var order []string
+	seen := map[string]bool{task: true}
+	var visit func(string)
+	visit = func(name string) {
+		for _, dep := range g.Deps[name] {
+			if seen[dep] {
+				continue
+			}
+			seen[dep] = true
+			visit(dep)
+			order = append(order, dep)
+		}
+	}
+	visit(task)
+	return order
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language, with an edge from
+// each task to each of its deps.
+func (g Graph) WriteDOT(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "digraph tasks {")
+	fmt.Fprintln(b, "  rankdir=LR;")
+	for _, task := range g.Tasks {
+		fmt.Fprintf(b, "  %s;\n", strconv.Quote(task))
+	}
+	for _, task := range g.Tasks {
+		for _, dep := range g.Deps[task] {
+			fmt.Fprintf(b, "  %s -> %s;\n", strconv.Quote(task), strconv.Quote(dep))
+		}
+	}
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart, with an arrow from
+// each task to each of its deps. Task names are labels, since Mermaid node
+// IDs can't hold every character they can.
+func (g Graph) WriteMermaid(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	ids := make(map[string]string, len(g.Tasks))
+	fmt.Fprintln(b, "flowchart LR")
+	for n, task := range g.Tasks {
+		ids[task] = fmt.Sprintf("t%d", n)
+		fmt.Fprintf(b, "  %s[\"%s\"]\n", ids[task], strings.ReplaceAll(task, `"`, "#quot;"))
+	}
+	for _, task := range g.Tasks {
+		for _, dep := range g.Deps[task] {
+			fmt.Fprintf(b, "  %s --> %s\n", ids[task], ids[dep])
+		}
+	}
+	return b.Flush()
+}
@@ -0,0 +1,96 @@
+package inspector
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadTaskDeps(t *testing.T) {
+	taskfilePath := createMockTaskfile(t, `
+version: '3'
+tasks:
+  deploy:
+    deps:
+      - build
+      - task: migrate
+        vars: {ENV: prod}
+      - :root-task
+      - '{{.OTHER}}'
+      - cmd: echo not a task
+    cmds:
+      - ./deploy.sh
+  build:
+    deps: [generate]
+  generate: go generate ./...
+`)
+	metadata, err := loadTaskMetadata(taskfilePath)
+	if err != nil {
+		t.Fatalf("loadTaskMetadata() error = %v", err)
+	}
+	if got, want := []string(metadata["deploy"].Deps), []string{"build", "migrate", "root-task"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("deploy deps = %q, want %q", got, want)
+	}
+	if got := []string(metadata["build"].Deps); !reflect.DeepEqual(got, []string{"generate"}) {
+		t.Errorf("build deps = %q, want generate", got)
+	}
+	if got := metadata["generate"].Deps; got != nil {
+		t.Errorf("generate deps = %q, want none", got)
+	}
+}
+
+func TestGraph(t *testing.T) {
+	config := MCPConfig{Tasks: []TaskDefinition{
+		{Name: "deploy", Deps: []string{"build", "docs:build"}},
+		{Name: "build", Deps: []string{"generate", "deploy"}},
+		{Name: "generate"},
+	}}
+	g := config.Graph()
+	if want := []string{"deploy", "build", "generate", "docs:build"}; !reflect.DeepEqual(g.Tasks, want) {
+		t.Errorf("Tasks = %q, want %q", g.Tasks, want)
+	}
+	if got, want := g.Requires("deploy"), []string{"generate", "build", "docs:build"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Requires(deploy) = %q, want %q", got, want)
+	}
+	if got := g.Requires("generate"); len(got) != 0 {
+		t.Errorf("Requires(generate) = %q, want none", got)
+	}
+
+	var dot strings.Builder
+	if err := g.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	wantDOT := `digraph tasks {
+  rankdir=LR;
+  "deploy";
+  "build";
+  "generate";
+  "docs:build";
+  "deploy" -> "build";
+  "deploy" -> "docs:build";
+  "build" -> "generate";
+  "build" -> "deploy";
+}
+`
+	if dot.String() != wantDOT {
+		t.Errorf("WriteDOT() =\n%s\nwant\n%s", dot.String(), wantDOT)
+	}
+
+	var mermaid strings.Builder
+	if err := g.WriteMermaid(&mermaid); err != nil {
+		t.Fatal(err)
+	}
+	wantMermaid := `flowchart LR
+  t0["deploy"]
+  t1["build"]
+  t2["generate"]
+  t3["docs:build"]
+  t0 --> t1
+  t0 --> t3
+  t1 --> t2
+  t1 --> t0
+`
+	if mermaid.String() != wantMermaid {
+		t.Errorf("WriteMermaid() =\n%s\nwant\n%s", mermaid.String(), wantMermaid)
+	}
+}
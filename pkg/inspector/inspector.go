@@ -0,0 +1,352 @@
+// Package inspector works out the tasks of a Taskfile, or of a Makefile,
+// package.json, justfile, tools.yml manifest or plugin, and what tmcp needs
+// to serve each as an MCP tool: its description, parameters and hints. It
+// runs the file's own tool, e.g. task, rather than parsing the file itself.
+//
+// The JSON encoding of MCPConfig is versioned by SchemaVersion, so saved
+// inspections can be read back by later releases or by other programs.
+package inspector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Inspector is responsible for inspecting a Taskfile.
+type Inspector struct {
+	taskBinPath  string
+	taskfilePath string
+	// For improved testability, we can also include the command executor here.
+	cmdExecutor func(ctx context.Context, command string, args ...string) *exec.Cmd
+	progress    func(Progress)
+	cache       *Cache
+	// source is the kind of file taskfilePath is.
+	source Source
+	// usagePattern finds the usage line of task summaries.
+	usagePattern *regexp.Regexp
+}
+
+// Progress reports how far Inspect got.
+type Progress struct {
+	Taskfile string
+	// Task is the task being inspected, the N-th of Total.
+	Task  string
+	N     int
+	Total int
+}
+
+// Option is a function that configures an Inspector.
+type Option func(*Inspector)
+
+// New creates a new Inspector with the given options.
+func New(opts ...Option) (*Inspector, error) {
+	// Start with default values
+	inspector := &Inspector{
+		cmdExecutor:  exec.CommandContext, // Default to the real exec.CommandContext
+		usagePattern: DefaultUsagePattern,
+	}
+
+	// Apply all provided options
+	for _, opt := range opts {
+		opt(inspector)
+	}
+
+	// Validate that required options were provided
+	if inspector.taskfilePath == "" {
+		return nil, errors.New("taskfile path is required")
+	}
+	if inspector.source == nil {
+		inspector.source = SourceOf(inspector.taskfilePath)
+	}
+	if inspector.taskBinPath == "" {
+		inspector.taskBinPath = inspector.source.Bin(inspector.taskfilePath)
+	}
+
+	return inspector, nil
+}
+
+// WithTaskfile sets the path to the Taskfile.
+func WithTaskfile(path string) Option {
+	return func(i *Inspector) {
+		i.taskfilePath = path
+	}
+}
+
+// WithTaskBin sets the path to the task binary, or to the program of the
+// file's source, such as just. It defaults to the source's program.
+func WithTaskBin(path string) Option {
+	return func(i *Inspector) {
+		i.taskBinPath = path
+	}
+}
+
+// WithSource sets the kind of file the Taskfile is, for files whose name
+// doesn't tell. By default it is told from the name, see SourceOf.
+func WithSource(source Source) Option {
+	return func(i *Inspector) {
+		i.source = source
+	}
+}
+
+// WithUsagePattern sets the pattern of the usage line of task summaries,
+// for Taskfiles whose summaries aren't written in English. Its one capture
+// group is the usage, e.g. `(?i)^\s*uso\s*:\s*(.*)$`. See ParseUsagePattern.
+func WithUsagePattern(re *regexp.Regexp) Option {
+	return func(i *Inspector) {
+		i.usagePattern = re
+	}
+}
+
+// WithProgress calls fn before each task Inspect inspects, so slow
+// inspections of large Taskfiles can show how far they got.
+func WithProgress(fn func(Progress)) Option {
+	return func(i *Inspector) {
+		i.progress = fn
+	}
+}
+
+// (For Testing) withCmdExecutor sets a custom command executor.
+func withCmdExecutor(execFunc func(context.Context, string, ...string) *exec.Cmd) Option {
+	return func(i *Inspector) {
+		i.cmdExecutor = execFunc
+	}
+}
+
+type TaskResult struct {
+	Name        string `json:"name"`
+	TaskKey     string `json:"task"`
+	Description string `json:"desc"`
+	Usage       string `json:"usage"` // This field is not directly available in --list --json, summary contains it.
+	Summary     string `json:"summary"`
+}
+
+type TaskListResult struct {
+	Tasks []TaskResult `json:"tasks"`
+}
+
+// Inspect runs the full inspection process. The task processes it starts
+// are killed when ctx is done. With WithCache, a cached result is returned
+// instead when the Taskfile hasn't changed.
+func (i *Inspector) Inspect(ctx context.Context) (*MCPConfig, error) {
+	if i.cache == nil {
+		return i.inspect(ctx)
+	}
+	key, err := cacheKey(i.taskfilePath, i.source, i.taskBinPath, i.usagePattern)
+	if err != nil {
+		slog.Debug("Not caching the inspection", "path", i.taskfilePath, "error", err)
+		return i.inspect(ctx)
+	}
+	if config, ok := i.cache.load(key); ok {
+		slog.Debug("Using the cached inspection", "path", i.taskfilePath, "key", key)
+		return config, nil
+	}
+	config, err := i.inspect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.cache.store(key, config); err != nil {
+		slog.Warn("Could not cache the inspection", "path", i.taskfilePath, "error", err)
+	}
+	return config, nil
+}
+
+// inspect inspects the Taskfile, listing its tasks and then inspecting
+// each of them. Files of other sources are parsed instead.
+func (i *Inspector) inspect(ctx context.Context) (*MCPConfig, error) {
+	if p, ok := i.source.(parser); ok {
+		tasks, err := p.parse(ctx, i)
+		if err != nil {
+			return nil, err
+		}
+		return &MCPConfig{Tasks: tasks}, nil
+	}
+	taskNames, err := i.DiscoverTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &MCPConfig{}
+	for n, taskName := range taskNames {
+		if i.progress != nil {
+			i.progress(Progress{Taskfile: i.taskfilePath, Task: taskName, N: n + 1, Total: len(taskNames)})
+		}
+		details, err := i.GetTaskDetails(ctx, taskName)
+		if err != nil {
+			return nil, err
+		}
+		config.Tasks = append(config.Tasks, *details)
+	}
+
+	i.applyMetadata(config.Tasks)
+	return config, nil
+}
+
+// InspectTask inspects a single task without listing the others. Unlike
+// Inspect, it also returns the raw summary.
+func (i *Inspector) InspectTask(ctx context.Context, taskName string) (*TaskDefinition, error) {
+	if p, ok := i.source.(parser); ok {
+		tasks, err := p.parse(ctx, i)
+		if err != nil {
+			return nil, err
+		}
+		return findTask(tasks, taskName)
+	}
+	summary, err := i.taskSummary(ctx, taskName)
+	if err != nil {
+		return nil, err
+	}
+	details := parseSummary(taskName, summary, i.usagePattern)
+	details.Summary = summary
+	tasks := []TaskDefinition{*details}
+	i.applyMetadata(tasks)
+	return &tasks[0], nil
+}
+
+// applyMetadata fills in what `task` doesn't report from the Taskfile
+// itself.
+func (i *Inspector) applyMetadata(tasks []TaskDefinition) {
+	metadata, err := loadTaskMetadata(i.taskfilePath)
+	if err != nil {
+		slog.Warn("Could not read Taskfile metadata, continuing without it", "path", i.taskfilePath, "error", err)
+		return
+	}
+	// Inspections are cached by absolute path, so the file is too.
+	file, err := filepath.Abs(i.taskfilePath)
+	if err != nil {
+		file = i.taskfilePath
+	}
+	for idx := range tasks {
+		if meta, ok := metadata[tasks[idx].Name]; ok {
+			tasks[idx].File = file
+			tasks[idx].Line, tasks[idx].Column = meta.Line, meta.Column
+			tasks[idx].Dir = resolveTaskDir(i.taskfilePath, meta.Dir)
+			tasks[idx].Deps = meta.Deps
+			tasks[idx].ReadOnly = meta.MCP.ReadOnly
+			tasks[idx].Title = meta.MCP.Title
+			tasks[idx].Destructive = meta.MCP.Destructive
+			tasks[idx].Idempotent = meta.MCP.Idempotent
+			tasks[idx].Docs = docsURL(tasks[idx].Name, meta.MCP.Docs)
+			tasks[idx].Retry = retryPolicy(tasks[idx].Name, meta.MCP.Retry)
+			tasks[idx].CacheTTL = cacheTTL(tasks[idx].Name, meta.MCP.CacheTTL)
+			tasks[idx].Cost, tasks[idx].Duration = estimates(tasks[idx].Name, meta.MCP.Cost, meta.MCP.Duration)
+			tasks[idx].Async = meta.MCP.Async
+			tasks[idx].Outputs = outputGlobs(tasks[idx].Name, meta)
+			tasks[idx].Stdin = meta.MCP.Stdin
+			tasks[idx].OutputSchema = outputSchema(tasks[idx].Name, meta.MCP.OutputSchema)
+			tasks[idx].JSON = meta.MCP.JSON
+			tasks[idx].CLIArgs = meta.UsesCLIArgs
+			applyParamTypes(tasks[idx].Name, tasks[idx].Parameters, meta.MCP.Params)
+			applyDefaults(tasks[idx].Parameters, meta.Defaults)
+		}
+	}
+}
+
+// List lists the tasks of the Taskfile with a single run of task. Unlike
+// Inspect, it doesn't look at each task's summary, so the tasks have their
+// descriptions but no usage or parameters; InspectTask fills those in.
+func (i *Inspector) List(ctx context.Context) (*MCPConfig, error) {
+	if _, ok := i.source.(parser); ok {
+		// Parsing is as fast as listing.
+		return i.inspect(ctx)
+	}
+	listed, err := i.listTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &MCPConfig{}
+	for _, task := range listed {
+		// --summary prints the summary, or the description without one.
+		description := task.Summary
+		if strings.TrimSpace(description) == "" {
+			description = task.Description
+		}
+		config.Tasks = append(config.Tasks, TaskDefinition{Name: task.Name, Description: strings.TrimSpace(description)})
+	}
+
+	i.applyMetadata(config.Tasks)
+	return config, nil
+}
+
+// DiscoverTasks discovers the tasks in the configured Taskfile.
+func (i *Inspector) DiscoverTasks(ctx context.Context) ([]string, error) {
+	var tasks []string
+	if p, ok := i.source.(parser); ok {
+		parsed, err := p.parse(ctx, i)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range parsed {
+			tasks = append(tasks, task.Name)
+		}
+		return tasks, nil
+	}
+
+	listed, err := i.listTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range listed {
+		tasks = append(tasks, task.Name)
+	}
+	slog.Debug("Discovered tasks", "task_count", len(tasks))
+	return tasks, nil
+}
+
+// listTasks returns the output of `task --list --json --verbose`.
+func (i *Inspector) listTasks(ctx context.Context) ([]TaskResult, error) {
+	slog.Debug("Discovering tasks in", "path", i.taskfilePath)
+	cmd := i.cmdExecutor(ctx, i.taskBinPath, "--list", "--json", "--verbose", "--taskfile", i.taskfilePath)
+
+	var out bytes.Buffer
+	var errOut bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut // Capture stderr as well for debugging
+	err := cmd.Run()
+	if err != nil {
+		slog.Error("Error running task command", "error", err, "output", out.String(), "stderr", errOut.String(), "inspectorConfig", i)
+		return nil, err
+	}
+
+	slog.Debug("Marshalling json output")
+	var taskListResult TaskListResult
+	if err := json.Unmarshal(out.Bytes(), &taskListResult); err != nil {
+		slog.Error("Error unmarshalling JSON from task list", "error", err)
+		return nil, err
+	}
+	return taskListResult.Tasks, nil
+}
+
+// GetTaskDetails gets the details for a specific task.
+func (i *Inspector) GetTaskDetails(ctx context.Context, taskName string) (*TaskDefinition, error) {
+	summary, err := i.taskSummary(ctx, taskName)
+	if err != nil {
+		return nil, err
+	}
+	return parseSummary(taskName, summary, i.usagePattern), nil
+}
+
+// taskSummary returns the output of `task <name> --summary`.
+func (i *Inspector) taskSummary(ctx context.Context, taskName string) (string, error) {
+	slog.Debug("Getting details for", "task", taskName)
+	cmd := i.cmdExecutor(ctx, i.taskBinPath, taskName, "--summary", "--taskfile", i.taskfilePath)
+	var out bytes.Buffer
+	var errOut bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(errOut.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,778 @@
+package inspector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Helper function to create a mock Taskfile
+func createMockTaskfile(t *testing.T, content string) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	taskfilePath := filepath.Join(tmpDir, "Taskfile.yml")
+	err := os.WriteFile(taskfilePath, []byte(content), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create mock Taskfile: %v", err)
+	}
+	return taskfilePath
+}
+
+func newMockCmdExecutor(t *testing.T, expectedCmdSubstring string, output string, errToReturn error) func(context.Context, string, ...string) *exec.Cmd {
+	t.Helper()
+	return func(_ context.Context, command string, args ...string) *exec.Cmd {
+		cmdStr := command + " " + strings.Join(args, " ")
+		if !strings.Contains(cmdStr, expectedCmdSubstring) {
+			t.Logf("Warning: execCommand called with %s, but mock is for %s. Falling back to real exec.", cmdStr, expectedCmdSubstring)
+			return exec.Command(command, args...)
+		}
+
+		cs := []string{"-test.run=TestHelperProcess", "--"}
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = append(os.Environ(),
+			"GO_WANT_HELPER_PROCESS=1",
+			"STDOUT="+output,
+		)
+		if errToReturn != nil {
+			cmd.Env = append(cmd.Env, "EXIT_CODE=1", "STDERR="+errToReturn.Error())
+		} else {
+			cmd.Env = append(cmd.Env, "EXIT_CODE=0")
+		}
+		return cmd
+	}
+}
+
+// TestHelperProcess isn't a real test. It's used as a helper for setupMockCmd.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	// GO_COMMAND might be useful if we need to check which command was intended
+	// fmt.Fprintf(os.Stderr, "Helper process called for command: %s\n", os.Getenv("GO_COMMAND"))
+	// fmt.Fprintf(os.Stderr, "Helper process STDOUT: %s\n", os.Getenv("STDOUT"))
+	// fmt.Fprintf(os.Stderr, "Helper process STDERR: %s\n", os.Getenv("STDERR"))
+	// fmt.Fprintf(os.Stderr, "Helper process EXIT_CODE: %s\n", os.Getenv("EXIT_CODE"))
+
+	fmt.Fprint(os.Stdout, os.Getenv("STDOUT"))
+	fmt.Fprint(os.Stderr, os.Getenv("STDERR"))
+
+	exitCode := 0
+	if os.Getenv("EXIT_CODE") == "1" {
+		exitCode = 1
+	}
+	os.Exit(exitCode)
+}
+
+func TestDiscoverTasks(t *testing.T) {
+	// This test will use the actual task binary if available,
+	// or a mocked version if we implement more sophisticated mocking later.
+	// For now, let's create a real Taskfile and use the actual task command.
+
+	t.Run("successful discovery", func(t *testing.T) {
+		taskfileContent := `
+version: '3'
+tasks:
+  task1:
+    desc: "This is task 1"
+  task2:
+    desc: "This is task 2"
+`
+		taskfilePath := createMockTaskfile(t, taskfileContent)
+		mockExecutor := newMockCmdExecutor(t, "task --list --json", `{"tasks": [{"name": "task1", "desc": "This is task 1"}, {"name": "task2", "desc": "This is task 2"}]}`, nil)
+
+		inspector, err := New(WithTaskfile(taskfilePath), withCmdExecutor(mockExecutor))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		expectedTasks := []string{"task1", "task2"}
+		tasks, err := inspector.DiscoverTasks(context.Background())
+
+		if err != nil {
+			t.Fatalf("DiscoverTasks() error = %v, wantErr %v", err, false)
+		}
+		if !reflect.DeepEqual(tasks, expectedTasks) {
+			t.Errorf("DiscoverTasks() = %v, want %v", tasks, expectedTasks)
+		}
+	})
+
+	t.Run("task command fails", func(t *testing.T) {
+		taskfilePath := createMockTaskfile(t, "") // Content doesn't matter for this case
+		mockExecutor := newMockCmdExecutor(t, "task --list --json", "", fmt.Errorf("task command failed"))
+
+		inspector, err := New(WithTaskfile(taskfilePath), withCmdExecutor(mockExecutor))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		_, err = inspector.DiscoverTasks(context.Background())
+		if err == nil {
+			t.Fatalf("DiscoverTasks() error = nil, wantErr %v", true)
+		}
+	})
+
+	t.Run("json unmarshalling fails", func(t *testing.T) {
+		taskfilePath := createMockTaskfile(t, "")
+		mockExecutor := newMockCmdExecutor(t, "task --list --json", `{"tasks": [{"name": "task1", "desc": "This is task 1"}`, nil) // Invalid JSON
+
+		inspector, err := New(WithTaskfile(taskfilePath), withCmdExecutor(mockExecutor))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		_, err = inspector.DiscoverTasks(context.Background())
+		if err == nil {
+			t.Fatalf("DiscoverTasks() error = nil, wantErr %v", true)
+		}
+		// Check if the error is due to JSON unmarshalling problem as logged by DiscoverTasks,
+		// or if it's the direct unmarshalling error.
+		// The function logs "Error unmarshalling JSON" and returns the original error.
+		// So, we check if the original error is related to JSON syntax.
+		if _, ok := err.(*json.SyntaxError); !ok && !strings.Contains(err.Error(), "unexpected end of JSON input") {
+			// Add more checks if other json errors are expected
+			t.Errorf("DiscoverTasks() error = %v (%T), want json.SyntaxError or similar", err, err)
+		}
+	})
+}
+
+func TestGetTaskDetails(t *testing.T) {
+	t.Run("successful details parsing", func(t *testing.T) {
+		taskfileContent := `
+version: '3'
+tasks:
+  weather:
+    desc: "Get the current weather forecast"
+    summary: |
+      Retrieve a weather forecast for the provided ZIPCODE.
+      Usage: task weather ZIPCODE=<zip> ANOTHER_PARAM=value
+`
+		taskfilePath := createMockTaskfile(t, taskfileContent)
+		mockSummaryOutput := `
+task: weather
+Retrieve a weather forecast for the provided ZIPCODE.
+Usage: task weather ZIPCODE=<zip> ANOTHER_PARAM=value
+Required:
+  ZIPCODE: The zipcode to get the weather for.
+`
+		mockExecutor := newMockCmdExecutor(t, "task weather --summary", mockSummaryOutput, nil)
+
+		inspector, err := New(WithTaskfile(taskfilePath), withCmdExecutor(mockExecutor))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		expectedDetails := &TaskDefinition{
+			Name:        "weather",
+			Description: "Retrieve a weather forecast for the provided ZIPCODE.",
+			Usage:       "task weather ZIPCODE=<zip> ANOTHER_PARAM=value",
+			Parameters: []TaskParameter{
+				{Name: "ZIPCODE"},
+				{Name: "ANOTHER_PARAM"},
+			},
+		}
+
+		details, err := inspector.GetTaskDetails(context.Background(), "weather")
+		if err != nil {
+			t.Fatalf("GetTaskDetails() error = %v, wantErr %v", err, false)
+		}
+		if details.Name != expectedDetails.Name {
+			t.Errorf("GetTaskDetails() Name = %q, want %q", details.Name, expectedDetails.Name)
+		}
+		if strings.TrimSpace(details.Description) != strings.TrimSpace(expectedDetails.Description) {
+			t.Errorf("GetTaskDetails() Description = %q, want %q", details.Description, expectedDetails.Description)
+		}
+		if details.Usage != expectedDetails.Usage {
+			t.Errorf("GetTaskDetails() Usage = %q, want %q", details.Usage, expectedDetails.Usage)
+		}
+		if !reflect.DeepEqual(details.Parameters, expectedDetails.Parameters) {
+			t.Errorf("GetTaskDetails() Parameters = %v, want %v", details.Parameters, expectedDetails.Parameters)
+		}
+
+	})
+
+	t.Run("task summary command fails", func(t *testing.T) {
+		taskfilePath := createMockTaskfile(t, "")
+		mockExecutor := newMockCmdExecutor(t, "task test-task --summary", "", fmt.Errorf("summary command failed"))
+
+		inspector, err := New(WithTaskfile(taskfilePath), withCmdExecutor(mockExecutor))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		_, err = inspector.GetTaskDetails(context.Background(), "test-task")
+		if err == nil {
+			t.Fatalf("GetTaskDetails() error = nil, wantErr %v", true)
+		}
+	})
+
+	t.Run("summary with no usage line", func(t *testing.T) {
+		taskfileContent := `
+version: '3'
+tasks:
+  simple:
+    desc: "A simple task"
+    summary: |
+      This is just a simple task.
+      It has no specific usage instructions here.
+`
+		taskfilePath := createMockTaskfile(t, taskfileContent)
+		mockSummaryOutput := `
+task: simple
+This is just a simple task.
+It has no specific usage instructions here.
+`
+		mockExecutor := newMockCmdExecutor(t, "task simple --summary", mockSummaryOutput, nil)
+
+		inspector, err := New(WithTaskfile(taskfilePath), withCmdExecutor(mockExecutor))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		expectedDetails := &TaskDefinition{
+			Name:        "simple",
+			Description: "This is just a simple task.\nIt has no specific usage instructions here.",
+			Usage:       "", // Expect empty usage
+			Parameters:  []TaskParameter{},
+		}
+
+		details, err := inspector.GetTaskDetails(context.Background(), "simple")
+		if err != nil {
+			t.Fatalf("GetTaskDetails() error = %v, wantErr %v", err, false)
+		}
+		if details.Name != expectedDetails.Name {
+			t.Errorf("GetTaskDetails() Name = %q, want %q", details.Name, expectedDetails.Name)
+		}
+		if strings.TrimSpace(details.Description) != strings.TrimSpace(expectedDetails.Description) {
+			t.Errorf("GetTaskDetails() Description = %q, want %q", details.Description, expectedDetails.Description)
+		}
+		if details.Usage != expectedDetails.Usage {
+			t.Errorf("GetTaskDetails() Usage = %q, want %q", details.Usage, expectedDetails.Usage)
+		}
+		if len(details.Parameters) != 0 {
+			t.Errorf("GetTaskDetails() Parameters = %v, want empty slice", details.Parameters)
+		}
+	})
+
+	t.Run("summary with usage but no parameters", func(t *testing.T) {
+		taskfileContent := `
+version: '3'
+tasks:
+  usageonly:
+    desc: "A task with usage but no params"
+    summary: |
+      This task has a usage line.
+      Usage: task usageonly --flag
+`
+		taskfilePath := createMockTaskfile(t, taskfileContent)
+		mockSummaryOutput := `
+task: usageonly
+This task has a usage line.
+Usage: task usageonly --flag
+`
+		mockExecutor := newMockCmdExecutor(t, "task usageonly --summary", mockSummaryOutput, nil)
+
+		inspector, err := New(WithTaskfile(taskfilePath), withCmdExecutor(mockExecutor))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		expectedDetails := &TaskDefinition{
+			Name:        "usageonly",
+			Description: "This task has a usage line.",
+			Usage:       "task usageonly --flag",
+			Parameters:  []TaskParameter{},
+		}
+
+		details, err := inspector.GetTaskDetails(context.Background(), "usageonly")
+		if err != nil {
+			t.Fatalf("GetTaskDetails() error = %v, wantErr %v", err, false)
+		}
+		if details.Name != expectedDetails.Name {
+			t.Errorf("GetTaskDetails() Name = %q, want %q", details.Name, expectedDetails.Name)
+		}
+		if strings.TrimSpace(details.Description) != strings.TrimSpace(expectedDetails.Description) {
+			t.Errorf("GetTaskDetails() Description = %q, want %q", details.Description, expectedDetails.Description)
+		}
+		if details.Usage != expectedDetails.Usage {
+			t.Errorf("GetTaskDetails() Usage = %q, want %q", details.Usage, expectedDetails.Usage)
+		}
+		if len(details.Parameters) != 0 {
+			t.Errorf("GetTaskDetails() Parameters = %v, want empty slice", details.Parameters)
+		}
+	})
+}
+
+func TestInspect(t *testing.T) {
+	t.Run("successful inspection", func(t *testing.T) {
+		taskfilePath := createMockTaskfile(t, "version: '3'")
+
+		mockExecutor := func(_ context.Context, command string, args ...string) *exec.Cmd {
+			var output string
+			switch {
+			case strings.Contains(strings.Join(args, " "), "--list --json"):
+				output = `{"tasks": [{"name": "task1"}, {"name": "task2"}]}`
+			case strings.Contains(strings.Join(args, " "), "task1 --summary"):
+				output = "task: task1\nDesc 1\nUsage: Usage 1"
+			case strings.Contains(strings.Join(args, " "), "task2 --summary"):
+				output = "task: task2\nDesc 2\nUsage: Usage 2"
+			}
+			cs := []string{"-test.run=TestHelperProcess", "--"}
+			cmd := exec.Command(os.Args[0], cs...)
+			cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1", "STDOUT="+output, "EXIT_CODE=0")
+			return cmd
+		}
+
+		inspector, err := New(WithTaskfile(taskfilePath), withCmdExecutor(mockExecutor))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		expectedConfig := &MCPConfig{
+			Tasks: []TaskDefinition{
+				{Name: "task1", Description: "Desc 1", Usage: "Usage 1"},
+				{Name: "task2", Description: "Desc 2", Usage: "Usage 2"},
+			},
+		}
+
+		config, err := inspector.Inspect(context.Background())
+		if err != nil {
+			t.Fatalf("Inspect() error = %v, wantErr %v", err, false)
+		}
+		if !reflect.DeepEqual(config, expectedConfig) {
+			t.Errorf("Inspect() config = \n%+v, want \n%+v", config, expectedConfig)
+		}
+	})
+
+	t.Run("DiscoverTasks fails", func(t *testing.T) {
+		taskfilePath := createMockTaskfile(t, "")
+		mockExecutor := newMockCmdExecutor(t, "task --list --json", "", fmt.Errorf("discover failed"))
+
+		inspector, err := New(WithTaskfile(taskfilePath), withCmdExecutor(mockExecutor))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		_, err = inspector.Inspect(context.Background())
+		if err == nil {
+			t.Fatalf("Inspect() error = nil, wantErr %v", true)
+		}
+	})
+
+	t.Run("GetTaskDetails fails for one task", func(t *testing.T) {
+		taskfilePath := createMockTaskfile(t, "")
+
+		mockExecutor := func(_ context.Context, command string, args ...string) *exec.Cmd {
+			var output, stderr string
+			exitCode := "0"
+			switch {
+			case strings.Contains(strings.Join(args, " "), "--list --json"):
+				output = `{"tasks": [{"name": "task1"}, {"name": "task2"}]}`
+			case strings.Contains(strings.Join(args, " "), "task1 --summary"):
+				output = "task: task1\nDesc 1\nUsage: Usage 1"
+			case strings.Contains(strings.Join(args, " "), "task2 --summary"):
+				stderr = "get details failed"
+				exitCode = "1"
+			}
+			cs := []string{"-test.run=TestHelperProcess", "--"}
+			cmd := exec.Command(os.Args[0], cs...)
+			cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1", "STDOUT="+output, "STDERR="+stderr, "EXIT_CODE="+exitCode)
+			return cmd
+		}
+
+		inspector, err := New(WithTaskfile(taskfilePath), withCmdExecutor(mockExecutor))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		_, err = inspector.Inspect(context.Background())
+		if err == nil {
+			t.Fatalf("Inspect() error = nil, wantErr %v", true)
+		}
+	})
+}
+
+func TestInspectTask(t *testing.T) {
+	taskfilePath := createMockTaskfile(t, `
+version: '3'
+tasks:
+  weather:
+    x-mcp:
+      read_only: true
+      title: Weather report
+      idempotent: true
+`)
+	summary := "task: weather\n\nGet the weather\n\nUsage: task weather ZIPCODE=<zip>\n"
+	mockExecutor := func(_ context.Context, command string, args ...string) *exec.Cmd {
+		if strings.Contains(strings.Join(args, " "), "--list") {
+			t.Errorf("InspectTask listed all tasks: %v", args)
+		}
+		cs := []string{"-test.run=TestHelperProcess", "--"}
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1", "STDOUT="+summary, "EXIT_CODE=0")
+		return cmd
+	}
+
+	inspector, err := New(WithTaskfile(taskfilePath), withCmdExecutor(mockExecutor))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	task, err := inspector.InspectTask(context.Background(), "weather")
+	if err != nil {
+		t.Fatalf("InspectTask() error = %v", err)
+	}
+	idempotent := true
+	want := &TaskDefinition{
+		Name:        "weather",
+		Description: "Get the weather",
+		Usage:       "task weather ZIPCODE=<zip>",
+		Parameters:  []TaskParameter{{Name: "ZIPCODE"}},
+		File:        taskfilePath,
+		Line:        4,
+		Column:      3,
+		ReadOnly:    true,
+		Title:       "Weather report",
+		Idempotent:  &idempotent,
+		Summary:     summary,
+	}
+	if !reflect.DeepEqual(task, want) {
+		t.Errorf("InspectTask() = %+v, want %+v", task, want)
+	}
+}
+
+func TestInspectTaskUnknown(t *testing.T) {
+	taskfilePath := createMockTaskfile(t, "version: '3'")
+	mockExecutor := newMockCmdExecutor(t, "task nope --summary", "", fmt.Errorf(`task: Task "nope" does not exist`))
+
+	inspector, err := New(WithTaskfile(taskfilePath), withCmdExecutor(mockExecutor))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_, err = inspector.InspectTask(context.Background(), "nope")
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("InspectTask() error = %v, want the task error", err)
+	}
+}
+
+func TestLoadTaskMetadata(t *testing.T) {
+	taskfilePath := createMockTaskfile(t, `
+version: '3'
+tasks:
+  build:
+    dir: ./app
+    x-mcp:
+      read_only: true
+      docs: https://example.com/build
+      retry:
+        attempts: 3
+        backoff: 500ms
+      cache_ttl: 1m
+      cost: 0.25
+      duration: 2m
+      async: true
+      outputs: [reports/*.xml, bin/app]
+      stdin: TEXT
+      output_schema:
+        type: object
+        required: [binary]
+      json: false
+    generates:
+      - bin/app
+      - '{{.OUT}}/extra'
+      - exclude: bin/tmp
+    cmds:
+      - go build
+  abs:
+    dir: /srv/data
+  templated:
+    dir: '{{.USER_WORKING_DIR}}'
+  short: echo hi
+  short-args: go test {{.CLI_ARGS}}
+  lint:
+    cmds:
+      - cmd: golangci-lint run {{.CLI_ARGS}}
+`)
+
+	metadata, err := loadTaskMetadata(taskfilePath)
+	if err != nil {
+		t.Fatalf("loadTaskMetadata() error = %v", err)
+	}
+
+	base := filepath.Dir(taskfilePath)
+	tests := map[string]string{
+		"build":     filepath.Join(base, "app"),
+		"abs":       "/srv/data",
+		"templated": "",
+	}
+	for name, want := range tests {
+		if got := resolveTaskDir(taskfilePath, metadata[name].Dir); got != want {
+			t.Errorf("resolveTaskDir(%s) = %q, want %q", name, got, want)
+		}
+	}
+	if !metadata["build"].MCP.ReadOnly {
+		t.Errorf("loadTaskMetadata() build read_only = false, want true")
+	}
+	if got := metadata["build"].MCP.Docs; got != "https://example.com/build" {
+		t.Errorf("loadTaskMetadata() build docs = %q", got)
+	}
+	if got := metadata["build"].MCP.Retry; got == nil || *got != (RetryPolicy{Attempts: 3, Backoff: 500 * time.Millisecond}) {
+		t.Errorf("loadTaskMetadata() build retry = %+v", got)
+	}
+	if got := outputSchema("build", metadata["build"].MCP.OutputSchema); !reflect.DeepEqual(got, map[string]any{"type": "object", "required": []any{"binary"}}) {
+		t.Errorf("loadTaskMetadata() build output_schema = %#v", got)
+	}
+	if got := metadata["build"].MCP.JSON; got == nil || *got {
+		t.Errorf("loadTaskMetadata() build json = %v, want false", got)
+	}
+	if !metadata["build"].MCP.Async {
+		t.Errorf("loadTaskMetadata() build async = false, want true")
+	}
+	if got := metadata["build"].MCP.CacheTTL; got != time.Minute {
+		t.Errorf("loadTaskMetadata() build cache_ttl = %v, want 1m", got)
+	}
+	if cost, duration := estimates("build", metadata["build"].MCP.Cost, metadata["build"].MCP.Duration); cost != 0.25 || duration != 2*time.Minute {
+		t.Errorf("loadTaskMetadata() build cost, duration = %v, %v, want 0.25, 2m", cost, duration)
+	}
+	if got := metadata["build"].MCP.Stdin; got != "TEXT" {
+		t.Errorf("loadTaskMetadata() build stdin = %q, want TEXT", got)
+	}
+	if got := outputGlobs("build", metadata["build"]); !reflect.DeepEqual(got, []string{"bin/app", "reports/*.xml"}) {
+		t.Errorf("outputGlobs(build) = %q", got)
+	}
+	for name, line := range map[string]int{"build": 4, "short": 32, "lint": 34} {
+		if got := metadata[name]; got.Line != line || got.Column != 3 {
+			t.Errorf("loadTaskMetadata() %s is at %d:%d, want %d:3", name, got.Line, got.Column, line)
+		}
+	}
+	if got := metadata["short"]; got.Dir != "" || got.MCP.ReadOnly {
+		t.Errorf("loadTaskMetadata() short-form task = %+v, want only its location", got)
+	}
+	for name, want := range map[string]bool{"build": false, "short-args": true, "lint": true} {
+		if got := metadata[name].UsesCLIArgs; got != want {
+			t.Errorf("loadTaskMetadata() %s uses CLI_ARGS = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	if got := retryPolicy("build", &RetryPolicy{Attempts: 3, Backoff: time.Second}); got == nil {
+		t.Error("retryPolicy() dropped a valid policy")
+	}
+	for _, invalid := range []RetryPolicy{{Attempts: 0}, {Attempts: MaxRetryAttempts + 1}, {Attempts: 2, Backoff: -time.Second}} {
+		if got := retryPolicy("build", &invalid); got != nil {
+			t.Errorf("retryPolicy(%+v) = %+v, want nil", invalid, got)
+		}
+	}
+}
+
+func TestDocsURL(t *testing.T) {
+	for link, want := range map[string]string{
+		"":                            "",
+		"https://example.com/runbook": "https://example.com/runbook",
+		"http://wiki/deploy":          "http://wiki/deploy",
+		"docs/deploy.md":              "",
+		"javascript:alert(1)":         "",
+	} {
+		if got := docsURL("deploy", link); got != want {
+			t.Errorf("docsURL(%q) = %q, want %q", link, got, want)
+		}
+	}
+}
+
+func TestInspectCancelled(t *testing.T) {
+	taskfilePath := createMockTaskfile(t, "")
+	taskBin := filepath.Join(t.TempDir(), "task")
+	if err := os.WriteFile(taskBin, []byte("#!/bin/sh\nexec sleep 30\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	inspector, err := New(WithTaskfile(taskfilePath), WithTaskBin(taskBin))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := inspector.Inspect(ctx); err == nil {
+		t.Fatal("Inspect() error = nil, want the run killed")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Inspect() took %s after its context was done", elapsed)
+	}
+}
+
+func TestInspectProgress(t *testing.T) {
+	taskfilePath := createMockTaskfile(t, "")
+	mockExecutor := func(_ context.Context, command string, args ...string) *exec.Cmd {
+		output := "task: x\nDesc"
+		if strings.Contains(strings.Join(args, " "), "--list --json") {
+			output = `{"tasks": [{"name": "build"}, {"name": "test"}]}`
+		}
+		cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess", "--")
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1", "STDOUT="+output, "EXIT_CODE=0")
+		return cmd
+	}
+	var got []Progress
+	inspector, err := New(WithTaskfile(taskfilePath), withCmdExecutor(mockExecutor), WithProgress(func(p Progress) {
+		got = append(got, p)
+	}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := inspector.Inspect(context.Background()); err != nil {
+		t.Fatalf("Inspect() error = %v", err)
+	}
+	want := []Progress{
+		{Taskfile: taskfilePath, Task: "build", N: 1, Total: 2},
+		{Taskfile: taskfilePath, Task: "test", N: 2, Total: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("progress = %+v, want %+v", got, want)
+	}
+}
+
+func TestList(t *testing.T) {
+	taskfilePath := createMockTaskfile(t, "version: '3'\ntasks:\n  build:\n    x-mcp:\n      read_only: true\n")
+	output := `{"tasks": [{"name": "build", "desc": "Build it"}, {"name": "test", "desc": "Test it", "summary": "Run the tests.\n"}]}`
+	calls := 0
+	mockExecutor := func(ctx context.Context, command string, args ...string) *exec.Cmd {
+		calls++
+		return newMockCmdExecutor(t, "--list --json", output, nil)(ctx, command, args...)
+	}
+	inspector, err := New(WithTaskfile(taskfilePath), withCmdExecutor(mockExecutor))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	config, err := inspector.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("List() ran task %d times, want once", calls)
+	}
+	want := []TaskDefinition{
+		{Name: "build", Description: "Build it", File: taskfilePath, Line: 3, Column: 3, ReadOnly: true},
+		{Name: "test", Description: "Run the tests."},
+	}
+	if !reflect.DeepEqual(config.Tasks, want) {
+		t.Errorf("List() = %+v, want %+v", config.Tasks, want)
+	}
+}
+
+// BenchmarkInspect inspects a Taskfile of 50 tasks, with a helper process
+// standing in for each run of task.
+func BenchmarkInspect(b *testing.B) {
+	dir := b.TempDir()
+	taskfilePath := filepath.Join(dir, "Taskfile.yml")
+	if err := os.WriteFile(taskfilePath, []byte("version: '3'\n"), 0644); err != nil {
+		b.Fatal(err)
+	}
+	var list TaskListResult
+	for n := 0; n < 50; n++ {
+		list.Tasks = append(list.Tasks, TaskResult{Name: fmt.Sprintf("task-%d", n)})
+	}
+	listOutput, _ := json.Marshal(list)
+	mockExecutor := func(_ context.Context, command string, args ...string) *exec.Cmd {
+		output := "task: x\nBuild it\n\nUsage: task x TARGET=<target> MODE=<mode>"
+		if strings.Contains(strings.Join(args, " "), "--list --json") {
+			output = string(listOutput)
+		}
+		cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess", "--")
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1", "STDOUT="+output, "EXIT_CODE=0")
+		return cmd
+	}
+	inspector, err := New(WithTaskfile(taskfilePath), withCmdExecutor(mockExecutor))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := inspector.Inspect(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseSummary(b *testing.B) {
+	summary := "task: deploy\nDeploy the service to an environment.\nRolls back on failure.\n\nUsage: task deploy ENV=<env> VERSION=<version> REGION=<region>\n"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		parseSummary("deploy", summary, DefaultUsagePattern)
+	}
+}
+
+func TestVarDefaults(t *testing.T) {
+	taskfilePath := createMockTaskfile(t, `
+version: '3'
+vars:
+  REGION: eu-west-1
+  PORT: 8080
+  VERSION: '{{.VERSION | default "latest"}}'
+  COMMIT:
+    sh: git rev-parse HEAD
+  OWNER: '{{.USER}}'
+  TIER:
+tasks:
+  deploy:
+    vars:
+      ENV: '{{default "staging" .ENV}}'
+      REPLICAS: '{{ .REPLICAS | default 2 }}'
+      PORT: 9090
+      NOTE: '{{.NOTE | default `+"`a \"quoted\" note`"+`}}'
+    cmds:
+      - echo {{.ENV}}
+`)
+	metadata, err := loadTaskMetadata(taskfilePath)
+	if err != nil {
+		t.Fatalf("loadTaskMetadata() error = %v", err)
+	}
+	want := map[string]string{
+		"REGION":   "eu-west-1",
+		"VERSION":  "latest",
+		"ENV":      "staging",
+		"REPLICAS": "2",
+		"NOTE":     `a "quoted" note`,
+	}
+	if got := metadata["deploy"].Defaults; !reflect.DeepEqual(got, want) {
+		t.Errorf("loadTaskMetadata() deploy defaults = %v, want %v", got, want)
+	}
+
+	params := []TaskParameter{{Name: "ENV", IsRequired: true}, {Name: "PORT"}, {Name: "REGION", Default: "us-east-1"}}
+	applyDefaults(params, want)
+	wantParams := []TaskParameter{{Name: "ENV", Default: "staging"}, {Name: "PORT"}, {Name: "REGION", Default: "us-east-1"}}
+	if !reflect.DeepEqual(params, wantParams) {
+		t.Errorf("applyDefaults() = %+v, want %+v", params, wantParams)
+	}
+}
+
+func TestApplyParamTypes(t *testing.T) {
+	taskfilePath := createMockTaskfile(t, `
+version: '3'
+tasks:
+  lint:
+    x-mcp:
+      params:
+        FILES: {type: array, join: comma}
+        COUNT: {type: integer}
+        ENV: {type: string, join: comma}
+        MODE: {type: list}
+`)
+	metadata, err := loadTaskMetadata(taskfilePath)
+	if err != nil {
+		t.Fatalf("loadTaskMetadata() error = %v", err)
+	}
+	params := []TaskParameter{{Name: "FILES"}, {Name: "COUNT"}, {Name: "ENV"}, {Name: "MODE"}, {Name: "OTHER"}}
+	applyParamTypes("lint", params, metadata["lint"].MCP.Params)
+	// Entries with a bad type or join are ignored.
+	want := []TaskParameter{{Name: "FILES", Type: "array", Join: "comma"}, {Name: "COUNT", Type: "integer"}, {Name: "ENV"}, {Name: "MODE"}, {Name: "OTHER"}}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("applyParamTypes() = %+v, want %+v", params, want)
+	}
+}
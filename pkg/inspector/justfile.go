@@ -0,0 +1,123 @@
+package inspector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type justfileSource struct{}
+
+func (justfileSource) Name() string { return "just" }
+
+func (justfileSource) Bin(string) string { return "just" }
+
+// Command runs the recipe with just. Recipes take their parameters by
+// position, so values are passed in the order of r.Parameters, up to the
+// first one that isn't set. just rejects overrides of variables a justfile
+// doesn't assign, so other Vars are dropped, and it has no equivalent of
+// CLI_ARGS, so Args are too.
+func (justfileSource) Command(r Run) []string {
+	args := []string{"--justfile", r.File}
+	if r.Dir != "" {
+		args = append(args, "--working-directory", r.Dir)
+	}
+	if r.DryRun {
+		args = append(args, "--dry-run")
+	}
+	args = append(args, r.Task)
+	values := map[string]string{}
+	for _, v := range r.Vars {
+		if key, value, ok := strings.Cut(v, "="); ok {
+			values[key] = value
+		}
+	}
+	for _, name := range r.Parameters {
+		value, ok := values[name]
+		if !ok {
+			break
+		}
+		args = append(args, value)
+	}
+	return args
+}
+
+func (justfileSource) parse(ctx context.Context, i *Inspector) ([]TaskDefinition, error) {
+	var stderr bytes.Buffer
+	cmd := i.cmdExecutor(ctx, i.taskBinPath, "--justfile", i.taskfilePath, "--dump", "--dump-format", "json")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("running just --dump: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseJustDump(out)
+}
+
+// justDump is the part of `just --dump --dump-format json` tmcp reads.
+type justDump struct {
+	Recipes map[string]struct {
+		Name       string  `json:"name"`
+		Doc        *string `json:"doc"`
+		Private    bool    `json:"private"`
+		Parameters []struct {
+			Name string `json:"name"`
+			// Kind is singular, plus or star, for variadic parameters
+			// that take one or more and zero or more values.
+			Kind string `json:"kind"`
+			// Default is null, a string, or an expression as an array.
+			Default json.RawMessage `json:"default"`
+		} `json:"parameters"`
+	} `json:"recipes"`
+}
+
+// parseJustDump returns a task for each public recipe of a justfile dump,
+// sorted by name like `just --list`. A recipe's doc comment describes it
+// and its parameters are the task's. Recipes of modules aren't included.
+func parseJustDump(data []byte) ([]TaskDefinition, error) {
+	var dump justDump
+	if err := json.Unmarshal(data, &dump); err != nil {
+		return nil, fmt.Errorf("parsing just --dump: %w", err)
+	}
+	names := make([]string, 0, len(dump.Recipes))
+	for name, recipe := range dump.Recipes {
+		if !recipe.Private {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var tasks []TaskDefinition
+	for _, name := range names {
+		recipe := dump.Recipes[name]
+		task := TaskDefinition{Name: name}
+		if recipe.Doc != nil {
+			task.Description = *recipe.Doc
+		}
+		usage := []string{"just", name}
+		for _, p := range recipe.Parameters {
+			param := TaskParameter{Name: p.Name}
+			var value string
+			switch {
+			case len(p.Default) == 0 || string(p.Default) == "null":
+				param.IsRequired = p.Kind != "star"
+			case json.Unmarshal(p.Default, &value) == nil:
+				param.Description = fmt.Sprintf("Defaults to %s.", value)
+			}
+			if p.Kind == "plus" || p.Kind == "star" {
+				param.Description = strings.TrimSpace("Separate values with spaces. " + param.Description)
+			}
+			task.Parameters = append(task.Parameters, param)
+			if param.IsRequired {
+				usage = append(usage, "<"+p.Name+">")
+			} else {
+				usage = append(usage, "["+p.Name+"]")
+			}
+		}
+		task.Usage = strings.Join(usage, " ")
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
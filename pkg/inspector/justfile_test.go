@@ -0,0 +1,82 @@
+package inspector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testJustDump is `just --dump --dump-format json`, trimmed, for:
+//
+//	# Deploy to an environment
+//	deploy env target="app" +flags="":
+//	    ./deploy.sh {{env}} {{target}} {{flags}}
+//
+//	test *args:
+//	    go test {{args}} ./...
+//
+//	_helper:
+//	    echo hidden
+const testJustDump = `{
+  "first": "deploy",
+  "recipes": {
+    "test": {"name": "test", "doc": null, "private": false, "parameters": [
+      {"name": "args", "kind": "star", "default": null, "export": false}
+    ]},
+    "deploy": {"name": "deploy", "doc": "Deploy to an environment", "private": false, "parameters": [
+      {"name": "env", "kind": "singular", "default": null, "export": false},
+      {"name": "target", "kind": "singular", "default": "app", "export": false},
+      {"name": "flags", "kind": "plus", "default": ["variable", "FLAGS"], "export": false}
+    ]},
+    "_helper": {"name": "_helper", "doc": null, "private": true, "parameters": []}
+  }
+}`
+
+func TestJustfileSource(t *testing.T) {
+	dir := t.TempDir()
+	justfile := filepath.Join(dir, "justfile")
+	if err := os.WriteFile(justfile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	dump := filepath.Join(dir, "dump.json")
+	if err := os.WriteFile(dump, []byte(testJustDump), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The fake just checks it was asked for a dump of the justfile.
+	bin := filepath.Join(dir, "just")
+	script := "#!/bin/sh\n[ \"$*\" = \"--justfile " + justfile + " --dump --dump-format json\" ] || exit 1\ncat " + dump + "\n"
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	i, err := New(WithTaskfile(justfile), WithTaskBin(bin))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := i.Inspect(context.Background())
+	if err != nil {
+		t.Fatalf("Inspect() error = %v", err)
+	}
+	want := []TaskDefinition{
+		{Name: "deploy", Description: "Deploy to an environment", Usage: "just deploy <env> [target] [flags]", Parameters: []TaskParameter{
+			{Name: "env", IsRequired: true},
+			{Name: "target", Description: "Defaults to app."},
+			{Name: "flags", Description: "Separate values with spaces."},
+		}},
+		{Name: "test", Usage: "just test [args]", Parameters: []TaskParameter{{Name: "args", Description: "Separate values with spaces."}}},
+	}
+	if !reflect.DeepEqual(config.Tasks, want) {
+		t.Errorf("Inspect() =\n%+v\nwant\n%+v", config.Tasks, want)
+	}
+
+	run := Run{File: justfile, Dir: dir, Task: "deploy", Parameters: []string{"env", "target", "flags"}, Vars: []string{"target=web", "env=prod", "GLOBAL=1"}, DryRun: true}
+	if got := strings.Join(SourceOf(justfile).Command(run), " "); got != "--justfile "+justfile+" --working-directory "+dir+" --dry-run deploy prod web" {
+		t.Errorf("just command = %q", got)
+	}
+	if SourceOf("Justfile") != Justfile || SourceOf(".justfile") != Justfile || SourceOf("tools.just") != Justfile {
+		t.Error("SourceOf() didn't recognise a justfile")
+	}
+}
@@ -0,0 +1,146 @@
+package inspector
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	// makeRule matches the targets of a rule, leaving out variable
+	// assignments such as FOO := bar.
+	makeRule = regexp.MustCompile(`^([^\s:=#][^:=#]*?)\s*::?(?:[^=:]|$)`)
+	// makeOptionalVar matches a variable assigned with ?=, which the
+	// command line is meant to override.
+	makeOptionalVar = regexp.MustCompile(`^(?:override\s+|export\s+)?([A-Za-z_][A-Za-z0-9_]*)\s*\?=\s*(.*)$`)
+	// makeVarRef matches $(VAR) and ${VAR}.
+	makeVarRef = regexp.MustCompile(`\$[({]([A-Za-z_][A-Za-z0-9_]*)[)}]`)
+)
+
+// parseMakefile returns the targets of the Makefile at path that can be run
+// by name: not special targets like .PHONY, patterns or targets named by
+// variables. As in the common `make help` convention, a `## text` comment
+// after the target, or on the lines right above it, describes it.
+// Variables the Makefile assigns with ?= and the target's recipe uses are
+// its parameters. Included Makefiles aren't read.
+func parseMakefile(path string) ([]TaskDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	optional := map[string]string{}
+	var tasks []TaskDefinition
+	index := map[string]int{}
+	var doc []string
+	// current are the indexes in tasks of the rule whose recipe is read.
+	var current []int
+	var inDefine bool
+	for _, line := range makeLines(string(data)) {
+		// Multi-line variables can hold anything.
+		if inDefine {
+			inDefine = strings.TrimSpace(line) != "endef"
+			continue
+		}
+		if strings.HasPrefix(line, "\t") {
+			for _, ref := range makeVarRef.FindAllStringSubmatch(line, -1) {
+				for _, i := range current {
+					tasks[i].Parameters = appendParameter(tasks[i].Parameters, ref[1])
+				}
+			}
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "##") {
+			doc = append(doc, strings.TrimSpace(strings.TrimLeft(trimmed, "#")))
+			continue
+		}
+		if fields := strings.Fields(trimmed); len(fields) > 0 && fields[0] == "define" {
+			inDefine = true
+			current, doc = nil, nil
+			continue
+		}
+		if m := makeOptionalVar.FindStringSubmatch(trimmed); m != nil {
+			optional[m[1]] = strings.TrimSpace(m[2])
+			current, doc = nil, nil
+			continue
+		}
+		m := makeRule.FindStringSubmatch(line)
+		if m == nil {
+			// Blank lines, comments and other directives end a rule.
+			if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+				current = nil
+			}
+			doc = nil
+			continue
+		}
+
+		description := strings.Join(doc, "\n")
+		if _, comment, ok := strings.Cut(line, "##"); ok {
+			description = strings.TrimSpace(comment)
+		}
+		doc, current = nil, nil
+		for _, name := range strings.Fields(m[1]) {
+			if strings.HasPrefix(name, ".") || strings.ContainsAny(name, "%$") {
+				continue
+			}
+			i, ok := index[name]
+			if !ok {
+				i = len(tasks)
+				index[name] = i
+				tasks = append(tasks, TaskDefinition{Name: name})
+			}
+			if tasks[i].Description == "" {
+				tasks[i].Description = description
+			}
+			current = append(current, i)
+		}
+	}
+
+	for i := range tasks {
+		var params []TaskParameter
+		for _, p := range tasks[i].Parameters {
+			value, ok := optional[p.Name]
+			if !ok {
+				continue
+			}
+			if value != "" {
+				p.Description = fmt.Sprintf("Defaults to %s.", value)
+			}
+			params = append(params, p)
+		}
+		tasks[i].Parameters = params
+		tasks[i].Usage = usage("make", tasks[i])
+	}
+	return tasks, nil
+}
+
+// makeLines splits a Makefile into lines, joining those continued with a
+// trailing backslash.
+func makeLines(data string) []string {
+	var lines []string
+	var continued strings.Builder
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.HasSuffix(line, "\\") {
+			continued.WriteString(strings.TrimSuffix(line, "\\"))
+			continued.WriteString(" ")
+			continue
+		}
+		continued.WriteString(line)
+		lines = append(lines, continued.String())
+		continued.Reset()
+	}
+	return lines
+}
+
+// appendParameter adds the parameter name to params unless it is there.
+func appendParameter(params []TaskParameter, name string) []TaskParameter {
+	for _, p := range params {
+		if p.Name == name {
+			return params
+		}
+	}
+	return append(params, TaskParameter{Name: name})
+}
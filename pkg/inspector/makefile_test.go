@@ -0,0 +1,97 @@
+package inspector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testMakefile = `ENV ?= dev
+VERSION ?=
+CC := gcc
+OUT = bin
+
+.PHONY: build test deploy
+
+## Build the binary.
+## Puts it in bin/.
+build: ## Build it
+	$(CC) -o $(OUT)/app .
+
+# Not documented for help.
+test:
+	go test ./... \
+		-tags $(ENV)
+
+deploy: build ## Deploy to an environment
+	./deploy.sh $(ENV) ${VERSION} $(HOME)
+
+define SCRIPT
+fake: target
+endef
+
+%.o: %.c
+	$(CC) -c $<
+
+$(OUT)/app: build
+`
+
+func TestParseMakefile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Makefile")
+	if err := os.WriteFile(path, []byte(testMakefile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := parseMakefile(path)
+	if err != nil {
+		t.Fatalf("parseMakefile() error = %v", err)
+	}
+	want := []TaskDefinition{
+		{Name: "build", Description: "Build it", Usage: "make build"},
+		{Name: "test", Usage: "make test ENV=<value>", Parameters: []TaskParameter{{Name: "ENV", Description: "Defaults to dev."}}},
+		{Name: "deploy", Description: "Deploy to an environment", Usage: "make deploy ENV=<value> VERSION=<value>", Parameters: []TaskParameter{
+			{Name: "ENV", Description: "Defaults to dev."},
+			{Name: "VERSION"},
+		}},
+	}
+	if !reflect.DeepEqual(tasks, want) {
+		t.Errorf("parseMakefile() =\n%+v\nwant\n%+v", tasks, want)
+	}
+}
+
+func TestParseMakefileDocAbove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Makefile")
+	if err := os.WriteFile(path, []byte("## Run the linters.\n## All of them.\nlint:\n\tgolangci-lint run\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	i, err := New(WithTaskfile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, err := i.InspectTask(context.Background(), "lint")
+	if err != nil {
+		t.Fatalf("InspectTask() error = %v", err)
+	}
+	if task.Description != "Run the linters.\nAll of them." {
+		t.Errorf("Description = %q", task.Description)
+	}
+	if _, err := i.InspectTask(context.Background(), "missing"); err == nil {
+		t.Error("InspectTask(missing) error = nil")
+	}
+}
+
+func TestSourceCommand(t *testing.T) {
+	run := Run{File: "/src/Makefile", Dir: "/src", Task: "deploy", Vars: []string{"ENV=prod"}, Args: []string{"--force"}, DryRun: true}
+	if got := strings.Join(SourceOf(run.File).Command(run), " "); got != "-f /src/Makefile -C /src --no-print-directory -n deploy ENV=prod" {
+		t.Errorf("make command = %q", got)
+	}
+	run.File = "/src/Taskfile.yml"
+	if got := strings.Join(SourceOf(run.File).Command(run), " "); got != "--taskfile /src/Taskfile.yml --dir /src --dry deploy ENV=prod -- --force" {
+		t.Errorf("task command = %q", got)
+	}
+	if SourceOf("build/rules.mk") != Makefile || SourceOf("GNUmakefile") != Makefile || SourceOf("Taskfile.dist.yml") != Taskfile {
+		t.Error("SourceOf() picked the wrong source")
+	}
+}
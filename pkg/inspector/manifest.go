@@ -0,0 +1,219 @@
+package inspector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestVersion is the newest tools.yml version this tmcp understands.
+const ManifestVersion = 1
+
+// manifest is a tools.yml file, which declares shell commands to serve as
+// tools without a task runner.
+type manifest struct {
+	// Version is the manifest format version; it defaults to 1.
+	Version int            `yaml:"version"`
+	Tools   []manifestTool `yaml:"tools"`
+}
+
+type manifestTool struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Command is run with sh -c. {{.name}} stands for the value of the
+	// parameter name, quoted for the shell.
+	Command string `yaml:"command"`
+	// Dir is the directory the command runs from, relative to the manifest.
+	Dir      string          `yaml:"dir"`
+	ReadOnly bool            `yaml:"read_only"`
+	Params   []manifestParam `yaml:"params"`
+}
+
+type manifestParam struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Type is string, number, integer or boolean; string by default.
+	Type string `yaml:"type"`
+	// Default makes the parameter optional.
+	Default any `yaml:"default"`
+}
+
+// manifestParamName matches the parameter names templates can refer to.
+var manifestParamName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// loadManifest reads and validates the tools.yml at path. Tool directories
+// are resolved against the manifest's.
+func loadManifest(path string) (*manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &manifest{}
+	if err := yaml.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if m.Version == 0 {
+		m.Version = 1
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	for i := range m.Tools {
+		if dir := m.Tools[i].Dir; dir != "" && !filepath.IsAbs(dir) {
+			m.Tools[i].Dir = filepath.Join(filepath.Dir(path), dir)
+		}
+	}
+	return m, nil
+}
+
+// validate checks the manifest for tools that can't be served.
+func (m *manifest) validate() error {
+	if m.Version > ManifestVersion {
+		return fmt.Errorf("version %d requires a newer tmcp (this one understands up to version %d)", m.Version, ManifestVersion)
+	}
+	seen := map[string]bool{}
+	for i, tool := range m.Tools {
+		name := fmt.Sprintf("tools[%d]", i)
+		if tool.Name == "" {
+			return fmt.Errorf("%s: name is required", name)
+		}
+		if seen[tool.Name] {
+			return fmt.Errorf("%s: duplicate tool %q", name, tool.Name)
+		}
+		seen[tool.Name] = true
+		if strings.TrimSpace(tool.Command) == "" {
+			return fmt.Errorf("%s: command is required", name)
+		}
+		if _, err := template.New(tool.Name).Parse(tool.Command); err != nil {
+			return fmt.Errorf("%s: command: %w", name, err)
+		}
+		for _, p := range tool.Params {
+			if !manifestParamName.MatchString(p.Name) {
+				return fmt.Errorf("%s: parameter name %q must be letters, digits and '_'", name, p.Name)
+			}
+			switch p.Type {
+			case "", "string", "number", "integer", "boolean":
+			default:
+				return fmt.Errorf("%s: parameter %s has unknown type %q (want string, number, integer or boolean)", name, p.Name, p.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// tool returns the tool named name.
+func (m *manifest) tool(name string) (*manifestTool, error) {
+	for i := range m.Tools {
+		if m.Tools[i].Name == name {
+			return &m.Tools[i], nil
+		}
+	}
+	return nil, fmt.Errorf("tool %q not found", name)
+}
+
+type manifestSource struct{}
+
+func (manifestSource) Name() string { return "tools" }
+
+func (manifestSource) Bin(string) string { return "sh" }
+
+// Command runs the tool's command with sh from the tool's directory, or
+// from r.Dir. Only the tool's parameters are taken from Vars, and there is
+// no equivalent of CLI_ARGS. A shell command can't be dry-run. If the
+// command can't be built, the returned arguments make sh fail with the
+// reason.
+func (manifestSource) Command(r Run) []string {
+	if r.DryRun {
+		return nil
+	}
+	script, err := manifestScript(r)
+	if err != nil {
+		return []string{"-c", `echo "$1" >&2; exit 2`, "tmcp", err.Error()}
+	}
+	return []string{"-c", script}
+}
+
+func (manifestSource) parse(_ context.Context, i *Inspector) ([]TaskDefinition, error) {
+	m, err := loadManifest(i.taskfilePath)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []TaskDefinition
+	for _, tool := range m.Tools {
+		task := TaskDefinition{Name: tool.Name, Description: tool.Description, Usage: tool.Command, Dir: tool.Dir, ReadOnly: tool.ReadOnly}
+		for _, p := range tool.Params {
+			task.Parameters = append(task.Parameters, TaskParameter{
+				Name:        p.Name,
+				Description: p.Description,
+				IsRequired:  p.Default == nil,
+				Type:        p.Type,
+				Default:     p.Default,
+			})
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// manifestScript renders the command of the tool r runs, preceded by a cd
+// to the directory it runs from.
+func manifestScript(r Run) (string, error) {
+	m, err := loadManifest(r.File)
+	if err != nil {
+		return "", err
+	}
+	tool, err := m.tool(r.Task)
+	if err != nil {
+		return "", err
+	}
+	values := map[string]string{}
+	for _, v := range r.Vars {
+		if key, value, ok := strings.Cut(v, "="); ok {
+			values[key] = value
+		}
+	}
+	data := map[string]shellArg{}
+	for _, p := range tool.Params {
+		value, ok := values[p.Name]
+		switch {
+		case ok:
+			data[p.Name] = shellArg(value)
+		case p.Default != nil:
+			data[p.Name] = shellArg(fmt.Sprint(p.Default))
+		default:
+			return "", fmt.Errorf("missing parameter %q", p.Name)
+		}
+	}
+
+	tmpl, err := template.New(tool.Name).Option("missingkey=error").Parse(tool.Command)
+	if err != nil {
+		return "", err
+	}
+	var command strings.Builder
+	if err := tmpl.Execute(&command, data); err != nil {
+		return "", fmt.Errorf("command of %s: %w", tool.Name, err)
+	}
+	dir := tool.Dir
+	if dir == "" {
+		dir = r.Dir
+	}
+	if dir == "" {
+		dir = filepath.Dir(r.File)
+	}
+	return "cd " + shellArg(dir).String() + " && " + command.String(), nil
+}
+
+// shellArg is a parameter value in a command template. It prints quoted
+// for the shell, so values can't inject commands, but compares as itself:
+// {{if eq .verbose "true"}}-v{{end}}.
+type shellArg string
+
+func (s shellArg) String() string {
+	return "'" + strings.ReplaceAll(string(s), "'", `'\''`) + "'"
+}
@@ -0,0 +1,106 @@
+package inspector
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testManifest = `version: 1
+tools:
+  - name: greet
+    description: Greet someone
+    command: echo hello {{.who}}{{if eq .loud "true"}}!{{end}} x{{.times}}
+    read_only: true
+    params:
+      - name: who
+        description: Who to greet
+      - name: loud
+        type: boolean
+        default: false
+      - name: times
+        type: integer
+        default: 1
+  - name: where
+    command: pwd
+    dir: sub
+`
+
+func TestManifestSource(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tools.yml")
+	if err := os.WriteFile(path, []byte(testManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if SourceOf(path) != Manifest {
+		t.Fatal("SourceOf(tools.yml) isn't Manifest")
+	}
+	i, err := New(WithTaskfile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := i.Inspect(context.Background())
+	if err != nil {
+		t.Fatalf("Inspect() error = %v", err)
+	}
+	want := []TaskDefinition{
+		{Name: "greet", Description: "Greet someone", Usage: `echo hello {{.who}}{{if eq .loud "true"}}!{{end}} x{{.times}}`, ReadOnly: true, Parameters: []TaskParameter{
+			{Name: "who", Description: "Who to greet", IsRequired: true},
+			{Name: "loud", Type: "boolean", Default: false},
+			{Name: "times", Type: "integer", Default: 1},
+		}},
+		{Name: "where", Usage: "pwd", Dir: filepath.Join(dir, "sub")},
+	}
+	if !reflect.DeepEqual(config.Tasks, want) {
+		t.Errorf("Inspect() =\n%+v\nwant\n%+v", config.Tasks, want)
+	}
+
+	tests := []struct {
+		vars []string
+		want string
+	}{
+		{[]string{"who=Ada"}, "hello Ada x1\n"},
+		{[]string{"who=Ada", "loud=true", "times=3", "GLOBAL=1"}, "hello Ada! x3\n"},
+		// Values are quoted, so they can't run commands.
+		{[]string{"who=$(id); exit 1 'x'"}, "hello $(id); exit 1 'x' x1\n"},
+	}
+	for _, tt := range tests {
+		args := Manifest.Command(Run{File: path, Dir: dir, Task: "greet", Vars: tt.vars})
+		out, err := exec.Command("sh", args...).CombinedOutput()
+		if err != nil || string(out) != tt.want {
+			t.Errorf("sh %q = %q, %v, want %q", args, out, err, tt.want)
+		}
+	}
+
+	args := Manifest.Command(Run{File: path, Task: "greet"})
+	if out, err := exec.Command("sh", args...).CombinedOutput(); err == nil || !strings.Contains(string(out), `missing parameter "who"`) {
+		t.Errorf("sh without who = %q, %v, want a missing parameter error", out, err)
+	}
+	if args := Manifest.Command(Run{File: path, Task: "greet", Vars: []string{"who=Ada"}, DryRun: true}); args != nil {
+		t.Errorf("dry run command = %q, want none", args)
+	}
+}
+
+func TestLoadManifestInvalid(t *testing.T) {
+	tests := map[string]string{
+		"version":   "version: 2\ntools: []\n",
+		"command":   "tools:\n  - name: a\n",
+		"duplicate": "tools:\n  - {name: a, command: x}\n  - {name: a, command: y}\n",
+		"template":  "tools:\n  - {name: a, command: 'echo {{.x'}\n",
+		"param":     "tools:\n  - name: a\n    command: x\n    params: [{name: my-param}]\n",
+		"type":      "tools:\n  - name: a\n    command: x\n    params: [{name: n, type: array}]\n",
+	}
+	for name, manifest := range tests {
+		path := filepath.Join(t.TempDir(), "tools.yml")
+		if err := os.WriteFile(path, []byte(manifest), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadManifest(path); err == nil {
+			t.Errorf("%s: loadManifest() error = nil", name)
+		}
+	}
+}
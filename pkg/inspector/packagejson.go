@@ -0,0 +1,170 @@
+package inspector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type packageJSONSource struct{}
+
+func (packageJSONSource) Name() string { return "npm" }
+
+// Bin returns the package manager the package uses.
+func (packageJSONSource) Bin(path string) string {
+	return packageManager(path)
+}
+
+// Command runs the script with the package's package manager. Scripts take
+// arguments rather than variables, so Vars are dropped. Package managers
+// can't show what a script would do without running it, so dry runs
+// aren't supported.
+func (packageJSONSource) Command(r Run) []string {
+	if r.DryRun {
+		return nil
+	}
+	dir := r.Dir
+	if dir == "" {
+		dir = filepath.Dir(r.File)
+	}
+	switch packageManager(r.File) {
+	case "pnpm":
+		return append([]string{"--dir", dir, "run", r.Task}, r.Args...)
+	case "yarn", "bun":
+		return append([]string{"--cwd", dir, "run", r.Task}, r.Args...)
+	default:
+		args := []string{"--prefix", dir, "run", r.Task}
+		if len(r.Args) > 0 {
+			args = append(append(args, "--"), r.Args...)
+		}
+		return args
+	}
+}
+
+func (packageJSONSource) parse(_ context.Context, i *Inspector) ([]TaskDefinition, error) {
+	return parsePackageJSON(i.taskfilePath)
+}
+
+// packageJSON is the part of a package.json tmcp reads.
+type packageJSON struct {
+	Scripts json.RawMessage `json:"scripts"`
+	// ScriptsInfo describes scripts, by name, as npm-scripts-info reads it.
+	ScriptsInfo    map[string]string `json:"scriptsInfo"`
+	ScriptsInfoAlt map[string]string `json:"scripts-info"`
+	PackageManager string            `json:"packageManager"`
+}
+
+// lockfiles name the package manager that wrote each lockfile, in the
+// order they are looked for.
+var lockfiles = []struct{ name, manager string }{
+	{"pnpm-lock.yaml", "pnpm"},
+	{"yarn.lock", "yarn"},
+	{"bun.lock", "bun"},
+	{"bun.lockb", "bun"},
+	{"package-lock.json", "npm"},
+	{"npm-shrinkwrap.json", "npm"},
+}
+
+// packageManager returns the package manager of the package.json at path:
+// the one its packageManager field names, else the one whose lockfile is
+// next to it, else npm.
+func packageManager(path string) string {
+	if data, err := os.ReadFile(path); err == nil {
+		var pkg packageJSON
+		if json.Unmarshal(data, &pkg) == nil && pkg.PackageManager != "" {
+			name, _, _ := strings.Cut(pkg.PackageManager, "@")
+			return name
+		}
+	}
+	for _, lockfile := range lockfiles {
+		if _, err := os.Stat(filepath.Join(filepath.Dir(path), lockfile.name)); err == nil {
+			return lockfile.manager
+		}
+	}
+	return "npm"
+}
+
+// parsePackageJSON returns a task for each script of the package.json at
+// path, in the order they are defined. A script is described by its entry
+// in scriptsInfo (or scripts-info), or else by a comment entry in scripts
+// whose name is the script's behind "//", e.g. "//build". Scripts take
+// extra arguments rather than parameters.
+func parsePackageJSON(path string) ([]TaskDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pkg packageJSON
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if len(pkg.Scripts) == 0 {
+		return nil, nil
+	}
+	names, scripts, err := orderedStrings(pkg.Scripts)
+	if err != nil {
+		return nil, fmt.Errorf("parsing the scripts of %s: %w", path, err)
+	}
+
+	comments := map[string]string{}
+	for _, name := range names {
+		if script, ok := strings.CutPrefix(name, "//"); ok {
+			comments[strings.TrimSpace(script)] = scripts[name]
+		}
+	}
+	manager := packageManager(path)
+	var tasks []TaskDefinition
+	for _, name := range names {
+		if strings.HasPrefix(name, "//") {
+			continue
+		}
+		description := pkg.ScriptsInfo[name]
+		if description == "" {
+			description = pkg.ScriptsInfoAlt[name]
+		}
+		if description == "" {
+			description = comments[name]
+		}
+		if description == "" {
+			description = "Runs: " + scripts[name]
+		}
+		tasks = append(tasks, TaskDefinition{
+			Name:        name,
+			Description: description,
+			Usage:       manager + " run " + name,
+			CLIArgs:     true,
+		})
+	}
+	return tasks, nil
+}
+
+// orderedStrings decodes a JSON object of strings, returning its keys in
+// the order they appear.
+func orderedStrings(data json.RawMessage) ([]string, map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, fmt.Errorf("want an object")
+	}
+	var keys []string
+	values := map[string]string{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := tok.(string)
+		var value string
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", key, err)
+		}
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+	return keys, values, nil
+}
@@ -0,0 +1,76 @@
+package inspector
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testPackageJSON = `{
+  "name": "app",
+  "packageManager": "yarn@4.1.0",
+  "scripts": {
+    "// lint": "Lint the sources",
+    "lint": "eslint .",
+    "build": "tsc -p .",
+    "test": "vitest run",
+    "//": "A comment about all of them"
+  },
+  "scriptsInfo": {
+    "build": "Compile the app"
+  }
+}`
+
+func TestParsePackageJSON(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "package.json")
+	if err := os.WriteFile(path, []byte(testPackageJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := parsePackageJSON(path)
+	if err != nil {
+		t.Fatalf("parsePackageJSON() error = %v", err)
+	}
+	want := []TaskDefinition{
+		{Name: "lint", Description: "Lint the sources", Usage: "yarn run lint", CLIArgs: true},
+		{Name: "build", Description: "Compile the app", Usage: "yarn run build", CLIArgs: true},
+		{Name: "test", Description: "Runs: vitest run", Usage: "yarn run test", CLIArgs: true},
+	}
+	if !reflect.DeepEqual(tasks, want) {
+		t.Errorf("parsePackageJSON() =\n%+v\nwant\n%+v", tasks, want)
+	}
+
+	run := Run{File: path, Task: "test", Vars: []string{"ENV=ci"}, Args: []string{"--watch"}}
+	if got := strings.Join(PackageJSON.Command(run), " "); got != "--cwd "+dir+" run test --watch" {
+		t.Errorf("yarn command = %q", got)
+	}
+	run.DryRun = true
+	if got := PackageJSON.Command(run); got != nil {
+		t.Errorf("dry run command = %q, want none", got)
+	}
+}
+
+func TestPackageManager(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "package.json")
+	if err := os.WriteFile(path, []byte(`{"scripts": {"test": "jest"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := PackageJSON.Bin(path); got != "npm" {
+		t.Errorf("Bin() = %q, want npm without a lockfile", got)
+	}
+	if got := strings.Join(PackageJSON.Command(Run{File: path, Task: "test", Args: []string{"-u"}}), " "); got != "--prefix "+dir+" run test -- -u" {
+		t.Errorf("npm command = %q", got)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bun.lockb"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := PackageJSON.Bin(path); got != "bun" {
+		t.Errorf("Bin() = %q, want bun", got)
+	}
+	if SourceOf(filepath.Join("web", "package.json")) != PackageJSON {
+		t.Error("SourceOf(package.json) isn't PackageJSON")
+	}
+}
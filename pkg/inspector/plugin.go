@@ -0,0 +1,110 @@
+package inspector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PluginProtocol is the version of the plugin protocol this tmcp speaks.
+// Plugins get it in the TMCP_PLUGIN_PROTOCOL environment variable.
+const PluginProtocol = 1
+
+// PluginSource returns the source of files served by a plugin, an external
+// program run as command. The plugin lists the tasks of a file when run as
+//
+//	command... list FILE
+//
+// by printing {"tasks": [...]}, each task an object with name, description,
+// usage, dir (relative to the file), read_only and parameters (name,
+// description, type, join and default). It runs a task, with the task's output
+// and exit status, when run as
+//
+//	command... run [--dir DIR] FILE TASK [KEY=VALUE...] [-- ARGS...]
+//
+// Plugins aren't trusted to dry-run, so safe mode only shows the command.
+func PluginSource(command []string) Source {
+	return &pluginSource{command: command}
+}
+
+type pluginSource struct {
+	command []string
+}
+
+func (*pluginSource) Name() string { return "plugin" }
+
+func (p *pluginSource) Bin(string) string { return p.command[0] }
+
+func (p *pluginSource) Command(r Run) []string {
+	if r.DryRun {
+		return nil
+	}
+	args := append(append([]string{}, p.command[1:]...), "run")
+	if r.Dir != "" {
+		args = append(args, "--dir", r.Dir)
+	}
+	args = append(append(args, r.File, r.Task), r.Vars...)
+	if len(r.Args) > 0 {
+		args = append(append(args, "--"), r.Args...)
+	}
+	return args
+}
+
+// pluginTasks is the output of a plugin's list command.
+type pluginTasks struct {
+	Tasks []struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Usage       string `json:"usage"`
+		Dir         string `json:"dir"`
+		ReadOnly    bool   `json:"read_only"`
+		Parameters  []struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			Type        string `json:"type"`
+			Join        string `json:"join"`
+			Default     any    `json:"default"`
+		} `json:"parameters"`
+	} `json:"tasks"`
+}
+
+func (p *pluginSource) parse(ctx context.Context, i *Inspector) ([]TaskDefinition, error) {
+	var stderr bytes.Buffer
+	args := append(append([]string{}, p.command[1:]...), "list", i.taskfilePath)
+	cmd := i.cmdExecutor(ctx, i.taskBinPath, args...)
+	cmd.Env = append(cmd.Environ(), fmt.Sprintf("TMCP_PLUGIN_PROTOCOL=%d", PluginProtocol))
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("running plugin %s list: %w: %s", i.taskBinPath, err, strings.TrimSpace(stderr.String()))
+	}
+	var listed pluginTasks
+	if err := json.Unmarshal(out, &listed); err != nil {
+		return nil, fmt.Errorf("parsing the output of plugin %s list: %w", i.taskBinPath, err)
+	}
+
+	var tasks []TaskDefinition
+	for _, t := range listed.Tasks {
+		if t.Name == "" {
+			return nil, fmt.Errorf("plugin %s listed a task without a name", i.taskBinPath)
+		}
+		task := TaskDefinition{Name: t.Name, Description: t.Description, Usage: t.Usage, Dir: resolveTaskDir(i.taskfilePath, t.Dir), ReadOnly: t.ReadOnly}
+		for _, param := range t.Parameters {
+			if err := ValidateParameterType(param.Type, param.Join); err != nil {
+				return nil, fmt.Errorf("plugin %s: task %s: parameter %s: %w", i.taskBinPath, t.Name, param.Name, err)
+			}
+			task.Parameters = append(task.Parameters, TaskParameter{
+				Name:        param.Name,
+				Description: param.Description,
+				IsRequired:  param.Default == nil,
+				Type:        param.Type,
+				Join:        param.Join,
+				Default:     param.Default,
+			})
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
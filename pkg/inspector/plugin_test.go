@@ -0,0 +1,56 @@
+package inspector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPluginSource(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "Rakefile")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	plugin := filepath.Join(dir, "rake-plugin")
+	script := `#!/bin/sh
+[ "$1 $2 $3" = "--rake list ` + file + `" ] && [ "$TMCP_PLUGIN_PROTOCOL" = 1 ] || exit 1
+echo '{"tasks": [{"name": "db:migrate", "description": "Migrate", "dir": "db", "parameters": [{"name": "VERSION", "type": "integer", "default": 0}, {"name": "ENV"}]}]}'
+`
+	if err := os.WriteFile(plugin, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	source := PluginSource([]string{plugin, "--rake"})
+	i, err := New(WithTaskfile(file), WithSource(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := i.DiscoverTasks(context.Background())
+	if err != nil || !reflect.DeepEqual(tasks, []string{"db:migrate"}) {
+		t.Fatalf("DiscoverTasks() = %v, %v", tasks, err)
+	}
+	task, err := i.InspectTask(context.Background(), "db:migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &TaskDefinition{Name: "db:migrate", Description: "Migrate", Dir: filepath.Join(dir, "db"), Parameters: []TaskParameter{
+		{Name: "VERSION", Type: "integer", Default: float64(0)},
+		{Name: "ENV", IsRequired: true},
+	}}
+	if !reflect.DeepEqual(task, want) {
+		t.Errorf("InspectTask() = %+v, want %+v", task, want)
+	}
+
+	run := Run{File: file, Dir: dir, Task: "db:migrate", Vars: []string{"ENV=test"}, Args: []string{"--trace"}}
+	if got := strings.Join(source.Command(run), " "); got != "--rake run --dir "+dir+" "+file+" db:migrate ENV=test -- --trace" {
+		t.Errorf("plugin command = %q", got)
+	}
+	run.DryRun = true
+	if source.Command(run) != nil {
+		t.Error("plugins shouldn't be trusted to dry-run")
+	}
+}
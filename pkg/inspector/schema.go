@@ -0,0 +1,12 @@
+package inspector
+
+import _ "embed"
+
+//go:embed schema.json
+var jsonSchema []byte
+
+// JSONSchema returns the JSON Schema of the JSON encoding of MCPConfig at
+// the current SchemaVersion.
+func JSONSchema() []byte {
+	return append([]byte(nil), jsonSchema...)
+}
@@ -0,0 +1,169 @@
+package inspector
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Source is a kind of file whose tasks tmcp exposes, such as a Taskfile, a
+// Makefile, a package.json, a justfile or a tools.yml manifest. It knows which program runs the tasks and how.
+type Source interface {
+	// Name names the source's kind, e.g. "make".
+	Name() string
+	// Bin returns the program that runs the tasks of the file at path,
+	// unless another is configured.
+	Bin(path string) string
+	// Command returns the arguments Bin runs r with, or nil if r is a dry
+	// run and the source has no way of doing one.
+	Command(r Run) []string
+}
+
+// Run is a run of a task, for Source.Command.
+type Run struct {
+	// File is the file the task is defined in.
+	File string
+	// Dir is the directory the task runs from; the file's directory when
+	// empty.
+	Dir  string
+	Task string
+	// Vars are the task's variables as KEY=VALUE pairs.
+	Vars []string
+	// Parameters are the names of the task's parameters in order, for
+	// sources that take them by position.
+	Parameters []string
+	// Args are extra command-line arguments, CLI_ARGS for Taskfiles.
+	Args []string
+	// DryRun prints what the task would do without doing it.
+	DryRun bool
+}
+
+// parser is implemented by sources whose tasks are read all at once, by
+// tmcp itself or with a single run of i's program, rather than asking the
+// program about each task.
+type parser interface {
+	parse(ctx context.Context, i *Inspector) ([]TaskDefinition, error)
+}
+
+var (
+	// Taskfile is the source of Taskfiles, inspected and run with task.
+	Taskfile Source = taskfileSource{}
+	// Makefile is the source of Makefiles, whose targets run with make.
+	Makefile Source = makefileSource{}
+	// PackageJSON is the source of package.json files, whose scripts run
+	// with the package manager the package uses.
+	PackageJSON Source = packageJSONSource{}
+	// Justfile is the source of justfiles, whose recipes run with just.
+	Justfile Source = justfileSource{}
+	// Manifest is the source of tools.yml manifests, which declare shell
+	// commands to serve without a task runner.
+	Manifest Source = manifestSource{}
+)
+
+// Sources are the sources tmcp knows.
+var Sources = []Source{Taskfile, Makefile, PackageJSON, Justfile, Manifest}
+
+// SourceNamed returns the source whose Name is name.
+func SourceNamed(name string) (Source, bool) {
+	for _, source := range Sources {
+		if source.Name() == name {
+			return source, true
+		}
+	}
+	return nil, false
+}
+
+// SourceOf returns the source of the file at path by its name. Anything
+// that isn't recognised as another kind is taken for a Taskfile.
+func SourceOf(path string) Source {
+	base := filepath.Base(path)
+	switch {
+	case base == "Makefile" || base == "makefile" || base == "GNUmakefile" || filepath.Ext(base) == ".mk":
+		return Makefile
+	case base == "package.json":
+		return PackageJSON
+	case strings.EqualFold(base, "justfile") || base == ".justfile" || filepath.Ext(base) == ".just":
+		return Justfile
+	case base == "tools.yml" || base == "tools.yaml":
+		return Manifest
+	default:
+		return Taskfile
+	}
+}
+
+type taskfileSource struct{}
+
+func (taskfileSource) Name() string { return "task" }
+
+func (taskfileSource) Bin(string) string { return "task" }
+
+func (taskfileSource) Command(r Run) []string {
+	args := []string{"--taskfile", r.File}
+	if r.Dir != "" {
+		args = append(args, "--dir", r.Dir)
+	}
+	if r.DryRun {
+		args = append(args, "--dry")
+	}
+	args = append(append(args, r.Task), r.Vars...)
+	if len(r.Args) > 0 {
+		args = append(append(args, "--"), r.Args...)
+	}
+	return args
+}
+
+type makefileSource struct{}
+
+func (makefileSource) Name() string { return "make" }
+
+func (makefileSource) Bin(string) string { return "make" }
+
+// Command runs the target with GNU make. Variables given on make's command
+// line override those the Makefile assigns. make has no equivalent of
+// CLI_ARGS, so Args are dropped.
+func (makefileSource) Command(r Run) []string {
+	dir := r.Dir
+	if dir == "" {
+		dir = filepath.Dir(r.File)
+	}
+	args := []string{"-f", r.File, "-C", dir, "--no-print-directory"}
+	if r.DryRun {
+		args = append(args, "-n")
+	}
+	return append(append(args, r.Task), r.Vars...)
+}
+
+func (makefileSource) parse(_ context.Context, i *Inspector) ([]TaskDefinition, error) {
+	return parseMakefile(i.taskfilePath)
+}
+
+// ParameterNames returns the names of task's parameters in order, for
+// Run.Parameters.
+func ParameterNames(task TaskDefinition) []string {
+	names := make([]string, len(task.Parameters))
+	for i, p := range task.Parameters {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// findTask returns the task named name among tasks.
+func findTask(tasks []TaskDefinition, name string) (*TaskDefinition, error) {
+	for i := range tasks {
+		if tasks[i].Name == name {
+			return &tasks[i], nil
+		}
+	}
+	return nil, fmt.Errorf("task %q not found", name)
+}
+
+// usage returns the command line that runs task with its parameters, for
+// sources that have no usage of their own.
+func usage(bin string, task TaskDefinition) string {
+	parts := []string{bin, task.Name}
+	for _, p := range task.Parameters {
+		parts = append(parts, p.Name+"=<value>")
+	}
+	return strings.Join(parts, " ")
+}
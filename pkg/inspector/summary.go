@@ -0,0 +1,116 @@
+package inspector
+
+import (
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// DefaultUsagePattern matches the usage line of a task summary, such as
+// "Usage: task deploy ENV=<env>", in any case and with any indentation.
+var DefaultUsagePattern = regexp.MustCompile(`(?i)^\s*usage\s*[:：]\s*(.*)$`)
+
+// requiredPattern matches the line starting the list of required
+// variables.
+var requiredPattern = regexp.MustCompile(`(?i)^\s*required\s*[:：]`)
+
+// ParseUsagePattern compiles a usage line pattern for WithUsagePattern. It
+// must have exactly one capture group, the usage.
+func ParseUsagePattern(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if re.NumSubexp() != 1 {
+		return nil, errors.New("the pattern must have exactly one capture group, the usage")
+	}
+	return re, nil
+}
+
+// normalizeSummary makes summaries written on Windows, or with a byte
+// order mark, read like any other.
+func normalizeSummary(summary string) string {
+	summary = strings.TrimPrefix(summary, "\ufeff")
+	summary = strings.ReplaceAll(summary, "\r\n", "\n")
+	return strings.ReplaceAll(summary, "\r", "\n")
+}
+
+// parseSummary extracts the description, usage and parameters of a task
+// from its summary. usage matches the usage line. A usage continues on the
+// next line when it ends in a backslash, and on lines indented deeper than
+// it, up to a blank line.
+func parseSummary(taskName string, summary string, usage *regexp.Regexp) *TaskDefinition {
+	lines := strings.Split(normalizeSummary(summary), "\n")
+	details := &TaskDefinition{Name: taskName}
+	parsingState := ""
+	// usageIndent is the indentation of the usage line, and continued is
+	// set when the last usage line ended in a backslash.
+	usageIndent := 0
+	continued := false
+
+	for _, line := range lines {
+		slog.Debug("Processing line", "line", line)
+
+		if strings.HasPrefix(line, "task: ") {
+			continue
+		}
+
+		if parsingState == "usage" && strings.TrimSpace(line) != "" && (continued || indentation(line) > usageIndent) {
+			continued = appendUsage(details, line)
+			continue
+		}
+		continued = false
+		if m := usage.FindStringSubmatch(line); m != nil {
+			parsingState = "usage"
+			usageIndent = indentation(line)
+			details.Usage = ""
+			continued = appendUsage(details, m[1])
+			continue
+		}
+		switch {
+		case requiredPattern.MatchString(line):
+			parsingState = "required"
+			// Further parsing for required params can be done here
+		default:
+			if parsingState == "" {
+				details.Description += line + "\n"
+			} else if parsingState == "usage" {
+				parsingState = "after usage"
+			}
+		}
+	}
+
+	details.Description = strings.TrimSpace(details.Description)
+	slog.Debug("Parsed task details", "taskName", taskName, "description", details.Description, "usage", details.Usage)
+	// Basic parameter parsing from Usage line
+	seen := map[string]bool{}
+	for _, part := range strings.Fields(details.Usage) {
+		if name, _, ok := strings.Cut(part, "="); ok && name != "" && !seen[name] {
+			seen[name] = true
+			details.Parameters = append(details.Parameters, TaskParameter{Name: name})
+		}
+	}
+
+	return details
+}
+
+// appendUsage adds a line of a usage to the task's usage, and reports
+// whether it ends in a backslash, continuing on the next line.
+func appendUsage(details *TaskDefinition, line string) bool {
+	line = strings.TrimSpace(line)
+	continued := strings.HasSuffix(line, "\\")
+	line = strings.TrimSpace(strings.TrimSuffix(line, "\\"))
+	if line != "" {
+		if details.Usage != "" {
+			details.Usage += " "
+		}
+		details.Usage += line
+	}
+	return continued
+}
+
+// indentation returns the width of line's leading whitespace.
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
@@ -0,0 +1,125 @@
+package inspector
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestParseSummary(t *testing.T) {
+	spanish := regexp.MustCompile(`(?i)^\s*uso\s*:\s*(.*)$`)
+	tests := []struct {
+		name    string
+		summary string
+		usage   *regexp.Regexp
+		want    TaskDefinition
+	}{
+		{
+			name:    "plain",
+			summary: "task: deploy\n\nDeploy the service.\n\nUsage: task deploy ENV=<env> VERSION=<v>\n",
+			want: TaskDefinition{Description: "Deploy the service.", Usage: "task deploy ENV=<env> VERSION=<v>",
+				Parameters: []TaskParameter{{Name: "ENV"}, {Name: "VERSION"}}},
+		},
+		{
+			name:    "windows line endings",
+			summary: "task: deploy\r\n\r\nDeploy the service.\r\nRolls back on failure.\r\n\r\nUsage: task deploy ENV=<env>\r\n",
+			want: TaskDefinition{Description: "Deploy the service.\nRolls back on failure.", Usage: "task deploy ENV=<env>",
+				Parameters: []TaskParameter{{Name: "ENV"}}},
+		},
+		{
+			name:    "byte order mark",
+			summary: "\ufefftask: deploy\nDeploy the service.\nUsage: task deploy ENV=<env>\n",
+			want: TaskDefinition{Description: "Deploy the service.", Usage: "task deploy ENV=<env>",
+				Parameters: []TaskParameter{{Name: "ENV"}}},
+		},
+		{
+			name:    "tabs",
+			summary: "task: deploy\n\tDeploy the service.\n\tUsage:\ttask deploy\tENV=<env>\tVERSION=<v>\n",
+			want: TaskDefinition{Description: "Deploy the service.", Usage: "task deploy\tENV=<env>\tVERSION=<v>",
+				Parameters: []TaskParameter{{Name: "ENV"}, {Name: "VERSION"}}},
+		},
+		{
+			name:    "case and spacing",
+			summary: "task: deploy\nDeploy the service.\n  USAGE : task deploy ENV=<env>\n",
+			want: TaskDefinition{Description: "Deploy the service.", Usage: "task deploy ENV=<env>",
+				Parameters: []TaskParameter{{Name: "ENV"}}},
+		},
+		{
+			name:    "full-width colon",
+			summary: "task: deploy\nDeploy the service.\nusage：task deploy ENV=<env>\n",
+			want: TaskDefinition{Description: "Deploy the service.", Usage: "task deploy ENV=<env>",
+				Parameters: []TaskParameter{{Name: "ENV"}}},
+		},
+		{
+			name:    "required list",
+			summary: "task: weather\nGet the weather.\nUsage: task weather ZIPCODE=<zip>\nrequired:\n  ZIPCODE: The zipcode.\n",
+			want: TaskDefinition{Description: "Get the weather.", Usage: "task weather ZIPCODE=<zip>",
+				Parameters: []TaskParameter{{Name: "ZIPCODE"}}},
+		},
+		{
+			name:    "backslash continuation",
+			summary: "task: deploy\nDeploy the service.\nUsage: task deploy ENV=<env> \\\n  VERSION=<v> \\\nREGION=<region>\nRolls back on failure.\n",
+			want: TaskDefinition{Description: "Deploy the service.", Usage: "task deploy ENV=<env> VERSION=<v> REGION=<region>",
+				Parameters: []TaskParameter{{Name: "ENV"}, {Name: "VERSION"}, {Name: "REGION"}}},
+		},
+		{
+			name:    "wrapped",
+			summary: "task: deploy\nDeploy the service.\nUsage: task deploy ENV=<env>\n       VERSION=<v>\n       ENV=<env>\n\n  Indented notes after a blank line.\n",
+			want: TaskDefinition{Description: "Deploy the service.", Usage: "task deploy ENV=<env> VERSION=<v> ENV=<env>",
+				Parameters: []TaskParameter{{Name: "ENV"}, {Name: "VERSION"}}},
+		},
+		{
+			name:    "indented lines after the usage ends",
+			summary: "task: deploy\nUsage: task deploy ENV=<env>\nNotes:\n  FORCE=1 skips checks.\n",
+			want: TaskDefinition{Usage: "task deploy ENV=<env>",
+				Parameters: []TaskParameter{{Name: "ENV"}}},
+		},
+		{
+			name:    "Windows line endings and continuation",
+			summary: "task: deploy\r\nUsage: task deploy ENV=<env> \\\r\n  VERSION=<v>\r\n",
+			want: TaskDefinition{Usage: "task deploy ENV=<env> VERSION=<v>",
+				Parameters: []TaskParameter{{Name: "ENV"}, {Name: "VERSION"}}},
+		},
+		{
+			name:    "usage in another language",
+			summary: "task: desplegar\nDespliega el servicio.\nUso: task desplegar ENTORNO=<entorno>\n",
+			usage:   spanish,
+			want: TaskDefinition{Description: "Despliega el servicio.", Usage: "task desplegar ENTORNO=<entorno>",
+				Parameters: []TaskParameter{{Name: "ENTORNO"}}},
+		},
+		{
+			name:    "other language without its pattern",
+			summary: "task: desplegar\nDespliega el servicio.\nUso: task desplegar ENTORNO=<entorno>\n",
+			want:    TaskDefinition{Description: "Despliega el servicio.\nUso: task desplegar ENTORNO=<entorno>"},
+		},
+		{
+			name:    "usage in prose",
+			summary: "task: build\nSee the usage: notes in the README.\n",
+			want:    TaskDefinition{Description: "See the usage: notes in the README."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage := tt.usage
+			if usage == nil {
+				usage = DefaultUsagePattern
+			}
+			got := parseSummary("task", tt.summary, usage)
+			tt.want.Name = "task"
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("parseSummary() =\n%#v\nwant\n%#v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUsagePattern(t *testing.T) {
+	if _, err := ParseUsagePattern(`(?i)^uso:\s*(.*)$`); err != nil {
+		t.Errorf("ParseUsagePattern() error = %v", err)
+	}
+	for _, pattern := range []string{`^uso: .*$`, `^(uso): (.*)$`, `(`} {
+		if _, err := ParseUsagePattern(pattern); err == nil {
+			t.Errorf("ParseUsagePattern(%q) error = nil", pattern)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package inspector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// taskMetadata holds the per-task fields tmcp reads straight from the Taskfile
+// YAML, since `task --list --json` does not report them.
+type taskMetadata struct {
+	Dir       string      `yaml:"dir"`
+	Deps      depList     `yaml:"deps"`
+	Generates globList    `yaml:"generates"`
+	MCP       mcpMetadata `yaml:"x-mcp"`
+	Vars      yaml.Node   `yaml:"vars"`
+	// UsesCLIArgs is set when the task mentions CLI_ARGS anywhere.
+	UsesCLIArgs bool `yaml:"-"`
+	// Defaults are the default values of variables the task can be called
+	// with, see varDefaults.
+	Defaults map[string]string `yaml:"-"`
+	// Line and Column locate the task's name in the Taskfile.
+	Line   int `yaml:"-"`
+	Column int `yaml:"-"`
+}
+
+// mcpMetadata is the tmcp-specific `x-mcp` block of a task.
+type mcpMetadata struct {
+	// ReadOnly marks a task as safe to run without side effects.
+	ReadOnly bool `yaml:"read_only"`
+	// Title is a human-readable name for the task's tool.
+	Title string `yaml:"title"`
+	// Destructive and Idempotent describe the task's side effects.
+	Destructive *bool `yaml:"destructive"`
+	Idempotent  *bool `yaml:"idempotent"`
+	// Docs links to further documentation for the task.
+	Docs string `yaml:"docs"`
+	// Retry retries the task when it fails.
+	Retry *RetryPolicy `yaml:"retry"`
+	// CacheTTL caches successful results for identical arguments.
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+	// Cost and Duration estimate what a run of the task costs and how long
+	// it takes.
+	Cost     float64       `yaml:"cost"`
+	Duration time.Duration `yaml:"duration"`
+	// Async runs the task as a background job.
+	Async bool `yaml:"async"`
+	// Outputs are the files the task produces, in addition to generates.
+	Outputs globList `yaml:"outputs"`
+	// Stdin names the parameter whose value is piped to the task's stdin.
+	Stdin string `yaml:"stdin"`
+	// OutputSchema is the JSON schema of the task's output.
+	OutputSchema map[string]any `yaml:"output_schema"`
+	// JSON returns JSON output as structured content.
+	JSON *bool `yaml:"json"`
+	// Params types the task's parameters, keyed by name.
+	Params map[string]paramMetadata `yaml:"params"`
+}
+
+// paramMetadata is the `x-mcp.params` entry of a parameter.
+type paramMetadata struct {
+	Type string `yaml:"type"`
+	Join string `yaml:"join"`
+}
+
+// globList is a list of file globs. Entries other than plain strings, such
+// as generates' `exclude:` entries, are skipped.
+type globList []string
+
+func (l *globList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.SequenceNode {
+		return fmt.Errorf("line %d: expected a list of globs", node.Line)
+	}
+	for _, item := range node.Content {
+		if item.Kind == yaml.ScalarNode {
+			*l = append(*l, item.Value)
+		}
+	}
+	return nil
+}
+
+// depList is the tasks of a task's deps, written as names or as
+// {task: name, vars: ...}. Deps that run a command instead, or whose task
+// is a template, are skipped.
+type depList []string
+
+func (l *depList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.SequenceNode {
+		return fmt.Errorf("line %d: expected a list of tasks", node.Line)
+	}
+	for _, item := range node.Content {
+		name := item.Value
+		if item.Kind == yaml.MappingNode {
+			var dep struct {
+				Task string `yaml:"task"`
+			}
+			if item.Decode(&dep) != nil {
+				continue
+			}
+			name = dep.Task
+		} else if item.Kind != yaml.ScalarNode {
+			continue
+		}
+		// A leading colon names a task of the root Taskfile.
+		name = strings.TrimPrefix(name, ":")
+		if name != "" && !strings.Contains(name, "{{") {
+			*l = append(*l, name)
+		}
+	}
+	return nil
+}
+
+type rawTaskfile struct {
+	Vars  yaml.Node `yaml:"vars"`
+	Tasks yaml.Node `yaml:"tasks"`
+}
+
+// loadTaskMetadata parses the Taskfile at path and returns the metadata of
+// every task keyed by task name. Tasks written in the short string or list
+// forms carry no metadata beyond whether they use CLI_ARGS.
+func loadTaskMetadata(path string) (map[string]taskMetadata, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw rawTaskfile
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]taskMetadata, len(raw.Tasks.Content)/2)
+	if raw.Tasks.Kind != yaml.MappingNode {
+		return metadata, nil
+	}
+	for n := 0; n+1 < len(raw.Tasks.Content); n += 2 {
+		key, node := raw.Tasks.Content[n], raw.Tasks.Content[n+1]
+		located := taskMetadata{Line: key.Line, Column: key.Column}
+		if node.Kind != yaml.MappingNode {
+			located.UsesCLIArgs = mentions(node, "CLI_ARGS")
+			metadata[key.Value] = located
+			continue
+		}
+		var meta taskMetadata
+		if err := node.Decode(&meta); err != nil {
+			slog.Warn("Ignoring unreadable task metadata", "task", key.Value, "error", err)
+			metadata[key.Value] = located
+			continue
+		}
+		meta.UsesCLIArgs = mentions(node, "CLI_ARGS")
+		meta.Defaults = varDefaults(&raw.Vars, &meta.Vars)
+		meta.Line, meta.Column = located.Line, located.Column
+		metadata[key.Value] = meta
+	}
+	return metadata, nil
+}
+
+// varDefaults returns the defaults of the variables a task can be called
+// with, given the Taskfile's vars and the task's. Static Taskfile vars are
+// defaults, since variables given on the command line override them, and so
+// are vars of either kind written as {{.NAME | default "value"}} or
+// {{default "value" .NAME}}. Static task vars override the command line, so
+// they aren't parameters and have no default. Dynamic (sh:) vars are
+// skipped.
+func varDefaults(taskfileVars *yaml.Node, taskVars *yaml.Node) map[string]string {
+	defaults := map[string]string{}
+	for _, scope := range []struct {
+		vars   *yaml.Node
+		static bool
+	}{{taskfileVars, true}, {taskVars, false}} {
+		if scope.vars.Kind != yaml.MappingNode {
+			continue
+		}
+		for n := 0; n+1 < len(scope.vars.Content); n += 2 {
+			name, value := scope.vars.Content[n].Value, scope.vars.Content[n+1]
+			if value.Kind != yaml.ScalarNode || value.Tag == "!!null" {
+				delete(defaults, name)
+				continue
+			}
+			if def, ok := templateDefault(name, value.Value); ok {
+				defaults[name] = def
+			} else if scope.static && !strings.Contains(value.Value, "{{") {
+				defaults[name] = value.Value
+			} else {
+				delete(defaults, name)
+			}
+		}
+	}
+	return defaults
+}
+
+// applyParamTypes types the parameters listed in x-mcp.params.
+func applyParamTypes(task string, params []TaskParameter, meta map[string]paramMetadata) {
+	for n := range params {
+		m, ok := meta[params[n].Name]
+		if !ok {
+			continue
+		}
+		if err := ValidateParameterType(m.Type, m.Join); err != nil {
+			slog.Warn("Ignoring x-mcp.params entry", "task", task, "param", params[n].Name, "error", err)
+			continue
+		}
+		params[n].Type = m.Type
+		params[n].Join = m.Join
+	}
+}
+
+// applyDefaults gives the parameters without a default the one from
+// defaults, if any, which makes them optional.
+func applyDefaults(params []TaskParameter, defaults map[string]string) {
+	for n := range params {
+		if def, ok := defaults[params[n].Name]; ok && params[n].Default == nil {
+			params[n].Default = def
+			params[n].IsRequired = false
+		}
+	}
+}
+
+// templateLiteral matches a string, number or boolean constant of a Go
+// template.
+const templateLiteral = `("(?:[^"\\]|\\.)*"|` + "`[^`]*`" + `|-?[0-9][0-9.]*|true|false)`
+
+// templateDefault returns value's default for the variable name, if value
+// uses the default function on it.
+func templateDefault(name string, value string) (string, bool) {
+	ref := `\.` + regexp.QuoteMeta(name)
+	for _, pattern := range []string{
+		`\{\{-?\s*` + ref + `\s*\|\s*default\s+` + templateLiteral + `\s*-?\}\}`,
+		`\{\{-?\s*default\s+` + templateLiteral + `\s+` + ref + `\s*-?\}\}`,
+	} {
+		m := regexp.MustCompile(pattern).FindStringSubmatch(value)
+		if m == nil {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(m[1]); err == nil {
+			return unquoted, true
+		}
+		return m[1], true
+	}
+	return "", false
+}
+
+// docsURL returns link if it is an absolute http(s) URL and "" otherwise,
+// so tool descriptions never carry anything but a followable link.
+func docsURL(task string, link string) string {
+	if link == "" {
+		return ""
+	}
+	if err := checkDocs(link); err != nil {
+		slog.Warn("Ignoring x-mcp.docs, not an http(s) URL", "task", task, "docs", link)
+		return ""
+	}
+	return link
+}
+
+// checkDocs checks that link is an absolute http(s) URL.
+func checkDocs(link string) error {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("not an http(s) URL")
+	}
+	return nil
+}
+
+// retryPolicy returns policy if it is valid and nil otherwise.
+func retryPolicy(task string, policy *RetryPolicy) *RetryPolicy {
+	if policy == nil {
+		return nil
+	}
+	if err := policy.Validate(); err != nil {
+		slog.Warn("Ignoring x-mcp.retry", "task", task, "error", err)
+		return nil
+	}
+	return policy
+}
+
+// cacheTTL returns ttl unless it is negative.
+func cacheTTL(task string, ttl time.Duration) time.Duration {
+	if ttl < 0 {
+		slog.Warn("Ignoring negative x-mcp.cache_ttl", "task", task, "cache_ttl", ttl)
+		return 0
+	}
+	return ttl
+}
+
+// estimates returns the cost and duration estimates of a task, leaving out
+// negative ones.
+func estimates(task string, cost float64, duration time.Duration) (float64, time.Duration) {
+	if cost < 0 {
+		slog.Warn("Ignoring negative x-mcp.cost", "task", task, "cost", cost)
+		cost = 0
+	}
+	if duration < 0 {
+		slog.Warn("Ignoring negative x-mcp.duration", "task", task, "duration", duration)
+		duration = 0
+	}
+	return cost, duration
+}
+
+// mentions reports whether any scalar in node contains s.
+func mentions(node *yaml.Node, s string) bool {
+	if node.Kind == yaml.ScalarNode {
+		return strings.Contains(node.Value, s)
+	}
+	for _, child := range node.Content {
+		if mentions(child, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// outputSchema returns schema if it can be encoded as JSON and nil
+// otherwise.
+func outputSchema(task string, schema map[string]any) map[string]any {
+	if schema == nil {
+		return nil
+	}
+	if _, err := json.Marshal(schema); err != nil {
+		slog.Warn("Ignoring x-mcp.output_schema, not a JSON schema", "task", task, "error", err)
+		return nil
+	}
+	return schema
+}
+
+// outputGlobs merges the generates and x-mcp.outputs globs of a task.
+// Templated globs are skipped, since only the task binary can expand them.
+func outputGlobs(task string, meta taskMetadata) []string {
+	var globs []string
+	seen := make(map[string]bool)
+	for _, glob := range append(append([]string{}, meta.Generates...), meta.MCP.Outputs...) {
+		if strings.Contains(glob, "{{") {
+			slog.Debug("Skipping templated output glob", "task", task, "glob", glob)
+			continue
+		}
+		if glob == "" || seen[glob] {
+			continue
+		}
+		seen[glob] = true
+		globs = append(globs, glob)
+	}
+	return globs
+}
+
+// resolveTaskDir makes a task's dir absolute relative to the Taskfile's
+// directory. Templated dirs are left for the task binary to resolve, so they
+// resolve to "".
+func resolveTaskDir(taskfilePath string, dir string) string {
+	if strings.Contains(dir, "{{") {
+		return ""
+	}
+	if dir == "" || filepath.IsAbs(dir) {
+		return dir
+	}
+	base, err := filepath.Abs(filepath.Dir(taskfilePath))
+	if err != nil {
+		return dir
+	}
+	return filepath.Join(base, dir)
+}
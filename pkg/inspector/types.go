@@ -0,0 +1,225 @@
+package inspector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the version of the JSON and YAML encodings of MCPConfig
+// and the types it holds, written as its schema_version. It is bumped when a field is renamed or removed, or its
+// meaning changes; added fields don't bump it.
+const SchemaVersion = 1
+
+// TaskParameter is an argument of a task, a variable the task reads or a
+// positional argument of a recipe or tool.
+type TaskParameter struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// IsRequired is set for parameters the task can't run without.
+	IsRequired bool `json:"is_required,omitempty" yaml:"is_required,omitempty"`
+
+	// Type is the JSON Schema type of the parameter: string, number,
+	// integer, boolean or array, a list of strings. Empty means string.
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// Join is how the values of an array parameter are passed: space or
+	// comma joins them into one value, repeat passes the variable once per
+	// value. Empty means space.
+	Join string `json:"join,omitempty" yaml:"join,omitempty"`
+	// Default makes the parameter optional, standing in when it isn't
+	// given. Parameters without one are required.
+	Default any `json:"default,omitempty" yaml:"default,omitempty"`
+}
+
+// TaskDefinition is a task as inspected, everything tmcp needs to serve it
+// as a tool.
+type TaskDefinition struct {
+	Name        string          `json:"name" yaml:"name"`
+	Description string          `json:"description,omitempty" yaml:"description,omitempty"`
+	Usage       string          `json:"usage,omitempty" yaml:"usage,omitempty"`
+	Parameters  []TaskParameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	// File, Line and Column locate the task's definition: the absolute path
+	// of the Taskfile, and the 1-based line and column of the task's name in
+	// it. They are unset for tasks of included Taskfiles and of
+	// other sources.
+	File   string `json:"file,omitempty" yaml:"file,omitempty"`
+	Line   int    `json:"line,omitempty" yaml:"line,omitempty"`
+	Column int    `json:"column,omitempty" yaml:"column,omitempty"`
+	// Dir is the task's working directory from the Taskfile, if it sets one.
+	Dir string `json:"dir,omitempty" yaml:"dir,omitempty"`
+	// Deps are the tasks task runs before this one, from its deps in the
+	// Taskfile. See MCPConfig.Graph.
+	Deps []string `json:"deps,omitempty" yaml:"deps,omitempty"`
+	// ReadOnly is set by `x-mcp: {read_only: true}` on the task.
+	ReadOnly bool `json:"read_only,omitempty" yaml:"read_only,omitempty"`
+	// Title is set by `x-mcp: {title: ...}` on the task, a human-readable
+	// name clients can show instead of the tool name.
+	Title string `json:"title,omitempty" yaml:"title,omitempty"`
+	// Destructive and Idempotent are set by `x-mcp: {destructive: false}`
+	// and `x-mcp: {idempotent: true}` on the task. nil when not set.
+	Destructive *bool `json:"destructive,omitempty" yaml:"destructive,omitempty"`
+	Idempotent  *bool `json:"idempotent,omitempty" yaml:"idempotent,omitempty"`
+	// Docs is a documentation URL from `x-mcp: {docs: ...}` on the task.
+	Docs string `json:"docs,omitempty" yaml:"docs,omitempty"`
+	// Retry is set by `x-mcp: {retry: {attempts: 3, backoff: 1s}}` on the task.
+	Retry *RetryPolicy `json:"retry,omitempty" yaml:"retry,omitempty"`
+	// CacheTTL is set by `x-mcp: {cache_ttl: 30s}` on the task. It is
+	// encoded in nanoseconds in JSON and as a duration string in YAML.
+	CacheTTL time.Duration `json:"cache_ttl,omitempty" yaml:"cache_ttl,omitempty"`
+	// Cost and Duration are set by `x-mcp: {cost: 0.5, duration: 2m}` on
+	// the task, estimates of what a run costs, in any unit, and how long it
+	// takes. Like CacheTTL, Duration is encoded in nanoseconds in JSON.
+	Cost     float64       `json:"cost,omitempty" yaml:"cost,omitempty"`
+	Duration time.Duration `json:"duration,omitempty" yaml:"duration,omitempty"`
+	// Async is set by `x-mcp: {async: true}` on the task. Async tasks run
+	// as background jobs.
+	Async bool `json:"async,omitempty" yaml:"async,omitempty"`
+	// Outputs are globs of the files the task produces, from `generates:`
+	// and `x-mcp: {outputs: [...]}`, relative to the task's directory.
+	Outputs []string `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+	// Stdin is set by `x-mcp: {stdin: TEXT}` on the task. The value of the
+	// named argument is piped to the task's stdin instead of being passed
+	// as a variable.
+	Stdin string `json:"stdin,omitempty" yaml:"stdin,omitempty"`
+	// OutputSchema is set by `x-mcp: {output_schema: {...}}` on the task,
+	// the JSON schema of what the task prints.
+	OutputSchema map[string]any `json:"output_schema,omitempty" yaml:"output_schema,omitempty"`
+	// JSON is set by `x-mcp: {json: true}` on the task to return output
+	// that is JSON as structured content, or false to never do so. nil when
+	// not set.
+	JSON *bool `json:"json,omitempty" yaml:"json,omitempty"`
+	// CLIArgs is set when the task uses CLI_ARGS, the arguments given to
+	// task after --.
+	CLIArgs bool `json:"cli_args,omitempty" yaml:"cli_args,omitempty"`
+	// Summary is the raw `task --summary` output. Only InspectTask sets it.
+	Summary string `json:"summary,omitempty" yaml:"summary,omitempty"`
+}
+
+// noDescription is what task --summary prints for a task without a desc or
+// summary.
+const noDescription = "(task does not have description or summary)"
+
+// Described reports whether the task has a description, which task stands
+// in for with a placeholder when it has no desc or summary.
+func (t TaskDefinition) Described() bool {
+	description := strings.TrimSpace(t.Description)
+	return description != "" && description != noDescription
+}
+
+// Location returns where the task is defined as file:line:column, or ""
+// when unknown.
+func (t TaskDefinition) Location() string {
+	if t.File == "" || t.Line == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d:%d", t.File, t.Line, t.Column)
+}
+
+// RetryPolicy retries failed runs of a task.
+type RetryPolicy struct {
+	// Attempts is the total number of runs, including the first.
+	Attempts int `json:"attempts" yaml:"attempts"`
+	// Backoff is the wait before the first retry. It doubles for every
+	// further retry. Like CacheTTL, it is encoded in nanoseconds in JSON.
+	Backoff time.Duration `json:"backoff" yaml:"backoff"`
+}
+
+// ValidateParameterType checks that typ is a parameter type, and join a way
+// of joining values that suits it.
+func ValidateParameterType(typ string, join string) error {
+	switch typ {
+	case "", "string", "number", "integer", "boolean", "array":
+	default:
+		return fmt.Errorf("unknown type %q", typ)
+	}
+	switch {
+	case join == "":
+	case typ != "array":
+		return errors.New("join is only for array parameters")
+	case join != "space" && join != "comma" && join != "repeat":
+		return fmt.Errorf("unknown join %q, want space, comma or repeat", join)
+	}
+	return nil
+}
+
+// MaxRetryAttempts caps RetryPolicy.Attempts so a failing task can't keep an
+// agent waiting indefinitely.
+const MaxRetryAttempts = 10
+
+// Validate checks that p can be applied.
+func (p RetryPolicy) Validate() error {
+	if p.Attempts < 1 || p.Attempts > MaxRetryAttempts {
+		return fmt.Errorf("attempts must be between 1 and %d", MaxRetryAttempts)
+	}
+	if p.Backoff < 0 {
+		return errors.New("backoff must not be negative")
+	}
+	return nil
+}
+
+// MCPConfig is the result of an inspection, the tasks of a file in the
+// order they are served. Its encodings carry SchemaVersion as
+// schema_version, and decoding rejects other versions. JSONSchema describes
+// the JSON encoding.
+type MCPConfig struct {
+	Tasks []TaskDefinition `json:"tasks" yaml:"tasks"`
+}
+
+// versionedConfig is MCPConfig with its schema_version.
+type versionedConfig struct {
+	SchemaVersion int              `json:"schema_version" yaml:"schema_version"`
+	Tasks         []TaskDefinition `json:"tasks" yaml:"tasks"`
+}
+
+// checkSchemaVersion fails unless version is SchemaVersion.
+func checkSchemaVersion(version int) error {
+	switch {
+	case version == 0:
+		return errors.New("inspection has no schema version; it was saved by an older tmcp, inspect again to update it")
+	case version != SchemaVersion:
+		return fmt.Errorf("inspection has schema version %d, this tmcp reads version %d", version, SchemaVersion)
+	}
+	return nil
+}
+
+// MarshalJSON encodes c with the current SchemaVersion.
+func (c MCPConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(versionedConfig{SchemaVersion, c.Tasks})
+}
+
+// UnmarshalJSON decodes c, failing if it was encoded with another
+// SchemaVersion.
+func (c *MCPConfig) UnmarshalJSON(data []byte) error {
+	var v versionedConfig
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if err := checkSchemaVersion(v.SchemaVersion); err != nil {
+		return err
+	}
+	c.Tasks = v.Tasks
+	return nil
+}
+
+// MarshalYAML encodes c with the current SchemaVersion.
+func (c MCPConfig) MarshalYAML() (any, error) {
+	return versionedConfig{SchemaVersion, c.Tasks}, nil
+}
+
+// UnmarshalYAML decodes c, failing if it was encoded with another
+// SchemaVersion.
+func (c *MCPConfig) UnmarshalYAML(node *yaml.Node) error {
+	var v versionedConfig
+	if err := node.Decode(&v); err != nil {
+		return err
+	}
+	if err := checkSchemaVersion(v.SchemaVersion); err != nil {
+		return err
+	}
+	c.Tasks = v.Tasks
+	return nil
+}
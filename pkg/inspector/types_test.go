@@ -0,0 +1,123 @@
+package inspector
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMCPConfigJSON(t *testing.T) {
+	config := MCPConfig{Tasks: []TaskDefinition{{
+		Name:       "deploy",
+		Parameters: []TaskParameter{{Name: "ENV", IsRequired: true}},
+		ReadOnly:   true,
+		CacheTTL:   time.Second,
+		Retry:      &RetryPolicy{Attempts: 2},
+	}}}
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"schema_version":1,"tasks":[{"name":"deploy","parameters":[{"name":"ENV","is_required":true}],"read_only":true,"retry":{"attempts":2,"backoff":0},"cache_ttl":1000000000}]}`
+	if string(data) != want {
+		t.Errorf("Marshal() =\n%s\nwant\n%s", data, want)
+	}
+
+	var decoded MCPConfig
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(decoded, config) {
+		t.Errorf("Unmarshal() = %+v, want %+v", decoded, config)
+	}
+
+	data, err = yaml.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "schema_version: 1\n") || !strings.Contains(string(data), "cache_ttl: 1s") {
+		t.Errorf("yaml.Marshal() =\n%s", data)
+	}
+	decoded = MCPConfig{}
+	if err := yaml.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(decoded, config) {
+		t.Errorf("yaml.Unmarshal() = %+v, want %+v", decoded, config)
+	}
+
+	tests := map[string]string{
+		`{"Tasks":[{"Name":"deploy"}]}`:   "older tmcp",
+		`{"schema_version":2,"tasks":[]}`: "schema version 2",
+	}
+	for data, want := range tests {
+		if err := json.Unmarshal([]byte(data), &decoded); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Unmarshal(%s) error = %v, want %q", data, err, want)
+		}
+	}
+}
+
+// TestJSONSchema checks that the schema describes every field, so it can't
+// fall behind the types.
+func TestJSONSchema(t *testing.T) {
+	var schema struct {
+		Properties struct {
+			SchemaVersion struct {
+				Const int `json:"const"`
+			} `json:"schema_version"`
+		} `json:"properties"`
+		Defs map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(JSONSchema(), &schema); err != nil {
+		t.Fatalf("schema.json isn't valid JSON: %v", err)
+	}
+	if schema.Properties.SchemaVersion.Const != SchemaVersion {
+		t.Errorf("schema_version = %d, want %d", schema.Properties.SchemaVersion.Const, SchemaVersion)
+	}
+	for def, typ := range map[string]reflect.Type{
+		"task":      reflect.TypeOf(TaskDefinition{}),
+		"parameter": reflect.TypeOf(TaskParameter{}),
+	} {
+		var fields []string
+		for i := 0; i < typ.NumField(); i++ {
+			fields = append(fields, strings.Split(typ.Field(i).Tag.Get("json"), ",")[0])
+		}
+		properties := schema.Defs[def].Properties
+		for _, field := range fields {
+			if _, ok := properties[field]; !ok {
+				t.Errorf("$defs.%s doesn't describe %s", def, field)
+			}
+		}
+		if len(properties) != len(fields) {
+			t.Errorf("$defs.%s has %d properties, want %d", def, len(properties), len(fields))
+		}
+	}
+}
+
+func TestLocation(t *testing.T) {
+	task := TaskDefinition{Name: "build", File: "/src/Taskfile.yml", Line: 12, Column: 3}
+	if got, want := task.Location(), "/src/Taskfile.yml:12:3"; got != want {
+		t.Errorf("Location() = %q, want %q", got, want)
+	}
+	if got := (TaskDefinition{Name: "build", File: "/src/Taskfile.yml"}).Location(); got != "" {
+		t.Errorf("Location() without a line = %q, want none", got)
+	}
+}
+
+func TestDescribed(t *testing.T) {
+	for description, want := range map[string]bool{
+		"Build it.": true,
+		"":          false,
+		"(task does not have description or summary)\n": false,
+	} {
+		if got := (TaskDefinition{Name: "build", Description: description}).Described(); got != want {
+			t.Errorf("Described() of %q = %v, want %v", description, got, want)
+		}
+	}
+}
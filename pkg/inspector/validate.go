@@ -0,0 +1,210 @@
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severities of Problems.
+const (
+	// SeverityError marks metadata tmcp ignores when serving the task.
+	SeverityError = "error"
+	// SeverityWarning marks what makes a tool harder for agents to use.
+	SeverityWarning = "warning"
+)
+
+// Problem is something Validate found wrong with a task.
+type Problem struct {
+	File string `json:"file"`
+	// Line is the line of File the problem is on; 0 when unknown.
+	Line     int    `json:"line,omitempty"`
+	Task     string `json:"task,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// String formats p like a compiler message, e.g.
+// "Taskfile.yml:12: error: deploy: x-mcp.cost is ignored, it must not
+// be negative".
+func (p Problem) String() string {
+	location := p.File
+	if p.Line > 0 {
+		location += fmt.Sprintf(":%d", p.Line)
+	}
+	if p.Task == "" {
+		return fmt.Sprintf("%s: %s: %s", location, p.Severity, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s: %s", location, p.Severity, p.Task, p.Message)
+}
+
+// Validate checks the tasks of config, as inspected from the file at path,
+// for what makes their tools hard for agents to use. For Taskfiles it also
+// checks the x-mcp metadata for what tmcp ignores. Problems are returned in
+// the order of the file.
+func Validate(path string, config *MCPConfig) ([]Problem, error) {
+	var nodes map[string]taskNode
+	if SourceOf(path) == Taskfile {
+		var err error
+		if nodes, err = taskNodes(path); err != nil {
+			return nil, err
+		}
+	}
+	var problems []Problem
+	for _, task := range config.Tasks {
+		node := nodes[task.Name]
+		report := func(line int, severity string, format string, args ...any) {
+			problems = append(problems, Problem{File: path, Line: line, Task: task.Name, Severity: severity, Message: fmt.Sprintf(format, args...)})
+		}
+		if !task.Described() {
+			report(node.line(), SeverityWarning, "no desc or summary, so agents only know the tool by its name")
+		}
+		if node.value != nil {
+			checkMetadata(task, node.value, report)
+		}
+	}
+	sort.SliceStable(problems, func(a, b int) bool {
+		return problems[a].Line < problems[b].Line
+	})
+	return problems, nil
+}
+
+// taskNode is the YAML of a task in a Taskfile.
+type taskNode struct {
+	key   *yaml.Node
+	value *yaml.Node
+}
+
+// line returns the line of the task's name; 0 when unknown.
+func (n taskNode) line() int {
+	if n.key == nil {
+		return 0
+	}
+	return n.key.Line
+}
+
+// taskNodes parses the Taskfile at path and returns the YAML of its tasks
+// keyed by name.
+func taskNodes(path string) (map[string]taskNode, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	nodes := map[string]taskNode{}
+	if len(doc.Content) == 0 {
+		return nodes, nil
+	}
+	_, tasks := mappingEntry(doc.Content[0], "tasks")
+	if tasks == nil || tasks.Kind != yaml.MappingNode {
+		return nodes, nil
+	}
+	for n := 0; n+1 < len(tasks.Content); n += 2 {
+		nodes[tasks.Content[n].Value] = taskNode{key: tasks.Content[n], value: tasks.Content[n+1]}
+	}
+	return nodes, nil
+}
+
+// mappingEntry returns the key and value nodes of key in the mapping node,
+// or nils.
+func mappingEntry(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for n := 0; n+1 < len(node.Content); n += 2 {
+		if node.Content[n].Value == key {
+			return node.Content[n], node.Content[n+1]
+		}
+	}
+	return nil, nil
+}
+
+// mcpKeys are the keys of the x-mcp block.
+var mcpKeys = yamlKeys(reflect.TypeOf(mcpMetadata{}))
+
+func yamlKeys(t reflect.Type) []string {
+	var keys []string
+	for i := range t.NumField() {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ","); name != "" && name != "-" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// checkMetadata reports what in the x-mcp block of the task node tmcp
+// ignores, the same way loadTaskMetadata and applyMetadata do.
+func checkMetadata(task TaskDefinition, node *yaml.Node, report func(line int, severity string, format string, args ...any)) {
+	mcpKey, mcp := mappingEntry(node, "x-mcp")
+	if mcp == nil {
+		return
+	}
+	var meta taskMetadata
+	if err := node.Decode(&meta); err != nil {
+		report(mcpKey.Line, SeverityError, "unreadable task, so its x-mcp is ignored: %v", err)
+		return
+	}
+	line := func(key string) int {
+		if k, _ := mappingEntry(mcp, key); k != nil {
+			return k.Line
+		}
+		return mcpKey.Line
+	}
+	if mcp.Kind == yaml.MappingNode {
+		for n := 0; n < len(mcp.Content); n += 2 {
+			if key := mcp.Content[n]; !slices.Contains(mcpKeys, key.Value) {
+				report(key.Line, SeverityWarning, "unknown x-mcp key %q", key.Value)
+			}
+		}
+	}
+	m := meta.MCP
+	if m.Docs != "" {
+		if err := checkDocs(m.Docs); err != nil {
+			report(line("docs"), SeverityError, "x-mcp.docs %q is ignored, %v", m.Docs, err)
+		}
+	}
+	if m.Retry != nil {
+		if err := m.Retry.Validate(); err != nil {
+			report(line("retry"), SeverityError, "x-mcp.retry is ignored, %v", err)
+		}
+	}
+	for _, field := range []struct {
+		key      string
+		negative bool
+	}{{"cache_ttl", m.CacheTTL < 0}, {"cost", m.Cost < 0}, {"duration", m.Duration < 0}} {
+		if field.negative {
+			report(line(field.key), SeverityError, "x-mcp.%s is ignored, it must not be negative", field.key)
+		}
+	}
+	if m.OutputSchema != nil {
+		if _, err := json.Marshal(m.OutputSchema); err != nil {
+			report(line("output_schema"), SeverityError, "x-mcp.output_schema is ignored, not a JSON schema: %v", err)
+		}
+	}
+	names := make([]string, 0, len(m.Params))
+	for name := range m.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	_, params := mappingEntry(mcp, "params")
+	for _, name := range names {
+		paramLine := line("params")
+		if k, _ := mappingEntry(params, name); k != nil {
+			paramLine = k.Line
+		}
+		if err := ValidateParameterType(m.Params[name].Type, m.Params[name].Join); err != nil {
+			report(paramLine, SeverityError, "x-mcp.params.%s is ignored, %v", name, err)
+		} else if !slices.ContainsFunc(task.Parameters, func(p TaskParameter) bool { return p.Name == name }) {
+			report(paramLine, SeverityWarning, "x-mcp.params.%s is not a parameter of the task", name)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package inspector
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Taskfile.yml")
+	content := `version: '3'
+tasks:
+  build:
+    desc: Build it.
+    x-mcp:
+      read_only: true
+  deploy:
+    x-mcp:
+      retry: {attempts: 50}
+      cost: -1
+      docs: ftp://example.com
+      dry_run: true
+      params:
+        ENV: {type: list}
+        TAGS: {type: array}
+  lint: echo lint
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := &MCPConfig{Tasks: []TaskDefinition{
+		{Name: "build", Description: "Build it."},
+		{Name: "deploy", Parameters: []TaskParameter{{Name: "ENV"}}},
+		{Name: "lint", Description: "(task does not have description or summary)\n"},
+	}}
+
+	problems, err := Validate(path, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Problem{
+		{File: path, Line: 7, Task: "deploy", Severity: SeverityWarning, Message: "no desc or summary, so agents only know the tool by its name"},
+		{File: path, Line: 9, Task: "deploy", Severity: SeverityError, Message: "x-mcp.retry is ignored, attempts must be between 1 and 10"},
+		{File: path, Line: 10, Task: "deploy", Severity: SeverityError, Message: "x-mcp.cost is ignored, it must not be negative"},
+		{File: path, Line: 11, Task: "deploy", Severity: SeverityError, Message: `x-mcp.docs "ftp://example.com" is ignored, not an http(s) URL`},
+		{File: path, Line: 12, Task: "deploy", Severity: SeverityWarning, Message: `unknown x-mcp key "dry_run"`},
+		{File: path, Line: 14, Task: "deploy", Severity: SeverityError, Message: `x-mcp.params.ENV is ignored, unknown type "list"`},
+		{File: path, Line: 15, Task: "deploy", Severity: SeverityWarning, Message: "x-mcp.params.TAGS is not a parameter of the task"},
+		{File: path, Line: 16, Task: "lint", Severity: SeverityWarning, Message: "no desc or summary, so agents only know the tool by its name"},
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("Validate() =\n%v\nwant\n%v", problems, want)
+	}
+	if got, want := problems[0].String(), path+":7: warning: deploy: no desc or summary, so agents only know the tool by its name"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestValidateOtherSources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Makefile")
+	config := &MCPConfig{Tasks: []TaskDefinition{{Name: "build"}}}
+	problems, err := Validate(path, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0].Line != 0 || problems[0].Severity != SeverityWarning {
+		t.Errorf("Validate() = %v, want a warning without a line", problems)
+	}
+}